@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gaucho-racing/sentinel/core/config"
+	"github.com/gaucho-racing/sentinel/core/model"
 	"github.com/gaucho-racing/sentinel/core/pkg/logger"
 	"github.com/gaucho-racing/sentinel/core/service"
 	"github.com/gin-contrib/cors"
@@ -43,6 +44,8 @@ func InitializeRoutes(router *gin.Engine) {
 	router.GET("/core/keys", JWKS)
 	router.POST("/core/token", GenerateToken)
 	router.POST("/core/token/validate", ValidateToken)
+	router.POST("/core/token/families", CreateTokenFamily)
+	router.POST("/core/token/rotate", RotateRefreshToken)
 	router.DELETE("/core/token/:id", RevokeToken)
 
 	router.GET("/core/entity/external/:provider", ListExternalAuthsByProvider)
@@ -57,25 +60,67 @@ func InitializeRoutes(router *gin.Engine) {
 	router.POST("/core/entity/:entityID/phone-auth", CreateEntityPhoneAuth)
 	router.POST("/core/entity/:entityID/external-auth", CreateEntityExternalAuth)
 	router.PATCH("/core/entity/:entityID/external-auth/:provider", UpdateEntityExternalAuthMetadata)
+	router.GET("/core/entity/:entityID/mfa", GetEntityMFA)
+	router.GET("/core/entity/:entityID/passkeys", GetEntityPasskeys)
+	router.POST("/core/entity/:entityID/passkeys", CreateEntityPasskey)
+	router.DELETE("/core/entity/:entityID/passkeys/:passkeyID", DeleteEntityPasskey)
+	router.GET("/core/passkeys/:credentialID", GetPasskeyByCredentialID)
+	router.POST("/core/passkeys/:credentialID/use", RecordPasskeyUse)
 	router.POST("/core/users", CreateOrUpdateUser)
+	router.GET("/core/users/status-changes", ListUserStatusChangeFeed)
 
 	router.POST("/core/applications/verify", VerifyClientCredentials)
+	router.POST("/core/applications/verify-assertion", VerifyClientAssertionRequest)
+	router.POST("/core/applications/verify-request-object", VerifyRequestObjectRequest)
 	router.GET("/core/applications/client/:clientID/groups", GetApplicationGroupsByClientID)
+	router.GET("/core/applications/client/:clientID/roles/:entityID", GetEntityRolesByClientID)
+	router.GET("/core/applications/client/:clientID/token-policy", GetTokenPolicyByClientID)
+	router.POST("/core/registrations", RegisterClient)
+	router.GET("/core/registrations/:clientID", GetRegisteredClient)
+	router.PUT("/core/registrations/:clientID", UpdateRegisteredClient)
+	router.DELETE("/core/registrations/:clientID", DeleteRegisteredClient)
 	router.POST("/core/saml/sp/resolve", ResolveSAMLServiceProvider)
 	router.POST("/core/login/email-password", LoginEmailPassword)
+	router.POST("/core/login/email-code/request", RequestEmailLoginCode)
+	router.POST("/core/login/email-code/verify", VerifyEmailLoginCode)
+	router.POST("/core/password-reset/request", RequestPasswordReset)
+	router.POST("/core/password-reset/confirm", ConfirmPasswordReset)
+	router.POST("/core/mfa/challenge", CreateMFAChallenge)
+	router.POST("/core/mfa/challenge/enroll", BeginChallengeEnrollment)
+	router.POST("/core/mfa/challenge/verify", VerifyMFAChallenge)
+	router.POST("/core/mfa/challenge/resolve", ResolveMFAChallenge)
+	router.POST("/core/mfa/challenge/passkey", CompleteMFAChallengeWithPasskey)
 	router.POST("/core/internal/bootstrap-token", BootstrapToken)
 
 	router.GET("/entities/@me", GetMe)
+	router.GET("/entities/@me/mfa", GetMyMFA)
+	router.POST("/entities/@me/mfa/totp", BeginMyTOTPEnrollment)
+	router.POST("/entities/@me/mfa/totp/confirm", ConfirmMyTOTPEnrollment)
+	router.POST("/entities/@me/mfa/recovery-codes", RegenerateMyRecoveryCodes)
+	router.POST("/entities/@me/mfa/disable", DisableMyMFA)
+	router.GET("/entities/@me/passkeys", GetMyPasskeys)
+	router.PATCH("/entities/@me/passkeys/:passkeyID", RenameMyPasskey)
+	router.DELETE("/entities/@me/passkeys/:passkeyID", DeleteMyPasskey)
+	router.DELETE("/entities/@me/external-auths/:provider", UnlinkMyExternalAuth)
 	router.GET("/entities/:id", GetEntity)
+	router.GET("/entities/:id/merges", GetEntityMerges)
+	router.GET("/entities/:id/security-events", GetEntitySecurityEvents)
+	router.POST("/entities/merge/preview", PreviewEntityMerge)
+	router.POST("/entities/merge", MergeEntities)
 
 	router.GET("/users", GetAllUsers)
 	router.GET("/users/check-username", CheckUsername)
+	router.GET("/users/export", ExportUsers)
 	router.GET("/users/:id", GetUserByID)
 	router.POST("/users", CreateOrUpdateUser)
 	router.DELETE("/users/:id", DeleteUser)
+	router.POST("/users/:id/restore", RestoreUser)
 	router.GET("/users/:id/groups", GetUserGroups)
 	router.GET("/users/:id/logins", GetUserLogins)
 	router.GET("/users/:id/recent-applications", GetUserRecentApplications)
+	router.POST("/users/:id/status", ChangeUserStatus)
+	router.GET("/users/:id/status-changes", GetUserStatusChanges)
+	router.DELETE("/users/:id/status-changes/:changeID", CancelUserStatusChange)
 
 	router.GET("/applications", GetAllApplications)
 	router.GET("/applications/:id", GetApplicationByID)
@@ -83,7 +128,12 @@ func InitializeRoutes(router *gin.Engine) {
 	router.POST("/applications", CreateApplication)
 	router.PUT("/applications/:id", UpdateApplication)
 	router.DELETE("/applications/:id", DeleteApplication)
-	router.GET("/applications/:id/secret", GetApplicationSecret)
+	router.POST("/applications/:id/restore", RestoreApplication)
+	router.POST("/applications/:id/approve", ApproveApplication)
+	router.GET("/applications/:id/secrets", GetApplicationSecrets)
+	router.POST("/applications/:id/secrets", CreateApplicationSecret)
+	router.POST("/applications/:id/secrets/rotate", RotateApplicationSecret)
+	router.DELETE("/applications/:id/secrets/:secretID", RevokeApplicationSecret)
 	router.GET("/applications/:id/groups", GetApplicationGroups)
 	router.POST("/applications/:id/groups", AddApplicationGroup)
 	router.DELETE("/applications/:id/groups/:groupID", RemoveApplicationGroup)
@@ -96,24 +146,52 @@ func InitializeRoutes(router *gin.Engine) {
 	router.GET("/applications/:id/redirect-uris", GetApplicationRedirectURIs)
 	router.POST("/applications/:id/redirect-uris", AddApplicationRedirectURI)
 	router.DELETE("/applications/:id/redirect-uris", RemoveApplicationRedirectURI)
+	router.GET("/applications/:id/scopes", GetApplicationScopes)
+	router.POST("/applications/:id/scopes", CreateApplicationScope)
+	router.DELETE("/applications/:id/scopes/:scope", DeleteApplicationScope)
+	router.PUT("/applications/:id/allowed-scopes", SetApplicationAllowedScopes)
+	router.POST("/applications/:id/allowed-scopes/:scope/approve", ApproveAllowedScope)
+	router.DELETE("/applications/:id/allowed-scopes/:scope", RejectAllowedScope)
+	router.GET("/applications/:id/scope-requests", GetApplicationScopeRequests)
+	router.GET("/applications/:id/roles", GetApplicationRoles)
+	router.POST("/applications/:id/roles", CreateApplicationRole)
+	router.GET("/applications/:id/roles/assignments", GetApplicationRoleAssignments)
+	router.PUT("/applications/:id/roles/:roleID", UpdateApplicationRole)
+	router.DELETE("/applications/:id/roles/:roleID", DeleteApplicationRole)
+	router.GET("/scopes", GetScopes)
+	router.GET("/registration-tokens", GetInitialAccessTokens)
+	router.POST("/registration-tokens", CreateInitialAccessToken)
+	router.DELETE("/registration-tokens/:id", RevokeInitialAccessToken)
 	router.GET("/applications/:id/saml", GetApplicationSAML)
 	router.POST("/applications/:id/saml", UpsertApplicationSAML)
 	router.DELETE("/applications/:id/saml", DeleteApplicationSAML)
+	router.GET("/applications/:id/token-policy", GetApplicationTokenPolicy)
+	router.PUT("/applications/:id/token-policy", SetApplicationTokenPolicy)
 
 	router.GET("/groups", GetAllGroups)
 	router.GET("/groups/:id", GetGroupByID)
 	router.POST("/groups", CreateOrUpdateGroup)
 	router.DELETE("/groups/:id", DeleteGroup)
+	router.POST("/groups/:id/restore", RestoreGroup)
 
 	router.GET("/groups/:id/applications", GetGroupApplications)
 
 	router.GET("/groups/:id/members", GetGroupMembers)
 	router.POST("/groups/:id/members", AddGroupMember)
+	router.POST("/groups/:id/members/import", ImportGroupMembers)
+	router.GET("/groups/:id/members/export", ExportGroupMembers)
 	router.DELETE("/groups/:id/members/:entityID", RemoveGroupMember)
 
+	router.GET("/groups/:id/children", GetGroupChildren)
+	router.POST("/groups/:id/children", AddGroupChild)
+	router.DELETE("/groups/:id/children/:childID", RemoveGroupChild)
+	router.GET("/groups/:id/parents", GetGroupParents)
+
 	router.GET("/groups/:id/conditional-bindings", GetGroupConditionalBindings)
 	router.POST("/groups/:id/conditional-bindings", CreateGroupConditionalBinding)
 	router.DELETE("/groups/:id/conditional-bindings/:bindingID", DeleteGroupConditionalBinding)
+	router.GET("/groups/:id/conditional-bindings/explain", ExplainGroupConditionalMembership)
+	router.POST("/groups/:id/conditional-bindings/simulate", SimulateGroupConditionalBinding)
 
 	router.GET("/groups/:id/owners", GetGroupOwners)
 	router.POST("/groups/:id/owners", AddGroupOwner)
@@ -150,6 +228,10 @@ func AuthChecker() gin.HandlerFunc {
 					c.Set("Auth-Audience", claims.Audience[0])
 					c.Set("Auth-Scope", claims.Scope)
 					c.Set("Auth-Claims", claims.CustomClaims)
+					if actorID := model.ActorFromClaims(claims.CustomClaims); actorID != "" {
+						logger.SugarLogger.Infof("↳ Actor: %s", actorID)
+						c.Set("Auth-ActorID", actorID)
+					}
 				}
 			}
 		}
@@ -266,6 +348,18 @@ func GetRequestTokenEntityID(c *gin.Context) string {
 	return id.(string)
 }
 
+// GetRequestTokenActorID returns the entity acting on the subject's behalf
+// when the bearer came from token exchange (its act claim), or "" for a
+// token the subject holds directly. Authz still runs against the subject;
+// this is for handlers that record or restrict who actually made the call.
+func GetRequestTokenActorID(c *gin.Context) string {
+	id, exists := c.Get("Auth-ActorID")
+	if !exists {
+		return ""
+	}
+	return id.(string)
+}
+
 // GetRequestTokenUserID returns the user_id custom claim from the bearer, or
 // "" if the bearer represents a non-user (service account) or no bearer.
 func GetRequestTokenUserID(c *gin.Context) string {
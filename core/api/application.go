@@ -1,26 +1,46 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/pkg/logger"
 	"github.com/gaucho-racing/sentinel/core/service"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// GetAllApplications lists applications. Filters: ?q= (name, description,
+// client ID), ?owner= (owner entity ID), ?created_after=.
 func GetAllApplications(c *gin.Context) {
 	Require(c, Any(
 		RequestTokenHasAudience(c, "sentinel"),
 		RequestTokenHasScope(c, "sentinel:all"),
 		RequestTokenHasScope(c, "applications:read"),
 	))
-	applications, err := service.GetAllApplications()
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter := service.ApplicationFilter{
+		Query:          c.Query("q"),
+		OwnerID:        c.Query("owner"),
+		IncludeDeleted: includeDeleted(c),
+	}
+	if filter.CreatedAfter, err = parseTimeQuery(c, "created_after"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, applications)
+	page, err := service.ListApplications(filter, opts)
+	if err != nil {
+		writeListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
 }
 
 func GetApplicationByID(c *gin.Context) {
@@ -75,16 +95,76 @@ func VerifyClientCredentials(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	app, err := service.GetApplicationByClientID(req.ClientID)
+	if _, err := service.VerifyClientSecret(req.ClientID, req.ClientSecret); err != nil {
+		if errors.Is(err, service.ErrInvalidClientCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid client credentials"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "valid"})
+}
+
+type verifyClientAssertionRequest struct {
+	ClientAssertion     string `json:"client_assertion" binding:"required"`
+	ClientAssertionType string `json:"client_assertion_type" binding:"required"`
+	// Endpoint is the URL the assertion was presented to; it's accepted as
+	// an aud alongside the issuer.
+	Endpoint string `json:"endpoint"`
+}
+
+// VerifyClientAssertionRequest authenticates a private_key_jwt client and
+// returns its client_id. Why an assertion failed is logged, not returned.
+func VerifyClientAssertionRequest(c *gin.Context) {
+	var req verifyClientAssertionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ClientAssertionType != service.ClientAssertionType {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported client_assertion_type"})
+		return
+	}
+	app, err := service.VerifyClientAssertion(req.ClientAssertion, req.Endpoint)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid client credentials"})
+		if errors.Is(err, service.ErrInvalidClientAssertion) {
+			logger.SugarLogger.Infof("Rejected client assertion: %v", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid client credentials"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	if app.ClientSecret != req.ClientSecret {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid client credentials"})
+	c.JSON(http.StatusOK, gin.H{"client_id": app.ClientID})
+}
+
+type verifyRequestObjectRequest struct {
+	ClientID string `json:"client_id" binding:"required"`
+	Request  string `json:"request" binding:"required"`
+}
+
+// VerifyRequestObjectRequest checks an RFC 9101 request object against the
+// client's registered keys and returns the authorization parameters it
+// carries. Internal-only; the oauth service calls it for the request
+// parameter on the authorize and PAR endpoints.
+func VerifyRequestObjectRequest(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	var req verifyRequestObjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "valid"})
+	params, err := service.VerifyRequestObject(req.ClientID, req.Request)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequestObject) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, params)
 }
 
 type createApplicationRequest struct {
@@ -94,8 +174,8 @@ type createApplicationRequest struct {
 	LaunchURL   string `json:"launch_url"`
 }
 
-// createdApplicationResponse exposes the freshly minted client_secret
-// (model.Application JSON-skips it on subsequent reads).
+// createdApplicationResponse exposes the application's first client_secret.
+// Only its hash is stored, so this is the one time it can be read.
 type createdApplicationResponse struct {
 	model.Application
 	Secret string `json:"client_secret"`
@@ -113,20 +193,20 @@ func CreateApplication(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	app, err := service.CreateApplication(model.Application{
+	app, secret, err := service.CreateApplicationWithSecret(model.Application{
 		Name:        req.Name,
 		Description: req.Description,
 		IconURL:     req.IconURL,
 		LaunchURL:   req.LaunchURL,
 		OwnerID:     GetRequestTokenEntityID(c),
-	})
+	}, "Initial secret", GetRequestTokenEntityID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, createdApplicationResponse{
 		Application: app,
-		Secret:      app.ClientSecret,
+		Secret:      secret,
 	})
 }
 
@@ -135,6 +215,16 @@ type updateApplicationRequest struct {
 	Description string `json:"description"`
 	IconURL     string `json:"icon_url"`
 	LaunchURL   string `json:"launch_url"`
+	// Client authentication settings are replaced together, and only when
+	// token_endpoint_auth_method is sent, so older clients that send just
+	// the basics don't reset them.
+	TokenEndpointAuthMethod *string             `json:"token_endpoint_auth_method"`
+	JWKS                    model.JSONWebKeySet `json:"jwks"`
+	JWKSURI                 string              `json:"jwks_uri"`
+	// Left unchanged when omitted, like the client authentication settings.
+	RequirePushedAuthorizationRequests *bool `json:"require_pushed_authorization_requests"`
+	// Left unchanged when omitted. Only admins can change it.
+	TokenExchangeAudiences *[]string `json:"token_exchange_audiences"`
 }
 
 func UpdateApplication(c *gin.Context) {
@@ -158,6 +248,35 @@ func UpdateApplication(c *gin.Context) {
 	existing.Description = req.Description
 	existing.IconURL = req.IconURL
 	existing.LaunchURL = req.LaunchURL
+	if req.TokenEndpointAuthMethod != nil {
+		existing.TokenEndpointAuthMethod = *req.TokenEndpointAuthMethod
+		existing.JWKS = req.JWKS
+		existing.JWKSURI = strings.TrimSpace(req.JWKSURI)
+	}
+	if req.RequirePushedAuthorizationRequests != nil {
+		existing.RequirePushedAuthorizationRequests = *req.RequirePushedAuthorizationRequests
+	}
+	if req.TokenExchangeAudiences != nil {
+		previous := existing.TokenExchangeAudiences
+		if err := service.SetTokenExchangeAudiences(&existing, *req.TokenExchangeAudiences); err != nil {
+			if errors.Is(err, service.ErrInvalidExchangeAudience) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !slices.Equal(previous, existing.TokenExchangeAudiences) {
+			Require(c, Any(
+				RequestTokenHasScope(c, "sentinel:all"),
+				RequestUserIsAdmin(c),
+			))
+		}
+	}
+	if err := service.ValidateClientAuthentication(&existing); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	updated, err := service.UpdateApplication(existing)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -166,11 +285,9 @@ func UpdateApplication(c *gin.Context) {
 	c.JSON(http.StatusOK, updated)
 }
 
-// GetApplicationSecret returns just the client_secret. Separated from the
-// main GET handler so the secret doesn't leak through list/by-id reads.
-func GetApplicationSecret(c *gin.Context) {
+func DeleteApplication(c *gin.Context) {
 	id := c.Param("id")
-	app, err := service.GetApplicationByID(id)
+	existing, err := service.GetApplicationByID(id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "application not found"})
@@ -179,31 +296,35 @@ func GetApplicationSecret(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	Require(c, Any(
-		RequestTokenHasScope(c, "sentinel:all"),
-		RequestTokenHasAudience(c, "sentinel") && RequestTokenHasEntityID(c, app.OwnerID),
-		RequestTokenHasAudience(c, "sentinel") && RequestUserIsAdmin(c),
-	))
-	c.JSON(http.StatusOK, gin.H{"client_secret": app.ClientSecret})
+	Require(c, ApplicationWriteAuthorized(c, existing))
+	if err := service.DeleteApplication(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "application deleted"})
 }
 
-func DeleteApplication(c *gin.Context) {
+// RestoreApplication undoes a soft delete within the retention window,
+// bringing back its redirect URIs, group links, SAML SP, and service
+// accounts. Authorized like DeleteApplication, against the deleted row.
+func RestoreApplication(c *gin.Context) {
 	id := c.Param("id")
-	existing, err := service.GetApplicationByID(id)
+	existing, err := service.GetDeletedApplicationByID(id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "application not found"})
+			c.JSON(http.StatusNotFound, gin.H{"error": "no deleted application with that id"})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	Require(c, ApplicationWriteAuthorized(c, existing))
-	if err := service.DeleteApplication(id); err != nil {
+	app, err := service.RestoreApplication(id)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "application deleted"})
+	c.JSON(http.StatusOK, app)
 }
 
 func GetApplicationGroups(c *gin.Context) {
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetApplicationRoles lists the roles the application defines, with the
+// group rules behind each.
+func GetApplicationRoles(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasAudience(c, "sentinel"),
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestTokenHasScope(c, "applications:read"),
+	))
+	roles, err := service.GetRolesForApplication(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+type applicationRoleRequest struct {
+	Name        string          `json:"name" binding:"required"`
+	Description string          `json:"description"`
+	Rules       model.RoleRules `json:"rules"`
+}
+
+func CreateApplicationRole(c *gin.Context) {
+	app, ok := loadWritableApplication(c)
+	if !ok {
+		return
+	}
+	var req applicationRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	role, err := service.CreateApplicationRole(model.ApplicationRole{
+		ApplicationID: app.ID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Rules:         req.Rules,
+	})
+	if err != nil {
+		writeApplicationRoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
+
+// UpdateApplicationRole replaces the role's name, description, and rules.
+func UpdateApplicationRole(c *gin.Context) {
+	app, ok := loadWritableApplication(c)
+	if !ok {
+		return
+	}
+	var req applicationRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := service.GetApplicationRole(app.ID, c.Param("roleID")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	role, err := service.UpdateApplicationRole(model.ApplicationRole{
+		ID:            c.Param("roleID"),
+		ApplicationID: app.ID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Rules:         req.Rules,
+	})
+	if err != nil {
+		writeApplicationRoleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
+
+func DeleteApplicationRole(c *gin.Context) {
+	app, ok := loadWritableApplication(c)
+	if !ok {
+		return
+	}
+	if err := service.DeleteApplicationRole(app.ID, c.Param("roleID")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "role removed from application"})
+}
+
+// GetApplicationRoleAssignments lists the users and service accounts that
+// would receive at least one of the application's roles, and which. Roles
+// derive from group memberships, so this is limited to those who may
+// manage the application.
+func GetApplicationRoleAssignments(c *gin.Context) {
+	app, ok := loadWritableApplication(c)
+	if !ok {
+		return
+	}
+	assignments, err := service.GetApplicationRoleAssignments(app.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, assignments)
+}
+
+// GetEntityRolesByClientID returns the names of the roles an entity holds
+// on the application with the given client_id. Internal-only route — the
+// oauth and saml services use it for the roles claim and role attribute.
+func GetEntityRolesByClientID(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	app, err := service.GetApplicationByClientID(c.Param("clientID"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	roles, err := service.GetRolesForEntity(app.ID, c.Param("entityID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+func writeApplicationRoleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRoleName),
+		errors.Is(err, service.ErrEmptyRoleRule),
+		errors.Is(err, service.ErrUnknownGroup):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrRoleExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
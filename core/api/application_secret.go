@@ -0,0 +1,138 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxSecretRotationGraceHours bounds how long a rotated-out secret may stay
+// valid. A week covers any sane deploy cycle.
+const maxSecretRotationGraceHours = 24 * 7
+
+// createdSecretResponse is a new secret's metadata plus the plaintext,
+// which is never returned again.
+type createdSecretResponse struct {
+	model.ApplicationSecret
+	Secret string `json:"client_secret"`
+}
+
+// loadWritableApplication fetches the :id application and checks the
+// caller may modify it. Writes the error response and returns false
+// otherwise.
+func loadWritableApplication(c *gin.Context) (model.Application, bool) {
+	app, err := service.GetApplicationByID(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "application not found"})
+			return model.Application{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return model.Application{}, false
+	}
+	Require(c, ApplicationWriteAuthorized(c, app))
+	return app, true
+}
+
+// GetApplicationSecrets lists the application's secrets. Only metadata —
+// label, hint, expiry, last use — never the secret itself.
+func GetApplicationSecrets(c *gin.Context) {
+	app, ok := loadWritableApplication(c)
+	if !ok {
+		return
+	}
+	secrets, err := service.ListApplicationSecrets(app.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, secrets)
+}
+
+type createApplicationSecretRequest struct {
+	Label     string     `json:"label"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateApplicationSecret adds a secret alongside the existing ones. The
+// response is the only time the plaintext is shown.
+func CreateApplicationSecret(c *gin.Context) {
+	app, ok := loadWritableApplication(c)
+	if !ok {
+		return
+	}
+	var req createApplicationSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+	secret, raw, err := service.CreateApplicationSecret(app.ID, req.Label, GetRequestTokenEntityID(c), req.ExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, createdSecretResponse{ApplicationSecret: secret, Secret: raw})
+}
+
+type rotateApplicationSecretRequest struct {
+	Label string `json:"label"`
+	// GracePeriodHours is how long the previous secrets keep working. Nil
+	// means service.DefaultSecretRotationGrace; 0 cuts them off now.
+	GracePeriodHours *int `json:"grace_period_hours"`
+}
+
+// RotateApplicationSecret creates a new secret and puts every other active
+// secret on a countdown, so deployments can switch over before the old one
+// stops working.
+func RotateApplicationSecret(c *gin.Context) {
+	app, ok := loadWritableApplication(c)
+	if !ok {
+		return
+	}
+	var req rotateApplicationSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	grace := service.DefaultSecretRotationGrace
+	if req.GracePeriodHours != nil {
+		if *req.GracePeriodHours < 0 || *req.GracePeriodHours > maxSecretRotationGraceHours {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "grace_period_hours must be between 0 and 168"})
+			return
+		}
+		grace = time.Duration(*req.GracePeriodHours) * time.Hour
+	}
+	secret, raw, err := service.RotateApplicationSecret(app.ID, req.Label, GetRequestTokenEntityID(c), grace)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, createdSecretResponse{ApplicationSecret: secret, Secret: raw})
+}
+
+// RevokeApplicationSecret deletes one secret. Clients still using it start
+// failing immediately.
+func RevokeApplicationSecret(c *gin.Context) {
+	app, ok := loadWritableApplication(c)
+	if !ok {
+		return
+	}
+	if err := service.RevokeApplicationSecret(app.ID, c.Param("secretID")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "secret not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "secret revoked"})
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetInitialAccessTokens lists the tokens that authorize dynamic client
+// registration. Admin-only, like minting them.
+func GetInitialAccessTokens(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestUserIsAdmin(c),
+	))
+	tokens, err := service.ListInitialAccessTokens()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+type createInitialAccessTokenRequest struct {
+	Label            string `json:"label" binding:"required"`
+	MaxRegistrations int    `json:"max_registrations" binding:"min=0"`
+	// ExpiresInHours of zero means the token never expires.
+	ExpiresInHours int `json:"expires_in_hours" binding:"min=0"`
+}
+
+// createdInitialAccessTokenResponse exposes the plaintext token. Only its
+// hash is stored, so this is the one time it can be read.
+type createdInitialAccessTokenResponse struct {
+	ID    string `json:"id"`
+	Token string `json:"token"`
+}
+
+func CreateInitialAccessToken(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestUserIsAdmin(c),
+	))
+	var req createInitialAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var expiresAt *time.Time
+	if req.ExpiresInHours > 0 {
+		t := time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		expiresAt = &t
+	}
+	token, raw, err := service.CreateInitialAccessToken(req.Label, GetRequestTokenEntityID(c), req.MaxRegistrations, expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, createdInitialAccessTokenResponse{ID: token.ID, Token: raw})
+}
+
+func RevokeInitialAccessToken(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestUserIsAdmin(c),
+	))
+	if err := service.RevokeInitialAccessToken(c.Param("id")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no active initial access token with that id"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "initial access token revoked"})
+}
+
+// ApproveApplication lifts the scope restriction on a dynamically
+// registered client. Admin-only: the owner of a self-registered app can't
+// approve it themselves.
+func ApproveApplication(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestUserIsAdmin(c),
+	))
+	app, err := service.ApproveApplication(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, app)
+}
+
+// registeredClientResponse carries the credentials of a new registration.
+// Only their hashes are stored, so this is the one time they can be read.
+type registeredClientResponse struct {
+	Application             model.Application `json:"application"`
+	ClientSecret            string            `json:"client_secret,omitempty"`
+	RegistrationAccessToken string            `json:"registration_access_token"`
+}
+
+// RegisterClient creates an application from RFC 7591 client metadata.
+// Internal-only; the oauth service's registration endpoint passes the
+// caller's initial access token in X-Initial-Access-Token.
+func RegisterClient(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	var metadata service.ClientMetadata
+	if err := c.ShouldBindJSON(&metadata); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_client_metadata: " + err.Error()})
+		return
+	}
+	registered, err := service.RegisterClient(c.GetHeader("X-Initial-Access-Token"), metadata)
+	if err != nil {
+		writeClientRegistrationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, registeredClientResponse{
+		Application:             registered.Application,
+		ClientSecret:            registered.ClientSecret,
+		RegistrationAccessToken: registered.RegistrationAccessToken,
+	})
+}
+
+// GetRegisteredClient, UpdateRegisteredClient, and DeleteRegisteredClient
+// back the RFC 7592 client configuration endpoint. Internal-only; the
+// caller's registration access token arrives in X-Registration-Access-Token.
+func GetRegisteredClient(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	app, err := service.GetRegisteredClient(c.Param("clientID"), c.GetHeader("X-Registration-Access-Token"))
+	if err != nil {
+		writeClientRegistrationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, app)
+}
+
+func UpdateRegisteredClient(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	var metadata service.ClientMetadata
+	if err := c.ShouldBindJSON(&metadata); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_client_metadata: " + err.Error()})
+		return
+	}
+	app, err := service.UpdateRegisteredClient(c.Param("clientID"), c.GetHeader("X-Registration-Access-Token"), metadata)
+	if err != nil {
+		writeClientRegistrationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, app)
+}
+
+func DeleteRegisteredClient(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	if err := service.DeleteRegisteredClient(c.Param("clientID"), c.GetHeader("X-Registration-Access-Token")); err != nil {
+		writeClientRegistrationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "application deleted"})
+}
+
+func writeClientRegistrationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidClientMetadata),
+		errors.Is(err, service.ErrInvalidRedirectURI):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidInitialAccessToken),
+		errors.Is(err, service.ErrInvalidRegistrationAccessToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gaucho-racing/sentinel/core/model"
 	"github.com/gaucho-racing/sentinel/core/service"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func GetGroupConditionalBindings(c *gin.Context) {
@@ -22,7 +23,8 @@ func GetGroupConditionalBindings(c *gin.Context) {
 }
 
 type createConditionalBindingRequest struct {
-	RequiredGroupIDs []string `json:"required_group_ids" binding:"required"`
+	RequiredGroupIDs []string                  `json:"required_group_ids"`
+	Predicates       model.AttributePredicates `json:"predicates"`
 }
 
 func CreateGroupConditionalBinding(c *gin.Context) {
@@ -35,19 +37,21 @@ func CreateGroupConditionalBinding(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if len(req.RequiredGroupIDs) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "required_group_ids must not be empty"})
+	if len(req.RequiredGroupIDs) == 0 && len(req.Predicates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "required_group_ids and predicates must not both be empty"})
 		return
 	}
 
 	binding, err := service.CreateConditionalBinding(model.GroupConditionalBinding{
 		GroupID:          id,
 		RequiredGroupIDs: model.StringSlice(req.RequiredGroupIDs),
+		Predicates:       req.Predicates,
 	})
 	if err != nil {
-		// Cycle / self-ref are validation failures, not server errors — 400
-		// so admins see a clear "won't work" rather than a 500.
-		if errors.Is(err, service.ErrConditionalBindingCycle) || errors.Is(err, service.ErrConditionalBindingSelfRef) {
+		// Cycle / self-ref / bad predicates are validation failures, not
+		// server errors — 400 so admins see a clear "won't work" rather than
+		// a 500.
+		if errors.Is(err, service.ErrConditionalBindingCycle) || errors.Is(err, service.ErrConditionalBindingSelfRef) || errors.Is(err, service.ErrInvalidAttributePredicate) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -75,3 +79,74 @@ func DeleteGroupConditionalBinding(c *gin.Context) {
 	service.TriggerReconcileAllConditional()
 	c.JSON(http.StatusOK, gin.H{"message": "conditional binding deleted"})
 }
+
+// ExplainGroupConditionalMembership reports, binding by binding, why an
+// entity does or doesn't qualify for the group. Open to the group's owners
+// and to the entity itself.
+func ExplainGroupConditionalMembership(c *gin.Context) {
+	id := c.Param("id")
+	entityID := c.Query("entity_id")
+	if entityID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "entity_id query param is required"})
+		return
+	}
+	if !RequestTokenHasEntityID(c, entityID) && !requireGroupOwnerOrAdmin(c, id) {
+		return
+	}
+
+	explanation, err := service.ExplainConditionalMembership(id, entityID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "group or entity not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, explanation)
+}
+
+type simulateConditionalBindingRequest struct {
+	RequiredGroupIDs []string                  `json:"required_group_ids"`
+	Predicates       model.AttributePredicates `json:"predicates"`
+	// ExcludeBindingIDs are existing bindings to treat as deleted, so an
+	// edit (delete + re-create) can be previewed as one change.
+	ExcludeBindingIDs []string `json:"exclude_binding_ids"`
+}
+
+// SimulateGroupConditionalBinding previews who a proposed binding would add
+// or remove, without saving it. Validation errors match the create
+// endpoint's.
+func SimulateGroupConditionalBinding(c *gin.Context) {
+	id := c.Param("id")
+	if !requireGroupOwnerOrAdmin(c, id) {
+		return
+	}
+	var req simulateConditionalBindingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.RequiredGroupIDs) == 0 && len(req.Predicates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "required_group_ids and predicates must not both be empty"})
+		return
+	}
+
+	sim, err := service.SimulateConditionalBinding(model.GroupConditionalBinding{
+		GroupID:          id,
+		RequiredGroupIDs: model.StringSlice(req.RequiredGroupIDs),
+		Predicates:       req.Predicates,
+	}, req.ExcludeBindingIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+		case errors.Is(err, service.ErrConditionalBindingCycle), errors.Is(err, service.ErrConditionalBindingSelfRef), errors.Is(err, service.ErrInvalidAttributePredicate):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, sim)
+}
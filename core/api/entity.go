@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gaucho-racing/sentinel/core/model"
 	"github.com/gaucho-racing/sentinel/core/service"
@@ -48,6 +49,23 @@ func GetEntity(c *gin.Context) {
 	c.JSON(http.StatusOK, entity)
 }
 
+// GetEntitySecurityEvents lists the security events recorded against an
+// entity, such as a replayed refresh token. An entity can see its own.
+func GetEntitySecurityEvents(c *gin.Context) {
+	id := c.Param("id")
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestUserIsAdmin(c),
+		RequestTokenHasAudience(c, "sentinel") && RequestTokenHasEntityID(c, id),
+	))
+	events, err := service.GetSecurityEventsForEntity(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
+
 func GetEntityByID(c *gin.Context) {
 	entityID := c.Param("entityID")
 	// Entity rows carry PII (email-auth, phone-auth, linked external
@@ -91,6 +109,8 @@ func GetEntityGroups(c *gin.Context) {
 // GetEntityMemberships returns the raw GroupMember rows for an entity,
 // optionally filtered by source via the ?source= query param. Used by
 // integration services to read their own membership writes for diffing.
+// ?include_inherited=true appends an INHERITED row for every group the
+// entity is in only through a child group.
 func GetEntityMemberships(c *gin.Context) {
 	entityID := c.Param("entityID")
 	// Raw GroupMember rows (with source labels) are used by integration
@@ -102,7 +122,22 @@ func GetEntityMemberships(c *gin.Context) {
 		RequestUserIsAdmin(c),
 	))
 	source := c.Query("source")
-	memberships, err := service.GetMembershipsForEntity(entityID, source)
+	var memberships []model.GroupMember
+	var err error
+	if c.Query("include_inherited") == "true" {
+		memberships, err = service.GetEffectiveMembershipsForEntity(entityID)
+		if source != "" {
+			filtered := make([]model.GroupMember, 0, len(memberships))
+			for _, m := range memberships {
+				if m.Source == source {
+					filtered = append(filtered, m)
+				}
+			}
+			memberships = filtered
+		}
+	} else {
+		memberships, err = service.GetMembershipsForEntity(entityID, source)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -185,3 +220,36 @@ func GetEntityLogins(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, logins)
 }
+
+// linkableProviders are the providers members can link and unlink
+// themselves. Discord is tied to onboarding and role sync, so it's managed
+// by the bot, not from settings.
+var linkableProviders = map[model.ExternalAuthProvider]bool{
+	model.ExternalAuthProviderGitHub: true,
+	model.ExternalAuthProviderGoogle: true,
+}
+
+// UnlinkMyExternalAuth removes one of the caller's linked GitHub or Google
+// accounts. Linking goes through oauth's /auth/connect, which has to
+// exchange the provider code first.
+func UnlinkMyExternalAuth(c *gin.Context) {
+	entityID := requireSelfMFA(c)
+	provider := model.ExternalAuthProvider(strings.ToUpper(c.Param("provider")))
+	if !linkableProviders[provider] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only GitHub and Google accounts can be unlinked"})
+		return
+	}
+	if err := service.UnlinkExternalAuth(entityID, provider); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no linked account for that provider"})
+			return
+		}
+		if err == service.ErrLastLoginMethod {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "account unlinked"})
+}
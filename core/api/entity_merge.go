@@ -0,0 +1,85 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gaucho-racing/sentinel/core/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type entityMergeRequest struct {
+	SurvivorEntityID  string `json:"survivor_entity_id" binding:"required"`
+	DuplicateEntityID string `json:"duplicate_entity_id" binding:"required"`
+}
+
+func writeEntityMergeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "entity not found"})
+	case errors.Is(err, service.ErrMergeSameEntity), errors.Is(err, service.ErrMergeNotUser):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// PreviewEntityMerge returns what merging the duplicate into the survivor
+// would do, including any conflicts that would block it. Nothing changes.
+func PreviewEntityMerge(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestUserIsAdmin(c),
+	))
+	var req entityMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	plan, err := service.PreviewEntityMerge(req.SurvivorEntityID, req.DuplicateEntityID)
+	if err != nil {
+		writeEntityMergeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// MergeEntities applies a merge and returns its audit record. A plan with
+// conflicts is refused with 409 and the plan, so the caller can see why.
+func MergeEntities(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestUserIsAdmin(c),
+	))
+	var req entityMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	merge, err := service.MergeEntities(req.SurvivorEntityID, req.DuplicateEntityID, GetRequestTokenEntityID(c), GetRequestTokenActorID(c))
+	if err != nil {
+		if errors.Is(err, service.ErrMergeConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "plan": merge.Plan})
+			return
+		}
+		writeEntityMergeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, merge)
+}
+
+// GetEntityMerges lists the merges an entity was part of. The duplicate's
+// ID keeps resolving here after it's gone.
+func GetEntityMerges(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestUserIsAdmin(c),
+	))
+	merges, err := service.GetEntityMerges(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, merges)
+}
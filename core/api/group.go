@@ -26,37 +26,31 @@ func validateGroupName(name string) error {
 	return nil
 }
 
-// validateMembershipExpiration enforces the 1-year cap on time-boxed
-// memberships and join requests. Uses AddDate(1, 0, 0) so leap years are
-// handled correctly, with a small slack for clock drift between client and
-// server.
-func validateMembershipExpiration(hasExpiration bool, expiresAt time.Time) error {
-	if !hasExpiration {
-		return nil
-	}
-	if expiresAt.IsZero() {
-		return errors.New("expires_at is required when has_expiration is true")
-	}
-	now := time.Now()
-	if !expiresAt.After(now) {
-		return errors.New("expires_at must be in the future")
-	}
-	maxAllowed := now.AddDate(1, 0, 0).Add(1 * time.Minute)
-	if expiresAt.After(maxAllowed) {
-		return errors.New("maximum membership duration is 1 year")
-	}
-	return nil
-}
-
+// GetAllGroups lists groups. Filters: ?q= (name and description),
+// ?source= (groups that allow that membership source), ?created_after=.
 func GetAllGroups(c *gin.Context) {
 	Require(c, RequestTokenExists(c))
 
-	groups, err := service.GetAllGroups()
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter := service.GroupFilter{
+		Query:          c.Query("q"),
+		Source:         c.Query("source"),
+		IncludeDeleted: includeDeleted(c),
+	}
+	if filter.CreatedAfter, err = parseTimeQuery(c, "created_after"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := service.ListGroups(filter, opts)
+	if err != nil {
+		writeListError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, groups)
+	c.JSON(http.StatusOK, page)
 }
 
 // containsSource reports whether src is in sources. Sources are stored
@@ -112,6 +106,11 @@ type upsertGroupRequest struct {
 	Name           string   `json:"name"`
 	Description    string   `json:"description"`
 	AllowedSources []string `json:"allowed_sources"`
+	// RequireMFA is a pointer so clients that don't know about the field
+	// leave the existing policy alone on update.
+	RequireMFA *bool `json:"require_mfa"`
+	// AlumniGroupID is a pointer for the same reason; "" clears it.
+	AlumniGroupID *string `json:"alumni_group_id"`
 }
 
 func CreateOrUpdateGroup(c *gin.Context) {
@@ -158,6 +157,21 @@ func CreateOrUpdateGroup(c *gin.Context) {
 		return
 	}
 
+	if req.AlumniGroupID != nil && *req.AlumniGroupID != "" {
+		if *req.AlumniGroupID == existing.ID && existing.ID != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "a group cannot be its own alumni group"})
+			return
+		}
+		if _, err := service.GetGroupByID(*req.AlumniGroupID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "alumni group not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	var group model.Group
 	if existing.ID != "" {
 		if existing.ID == service.AdminsGroupID && req.Name != existing.Name {
@@ -170,6 +184,12 @@ func CreateOrUpdateGroup(c *gin.Context) {
 		group.Name = req.Name
 		group.Description = req.Description
 		group.AllowedSources = model.StringSlice(req.AllowedSources)
+		if req.RequireMFA != nil {
+			group.RequireMFA = *req.RequireMFA
+		}
+		if req.AlumniGroupID != nil {
+			group.AlumniGroupID = *req.AlumniGroupID
+		}
 		group, err = service.UpdateGroup(group)
 		if err == nil {
 			cascadeRemovedSources(existing.ID, existing.AllowedSources, req.AllowedSources)
@@ -188,7 +208,12 @@ func CreateOrUpdateGroup(c *gin.Context) {
 			Name:           req.Name,
 			Description:    req.Description,
 			AllowedSources: model.StringSlice(req.AllowedSources),
+			RequireMFA:     req.RequireMFA != nil && *req.RequireMFA,
 			CreatedBy:      GetRequestTokenEntityID(c),
+			CreatedByActor: GetRequestTokenActorID(c),
+		}
+		if req.AlumniGroupID != nil {
+			group.AlumniGroupID = *req.AlumniGroupID
 		}
 		group, err = service.CreateGroup(group)
 		// Auto-add the creator as an owner so new groups aren't ownerless.
@@ -239,18 +264,49 @@ func DeleteGroup(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "group deleted"})
 }
 
+// RestoreGroup undoes a soft delete within the retention window. The
+// group's owners survive the delete, so they can restore it themselves.
+func RestoreGroup(c *gin.Context) {
+	id := c.Param("id")
+	if !requireGroupOwnerOrAdmin(c, id) {
+		return
+	}
+	group, err := service.RestoreGroup(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no deleted group with that id"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
+
 // Members
 
+// GetGroupMembers lists a group's members. Filters: ?source= and
+// ?created_after= (matched against joined_at).
 func GetGroupMembers(c *gin.Context) {
 	Require(c, RequestTokenExists(c))
 
 	id := c.Param("id")
-	members, err := service.GetMembersForGroup(id)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter := service.GroupMemberFilter{Source: c.Query("source")}
+	if filter.JoinedAfter, err = parseTimeQuery(c, "created_after"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := service.ListGroupMembers(id, filter, opts)
+	if err != nil {
+		writeListError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, members)
+	c.JSON(http.StatusOK, page)
 }
 
 type addGroupMemberRequest struct {
@@ -295,7 +351,7 @@ func AddGroupMember(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	if err := validateMembershipExpiration(req.HasExpiration, req.ExpiresAt); err != nil {
+	if err := service.ValidateMembershipExpiration(req.HasExpiration, req.ExpiresAt); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -327,6 +383,7 @@ func AddGroupMember(c *gin.Context) {
 		EntityID:      req.EntityID,
 		Source:        source,
 		AddedBy:       requestAddedBy(c, req.AddedBy),
+		AddedByActor:  GetRequestTokenActorID(c),
 		HasExpiration: req.HasExpiration,
 		ExpiresAt:     req.ExpiresAt,
 	})
@@ -495,7 +552,7 @@ func CreateGroupJoinRequest(c *gin.Context) {
 		RequestTokenHasEntityID(c, req.EntityID),
 		RequestUserIsAdmin(c),
 	))
-	if err := validateMembershipExpiration(req.HasExpiration, req.ExpiresAt); err != nil {
+	if err := service.ValidateMembershipExpiration(req.HasExpiration, req.ExpiresAt); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -558,13 +615,14 @@ func ApproveGroupJoinRequest(c *gin.Context) {
 	if req.ExpiresAt != nil {
 		expiresAt = *req.ExpiresAt
 	}
-	if err := validateMembershipExpiration(hasExpiration, expiresAt); err != nil {
+	if err := service.ValidateMembershipExpiration(hasExpiration, expiresAt); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	request.Status = string(model.GroupJoinRequestStatusApproved)
 	request.ReviewedBy = req.ReviewedBy
+	request.ReviewedByActor = GetRequestTokenActorID(c)
 	request.ReviewedAt = time.Now()
 	request.HasExpiration = hasExpiration
 	request.ExpiresAt = expiresAt
@@ -578,6 +636,7 @@ func ApproveGroupJoinRequest(c *gin.Context) {
 		EntityID:      request.EntityID,
 		Source:        string(model.GroupMemberSourceDirect),
 		AddedBy:       req.ReviewedBy,
+		AddedByActor:  request.ReviewedByActor,
 		HasExpiration: hasExpiration,
 		ExpiresAt:     expiresAt,
 	})
@@ -610,6 +669,7 @@ func RejectGroupJoinRequest(c *gin.Context) {
 	}
 	request.Status = string(model.GroupJoinRequestStatusRejected)
 	request.ReviewedBy = req.ReviewedBy
+	request.ReviewedByActor = GetRequestTokenActorID(c)
 	request.ReviewedAt = time.Now()
 	request, err = service.UpdateJoinRequest(request)
 	if err != nil {
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gaucho-racing/sentinel/core/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func GetGroupChildren(c *gin.Context) {
+	Require(c, RequestTokenExists(c))
+
+	groups, err := service.GetChildGroups(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, groups)
+}
+
+func GetGroupParents(c *gin.Context) {
+	Require(c, RequestTokenExists(c))
+
+	groups, err := service.GetParentGroups(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, groups)
+}
+
+type addGroupChildRequest struct {
+	ChildGroupID string `json:"child_group_id" binding:"required"`
+}
+
+// AddGroupChild nests another group under this one. It's gated on the
+// parent: linking a child hands the child's owners a way to add members to
+// the parent, so it's the parent's owners who have to agree to it. Nesting
+// under Admins grants admin, so that one is admin-only.
+func AddGroupChild(c *gin.Context) {
+	id := c.Param("id")
+	if id == service.AdminsGroupID {
+		Require(c, Any(
+			RequestTokenHasScope(c, "sentinel:all"),
+			RequestUserIsAdmin(c),
+		))
+	} else if !requireGroupOwnerOrAdmin(c, id) {
+		return
+	}
+	var req addGroupChildRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link, err := service.AddChildGroup(id, req.ChildGroupID, GetRequestTokenEntityID(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+		case errors.Is(err, service.ErrGroupChildSelfRef), errors.Is(err, service.ErrGroupChildCycle):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrGroupChildExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	// The child's members are now effective members here, which may
+	// satisfy conditional bindings that require this group.
+	service.TriggerReconcileAllConditional()
+	c.JSON(http.StatusOK, link)
+}
+
+func RemoveGroupChild(c *gin.Context) {
+	id := c.Param("id")
+	if !requireGroupOwnerOrAdmin(c, id) {
+		return
+	}
+	if err := service.RemoveChildGroup(id, c.Param("childID")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// Members who were only here through the child may no longer satisfy
+	// conditional bindings that require this group.
+	service.TriggerReconcileAllConditional()
+	c.JSON(http.StatusOK, gin.H{"message": "child group removed"})
+}
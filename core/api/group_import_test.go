@@ -0,0 +1,22 @@
+package api
+
+import "testing"
+
+func TestCSVCell(t *testing.T) {
+	tests := map[string]string{
+		"":                  "",
+		"alice":             "alice",
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+1":                "'+1",
+		"-1":                "'-1",
+		"@SUM(A1)":          "'@SUM(A1)",
+		"\tcmd":             "'\tcmd",
+		"\rcmd":             "'\rcmd",
+		"a=b":               "a=b",
+	}
+	for in, want := range tests {
+		if got := csvCell(in); got != want {
+			t.Errorf("csvCell(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
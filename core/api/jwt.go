@@ -1,9 +1,12 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gaucho-racing/sentinel/core/config"
+	"github.com/gaucho-racing/sentinel/core/model"
 	"github.com/gaucho-racing/sentinel/core/service"
 	"github.com/gin-gonic/gin"
 )
@@ -18,6 +21,11 @@ type generateTokenRequest struct {
 	Scope     string                 `json:"scope" binding:"required"`
 	ExpiresIn int                    `json:"expires_in" binding:"required"`
 	Claims    map[string]interface{} `json:"claims"`
+	// Audience lists resource servers to add to aud after client_id.
+	Audience []string `json:"audience"`
+	// FamilyID puts the token in a refresh token family from
+	// /core/token/families.
+	FamilyID string `json:"family_id"`
 }
 
 func GenerateToken(c *gin.Context) {
@@ -33,7 +41,15 @@ func GenerateToken(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	token, tokenID, err := service.GenerateToken(req.EntityID, req.ClientID, req.Scope, req.ExpiresIn, req.Claims)
+	if err := service.CheckEntityActive(req.EntityID); err != nil {
+		if errors.Is(err, service.ErrUserInactive) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	token, tokenID, err := service.GenerateToken(req.EntityID, req.ClientID, req.Scope, req.ExpiresIn, req.Claims, req.FamilyID, req.Audience...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -72,3 +88,75 @@ func RevokeToken(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "token revoked"})
 }
+
+type createTokenFamilyRequest struct {
+	EntityID string `json:"entity_id" binding:"required"`
+	ClientID string `json:"client_id" binding:"required"`
+	// ExpiresIn is the family's absolute lifetime, in seconds.
+	ExpiresIn int `json:"expires_in" binding:"required,min=1"`
+}
+
+// CreateTokenFamily starts a refresh token family for a new grant. The
+// oauth service mints the grant's tokens into it with family_id.
+func CreateTokenFamily(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	var req createTokenFamilyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	family, err := service.CreateTokenFamily(req.EntityID, req.ClientID, time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, family)
+}
+
+type rotateRefreshTokenRequest struct {
+	Token    string `json:"token" binding:"required"`
+	ClientID string `json:"client_id" binding:"required"`
+	// IPAddress is the refreshing client's, recorded if this turns out to
+	// be reuse.
+	IPAddress string `json:"ip_address"`
+}
+
+// rotatedRefreshTokenResponse is the exchanged token's claims and, unless
+// it predates families, the family its replacement goes in.
+type rotatedRefreshTokenResponse struct {
+	Claims *model.TokenClaims `json:"claims"`
+	Family *model.TokenFamily `json:"family"`
+}
+
+// RotateRefreshToken exchanges a refresh token: it's checked, marked used,
+// and can't be exchanged again. Replaying one revokes its whole family and
+// answers 409. Internal-only; the oauth service calls it for the
+// refresh_token grant and first-party session refresh.
+func RotateRefreshToken(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	var req rotateRefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rotated, err := service.RotateRefreshToken(req.Token, req.ClientID, req.IPAddress)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRefreshToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrRefreshTokenReused):
+			// A status of its own, so oauth can tell reuse apart without
+			// matching on the message.
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrUserInactive):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, rotatedRefreshTokenResponse{
+		Claims: rotated.Claims,
+		Family: rotated.Family,
+	})
+}
@@ -1,8 +1,11 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
+	"github.com/gaucho-racing/sentinel/core/model"
 	"github.com/gaucho-racing/sentinel/core/service"
 	"github.com/gin-gonic/gin"
 )
@@ -28,3 +31,124 @@ func LoginEmailPassword(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"entity_id": entity.ID})
 }
+
+type requestEmailLoginCodeRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// RequestEmailLoginCode issues and mails a passwordless login code.
+// Internal: called by the oauth service from /auth/login/email-code/request.
+// Responds 200 whether or not the email is registered.
+func RequestEmailLoginCode(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+
+	var req requestEmailLoginCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := service.RequestEmailLoginCode(req.Email); err != nil {
+		if errors.Is(err, service.ErrLoginCodeRateLimited) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "if that email is registered, a login code has been sent"})
+}
+
+type verifyEmailLoginCodeRequest struct {
+	Email string `json:"email" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// VerifyEmailLoginCode consumes a login code and returns the entity it was
+// issued for. Like LoginEmailPassword, it does not mint tokens.
+func VerifyEmailLoginCode(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+
+	var req verifyEmailLoginCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	entity, err := service.VerifyEmailLoginCode(req.Email, req.Code)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidLoginCode) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entity_id": entity.ID})
+}
+
+type requestPasswordResetRequest struct {
+	Email   string                     `json:"email" binding:"required"`
+	Channel model.PasswordResetChannel `json:"channel"`
+}
+
+// RequestPasswordReset issues a password reset token. Internal: called by the
+// oauth service from /auth/password-reset/request. Email delivery happens
+// here; for DISCORD the response carries the DM for the caller to forward.
+func RequestPasswordReset(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+
+	var req requestPasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	channel := model.PasswordResetChannel(strings.ToUpper(string(req.Channel)))
+	switch channel {
+	case "":
+		channel = model.PasswordResetChannelEmail
+	case model.PasswordResetChannelEmail, model.PasswordResetChannelDiscord:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "channel must be EMAIL or DISCORD"})
+		return
+	}
+	delivery, err := service.RequestPasswordReset(req.Email, channel)
+	if err != nil {
+		if errors.Is(err, service.ErrPasswordResetRateLimited) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
+
+type confirmPasswordResetRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ConfirmPasswordReset consumes a reset token, sets the new password, and
+// revokes every token the entity holds.
+func ConfirmPasswordReset(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+
+	var req confirmPasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := service.ValidatePassword(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	entity, err := service.ConfirmPasswordReset(req.Token, req.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidResetToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entity_id": entity.ID})
+}
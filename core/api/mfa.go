@@ -0,0 +1,222 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gaucho-racing/sentinel/core/service"
+	"github.com/gin-gonic/gin"
+)
+
+// writeMFAError maps MFA sentinel errors to statuses. Code and challenge
+// failures are 401 so oauth can pass them through as "try again"; a
+// lockout is 429.
+func writeMFAError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMFACode),
+		errors.Is(err, service.ErrInvalidMFAChallenge):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMFALocked):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMFAAlreadyEnrolled),
+		errors.Is(err, service.ErrMFARequiredByPolicy):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMFANotEnrolled),
+		errors.Is(err, service.ErrMFAEnrollmentAbsent):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// requireSelfMFA gates the @me MFA endpoints to first-party sessions. A
+// third-party token must never be able to enroll or strip a second factor.
+func requireSelfMFA(c *gin.Context) string {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	entityID := GetRequestTokenEntityID(c)
+	Require(c, entityID != "")
+	return entityID
+}
+
+func GetMyMFA(c *gin.Context) {
+	entityID := requireSelfMFA(c)
+	status, err := service.GetMFAStatus(entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// GetEntityMFA lets oauth check an entity's MFA policy before it issues a
+// code or tokens on their consent.
+func GetEntityMFA(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	status, err := service.GetMFAStatus(c.Param("entityID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+func BeginMyTOTPEnrollment(c *gin.Context) {
+	entityID := requireSelfMFA(c)
+	enrollment, err := service.BeginTOTPEnrollment(entityID)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func ConfirmMyTOTPEnrollment(c *gin.Context) {
+	entityID := requireSelfMFA(c)
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	codes, err := service.ConfirmTOTPEnrollment(entityID, req.Code)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func RegenerateMyRecoveryCodes(c *gin.Context) {
+	entityID := requireSelfMFA(c)
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	codes, err := service.RegenerateRecoveryCodes(entityID, req.Code)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func DisableMyMFA(c *gin.Context) {
+	entityID := requireSelfMFA(c)
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := service.DisableMFA(entityID, req.Code); err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "mfa disabled"})
+}
+
+type createMFAChallengeRequest struct {
+	EntityID string   `json:"entity_id" binding:"required"`
+	AMR      []string `json:"amr"`
+}
+
+// CreateMFAChallenge runs after a first factor succeeds. Internal: oauth
+// calls it from every first-party login before minting a session.
+func CreateMFAChallenge(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	var req createMFAChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	result, err := service.CreateMFAChallenge(req.EntityID, req.AMR)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+type mfaChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+}
+
+func BeginChallengeEnrollment(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	var req mfaChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	enrollment, err := service.BeginChallengeEnrollment(req.ChallengeToken)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func VerifyMFAChallenge(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	var req mfaChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+	verification, err := service.VerifyMFAChallenge(req.ChallengeToken, req.Code)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, verification)
+}
+
+// ResolveMFAChallenge tells oauth which entity an open challenge belongs to,
+// without consuming it, so a passkey assertion can be built for that entity.
+func ResolveMFAChallenge(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	var req mfaChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	challenge, err := service.ResolveMFAChallenge(req.ChallengeToken)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, challenge)
+}
+
+type completeMFAChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	CredentialID   string `json:"credential_id" binding:"required"`
+}
+
+// CompleteMFAChallengeWithPasskey finishes a challenge once oauth has
+// verified a passkey assertion for it.
+func CompleteMFAChallengeWithPasskey(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	var req completeMFAChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	verification, err := service.CompleteMFAChallengeWithPasskey(req.ChallengeToken, req.CredentialID)
+	if err != nil {
+		writeMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, verification)
+}
@@ -44,9 +44,9 @@ type createEmailAuthRequest struct {
 func CreateEntityEmailAuth(c *gin.Context) {
 	// This endpoint upserts an entity's email + password — the entire
 	// account-takeover primitive in one call. Reserved for internal
-	// callers (discord onboarding mints the initial email auth; future
-	// password-reset flows would go through a separate token-mediated
-	// path before hitting this handler).
+	// callers (discord onboarding mints the initial email auth).
+	// Self-service resets go through the token-mediated
+	// /core/password-reset flow instead.
 	Require(c, RequestTokenHasScope(c, "sentinel:all"))
 	entityID := c.Param("entityID")
 	var req createEmailAuthRequest
@@ -107,12 +107,14 @@ func CreateEntityPhoneAuth(c *gin.Context) {
 type createExternalAuthRequest struct {
 	Provider   model.ExternalAuthProvider `json:"provider" binding:"required"`
 	ExternalID string                     `json:"external_id" binding:"required"`
+	Metadata   model.JSONMap              `json:"metadata"`
 }
 
 func CreateEntityExternalAuth(c *gin.Context) {
 	// Linking an external identity (DISCORD, GITHUB, etc.) to an
 	// entity is account-takeover-adjacent — anyone able to write this
-	// row can claim any entity. Internal callers only.
+	// row can claim any entity. Internal callers only: discord onboarding,
+	// and oauth's connect flow once it has verified the provider code.
 	Require(c, RequestTokenHasScope(c, "sentinel:all"))
 	entityID := c.Param("entityID")
 	var req createExternalAuthRequest
@@ -124,8 +126,13 @@ func CreateEntityExternalAuth(c *gin.Context) {
 		EntityID:   entityID,
 		Provider:   req.Provider,
 		ExternalID: req.ExternalID,
+		Metadata:   req.Metadata,
 	})
 	if err != nil {
+		if err == service.ErrExternalAuthTaken || err == service.ErrExternalAuthExists {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
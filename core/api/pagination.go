@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/service"
+	"github.com/gin-gonic/gin"
+)
+
+// parseListOptions reads the paging parameters every list endpoint shares:
+// ?cursor=, ?limit= (default 50, max 200), and ?sort= (a field name, "-"
+// prefixed for descending).
+func parseListOptions(c *gin.Context) (service.ListOptions, error) {
+	opts := service.ListOptions{
+		Cursor: c.Query("cursor"),
+		Sort:   c.Query("sort"),
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return service.ListOptions{}, fmt.Errorf("limit must be a positive integer")
+		}
+		opts.Limit = limit
+	}
+	return opts, nil
+}
+
+// parseTimeQuery accepts either an RFC 3339 timestamp or a bare date
+// (YYYY-MM-DD, taken as midnight UTC). Empty yields the zero time.
+func parseTimeQuery(c *gin.Context, key string) (time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date", key)
+}
+
+// includeDeleted reads ?include_deleted=true. Listing soft-deleted rows is
+// an admin view, so asking for it without admin or sentinel:all is refused.
+func includeDeleted(c *gin.Context) bool {
+	if c.Query("include_deleted") != "true" {
+		return false
+	}
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestUserIsAdmin(c),
+	))
+	return true
+}
+
+// writeListError maps bad cursors and sort fields to 400.
+func writeListError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidSort) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
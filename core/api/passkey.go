@@ -0,0 +1,172 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func GetEntityPasskeys(c *gin.Context) {
+	entityID := c.Param("entityID")
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestTokenHasEntityID(c, entityID),
+		RequestUserIsAdmin(c),
+	))
+	passkeys, err := service.GetPasskeysForEntity(entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, passkeys)
+}
+
+// CreateEntityPasskey stores a credential produced by a registration
+// ceremony. Internal: only oauth, which verified the attestation, may call it.
+func CreateEntityPasskey(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	entityID := c.Param("entityID")
+	if _, err := service.GetEntityByID(entityID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "entity not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var passkey model.EntityPasskey
+	if err := c.ShouldBindJSON(&passkey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if passkey.CredentialID == "" || len(passkey.PublicKey) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "credential_id and public_key are required"})
+		return
+	}
+	if _, err := service.GetPasskeyByCredentialID(passkey.CredentialID); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "passkey is already registered"})
+		return
+	}
+	created, err := service.CreatePasskeyForEntity(entityID, passkey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, created)
+}
+
+// DeleteEntityPasskey lets an admin remove a lost or compromised passkey on
+// someone else's behalf.
+func DeleteEntityPasskey(c *gin.Context) {
+	entityID := c.Param("entityID")
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestUserIsAdmin(c),
+	))
+	if err := service.DeletePasskey(entityID, c.Param("passkeyID")); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "passkey not found"})
+			return
+		}
+		if err == service.ErrLastLoginMethod {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "passkey deleted"})
+}
+
+func GetPasskeyByCredentialID(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	passkey, err := service.GetPasskeyByCredentialID(c.Param("credentialID"))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "passkey not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, passkey)
+}
+
+type recordPasskeyUseRequest struct {
+	SignCount    uint32 `json:"sign_count"`
+	UserVerified bool   `json:"user_verified"`
+	BackupState  bool   `json:"backup_state"`
+}
+
+// RecordPasskeyUse is called by oauth after every verified assertion.
+func RecordPasskeyUse(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	var req recordPasskeyUseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	passkey, err := service.RecordPasskeyUse(c.Param("credentialID"), req.SignCount, req.UserVerified, req.BackupState)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "passkey not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, passkey)
+}
+
+func GetMyPasskeys(c *gin.Context) {
+	entityID := requireSelfMFA(c)
+	passkeys, err := service.GetPasskeysForEntity(entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, passkeys)
+}
+
+type renamePasskeyRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func RenameMyPasskey(c *gin.Context) {
+	entityID := requireSelfMFA(c)
+	var req renamePasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	passkey, err := service.RenamePasskey(entityID, c.Param("passkeyID"), req.Name)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "passkey not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, passkey)
+}
+
+func DeleteMyPasskey(c *gin.Context) {
+	entityID := requireSelfMFA(c)
+	if err := service.DeletePasskey(entityID, c.Param("passkeyID")); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "passkey not found"})
+			return
+		}
+		if err == service.ErrLastLoginMethod {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "passkey deleted"})
+}
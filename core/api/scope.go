@@ -0,0 +1,199 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetScopes returns the scope registry: Sentinel's builtin scopes plus the
+// ones applications have registered. The oauth service reads it to
+// validate requests, describe scopes on the consent screen, and publish
+// scopes_supported.
+func GetScopes(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasAudience(c, "sentinel"),
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestTokenHasScope(c, "applications:read"),
+	))
+	scopes, err := service.ListScopes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, scopes)
+}
+
+// GetApplicationScopes lists the scopes the application defines for its
+// own API.
+func GetApplicationScopes(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasAudience(c, "sentinel"),
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestTokenHasScope(c, "applications:read"),
+	))
+	scopes, err := service.GetScopesForApplication(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, scopes)
+}
+
+type createApplicationScopeRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description" binding:"required"`
+}
+
+// CreateApplicationScope registers a scope on the application, making it
+// a resource server other clients can be allowed to request tokens for.
+func CreateApplicationScope(c *gin.Context) {
+	app, ok := loadWritableApplication(c)
+	if !ok {
+		return
+	}
+	var req createApplicationScopeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	scope, err := service.CreateApplicationScope(app.ID, req.Name, req.Description)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidScopeName), errors.Is(err, service.ErrReservedScope):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrScopeExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, scope)
+}
+
+func DeleteApplicationScope(c *gin.Context) {
+	app, ok := loadWritableApplication(c)
+	if !ok {
+		return
+	}
+	if err := service.DeleteApplicationScope(app.ID, c.Param("scope")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "scope not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "scope removed from application"})
+}
+
+type setAllowedScopesRequest struct {
+	Scopes []string `json:"scopes"`
+}
+
+// SetApplicationAllowedScopes replaces the list of scopes the application
+// may request as a client. Another application's scope is usable only once
+// that application's owner or an admin approves it, so a caller who can't
+// write the resource server leaves it pending.
+func SetApplicationAllowedScopes(c *gin.Context) {
+	app, ok := loadWritableApplication(c)
+	if !ok {
+		return
+	}
+	var req setAllowedScopesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	scopes, err := service.SetApplicationAllowedScopes(app.ID, req.Scopes, func(resourceServer model.Application) bool {
+		return ApplicationWriteAuthorized(c, resourceServer)
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownScope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, scopes)
+}
+
+// GetApplicationScopeRequests lists the clients waiting for the
+// application to approve one of its scopes.
+func GetApplicationScopeRequests(c *gin.Context) {
+	app, ok := loadWritableApplication(c)
+	if !ok {
+		return
+	}
+	requests, err := service.GetPendingScopeRequests(app.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// ApproveAllowedScope approves a pending scope on a client's allow-list.
+// Only the scope's resource server can, through its owner or an admin.
+func ApproveAllowedScope(c *gin.Context) {
+	if !requireScopeResourceServerWrite(c) {
+		return
+	}
+	if err := service.ApproveAllowedScope(c.Param("id"), c.Param("scope")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no pending request for that scope"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "scope approved"})
+}
+
+// RejectAllowedScope takes a pending scope off a client's allow-list, with
+// the same authorization as ApproveAllowedScope.
+func RejectAllowedScope(c *gin.Context) {
+	if !requireScopeResourceServerWrite(c) {
+		return
+	}
+	if err := service.RejectAllowedScope(c.Param("id"), c.Param("scope")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no pending request for that scope"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "scope request rejected"})
+}
+
+// requireScopeResourceServerWrite looks up the application that defines
+// the :scope param and requires the caller be able to write it.
+func requireScopeResourceServerWrite(c *gin.Context) bool {
+	scope, err := service.GetApplicationScope(c.Param("scope"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "scope not found"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	resourceServer, err := service.GetApplicationByID(scope.ApplicationID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "scope not found"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	Require(c, ApplicationWriteAuthorized(c, resourceServer))
+	return true
+}
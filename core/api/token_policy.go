@@ -0,0 +1,128 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetApplicationTokenPolicy returns an application's token policy; the
+// default one if it hasn't set its own.
+func GetApplicationTokenPolicy(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasAudience(c, "sentinel"),
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestTokenHasScope(c, "applications:read"),
+	))
+	app, err := service.GetApplicationByID(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	policy, err := service.GetTokenPolicy(app.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+type setTokenPolicyRequest struct {
+	AccessTokenTTL          int      `json:"access_token_ttl"`
+	IDTokenTTL              int      `json:"id_token_ttl"`
+	RefreshTokenIdleTTL     int      `json:"refresh_token_idle_ttl"`
+	RefreshTokenAbsoluteTTL int      `json:"refresh_token_absolute_ttl"`
+	AllowOfflineAccess      bool     `json:"allow_offline_access"`
+	OptionalClaims          []string `json:"optional_claims"`
+	SharedEmail             string   `json:"shared_email"`
+	SharedUsername          string   `json:"shared_username"`
+	SharedFirstName         string   `json:"shared_first_name"`
+	SharedLastName          string   `json:"shared_last_name"`
+}
+
+// SetApplicationTokenPolicy replaces an application's token policy. Owners
+// can change lifetimes and claims, but a shared identity lets the app's
+// tokens name any email address, so only admins can set or change one.
+func SetApplicationTokenPolicy(c *gin.Context) {
+	id := c.Param("id")
+	app, err := service.GetApplicationByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	Require(c, ApplicationWriteAuthorized(c, app))
+	var req setTokenPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	existing, err := service.GetTokenPolicy(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	policy := model.ApplicationTokenPolicy{
+		ApplicationID:           id,
+		AccessTokenTTL:          req.AccessTokenTTL,
+		IDTokenTTL:              req.IDTokenTTL,
+		RefreshTokenIdleTTL:     req.RefreshTokenIdleTTL,
+		RefreshTokenAbsoluteTTL: req.RefreshTokenAbsoluteTTL,
+		AllowOfflineAccess:      req.AllowOfflineAccess,
+		OptionalClaims:          req.OptionalClaims,
+		SharedEmail:             req.SharedEmail,
+		SharedUsername:          req.SharedUsername,
+		SharedFirstName:         req.SharedFirstName,
+		SharedLastName:          req.SharedLastName,
+	}
+	if policy.SharedEmail != existing.SharedEmail || policy.SharedUsername != existing.SharedUsername ||
+		policy.SharedFirstName != existing.SharedFirstName || policy.SharedLastName != existing.SharedLastName {
+		Require(c, Any(
+			RequestTokenHasScope(c, "sentinel:all"),
+			RequestUserIsAdmin(c),
+		))
+	}
+	updated, err := service.SetTokenPolicy(policy)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTokenPolicy) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// GetTokenPolicyByClientID returns the token policy of the application
+// with a client_id, for the oauth service as it issues tokens. Internal
+// (/core) route.
+func GetTokenPolicyByClientID(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	app, err := service.GetApplicationByClientID(c.Param("clientID"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "application not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	policy, err := service.GetTokenPolicy(app.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
@@ -10,17 +10,42 @@ import (
 	"gorm.io/gorm"
 )
 
+// GetAllUsers lists the user directory. Filters: ?q= (search over name,
+// username, and email), ?graduation_year=, ?major=, ?group= (group ID),
+// ?source= (membership source), and ?created_after=.
 func GetAllUsers(c *gin.Context) {
 	Require(c, Any(
 		RequestTokenHasAudience(c, "sentinel"),
 		RequestTokenHasScope(c, "sentinel:all"),
 	))
-	users, err := service.GetAllUsers()
+	opts, err := parseListOptions(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, users)
+	filter := service.UserFilter{
+		Query:          c.Query("q"),
+		Major:          c.Query("major"),
+		GroupID:        c.Query("group"),
+		Source:         c.Query("source"),
+		IncludeDeleted: includeDeleted(c),
+	}
+	if raw := c.Query("graduation_year"); raw != "" {
+		if filter.GraduationYear, err = strconv.Atoi(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "graduation_year must be an integer"})
+			return
+		}
+	}
+	if filter.CreatedAfter, err = parseTimeQuery(c, "created_after"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := service.ListUsers(filter, opts)
+	if err != nil {
+		writeListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
 }
 
 func CheckUsername(c *gin.Context) {
@@ -94,6 +119,16 @@ func CreateOrUpdateUser(c *gin.Context) {
 		))
 	}
 
+	// Lifecycle state only moves through ChangeUserStatus, so a profile
+	// write can't reinstate a suspended user or skip offboarding. New users
+	// always start ACTIVE (CreateUser's default).
+	if existing.ID != "" {
+		user.Status = existing.Status
+		user.StatusEffectiveAt = existing.StatusEffectiveAt
+	} else {
+		user.Status = ""
+	}
+
 	if existing.ID != "" {
 		user, err = service.UpdateUser(user)
 	} else {
@@ -103,6 +138,9 @@ func CreateOrUpdateUser(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// Conditional bindings can match on profile fields, so an edit may
+	// grant or revoke CONDITIONAL memberships.
+	service.ReconcileConditionalForEntity(user.EntityID)
 	c.JSON(http.StatusOK, user)
 }
 
@@ -122,6 +160,25 @@ func DeleteUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "user deleted"})
 }
 
+// RestoreUser undoes a soft delete within the retention window. Admin-only,
+// like DeleteUser.
+func RestoreUser(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestUserIsAdmin(c),
+	))
+	user, err := service.RestoreUser(c.Param("id"))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no deleted user with that id"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
 func GetUserGroups(c *gin.Context) {
 	id := c.Param("id")
 	// Same authorization-signal concern as GetEntityGroups — leaking
@@ -210,3 +267,62 @@ func GetUserLogins(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, logins)
 }
+
+// ExportUsers streams the user directory as CSV, with the same filters as
+// GET /users. Carries phone numbers and SAE registration numbers, so it's
+// admin-only.
+func ExportUsers(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestUserIsAdmin(c),
+	))
+	filter := service.UserFilter{
+		Query:   c.Query("q"),
+		Major:   c.Query("major"),
+		GroupID: c.Query("group"),
+		Source:  c.Query("source"),
+	}
+	if raw := c.Query("graduation_year"); raw != "" {
+		var err error
+		if filter.GraduationYear, err = strconv.Atoi(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "graduation_year must be an integer"})
+			return
+		}
+	}
+	opts := service.ListOptions{Limit: service.MaxPageLimit}
+	page, err := service.ListUsers(filter, opts)
+	if err != nil {
+		writeListError(c, err)
+		return
+	}
+
+	w := startCSVDownload(c, "users.csv")
+	_ = w.Write([]string{
+		"id", "entity_id", "username", "first_name", "last_name", "email", "phone_number",
+		"graduate_level", "graduation_year", "major", "shirt_size", "jacket_size",
+		"sae_registration_number", "status", "created_at",
+	})
+	for {
+		for _, u := range page.Data {
+			year := ""
+			if u.GraduationYear != 0 {
+				year = strconv.Itoa(u.GraduationYear)
+			}
+			_ = w.Write([]string{
+				u.ID, u.EntityID, u.Username, u.FirstName, u.LastName, u.Email, u.PhoneNumber,
+				u.GraduateLevel, year, u.Major, u.ShirtSize, u.JacketSize,
+				u.SAERegistrationNumber, string(u.Status), csvTime(u.CreatedAt),
+			})
+		}
+		w.Flush()
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+		if page, err = service.ListUsers(filter, opts); err != nil {
+			_ = c.Error(err)
+			break
+		}
+	}
+	w.Flush()
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type changeUserStatusRequest struct {
+	Status      model.UserStatus `json:"status" binding:"required"`
+	Reason      string           `json:"reason"`
+	EffectiveAt time.Time        `json:"effective_at"`
+}
+
+// ChangeUserStatus moves a user through the lifecycle, now or on a future
+// effective_at. Admin or internal only — suspension and offboarding cut off
+// every token the user holds.
+func ChangeUserStatus(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestUserIsAdmin(c),
+	))
+	var req changeUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	change, err := service.ChangeUserStatus(c.Param("id"), req.Status, req.Reason, req.EffectiveAt, GetRequestTokenEntityID(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		case errors.Is(err, service.ErrInvalidUserStatus):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidStatusTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, change)
+}
+
+func GetUserStatusChanges(c *gin.Context) {
+	id := c.Param("id")
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestTokenHasUserID(c, id),
+		RequestUserIsAdmin(c),
+	))
+	changes, err := service.GetStatusChangesForUser(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
+func CancelUserStatusChange(c *gin.Context) {
+	Require(c, Any(
+		RequestTokenHasScope(c, "sentinel:all"),
+		RequestUserIsAdmin(c),
+	))
+	if err := service.CancelScheduledStatusChange(c.Param("id"), c.Param("changeID")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no scheduled status change with that id"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "status change cancelled"})
+}
+
+// ListUserStatusChangeFeed serves applied lifecycle changes to integrations,
+// which poll it with ?applied_after= (and optionally ?status=) to react to
+// suspensions, alumni moves, and offboarding.
+func ListUserStatusChangeFeed(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+	opts, err := parseListOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter := service.StatusChangeFilter{ToStatus: model.UserStatus(c.Query("status"))}
+	if filter.ToStatus != "" && !service.IsValidUserStatus(filter.ToStatus) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidUserStatus.Error()})
+		return
+	}
+	if filter.AppliedAfter, err = parseTimeQuery(c, "applied_after"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := service.ListAppliedStatusChanges(filter, opts)
+	if err != nil {
+		writeListError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
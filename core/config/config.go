@@ -48,6 +48,54 @@ var DatabaseName = os.Getenv("DATABASE_NAME")
 // wire. Default 1h; set to 0 (or any non-positive duration) to disable.
 var ConditionalSyncInterval = parseDurationOr("CONDITIONAL_SYNC_INTERVAL", time.Hour)
 
+// UserStatusSweepInterval is how often scheduled user lifecycle changes
+// (e.g. "becomes alumni on June 15") are checked and applied. Changes with
+// an effective date in the past apply immediately regardless. Default 5m;
+// set to 0 (or any non-positive duration) to disable the sweep.
+var UserStatusSweepInterval = parseDurationOr("USER_STATUS_SWEEP_INTERVAL", 5*time.Minute)
+
+// SoftDeleteRetention is how long deleted users, groups, and applications
+// can still be restored before the purge job removes them (and their
+// dependent rows) for good. Default 30 days.
+var SoftDeleteRetention = parseDurationOr("SOFT_DELETE_RETENTION", 30*24*time.Hour)
+
+// SoftDeletePurgeInterval is how often the purge job looks for soft-deleted
+// rows past retention. Default 1h; set to 0 (or any non-positive duration)
+// to disable purging.
+var SoftDeletePurgeInterval = parseDurationOr("SOFT_DELETE_PURGE_INTERVAL", time.Hour)
+
+// EmailLoginCodeTTL is how long a passwordless login code stays valid after
+// it's issued. Short on purpose — the code is the whole credential.
+var EmailLoginCodeTTL = parseDurationOr("EMAIL_LOGIN_CODE_TTL", 10*time.Minute)
+
+// PasswordResetTTL is how long a password reset link stays valid.
+var PasswordResetTTL = parseDurationOr("PASSWORD_RESET_TTL", 30*time.Minute)
+
+// WebBaseURL is the origin of the web frontend, used to build links in
+// outbound messages (e.g. password reset). Defaults to the issuer, since the
+// gateway serves both from the same origin.
+var WebBaseURL = os.Getenv("WEB_BASE_URL")
+
+// TeamGoogleClientID is the client_id of the shared Google Workspace account
+// application (team@gauchoracing.com), which used to get its shared identity
+// from a hard-coded override in the oauth service. Deprecated: core reads it
+// once, to give that application an equivalent token policy, and it can be
+// unset after that.
+var TeamGoogleClientID = os.Getenv("TEAM_GOOGLE_CLIENT_ID")
+
+// MailSink selects how outbound email is delivered: "smtp" sends through
+// SMTP_HOST, "log" writes messages to the logger, and "file" appends them to
+// MAIL_SINK_FILE. Defaults to "log" outside production so local login codes
+// are readable straight from the container output.
+var MailSink = os.Getenv("MAIL_SINK")
+var MailSinkFile = os.Getenv("MAIL_SINK_FILE")
+
+var SMTPHost = os.Getenv("SMTP_HOST")
+var SMTPPort = os.Getenv("SMTP_PORT")
+var SMTPUsername = os.Getenv("SMTP_USERNAME")
+var SMTPPassword = os.Getenv("SMTP_PASSWORD")
+var SMTPFrom = os.Getenv("SMTP_FROM")
+
 func parseDurationOr(envKey string, fallback time.Duration) time.Duration {
 	raw := os.Getenv(envKey)
 	if raw == "" {
@@ -35,4 +35,28 @@ func Verify() {
 		Issuer = "https://sso.gauchoracing.com"
 		logger.SugarLogger.Infof("ISSUER is not set, defaulting to %s", Issuer)
 	}
+	if WebBaseURL == "" {
+		WebBaseURL = Issuer
+		logger.SugarLogger.Infof("WEB_BASE_URL is not set, defaulting to %s", WebBaseURL)
+	}
+	if MailSink == "" {
+		if IsProduction() {
+			MailSink = "smtp"
+		} else {
+			MailSink = "log"
+		}
+		logger.SugarLogger.Infof("MAIL_SINK is not set, defaulting to %s", MailSink)
+	}
+	if MailSink == "file" && MailSinkFile == "" {
+		MailSinkFile = "mail.log"
+		logger.SugarLogger.Infof("MAIL_SINK_FILE is not set, defaulting to %s", MailSinkFile)
+	}
+	if SMTPPort == "" {
+		SMTPPort = "587"
+		logger.SugarLogger.Infof("SMTP_PORT is not set, defaulting to %s", SMTPPort)
+	}
+	if SMTPFrom == "" {
+		SMTPFrom = "sentinel@gauchoracing.com"
+		logger.SugarLogger.Infof("SMTP_FROM is not set, defaulting to %s", SMTPFrom)
+	}
 }
@@ -1,12 +1,15 @@
 package database
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"time"
 
 	"github.com/gaucho-racing/sentinel/core/config"
 	"github.com/gaucho-racing/sentinel/core/model"
 	"github.com/gaucho-racing/sentinel/core/pkg/logger"
+	"github.com/gaucho-racing/ulid-go"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -29,20 +32,47 @@ func Init() {
 		}
 	} else {
 		logger.SugarLogger.Infoln("Connected to database")
+		// auth_email_login_code used to be keyed on (email, code) with the code
+		// stored in plaintext. Nothing ever wrote to it, so the old shape is
+		// dropped outright rather than migrated; AutoMigrate can't change a
+		// primary key in place.
+		if db.Migrator().HasColumn(&model.EmailLoginCode{}, "code") {
+			if err := db.Migrator().DropTable(&model.EmailLoginCode{}); err != nil {
+				logger.SugarLogger.Errorf("Failed to drop legacy auth_email_login_code table: %v", err)
+			}
+		}
 		db.AutoMigrate(
 			&model.Entity{},
 			&model.EntityEmail{},
 			&model.EntityPhone{},
 			&model.EntityExternalAuth{},
+			&model.EntityPasskey{},
 			&model.PhoneLoginCode{},
 			&model.EmailLoginCode{},
+			&model.EmailRequest{},
+			&model.PasswordResetToken{},
+			&model.EntityMFA{},
+			&model.MFARecoveryCode{},
+			&model.MFAChallenge{},
+			&model.MFAAttempt{},
 			&model.Token{},
+			&model.TokenFamily{},
+			&model.SecurityEvent{},
 			&model.User{},
+			&model.UserStatusChange{},
 			&model.Application{},
 			&model.ApplicationGroup{},
 			&model.ApplicationRedirectURI{},
+			&model.ApplicationSecret{},
+			&model.ApplicationScope{},
+			&model.ApplicationRole{},
+			&model.ApplicationAllowedScope{},
+			&model.ClientAssertionJTI{},
+			&model.InitialAccessToken{},
 			&model.SAMLServiceProvider{},
+			&model.ApplicationTokenPolicy{},
 			&model.EntityLogin{},
+			&model.EntityMerge{},
 			&model.ServiceAccount{},
 			&model.Group{},
 			&model.GroupMember{},
@@ -50,9 +80,54 @@ func Init() {
 			&model.GroupJoinRequestComment{},
 			&model.GroupOwner{},
 			&model.GroupConditionalBinding{},
+			&model.GroupChild{},
 			&model.SigningKey{},
 		)
+		migrateLegacyClientSecrets(db)
 		logger.SugarLogger.Infoln("AutoMigration complete")
 		DB = db
 	}
 }
+
+// migrateLegacyClientSecrets moves the plaintext application.client_secret
+// column into application_secret as hashed rows, then drops the column.
+// Existing clients keep working with the secret they already have.
+func migrateLegacyClientSecrets(db *gorm.DB) {
+	if !db.Migrator().HasColumn("application", "client_secret") {
+		return
+	}
+	type legacyApplication struct {
+		ID           string
+		ClientSecret string
+	}
+	var apps []legacyApplication
+	if err := db.Table("application").Select("id, client_secret").Where("client_secret <> ''").Scan(&apps).Error; err != nil {
+		logger.SugarLogger.Errorf("Failed to read legacy client secrets: %v", err)
+		return
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, app := range apps {
+			sum := sha256.Sum256([]byte(app.ClientSecret))
+			hint := app.ClientSecret
+			if len(hint) > 4 {
+				hint = hint[len(hint)-4:]
+			}
+			secret := model.ApplicationSecret{
+				ID:            ulid.Make().Prefixed("csec"),
+				ApplicationID: app.ID,
+				Label:         "Migrated secret",
+				SecretHash:    hex.EncodeToString(sum[:]),
+				Hint:          hint,
+			}
+			if err := tx.Create(&secret).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Migrator().DropColumn("application", "client_secret")
+	})
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to migrate legacy client secrets: %v", err)
+		return
+	}
+	logger.SugarLogger.Infof("Migrated %d legacy client secrets", len(apps))
+}
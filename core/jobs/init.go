@@ -31,6 +31,7 @@ var InternalServiceAccountNames = []string{
 	"sentinel-oauth",
 	"sentinel-saml",
 	"sentinel-google",
+	"sentinel-github",
 }
 
 // IsInternalServiceAccountName reports whether name is on the
@@ -51,26 +52,112 @@ func InitializeCore() {
 	initializeAdminsGroup()
 	linkAdminsGroupToSentinelApp()
 	initializeInternalServiceAccounts()
+	migrateTeamGoogleIdentityOverride()
+	migrateRefreshTokenLifetimes()
 	logger.SugarLogger.Infoln("Finished initializing sentinel-core")
 }
 
+// migrateRefreshTokenLifetimes moves refresh token lifetimes set on the
+// application table, where they briefly lived, into the applications'
+// token policies, then drops the old columns. A lifetime the policy
+// already sets wins. If any application fails, the columns are kept so the
+// next start can retry.
+func migrateRefreshTokenLifetimes() {
+	migrator := database.DB.Migrator()
+	if !migrator.HasColumn("application", "refresh_token_idle_lifetime") {
+		return
+	}
+	var lifetimes []struct {
+		ID                           string
+		RefreshTokenIdleLifetime     int
+		RefreshTokenAbsoluteLifetime int
+	}
+	err := database.DB.Table("application").
+		Select("id, refresh_token_idle_lifetime, refresh_token_absolute_lifetime").
+		Where("refresh_token_idle_lifetime <> 0 OR refresh_token_absolute_lifetime <> 0").
+		Scan(&lifetimes).Error
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to load application refresh token lifetimes: %v", err)
+		return
+	}
+	failed := false
+	for _, l := range lifetimes {
+		policy, err := service.GetTokenPolicy(l.ID)
+		if err == nil {
+			if policy.RefreshTokenIdleTTL == 0 {
+				policy.RefreshTokenIdleTTL = l.RefreshTokenIdleLifetime
+			}
+			if policy.RefreshTokenAbsoluteTTL == 0 {
+				policy.RefreshTokenAbsoluteTTL = l.RefreshTokenAbsoluteLifetime
+			}
+			_, err = service.SetTokenPolicy(policy)
+		}
+		if err != nil {
+			logger.SugarLogger.Errorf("Failed to move refresh token lifetimes for application %s into its token policy: %v", l.ID, err)
+			failed = true
+		}
+	}
+	if failed {
+		return
+	}
+	for _, column := range []string{"refresh_token_idle_lifetime", "refresh_token_absolute_lifetime"} {
+		if err := migrator.DropColumn("application", column); err != nil {
+			logger.SugarLogger.Errorf("Failed to drop application.%s: %v", column, err)
+		}
+	}
+	logger.SugarLogger.Infof("Moved refresh token lifetimes for %d applications into their token policies", len(lifetimes))
+}
+
+// migrateTeamGoogleIdentityOverride gives the application named by the
+// deprecated TEAM_GOOGLE_CLIENT_ID the shared identity the oauth service
+// used to hard-code for it. It only runs while the app has no stored token
+// policy, so once it has (or an admin has set one) it's left alone.
+func migrateTeamGoogleIdentityOverride() {
+	if config.TeamGoogleClientID == "" {
+		return
+	}
+	app, err := service.GetApplicationByClientID(config.TeamGoogleClientID)
+	if err != nil {
+		logger.SugarLogger.Errorf("TEAM_GOOGLE_CLIENT_ID is set but application %s couldn't be loaded: %v", config.TeamGoogleClientID, err)
+		return
+	}
+	policy, err := service.GetTokenPolicy(app.ID)
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to load token policy for %s: %v", app.ID, err)
+		return
+	}
+	if !policy.CreatedAt.IsZero() {
+		logger.SugarLogger.Infof("Application %s already has a token policy; TEAM_GOOGLE_CLIENT_ID can be unset", app.ID)
+		return
+	}
+	policy.SharedEmail = "team@gauchoracing.com"
+	policy.SharedUsername = "team"
+	policy.SharedFirstName = "Gaucho"
+	policy.SharedLastName = "Racing"
+	if _, err := service.SetTokenPolicy(policy); err != nil {
+		logger.SugarLogger.Errorf("Failed to migrate the shared identity for %s: %v", app.ID, err)
+		return
+	}
+	logger.SugarLogger.Infof("Moved the team@gauchoracing.com identity override for %s into its token policy; TEAM_GOOGLE_CLIENT_ID can be unset", app.ID)
+}
+
 func initializeDefaultApplications() {
 	_, err := service.GetApplicationByID(SentinelApplicationID)
 	if err == gorm.ErrRecordNotFound {
-		app, err := service.CreateApplication(model.Application{
+		app, secret, err := service.CreateApplicationWithSecret(model.Application{
 			ID:          SentinelApplicationID,
 			Name:        "Sentinel",
 			Description: "Gaucho Racing's authentication service",
 			ClientID:    SentinelClientID,
 			LaunchURL:   "https://sso.gauchoracing.com",
 			OwnerID:     SentinelCoreEntityID,
-		})
+		}, "Initial secret", SentinelCoreEntityID)
 		if err != nil {
 			logger.SugarLogger.Fatalf("Failed to create Sentinel application: %v", err)
 			return
 		}
 		logger.SugarLogger.Infof("Created Sentinel application (id=%s, client_id=%s)", app.ID, app.ClientID)
-		logger.SugarLogger.Infof("Sentinel client secret: %s", app.ClientSecret)
+		logger.SugarLogger.Infof("Sentinel client secret: %s", secret)
 
 		defaultRedirectURIs := []string{
 			"http://localhost:3000/auth/callback",
@@ -17,6 +17,7 @@ func main() {
 	config.PrintStartupBanner()
 	database.Init()
 	service.InitializeKeys()
+	service.InitMailer()
 	jobs.InitializeCore()
 
 	// Initial conditional-group reconcile to catch drift accumulated while
@@ -25,6 +26,12 @@ func main() {
 	service.TriggerReconcileAllConditional()
 	// Periodic safety-net sweep on a configurable interval.
 	service.StartReconcileConditionalCron()
+	// Apply lifecycle changes that came due while core was down, then keep
+	// sweeping for scheduled ones.
+	service.ApplyDueStatusChanges()
+	service.StartUserStatusCron()
+	// Finalize soft deletes whose retention window has passed.
+	service.StartSoftDeletePurgeCron()
 
 	api.Run()
 }
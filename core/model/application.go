@@ -1,25 +1,157 @@
 package model
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Client authentication methods, named as in the OIDC registration spec.
+// An application uses exactly one: the secret methods accept a client
+// secret sent either way, private_key_jwt accepts only a signed assertion.
+const (
+	ClientAuthMethodSecretBasic   = "client_secret_basic"
+	ClientAuthMethodSecretPost    = "client_secret_post"
+	ClientAuthMethodPrivateKeyJWT = "private_key_jwt"
+)
 
 type Application struct {
-	ID           string    `json:"id" gorm:"primaryKey"`
-	OwnerID      string    `json:"owner_id" gorm:"index"`
-	Name         string    `json:"name"`
-	Description  string    `json:"description"`
-	ClientID     string    `json:"client_id" gorm:"uniqueIndex"`
-	ClientSecret string    `json:"-"`
-	IconURL      string    `json:"icon_url"`
-	LaunchURL    string    `json:"launch_url"`
-	RedirectURIs []string  `json:"redirect_uris" gorm:"-"`
-	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
-	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	ID           string   `json:"id" gorm:"primaryKey"`
+	OwnerID      string   `json:"owner_id" gorm:"index"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	ClientID     string   `json:"client_id" gorm:"uniqueIndex"`
+	IconURL      string   `json:"icon_url"`
+	LaunchURL    string   `json:"launch_url"`
+	RedirectURIs []string `json:"redirect_uris" gorm:"-"`
+	// AllowedScopes are the scopes this client may request; see
+	// ApplicationAllowedScope for what an empty list means.
+	AllowedScopes []string `json:"allowed_scopes" gorm:"-"`
+	// PendingScopes are the AllowedScopes still waiting on their resource
+	// server's approval.
+	PendingScopes []string `json:"pending_scopes" gorm:"-"`
+	// TokenEndpointAuthMethod is one of the ClientAuthMethod constants;
+	// empty means client_secret_basic.
+	TokenEndpointAuthMethod string `json:"token_endpoint_auth_method"`
+	// JWKS and JWKSURI hold the public keys for private_key_jwt and for
+	// signed request objects (RFC 9101). At most one is set.
+	JWKS      JSONWebKeySet `json:"jwks" gorm:"type:jsonb"`
+	JWKSURI   string        `json:"jwks_uri"`
+	UpdatedAt time.Time     `json:"updated_at" gorm:"autoUpdateTime"`
+	CreatedAt time.Time     `json:"created_at" gorm:"autoCreateTime"`
+	// DeletedAt marks a soft-deleted application. Redirect URIs, group
+	// links, and the SAML SP stay in place so a restore brings them back.
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	// RequirePushedAuthorizationRequests makes the authorize endpoint
+	// refuse parameters that didn't come through a pushed authorization
+	// request (RFC 9126), so none of them travel in the browser's URL.
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests"`
+	// PendingApproval is set on clients that registered themselves through
+	// dynamic client registration (RFC 7591) until an admin approves them.
+	// While it's set the client may only request PendingApprovalScopes.
+	PendingApproval bool `json:"pending_approval"`
+	// RegistrationTokenID is the initial access token a dynamically
+	// registered client used; empty for apps created in the console.
+	RegistrationTokenID string `json:"registration_token_id"`
+	// TokenExchangeAudiences are the client_ids this application's service
+	// accounts may exchange users' tokens for (RFC 8693), besides its own.
+	// Only admins can change them.
+	TokenExchangeAudiences StringSlice `json:"token_exchange_audiences" gorm:"type:jsonb"`
+	// RegistrationAccessTokenHash is the SHA-256 of the RFC 7592
+	// registration access token, for clients that have one.
+	RegistrationAccessTokenHash string `json:"-" gorm:"index"`
 }
 
 func (Application) TableName() string {
 	return "application"
 }
 
+// UsesPrivateKeyJWT reports whether the application authenticates with
+// signed assertions instead of a client secret.
+func (a Application) UsesPrivateKeyJWT() bool {
+	return a.TokenEndpointAuthMethod == ClientAuthMethodPrivateKeyJWT
+}
+
+// JSONWebKeySet is a raw RFC 7517 JWK Set, stored as jsonb and passed
+// through the API unchanged. Parsing and validation live in the service.
+type JSONWebKeySet json.RawMessage
+
+func (s JSONWebKeySet) MarshalJSON() ([]byte, error) {
+	if len(s) == 0 {
+		return []byte("null"), nil
+	}
+	return s, nil
+}
+
+func (s *JSONWebKeySet) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*s = nil
+		return nil
+	}
+	*s = append((*s)[:0], b...)
+	return nil
+}
+
+func (s JSONWebKeySet) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	return string(s), nil
+}
+
+func (s *JSONWebKeySet) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case string:
+		*s = JSONWebKeySet(v)
+		return nil
+	case []byte:
+		*s = append((*s)[:0], v...)
+		return nil
+	default:
+		return fmt.Errorf("unsupported type: %T", value)
+	}
+}
+
+// ClientAssertionJTI records the jti of every private_key_jwt assertion
+// accepted, until the assertion itself expires, so none can be replayed.
+type ClientAssertionJTI struct {
+	ClientID  string    `json:"client_id" gorm:"primaryKey"`
+	JTI       string    `json:"jti" gorm:"primaryKey"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+}
+
+func (ClientAssertionJTI) TableName() string {
+	return "client_assertion_jti"
+}
+
+// ApplicationSecret is one of an application's client secrets. An app can
+// hold several at once so a rotation can overlap: deployments switch to the
+// new secret while the old one is still accepted. Only the SHA-256 of the
+// secret is stored; the plaintext is returned once, when it's created.
+type ApplicationSecret struct {
+	ID            string `json:"id" gorm:"primaryKey"`
+	ApplicationID string `json:"application_id" gorm:"index"`
+	Label         string `json:"label"`
+	SecretHash    string `json:"-" gorm:"uniqueIndex"`
+	// Hint is the last few characters of the secret, so owners can tell
+	// which one a deployment is using.
+	Hint       string     `json:"hint"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (ApplicationSecret) TableName() string {
+	return "application_secret"
+}
+
 type ApplicationRedirectURI struct {
 	ApplicationID string `json:"application_id" gorm:"primaryKey"`
 	RedirectURI   string `json:"redirect_uri" gorm:"primaryKey"`
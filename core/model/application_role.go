@@ -0,0 +1,56 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ApplicationRole is a role an application defines for its own users, e.g.
+// "admin" or "viewer", so relying parties can key on a `roles` claim
+// instead of re-deriving roles from group names. Who holds the role is
+// decided by Rules with the same semantics as conditional bindings: a user
+// matches a rule if they're an effective member of EVERY group in it (AND),
+// and holds the role if they match ANY rule (OR).
+type ApplicationRole struct {
+	ID            string    `json:"id" gorm:"primaryKey"`
+	ApplicationID string    `json:"application_id" gorm:"index"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	Rules         RoleRules `json:"rules" gorm:"type:jsonb"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (ApplicationRole) TableName() string {
+	return "application_role"
+}
+
+// RoleRule is one AND-group of an ApplicationRole.
+type RoleRule struct {
+	GroupIDs []string `json:"group_ids"`
+}
+
+type RoleRules []RoleRule
+
+func (r RoleRules) Value() (driver.Value, error) {
+	if r == nil {
+		r = RoleRules{}
+	}
+	b, err := json.Marshal(r)
+	return string(b), err
+}
+
+func (r *RoleRules) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	case []byte:
+		return json.Unmarshal(v, r)
+	default:
+		return fmt.Errorf("unsupported type: %T", value)
+	}
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// PendingApprovalScopes are the only scopes a dynamically registered client
+// may request until an admin approves it: enough to sign users in, nothing
+// that reads or changes their data in Sentinel or another app.
+var PendingApprovalScopes = []string{"openid", "profile", "email"}
+
+// InitialAccessToken authorizes dynamic client registration (RFC 7591). An
+// admin mints one and hands it to developers, who present it as a bearer
+// token to the registration endpoint. Only the SHA-256 of the token is
+// stored; the plaintext is returned once, when it's created.
+type InitialAccessToken struct {
+	ID        string `json:"id" gorm:"primaryKey"`
+	Label     string `json:"label"`
+	TokenHash string `json:"-" gorm:"uniqueIndex"`
+	// MaxRegistrations caps how many clients the token can register; zero
+	// means no limit. Registrations counts the ones it has.
+	MaxRegistrations int        `json:"max_registrations"`
+	Registrations    int        `json:"registrations"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	CreatedBy        string     `json:"created_by"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (InitialAccessToken) TableName() string {
+	return "initial_access_token"
+}
@@ -1,10 +1,16 @@
 package model
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // GroupConditionalBinding ties a Sentinel group to a set of *other* group
-// IDs. A user matches the binding only if they're a member of EVERY group
-// in RequiredGroupIDs (AND within a binding). Membership in the parent
+// IDs and/or attribute predicates. A user matches the binding only if
+// they're a member of EVERY group in RequiredGroupIDs and satisfy EVERY
+// predicate in Predicates (AND within a binding). Membership in the parent
 // group is the OR across all bindings on the same parent (matches the
 // Discord role-binding semantics).
 //
@@ -15,14 +21,68 @@ import "time"
 // Class of 2026," where each of those may themselves be conditional.
 //
 // Cycles (A requires B, B requires A — or longer paths) are rejected at
-// binding-creation time; see service.CheckBindingCycle.
+// binding-creation time; see service.CreateConditionalBinding. Predicates
+// read profile data, not memberships, so they never add to the graph.
 type GroupConditionalBinding struct {
-	ID               string      `json:"id" gorm:"primaryKey"`
-	GroupID          string      `json:"group_id" gorm:"index"`
-	RequiredGroupIDs StringSlice `json:"required_group_ids" gorm:"type:jsonb"`
-	CreatedAt        time.Time   `json:"created_at" gorm:"autoCreateTime"`
+	ID               string              `json:"id" gorm:"primaryKey"`
+	GroupID          string              `json:"group_id" gorm:"index"`
+	RequiredGroupIDs StringSlice         `json:"required_group_ids" gorm:"type:jsonb"`
+	Predicates       AttributePredicates `json:"predicates" gorm:"type:jsonb"`
+	CreatedAt        time.Time           `json:"created_at" gorm:"autoCreateTime"`
 }
 
 func (GroupConditionalBinding) TableName() string {
 	return "group_conditional_binding"
 }
+
+// PredicateOp is the comparison an AttributePredicate applies.
+type PredicateOp string
+
+const (
+	PredicateOpEq      PredicateOp = "eq"
+	PredicateOpNeq     PredicateOp = "neq"
+	PredicateOpIn      PredicateOp = "in"
+	PredicateOpNotIn   PredicateOp = "not_in"
+	PredicateOpGt      PredicateOp = "gt"
+	PredicateOpGte     PredicateOp = "gte"
+	PredicateOpLt      PredicateOp = "lt"
+	PredicateOpLte     PredicateOp = "lte"
+	PredicateOpPresent PredicateOp = "present"
+	PredicateOpAbsent  PredicateOp = "absent"
+)
+
+// AttributePredicate is one condition over an entity's profile, e.g.
+// {"field": "major", "op": "in", "values": ["EE", "CE"]}. Field is a User
+// JSON field name or "entity_type". eq/neq and the ordered comparisons take
+// Value; in/not_in take Values; present/absent take neither. The allowed
+// fields and ops are enforced by service.ValidateAttributePredicates.
+type AttributePredicate struct {
+	Field  string      `json:"field"`
+	Op     PredicateOp `json:"op"`
+	Value  string      `json:"value,omitempty"`
+	Values []string    `json:"values,omitempty"`
+}
+
+type AttributePredicates []AttributePredicate
+
+func (p AttributePredicates) Value() (driver.Value, error) {
+	if p == nil {
+		p = AttributePredicates{}
+	}
+	b, err := json.Marshal(p)
+	return string(b), err
+}
+
+func (p *AttributePredicates) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*p = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	case []byte:
+		return json.Unmarshal(v, p)
+	default:
+		return fmt.Errorf("unsupported type: %T", value)
+	}
+}
@@ -2,9 +2,14 @@ package model
 
 import "time"
 
+// EmailLoginCode is a one-time passwordless login code sent to an email
+// address. Only a bcrypt hash of the code is stored; Attempts counts failed
+// verifications so a code can't be brute-forced inside its TTL.
 type EmailLoginCode struct {
-	Email      string    `json:"email" gorm:"primaryKey"`
-	Code       int       `json:"code" gorm:"primaryKey"`
+	ID         string    `json:"id" gorm:"primaryKey"`
+	Email      string    `json:"email" gorm:"index"`
+	CodeHash   string    `json:"-"`
+	Attempts   int       `json:"attempts"`
 	ExpiresAt  time.Time `json:"expires_at"`
 	Verified   bool      `json:"verified"`
 	VerifiedAt time.Time `json:"verified_at"`
@@ -14,3 +19,25 @@ type EmailLoginCode struct {
 func (EmailLoginCode) TableName() string {
 	return "auth_email_login_code"
 }
+
+// EmailRequestKind is the kind of mail an EmailRequest asked for.
+type EmailRequestKind string
+
+const (
+	EmailRequestLoginCode     EmailRequestKind = "LOGIN_CODE"
+	EmailRequestPasswordReset EmailRequestKind = "PASSWORD_RESET"
+)
+
+// EmailRequest records a request for mail to an address, whether or not the
+// address is registered, so requests can be throttled per address without
+// the throttle revealing which ones are.
+type EmailRequest struct {
+	ID        string           `json:"id" gorm:"primaryKey"`
+	Kind      EmailRequestKind `json:"kind" gorm:"index:idx_email_request_kind_email"`
+	Email     string           `json:"email" gorm:"index:idx_email_request_kind_email"`
+	CreatedAt time.Time        `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (EmailRequest) TableName() string {
+	return "auth_email_request"
+}
@@ -58,6 +58,7 @@ type Entity struct {
 	EmailAuth      EntityEmail          `json:"email_auth" gorm:"-"`
 	PhoneAuth      EntityPhone          `json:"phone_auth" gorm:"-"`
 	ExternalAuths  []EntityExternalAuth `json:"external_auths" gorm:"-"`
+	Passkeys       []EntityPasskey      `json:"passkeys" gorm:"-"`
 	User           *User                `json:"user,omitempty" gorm:"-"`
 	ServiceAccount *ServiceAccount      `json:"service_account,omitempty" gorm:"-"`
 }
@@ -108,3 +109,33 @@ func (EntityExternalAuth) TableName() string {
 	return "auth_entity_external_auth"
 }
 
+
+// EntityPasskey is a WebAuthn credential registered to an entity. The
+// ceremonies run in oauth; core only keeps the credential record they
+// produce. CredentialID is the raw credential ID, base64url-encoded without
+// padding, which is how it arrives in an assertion's rawId.
+type EntityPasskey struct {
+	ID                string      `json:"id" gorm:"primaryKey"`
+	EntityID          string      `json:"entity_id" gorm:"index"`
+	CredentialID      string      `json:"credential_id" gorm:"uniqueIndex"`
+	PublicKey         []byte      `json:"public_key"`
+	AttestationType   string      `json:"attestation_type"`
+	AttestationFormat string      `json:"attestation_format"`
+	AAGUID            []byte      `json:"aaguid"`
+	Transports        StringSlice `json:"transports" gorm:"type:jsonb"`
+	UserPresent       bool        `json:"user_present"`
+	UserVerified      bool        `json:"user_verified"`
+	BackupEligible    bool        `json:"backup_eligible"`
+	BackupState       bool        `json:"backup_state"`
+	Name              string      `json:"name"`
+	LastUsedAt        *time.Time  `json:"last_used_at"`
+	CreatedAt         time.Time   `json:"created_at" gorm:"autoCreateTime"`
+
+	// SignCount is the authenticator's signature counter as of the last
+	// assertion. Synced passkeys usually report 0 forever.
+	SignCount uint32 `json:"sign_count"`
+}
+
+func (EntityPasskey) TableName() string {
+	return "auth_entity_passkey"
+}
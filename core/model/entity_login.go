@@ -10,6 +10,7 @@ type EntityLogin struct {
 	AccessTokenID  string    `json:"access_token_id"`
 	RefreshTokenID string    `json:"refresh_token_id"`
 	IPAddress      string    `json:"ip_address"`
+	ActorID        string    `json:"actor_id,omitempty"` // set for token exchange: the service acting for EntityID
 	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
 }
 
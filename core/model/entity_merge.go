@@ -0,0 +1,95 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EntityMergePlan lists what merging a duplicate entity into a survivor
+// does. Items under Move are reassigned to the survivor; items under Drop
+// are deleted because the survivor already has their equivalent. A plan
+// with Conflicts can't be applied — an admin has to resolve them first.
+type EntityMergePlan struct {
+	SurvivorEntityID  string `json:"survivor_entity_id"`
+	DuplicateEntityID string `json:"duplicate_entity_id"`
+
+	MoveEmail bool   `json:"move_email"`
+	DropEmail string `json:"drop_email,omitempty"`
+	MovePhone bool   `json:"move_phone"`
+	DropPhone string `json:"drop_phone,omitempty"`
+
+	MoveExternalAuths []ExternalAuthProvider `json:"move_external_auths"`
+	DropExternalAuths []ExternalAuthProvider `json:"drop_external_auths"`
+
+	MoveMemberships  []EntityMergeMembership `json:"move_memberships"`
+	DropMemberships  []EntityMergeMembership `json:"drop_memberships"`
+	MoveOwnerships   []string                `json:"move_ownerships"`
+	DropOwnerships   []string                `json:"drop_ownerships"`
+	MoveJoinRequests []string                `json:"move_join_requests"`
+	DropJoinRequests []string                `json:"drop_join_requests"`
+	// UpgradeMemberships are groups both entities are in where the
+	// duplicate's membership is the stronger grant. The survivor's takes
+	// its source or its later expiry, and the duplicate's is dropped.
+	UpgradeMemberships []EntityMergeMembership `json:"upgrade_memberships"`
+
+	// Reassigned by ID: applications the duplicate owns, service accounts
+	// and groups it created.
+	Applications    []string `json:"applications"`
+	ServiceAccounts []string `json:"service_accounts"`
+	CreatedGroups   []string `json:"created_groups"`
+
+	MovePasskeys int   `json:"move_passkeys"`
+	MoveMFA      bool  `json:"move_mfa"`
+	DropMFA      bool  `json:"drop_mfa"`
+	EntityLogins int64 `json:"entity_logins"`
+	RevokeTokens int64 `json:"revoke_tokens"`
+	// MoveUser is set when only the duplicate has a user profile; otherwise
+	// the duplicate's profile (if any) is deleted in favor of the survivor's.
+	MoveUser   bool   `json:"move_user"`
+	DropUserID string `json:"drop_user_id,omitempty"`
+
+	Conflicts []string `json:"conflicts"`
+}
+
+// EntityMergeMembership is one group membership in a merge plan.
+type EntityMergeMembership struct {
+	GroupID string `json:"group_id"`
+	Source  string `json:"source"`
+}
+
+func (p EntityMergePlan) Value() (driver.Value, error) {
+	b, err := json.Marshal(p)
+	return string(b), err
+}
+
+func (p *EntityMergePlan) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	case []byte:
+		return json.Unmarshal(v, p)
+	default:
+		return fmt.Errorf("unsupported type: %T", value)
+	}
+}
+
+// EntityMerge is the audit record of an applied merge. The duplicate entity
+// no longer exists afterwards, so this row (and its plan) is the only trace
+// of what it held.
+type EntityMerge struct {
+	ID                string          `json:"id" gorm:"primaryKey"`
+	SurvivorEntityID  string          `json:"survivor_entity_id" gorm:"index"`
+	DuplicateEntityID string          `json:"duplicate_entity_id" gorm:"index"`
+	Plan              EntityMergePlan `json:"plan" gorm:"type:jsonb"`
+	MergedBy          string          `json:"merged_by"`
+	CreatedAt         time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	// MergedByActor is the service that made the merge on MergedBy's
+	// behalf through token exchange; empty when MergedBy made it directly.
+	MergedByActor string `json:"merged_by_actor,omitempty"`
+}
+
+func (EntityMerge) TableName() string {
+	return "entity_merge"
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type StringSlice []string
@@ -20,6 +22,9 @@ func (s *StringSlice) Scan(value interface{}) error {
 		return json.Unmarshal([]byte(v), s)
 	case []byte:
 		return json.Unmarshal(v, s)
+	case nil:
+		*s = nil
+		return nil
 	default:
 		return fmt.Errorf("unsupported type: %T", value)
 	}
@@ -31,6 +36,10 @@ const (
 	GroupMemberSourceDirect      GroupMemberSource = "DIRECT"
 	GroupMemberSourceConditional GroupMemberSource = "CONDITIONAL"
 	GroupMemberSourceDiscord     GroupMemberSource = "DISCORD"
+	// GroupMemberSourceInherited marks a membership that comes from being a
+	// member of a child group. It's never stored — inherited rows are
+	// derived from GroupChild links when memberships are read.
+	GroupMemberSourceInherited GroupMemberSource = "INHERITED"
 )
 
 type GroupJoinRequestStatus string
@@ -46,9 +55,21 @@ type Group struct {
 	Name           string      `json:"name" gorm:"uniqueIndex"`
 	Description    string      `json:"description"`
 	AllowedSources StringSlice `json:"allowed_sources" gorm:"type:jsonb"`
-	CreatedBy      string      `json:"created_by" gorm:"index"`
-	UpdatedAt      time.Time   `json:"updated_at" gorm:"autoUpdateTime"`
-	CreatedAt      time.Time   `json:"created_at" gorm:"autoCreateTime"`
+	// RequireMFA makes MFA mandatory for first-party sign-in by any member.
+	// Members who haven't enrolled are walked through enrollment at login.
+	RequireMFA bool `json:"require_mfa"`
+	// AlumniGroupID is where members land when they become alumni: they
+	// are added to it directly and their DIRECT membership here ends.
+	AlumniGroupID string    `json:"alumni_group_id"`
+	CreatedBy     string    `json:"created_by" gorm:"index"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+	// DeletedAt marks a soft-deleted group. Its members, owners, bindings,
+	// and application links are left in place so a restore brings them back.
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	// CreatedByActor is the service that created the group on CreatedBy's
+	// behalf through token exchange; empty when CreatedBy did it directly.
+	CreatedByActor string `json:"created_by_actor,omitempty"`
 
 	MemberCount  int64 `json:"member_count" gorm:"-"`
 	OwnerCount   int64 `json:"owner_count" gorm:"-"`
@@ -67,12 +88,33 @@ type GroupMember struct {
 	HasExpiration bool      `json:"has_expiration"`
 	ExpiresAt     time.Time `json:"expires_at"`
 	JoinedAt      time.Time `json:"joined_at" gorm:"autoCreateTime"`
+	// InheritedFrom is set on INHERITED rows: the descendant group the
+	// entity is actually a member of. Read-only and not a real column.
+	InheritedFrom string `json:"inherited_from,omitempty" gorm:"->;-:migration"`
+	// AddedByActor is the service that added the member on AddedBy's behalf
+	// through token exchange; empty when AddedBy did it directly.
+	AddedByActor string `json:"added_by_actor,omitempty"`
 }
 
 func (GroupMember) TableName() string {
 	return "group_member"
 }
 
+// GroupChild makes ChildGroupID a subgroup of ParentGroupID: every member of
+// the child (from any source) is an effective member of the parent, and of
+// the parent's own parents in turn. Links between groups form a DAG — see
+// service.AddChildGroup for the cycle check.
+type GroupChild struct {
+	ParentGroupID string    `json:"parent_group_id" gorm:"primaryKey"`
+	ChildGroupID  string    `json:"child_group_id" gorm:"primaryKey;index"`
+	AddedBy       string    `json:"added_by"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (GroupChild) TableName() string {
+	return "group_child"
+}
+
 type GroupOwner struct {
 	GroupID   string    `json:"group_id" gorm:"primaryKey"`
 	EntityID  string    `json:"entity_id" gorm:"primaryKey"`
@@ -95,6 +137,9 @@ type GroupJoinRequest struct {
 	ExpiresAt     time.Time                 `json:"expires_at"`
 	CreatedAt     time.Time                 `json:"created_at" gorm:"autoCreateTime"`
 	Comments      []GroupJoinRequestComment `json:"comments" gorm:"-"`
+	// ReviewedByActor is the service that reviewed the request on
+	// ReviewedBy's behalf through token exchange, if any.
+	ReviewedByActor string `json:"reviewed_by_actor,omitempty"`
 }
 
 func (GroupJoinRequest) TableName() string {
@@ -76,8 +76,27 @@ type Token struct {
 	EntityID  string    `json:"entity_id"`
 	ClientID  string    `json:"client_id"`
 	Scope     string    `json:"scope"`
+	ActorID   string    `json:"actor_id,omitempty"` // act.sub for exchanged tokens, see ActorFromClaims
 	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	// FamilyID groups the tokens issued from one grant and every refresh
+	// of it; see TokenFamily. Empty for tokens outside any family.
+	FamilyID string `json:"family_id,omitempty" gorm:"index"`
+	// RotatedAt is set on a refresh token once it's been exchanged. The row
+	// is kept until it expires so a replay can be recognized as reuse.
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+}
+
+// ActorFromClaims returns the sub of a token's act claim (RFC 8693): the
+// party the token was delegated to. Only the outermost actor is returned;
+// earlier ones in a delegation chain are nested inside it.
+func ActorFromClaims(claims map[string]interface{}) string {
+	act, ok := claims["act"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	sub, _ := act["sub"].(string)
+	return sub
 }
 
 func (Token) TableName() string {
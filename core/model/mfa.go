@@ -0,0 +1,73 @@
+package model
+
+import "time"
+
+// EntityMFA holds an entity's TOTP enrollment. A row with Enabled=false is a
+// pending enrollment: the secret has been shown to the user but not yet
+// confirmed with a code. TOTPSecret is base32 and has to stay recoverable to
+// verify codes, so it's never serialized.
+type EntityMFA struct {
+	EntityID    string     `json:"entity_id" gorm:"primaryKey"`
+	TOTPSecret  string     `json:"-"`
+	Enabled     bool       `json:"enabled"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	// LastUsedStep is the TOTP time step of the last accepted code, so a code
+	// can't be replayed within its validity window.
+	LastUsedStep int64     `json:"-"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (EntityMFA) TableName() string {
+	return "auth_entity_mfa"
+}
+
+// MFARecoveryCode is a single-use fallback for a lost authenticator. Stored
+// as a bcrypt hash; the plaintext is shown once, when the set is generated.
+type MFARecoveryCode struct {
+	ID        string     `json:"id" gorm:"primaryKey"`
+	EntityID  string     `json:"entity_id" gorm:"index"`
+	CodeHash  string     `json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "auth_mfa_recovery_code"
+}
+
+// MFAChallenge bridges the two steps of an MFA login. The first factor
+// succeeds and yields a challenge token instead of a session; presenting the
+// token with a valid second factor completes the login. AMR records the
+// methods already satisfied so they carry into the issued token's amr claim.
+type MFAChallenge struct {
+	ID        string      `json:"id" gorm:"primaryKey"`
+	EntityID  string      `json:"entity_id" gorm:"index"`
+	TokenHash string      `json:"-" gorm:"uniqueIndex"`
+	AMR       StringSlice `json:"amr" gorm:"type:jsonb"`
+	// EnrollmentRequired is set when group policy requires MFA but the entity
+	// hasn't enrolled; the challenge then also authorizes TOTP enrollment.
+	EnrollmentRequired bool       `json:"enrollment_required"`
+	Attempts           int        `json:"attempts"`
+	UsedAt             *time.Time `json:"used_at"`
+	ExpiresAt          time.Time  `json:"expires_at"`
+	CreatedAt          time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (MFAChallenge) TableName() string {
+	return "auth_mfa_challenge"
+}
+
+// MFAAttempt records one second-factor attempt against an entity, across
+// all of its challenges. Accepted attempts are deleted, so the rows left in
+// a window are failures and can lock the entity out even when each
+// challenge stays under its own cap.
+type MFAAttempt struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	EntityID  string    `json:"entity_id" gorm:"index:idx_mfa_attempt_entity_created"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;index:idx_mfa_attempt_entity_created"`
+}
+
+func (MFAAttempt) TableName() string {
+	return "auth_mfa_attempt"
+}
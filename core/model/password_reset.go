@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+type PasswordResetChannel string
+
+const (
+	PasswordResetChannelEmail   PasswordResetChannel = "EMAIL"
+	PasswordResetChannelDiscord PasswordResetChannel = "DISCORD"
+)
+
+// PasswordResetToken is a single-use, short-lived credential that lets the
+// holder set a new password for EntityID. Only the SHA-256 of the token is
+// stored — the raw value exists only in the message sent to the user.
+type PasswordResetToken struct {
+	ID        string               `json:"id" gorm:"primaryKey"`
+	EntityID  string               `json:"entity_id" gorm:"index"`
+	TokenHash string               `json:"-" gorm:"uniqueIndex"`
+	Channel   PasswordResetChannel `json:"channel"`
+	UsedAt    *time.Time           `json:"used_at"`
+	ExpiresAt time.Time            `json:"expires_at"`
+	CreatedAt time.Time            `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (PasswordResetToken) TableName() string {
+	return "auth_password_reset_token"
+}
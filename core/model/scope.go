@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// BuiltinScopes are the scopes Sentinel's own API understands, with the
+// description shown on the consent screen. Applications can register
+// more of their own as resource servers (ApplicationScope).
+var BuiltinScopes = map[string]string{
+	"openid":             "Authenticate you and issue an ID token",
+	"profile":            "Read your basic profile (name, username, picture)",
+	"email":              "Read your email address",
+	"offline_access":     "Stay signed in without re-authenticating (refresh token)",
+	"user:read":          "Read user and entity profile information",
+	"user:write":         "Update user profile information",
+	"groups:read":        "Read group memberships",
+	"applications:read":  "Read application details",
+	"applications:write": "Manage applications",
+	"sentinel:all":       "Full internal access (not available to third-party apps)",
+}
+
+// ApplicationScope is a scope an application defines for its own API,
+// e.g. telemetry:read on the telemetry API. Names are global: any client
+// allowed to may request it, and tokens granting it carry the defining
+// application's client_id in aud.
+type ApplicationScope struct {
+	Name          string    `json:"name" gorm:"primaryKey"`
+	ApplicationID string    `json:"application_id" gorm:"index"`
+	Description   string    `json:"description"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (ApplicationScope) TableName() string {
+	return "application_scope"
+}
+
+// ApplicationAllowedScope is one entry in the list of scopes a client may
+// request. A client with no entries may request any builtin scope except
+// sentinel:all, but no application-defined ones.
+type ApplicationAllowedScope struct {
+	ApplicationID string `json:"application_id" gorm:"primaryKey"`
+	Scope         string `json:"scope" gorm:"primaryKey"`
+	// PendingApproval is set on another application's scope until that
+	// application's owner or an admin approves it. The client can't
+	// request the scope while it's set.
+	PendingApproval bool `json:"pending_approval"`
+}
+
+func (ApplicationAllowedScope) TableName() string {
+	return "application_allowed_scope"
+}
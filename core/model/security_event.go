@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Security event types.
+const (
+	// SecurityEventRefreshTokenReuse is recorded when an already-rotated
+	// refresh token is presented again; its family is revoked.
+	SecurityEventRefreshTokenReuse = "REFRESH_TOKEN_REUSE"
+)
+
+// SecurityEvent records something suspicious that Sentinel acted on, for
+// admins and the affected user to review.
+type SecurityEvent struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	Type      string    `json:"type" gorm:"index"`
+	EntityID  string    `json:"entity_id" gorm:"index"`
+	ClientID  string    `json:"client_id"`
+	IPAddress string    `json:"ip_address"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (SecurityEvent) TableName() string {
+	return "security_event"
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// TokenFamily is the chain of refresh tokens descended from one grant. Each
+// refresh rotates the refresh token within the family, and every token
+// issued along the way carries its ID. Presenting a refresh token that was
+// already rotated means it leaked, so the whole family is revoked.
+type TokenFamily struct {
+	ID       string `json:"id" gorm:"primaryKey"`
+	EntityID string `json:"entity_id" gorm:"index"`
+	ClientID string `json:"client_id"`
+	// ExpiresAt is the family's absolute lifetime: no refresh token in it
+	// outlives this, however recently it was used.
+	ExpiresAt     time.Time  `json:"expires_at"`
+	LastRotatedAt *time.Time `json:"last_rotated_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	RevokedReason string     `json:"revoked_reason"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (TokenFamily) TableName() string {
+	return "auth_token_family"
+}
@@ -0,0 +1,73 @@
+package model
+
+import "time"
+
+// Optional claims an application's token policy can include. Groups still
+// need the groups:read scope and email the email scope; the policy only
+// decides whether the client wants them at all.
+const (
+	// OptionalClaimGroups is the groups and group_ids claims.
+	OptionalClaimGroups = "groups"
+	// OptionalClaimRoles is the roles claim: the client's own roles the
+	// user holds.
+	OptionalClaimRoles = "roles"
+	// OptionalClaimEmail puts the email claim in access tokens, not just ID
+	// tokens and userinfo, for APIs that key on it.
+	OptionalClaimEmail = "email"
+)
+
+// OptionalClaims lists every optional claim, in display order.
+var OptionalClaims = []string{OptionalClaimGroups, OptionalClaimRoles, OptionalClaimEmail}
+
+// DefaultOptionalClaims are the claims included for applications that
+// haven't set a policy.
+var DefaultOptionalClaims = StringSlice{OptionalClaimGroups, OptionalClaimRoles}
+
+// ApplicationTokenPolicy is how the oauth service issues tokens for one
+// application. Lifetimes are in seconds, and zero means the oauth service's
+// configured default. Applications without a row get DefaultTokenPolicy.
+type ApplicationTokenPolicy struct {
+	ApplicationID  string `json:"application_id" gorm:"primaryKey"`
+	AccessTokenTTL int    `json:"access_token_ttl"`
+	IDTokenTTL     int    `json:"id_token_ttl"`
+	// RefreshTokenIdleTTL is how long a refresh token lasts unused;
+	// RefreshTokenAbsoluteTTL is how long its family lasts, however often
+	// it's refreshed.
+	RefreshTokenIdleTTL     int `json:"refresh_token_idle_ttl"`
+	RefreshTokenAbsoluteTTL int `json:"refresh_token_absolute_ttl"`
+	// AllowOfflineAccess lets the client request offline_access and be
+	// issued refresh tokens at all.
+	AllowOfflineAccess bool        `json:"allow_offline_access"`
+	OptionalClaims     StringSlice `json:"optional_claims" gorm:"type:jsonb"`
+	// The shared identity, when SharedEmail is set, replaces the identity
+	// claims every user presents to this client, so that many users appear
+	// as one account (e.g. a shared Google Workspace mailbox). sub is left
+	// alone, so the real user stays attributable server-side.
+	SharedEmail     string    `json:"shared_email"`
+	SharedUsername  string    `json:"shared_username"`
+	SharedFirstName string    `json:"shared_first_name"`
+	SharedLastName  string    `json:"shared_last_name"`
+	UpdatedAt       time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (ApplicationTokenPolicy) TableName() string {
+	return "application_token_policy"
+}
+
+// DefaultTokenPolicy is the policy of an application that hasn't set one:
+// default lifetimes, refresh tokens allowed, DefaultOptionalClaims, and no
+// shared identity.
+func DefaultTokenPolicy(applicationID string) ApplicationTokenPolicy {
+	return ApplicationTokenPolicy{
+		ApplicationID:      applicationID,
+		AllowOfflineAccess: true,
+		OptionalClaims:     append(StringSlice{}, DefaultOptionalClaims...),
+	}
+}
+
+// HasSharedIdentity reports whether the policy replaces users' identity
+// claims with a shared one.
+func (p ApplicationTokenPolicy) HasSharedIdentity() bool {
+	return p.SharedEmail != ""
+}
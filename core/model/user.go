@@ -27,15 +27,54 @@ type User struct {
 	OccupationCompany     string    `json:"occupation_company"`
 	AvatarURL             string    `json:"avatar_url"`
 	InitialRole           string    `json:"initial_role"`
-	Groups                []string  `json:"groups" gorm:"-"`
-	UpdatedAt             time.Time `json:"updated_at"`
-	CreatedAt             time.Time `json:"created_at"`
+	// Status is the member's lifecycle state. It only moves through
+	// ChangeUserStatus; StatusEffectiveAt is when the current state began.
+	Status            UserStatus `json:"status" gorm:"default:ACTIVE;index"`
+	StatusEffectiveAt time.Time  `json:"status_effective_at"`
+	Groups            []string   `json:"groups" gorm:"-"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	// DeletedAt marks a soft-deleted user: hidden everywhere until restored
+	// or purged after the retention window.
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 func (User) TableName() string {
 	return "user"
 }
 
+// UserStatus is a member's place in the lifecycle. Everyone starts ACTIVE;
+// ALUMNI and SUSPENDED can be reversed, OFFBOARDED is terminal.
+type UserStatus string
+
+const (
+	UserStatusActive     UserStatus = "ACTIVE"
+	UserStatusAlumni     UserStatus = "ALUMNI"
+	UserStatusSuspended  UserStatus = "SUSPENDED"
+	UserStatusOffboarded UserStatus = "OFFBOARDED"
+)
+
+// UserStatusChange records one lifecycle transition. A change with a future
+// EffectiveAt is scheduled: AppliedAt stays nil until the status sweep
+// applies it. Applied rows double as the feed integrations poll to react to
+// offboarding and alumni moves.
+type UserStatusChange struct {
+	ID          string     `json:"id" gorm:"primaryKey"`
+	UserID      string     `json:"user_id" gorm:"index"`
+	EntityID    string     `json:"entity_id" gorm:"index"`
+	FromStatus  UserStatus `json:"from_status"`
+	ToStatus    UserStatus `json:"to_status"`
+	Reason      string     `json:"reason"`
+	EffectiveAt time.Time  `json:"effective_at" gorm:"index"`
+	AppliedAt   *time.Time `json:"applied_at" gorm:"index"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (UserStatusChange) TableName() string {
+	return "user_status_change"
+}
+
 func (u *User) BeforeSave(tx *gorm.DB) error {
 	u.Username = strings.ToLower(u.Username)
 	return nil
@@ -0,0 +1,116 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/pkg/logger"
+)
+
+// Message is a plain-text email. Everything Sentinel sends today (login
+// codes, password resets) is short transactional text, so there's no HTML
+// or attachment support.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers a Message. Implementations must be safe for concurrent use.
+type Sender interface {
+	Send(msg Message) error
+}
+
+// sender is the process-wide Sender configured at startup via SetSender.
+// Reads via RLock so request handlers don't serialize on it.
+var (
+	sender   Sender
+	senderMu sync.RWMutex
+)
+
+// SetSender wires the Sender used by Send. Call once at startup.
+func SetSender(s Sender) {
+	senderMu.Lock()
+	defer senderMu.Unlock()
+	sender = s
+}
+
+// Send delivers msg through the configured Sender. Returns an error if no
+// Sender has been configured rather than silently dropping the message.
+func Send(msg Message) error {
+	senderMu.RLock()
+	s := sender
+	senderMu.RUnlock()
+	if s == nil {
+		return errors.New("mailer: no sender configured")
+	}
+	return s.Send(msg)
+}
+
+// SMTPSender delivers mail through an SMTP relay using PLAIN auth. Auth is
+// skipped when Username is empty (e.g. a local relay that trusts the network).
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s SMTPSender) Send(msg Message) error {
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		return errors.New("mailer: header fields must not contain line breaks")
+	}
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	return smtp.SendMail(net.JoinHostPort(s.Host, s.Port), auth, s.From, []string{msg.To}, s.format(msg))
+}
+
+func (s SMTPSender) format(msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// LogSender writes messages to the logger instead of delivering them. For
+// local development only — the body (and any code in it) ends up in logs.
+type LogSender struct{}
+
+func (LogSender) Send(msg Message) error {
+	logger.SugarLogger.Infof("mail to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileSender appends messages to a file, one block per message. Useful when
+// a local tool wants to tail outgoing mail without scraping service logs.
+type FileSender struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (f *FileSender) Send(msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, "--- %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().UTC().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
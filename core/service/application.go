@@ -2,12 +2,17 @@ package service
 
 import (
 	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gaucho-racing/sentinel/core/database"
 	"github.com/gaucho-racing/sentinel/core/model"
 	"github.com/gaucho-racing/sentinel/core/pkg/logger"
 	"github.com/gaucho-racing/ulid-go"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
@@ -21,6 +26,7 @@ type AccessedApplication struct {
 
 // GetAccessedApplicationsForEntity returns the applications the entity has
 // signed into, deduplicated by client_id, ordered by most-recent access.
+// Logins a service obtained for the entity by token exchange don't count.
 // Server-side dedupe so users with lopsided login distributions (many logins
 // for one app, few for others) still see all distinct apps. limit=0 means
 // unlimited.
@@ -32,9 +38,10 @@ func GetAccessedApplicationsForEntity(entityID string, limit int) ([]AccessedApp
 		INNER JOIN (
 			SELECT client_id, MAX(created_at) AS last_accessed_at
 			FROM entity_login
-			WHERE entity_id = ?
+			WHERE entity_id = ? AND COALESCE(actor_id, '') = ''
 			GROUP BY client_id
 		) l ON l.client_id = a.client_id
+		WHERE a.deleted_at IS NULL
 		ORDER BY l.last_accessed_at DESC
 	`
 	args := []interface{}{entityID}
@@ -51,15 +58,47 @@ func GetAccessedApplicationsForEntity(entityID string, limit int) ([]AccessedApp
 	return apps, nil
 }
 
-func GetAllApplications() ([]model.Application, error) {
-	applications := []model.Application{}
-	if err := database.DB.Find(&applications).Error; err != nil {
-		return []model.Application{}, err
+// ApplicationFilter narrows ListApplications.
+type ApplicationFilter struct {
+	Query        string
+	OwnerID      string
+	CreatedAfter time.Time
+	// IncludeDeleted also lists soft-deleted applications.
+	IncludeDeleted bool
+}
+
+var applicationListSpec = listSpec[model.Application]{
+	sorts: map[string]sortField[model.Application]{
+		"name":       {column: "name", kind: sortString, value: func(a model.Application) any { return a.Name }},
+		"created_at": {column: "created_at", kind: sortTime, value: func(a model.Application) any { return a.CreatedAt }},
+	},
+	defaultSort: "name",
+	idColumn:    "id",
+	id:          func(a model.Application) string { return a.ID },
+}
+
+// ListApplications returns one page of applications. Query matches each
+// term against the name, description, and client ID.
+func ListApplications(filter ApplicationFilter, opts ListOptions) (Page[model.Application], error) {
+	query := database.DB.Model(&model.Application{})
+	if filter.IncludeDeleted {
+		query = query.Unscoped()
 	}
-	for i := range applications {
-		PopulateApplication(&applications[i])
+	for _, term := range searchTerms(filter.Query) {
+		query = query.Where("(name ILIKE ? OR description ILIKE ? OR client_id ILIKE ?)", term, term, term)
 	}
-	return applications, nil
+	if filter.OwnerID != "" {
+		query = query.Where("owner_id = ?", filter.OwnerID)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("created_at > ?", filter.CreatedAfter)
+	}
+	page, err := paginate(query, opts, applicationListSpec)
+	if err != nil {
+		return Page[model.Application]{}, err
+	}
+	populateApplications(page.Data)
+	return page, nil
 }
 
 func GetApplicationByID(id string) (model.Application, error) {
@@ -80,6 +119,32 @@ func GetApplicationByClientID(clientID string) (model.Application, error) {
 	return app, nil
 }
 
+// ErrInvalidExchangeAudience is returned by SetTokenExchangeAudiences for
+// a client_id that doesn't belong to a live application.
+var ErrInvalidExchangeAudience = errors.New("token exchange audience is not a registered client_id")
+
+// SetTokenExchangeAudiences trims and de-duplicates audiences and sets
+// them as the client_ids app's service accounts may exchange tokens for.
+// The app isn't saved.
+func SetTokenExchangeAudiences(app *model.Application, audiences []string) error {
+	cleaned := model.StringSlice{}
+	for _, audience := range audiences {
+		audience = strings.TrimSpace(audience)
+		if audience == "" || audience == app.ClientID || slices.Contains(cleaned, audience) {
+			continue
+		}
+		if _, err := GetApplicationByClientID(audience); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %s", ErrInvalidExchangeAudience, audience)
+			}
+			return err
+		}
+		cleaned = append(cleaned, audience)
+	}
+	app.TokenExchangeAudiences = cleaned
+	return nil
+}
+
 func GetApplicationsByOwnerID(ownerID string) ([]model.Application, error) {
 	applications := []model.Application{}
 	if err := database.DB.Where("owner_id = ?", ownerID).Find(&applications).Error; err != nil {
@@ -92,19 +157,49 @@ func GetApplicationsByOwnerID(ownerID string) ([]model.Application, error) {
 }
 
 func CreateApplication(app model.Application) (model.Application, error) {
+	app, err := createApplication(database.DB, app)
+	if err != nil {
+		return model.Application{}, err
+	}
+	PopulateApplication(&app)
+	return app, nil
+}
+
+// CreateApplicationWithSecret creates app together with its first client
+// secret, labelled label, in one transaction, so an app is never left with
+// no way to authenticate. It returns the secret's plaintext, which isn't
+// stored.
+func CreateApplicationWithSecret(app model.Application, label, createdBy string) (model.Application, string, error) {
+	var secret string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if app, err = createApplication(tx, app); err != nil {
+			return err
+		}
+		_, secret, err = createApplicationSecret(tx, app.ID, label, createdBy, nil)
+		return err
+	})
+	if err != nil {
+		return model.Application{}, "", err
+	}
+	PopulateApplication(&app)
+	return app, secret, nil
+}
+
+// createApplication fills in the generated fields and inserts app in tx.
+func createApplication(tx *gorm.DB, app model.Application) (model.Application, error) {
 	if app.ID == "" {
 		app.ID = ulid.Make().Prefixed("app")
 	}
 	if app.ClientID == "" {
 		app.ClientID = generateSecret(12)
 	}
-	if app.ClientSecret == "" {
-		app.ClientSecret = generateSecret(64)
+	if app.TokenEndpointAuthMethod == "" {
+		app.TokenEndpointAuthMethod = model.ClientAuthMethodSecretBasic
 	}
-	if err := database.DB.Create(&app).Error; err != nil {
+	if err := tx.Create(&app).Error; err != nil {
 		return model.Application{}, err
 	}
-	PopulateApplication(&app)
 	return app, nil
 }
 
@@ -122,8 +217,63 @@ func PopulateApplication(app *model.Application) {
 		logger.SugarLogger.Errorf("Failed to get redirect URIs for application %s: %v", app.ID, err)
 	}
 	app.RedirectURIs = uris
+	allowed, pending, err := GetAllowedScopesForApplication(app.ID)
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to get allowed scopes for application %s: %v", app.ID, err)
+	}
+	app.AllowedScopes = allowed
+	app.PendingScopes = pending
+}
+
+// populateApplications loads redirect URIs and allowed scopes for a page
+// of applications, one query each.
+func populateApplications(apps []model.Application) {
+	if len(apps) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(apps))
+	for _, app := range apps {
+		ids = append(ids, app.ID)
+	}
+	uris := []model.ApplicationRedirectURI{}
+	if err := database.DB.Where("application_id IN ?", ids).Find(&uris).Error; err != nil {
+		logger.SugarLogger.Errorf("Failed to get redirect URIs for applications: %v", err)
+	}
+	byApp := map[string][]string{}
+	for _, uri := range uris {
+		byApp[uri.ApplicationID] = append(byApp[uri.ApplicationID], uri.RedirectURI)
+	}
+	allowed := []model.ApplicationAllowedScope{}
+	if err := database.DB.Where("application_id IN ?", ids).Order("scope").Find(&allowed).Error; err != nil {
+		logger.SugarLogger.Errorf("Failed to get allowed scopes for applications: %v", err)
+	}
+	scopesByApp := map[string][]string{}
+	pendingByApp := map[string][]string{}
+	for _, a := range allowed {
+		scopesByApp[a.ApplicationID] = append(scopesByApp[a.ApplicationID], a.Scope)
+		if a.PendingApproval {
+			pendingByApp[a.ApplicationID] = append(pendingByApp[a.ApplicationID], a.Scope)
+		}
+	}
+	for i := range apps {
+		apps[i].RedirectURIs = byApp[apps[i].ID]
+		if apps[i].RedirectURIs == nil {
+			apps[i].RedirectURIs = []string{}
+		}
+		apps[i].AllowedScopes = scopesByApp[apps[i].ID]
+		if apps[i].AllowedScopes == nil {
+			apps[i].AllowedScopes = []string{}
+		}
+		apps[i].PendingScopes = pendingByApp[apps[i].ID]
+		if apps[i].PendingScopes == nil {
+			apps[i].PendingScopes = []string{}
+		}
+	}
 }
 
+// DeleteApplication soft-deletes an application. Lookups by ID or client_id
+// stop finding it, so OAuth and SAML sign-ins to it fail; its redirect URIs,
+// group links, SAML SP, and service accounts wait for a restore or purge.
 func DeleteApplication(id string) error {
 	if err := database.DB.Where("id = ?", id).Delete(&model.Application{}).Error; err != nil {
 		return err
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/ulid-go"
+)
+
+var (
+	ErrInvalidRoleName = errors.New("role names must be 1-64 letters, digits, or . _ : -")
+	ErrRoleExists      = errors.New("the application already has a role with that name")
+	ErrEmptyRoleRule   = errors.New("every role rule needs at least one group")
+	ErrUnknownGroup    = errors.New("unknown group")
+)
+
+var roleNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,63}$`)
+
+// RoleAssignment is the set of roles one entity would receive from an
+// application, as listed by GetApplicationRoleAssignments.
+type RoleAssignment struct {
+	EntityID   string           `json:"entity_id"`
+	EntityType model.EntityType `json:"entity_type"`
+	UserID     string           `json:"user_id,omitempty"`
+	Username   string           `json:"username,omitempty"`
+	Name       string           `json:"name"`
+	Roles      []string         `json:"roles"`
+}
+
+func GetRolesForApplication(applicationID string) ([]model.ApplicationRole, error) {
+	roles := []model.ApplicationRole{}
+	if err := database.DB.Where("application_id = ?", applicationID).Order("name").Find(&roles).Error; err != nil {
+		return []model.ApplicationRole{}, err
+	}
+	return roles, nil
+}
+
+func GetApplicationRole(applicationID, roleID string) (model.ApplicationRole, error) {
+	var role model.ApplicationRole
+	if err := database.DB.Where("application_id = ? AND id = ?", applicationID, roleID).First(&role).Error; err != nil {
+		return model.ApplicationRole{}, err
+	}
+	return role, nil
+}
+
+// CreateApplicationRole validates the role, mints an ID, and inserts.
+func CreateApplicationRole(role model.ApplicationRole) (model.ApplicationRole, error) {
+	if err := validateApplicationRole(&role); err != nil {
+		return model.ApplicationRole{}, err
+	}
+	role.ID = ulid.Make().Prefixed("arole")
+	if err := database.DB.Create(&role).Error; err != nil {
+		return model.ApplicationRole{}, err
+	}
+	return role, nil
+}
+
+// UpdateApplicationRole replaces a role's name, description, and rules.
+// Tokens already issued keep the roles they were minted with.
+func UpdateApplicationRole(role model.ApplicationRole) (model.ApplicationRole, error) {
+	if err := validateApplicationRole(&role); err != nil {
+		return model.ApplicationRole{}, err
+	}
+	err := database.DB.Model(&model.ApplicationRole{}).
+		Where("application_id = ? AND id = ?", role.ApplicationID, role.ID).
+		Updates(map[string]interface{}{
+			"name":        role.Name,
+			"description": role.Description,
+			"rules":       role.Rules,
+		}).Error
+	if err != nil {
+		return model.ApplicationRole{}, err
+	}
+	return GetApplicationRole(role.ApplicationID, role.ID)
+}
+
+// DeleteApplicationRole scopes the delete to (applicationID, roleID) so a
+// tampered request can't drop another application's role.
+func DeleteApplicationRole(applicationID, roleID string) error {
+	return database.DB.
+		Where("application_id = ? AND id = ?", applicationID, roleID).
+		Delete(&model.ApplicationRole{}).Error
+}
+
+// validateApplicationRole checks the name is well-formed and unique on the
+// application, and that every rule names at least one existing group.
+// Duplicate group IDs within a rule are dropped in place.
+func validateApplicationRole(role *model.ApplicationRole) error {
+	if !roleNamePattern.MatchString(role.Name) {
+		return ErrInvalidRoleName
+	}
+	var existing int64
+	q := database.DB.Model(&model.ApplicationRole{}).Where("application_id = ? AND name = ?", role.ApplicationID, role.Name)
+	if role.ID != "" {
+		q = q.Where("id <> ?", role.ID)
+	}
+	if err := q.Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return ErrRoleExists
+	}
+
+	referenced := map[string]struct{}{}
+	for i, rule := range role.Rules {
+		seen := map[string]struct{}{}
+		groupIDs := make([]string, 0, len(rule.GroupIDs))
+		for _, id := range rule.GroupIDs {
+			if _, dup := seen[id]; dup {
+				continue
+			}
+			seen[id] = struct{}{}
+			referenced[id] = struct{}{}
+			groupIDs = append(groupIDs, id)
+		}
+		if len(groupIDs) == 0 {
+			return ErrEmptyRoleRule
+		}
+		role.Rules[i].GroupIDs = groupIDs
+	}
+	if len(referenced) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(referenced))
+	for id := range referenced {
+		ids = append(ids, id)
+	}
+	var found []string
+	if err := database.DB.Model(&model.Group{}).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+		return err
+	}
+	if len(found) != len(ids) {
+		known := make(map[string]struct{}, len(found))
+		for _, id := range found {
+			known[id] = struct{}{}
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			if _, ok := known[id]; !ok {
+				return fmt.Errorf("%w: %s", ErrUnknownGroup, id)
+			}
+		}
+	}
+	return nil
+}
+
+// EvaluateApplicationRoles returns the names of the roles held by an entity
+// that's an effective member of entityGroupIDs: ALL groups of a rule (AND),
+// ANY rule of a role (OR). A role with no rules is never held. Names come
+// back in the order of roles.
+func EvaluateApplicationRoles(roles []model.ApplicationRole, entityGroupIDs []string) []string {
+	held := make(map[string]struct{}, len(entityGroupIDs))
+	for _, g := range entityGroupIDs {
+		held[g] = struct{}{}
+	}
+	result := []string{}
+	for _, role := range roles {
+		for _, rule := range role.Rules {
+			if len(rule.GroupIDs) == 0 {
+				continue
+			}
+			matched := true
+			for _, g := range rule.GroupIDs {
+				if _, ok := held[g]; !ok {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				result = append(result, role.Name)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// GetRolesForEntity returns the names of the application's roles the entity
+// holds, evaluated against its effective group memberships (all sources,
+// plus groups inherited through child groups).
+func GetRolesForEntity(applicationID, entityID string) ([]string, error) {
+	roles, err := GetRolesForApplication(applicationID)
+	if err != nil {
+		return []string{}, err
+	}
+	if len(roles) == 0 {
+		return []string{}, nil
+	}
+	groupIDs, err := effectiveGroupIDs(entityID)
+	if err != nil {
+		return []string{}, err
+	}
+	return EvaluateApplicationRoles(roles, groupIDs), nil
+}
+
+// GetApplicationRoleAssignments lists every entity that holds at least one
+// of the application's roles, with the roles it holds. Only members of a
+// group some rule references (directly or through a child group) can hold
+// a role, so those are the only candidates evaluated. Users are sorted by
+// name; service accounts follow.
+func GetApplicationRoleAssignments(applicationID string) ([]RoleAssignment, error) {
+	roles, err := GetRolesForApplication(applicationID)
+	if err != nil {
+		return []RoleAssignment{}, err
+	}
+	h, err := loadGroupHierarchy()
+	if err != nil {
+		return []RoleAssignment{}, err
+	}
+	candidateGroups := map[string]struct{}{}
+	for _, role := range roles {
+		for _, rule := range role.Rules {
+			for _, g := range rule.GroupIDs {
+				candidateGroups[g] = struct{}{}
+				for _, d := range h.descendants(g) {
+					candidateGroups[d] = struct{}{}
+				}
+			}
+		}
+	}
+	if len(candidateGroups) == 0 {
+		return []RoleAssignment{}, nil
+	}
+	groupIDs := make([]string, 0, len(candidateGroups))
+	for g := range candidateGroups {
+		groupIDs = append(groupIDs, g)
+	}
+
+	liveGroups := database.DB.Model(&model.Group{}).Select("id")
+	var entityIDs []string
+	if err := database.DB.Model(&model.GroupMember{}).
+		Where("group_id IN ? AND group_id IN (?)", groupIDs, liveGroups).
+		Distinct().Pluck("entity_id", &entityIDs).Error; err != nil {
+		return []RoleAssignment{}, err
+	}
+	if len(entityIDs) == 0 {
+		return []RoleAssignment{}, nil
+	}
+	memberships := []model.GroupMember{}
+	if err := database.DB.Where("entity_id IN ? AND group_id IN (?)", entityIDs, liveGroups).Find(&memberships).Error; err != nil {
+		return []RoleAssignment{}, err
+	}
+	direct := make(map[string][]string, len(entityIDs))
+	for _, m := range memberships {
+		direct[m.EntityID] = append(direct[m.EntityID], m.GroupID)
+	}
+	users := []model.User{}
+	if err := database.DB.Where("entity_id IN ?", entityIDs).Find(&users).Error; err != nil {
+		return []RoleAssignment{}, err
+	}
+	accounts := []model.ServiceAccount{}
+	if err := database.DB.Where("entity_id IN ?", entityIDs).Find(&accounts).Error; err != nil {
+		return []RoleAssignment{}, err
+	}
+	// Entities that are neither a live user nor a service account (e.g. a
+	// soft-deleted user) can't sign in, so they're left off the list.
+	byEntity := make(map[string]RoleAssignment, len(users)+len(accounts))
+	for _, u := range users {
+		byEntity[u.EntityID] = RoleAssignment{
+			EntityID:   u.EntityID,
+			EntityType: model.EntityTypeUser,
+			UserID:     u.ID,
+			Username:   u.Username,
+			Name:       strings.TrimSpace(u.FirstName + " " + u.LastName),
+		}
+	}
+	for _, sa := range accounts {
+		byEntity[sa.EntityID] = RoleAssignment{
+			EntityID:   sa.EntityID,
+			EntityType: model.EntityTypeServiceAccount,
+			Name:       sa.Name,
+		}
+	}
+
+	assignments := []RoleAssignment{}
+	for _, entityID := range entityIDs {
+		a, ok := byEntity[entityID]
+		if !ok {
+			continue
+		}
+		held := direct[entityID]
+		for g := range h.ancestors(held) {
+			held = append(held, g)
+		}
+		a.Roles = EvaluateApplicationRoles(roles, held)
+		if len(a.Roles) == 0 {
+			continue
+		}
+		assignments = append(assignments, a)
+	}
+	sort.Slice(assignments, func(i, j int) bool {
+		a, b := assignments[i], assignments[j]
+		if a.EntityType != b.EntityType {
+			return a.EntityType == model.EntityTypeUser
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.EntityID < b.EntityID
+	})
+	return assignments, nil
+}
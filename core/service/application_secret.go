@@ -0,0 +1,129 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/ulid-go"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidClientCredentials is returned by VerifyClientSecret for an
+// unknown client, a wrong secret, or an expired one. The cases aren't
+// distinguished so callers can't probe which client IDs exist.
+var ErrInvalidClientCredentials = errors.New("invalid client credentials")
+
+// DefaultSecretRotationGrace is how long the previous secrets stay valid
+// after a rotation when the caller doesn't say.
+const DefaultSecretRotationGrace = 24 * time.Hour
+
+// clientSecretHintLength is how many trailing characters of a secret are
+// kept in the clear.
+const clientSecretHintLength = 4
+
+// CreateApplicationSecret mints a new secret for the application and
+// returns the stored row along with the plaintext, which isn't kept and
+// can't be read back. A nil expiresAt never expires.
+func CreateApplicationSecret(appID, label, createdBy string, expiresAt *time.Time) (model.ApplicationSecret, string, error) {
+	return createApplicationSecret(database.DB, appID, label, createdBy, expiresAt)
+}
+
+func createApplicationSecret(tx *gorm.DB, appID, label, createdBy string, expiresAt *time.Time) (model.ApplicationSecret, string, error) {
+	secret, raw := newApplicationSecret(appID, label, createdBy)
+	secret.ExpiresAt = expiresAt
+	if err := tx.Create(&secret).Error; err != nil {
+		return model.ApplicationSecret{}, "", err
+	}
+	return secret, raw, nil
+}
+
+func newApplicationSecret(appID, label, createdBy string) (model.ApplicationSecret, string) {
+	raw := generateSecret(64)
+	return model.ApplicationSecret{
+		ID:            ulid.Make().Prefixed("csec"),
+		ApplicationID: appID,
+		Label:         label,
+		SecretHash:    hashOpaqueToken(raw),
+		Hint:          raw[len(raw)-clientSecretHintLength:],
+		CreatedBy:     createdBy,
+	}, raw
+}
+
+// ListApplicationSecrets returns every secret on the application, expired
+// ones included, newest first.
+func ListApplicationSecrets(appID string) ([]model.ApplicationSecret, error) {
+	secrets := []model.ApplicationSecret{}
+	if err := database.DB.Where("application_id = ?", appID).Order("created_at DESC").Find(&secrets).Error; err != nil {
+		return []model.ApplicationSecret{}, err
+	}
+	return secrets, nil
+}
+
+// RotateApplicationSecret creates a new secret and schedules every other
+// active secret on the application to expire after grace. Secrets already
+// due to expire sooner keep their earlier expiry. Both happen in one
+// transaction.
+func RotateApplicationSecret(appID, label, createdBy string, grace time.Duration) (model.ApplicationSecret, string, error) {
+	secret, raw := newApplicationSecret(appID, label, createdBy)
+	cutoff := time.Now().Add(grace)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.ApplicationSecret{}).
+			Where("application_id = ? AND (expires_at IS NULL OR expires_at > ?)", appID, cutoff).
+			Update("expires_at", cutoff).Error; err != nil {
+			return fmt.Errorf("expire previous secrets: %w", err)
+		}
+		return tx.Create(&secret).Error
+	})
+	if err != nil {
+		return model.ApplicationSecret{}, "", err
+	}
+	return secret, raw, nil
+}
+
+// RevokeApplicationSecret deletes one of the application's secrets; it
+// stops working immediately. Returns gorm.ErrRecordNotFound if the secret
+// doesn't belong to the application.
+func RevokeApplicationSecret(appID, secretID string) error {
+	result := database.DB.Where("id = ? AND application_id = ?", secretID, appID).Delete(&model.ApplicationSecret{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// VerifyClientSecret checks a client_id/client_secret pair against every
+// unexpired secret on the application and records when the matching one
+// was last used. Applications registered for private_key_jwt never accept
+// a secret. Returns the application on success.
+func VerifyClientSecret(clientID, raw string) (model.Application, error) {
+	app, err := GetApplicationByClientID(clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Application{}, ErrInvalidClientCredentials
+		}
+		return model.Application{}, err
+	}
+	if app.UsesPrivateKeyJWT() {
+		return model.Application{}, ErrInvalidClientCredentials
+	}
+	now := time.Now()
+	var secret model.ApplicationSecret
+	if err := database.DB.
+		Where("application_id = ? AND secret_hash = ? AND (expires_at IS NULL OR expires_at > ?)", app.ID, hashOpaqueToken(raw), now).
+		First(&secret).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Application{}, ErrInvalidClientCredentials
+		}
+		return model.Application{}, err
+	}
+	if err := database.DB.Model(&secret).Update("last_used_at", now).Error; err != nil {
+		return model.Application{}, fmt.Errorf("record secret use: %w", err)
+	}
+	return app, nil
+}
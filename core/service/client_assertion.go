@@ -0,0 +1,478 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/config"
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ClientAssertionType is the only client_assertion_type RFC 7523 defines.
+const ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// ClientAssertionSigningAlgs are the JWS algorithms accepted on client
+// assertions. No HMAC: the point is that Sentinel never holds a secret.
+var ClientAssertionSigningAlgs = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+var (
+	// ErrInvalidClientKeys is returned when an application's key
+	// registration is malformed. The API layer maps it to 400.
+	ErrInvalidClientKeys = errors.New("invalid client key registration")
+	// ErrInvalidClientAssertion covers every way an assertion can fail.
+	// The wrapped message says which, for logs; clients just see
+	// invalid_client.
+	ErrInvalidClientAssertion = errors.New("invalid client assertion")
+)
+
+// maxClientAssertionLifetime bounds how far in the future an assertion's
+// exp may be. It also bounds how long a jti has to be remembered.
+const maxClientAssertionLifetime = time.Hour
+
+// clientAssertionLeeway absorbs clock skew between Sentinel and clients.
+const clientAssertionLeeway = 30 * time.Second
+
+// ValidateClientAuthentication checks an application's auth method and key
+// registration, normalizing the method in place. private_key_jwt needs
+// exactly one of an inline JWKS with at least one usable key, or an https
+// jwks_uri. Keys may be registered ahead of switching methods.
+func ValidateClientAuthentication(app *model.Application) error {
+	switch app.TokenEndpointAuthMethod {
+	case "":
+		app.TokenEndpointAuthMethod = model.ClientAuthMethodSecretBasic
+	case model.ClientAuthMethodSecretBasic, model.ClientAuthMethodSecretPost, model.ClientAuthMethodPrivateKeyJWT:
+	default:
+		return fmt.Errorf("%w: unsupported token_endpoint_auth_method %q", ErrInvalidClientKeys, app.TokenEndpointAuthMethod)
+	}
+	if len(app.JWKS) > 0 && app.JWKSURI != "" {
+		return fmt.Errorf("%w: set jwks or jwks_uri, not both", ErrInvalidClientKeys)
+	}
+	if len(app.JWKS) > 0 {
+		keys, err := parseClientJWKS(app.JWKS)
+		if err != nil {
+			return fmt.Errorf("%w: jwks: %s", ErrInvalidClientKeys, err)
+		}
+		if len(keys) == 0 {
+			return fmt.Errorf("%w: jwks has no usable signing keys", ErrInvalidClientKeys)
+		}
+	}
+	if app.JWKSURI != "" {
+		u, err := url.Parse(app.JWKSURI)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("%w: jwks_uri must be an absolute https URL", ErrInvalidClientKeys)
+		}
+	}
+	if app.UsesPrivateKeyJWT() && len(app.JWKS) == 0 && app.JWKSURI == "" {
+		return fmt.Errorf("%w: private_key_jwt needs jwks or jwks_uri", ErrInvalidClientKeys)
+	}
+	return nil
+}
+
+// VerifyClientAssertion authenticates a client by an RFC 7523 assertion:
+// a JWT signed with one of the application's registered keys, whose iss and
+// sub are its client_id, whose aud names Sentinel's issuer or the endpoint
+// it was presented to, and whose jti hasn't been seen before. Returns the
+// application on success.
+func VerifyClientAssertion(assertion, endpoint string) (model.Application, error) {
+	unverified := jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(assertion, &unverified); err != nil {
+		return model.Application{}, fmt.Errorf("%w: malformed jwt", ErrInvalidClientAssertion)
+	}
+	clientID := unverified.Subject
+	if clientID == "" || unverified.Issuer != clientID {
+		return model.Application{}, fmt.Errorf("%w: iss and sub must both be the client_id", ErrInvalidClientAssertion)
+	}
+	app, err := GetApplicationByClientID(clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Application{}, fmt.Errorf("%w: unknown client", ErrInvalidClientAssertion)
+		}
+		return model.Application{}, err
+	}
+	if !app.UsesPrivateKeyJWT() {
+		return model.Application{}, fmt.Errorf("%w: client is not registered for private_key_jwt", ErrInvalidClientAssertion)
+	}
+
+	claims := jwt.RegisteredClaims{}
+	_, err = jwt.ParseWithClaims(assertion, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return clientVerificationKeys(app, kid, token.Method.Alg())
+	},
+		jwt.WithValidMethods(ClientAssertionSigningAlgs),
+		jwt.WithIssuer(clientID),
+		jwt.WithSubject(clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clientAssertionLeeway),
+	)
+	if err != nil {
+		return model.Application{}, fmt.Errorf("%w: %s", ErrInvalidClientAssertion, err)
+	}
+	if !assertionAudienceAllowed(claims.Audience, endpoint) {
+		return model.Application{}, fmt.Errorf("%w: aud must be the issuer or the endpoint", ErrInvalidClientAssertion)
+	}
+	if claims.ID == "" {
+		return model.Application{}, fmt.Errorf("%w: jti is required", ErrInvalidClientAssertion)
+	}
+	if claims.ExpiresAt.Time.After(time.Now().Add(maxClientAssertionLifetime)) {
+		return model.Application{}, fmt.Errorf("%w: exp is too far in the future", ErrInvalidClientAssertion)
+	}
+	if err := recordClientAssertionJTI(clientID, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return model.Application{}, err
+	}
+	return app, nil
+}
+
+func assertionAudienceAllowed(aud jwt.ClaimStrings, endpoint string) bool {
+	for _, a := range aud {
+		if a == config.Issuer || (endpoint != "" && a == endpoint) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordClientAssertionJTI stores the jti, failing if the client already
+// used it. Expired rows for the client are cleared first, which keeps the
+// table bounded without a separate sweeper.
+func recordClientAssertionJTI(clientID, jti string, expiresAt time.Time) error {
+	if err := database.DB.Where("client_id = ? AND expires_at < ?", clientID, time.Now().Add(-clientAssertionLeeway)).
+		Delete(&model.ClientAssertionJTI{}).Error; err != nil {
+		return fmt.Errorf("clear expired assertion jtis: %w", err)
+	}
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.ClientAssertionJTI{
+		ClientID:  clientID,
+		JTI:       jti,
+		ExpiresAt: expiresAt,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("record assertion jti: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: jti has already been used", ErrInvalidClientAssertion)
+	}
+	return nil
+}
+
+// clientKey is one parsed signing key from a client's JWK Set.
+type clientKey struct {
+	kid string
+	alg string
+	key interface{}
+}
+
+// clientVerificationKeys returns the application's keys that could have
+// produced a signature with alg: matching key type, matching alg if the key
+// pins one, and matching kid if the assertion names one. A kid miss on a
+// jwks_uri triggers one refetch, since it usually means the client rotated.
+func clientVerificationKeys(app model.Application, kid, alg string) (jwt.VerificationKeySet, error) {
+	keys, err := loadClientKeys(app, false)
+	if err != nil {
+		return jwt.VerificationKeySet{}, err
+	}
+	set := filterClientKeys(keys, kid, alg)
+	if len(set.Keys) == 0 && kid != "" && app.JWKSURI != "" {
+		if keys, err = loadClientKeys(app, true); err != nil {
+			return jwt.VerificationKeySet{}, err
+		}
+		set = filterClientKeys(keys, kid, alg)
+	}
+	if len(set.Keys) == 0 {
+		return jwt.VerificationKeySet{}, errors.New("no registered key matches the assertion")
+	}
+	return set, nil
+}
+
+func filterClientKeys(keys []clientKey, kid, alg string) jwt.VerificationKeySet {
+	set := jwt.VerificationKeySet{}
+	for _, k := range keys {
+		if kid != "" && k.kid != kid {
+			continue
+		}
+		if k.alg != "" && k.alg != alg {
+			continue
+		}
+		switch k.key.(type) {
+		case *rsa.PublicKey:
+			if !strings.HasPrefix(alg, "RS") && !strings.HasPrefix(alg, "PS") {
+				continue
+			}
+		case *ecdsa.PublicKey:
+			if !strings.HasPrefix(alg, "ES") {
+				continue
+			}
+		}
+		set.Keys = append(set.Keys, k.key)
+	}
+	return set
+}
+
+func loadClientKeys(app model.Application, refresh bool) ([]clientKey, error) {
+	if len(app.JWKS) > 0 {
+		return parseClientJWKS(app.JWKS)
+	}
+	if app.JWKSURI != "" {
+		return fetchClientJWKS(app.JWKSURI, refresh)
+	}
+	return nil, errors.New("client has no registered keys")
+}
+
+// jwksCacheTTL is how long a fetched jwks_uri document is reused.
+// jwksRefetchInterval rate-limits the refetch on a kid miss, so a client
+// sending garbage kids can't make Sentinel hammer its key server.
+// jwksCacheMaxEntries bounds the cache, since anyone with an initial access
+// token can register a jwks_uri.
+const (
+	jwksCacheTTL        = 5 * time.Minute
+	jwksRefetchInterval = 30 * time.Second
+	jwksMaxBytes        = 64 << 10
+	jwksCacheMaxEntries = 1024
+)
+
+type cachedJWKS struct {
+	keys      []clientKey
+	fetchedAt time.Time
+}
+
+var (
+	jwksCache   = map[string]cachedJWKS{}
+	jwksCacheMu sync.Mutex
+	jwksClient  = newJWKSClient()
+)
+
+// errJWKSAddressNotPublic is returned when a jwks_uri resolves to an
+// address Sentinel must not be made to call: loopback, private networks,
+// link-local (cloud metadata lives there), and the like.
+var errJWKSAddressNotPublic = errors.New("jwks_uri does not resolve to a public address")
+
+// jwksBlockedPrefixes are non-public ranges net.IP's predicates don't cover.
+var jwksBlockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address.
+func isPublicIP(ip net.IP) bool {
+	if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range jwksBlockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// newJWKSClient returns the client jwks_uri documents are fetched with. The
+// address check runs in the dialer, on the IP actually being connected to,
+// so neither a DNS answer that changes after validation nor a redirect can
+// point the fetch at an internal service. Proxies are ignored for the same
+// reason.
+func newJWKSClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicIP(net.ParseIP(host)) {
+				return errJWKSAddressNotPublic
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return errors.New("jwks_uri redirected off https")
+			}
+			if len(via) >= 3 {
+				return errors.New("jwks_uri redirected too many times")
+			}
+			return nil
+		},
+	}
+}
+
+func fetchClientJWKS(uri string, refresh bool) ([]clientKey, error) {
+	jwksCacheMu.Lock()
+	cached, ok := jwksCache[uri]
+	jwksCacheMu.Unlock()
+	age := time.Since(cached.fetchedAt)
+	if ok && age < jwksCacheTTL && (!refresh || age < jwksRefetchInterval) {
+		return cached.keys, nil
+	}
+
+	resp, err := jwksClient.Get(uri)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks_uri: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks_uri: status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, jwksMaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read jwks_uri: %w", err)
+	}
+	if len(body) > jwksMaxBytes {
+		return nil, fmt.Errorf("read jwks_uri: document is larger than %d bytes", jwksMaxBytes)
+	}
+	keys, err := parseClientJWKS(body)
+	if err != nil {
+		return nil, fmt.Errorf("parse jwks_uri: %w", err)
+	}
+	storeJWKS(uri, keys, time.Now())
+	return keys, nil
+}
+
+// storeJWKS caches keys for uri. When the cache is full, expired documents
+// are dropped first and then the oldest, so it never holds more than
+// jwksCacheMaxEntries.
+func storeJWKS(uri string, keys []clientKey, now time.Time) {
+	jwksCacheMu.Lock()
+	defer jwksCacheMu.Unlock()
+	if _, ok := jwksCache[uri]; !ok && len(jwksCache) >= jwksCacheMaxEntries {
+		oldest := ""
+		for cachedURI, cached := range jwksCache {
+			if now.Sub(cached.fetchedAt) >= jwksCacheTTL {
+				delete(jwksCache, cachedURI)
+				continue
+			}
+			if oldest == "" || cached.fetchedAt.Before(jwksCache[oldest].fetchedAt) {
+				oldest = cachedURI
+			}
+		}
+		if len(jwksCache) >= jwksCacheMaxEntries {
+			delete(jwksCache, oldest)
+		}
+	}
+	jwksCache[uri] = cachedJWKS{keys: keys, fetchedAt: now}
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	D   string `json:"d"`
+}
+
+// parseClientJWKS reads the RSA and EC signature keys out of a JWK Set.
+// Encryption keys and unknown key types are skipped; a private key is an
+// error, since it means the client pasted the wrong half.
+func parseClientJWKS(raw []byte) ([]clientKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("not a JWK Set: %w", err)
+	}
+	keys := make([]clientKey, 0, len(set.Keys))
+	for i, jwk := range set.Keys {
+		if jwk.D != "" {
+			return nil, fmt.Errorf("keys[%d] is a private key", i)
+		}
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		var key interface{}
+		var err error
+		switch jwk.Kty {
+		case "RSA":
+			key, err = parseRSAJWK(jwk)
+		case "EC":
+			key, err = parseECJWK(jwk)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("keys[%d]: %w", i, err)
+		}
+		keys = append(keys, clientKey{kid: jwk.Kid, alg: jwk.Alg, key: key})
+	}
+	return keys, nil
+}
+
+func parseRSAJWK(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil || len(n) == 0 {
+		return nil, errors.New("invalid RSA modulus")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	if key.N.BitLen() < 2048 {
+		return nil, errors.New("RSA keys must be at least 2048 bits")
+	}
+	return key, nil
+}
+
+func parseECJWK(jwk jsonWebKey) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch jwk.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return nil, errors.New("invalid EC x coordinate")
+	}
+	y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+	if err != nil {
+		return nil, errors.New("invalid EC y coordinate")
+	}
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, errors.New("EC point is not on the curve")
+	}
+	return key, nil
+}
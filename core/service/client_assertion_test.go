@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::248": true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"10.1.2.3":             false,
+		"172.16.0.1":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"fe80::1":              false,
+		"fd00::1":              false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"::":                   false,
+		"224.0.0.1":            false,
+		"::ffff:127.0.0.1":     false,
+		"::ffff:10.0.0.1":      false,
+		"64:ff9b::a00:1":       false,
+	}
+	for addr, want := range tests {
+		if got := isPublicIP(net.ParseIP(addr)); got != want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestFetchClientJWKSRefusesInternalAddresses(t *testing.T) {
+	_, jwks := newTestClientKey(t, "k1")
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(jwks)
+	}))
+	defer srv.Close()
+
+	_, err := fetchClientJWKS(srv.URL+"/jwks.json", false)
+	if !errors.Is(err, errJWKSAddressNotPublic) {
+		t.Errorf("fetchClientJWKS from loopback: error = %v, want errJWKSAddressNotPublic", err)
+	}
+}
+
+func TestFetchClientJWKSSizeCap(t *testing.T) {
+	_, jwks := newTestClientKey(t, "k1")
+	big := `{"keys":[],"padding":"` + strings.Repeat("a", jwksMaxBytes) + `"}`
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/big.json" {
+			_, _ = w.Write([]byte(big))
+			return
+		}
+		_, _ = w.Write(jwks)
+	}))
+	defer srv.Close()
+	// The test server is on loopback, so skip the address check here.
+	prev := jwksClient
+	jwksClient = srv.Client()
+	t.Cleanup(func() { jwksClient = prev })
+
+	if keys, err := fetchClientJWKS(srv.URL+"/jwks.json", false); err != nil || len(keys) != 1 {
+		t.Errorf("fetchClientJWKS = %d keys, %v, want the one key", len(keys), err)
+	}
+	if _, err := fetchClientJWKS(srv.URL+"/big.json", false); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("fetchClientJWKS of an oversized document: error = %v, want the size cap", err)
+	}
+}
+
+func TestStoreJWKSBoundsCache(t *testing.T) {
+	jwksCacheMu.Lock()
+	prev := jwksCache
+	jwksCache = map[string]cachedJWKS{}
+	jwksCacheMu.Unlock()
+	t.Cleanup(func() {
+		jwksCacheMu.Lock()
+		jwksCache = prev
+		jwksCacheMu.Unlock()
+	})
+
+	now := time.Now()
+	for i := 0; i < jwksCacheMaxEntries; i++ {
+		storeJWKS(fmt.Sprintf("https://client%d.example.com/jwks", i), nil, now.Add(time.Duration(i)*time.Millisecond))
+	}
+	storeJWKS("https://new.example.com/jwks", nil, now.Add(time.Second))
+	if len(jwksCache) != jwksCacheMaxEntries {
+		t.Errorf("cache holds %d documents, want at most %d", len(jwksCache), jwksCacheMaxEntries)
+	}
+	if _, ok := jwksCache["https://client0.example.com/jwks"]; ok {
+		t.Error("the oldest document survived eviction")
+	}
+	if _, ok := jwksCache["https://new.example.com/jwks"]; !ok {
+		t.Error("the new document wasn't cached")
+	}
+
+	// Once the rest have expired, they all go to make room.
+	storeJWKS("https://later.example.com/jwks", nil, now.Add(jwksCacheTTL+2*time.Second))
+	if len(jwksCache) != 1 {
+		t.Errorf("cache holds %d documents after expiry, want 1", len(jwksCache))
+	}
+}
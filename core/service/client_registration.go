@@ -0,0 +1,337 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/ulid-go"
+	"gorm.io/gorm"
+)
+
+// Errors for dynamic client registration. The metadata errors' messages are
+// the RFC 7591 error codes, with the reason after them, so the registration
+// endpoint can return them as-is. The token errors don't say which check
+// failed.
+var (
+	ErrInvalidClientMetadata          = errors.New("invalid_client_metadata")
+	ErrInvalidRedirectURI             = errors.New("invalid_redirect_uri")
+	ErrInvalidInitialAccessToken      = errors.New("invalid initial access token")
+	ErrInvalidRegistrationAccessToken = errors.New("invalid registration access token")
+)
+
+// registrableGrantTypes are the grant types a registered client may list.
+// They aren't stored: every client can use all of them.
+var registrableGrantTypes = []string{
+	"authorization_code",
+	"refresh_token",
+	"urn:ietf:params:oauth:grant-type:device_code",
+	"urn:ietf:params:oauth:grant-type:token-exchange",
+}
+
+// ClientMetadata is the subset of RFC 7591 client metadata Sentinel
+// understands. client_uri and logo_uri map to the app's launch and icon
+// URLs; scope, space-separated, becomes its scope allow-list.
+type ClientMetadata struct {
+	RedirectURIs            []string            `json:"redirect_uris"`
+	ClientName              string              `json:"client_name"`
+	ClientURI               string              `json:"client_uri"`
+	LogoURI                 string              `json:"logo_uri"`
+	TokenEndpointAuthMethod string              `json:"token_endpoint_auth_method"`
+	JWKS                    model.JSONWebKeySet `json:"jwks"`
+	JWKSURI                 string              `json:"jwks_uri"`
+	GrantTypes              []string            `json:"grant_types"`
+	ResponseTypes           []string            `json:"response_types"`
+	Scope                   string              `json:"scope"`
+}
+
+// RegisteredClient is a newly registered application with the credentials
+// that are only readable once: its client secret (unless it uses
+// private_key_jwt) and its registration access token.
+type RegisteredClient struct {
+	Application             model.Application
+	ClientSecret            string
+	RegistrationAccessToken string
+}
+
+// CreateInitialAccessToken mints a token that can register up to
+// maxRegistrations clients (zero for no limit) until expiresAt (nil for
+// never). It returns the stored row along with the plaintext, which isn't
+// kept.
+func CreateInitialAccessToken(label, createdBy string, maxRegistrations int, expiresAt *time.Time) (model.InitialAccessToken, string, error) {
+	raw, err := generateOpaqueToken()
+	if err != nil {
+		return model.InitialAccessToken{}, "", err
+	}
+	token := model.InitialAccessToken{
+		ID:               ulid.Make().Prefixed("iat"),
+		Label:            label,
+		TokenHash:        hashOpaqueToken(raw),
+		MaxRegistrations: maxRegistrations,
+		ExpiresAt:        expiresAt,
+		CreatedBy:        createdBy,
+	}
+	if err := database.DB.Create(&token).Error; err != nil {
+		return model.InitialAccessToken{}, "", err
+	}
+	return token, raw, nil
+}
+
+// ListInitialAccessTokens returns every initial access token, revoked and
+// expired ones included, newest first.
+func ListInitialAccessTokens() ([]model.InitialAccessToken, error) {
+	tokens := []model.InitialAccessToken{}
+	if err := database.DB.Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return []model.InitialAccessToken{}, err
+	}
+	return tokens, nil
+}
+
+// RevokeInitialAccessToken stops a token from registering more clients.
+// Clients it already registered are unaffected.
+func RevokeInitialAccessToken(id string) error {
+	result := database.DB.Model(&model.InitialAccessToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RegisterClient creates an application from RFC 7591 metadata on the
+// strength of an initial access token. The app is owned by whoever minted
+// the token and starts pending approval. The token is checked before the
+// metadata, so it can't be probed without one, and its use is counted
+// before anything is created, so concurrent registrations can't exceed its
+// limit.
+func RegisterClient(initialAccessToken string, metadata ClientMetadata) (RegisteredClient, error) {
+	var token model.InitialAccessToken
+	err := database.DB.
+		Where("token_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", hashOpaqueToken(initialAccessToken), time.Now()).
+		First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return RegisteredClient{}, ErrInvalidInitialAccessToken
+		}
+		return RegisteredClient{}, err
+	}
+	app, err := applyClientMetadata(model.Application{}, metadata)
+	if err != nil {
+		return RegisteredClient{}, err
+	}
+	allowedScopes := strings.Fields(metadata.Scope)
+	if err := validateAllowedScopes(allowedScopes); err != nil {
+		return RegisteredClient{}, fmt.Errorf("%w: %s", ErrInvalidClientMetadata, err)
+	}
+
+	registrationAccessToken, err := generateOpaqueToken()
+	if err != nil {
+		return RegisteredClient{}, err
+	}
+	app.OwnerID = token.CreatedBy
+	app.PendingApproval = true
+	app.RegistrationTokenID = token.ID
+	app.RegistrationAccessTokenHash = hashOpaqueToken(registrationAccessToken)
+	registered := RegisteredClient{RegistrationAccessToken: registrationAccessToken}
+
+	// The registration only counts against the token if the client is
+	// created in full, so a failure part way leaves neither behind.
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.InitialAccessToken{}).
+			Where("id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", token.ID, time.Now()).
+			Where("max_registrations = 0 OR registrations < max_registrations").
+			Update("registrations", gorm.Expr("registrations + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidInitialAccessToken
+		}
+		var err error
+		if app, err = createApplication(tx, app); err != nil {
+			return err
+		}
+		if err := replaceRegisteredClientSettings(tx, app.ID, metadata.RedirectURIs, allowedScopes); err != nil {
+			return err
+		}
+		if !app.UsesPrivateKeyJWT() {
+			if _, registered.ClientSecret, err = createApplicationSecret(tx, app.ID, "Registration secret", token.CreatedBy, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return RegisteredClient{}, err
+	}
+	PopulateApplication(&app)
+	registered.Application = app
+	return registered, nil
+}
+
+// GetRegisteredClient returns the application with the given client_id if
+// registrationAccessToken is its registration access token. An unknown
+// client and a wrong token look the same.
+func GetRegisteredClient(clientID, registrationAccessToken string) (model.Application, error) {
+	var app model.Application
+	err := database.DB.
+		Where("client_id = ? AND registration_access_token_hash = ?", clientID, hashOpaqueToken(registrationAccessToken)).
+		First(&app).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Application{}, ErrInvalidRegistrationAccessToken
+		}
+		return model.Application{}, err
+	}
+	PopulateApplication(&app)
+	return app, nil
+}
+
+// UpdateRegisteredClient replaces a registered client's metadata, as RFC
+// 7592 requires: fields left out are cleared, not kept. Approval is
+// unaffected.
+func UpdateRegisteredClient(clientID, registrationAccessToken string, metadata ClientMetadata) (model.Application, error) {
+	app, err := GetRegisteredClient(clientID, registrationAccessToken)
+	if err != nil {
+		return model.Application{}, err
+	}
+	if app, err = applyClientMetadata(app, metadata); err != nil {
+		return model.Application{}, err
+	}
+	allowedScopes := strings.Fields(metadata.Scope)
+	if err := validateAllowedScopes(allowedScopes); err != nil {
+		return model.Application{}, fmt.Errorf("%w: %s", ErrInvalidClientMetadata, err)
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&app).Error; err != nil {
+			return err
+		}
+		return replaceRegisteredClientSettings(tx, app.ID, metadata.RedirectURIs, allowedScopes)
+	})
+	if err != nil {
+		return model.Application{}, err
+	}
+	PopulateApplication(&app)
+	return app, nil
+}
+
+// DeleteRegisteredClient soft-deletes a registered client, like deleting
+// it in the console.
+func DeleteRegisteredClient(clientID, registrationAccessToken string) error {
+	app, err := GetRegisteredClient(clientID, registrationAccessToken)
+	if err != nil {
+		return err
+	}
+	return DeleteApplication(app.ID)
+}
+
+// ApproveApplication lifts the pending-approval scope restriction from a
+// dynamically registered client.
+func ApproveApplication(id string) (model.Application, error) {
+	app, err := GetApplicationByID(id)
+	if err != nil {
+		return model.Application{}, err
+	}
+	if err := database.DB.Model(&app).Update("pending_approval", false).Error; err != nil {
+		return model.Application{}, err
+	}
+	app.PendingApproval = false
+	return app, nil
+}
+
+// applyClientMetadata validates metadata and copies it onto app. Redirect
+// URIs and scopes live in their own tables and are left to the caller.
+func applyClientMetadata(app model.Application, metadata ClientMetadata) (model.Application, error) {
+	name := strings.TrimSpace(metadata.ClientName)
+	if name == "" {
+		return app, fmt.Errorf("%w: client_name is required", ErrInvalidClientMetadata)
+	}
+	for _, grantType := range metadata.GrantTypes {
+		if !slices.Contains(registrableGrantTypes, grantType) {
+			return app, fmt.Errorf("%w: unsupported grant_type %q", ErrInvalidClientMetadata, grantType)
+		}
+	}
+	for _, responseType := range metadata.ResponseTypes {
+		if responseType != "code" {
+			return app, fmt.Errorf("%w: unsupported response_type %q", ErrInvalidClientMetadata, responseType)
+		}
+	}
+	usesCode := len(metadata.GrantTypes) == 0 || slices.Contains(metadata.GrantTypes, "authorization_code")
+	if usesCode && len(metadata.RedirectURIs) == 0 {
+		return app, fmt.Errorf("%w: redirect_uris is required for the authorization_code grant", ErrInvalidRedirectURI)
+	}
+	for _, uri := range metadata.RedirectURIs {
+		if err := validateRegisteredRedirectURI(uri); err != nil {
+			return app, err
+		}
+	}
+	for field, value := range map[string]string{"client_uri": metadata.ClientURI, "logo_uri": metadata.LogoURI} {
+		if u, err := url.Parse(value); value != "" && (err != nil || u.Scheme != "https" || u.Host == "") {
+			return app, fmt.Errorf("%w: %s must be an absolute https URL", ErrInvalidClientMetadata, field)
+		}
+	}
+
+	app.Name = name
+	app.LaunchURL = metadata.ClientURI
+	app.IconURL = metadata.LogoURI
+	app.TokenEndpointAuthMethod = metadata.TokenEndpointAuthMethod
+	app.JWKS = metadata.JWKS
+	app.JWKSURI = strings.TrimSpace(metadata.JWKSURI)
+	if err := ValidateClientAuthentication(&app); err != nil {
+		return app, fmt.Errorf("%w: %s", ErrInvalidClientMetadata, strings.TrimPrefix(err.Error(), ErrInvalidClientKeys.Error()+": "))
+	}
+	return app, nil
+}
+
+// validateRegisteredRedirectURI holds self-registered clients to a stricter
+// rule than the console: https, or http to a loopback address for tools
+// run locally. Fragments are never allowed.
+func validateRegisteredRedirectURI(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.Fragment != "" {
+		return fmt.Errorf("%w: %q must be an absolute URL without a fragment", ErrInvalidRedirectURI, raw)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q must use https, or http to a loopback address", ErrInvalidRedirectURI, raw)
+}
+
+// replaceRegisteredClientSettings sets a registered client's redirect URIs
+// and scope allow-list to exactly the given ones, in tx. Other
+// applications' scopes wait for their owners' approval, as for any client.
+func replaceRegisteredClientSettings(tx *gorm.DB, appID string, redirectURIs []string, scopes []string) error {
+	if err := tx.Where("application_id = ?", appID).Delete(&model.ApplicationRedirectURI{}).Error; err != nil {
+		return fmt.Errorf("set redirect uris: %w", err)
+	}
+	seen := map[string]bool{}
+	for _, uri := range redirectURIs {
+		if seen[uri] {
+			continue
+		}
+		seen[uri] = true
+		if err := tx.Create(&model.ApplicationRedirectURI{ApplicationID: appID, RedirectURI: uri}).Error; err != nil {
+			return fmt.Errorf("set redirect uris: %w", err)
+		}
+	}
+	if err := replaceAllowedScopes(tx, appID, scopes, nil); err != nil {
+		return fmt.Errorf("set allowed scopes: %w", err)
+	}
+	return nil
+}
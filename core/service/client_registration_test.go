@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gaucho-racing/sentinel/core/model"
+)
+
+func TestValidateRegisteredRedirectURI(t *testing.T) {
+	valid := []string{
+		"https://app.example.com/callback",
+		"http://localhost:8080/callback",
+		"http://127.0.0.1/callback",
+		"http://[::1]:3000/cb",
+	}
+	for _, uri := range valid {
+		if err := validateRegisteredRedirectURI(uri); err != nil {
+			t.Errorf("validateRegisteredRedirectURI(%q) = %v, want nil", uri, err)
+		}
+	}
+	invalid := []string{
+		"http://app.example.com/callback",
+		"https://app.example.com/callback#frag",
+		"/callback",
+		"com.example.app:/callback",
+		"javascript:alert(1)",
+	}
+	for _, uri := range invalid {
+		if err := validateRegisteredRedirectURI(uri); !errors.Is(err, ErrInvalidRedirectURI) {
+			t.Errorf("validateRegisteredRedirectURI(%q) = %v, want ErrInvalidRedirectURI", uri, err)
+		}
+	}
+}
+
+func TestApplyClientMetadata(t *testing.T) {
+	_, jwks := newTestClientKey(t, "k1")
+	base := ClientMetadata{
+		ClientName:   "  CLI Tool  ",
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		ClientURI:    "https://app.example.com",
+		LogoURI:      "https://app.example.com/logo.png",
+	}
+
+	app, err := applyClientMetadata(model.Application{ID: "app_1"}, base)
+	if err != nil {
+		t.Fatalf("applyClientMetadata: %v", err)
+	}
+	if app.ID != "app_1" || app.Name != "CLI Tool" || app.LaunchURL != base.ClientURI || app.IconURL != base.LogoURI {
+		t.Errorf("app = %+v, want the metadata copied onto app_1", app)
+	}
+	if app.TokenEndpointAuthMethod != model.ClientAuthMethodSecretBasic {
+		t.Errorf("TokenEndpointAuthMethod = %q, want the client_secret_basic default", app.TokenEndpointAuthMethod)
+	}
+
+	deviceOnly := base
+	deviceOnly.RedirectURIs = nil
+	deviceOnly.GrantTypes = []string{"urn:ietf:params:oauth:grant-type:device_code"}
+	if _, err := applyClientMetadata(model.Application{}, deviceOnly); err != nil {
+		t.Errorf("device-only client without redirect_uris: %v", err)
+	}
+
+	privateKeyJWT := base
+	privateKeyJWT.TokenEndpointAuthMethod = model.ClientAuthMethodPrivateKeyJWT
+	privateKeyJWT.JWKS = jwks
+	if app, err := applyClientMetadata(model.Application{}, privateKeyJWT); err != nil || !app.UsesPrivateKeyJWT() {
+		t.Errorf("private_key_jwt client: UsesPrivateKeyJWT = %v, err = %v", app.UsesPrivateKeyJWT(), err)
+	}
+
+	invalid := []struct {
+		name string
+		edit func(*ClientMetadata)
+		want error
+	}{
+		{"no name", func(m *ClientMetadata) { m.ClientName = " " }, ErrInvalidClientMetadata},
+		{"implicit grant", func(m *ClientMetadata) { m.GrantTypes = []string{"implicit"} }, ErrInvalidClientMetadata},
+		{"token response type", func(m *ClientMetadata) { m.ResponseTypes = []string{"token"} }, ErrInvalidClientMetadata},
+		{"code grant without redirect_uris", func(m *ClientMetadata) { m.RedirectURIs = nil }, ErrInvalidRedirectURI},
+		{"insecure redirect_uri", func(m *ClientMetadata) { m.RedirectURIs = []string{"http://app.example.com/cb"} }, ErrInvalidRedirectURI},
+		{"http client_uri", func(m *ClientMetadata) { m.ClientURI = "http://app.example.com" }, ErrInvalidClientMetadata},
+		{"relative logo_uri", func(m *ClientMetadata) { m.LogoURI = "/logo.png" }, ErrInvalidClientMetadata},
+		{"unknown auth method", func(m *ClientMetadata) { m.TokenEndpointAuthMethod = "tls_client_auth" }, ErrInvalidClientMetadata},
+		{"private_key_jwt without keys", func(m *ClientMetadata) { m.TokenEndpointAuthMethod = model.ClientAuthMethodPrivateKeyJWT }, ErrInvalidClientMetadata},
+		{"jwks and jwks_uri", func(m *ClientMetadata) { m.JWKS = jwks; m.JWKSURI = "https://app.example.com/jwks.json" }, ErrInvalidClientMetadata},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := base
+			tt.edit(&metadata)
+			if _, err := applyClientMetadata(model.Application{}, metadata); !errors.Is(err, tt.want) {
+				t.Errorf("applyClientMetadata error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -35,32 +35,49 @@ func GetConditionalBindingsForGroup(groupID string) ([]model.GroupConditionalBin
 	return bindings, nil
 }
 
-// CreateConditionalBinding validates the binding against existing ones for
-// cycles, mints an ID if absent, and inserts. Returns the created row.
+// CreateConditionalBinding validates the binding, mints an ID if absent,
+// and inserts. Returns the created row.
 func CreateConditionalBinding(binding model.GroupConditionalBinding) (model.GroupConditionalBinding, error) {
+	if err := validateConditionalBinding(binding); err != nil {
+		return model.GroupConditionalBinding{}, err
+	}
+
+	if binding.ID == "" {
+		binding.ID = ulid.Make().Prefixed("gcb")
+	}
+	if err := database.DB.Create(&binding).Error; err != nil {
+		return model.GroupConditionalBinding{}, err
+	}
+	return binding, nil
+}
+
+// validateConditionalBinding checks a binding's predicates and rejects it if
+// it requires its own group or would close a cycle with the existing
+// bindings and child groups. Predicates are normalized in place.
+func validateConditionalBinding(binding model.GroupConditionalBinding) error {
+	if err := ValidateAttributePredicates(binding.Predicates); err != nil {
+		return err
+	}
 	// Self-reference is the trivial cycle — catch it explicitly so the error
 	// is unambiguous (vs. surfacing as a generic "creates a cycle").
 	for _, req := range binding.RequiredGroupIDs {
 		if req == binding.GroupID {
-			return model.GroupConditionalBinding{}, ErrConditionalBindingSelfRef
+			return ErrConditionalBindingSelfRef
 		}
 	}
 
 	existing, err := GetAllConditionalBindings()
 	if err != nil {
-		return model.GroupConditionalBinding{}, fmt.Errorf("load existing bindings: %w", err)
+		return fmt.Errorf("load existing bindings: %w", err)
 	}
-	if wouldCreateCycle(binding, existing) {
-		return model.GroupConditionalBinding{}, ErrConditionalBindingCycle
-	}
-
-	if binding.ID == "" {
-		binding.ID = ulid.Make().Prefixed("gcb")
+	links, err := GetAllGroupChildren()
+	if err != nil {
+		return fmt.Errorf("load child groups: %w", err)
 	}
-	if err := database.DB.Create(&binding).Error; err != nil {
-		return model.GroupConditionalBinding{}, err
+	if wouldCreateCycle(binding, existing, links) {
+		return ErrConditionalBindingCycle
 	}
-	return binding, nil
+	return nil
 }
 
 // DeleteConditionalBinding scopes the delete to (groupID, bindingID) so a
@@ -79,11 +96,12 @@ func DeleteAllConditionalBindingsForGroup(groupID string) error {
 
 // EvaluateConditionalMembership reports whether an entity that's a member of
 // entityGroupIDs satisfies any of the given conditional bindings. Within a
-// binding, ALL required groups must be held (AND); across bindings on the
-// same parent, ANY match qualifies the user (OR). A binding with no
-// required groups never matches — an empty AND-group is a no-match rather
-// than a vacuous grant. Mirrors EvaluateDiscordMembership exactly.
-func EvaluateConditionalMembership(bindings []model.GroupConditionalBinding, entityGroupIDs []string) bool {
+// binding, ALL required groups must be held and ALL predicates must hold on
+// subject (AND); across bindings on the same parent, ANY match qualifies the
+// user (OR). A binding with no required groups and no predicates never
+// matches — an empty AND-group is a no-match rather than a vacuous grant.
+// Mirrors EvaluateDiscordMembership for the group half.
+func EvaluateConditionalMembership(bindings []model.GroupConditionalBinding, entityGroupIDs []string, subject ConditionalSubject) bool {
 	if len(bindings) == 0 {
 		return false
 	}
@@ -92,7 +110,7 @@ func EvaluateConditionalMembership(bindings []model.GroupConditionalBinding, ent
 		held[g] = struct{}{}
 	}
 	for _, b := range bindings {
-		if len(b.RequiredGroupIDs) == 0 {
+		if len(b.RequiredGroupIDs) == 0 && len(b.Predicates) == 0 {
 			continue
 		}
 		matched := true
@@ -102,6 +120,12 @@ func EvaluateConditionalMembership(bindings []model.GroupConditionalBinding, ent
 				break
 			}
 		}
+		for _, p := range b.Predicates {
+			if !matched {
+				break
+			}
+			matched = evaluateAttributePredicate(p, subject)
+		}
 		if matched {
 			return true
 		}
@@ -110,35 +134,49 @@ func EvaluateConditionalMembership(bindings []model.GroupConditionalBinding, ent
 }
 
 // wouldCreateCycle returns true if adding `newBinding` to `existing` would
-// close a cycle in the dependency graph (parent group → required groups).
-// DFS from each of the new binding's required groups; if we can reach the
-// new binding's own GroupID, that's a cycle.
-func wouldCreateCycle(newBinding model.GroupConditionalBinding, existing []model.GroupConditionalBinding) bool {
-	// Build adjacency: for each group, the union of required-group IDs across
-	// all its bindings. (Multiple bindings on the same parent = OR semantics
-	// at evaluation time, but for cycle detection any required group from any
-	// binding creates a dependency edge.)
+// close a cycle in the dependency graph (parent group → required groups,
+// plus parent group → child groups from the hierarchy). Checks whether any
+// of the new binding's required groups already depends on the new binding's
+// own GroupID.
+func wouldCreateCycle(newBinding model.GroupConditionalBinding, existing []model.GroupConditionalBinding, links []model.GroupChild) bool {
+	adj := groupDependencies(append(existing, newBinding), links)
+	for _, req := range newBinding.RequiredGroupIDs {
+		if dependsOn(adj, req, newBinding.GroupID) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupDependencies builds the graph of whose membership is derived from
+// whose: an edge A → B means A's membership depends on B's, either because
+// B is a required group of one of A's conditional bindings or because B is
+// a child group of A. (Multiple bindings on the same parent = OR semantics
+// at evaluation time, but for cycle detection any required group from any
+// binding creates a dependency edge.)
+func groupDependencies(bindings []model.GroupConditionalBinding, links []model.GroupChild) map[string]map[string]struct{} {
 	adj := make(map[string]map[string]struct{})
-	add := func(parent, req string) {
+	add := func(parent, dep string) {
 		if adj[parent] == nil {
 			adj[parent] = make(map[string]struct{})
 		}
-		adj[parent][req] = struct{}{}
+		adj[parent][dep] = struct{}{}
 	}
-	for _, b := range existing {
+	for _, b := range bindings {
 		for _, req := range b.RequiredGroupIDs {
 			add(b.GroupID, req)
 		}
 	}
-	for _, req := range newBinding.RequiredGroupIDs {
-		add(newBinding.GroupID, req)
+	for _, l := range links {
+		add(l.ParentGroupID, l.ChildGroupID)
 	}
+	return adj
+}
 
-	// DFS from each required group looking for a path back to the new
-	// binding's parent. visited prevents infinite loops in case the EXISTING
-	// graph already has cycles (which shouldn't happen if we always check on
-	// create, but defensive).
-	target := newBinding.GroupID
+// dependsOn reports whether from is target or reaches it in adj. visited
+// prevents infinite loops in case the existing graph already has cycles
+// (which shouldn't happen if we always check on create, but defensive).
+func dependsOn(adj map[string]map[string]struct{}, from, target string) bool {
 	visited := make(map[string]bool)
 	var dfs func(node string) bool
 	dfs = func(node string) bool {
@@ -156,10 +194,5 @@ func wouldCreateCycle(newBinding model.GroupConditionalBinding, existing []model
 		}
 		return false
 	}
-	for _, req := range newBinding.RequiredGroupIDs {
-		if dfs(req) {
-			return true
-		}
-	}
-	return false
+	return dfs(from)
 }
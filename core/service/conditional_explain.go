@@ -0,0 +1,240 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+)
+
+// ConditionalExplanation is why an entity is or isn't a CONDITIONAL member
+// of a group, evaluated against the current bindings and memberships — the
+// same inputs the next reconcile round would see.
+type ConditionalExplanation struct {
+	GroupID  string `json:"group_id"`
+	EntityID string `json:"entity_id"`
+	// ConditionalEnabled is false when the group doesn't allow the
+	// CONDITIONAL source; its bindings are then ignored by reconcile.
+	ConditionalEnabled bool `json:"conditional_enabled"`
+	// Satisfied is the OR across Bindings.
+	Satisfied bool `json:"satisfied"`
+	// Source is how the entity is in the group today, or empty when it
+	// isn't. INHERITED means only through a child group.
+	Source   string                      `json:"source"`
+	Bindings []ConditionalBindingExplain `json:"bindings"`
+}
+
+// ConditionalBindingExplain is one binding's verdict: every required group
+// and predicate with whether the entity met it.
+type ConditionalBindingExplain struct {
+	BindingID      string                      `json:"binding_id"`
+	Matched        bool                        `json:"matched"`
+	RequiredGroups []ConditionalGroupCheck     `json:"required_groups"`
+	Predicates     []ConditionalPredicateCheck `json:"predicates"`
+}
+
+// ConditionalGroupCheck reports whether the entity holds one required group
+// and, if so, through which source. InheritedFrom is set for INHERITED.
+type ConditionalGroupCheck struct {
+	GroupID       string `json:"group_id"`
+	Held          bool   `json:"held"`
+	Source        string `json:"source,omitempty"`
+	InheritedFrom string `json:"inherited_from,omitempty"`
+}
+
+// ConditionalPredicateCheck reports whether one predicate held. The
+// profile value itself is left out — explain is open to group owners, who
+// can't otherwise read a member's profile.
+type ConditionalPredicateCheck struct {
+	Predicate model.AttributePredicate `json:"predicate"`
+	Matched   bool                     `json:"matched"`
+}
+
+// ExplainConditionalMembership evaluates groupID's conditional bindings for
+// one entity. Returns gorm.ErrRecordNotFound if the group or entity doesn't
+// exist.
+func ExplainConditionalMembership(groupID, entityID string) (ConditionalExplanation, error) {
+	group, err := GetGroupByID(groupID)
+	if err != nil {
+		return ConditionalExplanation{}, err
+	}
+	subject, err := loadConditionalSubject(entityID)
+	if err != nil {
+		return ConditionalExplanation{}, err
+	}
+	bindings, err := GetConditionalBindingsForGroup(groupID)
+	if err != nil {
+		return ConditionalExplanation{}, fmt.Errorf("load bindings: %w", err)
+	}
+	memberships, err := GetEffectiveMembershipsForEntity(entityID)
+	if err != nil {
+		return ConditionalExplanation{}, fmt.Errorf("fetch memberships: %w", err)
+	}
+	byGroup := make(map[string]model.GroupMember, len(memberships))
+	for _, m := range memberships {
+		byGroup[m.GroupID] = m
+	}
+
+	explanation := ConditionalExplanation{
+		GroupID:            groupID,
+		EntityID:           entityID,
+		ConditionalEnabled: allowsConditional(group),
+		Source:             byGroup[groupID].Source,
+		Bindings:           make([]ConditionalBindingExplain, 0, len(bindings)),
+	}
+	for _, b := range bindings {
+		be := ConditionalBindingExplain{
+			BindingID:      b.ID,
+			RequiredGroups: make([]ConditionalGroupCheck, 0, len(b.RequiredGroupIDs)),
+			Predicates:     make([]ConditionalPredicateCheck, 0, len(b.Predicates)),
+		}
+		// Same rule as EvaluateConditionalMembership: an empty binding
+		// never matches.
+		be.Matched = len(b.RequiredGroupIDs) > 0 || len(b.Predicates) > 0
+		for _, required := range b.RequiredGroupIDs {
+			check := ConditionalGroupCheck{GroupID: required}
+			if m, ok := byGroup[required]; ok {
+				check.Held = true
+				check.Source = m.Source
+				check.InheritedFrom = m.InheritedFrom
+			} else {
+				be.Matched = false
+			}
+			be.RequiredGroups = append(be.RequiredGroups, check)
+		}
+		for _, p := range b.Predicates {
+			matched := evaluateAttributePredicate(p, subject)
+			if !matched {
+				be.Matched = false
+			}
+			be.Predicates = append(be.Predicates, ConditionalPredicateCheck{Predicate: p, Matched: matched})
+		}
+		if be.Matched {
+			explanation.Satisfied = true
+		}
+		explanation.Bindings = append(explanation.Bindings, be)
+	}
+	return explanation, nil
+}
+
+// ConditionalSimulation is the effect a proposed binding set would have on
+// one group's CONDITIONAL members.
+type ConditionalSimulation struct {
+	GroupID            string `json:"group_id"`
+	ConditionalEnabled bool   `json:"conditional_enabled"`
+	// Added are entities that would gain a CONDITIONAL membership; Removed
+	// are CONDITIONAL members who would lose it. Both are sorted.
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// SimulateConditionalBinding reports who would be added to or removed from
+// the binding's group if it were saved, with the excluded bindings deleted
+// at the same time. The proposed binding is validated exactly as
+// CreateConditionalBinding would. Only the direct effect on this group is
+// computed — cascades into groups whose bindings require it show up on the
+// first reconcile after saving.
+//
+// Runs over every entity in a handful of bulk queries, so it's cheap enough
+// to call on each edit at typical org scale. On a group that doesn't allow
+// CONDITIONAL, nothing would be added.
+func SimulateConditionalBinding(binding model.GroupConditionalBinding, excludeBindingIDs []string) (ConditionalSimulation, error) {
+	group, err := GetGroupByID(binding.GroupID)
+	if err != nil {
+		return ConditionalSimulation{}, err
+	}
+	if err := validateConditionalBinding(binding); err != nil {
+		return ConditionalSimulation{}, err
+	}
+	current, err := GetConditionalBindingsForGroup(binding.GroupID)
+	if err != nil {
+		return ConditionalSimulation{}, fmt.Errorf("load bindings: %w", err)
+	}
+	excluded := make(map[string]struct{}, len(excludeBindingIDs))
+	for _, id := range excludeBindingIDs {
+		excluded[id] = struct{}{}
+	}
+	proposed := make([]model.GroupConditionalBinding, 0, len(current)+1)
+	for _, b := range current {
+		if _, ok := excluded[b.ID]; !ok {
+			proposed = append(proposed, b)
+		}
+	}
+	proposed = append(proposed, binding)
+
+	var entities []model.Entity
+	if err := database.DB.Find(&entities).Error; err != nil {
+		return ConditionalSimulation{}, fmt.Errorf("list entities: %w", err)
+	}
+	var users []model.User
+	if err := database.DB.Find(&users).Error; err != nil {
+		return ConditionalSimulation{}, fmt.Errorf("list users: %w", err)
+	}
+	usersByEntity := make(map[string]*model.User, len(users))
+	for i := range users {
+		usersByEntity[users[i].EntityID] = &users[i]
+	}
+	var memberships []model.GroupMember
+	if err := database.DB.Find(&memberships).Error; err != nil {
+		return ConditionalSimulation{}, fmt.Errorf("list memberships: %w", err)
+	}
+	heldByEntity := make(map[string][]string)
+	sourceInGroup := make(map[string]string)
+	for _, m := range memberships {
+		heldByEntity[m.EntityID] = append(heldByEntity[m.EntityID], m.GroupID)
+		if m.GroupID == binding.GroupID {
+			sourceInGroup[m.EntityID] = m.Source
+		}
+	}
+	hierarchy, err := loadGroupHierarchy()
+	if err != nil {
+		return ConditionalSimulation{}, fmt.Errorf("load child groups: %w", err)
+	}
+
+	sim := ConditionalSimulation{
+		GroupID:            binding.GroupID,
+		ConditionalEnabled: allowsConditional(group),
+		Added:              []string{},
+		Removed:            []string{},
+	}
+	for _, e := range entities {
+		held := heldByEntity[e.ID]
+		effective := append([]string(nil), held...)
+		for ancestor := range hierarchy.ancestors(held) {
+			effective = append(effective, ancestor)
+		}
+		subject := ConditionalSubject{EntityType: e.Type, User: usersByEntity[e.ID]}
+		satisfied := EvaluateConditionalMembership(proposed, effective, subject)
+
+		source, stored := sourceInGroup[e.ID]
+		isMember := stored
+		if !isMember {
+			for _, g := range effective {
+				if g == binding.GroupID {
+					isMember = true
+					break
+				}
+			}
+		}
+		switch {
+		case satisfied && !isMember && sim.ConditionalEnabled:
+			sim.Added = append(sim.Added, e.ID)
+		case !satisfied && source == string(model.GroupMemberSourceConditional):
+			sim.Removed = append(sim.Removed, e.ID)
+		}
+	}
+	sort.Strings(sim.Added)
+	sort.Strings(sim.Removed)
+	return sim, nil
+}
+
+// allowsConditional reports whether the group accepts CONDITIONAL members.
+func allowsConditional(group model.Group) bool {
+	for _, src := range group.AllowedSources {
+		if src == string(model.GroupMemberSourceConditional) {
+			return true
+		}
+	}
+	return false
+}
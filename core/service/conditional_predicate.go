@@ -0,0 +1,226 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidAttributePredicate is returned when a conditional binding's
+// predicate names an unknown field or op, or carries the wrong operands.
+// The API layer maps it to 400.
+var ErrInvalidAttributePredicate = errors.New("invalid attribute predicate")
+
+// entityTypeField is the one predicate field that isn't a User field — it
+// applies to every entity, service accounts included.
+const entityTypeField = "entity_type"
+
+// predicateField reads one profile field. Numeric fields are compared as
+// integers by the ordered ops; everything else is a case-insensitive string
+// compare.
+type predicateField struct {
+	numeric bool
+	get     func(u model.User) string
+}
+
+func stringField(get func(u model.User) string) predicateField {
+	return predicateField{get: get}
+}
+
+// predicateFields is every User field a predicate may reference, keyed by
+// its JSON name. Contact details and free-form fields like the birthday are
+// left out on purpose.
+var predicateFields = map[string]predicateField{
+	"username":                stringField(func(u model.User) string { return u.Username }),
+	"first_name":              stringField(func(u model.User) string { return u.FirstName }),
+	"last_name":               stringField(func(u model.User) string { return u.LastName }),
+	"gender":                  stringField(func(u model.User) string { return u.Gender }),
+	"graduate_level":          stringField(func(u model.User) string { return u.GraduateLevel }),
+	"major":                   stringField(func(u model.User) string { return u.Major }),
+	"shirt_size":              stringField(func(u model.User) string { return u.ShirtSize }),
+	"jacket_size":             stringField(func(u model.User) string { return u.JacketSize }),
+	"sae_registration_number": stringField(func(u model.User) string { return u.SAERegistrationNumber }),
+	"occupation_title":        stringField(func(u model.User) string { return u.OccupationTitle }),
+	"occupation_company":      stringField(func(u model.User) string { return u.OccupationCompany }),
+	"initial_role":            stringField(func(u model.User) string { return u.InitialRole }),
+	"status":                  stringField(func(u model.User) string { return string(u.Status) }),
+	"graduation_year": {
+		numeric: true,
+		get: func(u model.User) string {
+			if u.GraduationYear == 0 {
+				return ""
+			}
+			return strconv.Itoa(u.GraduationYear)
+		},
+	},
+}
+
+// ConditionalSubject is what attribute predicates are evaluated against:
+// the entity's type and, for users, their profile. User is nil for service
+// accounts and for users whose profile can't be loaded.
+type ConditionalSubject struct {
+	EntityType model.EntityType
+	User       *model.User
+}
+
+// loadConditionalSubject reads the entity and its user profile (if any).
+// A missing profile isn't an error — the subject just has no User.
+func loadConditionalSubject(entityID string) (ConditionalSubject, error) {
+	var entity model.Entity
+	if err := database.DB.Where("id = ?", entityID).First(&entity).Error; err != nil {
+		return ConditionalSubject{}, err
+	}
+	subject := ConditionalSubject{EntityType: entity.Type}
+	var user model.User
+	if err := database.DB.Where("entity_id = ?", entityID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return subject, nil
+		}
+		return ConditionalSubject{}, err
+	}
+	subject.User = &user
+	return subject, nil
+}
+
+// ValidateAttributePredicates checks every predicate's field, op, and
+// operands, and normalizes them in place (trimmed, lowercased field and op).
+// Errors wrap ErrInvalidAttributePredicate with the offending index.
+func ValidateAttributePredicates(predicates model.AttributePredicates) error {
+	for i := range predicates {
+		p := &predicates[i]
+		p.Field = strings.ToLower(strings.TrimSpace(p.Field))
+		p.Op = model.PredicateOp(strings.ToLower(strings.TrimSpace(string(p.Op))))
+		p.Value = strings.TrimSpace(p.Value)
+		for j := range p.Values {
+			p.Values[j] = strings.TrimSpace(p.Values[j])
+		}
+		if err := validateAttributePredicate(*p); err != nil {
+			return fmt.Errorf("%w: predicates[%d]: %s", ErrInvalidAttributePredicate, i, err)
+		}
+	}
+	return nil
+}
+
+func validateAttributePredicate(p model.AttributePredicate) error {
+	if p.Field == entityTypeField {
+		if p.Op != model.PredicateOpEq && p.Op != model.PredicateOpNeq {
+			return fmt.Errorf("entity_type only supports eq and neq")
+		}
+		if t := model.EntityType(strings.ToUpper(p.Value)); t != model.EntityTypeUser && t != model.EntityTypeServiceAccount {
+			return fmt.Errorf("entity_type must be USER or SERVICE_ACCOUNT")
+		}
+		return nil
+	}
+	field, ok := predicateFields[p.Field]
+	if !ok {
+		return fmt.Errorf("unknown field %q", p.Field)
+	}
+	numeric := field.numeric
+
+	switch p.Op {
+	case model.PredicateOpEq, model.PredicateOpNeq:
+		if p.Value == "" || len(p.Values) > 0 {
+			return fmt.Errorf("%s takes a single value", p.Op)
+		}
+		if numeric {
+			if _, err := strconv.Atoi(p.Value); err != nil {
+				return fmt.Errorf("%s is numeric", p.Field)
+			}
+		}
+	case model.PredicateOpGt, model.PredicateOpGte, model.PredicateOpLt, model.PredicateOpLte:
+		if !numeric {
+			return fmt.Errorf("%s only applies to numeric fields", p.Op)
+		}
+		if len(p.Values) > 0 {
+			return fmt.Errorf("%s takes a single value", p.Op)
+		}
+		if _, err := strconv.Atoi(p.Value); err != nil {
+			return fmt.Errorf("%s is numeric", p.Field)
+		}
+	case model.PredicateOpIn, model.PredicateOpNotIn:
+		if p.Value != "" || len(p.Values) == 0 {
+			return fmt.Errorf("%s takes a non-empty values list", p.Op)
+		}
+		for _, v := range p.Values {
+			if v == "" {
+				return fmt.Errorf("%s values must not be empty", p.Op)
+			}
+			if numeric {
+				if _, err := strconv.Atoi(v); err != nil {
+					return fmt.Errorf("%s is numeric", p.Field)
+				}
+			}
+		}
+	case model.PredicateOpPresent, model.PredicateOpAbsent:
+		if p.Value != "" || len(p.Values) > 0 {
+			return fmt.Errorf("%s takes no value", p.Op)
+		}
+	default:
+		return fmt.Errorf("unknown op %q", p.Op)
+	}
+	return nil
+}
+
+// evaluateAttributePredicate reports whether the subject satisfies p. User
+// field predicates never match a subject without a profile — not even
+// absent or neq — so a rule over profile data can't pull in service
+// accounts.
+func evaluateAttributePredicate(p model.AttributePredicate, subject ConditionalSubject) bool {
+	if p.Field == entityTypeField {
+		matches := strings.EqualFold(string(subject.EntityType), p.Value)
+		if p.Op == model.PredicateOpNeq {
+			return !matches
+		}
+		return matches
+	}
+	field, ok := predicateFields[p.Field]
+	if !ok || subject.User == nil {
+		return false
+	}
+	actual := field.get(*subject.User)
+
+	switch p.Op {
+	case model.PredicateOpEq:
+		return strings.EqualFold(actual, p.Value)
+	case model.PredicateOpNeq:
+		return !strings.EqualFold(actual, p.Value)
+	case model.PredicateOpIn, model.PredicateOpNotIn:
+		found := false
+		for _, v := range p.Values {
+			if strings.EqualFold(actual, v) {
+				found = true
+				break
+			}
+		}
+		return found == (p.Op == model.PredicateOpIn)
+	case model.PredicateOpPresent:
+		return actual != ""
+	case model.PredicateOpAbsent:
+		return actual == ""
+	case model.PredicateOpGt, model.PredicateOpGte, model.PredicateOpLt, model.PredicateOpLte:
+		a, err := strconv.Atoi(actual)
+		if err != nil {
+			return false
+		}
+		v, err := strconv.Atoi(p.Value)
+		if err != nil {
+			return false
+		}
+		switch p.Op {
+		case model.PredicateOpGt:
+			return a > v
+		case model.PredicateOpGte:
+			return a >= v
+		case model.PredicateOpLt:
+			return a < v
+		default:
+			return a <= v
+		}
+	}
+	return false
+}
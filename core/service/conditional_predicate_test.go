@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gaucho-racing/sentinel/core/model"
+)
+
+func TestValidateAttributePredicates(t *testing.T) {
+	valid := []struct {
+		name string
+		p    model.AttributePredicate
+	}{
+		{"eq", model.AttributePredicate{Field: "major", Op: model.PredicateOpEq, Value: "EE"}},
+		{"neq", model.AttributePredicate{Field: "status", Op: model.PredicateOpNeq, Value: "ALUMNI"}},
+		{"numeric eq", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpEq, Value: "2027"}},
+		{"gt", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpGt, Value: "2025"}},
+		{"gte", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpGte, Value: "2025"}},
+		{"lt", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpLt, Value: "2030"}},
+		{"lte", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpLte, Value: "2030"}},
+		{"in", model.AttributePredicate{Field: "major", Op: model.PredicateOpIn, Values: []string{"EE", "CE"}}},
+		{"numeric not_in", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpNotIn, Values: []string{"2024"}}},
+		{"present", model.AttributePredicate{Field: "sae_registration_number", Op: model.PredicateOpPresent}},
+		{"absent", model.AttributePredicate{Field: "occupation_company", Op: model.PredicateOpAbsent}},
+		{"entity type", model.AttributePredicate{Field: "entity_type", Op: model.PredicateOpEq, Value: "service_account"}},
+	}
+	for _, tt := range valid {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateAttributePredicates(model.AttributePredicates{tt.p}); err != nil {
+				t.Errorf("ValidateAttributePredicates: %v", err)
+			}
+		})
+	}
+
+	invalid := []struct {
+		name string
+		p    model.AttributePredicate
+	}{
+		{"unknown field", model.AttributePredicate{Field: "email", Op: model.PredicateOpEq, Value: "x"}},
+		{"unknown op", model.AttributePredicate{Field: "major", Op: "like", Value: "E%"}},
+		{"eq without a value", model.AttributePredicate{Field: "major", Op: model.PredicateOpEq}},
+		{"eq with values", model.AttributePredicate{Field: "major", Op: model.PredicateOpEq, Value: "EE", Values: []string{"CE"}}},
+		{"numeric eq with text", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpEq, Value: "soon"}},
+		{"gt on a string field", model.AttributePredicate{Field: "major", Op: model.PredicateOpGt, Value: "EE"}},
+		{"gt with text", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpGt, Value: "2025.5"}},
+		{"lte with values", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpLte, Value: "2030", Values: []string{"2031"}}},
+		{"in without values", model.AttributePredicate{Field: "major", Op: model.PredicateOpIn}},
+		{"in with a value", model.AttributePredicate{Field: "major", Op: model.PredicateOpIn, Value: "EE", Values: []string{"CE"}}},
+		{"in with a blank value", model.AttributePredicate{Field: "major", Op: model.PredicateOpIn, Values: []string{"EE", "  "}}},
+		{"numeric in with text", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpIn, Values: []string{"2027", "next"}}},
+		{"present with a value", model.AttributePredicate{Field: "major", Op: model.PredicateOpPresent, Value: "EE"}},
+		{"entity type gt", model.AttributePredicate{Field: "entity_type", Op: model.PredicateOpGt, Value: "USER"}},
+		{"unknown entity type", model.AttributePredicate{Field: "entity_type", Op: model.PredicateOpEq, Value: "ROBOT"}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAttributePredicates(model.AttributePredicates{tt.p})
+			if !errors.Is(err, ErrInvalidAttributePredicate) {
+				t.Errorf("ValidateAttributePredicates error = %v, want ErrInvalidAttributePredicate", err)
+			}
+		})
+	}
+
+	t.Run("normalizes in place", func(t *testing.T) {
+		predicates := model.AttributePredicates{{Field: " Major ", Op: " IN ", Values: []string{" EE ", "CE"}}}
+		if err := ValidateAttributePredicates(predicates); err != nil {
+			t.Fatalf("ValidateAttributePredicates: %v", err)
+		}
+		p := predicates[0]
+		if p.Field != "major" || p.Op != model.PredicateOpIn || p.Values[0] != "EE" {
+			t.Errorf("predicate = %+v, want it trimmed and lowercased", p)
+		}
+	})
+}
+
+func TestEvaluateAttributePredicate(t *testing.T) {
+	user := ConditionalSubject{
+		EntityType: model.EntityTypeUser,
+		User:       &model.User{Major: "Electrical Engineering", GraduationYear: 2027, Status: "ACTIVE"},
+	}
+	noYear := ConditionalSubject{EntityType: model.EntityTypeUser, User: &model.User{Major: "Physics"}}
+	serviceAccount := ConditionalSubject{EntityType: model.EntityTypeServiceAccount}
+	noProfile := ConditionalSubject{EntityType: model.EntityTypeUser}
+
+	tests := []struct {
+		name    string
+		p       model.AttributePredicate
+		subject ConditionalSubject
+		want    bool
+	}{
+		{"eq ignores case", model.AttributePredicate{Field: "major", Op: model.PredicateOpEq, Value: "electrical engineering"}, user, true},
+		{"eq mismatch", model.AttributePredicate{Field: "major", Op: model.PredicateOpEq, Value: "Physics"}, user, false},
+		{"neq", model.AttributePredicate{Field: "major", Op: model.PredicateOpNeq, Value: "Physics"}, user, true},
+		{"in", model.AttributePredicate{Field: "major", Op: model.PredicateOpIn, Values: []string{"Physics", "ELECTRICAL ENGINEERING"}}, user, true},
+		{"in miss", model.AttributePredicate{Field: "major", Op: model.PredicateOpIn, Values: []string{"Physics"}}, user, false},
+		{"not_in", model.AttributePredicate{Field: "major", Op: model.PredicateOpNotIn, Values: []string{"Physics"}}, user, true},
+		{"not_in hit", model.AttributePredicate{Field: "major", Op: model.PredicateOpNotIn, Values: []string{"electrical engineering"}}, user, false},
+		{"gt", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpGt, Value: "2026"}, user, true},
+		{"gt equal", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpGt, Value: "2027"}, user, false},
+		{"gte equal", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpGte, Value: "2027"}, user, true},
+		{"lt", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpLt, Value: "2027"}, user, false},
+		{"lte equal", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpLte, Value: "2027"}, user, true},
+		{"ordered op on an unset number", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpLt, Value: "3000"}, noYear, false},
+		{"numeric eq compares as text", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpEq, Value: "2027"}, user, true},
+		{"present", model.AttributePredicate{Field: "major", Op: model.PredicateOpPresent}, user, true},
+		{"present on an empty field", model.AttributePredicate{Field: "shirt_size", Op: model.PredicateOpPresent}, user, false},
+		{"absent", model.AttributePredicate{Field: "shirt_size", Op: model.PredicateOpAbsent}, user, true},
+		{"absent on an unset number", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpAbsent}, noYear, true},
+		{"unknown field", model.AttributePredicate{Field: "email", Op: model.PredicateOpPresent}, user, false},
+
+		{"entity type", model.AttributePredicate{Field: "entity_type", Op: model.PredicateOpEq, Value: "service_account"}, serviceAccount, true},
+		{"entity type neq", model.AttributePredicate{Field: "entity_type", Op: model.PredicateOpNeq, Value: "SERVICE_ACCOUNT"}, user, true},
+		{"entity type without a profile", model.AttributePredicate{Field: "entity_type", Op: model.PredicateOpEq, Value: "USER"}, noProfile, true},
+
+		// Profile fields never match a subject with no profile, however the
+		// predicate is phrased.
+		{"service account neq", model.AttributePredicate{Field: "major", Op: model.PredicateOpNeq, Value: "Physics"}, serviceAccount, false},
+		{"service account absent", model.AttributePredicate{Field: "major", Op: model.PredicateOpAbsent}, serviceAccount, false},
+		{"service account not_in", model.AttributePredicate{Field: "major", Op: model.PredicateOpNotIn, Values: []string{"Physics"}}, serviceAccount, false},
+		{"missing profile absent", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpAbsent}, noProfile, false},
+		{"missing profile lt", model.AttributePredicate{Field: "graduation_year", Op: model.PredicateOpLt, Value: "3000"}, noProfile, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := evaluateAttributePredicate(tt.p, tt.subject); got != tt.want {
+				t.Errorf("evaluateAttributePredicate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
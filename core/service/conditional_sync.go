@@ -83,12 +83,14 @@ func reconcileConditionalForEntityCtx(ctx context.Context, entityID string) erro
 	}
 	conditionalEnabled := make(map[string]bool, len(allGroups))
 	for _, g := range allGroups {
-		for _, src := range g.AllowedSources {
-			if src == string(model.GroupMemberSourceConditional) {
-				conditionalEnabled[g.ID] = true
-				break
-			}
-		}
+		conditionalEnabled[g.ID] = allowsConditional(g)
+	}
+
+	// Profile fields can't change mid-loop either: a profile edit triggers a
+	// fresh reconcile, which cancels this one.
+	subject, err := loadConditionalSubject(entityID)
+	if err != nil {
+		return fmt.Errorf("load entity profile: %w", err)
 	}
 
 	for round := 0; round < maxFixedPointRounds; round++ {
@@ -96,14 +98,14 @@ func reconcileConditionalForEntityCtx(ctx context.Context, entityID string) erro
 			return err
 		}
 
-		memberships, err := GetMembershipsForEntity(entityID, "")
+		memberships, err := GetEffectiveMembershipsForEntity(entityID)
 		if err != nil {
 			return fmt.Errorf("fetch memberships: %w", err)
 		}
 
 		// Set of every group the entity is in (any source) — used to evaluate
-		// bindings. CONDITIONAL memberships count too, which is what enables
-		// transitive composition.
+		// bindings. CONDITIONAL and INHERITED memberships count too, which is
+		// what enables transitive composition.
 		memberGroups := make(map[string]struct{}, len(memberships))
 		// Map of group → source for the entity's existing memberships, used
 		// to scope our deletes to CONDITIONAL-only (so we never accidentally
@@ -130,7 +132,7 @@ func reconcileConditionalForEntityCtx(ctx context.Context, entityID string) erro
 			if !conditionalEnabled[parentGroup] {
 				continue
 			}
-			satisfied := EvaluateConditionalMembership(bs, memberGroupIDs)
+			satisfied := EvaluateConditionalMembership(bs, memberGroupIDs, subject)
 			_, isMember := memberGroups[parentGroup]
 			currentSource := sourceByGroup[parentGroup]
 
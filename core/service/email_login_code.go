@@ -0,0 +1,156 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/config"
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/pkg/logger"
+	"github.com/gaucho-racing/sentinel/core/pkg/mailer"
+	"github.com/gaucho-racing/ulid-go"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ErrLoginCodeRateLimited is returned when an email has requested codes too
+// often. The API layer maps it to 429.
+var ErrLoginCodeRateLimited = errors.New("too many login code requests, please wait before trying again")
+
+// ErrInvalidLoginCode covers every verification failure — wrong code, expired,
+// already used, too many attempts, unknown email — so callers can't tell them
+// apart and probe for which one applies.
+var ErrInvalidLoginCode = errors.New("invalid or expired login code")
+
+const (
+	emailLoginCodeDigits      = 6
+	emailLoginCodeMaxAttempts = 5
+)
+
+// emailLoginCodeThrottle limits code requests per address: one a minute, and
+// five per 15 minutes.
+var emailLoginCodeThrottle = emailRequestThrottle{
+	MinInterval: time.Minute,
+	Window:      15 * time.Minute,
+	WindowMax:   5,
+}
+
+// RequestEmailLoginCode issues a one-time login code for email and mails it.
+// Emails with no matching entity return nil without sending anything, so the
+// endpoint can't be used to enumerate registered addresses. They're
+// throttled the same way, so the 429 doesn't give them away either.
+func RequestEmailLoginCode(email string) error {
+	email = normalizeEmail(email)
+	allowed, err := emailLoginCodeThrottle.allow(model.EmailRequestLoginCode, email)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrLoginCodeRateLimited
+	}
+	entity, _ := GetEntityByEmail(email)
+	if entity.ID == "" {
+		logger.SugarLogger.Infof("Login code requested for unknown email %s", email)
+		return nil
+	}
+
+	code, err := generateNumericCode(emailLoginCodeDigits)
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	loginCode := model.EmailLoginCode{
+		ID:        ulid.Make().Prefixed("elc"),
+		Email:     email,
+		CodeHash:  string(hash),
+		ExpiresAt: time.Now().Add(config.EmailLoginCodeTTL),
+	}
+	if err := database.DB.Create(&loginCode).Error; err != nil {
+		return err
+	}
+
+	err = mailer.Send(mailer.Message{
+		To:      email,
+		Subject: "Your Sentinel login code",
+		Body: fmt.Sprintf(
+			"Your Sentinel login code is %s.\n\nIt expires in %s. If you didn't request this, you can ignore this email.",
+			code, config.EmailLoginCodeTTL.Round(time.Minute),
+		),
+	})
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to send login code to %s: %v", email, err)
+		database.DB.Where("id = ?", loginCode.ID).Delete(&model.EmailLoginCode{})
+		return fmt.Errorf("failed to send login code: %w", err)
+	}
+	logger.SugarLogger.Infof("Issued login code %s for entity %s", loginCode.ID, entity.ID)
+	return nil
+}
+
+// VerifyEmailLoginCode checks code against the most recent outstanding code
+// for email and, on success, consumes it and returns the entity. Every failure
+// returns ErrInvalidLoginCode. Each guess spends one of the code's attempts
+// before it's checked, in a single conditional update, so concurrent guesses
+// can't get past the limit.
+func VerifyEmailLoginCode(email string, code string) (model.Entity, error) {
+	email = normalizeEmail(email)
+	var loginCode model.EmailLoginCode
+	err := database.DB.
+		Where("LOWER(email) = ? AND verified = ? AND expires_at > ?", email, false, time.Now()).
+		Order("created_at DESC").
+		First(&loginCode).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Entity{}, err
+		}
+		return model.Entity{}, ErrInvalidLoginCode
+	}
+	attempt := database.DB.Model(&model.EmailLoginCode{}).
+		Where("id = ? AND attempts < ?", loginCode.ID, emailLoginCodeMaxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if attempt.Error != nil {
+		return model.Entity{}, attempt.Error
+	}
+	if attempt.RowsAffected == 0 {
+		return model.Entity{}, ErrInvalidLoginCode
+	}
+	if bcrypt.CompareHashAndPassword([]byte(loginCode.CodeHash), []byte(strings.TrimSpace(code))) != nil {
+		return model.Entity{}, ErrInvalidLoginCode
+	}
+
+	// Conditional update so two concurrent verifies of the same code can't
+	// both succeed.
+	result := database.DB.Model(&model.EmailLoginCode{}).
+		Where("id = ? AND verified = ?", loginCode.ID, false).
+		Updates(map[string]interface{}{"verified": true, "verified_at": time.Now()})
+	if result.Error != nil {
+		return model.Entity{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.Entity{}, ErrInvalidLoginCode
+	}
+
+	entity, _ := GetEntityByEmail(email)
+	if entity.ID == "" {
+		return model.Entity{}, ErrInvalidLoginCode
+	}
+	return entity, nil
+}
+
+// generateNumericCode returns a uniformly random zero-padded decimal string
+// of the given length from crypto/rand.
+func generateNumericCode(digits int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
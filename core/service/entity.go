@@ -1,6 +1,9 @@
 package service
 
 import (
+	"errors"
+	"strings"
+
 	"github.com/gaucho-racing/sentinel/core/database"
 	"github.com/gaucho-racing/sentinel/core/model"
 	"github.com/gaucho-racing/sentinel/core/pkg/logger"
@@ -10,6 +13,18 @@ import (
 
 const SentinelServiceAccountName = "sentinel-core"
 
+var (
+	// ErrExternalAuthTaken is returned when the provider account is already
+	// linked to a different entity.
+	ErrExternalAuthTaken = errors.New("that account is already linked to another user")
+	// ErrExternalAuthExists is returned when the entity already has an
+	// account linked for the provider; it has to unlink that one first.
+	ErrExternalAuthExists = errors.New("an account from this provider is already linked")
+	// ErrLastLoginMethod blocks removing the only way an entity has left to
+	// sign in.
+	ErrLastLoginMethod = errors.New("this is your last sign-in method; add another before removing it")
+)
+
 func GetEntityByID(id string) (model.Entity, error) {
 	var entity model.Entity
 	if err := database.DB.Where("id = ?", id).First(&entity).Error; err != nil {
@@ -53,6 +68,10 @@ func PopulateEntity(entity *model.Entity) {
 	if err != nil && err != gorm.ErrRecordNotFound {
 		logger.SugarLogger.Errorf("Failed to get external auths for entity %s: %v", entity.ID, err)
 	}
+	entity.Passkeys, err = GetPasskeysForEntity(entity.ID)
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to get passkeys for entity %s: %v", entity.ID, err)
+	}
 	if entity.Type == model.EntityTypeUser {
 		user, err := GetUserByEntityID(entity.ID)
 		if err != nil && err != gorm.ErrRecordNotFound {
@@ -108,7 +127,7 @@ func UpdateEmailAuthForEntity(entityID string, email string, password string) (m
 
 func GetEntityByEmail(email string) (model.Entity, error) {
 	var entityEmail model.EntityEmail
-	if err := database.DB.Where("email = ?", email).First(&entityEmail).Error; err != nil {
+	if err := database.DB.Where("LOWER(email) = LOWER(?)", strings.TrimSpace(email)).First(&entityEmail).Error; err != nil {
 		return model.Entity{}, err
 	}
 	entity, err := GetEntityByID(entityEmail.EntityID)
@@ -165,7 +184,24 @@ func ListExternalAuthsByProvider(provider string) ([]model.EntityExternalAuth, e
 	return auths, nil
 }
 
+// CreateExternalAuthForEntity links a provider account to an entity. An
+// entity holds at most one account per provider, and a provider account
+// belongs to at most one entity.
 func CreateExternalAuthForEntity(auth model.EntityExternalAuth) (model.EntityExternalAuth, error) {
+	var existing []model.EntityExternalAuth
+	if err := database.DB.
+		Where("UPPER(provider) = UPPER(?) AND (entity_id = ? OR external_id = ?)", auth.Provider, auth.EntityID, auth.ExternalID).
+		Find(&existing).Error; err != nil {
+		return model.EntityExternalAuth{}, err
+	}
+	for _, e := range existing {
+		if e.ExternalID == auth.ExternalID && e.EntityID != auth.EntityID {
+			return model.EntityExternalAuth{}, ErrExternalAuthTaken
+		}
+		if e.EntityID == auth.EntityID {
+			return model.EntityExternalAuth{}, ErrExternalAuthExists
+		}
+	}
 	if err := database.DB.Create(&auth).Error; err != nil {
 		return model.EntityExternalAuth{}, err
 	}
@@ -189,3 +225,49 @@ func UpdateExternalAuthMetadata(entityID string, provider string, metadata model
 	}
 	return nil
 }
+
+// CountLoginMethods counts the ways an entity can sign in: an email (by
+// password or emailed code), each linked provider account, and each
+// passkey.
+func CountLoginMethods(entityID string) (int64, error) {
+	var total int64
+	for _, value := range []interface{}{
+		&model.EntityEmail{},
+		&model.EntityExternalAuth{},
+		&model.EntityPasskey{},
+	} {
+		var n int64
+		if err := database.DB.Model(value).Where("entity_id = ?", entityID).Count(&n).Error; err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// requireAnotherLoginMethod returns ErrLastLoginMethod when removing one
+// login method would leave the entity with none.
+func requireAnotherLoginMethod(entityID string) error {
+	n, err := CountLoginMethods(entityID)
+	if err != nil {
+		return err
+	}
+	if n <= 1 {
+		return ErrLastLoginMethod
+	}
+	return nil
+}
+
+// UnlinkExternalAuth removes the entity's account for a provider. Returns
+// gorm.ErrRecordNotFound if none is linked, or ErrLastLoginMethod if it's
+// the entity's only way to sign in.
+func UnlinkExternalAuth(entityID string, provider model.ExternalAuthProvider) error {
+	var auth model.EntityExternalAuth
+	if err := database.DB.Where("entity_id = ? AND UPPER(provider) = UPPER(?)", entityID, provider).First(&auth).Error; err != nil {
+		return err
+	}
+	if err := requireAnotherLoginMethod(entityID); err != nil {
+		return err
+	}
+	return database.DB.Where("entity_id = ? AND provider = ?", entityID, auth.Provider).Delete(&model.EntityExternalAuth{}).Error
+}
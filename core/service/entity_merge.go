@@ -0,0 +1,473 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/pkg/logger"
+	"github.com/gaucho-racing/ulid-go"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMergeSameEntity = errors.New("an entity can't be merged into itself")
+	ErrMergeNotUser    = errors.New("only user entities can be merged")
+	// ErrMergeConflict is returned by MergeEntities when the plan has
+	// conflicts; the plan is returned alongside so the caller can show them.
+	ErrMergeConflict = errors.New("merge has unresolved conflicts")
+)
+
+// PreviewEntityMerge builds the plan for merging duplicateID into
+// survivorID without changing anything.
+func PreviewEntityMerge(survivorID, duplicateID string) (model.EntityMergePlan, error) {
+	return buildEntityMergePlan(database.DB, survivorID, duplicateID)
+}
+
+// MergeEntities folds the duplicate entity into the survivor: its logins,
+// memberships, ownerships, join requests, and the things it owns or created
+// move over, its tokens are revoked, and the duplicate entity is deleted.
+// The plan is rebuilt and applied in one transaction together with the
+// EntityMerge audit row, so what's recorded is exactly what was done.
+// mergedByActor is the service acting for mergedBy, if any.
+func MergeEntities(survivorID, duplicateID, mergedBy, mergedByActor string) (model.EntityMerge, error) {
+	var merge model.EntityMerge
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		plan, err := buildEntityMergePlan(tx, survivorID, duplicateID)
+		if err != nil {
+			return err
+		}
+		if len(plan.Conflicts) > 0 {
+			merge.Plan = plan
+			return ErrMergeConflict
+		}
+		if err := applyEntityMergePlan(tx, plan); err != nil {
+			return err
+		}
+		merge = model.EntityMerge{
+			ID:                ulid.Make().Prefixed("emg"),
+			SurvivorEntityID:  survivorID,
+			DuplicateEntityID: duplicateID,
+			Plan:              plan,
+			MergedBy:          mergedBy,
+			MergedByActor:     mergedByActor,
+		}
+		return tx.Create(&merge).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrMergeConflict) {
+			return model.EntityMerge{Plan: merge.Plan}, err
+		}
+		return model.EntityMerge{}, err
+	}
+	logger.SugarLogger.Infof("entity merge: merged %s into %s (%s)", duplicateID, survivorID, merge.ID)
+	// Moved memberships can satisfy (or stop satisfying) conditional
+	// bindings; the dropped CONDITIONAL rows are recomputed here too.
+	ReconcileConditionalForEntity(survivorID)
+	return merge, nil
+}
+
+// GetEntityMerges returns the merge audit records an entity took part in,
+// as survivor or duplicate, newest first.
+func GetEntityMerges(entityID string) ([]model.EntityMerge, error) {
+	merges := []model.EntityMerge{}
+	if err := database.DB.Where("survivor_entity_id = ? OR duplicate_entity_id = ?", entityID, entityID).
+		Order("created_at DESC").Find(&merges).Error; err != nil {
+		return []model.EntityMerge{}, err
+	}
+	return merges, nil
+}
+
+func buildEntityMergePlan(tx *gorm.DB, survivorID, duplicateID string) (model.EntityMergePlan, error) {
+	if survivorID == duplicateID {
+		return model.EntityMergePlan{}, ErrMergeSameEntity
+	}
+	for _, id := range []string{survivorID, duplicateID} {
+		var entity model.Entity
+		if err := tx.Where("id = ?", id).First(&entity).Error; err != nil {
+			return model.EntityMergePlan{}, err
+		}
+		if entity.Type != model.EntityTypeUser {
+			return model.EntityMergePlan{}, ErrMergeNotUser
+		}
+	}
+	plan := model.EntityMergePlan{
+		SurvivorEntityID:   survivorID,
+		DuplicateEntityID:  duplicateID,
+		MoveExternalAuths:  []model.ExternalAuthProvider{},
+		DropExternalAuths:  []model.ExternalAuthProvider{},
+		MoveMemberships:    []model.EntityMergeMembership{},
+		DropMemberships:    []model.EntityMergeMembership{},
+		UpgradeMemberships: []model.EntityMergeMembership{},
+		MoveOwnerships:     []string{},
+		DropOwnerships:     []string{},
+		MoveJoinRequests:   []string{},
+		DropJoinRequests:   []string{},
+		Applications:       []string{},
+		ServiceAccounts:    []string{},
+		CreatedGroups:      []string{},
+		Conflicts:          []string{},
+	}
+
+	// Email and phone: one of each per entity, and the survivor's wins.
+	var dupEmail, survEmail model.EntityEmail
+	if err := tx.Where("entity_id = ?", duplicateID).Limit(1).Find(&dupEmail).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	if err := tx.Where("entity_id = ?", survivorID).Limit(1).Find(&survEmail).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	if dupEmail.EntityID != "" {
+		if survEmail.EntityID == "" {
+			plan.MoveEmail = true
+		} else {
+			plan.DropEmail = dupEmail.Email
+		}
+	}
+	var dupPhone, survPhone model.EntityPhone
+	if err := tx.Where("entity_id = ?", duplicateID).Limit(1).Find(&dupPhone).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	if err := tx.Where("entity_id = ?", survivorID).Limit(1).Find(&survPhone).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	if dupPhone.EntityID != "" {
+		if survPhone.EntityID == "" {
+			plan.MovePhone = true
+		} else {
+			plan.DropPhone = dupPhone.PhoneNumber
+		}
+	}
+
+	// External auths: one per provider. Two different accounts at the same
+	// provider is a conflict — dropping either would orphan a real login and
+	// whatever that provider syncs (Discord roles, say).
+	var dupAuths, survAuths []model.EntityExternalAuth
+	if err := tx.Where("entity_id = ?", duplicateID).Find(&dupAuths).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	if err := tx.Where("entity_id = ?", survivorID).Find(&survAuths).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	survAuthByProvider := make(map[model.ExternalAuthProvider]model.EntityExternalAuth, len(survAuths))
+	for _, a := range survAuths {
+		survAuthByProvider[a.Provider] = a
+	}
+	for _, a := range dupAuths {
+		existing, ok := survAuthByProvider[a.Provider]
+		switch {
+		case !ok:
+			plan.MoveExternalAuths = append(plan.MoveExternalAuths, a.Provider)
+		case existing.ExternalID == a.ExternalID:
+			plan.DropExternalAuths = append(plan.DropExternalAuths, a.Provider)
+		default:
+			plan.Conflicts = append(plan.Conflicts, fmt.Sprintf("both entities have a %s login (%s and %s)", a.Provider, existing.ExternalID, a.ExternalID))
+		}
+	}
+
+	// Memberships: CONDITIONAL rows are derived, so they're dropped and
+	// recomputed for the survivor. DIRECT and DISCORD rows move unless the
+	// survivor is already in the group, in which case its row is kept, but
+	// upgraded if the duplicate's is the stronger grant.
+	var dupMembers, survMembers []model.GroupMember
+	if err := tx.Where("entity_id = ?", duplicateID).Find(&dupMembers).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	if err := tx.Where("entity_id = ?", survivorID).Find(&survMembers).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	survByGroup := make(map[string]model.GroupMember, len(survMembers))
+	for _, m := range survMembers {
+		survByGroup[m.GroupID] = m
+	}
+	for _, m := range dupMembers {
+		item := model.EntityMergeMembership{GroupID: m.GroupID, Source: m.Source}
+		surv, inGroup := survByGroup[m.GroupID]
+		switch {
+		case m.Source == string(model.GroupMemberSourceConditional):
+			plan.DropMemberships = append(plan.DropMemberships, item)
+		case !inGroup:
+			plan.MoveMemberships = append(plan.MoveMemberships, item)
+		case membershipUpgrades(surv, m):
+			plan.UpgradeMemberships = append(plan.UpgradeMemberships, item)
+		default:
+			plan.DropMemberships = append(plan.DropMemberships, item)
+		}
+	}
+
+	var dupOwners []model.GroupOwner
+	if err := tx.Where("entity_id = ?", duplicateID).Find(&dupOwners).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	var survOwned []string
+	if err := tx.Model(&model.GroupOwner{}).Where("entity_id = ?", survivorID).Pluck("group_id", &survOwned).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	survOwns := make(map[string]bool, len(survOwned))
+	for _, g := range survOwned {
+		survOwns[g] = true
+	}
+	for _, o := range dupOwners {
+		if survOwns[o.GroupID] {
+			plan.DropOwnerships = append(plan.DropOwnerships, o.GroupID)
+		} else {
+			plan.MoveOwnerships = append(plan.MoveOwnerships, o.GroupID)
+		}
+	}
+
+	// Join requests: a pending request is dropped when the survivor already
+	// has one pending for the same group; decided requests always move so
+	// the history stays intact.
+	var dupRequests []model.GroupJoinRequest
+	if err := tx.Where("entity_id = ?", duplicateID).Find(&dupRequests).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	var survPending []string
+	if err := tx.Model(&model.GroupJoinRequest{}).
+		Where("entity_id = ? AND status = ?", survivorID, model.GroupJoinRequestStatusPending).
+		Pluck("group_id", &survPending).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	survPendingFor := make(map[string]bool, len(survPending))
+	for _, g := range survPending {
+		survPendingFor[g] = true
+	}
+	for _, r := range dupRequests {
+		if r.Status == string(model.GroupJoinRequestStatusPending) && survPendingFor[r.GroupID] {
+			plan.DropJoinRequests = append(plan.DropJoinRequests, r.ID)
+		} else {
+			plan.MoveJoinRequests = append(plan.MoveJoinRequests, r.ID)
+		}
+	}
+
+	if err := tx.Unscoped().Model(&model.Application{}).Where("owner_id = ?", duplicateID).Pluck("id", &plan.Applications).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	if err := tx.Model(&model.ServiceAccount{}).Where("created_by = ?", duplicateID).Pluck("id", &plan.ServiceAccounts).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	if err := tx.Unscoped().Model(&model.Group{}).Where("created_by = ?", duplicateID).Pluck("id", &plan.CreatedGroups).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+
+	var passkeys int64
+	if err := tx.Model(&model.EntityPasskey{}).Where("entity_id = ?", duplicateID).Count(&passkeys).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	plan.MovePasskeys = int(passkeys)
+
+	var dupMFA, survMFA int64
+	if err := tx.Model(&model.EntityMFA{}).Where("entity_id = ?", duplicateID).Count(&dupMFA).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	if err := tx.Model(&model.EntityMFA{}).Where("entity_id = ?", survivorID).Count(&survMFA).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	if dupMFA > 0 {
+		plan.MoveMFA = survMFA == 0
+		plan.DropMFA = survMFA > 0
+	}
+
+	if err := tx.Model(&model.EntityLogin{}).Where("entity_id = ?", duplicateID).Count(&plan.EntityLogins).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	if err := tx.Model(&model.Token{}).Where("entity_id = ?", duplicateID).Count(&plan.RevokeTokens).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+
+	var dupUser, survUser model.User
+	if err := tx.Where("entity_id = ?", duplicateID).Limit(1).Find(&dupUser).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	if err := tx.Where("entity_id = ?", survivorID).Limit(1).Find(&survUser).Error; err != nil {
+		return model.EntityMergePlan{}, err
+	}
+	if dupUser.ID != "" {
+		if survUser.ID == "" {
+			plan.MoveUser = true
+		} else {
+			plan.DropUserID = dupUser.ID
+		}
+	}
+	return plan, nil
+}
+
+func applyEntityMergePlan(tx *gorm.DB, plan model.EntityMergePlan) error {
+	survivorID, duplicateID := plan.SurvivorEntityID, plan.DuplicateEntityID
+	moveEntity := func(value interface{}, where string, args ...interface{}) error {
+		return tx.Model(value).Where(where, args...).Update("entity_id", survivorID).Error
+	}
+
+	if plan.MoveEmail {
+		if err := moveEntity(&model.EntityEmail{}, "entity_id = ?", duplicateID); err != nil {
+			return err
+		}
+	}
+	if plan.MovePhone {
+		if err := moveEntity(&model.EntityPhone{}, "entity_id = ?", duplicateID); err != nil {
+			return err
+		}
+	}
+	if len(plan.MoveExternalAuths) > 0 {
+		if err := moveEntity(&model.EntityExternalAuth{}, "entity_id = ? AND provider IN ?", duplicateID, plan.MoveExternalAuths); err != nil {
+			return err
+		}
+	}
+	for _, m := range plan.MoveMemberships {
+		if err := moveEntity(&model.GroupMember{}, "group_id = ? AND entity_id = ?", m.GroupID, duplicateID); err != nil {
+			return err
+		}
+	}
+	for _, m := range plan.UpgradeMemberships {
+		var surv, dup model.GroupMember
+		if err := tx.Where("group_id = ? AND entity_id = ?", m.GroupID, survivorID).First(&surv).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ? AND entity_id = ?", m.GroupID, duplicateID).First(&dup).Error; err != nil {
+			return err
+		}
+		merged := mergeMemberships(surv, dup)
+		if err := tx.Model(&model.GroupMember{}).Where("group_id = ? AND entity_id = ?", m.GroupID, survivorID).Updates(map[string]interface{}{
+			"source":         merged.Source,
+			"added_by":       merged.AddedBy,
+			"added_by_actor": merged.AddedByActor,
+			"has_expiration": merged.HasExpiration,
+			"expires_at":     merged.ExpiresAt,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	if len(plan.MoveOwnerships) > 0 {
+		if err := moveEntity(&model.GroupOwner{}, "entity_id = ? AND group_id IN ?", duplicateID, plan.MoveOwnerships); err != nil {
+			return err
+		}
+	}
+	if len(plan.MoveJoinRequests) > 0 {
+		if err := moveEntity(&model.GroupJoinRequest{}, "id IN ?", plan.MoveJoinRequests); err != nil {
+			return err
+		}
+	}
+	if err := moveEntity(&model.GroupJoinRequestComment{}, "entity_id = ?", duplicateID); err != nil {
+		return err
+	}
+	if len(plan.Applications) > 0 {
+		if err := tx.Unscoped().Model(&model.Application{}).Where("id IN ?", plan.Applications).Update("owner_id", survivorID).Error; err != nil {
+			return err
+		}
+	}
+	if len(plan.ServiceAccounts) > 0 {
+		if err := tx.Model(&model.ServiceAccount{}).Where("id IN ?", plan.ServiceAccounts).Update("created_by", survivorID).Error; err != nil {
+			return err
+		}
+	}
+	if len(plan.CreatedGroups) > 0 {
+		if err := tx.Unscoped().Model(&model.Group{}).Where("id IN ?", plan.CreatedGroups).Update("created_by", survivorID).Error; err != nil {
+			return err
+		}
+	}
+	if err := moveEntity(&model.EntityPasskey{}, "entity_id = ?", duplicateID); err != nil {
+		return err
+	}
+	if plan.MoveMFA {
+		if err := moveEntity(&model.EntityMFA{}, "entity_id = ?", duplicateID); err != nil {
+			return err
+		}
+		if err := moveEntity(&model.MFARecoveryCode{}, "entity_id = ?", duplicateID); err != nil {
+			return err
+		}
+	}
+	if err := moveEntity(&model.EntityLogin{}, "entity_id = ?", duplicateID); err != nil {
+		return err
+	}
+	if plan.MoveUser {
+		var userID string
+		if err := tx.Model(&model.User{}).Where("entity_id = ?", duplicateID).Pluck("id", &userID).Error; err != nil {
+			return err
+		}
+		if err := moveEntity(&model.User{}, "id = ?", userID); err != nil {
+			return err
+		}
+		if err := moveEntity(&model.UserStatusChange{}, "user_id = ?", userID); err != nil {
+			return err
+		}
+	}
+	if plan.DropUserID != "" {
+		if err := tx.Where("user_id = ? AND applied_at IS NULL", plan.DropUserID).Delete(&model.UserStatusChange{}).Error; err != nil {
+			return err
+		}
+		// Soft delete, so the duplicate's profile can still be looked at
+		// (and its username stays reserved) until the purge job runs.
+		if err := tx.Where("id = ?", plan.DropUserID).Delete(&model.User{}).Error; err != nil {
+			return err
+		}
+	}
+
+	// Whatever wasn't moved is deleted along with the duplicate entity.
+	for _, value := range []interface{}{
+		&model.EntityEmail{},
+		&model.EntityPhone{},
+		&model.EntityExternalAuth{},
+		&model.GroupMember{},
+		&model.GroupOwner{},
+		&model.GroupJoinRequest{},
+		&model.EntityMFA{},
+		&model.MFARecoveryCode{},
+		&model.MFAChallenge{},
+		&model.PasswordResetToken{},
+		&model.Token{},
+	} {
+		if err := tx.Where("entity_id = ?", duplicateID).Delete(value).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("id = ?", duplicateID).Delete(&model.Entity{}).Error
+}
+
+// membershipSourceRank orders membership sources by how firmly they grant
+// access: DIRECT is an explicit grant, DISCORD belongs to the Discord sync,
+// and CONDITIONAL is derived and recomputed.
+func membershipSourceRank(source string) int {
+	switch model.GroupMemberSource(source) {
+	case model.GroupMemberSourceDirect:
+		return 2
+	case model.GroupMemberSourceConditional:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// membershipOutlasts reports whether a expires after b. No expiry outlasts
+// any.
+func membershipOutlasts(a, b model.GroupMember) bool {
+	if !a.HasExpiration {
+		return b.HasExpiration
+	}
+	return b.HasExpiration && a.ExpiresAt.After(b.ExpiresAt)
+}
+
+// mergeMemberships returns the membership the survivor keeps in a group
+// both entities are in: the stronger source, with whoever granted it, and
+// the later expiry, each from whichever row has it.
+func mergeMemberships(survivor, duplicate model.GroupMember) model.GroupMember {
+	merged := survivor
+	if membershipSourceRank(duplicate.Source) > membershipSourceRank(survivor.Source) {
+		merged.Source = duplicate.Source
+		merged.AddedBy = duplicate.AddedBy
+		merged.AddedByActor = duplicate.AddedByActor
+	}
+	if membershipOutlasts(duplicate, survivor) {
+		merged.HasExpiration = duplicate.HasExpiration
+		merged.ExpiresAt = duplicate.ExpiresAt
+	}
+	return merged
+}
+
+// membershipUpgrades reports whether merging in the duplicate's membership
+// changes the survivor's.
+func membershipUpgrades(survivor, duplicate model.GroupMember) bool {
+	merged := mergeMemberships(survivor, duplicate)
+	return merged.Source != survivor.Source || merged.HasExpiration != survivor.HasExpiration ||
+		!merged.ExpiresAt.Equal(survivor.ExpiresAt)
+}
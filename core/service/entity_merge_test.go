@@ -0,0 +1,75 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/model"
+)
+
+func TestMergeMemberships(t *testing.T) {
+	soon := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := soon.AddDate(1, 0, 0)
+	member := func(source model.GroupMemberSource, addedBy string, expiresAt *time.Time) model.GroupMember {
+		m := model.GroupMember{Source: string(source), AddedBy: addedBy}
+		if expiresAt != nil {
+			m.HasExpiration = true
+			m.ExpiresAt = *expiresAt
+		}
+		return m
+	}
+
+	tests := []struct {
+		name      string
+		survivor  model.GroupMember
+		duplicate model.GroupMember
+		want      model.GroupMember
+		upgrades  bool
+	}{
+		{
+			name:      "direct beats discord",
+			survivor:  member(model.GroupMemberSourceDiscord, "sync", nil),
+			duplicate: member(model.GroupMemberSourceDirect, "ent_admin", nil),
+			want:      member(model.GroupMemberSourceDirect, "ent_admin", nil),
+			upgrades:  true,
+		},
+		{
+			name:      "weaker source is ignored",
+			survivor:  member(model.GroupMemberSourceDirect, "ent_admin", nil),
+			duplicate: member(model.GroupMemberSourceDiscord, "sync", nil),
+			want:      member(model.GroupMemberSourceDirect, "ent_admin", nil),
+		},
+		{
+			name:      "later expiry wins",
+			survivor:  member(model.GroupMemberSourceDirect, "ent_admin", &soon),
+			duplicate: member(model.GroupMemberSourceDirect, "ent_other", &later),
+			want:      member(model.GroupMemberSourceDirect, "ent_admin", &later),
+			upgrades:  true,
+		},
+		{
+			name:      "no expiry outlasts any",
+			survivor:  member(model.GroupMemberSourceDirect, "ent_admin", &later),
+			duplicate: member(model.GroupMemberSourceDiscord, "sync", nil),
+			want:      member(model.GroupMemberSourceDirect, "ent_admin", nil),
+			upgrades:  true,
+		},
+		{
+			name:      "expiry never shortens",
+			survivor:  member(model.GroupMemberSourceDirect, "ent_admin", nil),
+			duplicate: member(model.GroupMemberSourceDirect, "ent_other", &later),
+			want:      member(model.GroupMemberSourceDirect, "ent_admin", nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeMemberships(tt.survivor, tt.duplicate)
+			if got.Source != tt.want.Source || got.AddedBy != tt.want.AddedBy ||
+				got.HasExpiration != tt.want.HasExpiration || !got.ExpiresAt.Equal(tt.want.ExpiresAt) {
+				t.Errorf("mergeMemberships = %+v, want %+v", got, tt.want)
+			}
+			if up := membershipUpgrades(tt.survivor, tt.duplicate); up != tt.upgrades {
+				t.Errorf("membershipUpgrades = %v, want %v", up, tt.upgrades)
+			}
+		})
+	}
+}
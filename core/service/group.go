@@ -1,35 +1,131 @@
 package service
 
 import (
+	"encoding/json"
+	"errors"
+	"time"
+
 	"github.com/gaucho-racing/sentinel/core/database"
 	"github.com/gaucho-racing/sentinel/core/model"
 	"github.com/gaucho-racing/sentinel/core/pkg/logger"
 	"github.com/gaucho-racing/ulid-go"
+	"gorm.io/gorm"
 )
 
 // AdminsGroupID is the fixed ID of the global Admins group. Members get
 // owner-equivalent permissions on every group and other admin-gated surfaces.
 const AdminsGroupID = "grp_01kqs3w6h82xkdnft94vpj7qrm"
 
-// IsAdmin reports whether the given entity is a member of the Admins group.
-// Returns false if the lookup fails so callers can treat it as a deny-by-default.
+// IsAdmin reports whether the given entity is a member of the Admins group,
+// directly or through a group nested under it. Returns false if the lookup
+// fails so callers can treat it as a deny-by-default.
 func IsAdmin(entityID string) bool {
 	if entityID == "" {
 		return false
 	}
-	_, err := GetGroupMember(AdminsGroupID, entityID)
-	return err == nil
+	return IsEffectiveGroupMember(AdminsGroupID, entityID)
 }
 
-func GetAllGroups() ([]model.Group, error) {
-	groups := []model.Group{}
-	if err := database.DB.Find(&groups).Error; err != nil {
-		return []model.Group{}, err
+// ValidateMembershipExpiration enforces the 1-year cap on time-boxed
+// memberships and join requests. Uses AddDate(1, 0, 0) so leap years are
+// handled correctly, with a small slack for clock drift between client and
+// server.
+func ValidateMembershipExpiration(hasExpiration bool, expiresAt time.Time) error {
+	if !hasExpiration {
+		return nil
+	}
+	if expiresAt.IsZero() {
+		return errors.New("expires_at is required when has_expiration is true")
+	}
+	now := time.Now()
+	if !expiresAt.After(now) {
+		return errors.New("expires_at must be in the future")
+	}
+	maxAllowed := now.AddDate(1, 0, 0).Add(1 * time.Minute)
+	if expiresAt.After(maxAllowed) {
+		return errors.New("maximum membership duration is 1 year")
+	}
+	return nil
+}
+
+// GroupFilter narrows ListGroups. Source matches groups whose
+// allowed_sources includes it.
+type GroupFilter struct {
+	Query        string
+	Source       string
+	CreatedAfter time.Time
+	// IncludeDeleted also lists soft-deleted groups.
+	IncludeDeleted bool
+}
+
+var groupListSpec = listSpec[model.Group]{
+	sorts: map[string]sortField[model.Group]{
+		"name":       {column: `"group".name`, kind: sortString, value: func(g model.Group) any { return g.Name }},
+		"created_at": {column: `"group".created_at`, kind: sortTime, value: func(g model.Group) any { return g.CreatedAt }},
+	},
+	defaultSort: "name",
+	idColumn:    `"group".id`,
+	id:          func(g model.Group) string { return g.ID },
+}
+
+// ListGroups returns one page of groups. Query matches each term against
+// the name and description.
+func ListGroups(filter GroupFilter, opts ListOptions) (Page[model.Group], error) {
+	query := database.DB.Model(&model.Group{})
+	if filter.IncludeDeleted {
+		query = query.Unscoped()
+	}
+	for _, term := range searchTerms(filter.Query) {
+		query = query.Where(`("group".name ILIKE ? OR "group".description ILIKE ?)`, term, term)
 	}
+	if filter.Source != "" {
+		source, _ := json.Marshal([]string{filter.Source})
+		query = query.Where(`"group".allowed_sources @> ?::jsonb`, string(source))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where(`"group".created_at > ?`, filter.CreatedAfter)
+	}
+	page, err := paginate(query, opts, groupListSpec)
+	if err != nil {
+		return Page[model.Group]{}, err
+	}
+	populateGroups(page.Data)
+	return page, nil
+}
+
+// populateGroups is PopulateGroup for a whole page: one grouped count per
+// table instead of three counts per group.
+func populateGroups(groups []model.Group) {
+	if len(groups) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(groups))
+	for _, g := range groups {
+		ids = append(ids, g.ID)
+	}
+	type groupCount struct {
+		GroupID string
+		Count   int64
+	}
+	count := func(query *gorm.DB) map[string]int64 {
+		var rows []groupCount
+		if err := query.Select("group_id, COUNT(*) AS count").Where("group_id IN ?", ids).Group("group_id").Scan(&rows).Error; err != nil {
+			logger.SugarLogger.Errorf("Failed to count for groups: %v", err)
+		}
+		counts := make(map[string]int64, len(rows))
+		for _, r := range rows {
+			counts[r.GroupID] = r.Count
+		}
+		return counts
+	}
+	members := count(database.DB.Model(&model.GroupMember{}))
+	owners := count(database.DB.Model(&model.GroupOwner{}))
+	pending := count(database.DB.Model(&model.GroupJoinRequest{}).Where("status = ?", model.GroupJoinRequestStatusPending))
 	for i := range groups {
-		PopulateGroup(&groups[i])
+		groups[i].MemberCount = members[groups[i].ID]
+		groups[i].OwnerCount = owners[groups[i].ID]
+		groups[i].PendingCount = pending[groups[i].ID]
 	}
-	return groups, nil
 }
 
 func GetGroupByID(id string) (model.Group, error) {
@@ -75,7 +171,9 @@ func UpdateGroup(group model.Group) (model.Group, error) {
 // own current name during an update.
 func IsGroupNameAvailable(name string, excludeID string) (bool, error) {
 	var count int64
-	q := database.DB.Model(&model.Group{}).Where("LOWER(name) = LOWER(?)", name)
+	// Unscoped: a soft-deleted group keeps its name until it's purged, so
+	// restoring it can't collide with a newer group.
+	q := database.DB.Unscoped().Model(&model.Group{}).Where("LOWER(name) = LOWER(?)", name)
 	if excludeID != "" {
 		q = q.Where("id != ?", excludeID)
 	}
@@ -85,6 +183,8 @@ func IsGroupNameAvailable(name string, excludeID string) (bool, error) {
 	return count == 0, nil
 }
 
+// DeleteGroup soft-deletes a group. Everything hanging off it stays in place
+// until RestoreGroup brings it back or PurgeDeletedRecords removes it.
 func DeleteGroup(id string) error {
 	if err := database.DB.Where("id = ?", id).Delete(&model.Group{}).Error; err != nil {
 		return err
@@ -92,6 +192,53 @@ func DeleteGroup(id string) error {
 	return nil
 }
 
+// GroupMemberFilter narrows ListGroupMembers. JoinedAfter is the members
+// list's created-after.
+type GroupMemberFilter struct {
+	Source      string
+	JoinedAfter time.Time
+}
+
+var groupMemberListSpec = listSpec[model.GroupMember]{
+	sorts: map[string]sortField[model.GroupMember]{
+		"joined_at": {column: "joined_at", kind: sortTime, value: func(m model.GroupMember) any { return m.JoinedAt }},
+		"entity_id": {column: "entity_id", kind: sortString, value: func(m model.GroupMember) any { return m.EntityID }},
+	},
+	defaultSort: "joined_at",
+	idColumn:    "entity_id",
+	id:          func(m model.GroupMember) string { return m.EntityID },
+}
+
+// ListGroupMembers returns one page of a group's effective members: its own
+// member rows, plus one INHERITED row for each entity that's only in the
+// group through a descendant group. ?source=INHERITED lists just those.
+func ListGroupMembers(groupID string, filter GroupMemberFilter, opts ListOptions) (Page[model.GroupMember], error) {
+	query := database.DB.Model(&model.GroupMember{}).Where("group_id = ?", groupID)
+	hierarchy, err := loadGroupHierarchy()
+	if err != nil {
+		return Page[model.GroupMember]{}, err
+	}
+	if descendants := hierarchy.descendants(groupID); len(descendants) > 0 {
+		direct := database.DB.Model(&model.GroupMember{}).
+			Select("group_id, entity_id, source, added_by, added_by_actor, has_expiration, expires_at, joined_at, CAST('' AS text) AS inherited_from").
+			Where("group_id = ?", groupID)
+		inherited := database.DB.Model(&model.GroupMember{}).
+			Select("CAST(? AS text) AS group_id, entity_id, CAST(? AS text) AS source, CAST('' AS text) AS added_by, CAST('' AS text) AS added_by_actor, false AS has_expiration, TIMESTAMPTZ '0001-01-01 00:00:00Z' AS expires_at, MIN(joined_at) AS joined_at, MIN(group_id) AS inherited_from",
+				groupID, model.GroupMemberSourceInherited).
+			Where("group_id IN ? AND entity_id NOT IN (?)", descendants,
+				database.DB.Model(&model.GroupMember{}).Select("entity_id").Where("group_id = ?", groupID)).
+			Group("entity_id")
+		query = database.DB.Table("(? UNION ALL ?) AS group_member", direct, inherited)
+	}
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if !filter.JoinedAfter.IsZero() {
+		query = query.Where("joined_at > ?", filter.JoinedAfter)
+	}
+	return paginate(query, opts, groupMemberListSpec)
+}
+
 func GetMembersForGroup(groupID string) ([]model.GroupMember, error) {
 	members := []model.GroupMember{}
 	if err := database.DB.Where("group_id = ?", groupID).Find(&members).Error; err != nil {
@@ -124,9 +271,10 @@ func UpdateGroupMember(member model.GroupMember) (model.GroupMember, error) {
 
 // GetMembershipsForEntity returns every GroupMember row for an entity,
 // optionally filtered to a single Source. An empty source returns all rows.
+// Memberships in soft-deleted groups are left out.
 func GetMembershipsForEntity(entityID, source string) ([]model.GroupMember, error) {
 	members := []model.GroupMember{}
-	q := database.DB.Where("entity_id = ?", entityID)
+	q := database.DB.Where("entity_id = ? AND group_id IN (?)", entityID, database.DB.Model(&model.Group{}).Select("id"))
 	if source != "" {
 		q = q.Where("source = ?", source)
 	}
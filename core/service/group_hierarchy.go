@@ -0,0 +1,295 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+)
+
+// ErrGroupChildSelfRef is returned when a group is linked as its own child.
+var ErrGroupChildSelfRef = errors.New("a group cannot be its own child")
+
+// ErrGroupChildCycle is returned when linking a child would close a cycle —
+// the parent's membership would end up feeding back into itself, through
+// other child links or conditional bindings.
+var ErrGroupChildCycle = errors.New("child group would create a cycle")
+
+// ErrGroupChildExists is returned when the child is already linked to the
+// parent. The API layer maps it to 409.
+var ErrGroupChildExists = errors.New("group is already a child of that group")
+
+// groupHierarchy is the set of parent/child links between live groups,
+// indexed both ways. Links touching a soft-deleted group are left out, so a
+// deleted child stops passing its members up until it's restored.
+type groupHierarchy struct {
+	parents  map[string][]string
+	children map[string][]string
+}
+
+func loadGroupHierarchy() (groupHierarchy, error) {
+	var links []model.GroupChild
+	if err := database.DB.Find(&links).Error; err != nil {
+		return groupHierarchy{}, err
+	}
+	var live []string
+	if err := database.DB.Model(&model.Group{}).Pluck("id", &live).Error; err != nil {
+		return groupHierarchy{}, err
+	}
+	return newGroupHierarchy(links, live), nil
+}
+
+// newGroupHierarchy indexes the links whose parent and child are both in
+// liveGroupIDs.
+func newGroupHierarchy(links []model.GroupChild, liveGroupIDs []string) groupHierarchy {
+	live := make(map[string]struct{}, len(liveGroupIDs))
+	for _, id := range liveGroupIDs {
+		live[id] = struct{}{}
+	}
+	h := groupHierarchy{
+		parents:  make(map[string][]string),
+		children: make(map[string][]string),
+	}
+	for _, l := range links {
+		if _, ok := live[l.ParentGroupID]; !ok {
+			continue
+		}
+		if _, ok := live[l.ChildGroupID]; !ok {
+			continue
+		}
+		h.parents[l.ChildGroupID] = append(h.parents[l.ChildGroupID], l.ParentGroupID)
+		h.children[l.ParentGroupID] = append(h.children[l.ParentGroupID], l.ChildGroupID)
+	}
+	return h
+}
+
+// ancestors returns every group the given groups are (transitively) nested
+// under, mapped to the group in groupIDs it was reached from. Groups that
+// are themselves in groupIDs are left out. Inputs are walked in sorted order
+// so the "reached from" group is stable.
+func (h groupHierarchy) ancestors(groupIDs []string) map[string]string {
+	held := make(map[string]struct{}, len(groupIDs))
+	for _, id := range groupIDs {
+		held[id] = struct{}{}
+	}
+	sorted := append([]string(nil), groupIDs...)
+	sort.Strings(sorted)
+
+	via := make(map[string]string)
+	for _, start := range sorted {
+		queue := []string{start}
+		for len(queue) > 0 {
+			node := queue[0]
+			queue = queue[1:]
+			for _, parent := range h.parents[node] {
+				if _, ok := held[parent]; ok {
+					continue
+				}
+				if _, ok := via[parent]; ok {
+					continue
+				}
+				via[parent] = start
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return via
+}
+
+// descendants returns every group nested (transitively) under groupID.
+func (h groupHierarchy) descendants(groupID string) []string {
+	seen := map[string]struct{}{groupID: {}}
+	var out []string
+	queue := []string{groupID}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, child := range h.children[node] {
+			if _, ok := seen[child]; ok {
+				continue
+			}
+			seen[child] = struct{}{}
+			out = append(out, child)
+			queue = append(queue, child)
+		}
+	}
+	return out
+}
+
+// GetAllGroupChildren returns every parent/child link between live groups.
+func GetAllGroupChildren() ([]model.GroupChild, error) {
+	liveGroups := database.DB.Model(&model.Group{}).Select("id")
+	links := []model.GroupChild{}
+	if err := database.DB.Where("parent_group_id IN (?) AND child_group_id IN (?)", liveGroups, liveGroups).Find(&links).Error; err != nil {
+		return []model.GroupChild{}, err
+	}
+	return links, nil
+}
+
+// GetChildGroups returns the groups linked directly under parentID.
+func GetChildGroups(parentID string) ([]model.Group, error) {
+	groups := []model.Group{}
+	if err := database.DB.
+		Where("id IN (?)", database.DB.Model(&model.GroupChild{}).Select("child_group_id").Where("parent_group_id = ?", parentID)).
+		Order("name").
+		Find(&groups).Error; err != nil {
+		return []model.Group{}, err
+	}
+	populateGroups(groups)
+	return groups, nil
+}
+
+// GetParentGroups returns the groups childID is linked directly under.
+func GetParentGroups(childID string) ([]model.Group, error) {
+	groups := []model.Group{}
+	if err := database.DB.
+		Where("id IN (?)", database.DB.Model(&model.GroupChild{}).Select("parent_group_id").Where("child_group_id = ?", childID)).
+		Order("name").
+		Find(&groups).Error; err != nil {
+		return []model.Group{}, err
+	}
+	populateGroups(groups)
+	return groups, nil
+}
+
+// AddChildGroup links childID under parentID, so the child's members become
+// effective members of the parent. Both groups must exist. The link is
+// refused if the parent's membership already feeds the child's, through
+// child links or conditional bindings, since that would be a cycle.
+func AddChildGroup(parentID, childID, addedBy string) (model.GroupChild, error) {
+	if parentID == childID {
+		return model.GroupChild{}, ErrGroupChildSelfRef
+	}
+	if _, err := GetGroupByID(parentID); err != nil {
+		return model.GroupChild{}, err
+	}
+	if _, err := GetGroupByID(childID); err != nil {
+		return model.GroupChild{}, err
+	}
+
+	var existing int64
+	if err := database.DB.Model(&model.GroupChild{}).
+		Where("parent_group_id = ? AND child_group_id = ?", parentID, childID).
+		Count(&existing).Error; err != nil {
+		return model.GroupChild{}, err
+	}
+	if existing > 0 {
+		return model.GroupChild{}, ErrGroupChildExists
+	}
+
+	links, err := GetAllGroupChildren()
+	if err != nil {
+		return model.GroupChild{}, fmt.Errorf("load existing child groups: %w", err)
+	}
+	bindings, err := GetAllConditionalBindings()
+	if err != nil {
+		return model.GroupChild{}, fmt.Errorf("load existing bindings: %w", err)
+	}
+	if childLinkCreatesCycle(parentID, childID, bindings, links) {
+		return model.GroupChild{}, ErrGroupChildCycle
+	}
+
+	link := model.GroupChild{
+		ParentGroupID: parentID,
+		ChildGroupID:  childID,
+		AddedBy:       addedBy,
+	}
+	if err := database.DB.Create(&link).Error; err != nil {
+		return model.GroupChild{}, err
+	}
+	return link, nil
+}
+
+// childLinkCreatesCycle reports whether linking childID under parentID
+// would make the parent's membership feed back into itself: the child
+// already depends on the parent through existing child links or
+// conditional bindings, or is the parent.
+func childLinkCreatesCycle(parentID, childID string, bindings []model.GroupConditionalBinding, links []model.GroupChild) bool {
+	return dependsOn(groupDependencies(bindings, links), childID, parentID)
+}
+
+// RemoveChildGroup unlinks childID from parentID. Members who were only in
+// the parent through that child stop being effective members right away.
+func RemoveChildGroup(parentID, childID string) error {
+	return database.DB.
+		Where("parent_group_id = ? AND child_group_id = ?", parentID, childID).
+		Delete(&model.GroupChild{}).Error
+}
+
+// GetEffectiveMembershipsForEntity returns the entity's stored memberships
+// (as GetMembershipsForEntity with no source) followed by an INHERITED row
+// for every group it's in only through a child group. InheritedFrom on
+// those rows is the stored membership's group.
+func GetEffectiveMembershipsForEntity(entityID string) ([]model.GroupMember, error) {
+	memberships, err := GetMembershipsForEntity(entityID, "")
+	if err != nil {
+		return []model.GroupMember{}, err
+	}
+	h, err := loadGroupHierarchy()
+	if err != nil {
+		return []model.GroupMember{}, err
+	}
+	groupIDs := make([]string, 0, len(memberships))
+	byGroup := make(map[string]model.GroupMember, len(memberships))
+	for _, m := range memberships {
+		groupIDs = append(groupIDs, m.GroupID)
+		byGroup[m.GroupID] = m
+	}
+	inherited := h.ancestors(groupIDs)
+	ancestorIDs := make([]string, 0, len(inherited))
+	for id := range inherited {
+		ancestorIDs = append(ancestorIDs, id)
+	}
+	sort.Strings(ancestorIDs)
+	for _, id := range ancestorIDs {
+		from := inherited[id]
+		memberships = append(memberships, model.GroupMember{
+			GroupID:       id,
+			EntityID:      entityID,
+			Source:        string(model.GroupMemberSourceInherited),
+			JoinedAt:      byGroup[from].JoinedAt,
+			InheritedFrom: from,
+		})
+	}
+	return memberships, nil
+}
+
+// effectiveGroupIDs returns the IDs of every group the entity is an
+// effective member of: its stored memberships plus their ancestors.
+func effectiveGroupIDs(entityID string) ([]string, error) {
+	memberships, err := GetEffectiveMembershipsForEntity(entityID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(memberships))
+	for _, m := range memberships {
+		ids = append(ids, m.GroupID)
+	}
+	return ids, nil
+}
+
+// IsEffectiveGroupMember reports whether the entity is in groupID directly
+// or through any of its descendant groups. Lookup failures count as not a
+// member.
+func IsEffectiveGroupMember(groupID, entityID string) bool {
+	if _, err := GetGroupMember(groupID, entityID); err == nil {
+		return true
+	}
+	h, err := loadGroupHierarchy()
+	if err != nil {
+		return false
+	}
+	descendants := h.descendants(groupID)
+	if len(descendants) == 0 {
+		return false
+	}
+	var count int64
+	if err := database.DB.Model(&model.GroupMember{}).
+		Where("group_id IN ? AND entity_id = ?", descendants, entityID).
+		Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
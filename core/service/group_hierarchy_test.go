@@ -0,0 +1,145 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gaucho-racing/sentinel/core/model"
+)
+
+func childLinks(pairs ...string) []model.GroupChild {
+	out := make([]model.GroupChild, 0, len(pairs))
+	for _, pair := range pairs {
+		parent, child, _ := strings.Cut(pair, ">")
+		out = append(out, model.GroupChild{ParentGroupID: parent, ChildGroupID: child})
+	}
+	return out
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestChildLinkCreatesCycle(t *testing.T) {
+	// A diamond: a has children b and c, which both have child d.
+	diamond := childLinks("a>b", "a>c", "b>d", "c>d")
+	binding := []model.GroupConditionalBinding{{GroupID: "x", RequiredGroupIDs: model.StringSlice{"a"}}}
+
+	tests := []struct {
+		name          string
+		parent, child string
+		bindings      []model.GroupConditionalBinding
+		want          bool
+	}{
+		{"own child", "a", "a", nil, true},
+		{"direct reverse", "d", "b", nil, true},
+		{"through the diamond", "d", "a", nil, true},
+		{"second path into the diamond", "b", "c", nil, false},
+		{"new leaf", "d", "e", nil, false},
+		{"new root", "e", "a", nil, false},
+		{"through a conditional binding", "a", "x", binding, true},
+		{"binding elsewhere", "e", "x", binding, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := childLinkCreatesCycle(tt.parent, tt.child, tt.bindings, diamond); got != tt.want {
+				t.Errorf("childLinkCreatesCycle(%s, %s) = %v, want %v", tt.parent, tt.child, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupHierarchy(t *testing.T) {
+	all := []string{"a", "b", "c", "d", "e"}
+	tests := []struct {
+		name            string
+		links           []model.GroupChild
+		live            []string
+		held            []string
+		wantAncestors   map[string]string
+		root            string
+		wantDescendants []string
+	}{
+		{
+			name:            "chain",
+			links:           childLinks("a>b", "b>c"),
+			live:            all,
+			held:            []string{"c"},
+			wantAncestors:   map[string]string{"a": "c", "b": "c"},
+			root:            "a",
+			wantDescendants: []string{"b", "c"},
+		},
+		{
+			name:            "diamond reaches the top once",
+			links:           childLinks("a>b", "a>c", "b>d", "c>d"),
+			live:            all,
+			held:            []string{"d"},
+			wantAncestors:   map[string]string{"a": "d", "b": "d", "c": "d"},
+			root:            "a",
+			wantDescendants: []string{"b", "c", "d"},
+		},
+		{
+			name:            "groups already held aren't inherited",
+			links:           childLinks("a>b", "a>c", "b>d", "c>d"),
+			live:            all,
+			held:            []string{"d", "b"},
+			wantAncestors:   map[string]string{"a": "b", "c": "d"},
+			root:            "b",
+			wantDescendants: []string{"d"},
+		},
+		{
+			name:            "soft-deleted intermediate breaks the chain",
+			links:           childLinks("a>b", "b>c"),
+			live:            []string{"a", "c"},
+			held:            []string{"c"},
+			wantAncestors:   map[string]string{},
+			root:            "a",
+			wantDescendants: nil,
+		},
+		{
+			name:            "soft-deleted intermediate with another path",
+			links:           childLinks("a>b", "b>d", "a>c", "c>d"),
+			live:            []string{"a", "c", "d"},
+			held:            []string{"d"},
+			wantAncestors:   map[string]string{"a": "d", "c": "d"},
+			root:            "a",
+			wantDescendants: []string{"c", "d"},
+		},
+		{
+			name:            "soft-deleted top",
+			links:           childLinks("a>b", "b>c"),
+			live:            []string{"b", "c"},
+			held:            []string{"c"},
+			wantAncestors:   map[string]string{"b": "c"},
+			root:            "a",
+			wantDescendants: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newGroupHierarchy(tt.links, tt.live)
+
+			got := h.ancestors(tt.held)
+			if strings.Join(sortedKeys(got), ",") != strings.Join(sortedKeys(tt.wantAncestors), ",") {
+				t.Fatalf("ancestors(%v) = %v, want %v", tt.held, got, tt.wantAncestors)
+			}
+			for id, via := range tt.wantAncestors {
+				if got[id] != via {
+					t.Errorf("ancestors(%v)[%s] reached from %s, want %s", tt.held, id, got[id], via)
+				}
+			}
+
+			descendants := h.descendants(tt.root)
+			sort.Strings(descendants)
+			if strings.Join(descendants, ",") != strings.Join(tt.wantDescendants, ",") {
+				t.Errorf("descendants(%s) = %v, want %v", tt.root, descendants, tt.wantDescendants)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// MaxMembershipImportRows caps one bulk import. A fall recruiting class is
+// well under this; anything larger should be split up.
+const MaxMembershipImportRows = 1000
+
+// ErrImportTooLarge is returned when an import has more than
+// MaxMembershipImportRows rows.
+var ErrImportTooLarge = fmt.Errorf("imports are limited to %d rows", MaxMembershipImportRows)
+
+// MembershipImportRow is one entity to add. Identifier is an entity ID, an
+// email, a Discord user ID, or a username (optionally @-prefixed).
+type MembershipImportRow struct {
+	Identifier    string    `json:"identifier"`
+	HasExpiration bool      `json:"has_expiration"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type MembershipImportStatus string
+
+const (
+	MembershipImportAdded MembershipImportStatus = "ADDED"
+	// MembershipImportExists means the entity already had a member row, or
+	// appeared earlier in the same import. Not an error.
+	MembershipImportExists MembershipImportStatus = "EXISTS"
+	MembershipImportError  MembershipImportStatus = "ERROR"
+	// MembershipImportNotApplied marks a valid row that wasn't written
+	// because an all-or-nothing import had errors elsewhere.
+	MembershipImportNotApplied MembershipImportStatus = "NOT_APPLIED"
+)
+
+// MembershipImportResult is the outcome for one input row. Row is 1-based
+// and counts data rows only, so it lines up with the caller's list.
+type MembershipImportResult struct {
+	Row        int                    `json:"row"`
+	Identifier string                 `json:"identifier"`
+	EntityID   string                 `json:"entity_id,omitempty"`
+	Status     MembershipImportStatus `json:"status"`
+	Message    string                 `json:"message,omitempty"`
+}
+
+// MembershipImportReport summarizes an import. Applied is false only when
+// an all-or-nothing import was refused.
+type MembershipImportReport struct {
+	GroupID string                   `json:"group_id"`
+	Applied bool                     `json:"applied"`
+	Added   int                      `json:"added"`
+	Errors  int                      `json:"errors"`
+	Results []MembershipImportResult `json:"results"`
+}
+
+// ResolveEntityIdentifier maps a roster identifier to an entity ID. The
+// shape picks the lookup: "ent_…" is an entity ID, anything with an @ past
+// the first character is an email, all digits is a Discord user ID, and
+// everything else is a username. Returns gorm.ErrRecordNotFound when
+// nothing matches.
+func ResolveEntityIdentifier(identifier string) (string, error) {
+	identifier = strings.TrimSpace(identifier)
+	switch {
+	case identifier == "":
+		return "", gorm.ErrRecordNotFound
+	case strings.HasPrefix(identifier, "ent_"):
+		var entity model.Entity
+		if err := database.DB.Where("id = ?", identifier).First(&entity).Error; err != nil {
+			return "", err
+		}
+		return entity.ID, nil
+	case strings.Contains(identifier[1:], "@"):
+		var email model.EntityEmail
+		if err := database.DB.Where("LOWER(email) = LOWER(?)", identifier).First(&email).Error; err != nil {
+			return "", err
+		}
+		return email.EntityID, nil
+	case isDigits(identifier):
+		var auth model.EntityExternalAuth
+		if err := database.DB.
+			Where("UPPER(provider) = ? AND external_id = ?", model.ExternalAuthProviderDiscord, identifier).
+			First(&auth).Error; err != nil {
+			return "", err
+		}
+		return auth.EntityID, nil
+	default:
+		var user model.User
+		if err := database.DB.Where("LOWER(username) = LOWER(?)", strings.TrimPrefix(identifier, "@")).First(&user).Error; err != nil {
+			return "", err
+		}
+		return user.EntityID, nil
+	}
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// ImportGroupMembers adds every row as a DIRECT member of groupID. Rows are
+// resolved and validated up front; an entity that already has a member row
+// is reported as EXISTS and left alone. In allOrNothing mode any ERROR row
+// blocks the whole import, and the writes that do happen share one
+// transaction. Otherwise each valid row is written on its own and failures
+// stay per-row.
+//
+// Checking the group's AllowedSources is the caller's job, since who may
+// bypass it depends on the caller. addedByActor is the service acting for
+// addedBy, if any.
+func ImportGroupMembers(groupID string, rows []MembershipImportRow, addedBy, addedByActor string, allOrNothing bool) (MembershipImportReport, error) {
+	if len(rows) > MaxMembershipImportRows {
+		return MembershipImportReport{}, ErrImportTooLarge
+	}
+	report := MembershipImportReport{
+		GroupID: groupID,
+		Results: make([]MembershipImportResult, len(rows)),
+	}
+
+	var existing []string
+	if err := database.DB.Model(&model.GroupMember{}).Where("group_id = ?", groupID).Pluck("entity_id", &existing).Error; err != nil {
+		return MembershipImportReport{}, err
+	}
+	seenAt := make(map[string]int, len(existing)+len(rows))
+	for _, id := range existing {
+		seenAt[id] = 0
+	}
+
+	var pending []int
+	for i, row := range rows {
+		result := MembershipImportResult{Row: i + 1, Identifier: strings.TrimSpace(row.Identifier)}
+		entityID, err := ResolveEntityIdentifier(row.Identifier)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			result.Status = MembershipImportError
+			result.Message = "no entity matches this identifier"
+		case err != nil:
+			return MembershipImportReport{}, fmt.Errorf("resolve row %d: %w", i+1, err)
+		default:
+			result.EntityID = entityID
+			if err := ValidateMembershipExpiration(row.HasExpiration, row.ExpiresAt); err != nil {
+				result.Status = MembershipImportError
+				result.Message = err.Error()
+			} else if first, ok := seenAt[entityID]; ok {
+				result.Status = MembershipImportExists
+				if first > 0 {
+					result.Message = fmt.Sprintf("same entity as row %d", first)
+				} else {
+					result.Message = "already a member"
+				}
+			} else {
+				seenAt[entityID] = i + 1
+				pending = append(pending, i)
+			}
+		}
+		if result.Status == MembershipImportError {
+			report.Errors++
+		}
+		report.Results[i] = result
+	}
+
+	if allOrNothing && report.Errors > 0 {
+		for _, i := range pending {
+			report.Results[i].Status = MembershipImportNotApplied
+		}
+		return report, nil
+	}
+	report.Applied = true
+
+	memberFor := func(i int) model.GroupMember {
+		return model.GroupMember{
+			GroupID:       groupID,
+			EntityID:      report.Results[i].EntityID,
+			Source:        string(model.GroupMemberSourceDirect),
+			AddedBy:       addedBy,
+			AddedByActor:  addedByActor,
+			HasExpiration: rows[i].HasExpiration,
+			ExpiresAt:     rows[i].ExpiresAt,
+		}
+	}
+	if allOrNothing {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			for _, i := range pending {
+				member := memberFor(i)
+				if err := tx.Create(&member).Error; err != nil {
+					return fmt.Errorf("add row %d: %w", i+1, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return MembershipImportReport{}, err
+		}
+		for _, i := range pending {
+			report.Results[i].Status = MembershipImportAdded
+		}
+	} else {
+		for _, i := range pending {
+			if _, err := CreateGroupMember(memberFor(i)); err != nil {
+				report.Results[i].Status = MembershipImportError
+				report.Results[i].Message = err.Error()
+				report.Errors++
+				continue
+			}
+			report.Results[i].Status = MembershipImportAdded
+		}
+	}
+
+	for _, r := range report.Results {
+		if r.Status != MembershipImportAdded {
+			continue
+		}
+		report.Added++
+		// Same as a single add: the entity's group set changed.
+		ReconcileConditionalForEntity(r.EntityID)
+	}
+	logger.SugarLogger.Infof("group import: added %d members to %s (%d errors)", report.Added, groupID, report.Errors)
+	return report, nil
+}
+
+// GetUsersByEntityIDs returns the populated users for the given entities,
+// keyed by entity ID. Entities without a user (service accounts) are
+// absent from the map.
+func GetUsersByEntityIDs(entityIDs []string) (map[string]model.User, error) {
+	users := []model.User{}
+	if len(entityIDs) > 0 {
+		if err := database.DB.Where("entity_id IN ?", entityIDs).Find(&users).Error; err != nil {
+			return nil, err
+		}
+	}
+	populateUsers(users)
+	byEntity := make(map[string]model.User, len(users))
+	for _, u := range users {
+		byEntity[u.EntityID] = u
+	}
+	return byEntity, nil
+}
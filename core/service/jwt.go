@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 	"time"
 
 	"github.com/gaucho-racing/sentinel/core/config"
@@ -121,8 +122,19 @@ func PublicKeyToJWKS(publicKey *rsa.PublicKey) map[string]interface{} {
 	}
 }
 
-func GenerateToken(entityID string, clientID string, scope string, expiresIn int, claims map[string]interface{}) (string, string, error) {
+// GenerateToken signs a token for entityID and records it. aud is clientID,
+// followed by any resourceServers — the client_ids of APIs whose scopes
+// were granted — so those APIs can check the token was meant for them.
+// familyID, if set, puts the token in a refresh token family, so it's
+// revoked along with the family.
+func GenerateToken(entityID string, clientID string, scope string, expiresIn int, claims map[string]interface{}, familyID string, resourceServers ...string) (string, string, error) {
 	expirationTime := time.Now().Add(time.Duration(expiresIn) * time.Second)
+	audience := jwt.ClaimStrings{clientID}
+	for _, aud := range resourceServers {
+		if aud != "" && !slices.Contains(audience, aud) {
+			audience = append(audience, aud)
+		}
+	}
 
 	tokenID := ulid.Make().Prefixed("jwt")
 	tokenClaims := &model.TokenClaims{
@@ -132,7 +144,7 @@ func GenerateToken(entityID string, clientID string, scope string, expiresIn int
 			ID:        tokenID,
 			Subject:   entityID,
 			Issuer:    config.Issuer,
-			Audience:  jwt.ClaimStrings{clientID},
+			Audience:  audience,
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 		},
@@ -151,7 +163,9 @@ func GenerateToken(entityID string, clientID string, scope string, expiresIn int
 		EntityID:  entityID,
 		ClientID:  clientID,
 		Scope:     scope,
+		ActorID:   model.ActorFromClaims(claims),
 		ExpiresAt: expirationTime,
+		FamilyID:  familyID,
 	}
 	if err := database.DB.Create(dbToken).Error; err != nil {
 		logger.SugarLogger.Errorf("Failed to save token: %v", err)
@@ -162,6 +176,28 @@ func GenerateToken(entityID string, clientID string, scope string, expiresIn int
 }
 
 func ValidateToken(token string) (*model.TokenClaims, error) {
+	claims, err := parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	dbToken := &model.Token{}
+	result := database.DB.Where("id = ?", claims.ID).First(&dbToken)
+	if result.Error != nil || dbToken.RotatedAt != nil {
+		return nil, fmt.Errorf("token has been revoked")
+	}
+	// Suspension revokes outstanding tokens when it's applied; checking here
+	// as well closes the window for tokens minted while it was in flight.
+	if err := CheckEntityActive(claims.Subject); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// parseToken checks a token's signature, expiry, and audience, but not
+// whether it's been revoked.
+func parseToken(token string) (*model.TokenClaims, error) {
 	claims := &model.TokenClaims{}
 
 	parsedToken, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
@@ -178,13 +214,6 @@ func ValidateToken(token string) (*model.TokenClaims, error) {
 	if len(claims.Audience) == 0 {
 		return nil, fmt.Errorf("token has invalid audience")
 	}
-
-	dbToken := &model.Token{}
-	result := database.DB.Where("id = ?", claims.ID).First(&dbToken)
-	if result.Error != nil {
-		return nil, fmt.Errorf("token has been revoked")
-	}
-
 	return claims, nil
 }
 
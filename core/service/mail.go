@@ -0,0 +1,86 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/config"
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/pkg/logger"
+	"github.com/gaucho-racing/sentinel/core/pkg/mailer"
+	"github.com/gaucho-racing/ulid-go"
+)
+
+// emailRequestThrottle limits how often one kind of mail can be requested
+// for an address: not within MinInterval of the last request, and at most
+// WindowMax per Window.
+type emailRequestThrottle struct {
+	MinInterval time.Duration
+	Window      time.Duration
+	WindowMax   int
+}
+
+// allow records a request of kind for email and reports whether it's
+// within the throttle. Every request is counted, registered address or
+// not, so a throttled unknown address looks the same as a known one.
+func (t emailRequestThrottle) allow(kind model.EmailRequestKind, email string) (bool, error) {
+	email = normalizeEmail(email)
+	var recent []model.EmailRequest
+	if err := database.DB.
+		Where("kind = ? AND email = ? AND created_at > ?", kind, email, time.Now().Add(-t.Window)).
+		Order("created_at DESC").
+		Find(&recent).Error; err != nil {
+		return false, err
+	}
+	if len(recent) >= t.WindowMax {
+		return false, nil
+	}
+	if len(recent) > 0 && time.Since(recent[0].CreatedAt) < t.MinInterval {
+		return false, nil
+	}
+	request := model.EmailRequest{ID: ulid.Make().Prefixed("ereq"), Kind: kind, Email: email}
+	if err := database.DB.Create(&request).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// normalizeEmail is the form an address is throttled, stored, and matched
+// in, so "Alice@x" and "alice@x" are one address everywhere.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// InitMailer configures the process-wide mail sender from MAIL_SINK. An
+// unknown sink falls back to the log sender so a typo in dev config doesn't
+// take login codes down with it; in production it's fatal, since silently
+// logging codes instead of delivering them would be both broken and a leak.
+func InitMailer() {
+	switch config.MailSink {
+	case "smtp":
+		if config.SMTPHost == "" {
+			logger.SugarLogger.Fatalf("MAIL_SINK is smtp but SMTP_HOST is not set")
+			return
+		}
+		mailer.SetSender(mailer.SMTPSender{
+			Host:     config.SMTPHost,
+			Port:     config.SMTPPort,
+			Username: config.SMTPUsername,
+			Password: config.SMTPPassword,
+			From:     config.SMTPFrom,
+		})
+	case "file":
+		mailer.SetSender(&mailer.FileSender{Path: config.MailSinkFile})
+	case "log":
+		mailer.SetSender(mailer.LogSender{})
+	default:
+		if config.IsProduction() {
+			logger.SugarLogger.Fatalf("Unknown MAIL_SINK %q", config.MailSink)
+			return
+		}
+		logger.SugarLogger.Warnf("Unknown MAIL_SINK %q, falling back to log", config.MailSink)
+		mailer.SetSender(mailer.LogSender{})
+	}
+	logger.SugarLogger.Infof("Mailer initialized with %s sink", config.MailSink)
+}
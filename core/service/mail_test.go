@@ -0,0 +1,18 @@
+package service
+
+import "testing"
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := map[string]string{
+		"alice@example.com":     "alice@example.com",
+		"Alice@Example.COM":     "alice@example.com",
+		"  alice@example.com\n": "alice@example.com",
+		" ALICE@EXAMPLE.COM ":   "alice@example.com",
+		"":                      "",
+	}
+	for in, want := range tests {
+		if got := normalizeEmail(in); got != want {
+			t.Errorf("normalizeEmail(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
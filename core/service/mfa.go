@@ -0,0 +1,609 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/pkg/logger"
+	"github.com/gaucho-racing/ulid-go"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMFAAlreadyEnrolled  = errors.New("mfa is already enabled for this account")
+	ErrMFANotEnrolled      = errors.New("mfa is not enabled for this account")
+	ErrMFAEnrollmentAbsent = errors.New("no pending mfa enrollment, start enrollment first")
+	ErrInvalidMFACode      = errors.New("invalid mfa code")
+	ErrMFARequiredByPolicy = errors.New("mfa is required by one of your groups and cannot be disabled")
+	// ErrInvalidMFAChallenge covers unknown, expired, used, and exhausted
+	// challenge tokens alike.
+	ErrInvalidMFAChallenge = errors.New("invalid or expired mfa challenge")
+	ErrMFALocked           = errors.New("too many failed mfa attempts, try again later")
+)
+
+// Authentication method references stamped into the amr claim (RFC 8176).
+// AMRFederated isn't a registered value; it marks a sign-in delegated to an
+// external identity provider such as Discord.
+const (
+	AMRPassword    = "pwd"
+	AMROTP         = "otp"
+	AMRHardwareKey = "hwk"
+	AMRMFA         = "mfa"
+	AMRFederated   = "fed"
+)
+
+// Second factors a challenge can be completed with, reported to the login
+// page so it only offers what the entity has set up.
+const (
+	MFAMethodTOTP    = "totp"
+	MFAMethodPasskey = "passkey"
+)
+
+const (
+	mfaChallengeTTL         = 5 * time.Minute
+	mfaChallengeMaxAttempts = 5
+	mfaRecoveryCodeCount    = 10
+	// An entity with more than mfaLockoutAttempts failed second factors in
+	// mfaLockoutWindow is locked out, however many challenges they came from.
+	mfaLockoutAttempts = 10
+	mfaLockoutWindow   = 15 * time.Minute
+)
+
+// MFAStatus summarizes an entity's MFA state for the settings UI and login.
+type MFAStatus struct {
+	Enabled                bool `json:"enabled"`
+	Pending                bool `json:"pending"`
+	RequiredByPolicy       bool `json:"required_by_policy"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+	Passkeys               int  `json:"passkeys"`
+}
+
+// TOTPEnrollment is returned when enrollment starts. The secret is shown once
+// so the user can type it in if they can't scan the URI as a QR code.
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// MFAChallengeResult is the outcome of the first login factor. When Required
+// is false the caller can mint a session straight away.
+type MFAChallengeResult struct {
+	Required           bool      `json:"mfa_required"`
+	EnrollmentRequired bool      `json:"enrollment_required,omitempty"`
+	Methods            []string  `json:"methods,omitempty"`
+	Token              string    `json:"mfa_token,omitempty"`
+	ExpiresAt          time.Time `json:"expires_at,omitempty"`
+}
+
+// MFAChallengeVerification is returned once the second factor is accepted.
+// RecoveryCodes is only set when the challenge completed a first enrollment.
+type MFAChallengeVerification struct {
+	EntityID      string   `json:"entity_id"`
+	AMR           []string `json:"amr"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+func getEntityMFA(entityID string) (model.EntityMFA, error) {
+	var mfa model.EntityMFA
+	if err := database.DB.Where("entity_id = ?", entityID).First(&mfa).Error; err != nil {
+		return model.EntityMFA{}, err
+	}
+	return mfa, nil
+}
+
+// IsMFAEnabled reports whether the entity has a confirmed TOTP enrollment.
+func IsMFAEnabled(entityID string) bool {
+	mfa, err := getEntityMFA(entityID)
+	return err == nil && mfa.Enabled
+}
+
+// IsMFARequiredByPolicy reports whether any group the entity is an
+// effective member of, inherited memberships included, has RequireMFA set.
+func IsMFARequiredByPolicy(entityID string) (bool, error) {
+	groupIDs, err := effectiveGroupIDs(entityID)
+	if err != nil {
+		return false, err
+	}
+	if len(groupIDs) == 0 {
+		return false, nil
+	}
+	var count int64
+	err = database.DB.Model(&model.Group{}).
+		Where(`id IN ? AND require_mfa = ?`, groupIDs, true).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func GetMFAStatus(entityID string) (MFAStatus, error) {
+	status := MFAStatus{}
+	mfa, err := getEntityMFA(entityID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return MFAStatus{}, err
+	}
+	status.Enabled = mfa.Enabled
+	status.Pending = mfa.EntityID != "" && !mfa.Enabled
+	status.RequiredByPolicy, err = IsMFARequiredByPolicy(entityID)
+	if err != nil {
+		return MFAStatus{}, err
+	}
+	var remaining int64
+	if err := database.DB.Model(&model.MFARecoveryCode{}).
+		Where("entity_id = ? AND used_at IS NULL", entityID).
+		Count(&remaining).Error; err != nil {
+		return MFAStatus{}, err
+	}
+	status.RecoveryCodesRemaining = int(remaining)
+	var passkeys int64
+	if err := database.DB.Model(&model.EntityPasskey{}).
+		Where("entity_id = ?", entityID).
+		Count(&passkeys).Error; err != nil {
+		return MFAStatus{}, err
+	}
+	status.Passkeys = int(passkeys)
+	return status, nil
+}
+
+// BeginTOTPEnrollment generates a fresh secret and stores it as a pending
+// enrollment, replacing any earlier pending one. Refuses if MFA is already
+// enabled — disable it first to re-enroll.
+func BeginTOTPEnrollment(entityID string) (TOTPEnrollment, error) {
+	existing, err := getEntityMFA(entityID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return TOTPEnrollment{}, err
+	}
+	if existing.Enabled {
+		return TOTPEnrollment{}, ErrMFAAlreadyEnrolled
+	}
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	mfa := model.EntityMFA{
+		EntityID:   entityID,
+		TOTPSecret: secret,
+		Enabled:    false,
+	}
+	if err := database.DB.Save(&mfa).Error; err != nil {
+		return TOTPEnrollment{}, err
+	}
+	return TOTPEnrollment{
+		Secret:     secret,
+		OTPAuthURI: totpURI(secret, mfaAccountLabel(entityID)),
+	}, nil
+}
+
+// mfaAccountLabel picks what the authenticator app shows under "Sentinel":
+// the login email when there is one, otherwise the entity ID.
+func mfaAccountLabel(entityID string) string {
+	auth, err := GetEmailAuthForEntity(entityID)
+	if err == nil && auth.Email != "" {
+		return auth.Email
+	}
+	return entityID
+}
+
+// ConfirmTOTPEnrollment activates a pending enrollment once the user proves
+// their authenticator produces valid codes, and returns a fresh set of
+// recovery codes.
+func ConfirmTOTPEnrollment(entityID string, code string) ([]string, error) {
+	mfa, err := getEntityMFA(entityID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMFAEnrollmentAbsent
+		}
+		return nil, err
+	}
+	if mfa.Enabled {
+		return nil, ErrMFAAlreadyEnrolled
+	}
+	step, ok := verifyTOTP(mfa.TOTPSecret, code, time.Now(), 0)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	now := time.Now()
+	if err := database.DB.Model(&model.EntityMFA{}).Where("entity_id = ?", entityID).Updates(map[string]interface{}{
+		"enabled":        true,
+		"confirmed_at":   now,
+		"last_used_step": step,
+	}).Error; err != nil {
+		return nil, err
+	}
+	codes, err := replaceRecoveryCodes(entityID)
+	if err != nil {
+		return nil, err
+	}
+	logger.SugarLogger.Infof("MFA enabled for entity %s", entityID)
+	return codes, nil
+}
+
+// DisableMFA removes the entity's enrollment and recovery codes after
+// checking a current code. Refused while group policy requires MFA, unless a
+// passkey remains to serve as the second factor.
+func DisableMFA(entityID string, code string) error {
+	required, err := IsMFARequiredByPolicy(entityID)
+	if err != nil {
+		return err
+	}
+	if required && !HasPasskeys(entityID) {
+		return ErrMFARequiredByPolicy
+	}
+	if _, err := verifyMFACode(entityID, code); err != nil {
+		return err
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("entity_id = ?", entityID).Delete(&model.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("entity_id = ?", entityID).Delete(&model.EntityMFA{}).Error
+	})
+	if err != nil {
+		return err
+	}
+	logger.SugarLogger.Infof("MFA disabled for entity %s", entityID)
+	return nil
+}
+
+// RegenerateRecoveryCodes invalidates the existing recovery codes and issues
+// a new set, after checking a current code.
+func RegenerateRecoveryCodes(entityID string, code string) ([]string, error) {
+	if _, err := verifyMFACode(entityID, code); err != nil {
+		return nil, err
+	}
+	return replaceRecoveryCodes(entityID)
+}
+
+func replaceRecoveryCodes(entityID string) ([]string, error) {
+	codes := make([]string, 0, mfaRecoveryCodeCount)
+	rows := make([]model.MFARecoveryCode, 0, mfaRecoveryCodeCount)
+	for i := 0; i < mfaRecoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		rows = append(rows, model.MFARecoveryCode{
+			ID:       ulid.Make().Prefixed("mrc"),
+			EntityID: entityID,
+			CodeHash: string(hash),
+		})
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("entity_id = ?", entityID).Delete(&model.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode returns a code like "k7q2m-x9w4p": 10 characters from
+// an alphabet without look-alikes, split for readability. Random bytes past
+// the largest multiple of the alphabet's length are redrawn, so every
+// character is equally likely.
+func generateRecoveryCode() (string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	const length = 10
+	limit := 256 - 256%len(alphabet)
+	code := make([]byte, 0, length)
+	buf := make([]byte, length*2)
+	for len(code) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(code) < length {
+				code = append(code, alphabet[int(b)%len(alphabet)])
+			}
+		}
+	}
+	return string(code[:5]) + "-" + string(code[5:]), nil
+}
+
+// verifyMFACode accepts either a current TOTP code or an unused recovery
+// code, consuming whichever matched, and returns the amr method it counts as.
+func verifyMFACode(entityID string, code string) (string, error) {
+	mfa, err := getEntityMFA(entityID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrMFANotEnrolled
+		}
+		return "", err
+	}
+	if !mfa.Enabled {
+		return "", ErrMFANotEnrolled
+	}
+	code = strings.TrimSpace(code)
+
+	if step, ok := verifyTOTP(mfa.TOTPSecret, code, time.Now(), mfa.LastUsedStep); ok {
+		// Conditional on the step so two concurrent submissions of the same
+		// code can't both be accepted.
+		result := database.DB.Model(&model.EntityMFA{}).
+			Where("entity_id = ? AND last_used_step < ?", entityID, step).
+			Update("last_used_step", step)
+		if result.Error != nil {
+			return "", result.Error
+		}
+		if result.RowsAffected == 0 {
+			return "", ErrInvalidMFACode
+		}
+		return AMROTP, nil
+	}
+
+	normalized := strings.ToLower(code)
+	if len(normalized) == 10 {
+		normalized = normalized[:5] + "-" + normalized[5:]
+	}
+	var recovery []model.MFARecoveryCode
+	if err := database.DB.Where("entity_id = ? AND used_at IS NULL", entityID).Find(&recovery).Error; err != nil {
+		return "", err
+	}
+	for _, rc := range recovery {
+		if bcrypt.CompareHashAndPassword([]byte(rc.CodeHash), []byte(normalized)) != nil {
+			continue
+		}
+		result := database.DB.Model(&model.MFARecoveryCode{}).
+			Where("id = ? AND used_at IS NULL", rc.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return "", result.Error
+		}
+		if result.RowsAffected == 0 {
+			return "", ErrInvalidMFACode
+		}
+		logger.SugarLogger.Infof("Recovery code %s used by entity %s", rc.ID, entityID)
+		return AMROTP, nil
+	}
+	return "", ErrInvalidMFACode
+}
+
+// CreateMFAChallenge is called after a successful first factor. If the
+// entity has a second factor (TOTP or a passkey), or a group requires one, it
+// returns a challenge token that must be completed with VerifyMFAChallenge or
+// CompleteMFAChallengeWithPasskey; otherwise Required is false and no
+// challenge is created.
+func CreateMFAChallenge(entityID string, amr []string) (MFAChallengeResult, error) {
+	var methods []string
+	if IsMFAEnabled(entityID) {
+		methods = append(methods, MFAMethodTOTP)
+	}
+	if HasPasskeys(entityID) {
+		methods = append(methods, MFAMethodPasskey)
+	}
+	enabled := len(methods) > 0
+	required, err := IsMFARequiredByPolicy(entityID)
+	if err != nil {
+		return MFAChallengeResult{}, err
+	}
+	if !enabled && !required {
+		return MFAChallengeResult{Required: false}, nil
+	}
+
+	raw, err := generateOpaqueToken()
+	if err != nil {
+		return MFAChallengeResult{}, err
+	}
+	challenge := model.MFAChallenge{
+		ID:                 ulid.Make().Prefixed("mfa"),
+		EntityID:           entityID,
+		TokenHash:          hashOpaqueToken(raw),
+		AMR:                model.StringSlice(amr),
+		EnrollmentRequired: !enabled,
+		ExpiresAt:          time.Now().Add(mfaChallengeTTL),
+	}
+	if err := database.DB.Create(&challenge).Error; err != nil {
+		return MFAChallengeResult{}, err
+	}
+	return MFAChallengeResult{
+		Required:           true,
+		EnrollmentRequired: challenge.EnrollmentRequired,
+		Methods:            methods,
+		Token:              raw,
+		ExpiresAt:          challenge.ExpiresAt,
+	}, nil
+}
+
+func getOpenMFAChallenge(rawToken string) (model.MFAChallenge, error) {
+	var challenge model.MFAChallenge
+	err := database.DB.
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashOpaqueToken(strings.TrimSpace(rawToken)), time.Now()).
+		First(&challenge).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.MFAChallenge{}, ErrInvalidMFAChallenge
+		}
+		return model.MFAChallenge{}, err
+	}
+	if challenge.Attempts >= mfaChallengeMaxAttempts {
+		return model.MFAChallenge{}, ErrInvalidMFAChallenge
+	}
+	return challenge, nil
+}
+
+// ResolveMFAChallenge returns the open challenge behind a token without
+// consuming it, so oauth can build a passkey assertion for its entity.
+func ResolveMFAChallenge(rawToken string) (model.MFAChallenge, error) {
+	return getOpenMFAChallenge(rawToken)
+}
+
+// BeginChallengeEnrollment starts TOTP enrollment for the entity behind a
+// challenge that requires it, so policy-bound users who haven't enrolled can
+// do so mid-login without a session.
+func BeginChallengeEnrollment(rawToken string) (TOTPEnrollment, error) {
+	challenge, err := getOpenMFAChallenge(rawToken)
+	if err != nil {
+		return TOTPEnrollment{}, err
+	}
+	if !challenge.EnrollmentRequired {
+		return TOTPEnrollment{}, ErrMFAAlreadyEnrolled
+	}
+	return BeginTOTPEnrollment(challenge.EntityID)
+}
+
+// VerifyMFAChallenge completes a login challenge with a second factor. For
+// challenges that required enrollment, the code confirms the pending TOTP
+// enrollment and the new recovery codes are returned. Every submission
+// spends one of the challenge's attempts; wrong codes also count towards the
+// entity's lockout.
+func VerifyMFAChallenge(rawToken string, code string) (MFAChallengeVerification, error) {
+	challenge, err := getOpenMFAChallenge(rawToken)
+	if err != nil {
+		return MFAChallengeVerification{}, err
+	}
+	attemptID, err := reserveMFAAttempt(challenge)
+	if err != nil {
+		return MFAChallengeVerification{}, err
+	}
+
+	var (
+		method        string
+		recoveryCodes []string
+	)
+	if challenge.EnrollmentRequired && !IsMFAEnabled(challenge.EntityID) {
+		recoveryCodes, err = ConfirmTOTPEnrollment(challenge.EntityID, code)
+		method = AMROTP
+	} else {
+		method, err = verifyMFACode(challenge.EntityID, code)
+	}
+	if err != nil {
+		if !errors.Is(err, ErrInvalidMFACode) {
+			clearMFAAttempt(attemptID)
+		}
+		return MFAChallengeVerification{}, err
+	}
+	clearMFAAttempt(attemptID)
+
+	if err := consumeMFAChallenge(challenge.ID); err != nil {
+		return MFAChallengeVerification{}, err
+	}
+
+	return MFAChallengeVerification{
+		EntityID:      challenge.EntityID,
+		AMR:           appendAMR(challenge.AMR, method, AMRMFA),
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// CompleteMFAChallengeWithPasskey completes a login challenge after oauth
+// has verified a WebAuthn assertion from one of the entity's passkeys. The
+// credential must belong to the challenge's entity; anything else counts as
+// a failed attempt like a wrong code would.
+func CompleteMFAChallengeWithPasskey(rawToken string, credentialID string) (MFAChallengeVerification, error) {
+	challenge, err := getOpenMFAChallenge(rawToken)
+	if err != nil {
+		return MFAChallengeVerification{}, err
+	}
+	attemptID, err := reserveMFAAttempt(challenge)
+	if err != nil {
+		return MFAChallengeVerification{}, err
+	}
+	passkey, err := GetPasskeyByCredentialID(credentialID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		clearMFAAttempt(attemptID)
+		return MFAChallengeVerification{}, err
+	}
+	if passkey.EntityID != challenge.EntityID {
+		return MFAChallengeVerification{}, ErrInvalidMFACode
+	}
+	clearMFAAttempt(attemptID)
+	if err := consumeMFAChallenge(challenge.ID); err != nil {
+		return MFAChallengeVerification{}, err
+	}
+	return MFAChallengeVerification{
+		EntityID: challenge.EntityID,
+		AMR:      appendAMR(challenge.AMR, AMRHardwareKey, AMRMFA),
+	}, nil
+}
+
+// reserveMFAAttempt spends one of the challenge's attempts, and records one
+// against its entity, before the second factor is checked. The challenge's
+// count is taken in a single conditional update, so parallel submissions
+// can't all read it under the cap. The entity's count spans challenges, so
+// starting a fresh login doesn't reset the budget for guessing codes;
+// attempts made while locked out count too, which keeps the lock in place
+// for as long as someone keeps trying. Returns the attempt to clear if the
+// factor is accepted.
+func reserveMFAAttempt(challenge model.MFAChallenge) (string, error) {
+	result := database.DB.Model(&model.MFAChallenge{}).
+		Where("id = ? AND used_at IS NULL AND attempts < ?", challenge.ID, mfaChallengeMaxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return "", ErrInvalidMFAChallenge
+	}
+
+	attempt := model.MFAAttempt{
+		ID:       ulid.Make().Prefixed("mat"),
+		EntityID: challenge.EntityID,
+	}
+	if err := database.DB.Create(&attempt).Error; err != nil {
+		return "", err
+	}
+	var recent int64
+	if err := database.DB.Model(&model.MFAAttempt{}).
+		Where("entity_id = ? AND created_at > ?", challenge.EntityID, time.Now().Add(-mfaLockoutWindow)).
+		Count(&recent).Error; err != nil {
+		return "", err
+	}
+	if recent > mfaLockoutAttempts {
+		logger.SugarLogger.Warnf("MFA locked out for entity %s after %d failed attempts", challenge.EntityID, recent-1)
+		return "", ErrMFALocked
+	}
+	return attempt.ID, nil
+}
+
+// clearMFAAttempt forgets an attempt that didn't fail, so only wrong
+// factors count towards the lockout.
+func clearMFAAttempt(id string) {
+	if err := database.DB.Where("id = ?", id).Delete(&model.MFAAttempt{}).Error; err != nil {
+		logger.SugarLogger.Errorf("Failed to clear MFA attempt %s: %v", id, err)
+	}
+}
+
+// consumeMFAChallenge marks a challenge used. Conditional on it still being
+// open so two concurrent completions can't both mint a session.
+func consumeMFAChallenge(id string) error {
+	result := database.DB.Model(&model.MFAChallenge{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidMFAChallenge
+	}
+	return nil
+}
+
+func appendAMR(amr []string, methods ...string) []string {
+	out := append([]string{}, amr...)
+	for _, m := range methods {
+		found := false
+		for _, existing := range out {
+			if existing == m {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, m)
+		}
+	}
+	return out
+}
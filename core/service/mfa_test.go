@@ -0,0 +1,30 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateRecoveryCode(t *testing.T) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	seen := map[byte]int{}
+	for i := 0; i < 500; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			t.Fatalf("generateRecoveryCode: %v", err)
+		}
+		if len(code) != 11 || code[5] != '-' {
+			t.Fatalf("generateRecoveryCode = %q, want xxxxx-xxxxx", code)
+		}
+		for _, c := range []byte(code[:5] + code[6:]) {
+			if !strings.ContainsRune(alphabet, rune(c)) {
+				t.Fatalf("generateRecoveryCode = %q, has %q outside the alphabet", code, c)
+			}
+			seen[c]++
+		}
+	}
+	// 5000 draws from 31 characters: every one should turn up.
+	if len(seen) != len(alphabet) {
+		t.Errorf("only %d of %d characters were drawn", len(seen), len(alphabet))
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// generateOpaqueToken returns 256 bits of crypto/rand as a URL-safe string.
+// Used for bearer-style one-time credentials (password resets, MFA
+// challenges) that are handed to the user and looked up by hash.
+func generateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashOpaqueToken is SHA-256 rather than bcrypt: the token is 256 bits of
+// randomness, so a fast hash is enough and lets us look the row up by it.
+func hashOpaqueToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort field")
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// ListOptions are the paging and sorting parameters shared by every list
+// endpoint. Sort is an API field name (e.g. "created_at"), resolved against
+// the per-resource whitelist; prefix it with "-" for descending order.
+type ListOptions struct {
+	Cursor string
+	Limit  int
+	Sort   string
+}
+
+// Page is the envelope every list endpoint returns. NextCursor is empty on
+// the last page; Total counts every row matching the filters, not just this
+// page.
+type Page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor"`
+	Total      int64  `json:"total"`
+}
+
+type sortKind int
+
+const (
+	sortString sortKind = iota
+	sortInt
+	sortTime
+)
+
+// sortField maps an API sort name to a column and knows how to read that
+// value back off a row, which is what the next cursor is built from.
+type sortField[T any] struct {
+	column string
+	kind   sortKind
+	value  func(T) any
+}
+
+// listSpec describes how one resource is paginated. idColumn is the unique
+// tie-breaker appended to every ORDER BY so keyset pagination is stable when
+// sort values collide.
+type listSpec[T any] struct {
+	sorts       map[string]sortField[T]
+	defaultSort string
+	idColumn    string
+	id          func(T) string
+}
+
+type cursorPayload struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func encodeCursor(p cursorPayload) string {
+	raw, _ := json.Marshal(p)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(cursor string) (cursorPayload, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return cursorPayload{}, ErrInvalidCursor
+	}
+	var p cursorPayload
+	if err := json.Unmarshal(raw, &p); err != nil || p.ID == "" {
+		return cursorPayload{}, ErrInvalidCursor
+	}
+	return p, nil
+}
+
+func formatSortValue(kind sortKind, v any) string {
+	switch kind {
+	case sortInt:
+		return strconv.Itoa(v.(int))
+	case sortTime:
+		return v.(time.Time).UTC().Format(time.RFC3339Nano)
+	default:
+		return v.(string)
+	}
+}
+
+func parseSortValue(kind sortKind, s string) (any, error) {
+	switch kind {
+	case sortInt:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return n, nil
+	case sortTime:
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return t, nil
+	default:
+		return s, nil
+	}
+}
+
+// paginate runs a filtered query as one keyset page. query must already
+// carry the resource's filters; paginate adds the total count, the cursor
+// condition, ordering, and the limit. A cursor is only valid with the sort
+// it was issued for.
+func paginate[T any](query *gorm.DB, opts ListOptions, spec listSpec[T]) (Page[T], error) {
+	sortName := opts.Sort
+	if sortName == "" {
+		sortName = spec.defaultSort
+	}
+	desc := strings.HasPrefix(sortName, "-")
+	field, ok := spec.sorts[strings.TrimPrefix(sortName, "-")]
+	if !ok {
+		return Page[T]{}, ErrInvalidSort
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return Page[T]{}, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if desc {
+		direction, comparison = "DESC", "<"
+	}
+	page := query
+	if opts.Cursor != "" {
+		cursor, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		if cursor.Sort != sortName {
+			return Page[T]{}, ErrInvalidCursor
+		}
+		value, err := parseSortValue(field.kind, cursor.Value)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		page = page.Where("("+field.column+", "+spec.idColumn+") "+comparison+" (?, ?)", value, cursor.ID)
+	}
+
+	rows := []T{}
+	if err := page.
+		Order(field.column + " " + direction).
+		Order(spec.idColumn + " " + direction).
+		Limit(limit + 1).
+		Find(&rows).Error; err != nil {
+		return Page[T]{}, err
+	}
+
+	result := Page[T]{Data: rows, Total: total}
+	if len(rows) > limit {
+		result.Data = rows[:limit]
+		last := result.Data[limit-1]
+		result.NextCursor = encodeCursor(cursorPayload{
+			Sort:  sortName,
+			Value: formatSortValue(field.kind, field.value(last)),
+			ID:    spec.id(last),
+		})
+	}
+	return result, nil
+}
+
+// searchTerms splits a free-text query into lowercase terms, each wrapped
+// as an ILIKE substring pattern with LIKE metacharacters escaped.
+func searchTerms(q string) []string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	var terms []string
+	for _, term := range strings.Fields(strings.ToLower(q)) {
+		terms = append(terms, "%"+replacer.Replace(term)+"%")
+	}
+	return terms
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/ulid-go"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPasskeyName = "Passkey"
+	maxPasskeyNameLen  = 64
+)
+
+func GetPasskeysForEntity(entityID string) ([]model.EntityPasskey, error) {
+	var passkeys []model.EntityPasskey
+	if err := database.DB.Where("entity_id = ?", entityID).Order("created_at").Find(&passkeys).Error; err != nil {
+		return nil, err
+	}
+	return passkeys, nil
+}
+
+func GetPasskeyForEntity(entityID string, id string) (model.EntityPasskey, error) {
+	var passkey model.EntityPasskey
+	if err := database.DB.Where("entity_id = ? AND id = ?", entityID, id).First(&passkey).Error; err != nil {
+		return model.EntityPasskey{}, err
+	}
+	return passkey, nil
+}
+
+// GetPasskeyByCredentialID resolves the credential an assertion was signed
+// with. Passkey-only login has no username step, so this is how the entity
+// is found.
+func GetPasskeyByCredentialID(credentialID string) (model.EntityPasskey, error) {
+	var passkey model.EntityPasskey
+	if err := database.DB.Where("credential_id = ?", credentialID).First(&passkey).Error; err != nil {
+		return model.EntityPasskey{}, err
+	}
+	return passkey, nil
+}
+
+// HasPasskeys reports whether the entity has at least one registered passkey.
+func HasPasskeys(entityID string) bool {
+	var count int64
+	if err := database.DB.Model(&model.EntityPasskey{}).Where("entity_id = ?", entityID).Count(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
+
+func CreatePasskeyForEntity(entityID string, passkey model.EntityPasskey) (model.EntityPasskey, error) {
+	passkey.ID = ulid.Make().Prefixed("pky")
+	passkey.EntityID = entityID
+	passkey.Name = normalizePasskeyName(passkey.Name)
+	passkey.LastUsedAt = nil
+	if err := database.DB.Create(&passkey).Error; err != nil {
+		return model.EntityPasskey{}, err
+	}
+	return passkey, nil
+}
+
+// RecordPasskeyUse stores the authenticator state reported by a successful
+// assertion. UserVerified is latched: once a ceremony has verified the user
+// it stays true, matching the WebAuthn credential record's uvInitialized.
+func RecordPasskeyUse(credentialID string, signCount uint32, userVerified bool, backupState bool) (model.EntityPasskey, error) {
+	passkey, err := GetPasskeyByCredentialID(credentialID)
+	if err != nil {
+		return model.EntityPasskey{}, err
+	}
+	now := time.Now()
+	updates := map[string]interface{}{
+		"sign_count":   signCount,
+		"backup_state": backupState,
+		"last_used_at": now,
+	}
+	if userVerified {
+		updates["user_verified"] = true
+	}
+	if err := database.DB.Model(&model.EntityPasskey{}).Where("id = ?", passkey.ID).Updates(updates).Error; err != nil {
+		return model.EntityPasskey{}, err
+	}
+	return GetPasskeyByCredentialID(credentialID)
+}
+
+func RenamePasskey(entityID string, id string, name string) (model.EntityPasskey, error) {
+	passkey, err := GetPasskeyForEntity(entityID, id)
+	if err != nil {
+		return model.EntityPasskey{}, err
+	}
+	passkey.Name = normalizePasskeyName(name)
+	if err := database.DB.Model(&model.EntityPasskey{}).Where("id = ?", passkey.ID).Update("name", passkey.Name).Error; err != nil {
+		return model.EntityPasskey{}, err
+	}
+	return passkey, nil
+}
+
+// DeletePasskey removes one of the entity's passkeys. Returns
+// gorm.ErrRecordNotFound when the passkey doesn't belong to the entity, so a
+// caller can't probe other entities' credentials, and ErrLastLoginMethod
+// when it's the entity's only way left to sign in.
+func DeletePasskey(entityID string, id string) error {
+	var passkey model.EntityPasskey
+	if err := database.DB.Where("entity_id = ? AND id = ?", entityID, id).First(&passkey).Error; err != nil {
+		return err
+	}
+	if err := requireAnotherLoginMethod(entityID); err != nil {
+		return err
+	}
+	result := database.DB.Where("entity_id = ? AND id = ?", entityID, id).Delete(&model.EntityPasskey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func normalizePasskeyName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return defaultPasskeyName
+	}
+	if r := []rune(name); len(r) > maxPasskeyNameLen {
+		name = string(r[:maxPasskeyNameLen])
+	}
+	return name
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/config"
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/pkg/logger"
+	"github.com/gaucho-racing/sentinel/core/pkg/mailer"
+	"github.com/gaucho-racing/ulid-go"
+	"gorm.io/gorm"
+)
+
+// ErrPasswordResetRateLimited is returned when an email has had resets
+// requested too often. The API layer maps it to 429.
+var ErrPasswordResetRateLimited = errors.New("too many password reset requests, please wait before trying again")
+
+// ErrInvalidResetToken covers unknown, expired, and already-used reset tokens.
+var ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+
+// passwordResetThrottle limits reset requests per address: one a minute,
+// and five per 15 minutes.
+var passwordResetThrottle = emailRequestThrottle{
+	MinInterval: time.Minute,
+	Window:      15 * time.Minute,
+	WindowMax:   5,
+}
+
+// PasswordResetDelivery is what RequestPasswordReset hands back when core
+// can't deliver the reset link itself. Email is sent directly; a Discord DM
+// has to go through the discord service, so core returns the recipient and
+// message for the caller to forward. Empty when there's nothing to deliver.
+type PasswordResetDelivery struct {
+	DiscordID string `json:"discord_id,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// RequestPasswordReset issues a reset token for the entity that owns email
+// and delivers the link over channel. Unknown emails, and DISCORD requests for
+// entities with no linked Discord account, return an empty delivery and nil
+// so the endpoint can't be used to enumerate accounts. Every request is
+// throttled before the lookup, so neither is the 429. Any earlier unused
+// tokens for the entity are expired.
+func RequestPasswordReset(email string, channel model.PasswordResetChannel) (PasswordResetDelivery, error) {
+	email = strings.TrimSpace(email)
+	allowed, err := passwordResetThrottle.allow(model.EmailRequestPasswordReset, email)
+	if err != nil {
+		return PasswordResetDelivery{}, err
+	}
+	if !allowed {
+		return PasswordResetDelivery{}, ErrPasswordResetRateLimited
+	}
+	entity, _ := GetEntityByEmail(email)
+	if entity.ID == "" {
+		logger.SugarLogger.Infof("Password reset requested for unknown email %s", email)
+		return PasswordResetDelivery{}, nil
+	}
+
+	discordID := ""
+	if channel == model.PasswordResetChannelDiscord {
+		auths, err := GetExternalAuthForEntity(entity.ID)
+		if err != nil {
+			return PasswordResetDelivery{}, err
+		}
+		for _, a := range auths {
+			if strings.EqualFold(string(a.Provider), string(model.ExternalAuthProviderDiscord)) {
+				discordID = a.ExternalID
+				break
+			}
+		}
+		if discordID == "" {
+			logger.SugarLogger.Infof("Discord password reset requested for entity %s with no linked Discord account", entity.ID)
+			return PasswordResetDelivery{}, nil
+		}
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&model.PasswordResetToken{}).
+		Where("entity_id = ? AND used_at IS NULL AND expires_at > ?", entity.ID, now).
+		Update("expires_at", now).Error; err != nil {
+		logger.SugarLogger.Errorf("Failed to invalidate prior reset tokens for %s: %v", entity.ID, err)
+	}
+
+	raw, err := generateOpaqueToken()
+	if err != nil {
+		return PasswordResetDelivery{}, err
+	}
+	token := model.PasswordResetToken{
+		ID:        ulid.Make().Prefixed("prt"),
+		EntityID:  entity.ID,
+		TokenHash: hashOpaqueToken(raw),
+		Channel:   channel,
+		ExpiresAt: now.Add(config.PasswordResetTTL),
+	}
+	if err := database.DB.Create(&token).Error; err != nil {
+		return PasswordResetDelivery{}, err
+	}
+
+	link := strings.TrimRight(config.WebBaseURL, "/") + "/auth/reset-password?token=" + url.QueryEscape(raw)
+	body := fmt.Sprintf(
+		"Someone asked to reset the password for your Sentinel account. Use this link to choose a new one:\n\n%s\n\nIt expires in %s and can only be used once. If you didn't request this, you can ignore this message.",
+		link, config.PasswordResetTTL.Round(time.Minute),
+	)
+
+	if channel == model.PasswordResetChannelDiscord {
+		logger.SugarLogger.Infof("Issued password reset %s for entity %s via discord", token.ID, entity.ID)
+		return PasswordResetDelivery{DiscordID: discordID, Message: body}, nil
+	}
+
+	err = mailer.Send(mailer.Message{
+		To:      email,
+		Subject: "Reset your Sentinel password",
+		Body:    body,
+	})
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to send password reset to %s: %v", email, err)
+		database.DB.Where("id = ?", token.ID).Delete(&model.PasswordResetToken{})
+		return PasswordResetDelivery{}, fmt.Errorf("failed to send password reset: %w", err)
+	}
+	logger.SugarLogger.Infof("Issued password reset %s for entity %s via email", token.ID, entity.ID)
+	return PasswordResetDelivery{}, nil
+}
+
+// ConfirmPasswordReset consumes a reset token and sets the entity's password.
+// On success every existing token for the entity is revoked, so sessions
+// opened with the old password (or by whoever had it) stop working.
+func ConfirmPasswordReset(rawToken string, password string) (model.Entity, error) {
+	if err := ValidatePassword(password); err != nil {
+		return model.Entity{}, err
+	}
+	hashed, err := HashPassword(password)
+	if err != nil {
+		return model.Entity{}, err
+	}
+
+	var token model.PasswordResetToken
+	err = database.DB.
+		Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashOpaqueToken(strings.TrimSpace(rawToken)), time.Now()).
+		First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Entity{}, ErrInvalidResetToken
+		}
+		return model.Entity{}, err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		// Conditional on used_at so two concurrent confirms can't both win.
+		result := tx.Model(&model.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidResetToken
+		}
+		result = tx.Model(&model.EntityEmail{}).
+			Where("entity_id = ?", token.EntityID).
+			Update("password", hashed)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidResetToken
+		}
+		if err := tx.Where("entity_id = ?", token.EntityID).Delete(&model.Token{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.PasswordResetToken{}).
+			Where("entity_id = ? AND used_at IS NULL AND expires_at > ?", token.EntityID, now).
+			Update("expires_at", now).Error
+	})
+	if err != nil {
+		return model.Entity{}, err
+	}
+
+	entity, err := GetEntityByID(token.EntityID)
+	if err != nil {
+		return model.Entity{}, err
+	}
+	logger.SugarLogger.Infof("Password reset %s consumed for entity %s; all tokens revoked", token.ID, entity.ID)
+	return entity, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/config"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidRequestObject covers every way an RFC 9101 request object can
+// fail. The message is the OAuth error code the authorize endpoint returns,
+// with the reason after it.
+var ErrInvalidRequestObject = errors.New("invalid_request_object")
+
+// VerifyRequestObject checks a JWT-secured authorization request: it must
+// be signed with one of the client's registered keys (any auth method, as
+// long as it has jwks or a jwks_uri), name the client in iss and client_id,
+// name Sentinel's issuer in aud, and expire within the assertion lifetime.
+// It returns the authorization parameters the object carries, as strings.
+// request and request_uri can't be nested and are dropped.
+func VerifyRequestObject(clientID, requestObject string) (map[string]string, error) {
+	app, err := GetApplicationByClientID(clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: unknown client", ErrInvalidRequestObject)
+		}
+		return nil, err
+	}
+	return verifyRequestObject(app, requestObject)
+}
+
+// verifyRequestObject is VerifyRequestObject for an application already
+// loaded.
+func verifyRequestObject(app model.Application, requestObject string) (map[string]string, error) {
+	clientID := app.ClientID
+	if len(app.JWKS) == 0 && app.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: client has no registered keys", ErrInvalidRequestObject)
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(requestObject, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return clientVerificationKeys(app, kid, token.Method.Alg())
+	},
+		jwt.WithValidMethods(ClientAssertionSigningAlgs),
+		jwt.WithIssuer(clientID),
+		jwt.WithAudience(config.Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clientAssertionLeeway),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRequestObject, err)
+	}
+	exp, _ := claims.GetExpirationTime()
+	if exp.Time.After(time.Now().Add(maxClientAssertionLifetime)) {
+		return nil, fmt.Errorf("%w: exp is too far in the future", ErrInvalidRequestObject)
+	}
+	if id, _ := claims["client_id"].(string); id != clientID {
+		return nil, fmt.Errorf("%w: client_id must match the request", ErrInvalidRequestObject)
+	}
+
+	params := make(map[string]string, len(claims))
+	for name, value := range claims {
+		switch name {
+		case "iss", "aud", "exp", "iat", "nbf", "jti", "request", "request_uri":
+			continue
+		}
+		// Authorization parameters are strings; anything else (claims,
+		// authorization_details) isn't supported and is ignored.
+		if s, ok := value.(string); ok {
+			params[name] = s
+		}
+	}
+	return params, nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/config"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newTestClientKey returns a P-256 signing key and a JWK Set holding its
+// public half under kid.
+func newTestClientKey(t *testing.T, kid string) (*ecdsa.PrivateKey, model.JSONWebKeySet) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "EC",
+			"crv": "P-256",
+			"kid": kid,
+			"use": "sig",
+			"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, model.JSONWebKeySet(jwks)
+}
+
+func signTestJWT(t *testing.T, key *ecdsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestVerifyRequestObject(t *testing.T) {
+	prevIssuer := config.Issuer
+	config.Issuer = "https://sso.example.com"
+	t.Cleanup(func() { config.Issuer = prevIssuer })
+
+	key, jwks := newTestClientKey(t, "k1")
+	otherKey, _ := newTestClientKey(t, "k1")
+	app := model.Application{ClientID: "client", JWKS: jwks}
+	request := func(edit func(jwt.MapClaims)) jwt.MapClaims {
+		claims := jwt.MapClaims{
+			"iss":           "client",
+			"aud":           config.Issuer,
+			"exp":           time.Now().Add(5 * time.Minute).Unix(),
+			"client_id":     "client",
+			"response_type": "code",
+			"redirect_uri":  "https://app.example.com/callback",
+			"scope":         "openid email",
+			"state":         "xyz",
+		}
+		if edit != nil {
+			edit(claims)
+		}
+		return claims
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		claims := request(func(c jwt.MapClaims) {
+			c["request_uri"] = "urn:ietf:params:oauth:request_uri:nested"
+			c["max_age"] = 60
+		})
+		params, err := verifyRequestObject(app, signTestJWT(t, key, "k1", claims))
+		if err != nil {
+			t.Fatalf("verifyRequestObject: %v", err)
+		}
+		want := map[string]string{
+			"client_id":     "client",
+			"response_type": "code",
+			"redirect_uri":  "https://app.example.com/callback",
+			"scope":         "openid email",
+			"state":         "xyz",
+		}
+		if len(params) != len(want) {
+			t.Errorf("params = %v, want %v", params, want)
+		}
+		for name, value := range want {
+			if params[name] != value {
+				t.Errorf("params[%q] = %q, want %q", name, params[name], value)
+			}
+		}
+	})
+
+	invalid := []struct {
+		name    string
+		app     model.Application
+		request string
+	}{
+		{"client without keys", model.Application{ClientID: "client"}, signTestJWT(t, key, "k1", request(nil))},
+		{"signed by another key", app, signTestJWT(t, otherKey, "k1", request(nil))},
+		{"unknown kid", app, signTestJWT(t, key, "k2", request(nil))},
+		{"wrong issuer", app, signTestJWT(t, key, "k1", request(func(c jwt.MapClaims) { c["iss"] = "other" }))},
+		{"wrong audience", app, signTestJWT(t, key, "k1", request(func(c jwt.MapClaims) { c["aud"] = "https://elsewhere.example.com" }))},
+		{"client_id mismatch", app, signTestJWT(t, key, "k1", request(func(c jwt.MapClaims) { c["client_id"] = "other" }))},
+		{"no exp", app, signTestJWT(t, key, "k1", request(func(c jwt.MapClaims) { delete(c, "exp") }))},
+		{"expired", app, signTestJWT(t, key, "k1", request(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }))},
+		{"exp too far out", app, signTestJWT(t, key, "k1", request(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(2 * time.Hour).Unix() }))},
+		{"unsigned", app, unsignedTestJWT(t, request(nil))},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifyRequestObject(tt.app, tt.request); !errors.Is(err, ErrInvalidRequestObject) {
+				t.Errorf("verifyRequestObject error = %v, want ErrInvalidRequestObject", err)
+			}
+		})
+	}
+}
+
+func unsignedTestJWT(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
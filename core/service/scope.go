@@ -0,0 +1,340 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidScopeName = errors.New("scope names must look like namespace:action, in lowercase")
+	ErrReservedScope    = errors.New("that scope namespace is reserved for Sentinel")
+	ErrScopeExists      = errors.New("a scope with that name is already registered")
+	ErrUnknownScope     = errors.New("unknown scope")
+)
+
+var scopeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*:[a-z][a-z0-9_:-]*$`)
+
+// reservedScopeNamespaces are the prefixes of the builtin scopes. An
+// application can't register under them, so a custom scope can never be
+// mistaken for one of Sentinel's.
+var reservedScopeNamespaces = map[string]bool{
+	"user":         true,
+	"groups":       true,
+	"applications": true,
+	"sentinel":     true,
+}
+
+// ScopeInfo is one entry of the scope registry. Builtin scopes have no
+// application; the rest carry the resource server that defines them.
+// AllowedWhilePending marks the scopes in model.PendingApprovalScopes.
+type ScopeInfo struct {
+	Name                string `json:"name"`
+	Description         string `json:"description"`
+	Builtin             bool   `json:"builtin"`
+	AllowedWhilePending bool   `json:"allowed_while_pending,omitempty"`
+	ApplicationID       string `json:"application_id,omitempty"`
+	ClientID            string `json:"client_id,omitempty"`
+	ApplicationName     string `json:"application_name,omitempty"`
+}
+
+// ValidateScopeName checks a name an application wants to register.
+func ValidateScopeName(name string) error {
+	if !scopeNamePattern.MatchString(name) {
+		return ErrInvalidScopeName
+	}
+	namespace, _, _ := strings.Cut(name, ":")
+	if reservedScopeNamespaces[namespace] {
+		return ErrReservedScope
+	}
+	return nil
+}
+
+// ListScopes returns the whole registry: builtin scopes first, then every
+// scope registered by a live application, each group sorted by name.
+func ListScopes() ([]ScopeInfo, error) {
+	scopes := make([]ScopeInfo, 0, len(model.BuiltinScopes))
+	for name, description := range model.BuiltinScopes {
+		scopes = append(scopes, ScopeInfo{
+			Name:                name,
+			Description:         description,
+			Builtin:             true,
+			AllowedWhilePending: slices.Contains(model.PendingApprovalScopes, name),
+		})
+	}
+	sort.Slice(scopes, func(i, j int) bool { return scopes[i].Name < scopes[j].Name })
+
+	custom := []ScopeInfo{}
+	err := database.DB.Raw(`
+		SELECT s.name, s.description, s.application_id, a.client_id, a.name AS application_name
+		FROM application_scope s
+		INNER JOIN application a ON a.id = s.application_id AND a.deleted_at IS NULL
+		ORDER BY s.name
+	`).Scan(&custom).Error
+	if err != nil {
+		return nil, err
+	}
+	return append(scopes, custom...), nil
+}
+
+// GetScopesForApplication returns the scopes an application defines as a
+// resource server.
+func GetScopesForApplication(applicationID string) ([]model.ApplicationScope, error) {
+	scopes := []model.ApplicationScope{}
+	if err := database.DB.Where("application_id = ?", applicationID).Order("name").Find(&scopes).Error; err != nil {
+		return []model.ApplicationScope{}, err
+	}
+	return scopes, nil
+}
+
+// CreateApplicationScope registers a scope for the application's API.
+func CreateApplicationScope(applicationID string, name string, description string) (model.ApplicationScope, error) {
+	if err := ValidateScopeName(name); err != nil {
+		return model.ApplicationScope{}, err
+	}
+	if _, builtin := model.BuiltinScopes[name]; builtin {
+		return model.ApplicationScope{}, ErrReservedScope
+	}
+	var existing int64
+	if err := database.DB.Model(&model.ApplicationScope{}).Where("name = ?", name).Count(&existing).Error; err != nil {
+		return model.ApplicationScope{}, err
+	}
+	if existing > 0 {
+		return model.ApplicationScope{}, ErrScopeExists
+	}
+	scope := model.ApplicationScope{
+		Name:          name,
+		ApplicationID: applicationID,
+		Description:   description,
+	}
+	if err := database.DB.Create(&scope).Error; err != nil {
+		return model.ApplicationScope{}, err
+	}
+	return scope, nil
+}
+
+// DeleteApplicationScope unregisters one of the application's scopes and
+// takes it off every client's allow-list. Tokens already granting it keep
+// working until they expire.
+func DeleteApplicationScope(applicationID string, name string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("application_id = ? AND name = ?", applicationID, name).Delete(&model.ApplicationScope{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("scope = ?", name).Delete(&model.ApplicationAllowedScope{}).Error
+	})
+}
+
+// GetAllowedScopesForApplication returns a client's allow-list, approved
+// and pending entries alike, and separately the ones still pending.
+func GetAllowedScopesForApplication(applicationID string) ([]string, []string, error) {
+	allowed := []model.ApplicationAllowedScope{}
+	if err := database.DB.Where("application_id = ?", applicationID).Order("scope").Find(&allowed).Error; err != nil {
+		return []string{}, []string{}, err
+	}
+	scopes := make([]string, len(allowed))
+	pending := []string{}
+	for i, a := range allowed {
+		scopes[i] = a.Scope
+		if a.PendingApproval {
+			pending = append(pending, a.Scope)
+		}
+	}
+	return scopes, pending, nil
+}
+
+// ScopeApprover reports whether whoever is changing an allow-list may
+// approve a scope on behalf of the resource server that defines it.
+type ScopeApprover func(resourceServer model.Application) bool
+
+// SetApplicationAllowedScopes replaces the scopes a client may request.
+// Each must be a builtin other than sentinel:all, or registered by some
+// application. An empty list restores the default of builtin scopes only.
+// A scope another application defines goes in pending approval unless it
+// was already approved for this client or canApprove allows it; nil
+// canApprove allows none.
+func SetApplicationAllowedScopes(applicationID string, scopes []string, canApprove ScopeApprover) ([]string, error) {
+	if err := validateAllowedScopes(scopes); err != nil {
+		return nil, err
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceAllowedScopes(tx, applicationID, scopes, canApprove)
+	})
+	if err != nil {
+		return nil, err
+	}
+	allowed, _, err := GetAllowedScopesForApplication(applicationID)
+	return allowed, err
+}
+
+// replaceAllowedScopes swaps the application's allow-list for scopes in
+// tx. Scopes must already be validated.
+func replaceAllowedScopes(tx *gorm.DB, applicationID string, scopes []string, canApprove ScopeApprover) error {
+	pending, err := pendingAllowedScopes(tx, applicationID, scopes, canApprove)
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		seen[scope] = true
+	}
+	if err := tx.Where("application_id = ?", applicationID).Delete(&model.ApplicationAllowedScope{}).Error; err != nil {
+		return err
+	}
+	for scope := range seen {
+		entry := model.ApplicationAllowedScope{ApplicationID: applicationID, Scope: scope, PendingApproval: pending[scope]}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingAllowedScopes picks out the scopes on a new allow-list that need
+// their resource server's approval: those another application defines
+// that weren't already approved for this client and that canApprove
+// doesn't allow.
+func pendingAllowedScopes(tx *gorm.DB, applicationID string, scopes []string, canApprove ScopeApprover) (map[string]bool, error) {
+	pending := map[string]bool{}
+	if len(scopes) == 0 {
+		return pending, nil
+	}
+	foreign := []model.ApplicationScope{}
+	if err := tx.Where("name IN ? AND application_id <> ?", scopes, applicationID).Find(&foreign).Error; err != nil {
+		return nil, err
+	}
+	var approved []string
+	err := tx.Model(&model.ApplicationAllowedScope{}).
+		Where("application_id = ? AND pending_approval = ?", applicationID, false).
+		Pluck("scope", &approved).Error
+	if err != nil {
+		return nil, err
+	}
+	approvable := map[string]bool{}
+	for _, scope := range foreign {
+		if slices.Contains(approved, scope.Name) {
+			continue
+		}
+		ok, decided := approvable[scope.ApplicationID]
+		if !decided {
+			var resourceServer model.Application
+			err := tx.Where("id = ?", scope.ApplicationID).First(&resourceServer).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			ok = err == nil && canApprove != nil && canApprove(resourceServer)
+			approvable[scope.ApplicationID] = ok
+		}
+		if !ok {
+			pending[scope.Name] = true
+		}
+	}
+	return pending, nil
+}
+
+// ScopeRequest is a client waiting for a resource server to approve one of
+// its scopes.
+type ScopeRequest struct {
+	Scope           string `json:"scope"`
+	ApplicationID   string `json:"application_id"`
+	ClientID        string `json:"client_id"`
+	ApplicationName string `json:"application_name"`
+}
+
+// GetPendingScopeRequests lists the live clients waiting on approval for
+// the resource server's scopes, by scope and then client name.
+func GetPendingScopeRequests(resourceServerID string) ([]ScopeRequest, error) {
+	requests := []ScopeRequest{}
+	err := database.DB.Raw(`
+		SELECT allowed.scope, allowed.application_id, a.client_id, a.name AS application_name
+		FROM application_allowed_scope allowed
+		INNER JOIN application_scope s ON s.name = allowed.scope
+		INNER JOIN application a ON a.id = allowed.application_id AND a.deleted_at IS NULL
+		WHERE s.application_id = ? AND allowed.pending_approval
+		ORDER BY allowed.scope, a.name
+	`, resourceServerID).Scan(&requests).Error
+	if err != nil {
+		return []ScopeRequest{}, err
+	}
+	return requests, nil
+}
+
+// ApproveAllowedScope lets a client request a scope that was pending its
+// resource server's approval.
+func ApproveAllowedScope(applicationID string, scope string) error {
+	result := database.DB.Model(&model.ApplicationAllowedScope{}).
+		Where("application_id = ? AND scope = ? AND pending_approval = ?", applicationID, scope, true).
+		Update("pending_approval", false)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RejectAllowedScope takes a pending scope off a client's allow-list.
+func RejectAllowedScope(applicationID string, scope string) error {
+	result := database.DB.
+		Where("application_id = ? AND scope = ? AND pending_approval = ?", applicationID, scope, true).
+		Delete(&model.ApplicationAllowedScope{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetApplicationScope returns a registered scope by name.
+func GetApplicationScope(name string) (model.ApplicationScope, error) {
+	var scope model.ApplicationScope
+	if err := database.DB.Where("name = ?", name).First(&scope).Error; err != nil {
+		return model.ApplicationScope{}, err
+	}
+	return scope, nil
+}
+
+// validateAllowedScopes checks a client allow-list without saving it; see
+// SetApplicationAllowedScopes for the rules.
+func validateAllowedScopes(scopes []string) error {
+	custom := []string{}
+	for _, scope := range scopes {
+		if scope == "sentinel:all" {
+			return fmt.Errorf("%w: sentinel:all can't be granted to client applications", ErrUnknownScope)
+		}
+		if _, builtin := model.BuiltinScopes[scope]; !builtin {
+			custom = append(custom, scope)
+		}
+	}
+	if len(custom) == 0 {
+		return nil
+	}
+	var registered []string
+	if err := database.DB.Model(&model.ApplicationScope{}).Where("name IN ?", custom).Pluck("name", &registered).Error; err != nil {
+		return err
+	}
+	known := map[string]bool{}
+	for _, name := range registered {
+		known[name] = true
+	}
+	for _, scope := range custom {
+		if !known[scope] {
+			return fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/pkg/logger"
+	"github.com/gaucho-racing/ulid-go"
+)
+
+// RecordSecurityEvent stores an event. A failure is logged rather than
+// returned: whatever raised the event has already acted on it.
+func RecordSecurityEvent(event model.SecurityEvent) {
+	event.ID = ulid.Make().Prefixed("sev")
+	if err := database.DB.Create(&event).Error; err != nil {
+		logger.SugarLogger.Errorf("Failed to record %s security event for %s: %v", event.Type, event.EntityID, err)
+	}
+}
+
+// GetSecurityEventsForEntity returns the events recorded against an
+// entity, newest first.
+func GetSecurityEventsForEntity(entityID string) ([]model.SecurityEvent, error) {
+	events := []model.SecurityEvent{}
+	if err := database.DB.Where("entity_id = ?", entityID).Order("created_at DESC").Find(&events).Error; err != nil {
+		return []model.SecurityEvent{}, err
+	}
+	return events, nil
+}
@@ -212,7 +212,7 @@ func MintServiceAccountToken(sa model.ServiceAccount) (model.Token, string, erro
 		"service_account_id": sa.ID,
 	}
 
-	raw, tokenID, err := GenerateToken(sa.EntityID, app.ClientID, sa.Scope, ttlSeconds, claims)
+	raw, tokenID, err := GenerateToken(sa.EntityID, app.ClientID, sa.Scope, ttlSeconds, claims, "")
 	if err != nil {
 		return model.Token{}, "", err
 	}
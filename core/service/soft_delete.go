@@ -0,0 +1,206 @@
+package service
+
+import (
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/config"
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// Soft delete: deleting a user, group, or application only stamps its
+// deleted_at. The rows that hang off it (memberships, owners, application
+// links, redirect URIs, the SAML SP, service accounts) are left untouched,
+// so a restore within config.SoftDeleteRetention is just clearing the stamp.
+// PurgeDeletedRecords removes whatever is still deleted once the retention
+// window has passed.
+
+// restoreRow clears deleted_at on a soft-deleted row. Returns
+// gorm.ErrRecordNotFound if the row doesn't exist or isn't deleted.
+func restoreRow(value interface{}, id string) error {
+	result := database.DB.Unscoped().Model(value).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func RestoreUser(id string) (model.User, error) {
+	if err := restoreRow(&model.User{}, id); err != nil {
+		return model.User{}, err
+	}
+	return GetUserByID(id)
+}
+
+// RestoreGroup brings a group back with its members, owners, join requests,
+// and application links. Conditional bindings that require the group start
+// matching again, so a full conditional reconcile is kicked off.
+func RestoreGroup(id string) (model.Group, error) {
+	if err := restoreRow(&model.Group{}, id); err != nil {
+		return model.Group{}, err
+	}
+	TriggerReconcileAllConditional()
+	return GetGroupByID(id)
+}
+
+func RestoreApplication(id string) (model.Application, error) {
+	if err := restoreRow(&model.Application{}, id); err != nil {
+		return model.Application{}, err
+	}
+	return GetApplicationByID(id)
+}
+
+// GetDeletedApplicationByID looks up an application whether or not it's
+// soft-deleted, so the restore endpoint can authorize against its owner.
+func GetDeletedApplicationByID(id string) (model.Application, error) {
+	var app model.Application
+	if err := database.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&app).Error; err != nil {
+		return model.Application{}, err
+	}
+	return app, nil
+}
+
+// PurgeDeletedRecords hard-deletes every user, group, and application that
+// was soft-deleted more than config.SoftDeleteRetention ago, along with
+// their dependent rows. Each record is purged in its own transaction and
+// failures are logged per record.
+func PurgeDeletedRecords() {
+	cutoff := time.Now().Add(-config.SoftDeleteRetention)
+
+	var groupIDs []string
+	if err := database.DB.Unscoped().Model(&model.Group{}).Where("deleted_at < ?", cutoff).Pluck("id", &groupIDs).Error; err != nil {
+		logger.SugarLogger.Errorf("purge: failed to load deleted groups: %v", err)
+	}
+	for _, id := range groupIDs {
+		if err := database.DB.Transaction(func(tx *gorm.DB) error { return purgeGroup(tx, id) }); err != nil {
+			logger.SugarLogger.Errorf("purge: failed to purge group %s: %v", id, err)
+		}
+	}
+
+	var appIDs []string
+	if err := database.DB.Unscoped().Model(&model.Application{}).Where("deleted_at < ?", cutoff).Pluck("id", &appIDs).Error; err != nil {
+		logger.SugarLogger.Errorf("purge: failed to load deleted applications: %v", err)
+	}
+	for _, id := range appIDs {
+		if err := database.DB.Transaction(func(tx *gorm.DB) error { return purgeApplication(tx, id) }); err != nil {
+			logger.SugarLogger.Errorf("purge: failed to purge application %s: %v", id, err)
+		}
+	}
+
+	var userIDs []string
+	if err := database.DB.Unscoped().Model(&model.User{}).Where("deleted_at < ?", cutoff).Pluck("id", &userIDs).Error; err != nil {
+		logger.SugarLogger.Errorf("purge: failed to load deleted users: %v", err)
+	}
+	for _, id := range userIDs {
+		if err := database.DB.Transaction(func(tx *gorm.DB) error { return purgeUser(tx, id) }); err != nil {
+			logger.SugarLogger.Errorf("purge: failed to purge user %s: %v", id, err)
+		}
+	}
+
+	if len(groupIDs)+len(appIDs)+len(userIDs) > 0 {
+		logger.SugarLogger.Infof("purge: removed %d groups, %d applications, %d users", len(groupIDs), len(appIDs), len(userIDs))
+	}
+}
+
+func purgeGroup(tx *gorm.DB, id string) error {
+	var requestIDs []string
+	if err := tx.Model(&model.GroupJoinRequest{}).Where("group_id = ?", id).Pluck("id", &requestIDs).Error; err != nil {
+		return err
+	}
+	if len(requestIDs) > 0 {
+		if err := tx.Where("request_id IN ?", requestIDs).Delete(&model.GroupJoinRequestComment{}).Error; err != nil {
+			return err
+		}
+	}
+	for _, value := range []interface{}{
+		&model.GroupJoinRequest{},
+		&model.GroupMember{},
+		&model.GroupOwner{},
+		&model.GroupConditionalBinding{},
+		&model.ApplicationGroup{},
+	} {
+		if err := tx.Where("group_id = ?", id).Delete(value).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("parent_group_id = ? OR child_group_id = ?", id, id).Delete(&model.GroupChild{}).Error; err != nil {
+		return err
+	}
+	// Groups that sent their alumni here fall back to having none.
+	if err := tx.Unscoped().Model(&model.Group{}).Where("alumni_group_id = ?", id).Update("alumni_group_id", "").Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id = ?", id).Delete(&model.Group{}).Error
+}
+
+func purgeApplication(tx *gorm.DB, id string) error {
+	var accounts []model.ServiceAccount
+	if err := tx.Where("application_id = ?", id).Find(&accounts).Error; err != nil {
+		return err
+	}
+	for _, sa := range accounts {
+		if err := tx.Where("entity_id = ?", sa.EntityID).Delete(&model.Token{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", sa.EntityID).Delete(&model.Entity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", sa.ID).Delete(&model.ServiceAccount{}).Error; err != nil {
+			return err
+		}
+	}
+	for _, value := range []interface{}{
+		&model.ApplicationRedirectURI{},
+		&model.ApplicationSecret{},
+		&model.ApplicationAllowedScope{},
+		&model.ApplicationGroup{},
+		&model.ApplicationRole{},
+		&model.SAMLServiceProvider{},
+		&model.ApplicationTokenPolicy{},
+	} {
+		if err := tx.Where("application_id = ?", id).Delete(value).Error; err != nil {
+			return err
+		}
+	}
+	// The app's own scopes go too, off other clients' allow-lists first.
+	if err := tx.Where("scope IN (?)", tx.Model(&model.ApplicationScope{}).Select("name").Where("application_id = ?", id)).
+		Delete(&model.ApplicationAllowedScope{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("application_id = ?", id).Delete(&model.ApplicationScope{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id = ?", id).Delete(&model.Application{}).Error
+}
+
+func purgeUser(tx *gorm.DB, id string) error {
+	if err := tx.Where("user_id = ?", id).Delete(&model.UserStatusChange{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id = ?", id).Delete(&model.User{}).Error
+}
+
+// StartSoftDeletePurgeCron ticks PurgeDeletedRecords on
+// config.SoftDeletePurgeInterval. Non-positive interval disables it.
+func StartSoftDeletePurgeCron() {
+	interval := config.SoftDeletePurgeInterval
+	if interval <= 0 {
+		logger.SugarLogger.Infof("purge: disabled (interval=%v)", interval)
+		return
+	}
+	logger.SugarLogger.Infof("purge: enabled, interval=%v, retention=%v", interval, config.SoftDeleteRetention)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			PurgeDeletedRecords()
+		}
+	}()
+}
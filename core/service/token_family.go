@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/pkg/logger"
+	"github.com/gaucho-racing/ulid-go"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidRefreshToken covers a refresh token that's malformed,
+	// expired, revoked, issued to another client, or whose family has
+	// ended. The cases aren't distinguished.
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when a refresh token that was
+	// already rotated is presented again. Its family has been revoked by
+	// the time the caller sees this.
+	ErrRefreshTokenReused = errors.New("refresh token was already used; the session has been revoked")
+)
+
+// RotatedRefreshToken is a refresh token that's just been exchanged: its
+// claims, and the family the replacement belongs in. Family is nil for
+// tokens issued before families existed; the caller starts a new one.
+type RotatedRefreshToken struct {
+	Claims *model.TokenClaims
+	Family *model.TokenFamily
+}
+
+// CreateTokenFamily starts a family for a new grant that ends lifetime
+// from now.
+func CreateTokenFamily(entityID, clientID string, lifetime time.Duration) (model.TokenFamily, error) {
+	family := model.TokenFamily{
+		ID:        ulid.Make().Prefixed("tfam"),
+		EntityID:  entityID,
+		ClientID:  clientID,
+		ExpiresAt: time.Now().Add(lifetime),
+	}
+	if err := database.DB.Create(&family).Error; err != nil {
+		return model.TokenFamily{}, err
+	}
+	return family, nil
+}
+
+// RotateRefreshToken exchanges a refresh token issued to clientID: it
+// checks the token and its family and marks the token rotated, so it can't
+// be exchanged again. A token that was already rotated is reuse: its
+// family and every token in it are revoked, a security event is recorded
+// against ipAddress, and ErrRefreshTokenReused is returned. Of two
+// concurrent exchanges of the same token, the second is treated as reuse.
+func RotateRefreshToken(token, clientID, ipAddress string) (RotatedRefreshToken, error) {
+	claims, err := parseToken(token)
+	if err != nil || !slices.Contains(strings.Fields(claims.Scope), "refresh_token") {
+		return RotatedRefreshToken{}, ErrInvalidRefreshToken
+	}
+	var row model.Token
+	if err := database.DB.Where("id = ?", claims.ID).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return RotatedRefreshToken{}, ErrInvalidRefreshToken
+		}
+		return RotatedRefreshToken{}, err
+	}
+	if row.ClientID != clientID {
+		return RotatedRefreshToken{}, ErrInvalidRefreshToken
+	}
+	if row.RotatedAt != nil {
+		return RotatedRefreshToken{}, refreshTokenReused(row, ipAddress)
+	}
+	if err := CheckEntityActive(claims.Subject); err != nil {
+		return RotatedRefreshToken{}, err
+	}
+
+	if row.FamilyID == "" {
+		if err := RevokeToken(row.ID); err != nil {
+			return RotatedRefreshToken{}, err
+		}
+		return RotatedRefreshToken{Claims: claims}, nil
+	}
+
+	var family model.TokenFamily
+	if err := database.DB.Where("id = ?", row.FamilyID).First(&family).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return RotatedRefreshToken{}, ErrInvalidRefreshToken
+		}
+		return RotatedRefreshToken{}, err
+	}
+	if family.RevokedAt != nil || !family.ExpiresAt.After(time.Now()) {
+		return RotatedRefreshToken{}, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	result := database.DB.Model(&model.Token{}).
+		Where("id = ? AND rotated_at IS NULL", row.ID).
+		Update("rotated_at", now)
+	if result.Error != nil {
+		return RotatedRefreshToken{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return RotatedRefreshToken{}, refreshTokenReused(row, ipAddress)
+	}
+	if err := database.DB.Model(&family).Update("last_rotated_at", now).Error; err != nil {
+		logger.SugarLogger.Errorf("Failed to record rotation of token family %s: %v", family.ID, err)
+	}
+	return RotatedRefreshToken{Claims: claims, Family: &family}, nil
+}
+
+// refreshTokenReused revokes the family of a replayed refresh token and
+// records the security event. A token from before families has none, so
+// only it is revoked. It returns ErrRefreshTokenReused unless revoking
+// fails.
+func refreshTokenReused(row model.Token, ipAddress string) error {
+	detail := fmt.Sprintf("Refresh token %s was presented after it had been rotated. Token family %s was revoked.", row.ID, row.FamilyID)
+	if row.FamilyID == "" {
+		logger.SugarLogger.Warnf("Refresh token %s for entity %s on client %s was reused; revoking it", row.ID, row.EntityID, row.ClientID)
+		if err := database.DB.Where("id = ?", row.ID).Delete(&model.Token{}).Error; err != nil {
+			return fmt.Errorf("revoke refresh token: %w", err)
+		}
+		detail = fmt.Sprintf("Refresh token %s was presented after it had been rotated. It had no token family, so only it was revoked.", row.ID)
+	} else {
+		logger.SugarLogger.Warnf("Refresh token %s for entity %s on client %s was reused; revoking family %s", row.ID, row.EntityID, row.ClientID, row.FamilyID)
+		if err := RevokeTokenFamily(row.FamilyID, "refresh token reuse"); err != nil {
+			return fmt.Errorf("revoke token family: %w", err)
+		}
+	}
+	RecordSecurityEvent(model.SecurityEvent{
+		Type:      model.SecurityEventRefreshTokenReuse,
+		EntityID:  row.EntityID,
+		ClientID:  row.ClientID,
+		IPAddress: ipAddress,
+		Detail:    detail,
+	})
+	return ErrRefreshTokenReused
+}
+
+// RevokeTokenFamily ends a family and deletes every token in it, access
+// and ID tokens included, so they stop validating immediately. An empty id
+// is ignored: tokens from before families all share it.
+func RevokeTokenFamily(id, reason string) error {
+	if id == "" {
+		return nil
+	}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.TokenFamily{}).
+			Where("id = ? AND revoked_at IS NULL", id).
+			Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).Error; err != nil {
+			return err
+		}
+		return tx.Where("family_id = ?", id).Delete(&model.Token{}).Error
+	})
+}
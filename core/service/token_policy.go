@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidTokenPolicy wraps the reason a token policy was rejected.
+var ErrInvalidTokenPolicy = errors.New("invalid token policy")
+
+// Upper bounds on token policy lifetimes, in seconds. Access and ID tokens
+// can't be revoked once a relying party has verified them offline, so they
+// stay short.
+const (
+	maxAccessTokenTTL  = 24 * 60 * 60
+	maxIDTokenTTL      = 24 * 60 * 60
+	maxRefreshTokenTTL = 365 * 24 * 60 * 60
+)
+
+// GetTokenPolicy returns an application's token policy, or
+// model.DefaultTokenPolicy if it hasn't set one.
+func GetTokenPolicy(applicationID string) (model.ApplicationTokenPolicy, error) {
+	var policy model.ApplicationTokenPolicy
+	if err := database.DB.Where("application_id = ?", applicationID).First(&policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.DefaultTokenPolicy(applicationID), nil
+		}
+		return model.ApplicationTokenPolicy{}, err
+	}
+	if policy.OptionalClaims == nil {
+		policy.OptionalClaims = model.StringSlice{}
+	}
+	return policy, nil
+}
+
+// SetTokenPolicy validates a policy and stores it as its application's,
+// replacing any existing one.
+func SetTokenPolicy(policy model.ApplicationTokenPolicy) (model.ApplicationTokenPolicy, error) {
+	policy.SharedEmail = strings.TrimSpace(policy.SharedEmail)
+	policy.SharedUsername = strings.TrimSpace(policy.SharedUsername)
+	policy.SharedFirstName = strings.TrimSpace(policy.SharedFirstName)
+	policy.SharedLastName = strings.TrimSpace(policy.SharedLastName)
+	claims := model.StringSlice{}
+	for _, claim := range policy.OptionalClaims {
+		if !slices.Contains(claims, claim) {
+			claims = append(claims, claim)
+		}
+	}
+	policy.OptionalClaims = claims
+	if err := validateTokenPolicy(policy); err != nil {
+		return model.ApplicationTokenPolicy{}, err
+	}
+	err := database.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "application_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"access_token_ttl", "id_token_ttl", "refresh_token_idle_ttl", "refresh_token_absolute_ttl",
+			"allow_offline_access", "optional_claims",
+			"shared_email", "shared_username", "shared_first_name", "shared_last_name", "updated_at",
+		}),
+	}).Create(&policy).Error
+	if err != nil {
+		return model.ApplicationTokenPolicy{}, err
+	}
+	return GetTokenPolicy(policy.ApplicationID)
+}
+
+func validateTokenPolicy(policy model.ApplicationTokenPolicy) error {
+	for _, ttl := range []struct {
+		name  string
+		value int
+		max   int
+	}{
+		{"access_token_ttl", policy.AccessTokenTTL, maxAccessTokenTTL},
+		{"id_token_ttl", policy.IDTokenTTL, maxIDTokenTTL},
+		{"refresh_token_idle_ttl", policy.RefreshTokenIdleTTL, maxRefreshTokenTTL},
+		{"refresh_token_absolute_ttl", policy.RefreshTokenAbsoluteTTL, maxRefreshTokenTTL},
+	} {
+		if ttl.value < 0 || ttl.value > ttl.max {
+			return fmt.Errorf("%w: %s must be between 0 and %d seconds", ErrInvalidTokenPolicy, ttl.name, ttl.max)
+		}
+	}
+	if policy.RefreshTokenIdleTTL > 0 && policy.RefreshTokenAbsoluteTTL > 0 &&
+		policy.RefreshTokenIdleTTL > policy.RefreshTokenAbsoluteTTL {
+		return fmt.Errorf("%w: refresh_token_idle_ttl can't exceed refresh_token_absolute_ttl", ErrInvalidTokenPolicy)
+	}
+	for _, claim := range policy.OptionalClaims {
+		if !slices.Contains(model.OptionalClaims, claim) {
+			return fmt.Errorf("%w: unknown optional claim %q", ErrInvalidTokenPolicy, claim)
+		}
+	}
+	if policy.HasSharedIdentity() {
+		if _, err := mail.ParseAddress(policy.SharedEmail); err != nil {
+			return fmt.Errorf("%w: shared_email must be an email address", ErrInvalidTokenPolicy)
+		}
+	} else if policy.SharedUsername != "" || policy.SharedFirstName != "" || policy.SharedLastName != "" {
+		return fmt.Errorf("%w: a shared identity needs shared_email", ErrInvalidTokenPolicy)
+	}
+	return nil
+}
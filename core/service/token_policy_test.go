@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gaucho-racing/sentinel/core/model"
+)
+
+func TestValidateTokenPolicy(t *testing.T) {
+	if err := validateTokenPolicy(model.DefaultTokenPolicy("app_1")); err != nil {
+		t.Errorf("default policy: %v", err)
+	}
+	valid := model.ApplicationTokenPolicy{
+		AccessTokenTTL:          300,
+		IDTokenTTL:              300,
+		RefreshTokenIdleTTL:     3600,
+		RefreshTokenAbsoluteTTL: 86400,
+		OptionalClaims:          model.StringSlice{model.OptionalClaimEmail},
+		SharedEmail:             "team@example.com",
+		SharedUsername:          "team",
+	}
+	if err := validateTokenPolicy(valid); err != nil {
+		t.Errorf("valid policy: %v", err)
+	}
+
+	invalid := []struct {
+		name string
+		edit func(*model.ApplicationTokenPolicy)
+	}{
+		{"negative lifetime", func(p *model.ApplicationTokenPolicy) { p.AccessTokenTTL = -1 }},
+		{"access token lifetime over the cap", func(p *model.ApplicationTokenPolicy) { p.AccessTokenTTL = maxAccessTokenTTL + 1 }},
+		{"ID token lifetime over the cap", func(p *model.ApplicationTokenPolicy) { p.IDTokenTTL = maxIDTokenTTL + 1 }},
+		{"refresh lifetime over the cap", func(p *model.ApplicationTokenPolicy) { p.RefreshTokenAbsoluteTTL = maxRefreshTokenTTL + 1 }},
+		{"idle outlasting absolute", func(p *model.ApplicationTokenPolicy) { p.RefreshTokenIdleTTL = 90000 }},
+		{"unknown claim", func(p *model.ApplicationTokenPolicy) { p.OptionalClaims = model.StringSlice{"phone"} }},
+		{"bad shared email", func(p *model.ApplicationTokenPolicy) { p.SharedEmail = "team" }},
+		{"shared name without email", func(p *model.ApplicationTokenPolicy) { p.SharedEmail = "" }},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := valid
+			tt.edit(&policy)
+			if err := validateTokenPolicy(policy); !errors.Is(err, ErrInvalidTokenPolicy) {
+				t.Errorf("validateTokenPolicy error = %v, want ErrInvalidTokenPolicy", err)
+			}
+		})
+	}
+
+	// An idle lifetime alone is fine: the absolute one defaults.
+	idleOnly := model.ApplicationTokenPolicy{RefreshTokenIdleTTL: 90000}
+	if err := validateTokenPolicy(idleOnly); err != nil {
+		t.Errorf("idle lifetime without an absolute one: %v", err)
+	}
+}
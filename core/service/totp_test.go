@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key from RFC 6238 appendix B,
+// "12345678901234567890", in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeAtRFC6238(t *testing.T) {
+	// The RFC's vectors are 8 digits; a 6-digit code is their last six.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := totpCodeAt(rfc6238Secret, tt.unix/totpPeriod)
+		if err != nil {
+			t.Fatalf("totpCodeAt(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("totpCodeAt(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+	// Secrets are accepted in lower case, as some apps display them.
+	if got, _ := totpCodeAt("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", 59/totpPeriod); got != "287082" {
+		t.Errorf("totpCodeAt with a lower-case secret = %s, want 287082", got)
+	}
+	if _, err := totpCodeAt("not base32!", 1); err == nil {
+		t.Error("totpCodeAt accepted an invalid secret")
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / totpPeriod
+	codeAt := func(step int64) string {
+		code, err := totpCodeAt(rfc6238Secret, step)
+		if err != nil {
+			t.Fatalf("totpCodeAt: %v", err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		lastUsed int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", codeAt(current), 0, current, true},
+		{"surrounding whitespace", " " + codeAt(current) + "\n", 0, current, true},
+		{"one step behind", codeAt(current - 1), 0, current - 1, true},
+		{"one step ahead", codeAt(current + 1), 0, current + 1, true},
+		{"two steps behind", codeAt(current - 2), 0, 0, false},
+		{"two steps ahead", codeAt(current + 2), 0, 0, false},
+		{"replay of the last used step", codeAt(current), current, 0, false},
+		{"step before the last used one", codeAt(current - 1), current, 0, false},
+		{"later step after an earlier use", codeAt(current + 1), current, current + 1, true},
+		{"wrong length", "12345", 0, 0, false},
+		{"wrong code", "000000", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := verifyTOTP(rfc6238Secret, tt.code, now, tt.lastUsed)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("verifyTOTP = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
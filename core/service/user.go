@@ -1,21 +1,90 @@
 package service
 
 import (
+	"time"
+
 	"github.com/gaucho-racing/sentinel/core/database"
 	"github.com/gaucho-racing/sentinel/core/model"
 	"github.com/gaucho-racing/sentinel/core/pkg/logger"
 	"github.com/gaucho-racing/ulid-go"
 )
 
-func GetAllUsers() ([]model.User, error) {
-	users := []model.User{}
-	if err := database.DB.Find(&users).Error; err != nil {
-		return []model.User{}, err
+// UserFilter narrows ListUsers. Zero values mean "don't filter on this".
+// GroupID and Source both match on group membership: GroupID alone means
+// "member of this group", Source alone "member of any group via this
+// source", and both together "member of this group via this source".
+type UserFilter struct {
+	Query          string
+	GraduationYear int
+	Major          string
+	GroupID        string
+	Source         string
+	CreatedAfter   time.Time
+	// IncludeDeleted also lists soft-deleted users.
+	IncludeDeleted bool
+}
+
+var userListSpec = listSpec[model.User]{
+	sorts: map[string]sortField[model.User]{
+		"created_at":      {column: `"user".created_at`, kind: sortTime, value: func(u model.User) any { return u.CreatedAt }},
+		"username":        {column: `"user".username`, kind: sortString, value: func(u model.User) any { return u.Username }},
+		"first_name":      {column: `"user".first_name`, kind: sortString, value: func(u model.User) any { return u.FirstName }},
+		"last_name":       {column: `"user".last_name`, kind: sortString, value: func(u model.User) any { return u.LastName }},
+		"graduation_year": {column: `"user".graduation_year`, kind: sortInt, value: func(u model.User) any { return u.GraduationYear }},
+	},
+	defaultSort: "username",
+	idColumn:    `"user".id`,
+	id:          func(u model.User) string { return u.ID },
+}
+
+// ListUsers returns one page of the user directory. Query is a free-text
+// search: every whitespace-separated term must appear in the first name,
+// last name, username, or login email.
+func ListUsers(filter UserFilter, opts ListOptions) (Page[model.User], error) {
+	query := database.DB.Model(&model.User{})
+	if filter.IncludeDeleted {
+		query = query.Unscoped()
 	}
-	for i := range users {
-		PopulateUser(&users[i])
+	for _, term := range searchTerms(filter.Query) {
+		query = query.Where(`("user".first_name ILIKE ? OR "user".last_name ILIKE ? OR "user".username ILIKE ? OR EXISTS (
+			SELECT 1 FROM auth_entity_email WHERE auth_entity_email.entity_id = "user".entity_id AND auth_entity_email.email ILIKE ?))`,
+			term, term, term, term)
+	}
+	if filter.GraduationYear != 0 {
+		query = query.Where(`"user".graduation_year = ?`, filter.GraduationYear)
+	}
+	if filter.Major != "" {
+		query = query.Where(`LOWER("user".major) = LOWER(?)`, filter.Major)
+	}
+	if filter.GroupID != "" || filter.Source != "" {
+		membership := database.DB.Table("group_member").Select("1").Where(`group_member.entity_id = "user".entity_id`)
+		if filter.GroupID != "" {
+			groupIDs := []string{filter.GroupID}
+			// Without a source filter, match effective members: anyone in
+			// the group or any group nested under it.
+			if filter.Source == "" {
+				hierarchy, err := loadGroupHierarchy()
+				if err != nil {
+					return Page[model.User]{}, err
+				}
+				groupIDs = append(groupIDs, hierarchy.descendants(filter.GroupID)...)
+			}
+			membership = membership.Where("group_member.group_id IN ?", groupIDs)
+		}
+		if filter.Source != "" {
+			membership = membership.Where("group_member.source = ?", filter.Source)
+		}
+		query = query.Where("EXISTS (?)", membership)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where(`"user".created_at > ?`, filter.CreatedAfter)
 	}
-	return users, nil
+	page, err := paginate(query, opts, userListSpec)
+	if err != nil {
+		return Page[model.User]{}, err
+	}
+	populateUsers(page.Data)
+	return page, nil
 }
 
 func GetUserByID(id string) (model.User, error) {
@@ -40,7 +109,8 @@ func GetUserByEntityID(entityID string) (model.User, error) {
 // matched case-insensitively.
 func IsUsernameAvailable(username string) (bool, error) {
 	var count int64
-	if err := database.DB.Model(&model.User{}).
+	// Unscoped: a soft-deleted user keeps their username until purged.
+	if err := database.DB.Unscoped().Model(&model.User{}).
 		Where("LOWER(username) = LOWER(?)", username).
 		Count(&count).Error; err != nil {
 		return false, err
@@ -52,6 +122,10 @@ func CreateUser(user model.User) (model.User, error) {
 	if user.ID == "" {
 		user.ID = ulid.Make().Prefixed("usr")
 	}
+	if user.Status == "" {
+		user.Status = model.UserStatusActive
+		user.StatusEffectiveAt = time.Now()
+	}
 	if err := database.DB.Create(&user).Error; err != nil {
 		return model.User{}, err
 	}
@@ -67,6 +141,8 @@ func UpdateUser(user model.User) (model.User, error) {
 	return user, nil
 }
 
+// DeleteUser soft-deletes a user. They drop out of every list and lookup
+// and their tokens stop validating, until RestoreUser or the purge job.
 func DeleteUser(id string) error {
 	if err := database.DB.Where("id = ?", id).Delete(&model.User{}).Error; err != nil {
 		return err
@@ -96,23 +172,100 @@ func PopulateUser(user *model.User) {
 	}
 }
 
-// GetGroupsForEntity loads an entity's groups in two queries — the memberships,
-// then every referenced group at once. Fetching one group per membership made
-// this cost a round trip per group, and since it runs on the token-issuing path
-// (several times per login) it made auth latency scale with a user's group
-// count, to the point of exceeding the timeouts relying parties allow.
+// populateUsers is PopulateUser for a whole page at once: group names,
+// emails, and phone numbers are each loaded in a single query instead of a
+// few per row.
+func populateUsers(users []model.User) {
+	if len(users) == 0 {
+		return
+	}
+	entityIDs := make([]string, 0, len(users))
+	for _, u := range users {
+		entityIDs = append(entityIDs, u.EntityID)
+	}
+
+	var memberships []model.GroupMember
+	if err := database.DB.Where("entity_id IN ?", entityIDs).Find(&memberships).Error; err != nil {
+		logger.SugarLogger.Errorf("Failed to get groups for users: %v", err)
+	}
+	// Add a row for each group a user is in only through a child group.
+	hierarchy, err := loadGroupHierarchy()
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to load group hierarchy for users: %v", err)
+	}
+	heldByEntity := map[string][]string{}
+	for _, m := range memberships {
+		heldByEntity[m.EntityID] = append(heldByEntity[m.EntityID], m.GroupID)
+	}
+	for entityID, held := range heldByEntity {
+		for groupID := range hierarchy.ancestors(held) {
+			memberships = append(memberships, model.GroupMember{GroupID: groupID, EntityID: entityID})
+		}
+	}
+	groupIDs := make([]string, 0, len(memberships))
+	for _, m := range memberships {
+		groupIDs = append(groupIDs, m.GroupID)
+	}
+	groupNames := map[string]string{}
+	if len(groupIDs) > 0 {
+		var groups []model.Group
+		if err := database.DB.Select("id", "name").Where("id IN ?", groupIDs).Find(&groups).Error; err != nil {
+			logger.SugarLogger.Errorf("Failed to get groups for users: %v", err)
+		}
+		for _, g := range groups {
+			groupNames[g.ID] = g.Name
+		}
+	}
+	namesByEntity := map[string][]string{}
+	for _, m := range memberships {
+		if name, ok := groupNames[m.GroupID]; ok {
+			namesByEntity[m.EntityID] = append(namesByEntity[m.EntityID], name)
+		}
+	}
+
+	var emails []model.EntityEmail
+	if err := database.DB.Where("entity_id IN ?", entityIDs).Find(&emails).Error; err != nil {
+		logger.SugarLogger.Errorf("Failed to get email auth for users: %v", err)
+	}
+	emailByEntity := map[string]string{}
+	for _, e := range emails {
+		emailByEntity[e.EntityID] = e.Email
+	}
+
+	var phones []model.EntityPhone
+	if err := database.DB.Where("entity_id IN ?", entityIDs).Find(&phones).Error; err != nil {
+		logger.SugarLogger.Errorf("Failed to get phone auth for users: %v", err)
+	}
+	phoneByEntity := map[string]string{}
+	for _, p := range phones {
+		phoneByEntity[p.EntityID] = p.PhoneNumber
+	}
+
+	for i := range users {
+		users[i].Groups = namesByEntity[users[i].EntityID]
+		if users[i].Groups == nil {
+			users[i].Groups = []string{}
+		}
+		users[i].Email = emailByEntity[users[i].EntityID]
+		users[i].PhoneNumber = phoneByEntity[users[i].EntityID]
+	}
+}
+
+// GetGroupsForEntity loads every group an entity is an effective member of —
+// its own memberships plus the groups those are nested under — in a fixed
+// number of queries: the memberships, the hierarchy, then every referenced
+// group at once. Fetching one group per membership made this cost a round
+// trip per group, and since it runs on the token-issuing path (several times
+// per login) it made auth latency scale with a user's group count, to the
+// point of exceeding the timeouts relying parties allow.
 func GetGroupsForEntity(entityID string) ([]model.Group, error) {
-	var members []model.GroupMember
-	if err := database.DB.Where("entity_id = ?", entityID).Find(&members).Error; err != nil {
+	ids, err := effectiveGroupIDs(entityID)
+	if err != nil {
 		return []model.Group{}, err
 	}
-	if len(members) == 0 {
+	if len(ids) == 0 {
 		return []model.Group{}, nil
 	}
-	ids := make([]string, 0, len(members))
-	for _, member := range members {
-		ids = append(ids, member.GroupID)
-	}
 	groups := []model.Group{}
 	if err := database.DB.Where("id IN ?", ids).Find(&groups).Error; err != nil {
 		return []model.Group{}, err
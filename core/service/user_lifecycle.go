@@ -0,0 +1,323 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/core/config"
+	"github.com/gaucho-racing/sentinel/core/database"
+	"github.com/gaucho-racing/sentinel/core/model"
+	"github.com/gaucho-racing/sentinel/core/pkg/logger"
+	"github.com/gaucho-racing/ulid-go"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidUserStatus       = errors.New("invalid user status")
+	ErrInvalidStatusTransition = errors.New("user cannot move to that status from its current one")
+	// ErrUserInactive is returned wherever a suspended, offboarded, or
+	// deleted user would otherwise get (or keep using) a token.
+	ErrUserInactive = errors.New("user account is suspended, offboarded, or deleted")
+)
+
+// userStatusTransitions is the lifecycle state machine: active members
+// become alumni or get suspended, and only alumni or suspended members can
+// be offboarded. Alumni and suspended members can be reinstated; offboarded
+// is terminal.
+var userStatusTransitions = map[model.UserStatus][]model.UserStatus{
+	model.UserStatusActive:    {model.UserStatusAlumni, model.UserStatusSuspended},
+	model.UserStatusAlumni:    {model.UserStatusActive, model.UserStatusSuspended, model.UserStatusOffboarded},
+	model.UserStatusSuspended: {model.UserStatusActive, model.UserStatusAlumni, model.UserStatusOffboarded},
+}
+
+func IsValidUserStatus(status model.UserStatus) bool {
+	switch status {
+	case model.UserStatusActive, model.UserStatusAlumni, model.UserStatusSuspended, model.UserStatusOffboarded:
+		return true
+	}
+	return false
+}
+
+func CanTransitionUserStatus(from, to model.UserStatus) bool {
+	for _, next := range userStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckEntityActive returns ErrUserInactive when the entity belongs to a
+// suspended, offboarded, or deleted user. Entities without a user (service
+// accounts) are always active.
+func CheckEntityActive(entityID string) error {
+	var user model.User
+	err := database.DB.Unscoped().Select("status", "deleted_at").Where("entity_id = ?", entityID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.DeletedAt.Valid || user.Status == model.UserStatusSuspended || user.Status == model.UserStatusOffboarded {
+		return ErrUserInactive
+	}
+	return nil
+}
+
+// ChangeUserStatus moves a user to a new lifecycle state. A zero or past
+// effectiveAt applies the change now; a future one schedules it for the
+// status sweep. Either way the transition is checked against the user's
+// current state, and any change already scheduled for the user is
+// replaced.
+func ChangeUserStatus(userID string, to model.UserStatus, reason string, effectiveAt time.Time, changedBy string) (model.UserStatusChange, error) {
+	if !IsValidUserStatus(to) {
+		return model.UserStatusChange{}, ErrInvalidUserStatus
+	}
+	var user model.User
+	if err := database.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		return model.UserStatusChange{}, err
+	}
+	if !CanTransitionUserStatus(user.Status, to) {
+		return model.UserStatusChange{}, ErrInvalidStatusTransition
+	}
+	now := time.Now()
+	if effectiveAt.IsZero() {
+		effectiveAt = now
+	}
+	change := model.UserStatusChange{
+		ID:          ulid.Make().Prefixed("usc"),
+		UserID:      user.ID,
+		EntityID:    user.EntityID,
+		FromStatus:  user.Status,
+		ToStatus:    to,
+		Reason:      reason,
+		EffectiveAt: effectiveAt,
+		CreatedBy:   changedBy,
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND applied_at IS NULL", user.ID).Delete(&model.UserStatusChange{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&change).Error
+	})
+	if err != nil {
+		return model.UserStatusChange{}, err
+	}
+	if effectiveAt.After(now) {
+		return change, nil
+	}
+	return applyUserStatusChange(change)
+}
+
+// GetStatusChangesForUser returns a user's lifecycle history, newest first,
+// including any change still scheduled.
+func GetStatusChangesForUser(userID string) ([]model.UserStatusChange, error) {
+	changes := []model.UserStatusChange{}
+	if err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&changes).Error; err != nil {
+		return []model.UserStatusChange{}, err
+	}
+	return changes, nil
+}
+
+// CancelScheduledStatusChange deletes a change that hasn't been applied yet.
+// Returns gorm.ErrRecordNotFound if there's no such pending change.
+func CancelScheduledStatusChange(userID, changeID string) error {
+	result := database.DB.Where("id = ? AND user_id = ? AND applied_at IS NULL", changeID, userID).Delete(&model.UserStatusChange{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// StatusChangeFilter narrows ListAppliedStatusChanges.
+type StatusChangeFilter struct {
+	ToStatus     model.UserStatus
+	AppliedAfter time.Time
+}
+
+var statusChangeListSpec = listSpec[model.UserStatusChange]{
+	sorts: map[string]sortField[model.UserStatusChange]{
+		"applied_at": {column: "applied_at", kind: sortTime, value: func(c model.UserStatusChange) any { return *c.AppliedAt }},
+	},
+	defaultSort: "applied_at",
+	idColumn:    "id",
+	id:          func(c model.UserStatusChange) string { return c.ID },
+}
+
+// ListAppliedStatusChanges is the lifecycle feed integrations poll: applied
+// changes only, oldest first by default, so a consumer can keep the last
+// applied_at it saw and ask for everything after it.
+func ListAppliedStatusChanges(filter StatusChangeFilter, opts ListOptions) (Page[model.UserStatusChange], error) {
+	query := database.DB.Model(&model.UserStatusChange{}).Where("applied_at IS NOT NULL")
+	if filter.ToStatus != "" {
+		query = query.Where("to_status = ?", filter.ToStatus)
+	}
+	if !filter.AppliedAfter.IsZero() {
+		query = query.Where("applied_at > ?", filter.AppliedAfter)
+	}
+	return paginate(query, opts, statusChangeListSpec)
+}
+
+// ApplyDueStatusChanges applies every scheduled change whose effective date
+// has passed. Failures are logged per change so one bad row doesn't hold up
+// the rest.
+func ApplyDueStatusChanges() {
+	var due []model.UserStatusChange
+	if err := database.DB.Where("applied_at IS NULL AND effective_at <= ?", time.Now()).Order("effective_at").Find(&due).Error; err != nil {
+		logger.SugarLogger.Errorf("user status: failed to load scheduled changes: %v", err)
+		return
+	}
+	for _, change := range due {
+		if _, err := applyUserStatusChange(change); err != nil {
+			logger.SugarLogger.Errorf("user status: failed to apply change %s for user %s: %v", change.ID, change.UserID, err)
+		}
+	}
+}
+
+// StartUserStatusCron ticks ApplyDueStatusChanges on
+// config.UserStatusSweepInterval. Non-positive interval disables it.
+func StartUserStatusCron() {
+	interval := config.UserStatusSweepInterval
+	if interval <= 0 {
+		logger.SugarLogger.Infof("user status: sweep disabled (interval=%v)", interval)
+		return
+	}
+	logger.SugarLogger.Infof("user status: sweep enabled, interval=%v", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ApplyDueStatusChanges()
+		}
+	}()
+}
+
+// applyUserStatusChange flips the user's status and marks the change
+// applied in one transaction, then runs the transition's side effects. The
+// transition is re-checked against the user's status at apply time: a
+// scheduled change that no longer makes sense (the user was reinstated in
+// the meantime, say) is dropped instead of applied.
+func applyUserStatusChange(change model.UserStatusChange) (model.UserStatusChange, error) {
+	// A dropped change must stay deleted, so the transaction commits and
+	// ErrInvalidStatusTransition is returned once it has.
+	dropped := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		if err := tx.Where("id = ?", change.UserID).First(&user).Error; err != nil {
+			return err
+		}
+		if !CanTransitionUserStatus(user.Status, change.ToStatus) {
+			dropped = true
+			return tx.Delete(&change).Error
+		}
+		now := time.Now()
+		change.FromStatus = user.Status
+		change.AppliedAt = &now
+		if err := tx.Model(&model.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"status":              change.ToStatus,
+			"status_effective_at": change.EffectiveAt,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Save(&change).Error
+	})
+	if err != nil {
+		return model.UserStatusChange{}, err
+	}
+	if dropped {
+		return model.UserStatusChange{}, ErrInvalidStatusTransition
+	}
+	logger.SugarLogger.Infof("user status: user %s moved %s -> %s", change.UserID, change.FromStatus, change.ToStatus)
+
+	switch change.ToStatus {
+	case model.UserStatusAlumni:
+		moveMembershipsToAlumniGroups(change.EntityID, change.CreatedBy)
+	case model.UserStatusSuspended:
+		revokeTokensForStatusChange(change)
+	case model.UserStatusOffboarded:
+		revokeTokensForStatusChange(change)
+		stripDirectMemberships(change.EntityID)
+	}
+	// Bindings can test status as well as membership, so every change is
+	// reconciled, not only the ones that moved DIRECT memberships.
+	ReconcileConditionalForEntity(change.EntityID)
+	return change, nil
+}
+
+func revokeTokensForStatusChange(change model.UserStatusChange) {
+	if err := DeleteTokensForEntity(change.EntityID); err != nil {
+		logger.SugarLogger.Errorf("user status: failed to revoke tokens for %s: %v", change.EntityID, err)
+	}
+}
+
+// moveMembershipsToAlumniGroups adds the entity to the alumni group of
+// every group it belongs to that has one, and ends the DIRECT memberships
+// it moved out of. Memberships from a sync source (Discord, conditional)
+// stay put — their integration owns them.
+func moveMembershipsToAlumniGroups(entityID, addedBy string) {
+	memberships, err := GetMembershipsForEntity(entityID, "")
+	if err != nil {
+		logger.SugarLogger.Errorf("user status: failed to load memberships for %s: %v", entityID, err)
+		return
+	}
+	if len(memberships) == 0 {
+		return
+	}
+	current := make(map[string]bool, len(memberships))
+	groupIDs := make([]string, 0, len(memberships))
+	for _, m := range memberships {
+		current[m.GroupID] = true
+		groupIDs = append(groupIDs, m.GroupID)
+	}
+	var groups []model.Group
+	if err := database.DB.Select("id", "alumni_group_id").Where("id IN ? AND alumni_group_id IN (?)", groupIDs, database.DB.Model(&model.Group{}).Select("id")).Find(&groups).Error; err != nil {
+		logger.SugarLogger.Errorf("user status: failed to load alumni groups for %s: %v", entityID, err)
+		return
+	}
+	alumniGroupFor := make(map[string]string, len(groups))
+	for _, g := range groups {
+		alumniGroupFor[g.ID] = g.AlumniGroupID
+	}
+
+	for _, m := range memberships {
+		alumniGroupID, ok := alumniGroupFor[m.GroupID]
+		if !ok {
+			continue
+		}
+		if !current[alumniGroupID] {
+			if _, err := CreateGroupMember(model.GroupMember{
+				GroupID:  alumniGroupID,
+				EntityID: entityID,
+				Source:   string(model.GroupMemberSourceDirect),
+				AddedBy:  addedBy,
+			}); err != nil {
+				logger.SugarLogger.Errorf("user status: failed to add %s to alumni group %s: %v", entityID, alumniGroupID, err)
+				continue
+			}
+			current[alumniGroupID] = true
+		}
+		if m.Source == string(model.GroupMemberSourceDirect) {
+			if err := DeleteGroupMember(m.GroupID, entityID, m.Source); err != nil {
+				logger.SugarLogger.Errorf("user status: failed to remove %s from group %s: %v", entityID, m.GroupID, err)
+			}
+		}
+	}
+}
+
+// stripDirectMemberships ends every DIRECT membership the entity holds.
+func stripDirectMemberships(entityID string) {
+	memberships, err := GetMembershipsForEntity(entityID, string(model.GroupMemberSourceDirect))
+	if err != nil {
+		logger.SugarLogger.Errorf("user status: failed to load memberships for %s: %v", entityID, err)
+		return
+	}
+	for _, m := range memberships {
+		if err := DeleteGroupMember(m.GroupID, entityID, m.Source); err != nil {
+			logger.SugarLogger.Errorf("user status: failed to remove %s from group %s: %v", entityID, m.GroupID, err)
+		}
+	}
+}
@@ -45,4 +45,5 @@ func InitializeRoutes(router *gin.Engine) {
 	router.GET("/discord/role-bindings", ListRoleBindings)
 	router.POST("/discord/role-bindings", CreateRoleBinding)
 	router.DELETE("/discord/role-bindings/:bindingID", DeleteRoleBinding)
+	router.POST("/discord/direct-messages", SendDirectMessage)
 }
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gaucho-racing/sentinel/discord/pkg/logger"
+	"github.com/gaucho-racing/sentinel/discord/service"
+	"github.com/gin-gonic/gin"
+)
+
+type sendDirectMessageRequest struct {
+	UserID  string `json:"user_id" binding:"required"`
+	Content string `json:"content" binding:"required"`
+}
+
+// SendDirectMessage DMs a Discord user on behalf of another Sentinel service
+// (e.g. oauth delivering a password reset link). Internal callers only — an
+// open DM relay through the bot would be a phishing channel.
+func SendDirectMessage(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+
+	var req sendDirectMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	msg, err := service.SendDirectMessage(req.UserID, req.Content)
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to DM %s: %v", req.UserID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to send direct message"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message_id": msg.ID, "channel_id": msg.ChannelID})
+}
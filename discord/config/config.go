@@ -55,6 +55,11 @@ var OnboardingTokenTTL = 15 * time.Minute
 // falls back to 1h. Set to 0 (or any non-positive duration) to disable.
 var GroupSyncInterval = parseDurationOr("GROUP_SYNC_INTERVAL", time.Hour)
 
+// LifecyclePollInterval is how often core's user lifecycle feed is polled
+// for offboardings, whose group-bound Discord roles are then stripped.
+// Non-positive disables the watcher.
+var LifecyclePollInterval = parseDurationOr("LIFECYCLE_POLL_INTERVAL", time.Minute)
+
 func parseDurationOr(envKey string, fallback time.Duration) time.Duration {
 	raw := os.Getenv(envKey)
 	if raw == "" {
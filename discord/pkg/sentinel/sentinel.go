@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -199,3 +201,33 @@ func Patch(route string, body interface{}, result interface{}, headers ...map[st
 func Delete(route string, result interface{}, headers ...map[string]string) error {
 	return do("DELETE", route, nil, result, headers)
 }
+
+// GetAll reads every page of a core list endpoint, following next_cursor
+// until it runs out. route may already carry filters; the cursor and a
+// maximum page size are appended to them.
+func GetAll[T any](route string) ([]T, error) {
+	sep := "?"
+	if strings.Contains(route, "?") {
+		sep = "&"
+	}
+	var all []T
+	cursor := ""
+	for {
+		pageRoute := route + sep + "limit=200"
+		if cursor != "" {
+			pageRoute += "&cursor=" + url.QueryEscape(cursor)
+		}
+		var page struct {
+			Data       []T    `json:"data"`
+			NextCursor string `json:"next_cursor"`
+		}
+		if err := Get(pageRoute, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Data...)
+		if page.NextCursor == "" {
+			return all, nil
+		}
+		cursor = page.NextCursor
+	}
+}
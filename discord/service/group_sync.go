@@ -150,8 +150,8 @@ func computeDesiredDiscordGroups(userRoles []string) ([]string, error) {
 	if len(eligible) == 0 {
 		return nil, nil
 	}
-	var groups []groupSummary
-	if err := sentinel.Get("/api/groups", &groups); err != nil {
+	groups, err := sentinel.GetAll[groupSummary]("/api/groups?source=DISCORD")
+	if err != nil {
 		return nil, err
 	}
 	discordEnabled := make(map[string]bool, len(groups))
@@ -294,8 +294,8 @@ func reconcileAllOnboardedDiscordUsers(ctx context.Context) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
-	var groups []groupSummary
-	if err := sentinel.Get("/api/groups", &groups); err != nil {
+	groups, err := sentinel.GetAll[groupSummary]("/api/groups?source=DISCORD")
+	if err != nil {
 		return fmt.Errorf("load groups: %w", err)
 	}
 	discordEnabled := make(map[string]bool, len(groups))
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/discord/config"
+	"github.com/gaucho-racing/sentinel/discord/pkg/logger"
+	"github.com/gaucho-racing/sentinel/discord/pkg/sentinel"
+)
+
+// userStatusChange mirrors core/model/user.go::UserStatusChange on the wire.
+type userStatusChange struct {
+	ID        string    `json:"id"`
+	EntityID  string    `json:"entity_id"`
+	ToStatus  string    `json:"to_status"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// entityExternalAuths is the slice of core's entity response that links it
+// to a Discord account.
+type entityExternalAuths struct {
+	ExternalAuths []struct {
+		Provider   string `json:"provider"`
+		ExternalID string `json:"external_id"`
+	} `json:"external_auths"`
+}
+
+// lifecycleLookback is how far back the watcher reads on startup, so an
+// offboarding applied while this service was down still lands. Stripping
+// roles is idempotent, so re-reading recent changes is harmless.
+const lifecycleLookback = 24 * time.Hour
+
+// StartLifecycleWatcher polls core's lifecycle feed for offboardings. Core
+// strips an offboarded member's DIRECT memberships itself, but DISCORD
+// memberships follow Discord roles — so the roles that feed group bindings
+// come off here, and the usual role-driven sync drops the memberships.
+func StartLifecycleWatcher() {
+	interval := config.LifecyclePollInterval
+	if interval <= 0 {
+		logger.SugarLogger.Infof("lifecycle: watcher disabled (interval=%v)", interval)
+		return
+	}
+	logger.SugarLogger.Infof("lifecycle: watcher enabled, interval=%v", interval)
+	go func() {
+		since := time.Now().Add(-lifecycleLookback)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			since = processOffboardings(since)
+		}
+	}()
+}
+
+// processOffboardings handles every offboarding applied after since and
+// returns the new high-water mark. On a feed error the mark is unchanged so
+// the next tick retries.
+func processOffboardings(since time.Time) time.Time {
+	route := "/api/core/users/status-changes?status=OFFBOARDED&applied_after=" + url.QueryEscape(since.UTC().Format(time.RFC3339Nano))
+	changes, err := sentinel.GetAll[userStatusChange](route)
+	if err != nil {
+		logger.SugarLogger.Errorf("lifecycle: failed to read status changes: %v", err)
+		return since
+	}
+	for _, change := range changes {
+		if err := stripBoundRolesForEntity(change.EntityID); err != nil {
+			logger.SugarLogger.Errorf("lifecycle: failed to strip roles for offboarded entity %s: %v", change.EntityID, err)
+		}
+		if change.AppliedAt.After(since) {
+			since = change.AppliedAt
+		}
+	}
+	return since
+}
+
+// stripBoundRolesForEntity removes every role referenced by a group binding
+// from the entity's Discord account, then reconciles their DISCORD
+// memberships against what's left. Entities without a linked Discord
+// account, or no longer in the guild, are skipped.
+func stripBoundRolesForEntity(entityID string) error {
+	var entity entityExternalAuths
+	if err := sentinel.Get("/api/core/entity/"+entityID, &entity); err != nil {
+		return err
+	}
+	discordID := ""
+	for _, auth := range entity.ExternalAuths {
+		if auth.Provider == "DISCORD" {
+			discordID = auth.ExternalID
+		}
+	}
+	if discordID == "" {
+		return nil
+	}
+	member, err := GetGuildMember(discordID)
+	if err != nil {
+		logger.SugarLogger.Debugf("lifecycle: discord user %s not in guild: %v", discordID, err)
+		return nil
+	}
+
+	bindings, err := GetAllRoleBindings()
+	if err != nil {
+		return err
+	}
+	bound := map[string]bool{}
+	for _, b := range bindings {
+		for _, roleID := range b.DiscordRoleIDs {
+			bound[roleID] = true
+		}
+	}
+	remaining := make([]string, 0, len(member.Roles))
+	for _, roleID := range member.Roles {
+		if !bound[roleID] {
+			remaining = append(remaining, roleID)
+			continue
+		}
+		if err := Discord.GuildMemberRoleRemove(config.DiscordGuild, discordID, roleID); err != nil {
+			logger.SugarLogger.Errorf("lifecycle: failed to remove role %s from discord user %s: %v", roleID, discordID, err)
+			remaining = append(remaining, roleID)
+			continue
+		}
+		logger.SugarLogger.Infof("lifecycle: removed role %s from offboarded discord user %s", roleID, discordID)
+	}
+	return ReconcileGroupsForDiscordUser(discordID, remaining)
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"time"
+
+	"github.com/gaucho-racing/sentinel/github/config"
+	"github.com/gaucho-racing/sentinel/github/pkg/logger"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+func Run() {
+	api := InitializeRouter()
+	InitializeRoutes(api)
+	err := api.Run(":" + config.Port)
+	if err != nil {
+		logger.SugarLogger.Fatalf("Failed to start server: %v", err)
+	}
+}
+
+func InitializeRouter() *gin.Engine {
+	if config.IsProduction() {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	r := gin.Default()
+	r.Use(cors.New(cors.Config{
+		AllowAllOrigins:  true,
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization"},
+		MaxAge:           12 * time.Hour,
+		AllowCredentials: true,
+	}))
+	r.Use(AuthChecker())
+	r.Use(UnauthorizedPanicHandler())
+	return r
+}
+
+func InitializeRoutes(router *gin.Engine) {
+	router.GET("/github/ping", Ping)
+
+	router.GET("/github/group-bindings", ListGitHubBindings)
+	router.POST("/github/group-bindings", CreateGitHubBinding)
+	router.DELETE("/github/group-bindings/:bindingID", DeleteGitHubBinding)
+
+	router.POST("/github/reconcile", TriggerReconcile)
+}
+
+// GetClientIP returns the originating client IP, preferring Cloudflare's
+// unspoofable CF-Connecting-IP and falling back to gin's c.ClientIP().
+func GetClientIP(c *gin.Context) string {
+	if ip := c.GetHeader("CF-Connecting-IP"); ip != "" {
+		return ip
+	}
+	return c.ClientIP()
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gaucho-racing/sentinel/github/model"
+	"github.com/gaucho-racing/sentinel/github/service"
+	"github.com/gin-gonic/gin"
+)
+
+// teamSlugPattern matches GitHub team slugs: lowercase alphanumeric runs
+// joined by hyphens or underscores, as GitHub derives them from team names.
+var teamSlugPattern = regexp.MustCompile(`^[a-z0-9]+(?:[-_][a-z0-9]+)*$`)
+
+// ListGitHubBindings returns all group→team bindings, optionally filtered to
+// a single group_id. Used by the web UI and by reconciliation.
+func ListGitHubBindings(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+
+	if groupID := c.Query("group_id"); groupID != "" {
+		bindings, err := service.GetGitHubBindingsForGroup(groupID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, bindings)
+		return
+	}
+
+	bindings, err := service.GetAllGitHubBindings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, bindings)
+}
+
+type createGitHubBindingRequest struct {
+	GroupID  string `json:"group_id" binding:"required"`
+	TeamSlug string `json:"team_slug" binding:"required"`
+}
+
+func CreateGitHubBinding(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+
+	var req createGitHubBindingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	slug := strings.ToLower(strings.TrimSpace(req.TeamSlug))
+	if !teamSlugPattern.MatchString(slug) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "team_slug must be a github team slug (e.g. firmware-team)"})
+		return
+	}
+
+	binding, err := service.CreateGitHubBinding(c.Request.Context(), model.GroupGitHubBinding{
+		GroupID:  req.GroupID,
+		TeamSlug: slug,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrBindingExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrTeamNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, binding)
+}
+
+// DeleteGitHubBinding removes a binding by ID. The group_id query param is
+// required to scope the delete — protects against URL tampering that would
+// otherwise let a caller delete a binding for a group they don't control.
+func DeleteGitHubBinding(c *gin.Context) {
+	Require(c, RequestTokenHasScope(c, "sentinel:all"))
+
+	bindingID := c.Param("bindingID")
+	groupID := c.Query("group_id")
+	if groupID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_id query param is required"})
+		return
+	}
+	if err := service.DeleteGitHubBinding(groupID, bindingID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
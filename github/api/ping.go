@@ -0,0 +1,12 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gaucho-racing/sentinel/github/config"
+	"github.com/gin-gonic/gin"
+)
+
+func Ping(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"message": config.FormattedNameWithVersion() + " is online!"})
+}
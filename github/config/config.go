@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+const Name = "sentinel-github"
+const Version = "5.8.7"
+
+func FormattedNameWithVersion() string {
+	return Name + ":v" + Version
+}
+
+var Env = os.Getenv("ENV")
+var Port = os.Getenv("PORT")
+
+// Kerbecs admin API — the gateway doubles as the service registry. The sentinel
+// client resolves gateway-form paths (/api/core/...) to upstream URLs via its
+// /admin-gw/resolve endpoint, which sits behind basic auth.
+var KerbecsEndpoint = os.Getenv("KERBECS_ENDPOINT")
+var KerbecsUser = os.Getenv("KERBECS_USER")
+var KerbecsPassword = os.Getenv("KERBECS_PASSWORD")
+
+var DatabaseHost = os.Getenv("DATABASE_HOST")
+var DatabasePort = os.Getenv("DATABASE_PORT")
+var DatabaseUser = os.Getenv("DATABASE_USER")
+var DatabasePassword = os.Getenv("DATABASE_PASSWORD")
+var DatabaseName = os.Getenv("DATABASE_NAME")
+
+// InternalBootstrapSecret is the shared secret this service uses at
+// startup to exchange for its pre-seeded bearer JWT from core. Must
+// match core's INTERNAL_BOOTSTRAP_SECRET.
+var InternalBootstrapSecret = os.Getenv("INTERNAL_BOOTSTRAP_SECRET")
+
+// InternalServiceName is the SA name on core that this service exchanges
+// the bootstrap secret for. Must match a value in
+// core/jobs/init.go::InternalServiceAccountNames.
+const InternalServiceName = "sentinel-github"
+
+// GitHubOrg is the organization whose teams bindings point at. When empty,
+// GitHub sync is disabled and the service runs as a no-op (binding CRUD
+// still works).
+var GitHubOrg = os.Getenv("GITHUB_ORG")
+
+// GitHubToken authenticates calls to the GitHub REST API. It needs
+// admin:org (or a fine-grained token with organization Members read/write)
+// to manage team memberships and send org invitations.
+var GitHubToken = os.Getenv("GITHUB_TOKEN")
+
+// GitHubAPIURL is the REST API base URL. Defaults to api.github.com; point
+// it at a GitHub Enterprise host or a fake API server for local testing.
+var GitHubAPIURL = os.Getenv("GITHUB_API_URL")
+
+// GitHubSyncInterval is how often the reconcile cron fires. Like Google
+// sync, this is a periodic full sweep rather than per-change triggers from
+// core — team membership isn't latency-critical.
+const GitHubSyncInterval = 5 * time.Minute
+
+// GitHubSyncMaxRemovals caps how many members a single per-team reconcile
+// may remove. If a run wants to remove more than this, it skips the
+// removals for that team and logs loudly — a guard against draining a team
+// when core returns an empty/partial member set (e.g. mid-outage).
+const GitHubSyncMaxRemovals = 25
+
+func IsProduction() bool {
+	return Env == "PROD"
+}
+
+// GitHubSyncEnabled reports whether the service has the credentials needed
+// to talk to GitHub. When false, the reconcile engine no-ops.
+func GitHubSyncEnabled() bool {
+	return GitHubOrg != "" && GitHubToken != ""
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/gaucho-racing/sentinel/github/api"
+	"github.com/gaucho-racing/sentinel/github/config"
+	"github.com/gaucho-racing/sentinel/github/database"
+	"github.com/gaucho-racing/sentinel/github/pkg/kerbecs"
+	"github.com/gaucho-racing/sentinel/github/pkg/logger"
+	"github.com/gaucho-racing/sentinel/github/pkg/sentinel"
+	"github.com/gaucho-racing/sentinel/github/service"
+)
+
+func main() {
+	logger.Init(config.IsProduction())
+	defer logger.Logger.Sync()
+
+	config.Verify()
+	config.PrintStartupBanner()
+	kerbecs.Init(config.KerbecsEndpoint, config.KerbecsUser, config.KerbecsPassword)
+
+	// Exchange the shared bootstrap secret for this service's pre-seeded
+	// bearer JWT. From here on, every outbound sentinel-client call
+	// carries Authorization: Bearer <our SA token>.
+	if err := sentinel.Bootstrap(config.InternalServiceName, config.InternalBootstrapSecret); err != nil {
+		logger.SugarLogger.Fatalf("Failed to bootstrap service token: %v", err)
+	}
+
+	database.Init()
+
+	if err := service.InitGitHubClient(); err != nil {
+		logger.SugarLogger.Fatalf("Failed to initialize GitHub client: %v", err)
+	}
+	service.StartReconcileCron()
+
+	api.Run()
+}
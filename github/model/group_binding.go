@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// GroupGitHubBinding maps a Sentinel group to a team in the configured GitHub
+// org. Every member of the group who has linked a GitHub account is kept on
+// the team. Like Google bindings this is many-to-many: a group may feed any
+// number of teams, and a team may be fed by several groups — reconcile takes
+// the union of every binding that targets the same team. The pair
+// (GroupID, TeamSlug) is unique.
+//
+// Owned by the github service: bindings reference Sentinel group IDs from
+// core but live in github's domain. Sync is one-way (Sentinel -> GitHub);
+// this row only records where to project.
+type GroupGitHubBinding struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	GroupID   string    `json:"group_id" gorm:"uniqueIndex:idx_group_github_binding_pair"`
+	TeamSlug  string    `json:"team_slug" gorm:"uniqueIndex:idx_group_github_binding_pair;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (GroupGitHubBinding) TableName() string {
+	return "group_github_binding"
+}
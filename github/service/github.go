@@ -0,0 +1,216 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/github/config"
+	"github.com/gaucho-racing/sentinel/github/pkg/logger"
+)
+
+// githubClient is a minimal GitHub REST client scoped to one org. nil when
+// GitHub sync is disabled (no org/token configured).
+var githubClient *gitHubClient
+
+// githubPageSize is the per_page used for list calls — GitHub's maximum.
+const githubPageSize = 100
+
+type gitHubClient struct {
+	baseURL string
+	org     string
+	token   string
+	http    *http.Client
+}
+
+// gitHubError is a non-2xx response from the GitHub API.
+type gitHubError struct {
+	Status  int
+	Message string
+}
+
+func (e *gitHubError) Error() string {
+	return fmt.Sprintf("github api %d: %s", e.Status, e.Message)
+}
+
+// teamMember is a GitHub user reduced to the fields reconcile needs.
+type teamMember struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+// InitGitHubClient builds the REST client from GITHUB_ORG, GITHUB_TOKEN and
+// GITHUB_API_URL. A no-op when sync is disabled, so the service still boots
+// and serves binding CRUD without GitHub credentials.
+func InitGitHubClient() error {
+	if !config.GitHubSyncEnabled() {
+		logger.SugarLogger.Warnln("github sync disabled: GITHUB_ORG / GITHUB_TOKEN not set")
+		return nil
+	}
+	if _, err := url.Parse(config.GitHubAPIURL); err != nil {
+		return fmt.Errorf("parse GITHUB_API_URL: %w", err)
+	}
+	githubClient = &gitHubClient{
+		baseURL: strings.TrimRight(config.GitHubAPIURL, "/"),
+		org:     config.GitHubOrg,
+		token:   config.GitHubToken,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+	logger.SugarLogger.Infof("github sync enabled for org %s (%s)", config.GitHubOrg, githubClient.baseURL)
+	return nil
+}
+
+// do sends one API request and decodes the JSON response into out (when
+// non-nil). Non-2xx responses come back as *gitHubError.
+func (gc *gitHubClient) do(ctx context.Context, method, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, gc.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+gc.token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := gc.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		if e.Message == "" {
+			e.Message = resp.Status
+		}
+		return &gitHubError{Status: resp.StatusCode, Message: e.Message}
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// listAll walks a paginated list endpoint until a short page comes back.
+func listAll[T any](ctx context.Context, gc *gitHubClient, path string) ([]T, error) {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	var all []T
+	for page := 1; ; page++ {
+		var items []T
+		if err := gc.do(ctx, http.MethodGet, fmt.Sprintf("%s%sper_page=%d&page=%d", path, sep, githubPageSize, page), nil, &items); err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if len(items) < githubPageSize {
+			return all, nil
+		}
+	}
+}
+
+func (gc *gitHubClient) teamPath(slug string) string {
+	return "/orgs/" + url.PathEscape(gc.org) + "/teams/" + url.PathEscape(slug)
+}
+
+// getTeam checks that the team exists in the org. Returns ErrTeamNotFound on
+// a 404.
+func getTeam(ctx context.Context, slug string) error {
+	if err := githubClient.do(ctx, http.MethodGet, githubClient.teamPath(slug), nil, nil); err != nil {
+		if isStatus(err, http.StatusNotFound) {
+			return ErrTeamNotFound
+		}
+		return fmt.Errorf("get team %s: %w", slug, err)
+	}
+	return nil
+}
+
+// listTeamMembers returns the team's active members with the given role
+// ("member" or "maintainer").
+func listTeamMembers(ctx context.Context, slug, role string) ([]teamMember, error) {
+	members, err := listAll[teamMember](ctx, githubClient, githubClient.teamPath(slug)+"/members?role="+role)
+	if err != nil {
+		return nil, fmt.Errorf("list %s members of team %s: %w", role, slug, err)
+	}
+	return members, nil
+}
+
+// listTeamInvitations returns the logins with a pending invitation to the
+// team. Invitations sent to a bare email address carry no login and are
+// skipped.
+func listTeamInvitations(ctx context.Context, slug string) ([]string, error) {
+	invitations, err := listAll[struct {
+		Login *string `json:"login"`
+	}](ctx, githubClient, githubClient.teamPath(slug)+"/invitations")
+	if err != nil {
+		return nil, fmt.Errorf("list invitations of team %s: %w", slug, err)
+	}
+	var logins []string
+	for _, inv := range invitations {
+		if inv.Login != nil && *inv.Login != "" {
+			logins = append(logins, *inv.Login)
+		}
+	}
+	return logins, nil
+}
+
+// getUserLogin resolves a GitHub account ID to its current login. Sentinel
+// keys GitHub external auth on the numeric ID because logins can be renamed.
+func getUserLogin(ctx context.Context, githubID string) (string, error) {
+	var user teamMember
+	if err := githubClient.do(ctx, http.MethodGet, "/user/"+url.PathEscape(githubID), nil, &user); err != nil {
+		return "", fmt.Errorf("get github user %s: %w", githubID, err)
+	}
+	return user.Login, nil
+}
+
+// addTeamMember adds login to the team as a plain member. GitHub invites the
+// user to the org first when they aren't in it yet; the membership stays
+// pending until they accept.
+func addTeamMember(ctx context.Context, slug, login string) error {
+	path := githubClient.teamPath(slug) + "/memberships/" + url.PathEscape(login)
+	if err := githubClient.do(ctx, http.MethodPut, path, map[string]string{"role": "member"}, nil); err != nil {
+		return fmt.Errorf("add %s to team %s: %w", login, slug, err)
+	}
+	return nil
+}
+
+// removeTeamMember removes login from the team, cancelling a pending
+// invitation if there is one. Org membership is left alone. A 404 (not a
+// member) is treated as success.
+func removeTeamMember(ctx context.Context, slug, login string) error {
+	path := githubClient.teamPath(slug) + "/memberships/" + url.PathEscape(login)
+	if err := githubClient.do(ctx, http.MethodDelete, path, nil, nil); err != nil {
+		if isStatus(err, http.StatusNotFound) {
+			return nil
+		}
+		return fmt.Errorf("remove %s from team %s: %w", login, slug, err)
+	}
+	return nil
+}
+
+func isStatus(err error, code int) bool {
+	var gerr *gitHubError
+	return errors.As(err, &gerr) && gerr.Status == code
+}
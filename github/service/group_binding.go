@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gaucho-racing/sentinel/github/database"
+	"github.com/gaucho-racing/sentinel/github/model"
+	"github.com/gaucho-racing/ulid-go"
+)
+
+// ErrBindingExists is returned when the (group, team) pair is already bound.
+// The API layer maps it to 409.
+var ErrBindingExists = errors.New("group is already bound to that github team")
+
+// ErrTeamNotFound is returned when the team slug doesn't exist in the
+// configured org.
+var ErrTeamNotFound = errors.New("github team not found in the organization")
+
+func GetAllGitHubBindings() ([]model.GroupGitHubBinding, error) {
+	bindings := []model.GroupGitHubBinding{}
+	if err := database.DB.Find(&bindings).Error; err != nil {
+		return []model.GroupGitHubBinding{}, err
+	}
+	return bindings, nil
+}
+
+// GetGitHubBindingsForGroup returns every binding for a group. Empty slice
+// when the group has none.
+func GetGitHubBindingsForGroup(groupID string) ([]model.GroupGitHubBinding, error) {
+	bindings := []model.GroupGitHubBinding{}
+	if err := database.DB.Where("group_id = ?", groupID).Find(&bindings).Error; err != nil {
+		return []model.GroupGitHubBinding{}, err
+	}
+	return bindings, nil
+}
+
+// CreateGitHubBinding inserts a binding. Team slugs are stored lowercased so
+// reconcile can group by them. When sync is enabled the team is checked
+// against the org up front, so a typo fails here instead of in every sweep.
+func CreateGitHubBinding(ctx context.Context, binding model.GroupGitHubBinding) (model.GroupGitHubBinding, error) {
+	binding.TeamSlug = strings.ToLower(binding.TeamSlug)
+
+	var existing int64
+	if err := database.DB.Model(&model.GroupGitHubBinding{}).
+		Where("group_id = ? AND team_slug = ?", binding.GroupID, binding.TeamSlug).
+		Count(&existing).Error; err != nil {
+		return model.GroupGitHubBinding{}, err
+	}
+	if existing > 0 {
+		return model.GroupGitHubBinding{}, ErrBindingExists
+	}
+	if githubClient != nil {
+		if err := getTeam(ctx, binding.TeamSlug); err != nil {
+			return model.GroupGitHubBinding{}, err
+		}
+	}
+
+	if binding.ID == "" {
+		binding.ID = ulid.Make().Prefixed("ghb")
+	}
+	if err := database.DB.Create(&binding).Error; err != nil {
+		return model.GroupGitHubBinding{}, err
+	}
+	return binding, nil
+}
+
+// DeleteGitHubBinding scopes the delete to (groupID, bindingID) so a tampered
+// request can't drop a binding for a different group. If it was the team's
+// last binding, the team's current members are left in place — the team just
+// stops being managed.
+func DeleteGitHubBinding(groupID, bindingID string) error {
+	if err := database.DB.Where("group_id = ? AND id = ?", groupID, bindingID).Delete(&model.GroupGitHubBinding{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
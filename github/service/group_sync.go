@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/github/config"
+	"github.com/gaucho-racing/sentinel/github/model"
+	"github.com/gaucho-racing/sentinel/github/pkg/logger"
+	"github.com/gaucho-racing/sentinel/github/pkg/sentinel"
+)
+
+// coreGroupMember mirrors the fields of core's GroupMember we need.
+type coreGroupMember struct {
+	EntityID string `json:"entity_id"`
+	Source   string `json:"source"`
+}
+
+// coreExternalAuth mirrors core's EntityExternalAuth.
+type coreExternalAuth struct {
+	EntityID   string         `json:"entity_id"`
+	ExternalID string         `json:"external_id"`
+	Metadata   map[string]any `json:"metadata"`
+}
+
+func getGroupMembers(groupID string) ([]coreGroupMember, error) {
+	return sentinel.GetAll[coreGroupMember]("/api/groups/" + groupID + "/members")
+}
+
+// getLinkedGitHubAccounts returns every entity's linked GitHub account, keyed
+// by entity ID.
+func getLinkedGitHubAccounts() (map[string]coreExternalAuth, error) {
+	var auths []coreExternalAuth
+	if err := sentinel.Get("/api/core/entity/external/GITHUB", &auths); err != nil {
+		return nil, err
+	}
+	byEntity := make(map[string]coreExternalAuth, len(auths))
+	for _, a := range auths {
+		byEntity[a.EntityID] = a
+	}
+	return byEntity, nil
+}
+
+// teamTarget is one GitHub team and every binding that feeds it. Several
+// Sentinel groups may project onto the same team, so reconcile works per
+// target on the union of its bindings — reconciling binding-by-binding would
+// have each one remove the others' members.
+type teamTarget struct {
+	Slug     string
+	Bindings []model.GroupGitHubBinding
+}
+
+// groupTargets buckets bindings by (lowercased) team slug, in a stable order
+// so sweep logs read the same run to run.
+func groupTargets(bindings []model.GroupGitHubBinding) []teamTarget {
+	bySlug := make(map[string]*teamTarget)
+	var order []string
+	for _, b := range bindings {
+		slug := strings.ToLower(b.TeamSlug)
+		t, ok := bySlug[slug]
+		if !ok {
+			t = &teamTarget{Slug: slug}
+			bySlug[slug] = t
+			order = append(order, slug)
+		}
+		t.Bindings = append(t.Bindings, b)
+	}
+	sort.Strings(order)
+	targets := make([]teamTarget, 0, len(order))
+	for _, slug := range order {
+		targets = append(targets, *bySlug[slug])
+	}
+	return targets
+}
+
+// sweepState memoizes lookups for the duration of one sweep: the linked
+// GitHub accounts (fetched once), each Sentinel group's resolved logins, and
+// each GitHub ID's current login. A group that feeds several teams would
+// otherwise re-resolve every member once per target.
+type sweepState struct {
+	accounts map[string]coreExternalAuth
+	groups   map[string]map[string]struct{}
+	logins   map[string]string
+}
+
+// desiredLogins returns the lowercased GitHub logins of every member of
+// groupID. Members without a linked GitHub account — service accounts and
+// people who haven't connected one — are skipped, as are accounts GitHub
+// says no longer exist. Any other lookup failure fails the group: leaving
+// the member out would have reconcileTarget remove them.
+func (s *sweepState) desiredLogins(ctx context.Context, groupID string) (map[string]struct{}, error) {
+	if logins, ok := s.groups[groupID]; ok {
+		return logins, nil
+	}
+	members, err := getGroupMembers(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch sentinel members for group %s: %w", groupID, err)
+	}
+	logins := make(map[string]struct{}, len(members))
+	for _, m := range members {
+		account, ok := s.accounts[m.EntityID]
+		if !ok {
+			continue
+		}
+		login, err := s.resolveLogin(ctx, account)
+		if err != nil {
+			if !isStatus(err, http.StatusNotFound) {
+				return nil, fmt.Errorf("resolve github login for entity %s: %w", m.EntityID, err)
+			}
+			logger.SugarLogger.Warnf("github sync: entity %s is linked to a deleted github account: %v", m.EntityID, err)
+			continue
+		}
+		logins[strings.ToLower(login)] = struct{}{}
+	}
+	s.groups[groupID] = logins
+	return logins, nil
+}
+
+// resolveLogin looks up the account's current login by its numeric ID, since
+// the login stored in external auth metadata is only refreshed on sign-in.
+func (s *sweepState) resolveLogin(ctx context.Context, account coreExternalAuth) (string, error) {
+	if login, ok := s.logins[account.ExternalID]; ok {
+		return login, nil
+	}
+	login, err := getUserLogin(ctx, account.ExternalID)
+	if err != nil {
+		return "", err
+	}
+	s.logins[account.ExternalID] = login
+	return login, nil
+}
+
+// reconcileTarget brings one team's membership into agreement with the
+// bindings that feed it. Desired state is the union of the GitHub logins of
+// every bound group's members. Only role=member memberships and pending
+// invitations are the sync's to manage: maintainers are assumed to be added
+// by hand and are never removed. Adds are skipped when the login is already
+// on the team in any role or has a pending invitation.
+//
+// A failure resolving any feeding Sentinel group, or any of its members'
+// GitHub logins, aborts the whole target — computing removals from a
+// partial desired set would strip members who are only missing because of
+// the failed lookup.
+func reconcileTarget(ctx context.Context, t teamTarget, s *sweepState) error {
+	desired := make(map[string]struct{})
+	for _, b := range t.Bindings {
+		logins, err := s.desiredLogins(ctx, b.GroupID)
+		if err != nil {
+			return err
+		}
+		for login := range logins {
+			desired[login] = struct{}{}
+		}
+	}
+
+	members, err := listTeamMembers(ctx, t.Slug, "member")
+	if err != nil {
+		return err
+	}
+	maintainers, err := listTeamMembers(ctx, t.Slug, "maintainer")
+	if err != nil {
+		return err
+	}
+	invited, err := listTeamInvitations(ctx, t.Slug)
+	if err != nil {
+		return err
+	}
+	// present = on the team in any role or invited (skip adds for these);
+	// managed = members and invitations only (the only ones the sync may
+	// remove).
+	present := make(map[string]struct{})
+	managed := make(map[string]struct{})
+	for _, m := range maintainers {
+		present[strings.ToLower(m.Login)] = struct{}{}
+	}
+	for _, m := range members {
+		present[strings.ToLower(m.Login)] = struct{}{}
+		managed[strings.ToLower(m.Login)] = struct{}{}
+	}
+	for _, login := range invited {
+		present[strings.ToLower(login)] = struct{}{}
+		managed[strings.ToLower(login)] = struct{}{}
+	}
+
+	for login := range desired {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, ok := present[login]; ok {
+			continue
+		}
+		if err := addTeamMember(ctx, t.Slug, login); err != nil {
+			logger.SugarLogger.Errorf("github sync: %v", err)
+			continue
+		}
+		logger.SugarLogger.Infof("github sync: added %s to %s", login, t.Slug)
+	}
+
+	var toRemove []string
+	for login := range managed {
+		if _, ok := desired[login]; ok {
+			continue
+		}
+		toRemove = append(toRemove, login)
+	}
+	if len(toRemove) > config.GitHubSyncMaxRemovals {
+		logger.SugarLogger.Errorf("github sync: refusing to remove %d members from %s (exceeds GitHubSyncMaxRemovals=%d); skipping removals for this team", len(toRemove), t.Slug, config.GitHubSyncMaxRemovals)
+		return nil
+	}
+	for _, login := range toRemove {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := removeTeamMember(ctx, t.Slug, login); err != nil {
+			logger.SugarLogger.Errorf("github sync: %v", err)
+			continue
+		}
+		logger.SugarLogger.Infof("github sync: removed %s from %s", login, t.Slug)
+	}
+	return nil
+}
+
+// ReconcileAll reconciles every bound team. A failure on one target is logged
+// and does not abort the others.
+func ReconcileAll(ctx context.Context) error {
+	bindings, err := GetAllGitHubBindings()
+	if err != nil {
+		return fmt.Errorf("load bindings: %w", err)
+	}
+	if len(bindings) == 0 {
+		return nil
+	}
+	accounts, err := getLinkedGitHubAccounts()
+	if err != nil {
+		return fmt.Errorf("fetch linked github accounts: %w", err)
+	}
+	s := &sweepState{
+		accounts: accounts,
+		groups:   make(map[string]map[string]struct{}),
+		logins:   make(map[string]string),
+	}
+	for _, t := range groupTargets(bindings) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := reconcileTarget(ctx, t, s); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			logger.SugarLogger.Errorf("github sync: reconcile failed for team=%s (%d bindings): %v", t.Slug, len(t.Bindings), err)
+		}
+	}
+	return nil
+}
+
+// sweepJob serializes sweeps with cancel-and-restart: a trigger that arrives
+// while one is in flight cancels it and runs a fresh sweep with the latest
+// state. Safe because every sweep re-reads live state and reconcile is
+// idempotent.
+var sweepJob syncJob
+
+func runSweep() {
+	if githubClient == nil {
+		logger.SugarLogger.Debugln("github sync: skipping sweep, sync disabled")
+		return
+	}
+	sweepJob.Start(func(ctx context.Context) {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+		defer cancel()
+
+		logger.SugarLogger.Infoln("github sync: starting reconcile sweep")
+		if err := ReconcileAll(ctx); err != nil {
+			if errors.Is(err, context.Canceled) {
+				logger.SugarLogger.Debugln("github sync: sweep cancelled by newer trigger")
+				return
+			}
+			logger.SugarLogger.Errorf("github sync: sweep failed: %v", err)
+			return
+		}
+		logger.SugarLogger.Infoln("github sync: reconcile sweep complete")
+	})
+}
+
+// TriggerReconcile kicks a sweep and returns immediately. A sweep already in
+// flight is cancelled in favor of this one.
+func TriggerReconcile() {
+	runSweep()
+}
+
+// StartReconcileCron runs a periodic sweep on config.GitHubSyncInterval. The
+// cron is off only when sync isn't configured.
+func StartReconcileCron() {
+	if githubClient == nil {
+		logger.SugarLogger.Infoln("github sync: cron disabled (sync not configured)")
+		return
+	}
+	logger.SugarLogger.Infof("github sync: cron enabled, interval=%v", config.GitHubSyncInterval)
+	go func() {
+		ticker := time.NewTicker(config.GitHubSyncInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			runSweep()
+		}
+	}()
+}
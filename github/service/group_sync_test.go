@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gaucho-racing/sentinel/github/config"
+	"github.com/gaucho-racing/sentinel/github/model"
+	"github.com/gaucho-racing/sentinel/github/pkg/kerbecs"
+	"github.com/gaucho-racing/sentinel/github/pkg/logger"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+	logger.SugarLogger = logger.Logger.Sugar()
+
+	core := httptest.NewServer(fakeCore)
+	fakeCore.url = core.URL
+	kerbecs.Init(core.URL, "", "")
+	code := m.Run()
+	core.Close()
+	os.Exit(code)
+}
+
+// fakeCore stands in for both the kerbecs gateway and core, serving group
+// members for the groups tests register with serveGroupMembers.
+var fakeCore = &fakeCoreServer{groups: map[string][]coreGroupMember{}}
+
+type fakeCoreServer struct {
+	url    string
+	mu     sync.Mutex
+	groups map[string][]coreGroupMember
+}
+
+func (f *fakeCoreServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/admin-gw/resolve" {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"matched":        true,
+			"url":            f.url,
+			"rewritten_path": r.URL.Query().Get("path"),
+		})
+		return
+	}
+	groupID, ok := strings.CutPrefix(r.URL.Path, "/api/groups/")
+	groupID, ok2 := strings.CutSuffix(groupID, "/members")
+	f.mu.Lock()
+	members, known := f.groups[groupID]
+	f.mu.Unlock()
+	if !ok || !ok2 || !known {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "not found"})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": members})
+}
+
+// serveGroupMembers has the fake core list members for groupID for the
+// rest of the test.
+func serveGroupMembers(t *testing.T, groupID string, members ...coreGroupMember) {
+	t.Helper()
+	fakeCore.mu.Lock()
+	fakeCore.groups[groupID] = members
+	fakeCore.mu.Unlock()
+	t.Cleanup(func() {
+		fakeCore.mu.Lock()
+		delete(fakeCore.groups, groupID)
+		fakeCore.mu.Unlock()
+	})
+}
+
+// fakeTeam is one team on fakeGitHub, with the memberships and invitations
+// it starts with and the changes the sync made to it.
+type fakeTeam struct {
+	members     []string
+	maintainers []string
+	invitations []string
+	added       []string
+	removed     []string
+}
+
+// fakeGitHub serves the team endpoints reconcileTarget uses for org "gr",
+// and user lookups by ID. A user ID missing from users is a deleted
+// account; userStatus, when set, fails every user lookup with it.
+type fakeGitHub struct {
+	mu         sync.Mutex
+	teams      map[string]*fakeTeam
+	users      map[string]string
+	userStatus int
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if id, ok := strings.CutPrefix(r.URL.Path, "/user/"); ok && r.Method == http.MethodGet {
+		login, known := f.users[id]
+		switch {
+		case f.userStatus != 0:
+			w.WriteHeader(f.userStatus)
+			_ = json.NewEncoder(w).Encode(map[string]string{"message": http.StatusText(f.userStatus)})
+		case !known:
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"message": "Not Found"})
+		default:
+			_ = json.NewEncoder(w).Encode(teamMember{Login: login})
+		}
+		return
+	}
+
+	rest, ok := strings.CutPrefix(r.URL.Path, "/orgs/gr/teams/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	slug, sub, _ := strings.Cut(rest, "/")
+	team, ok := f.teams[slug]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"message": "Not Found"})
+		return
+	}
+	page := r.URL.Query().Get("page")
+
+	switch {
+	case r.Method == http.MethodGet && sub == "members":
+		logins := team.members
+		if r.URL.Query().Get("role") == "maintainer" {
+			logins = team.maintainers
+		}
+		out := []teamMember{}
+		if page == "1" {
+			for i, login := range logins {
+				out = append(out, teamMember{ID: int64(i + 1), Login: login})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	case r.Method == http.MethodGet && sub == "invitations":
+		out := []map[string]string{}
+		if page == "1" {
+			for _, login := range team.invitations {
+				out = append(out, map[string]string{"login": login})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	case r.Method == http.MethodPut && strings.HasPrefix(sub, "memberships/"):
+		team.added = append(team.added, strings.TrimPrefix(sub, "memberships/"))
+		_ = json.NewEncoder(w).Encode(map[string]string{"state": "pending"})
+	case r.Method == http.MethodDelete && strings.HasPrefix(sub, "memberships/"):
+		team.removed = append(team.removed, strings.TrimPrefix(sub, "memberships/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// startFakeGitHub points githubClient at a fake API serving teams for the
+// length of the test.
+func startFakeGitHub(t *testing.T, teams map[string]*fakeTeam) {
+	t.Helper()
+	startFakeGitHubServer(t, &fakeGitHub{teams: teams})
+}
+
+func startFakeGitHubServer(t *testing.T, fake *fakeGitHub) {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	prev := githubClient
+	githubClient = &gitHubClient{baseURL: srv.URL, org: "gr", token: "test", http: srv.Client()}
+	t.Cleanup(func() { githubClient = prev })
+}
+
+// newTestSweep returns a sweep whose groups are already resolved to the
+// given logins, so reconcileTarget never has to ask core for members.
+func newTestSweep(groups map[string][]string) *sweepState {
+	s := &sweepState{
+		accounts: map[string]coreExternalAuth{},
+		groups:   map[string]map[string]struct{}{},
+		logins:   map[string]string{},
+	}
+	for groupID, logins := range groups {
+		set := make(map[string]struct{}, len(logins))
+		for _, login := range logins {
+			set[strings.ToLower(login)] = struct{}{}
+		}
+		s.groups[groupID] = set
+	}
+	return s
+}
+
+func target(slug string, groupIDs ...string) teamTarget {
+	t := teamTarget{Slug: slug}
+	for _, id := range groupIDs {
+		t.Bindings = append(t.Bindings, model.GroupGitHubBinding{GroupID: id, TeamSlug: slug})
+	}
+	return t
+}
+
+func sorted(s []string) []string {
+	out := append([]string(nil), s...)
+	sort.Strings(out)
+	return out
+}
+
+func assertLogins(t *testing.T, what string, got, want []string) {
+	t.Helper()
+	got, want = sorted(got), sorted(want)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("%s = %v, want %v", what, got, want)
+	}
+}
+
+func TestReconcileTargetAddsAndRemoves(t *testing.T) {
+	team := &fakeTeam{
+		members:     []string{"Alice", "bob"},
+		maintainers: []string{"lead"},
+		invitations: []string{"carol", "stale-invite"},
+	}
+	startFakeGitHub(t, map[string]*fakeTeam{"eng": team})
+
+	sweep := newTestSweep(map[string][]string{
+		"grp_a": {"alice", "carol"},
+		"grp_b": {"dave", "Lead"},
+	})
+	if err := reconcileTarget(context.Background(), target("eng", "grp_a", "grp_b"), sweep); err != nil {
+		t.Fatalf("reconcileTarget: %v", err)
+	}
+
+	// alice is a member and carol is invited, so neither is re-added, and
+	// lead is a maintainer. Only dave is missing.
+	assertLogins(t, "added", team.added, []string{"dave"})
+	// Maintainers are never removed, even when no binding wants them.
+	assertLogins(t, "removed", team.removed, []string{"bob", "stale-invite"})
+}
+
+func TestReconcileTargetUnionsBindings(t *testing.T) {
+	team := &fakeTeam{members: []string{"alice", "bob"}}
+	startFakeGitHub(t, map[string]*fakeTeam{"eng": team})
+
+	// Each group alone would remove the other's member.
+	sweep := newTestSweep(map[string][]string{
+		"grp_a": {"alice"},
+		"grp_b": {"bob"},
+	})
+	if err := reconcileTarget(context.Background(), target("eng", "grp_a", "grp_b"), sweep); err != nil {
+		t.Fatalf("reconcileTarget: %v", err)
+	}
+	assertLogins(t, "added", team.added, nil)
+	assertLogins(t, "removed", team.removed, nil)
+}
+
+func TestReconcileTargetMassRemovalGuardrail(t *testing.T) {
+	var members []string
+	for i := 0; i <= config.GitHubSyncMaxRemovals; i++ {
+		members = append(members, fmt.Sprintf("user%d", i))
+	}
+	team := &fakeTeam{members: members}
+	startFakeGitHub(t, map[string]*fakeTeam{"eng": team})
+
+	// An empty group, as core might return mid-outage, would remove one
+	// more member than the cap allows. Adds still go through.
+	sweep := newTestSweep(map[string][]string{"grp_a": {"newcomer"}})
+	if err := reconcileTarget(context.Background(), target("eng", "grp_a"), sweep); err != nil {
+		t.Fatalf("reconcileTarget: %v", err)
+	}
+	assertLogins(t, "added", team.added, []string{"newcomer"})
+	if len(team.removed) != 0 {
+		t.Errorf("removed %d members past the guardrail: %v", len(team.removed), team.removed)
+	}
+}
+
+func TestReconcileTargetRemovesUpToGuardrail(t *testing.T) {
+	var members []string
+	for i := 0; i < config.GitHubSyncMaxRemovals; i++ {
+		members = append(members, fmt.Sprintf("user%d", i))
+	}
+	team := &fakeTeam{members: members}
+	startFakeGitHub(t, map[string]*fakeTeam{"eng": team})
+
+	sweep := newTestSweep(map[string][]string{"grp_a": {}})
+	if err := reconcileTarget(context.Background(), target("eng", "grp_a"), sweep); err != nil {
+		t.Fatalf("reconcileTarget: %v", err)
+	}
+	assertLogins(t, "removed", team.removed, members)
+}
+
+func TestReconcileTargetListFailureAborts(t *testing.T) {
+	startFakeGitHub(t, map[string]*fakeTeam{})
+
+	sweep := newTestSweep(map[string][]string{"grp_a": {"alice"}})
+	err := reconcileTarget(context.Background(), target("missing", "grp_a"), sweep)
+	if err == nil || !isStatus(err, http.StatusNotFound) {
+		t.Fatalf("reconcileTarget error = %v, want a 404 from listing members", err)
+	}
+}
+
+// newLinkedSweep returns an empty sweep where each entity is linked to the
+// GitHub account with the same ID, so reconcileTarget resolves groups
+// through the fake core and logins through the fake GitHub.
+func newLinkedSweep(entityIDs ...string) *sweepState {
+	s := newTestSweep(nil)
+	for _, id := range entityIDs {
+		s.accounts[id] = coreExternalAuth{EntityID: id, ExternalID: id}
+	}
+	return s
+}
+
+func TestReconcileTargetLoginLookupFailureAborts(t *testing.T) {
+	team := &fakeTeam{members: []string{"alice", "bob"}}
+	startFakeGitHubServer(t, &fakeGitHub{
+		teams:      map[string]*fakeTeam{"eng": team},
+		users:      map[string]string{"1": "alice", "2": "bob"},
+		userStatus: http.StatusInternalServerError,
+	})
+	serveGroupMembers(t, "grp_a", coreGroupMember{EntityID: "1"}, coreGroupMember{EntityID: "2"})
+
+	err := reconcileTarget(context.Background(), target("eng", "grp_a"), newLinkedSweep("1", "2"))
+	if err == nil || !isStatus(err, http.StatusInternalServerError) {
+		t.Fatalf("reconcileTarget error = %v, want the 500 from looking up a login", err)
+	}
+	assertLogins(t, "added", team.added, nil)
+	assertLogins(t, "removed", team.removed, nil)
+}
+
+func TestReconcileTargetDropsDeletedAccounts(t *testing.T) {
+	team := &fakeTeam{members: []string{"alice", "ghost"}}
+	startFakeGitHubServer(t, &fakeGitHub{
+		teams: map[string]*fakeTeam{"eng": team},
+		users: map[string]string{"1": "alice", "3": "carol"},
+	})
+	// Entity 2's GitHub account is gone, so it no longer holds a seat.
+	serveGroupMembers(t, "grp_a", coreGroupMember{EntityID: "1"}, coreGroupMember{EntityID: "2"}, coreGroupMember{EntityID: "3"})
+
+	if err := reconcileTarget(context.Background(), target("eng", "grp_a"), newLinkedSweep("1", "2", "3")); err != nil {
+		t.Fatalf("reconcileTarget: %v", err)
+	}
+	assertLogins(t, "added", team.added, []string{"carol"})
+	assertLogins(t, "removed", team.removed, []string{"ghost"})
+}
@@ -17,16 +17,12 @@ func ListGoogleBindings(c *gin.Context) {
 	Require(c, RequestTokenHasScope(c, "sentinel:all"))
 
 	if groupID := c.Query("group_id"); groupID != "" {
-		binding, err := service.GetGoogleBindingForGroup(groupID)
-		if errors.Is(err, service.ErrBindingNotFound) {
-			c.JSON(http.StatusOK, []model.GroupGoogleBinding{})
-			return
-		}
+		bindings, err := service.GetGoogleBindingsForGroup(groupID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, []model.GroupGoogleBinding{binding})
+		c.JSON(http.StatusOK, bindings)
 		return
 	}
 
@@ -39,8 +35,10 @@ func ListGoogleBindings(c *gin.Context) {
 }
 
 type createGoogleBindingRequest struct {
-	GroupID          string `json:"group_id" binding:"required"`
-	GoogleGroupEmail string `json:"google_group_email" binding:"required"`
+	GroupID          string                  `json:"group_id" binding:"required"`
+	GoogleGroupEmail string                  `json:"google_group_email" binding:"required"`
+	Mode             model.GoogleBindingMode `json:"mode"`
+	MemberGroupEmail string                  `json:"member_group_email"`
 }
 
 func CreateGoogleBinding(c *gin.Context) {
@@ -57,12 +55,38 @@ func CreateGoogleBinding(c *gin.Context) {
 		return
 	}
 
+	mode := model.GoogleBindingMode(strings.ToUpper(string(req.Mode)))
+	memberEmail := strings.TrimSpace(req.MemberGroupEmail)
+	switch mode {
+	case "", model.GoogleBindingModeMembers:
+		mode = model.GoogleBindingModeMembers
+	case model.GoogleBindingModeNested:
+		if _, err := mail.ParseAddress(memberEmail); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "member_group_email must be a valid email address for NESTED bindings"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be MEMBERS or NESTED"})
+		return
+	}
+
 	binding, err := service.CreateGoogleBinding(model.GroupGoogleBinding{
 		GroupID:          req.GroupID,
 		GoogleGroupEmail: email,
+		Mode:             mode,
+		MemberGroupEmail: memberEmail,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, service.ErrBindingExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrNestedMemberUnbound),
+			errors.Is(err, service.ErrNestedSelfRef),
+			errors.Is(err, service.ErrNestedCycle):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 	c.JSON(http.StatusOK, binding)
@@ -81,6 +105,10 @@ func DeleteGoogleBinding(c *gin.Context) {
 		return
 	}
 	if err := service.DeleteGoogleBinding(groupID, bindingID); err != nil {
+		if errors.Is(err, service.ErrBindingInUse) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -15,6 +15,14 @@ var DB *gorm.DB
 
 var dbRetries = 0
 
+// legacyBindingIndexes are the per-column unique indexes from when bindings
+// were 1:1. AutoMigrate only ever adds indexes, so they have to be dropped
+// explicitly or they'd keep rejecting a second binding for the same group.
+var legacyBindingIndexes = []string{
+	"idx_group_google_binding_group_id",
+	"idx_group_google_binding_google_group_email",
+}
+
 func Init() {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC", config.DatabaseHost, config.DatabaseUser, config.DatabasePassword, config.DatabaseName, config.DatabasePort)
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
@@ -29,6 +37,13 @@ func Init() {
 		}
 	} else {
 		logger.SugarLogger.Infoln("Connected to database")
+		for _, idx := range legacyBindingIndexes {
+			if db.Migrator().HasIndex(&model.GroupGoogleBinding{}, idx) {
+				if err := db.Migrator().DropIndex(&model.GroupGoogleBinding{}, idx); err != nil {
+					logger.SugarLogger.Errorf("Failed to drop legacy index %s: %v", idx, err)
+				}
+			}
+		}
 		db.AutoMigrate(
 			&model.GroupGoogleBinding{},
 		)
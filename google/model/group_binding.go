@@ -2,21 +2,47 @@ package model
 
 import "time"
 
-// GroupGoogleBinding maps a Sentinel group to the Google Group its membership
-// is mirrored into. The relationship is 1:1 — one Sentinel group projects onto
-// one Google Group, and a given Google Group is driven by a single Sentinel
-// group — so both columns are unique.
+type GoogleBindingMode string
+
+const (
+	// GoogleBindingModeMembers flattens the Sentinel group into the Google
+	// Group: every member's email is inserted individually.
+	GoogleBindingModeMembers GoogleBindingMode = "MEMBERS"
+	// GoogleBindingModeNested projects the Sentinel group as a single nested
+	// member of the Google Group. The nested member is one of the Google
+	// Groups the Sentinel group is already flattened into (MemberGroupEmail),
+	// so Google resolves the transitive membership itself.
+	GoogleBindingModeNested GoogleBindingMode = "NESTED"
+)
+
+// GroupGoogleBinding maps a Sentinel group to a Google Group its membership is
+// mirrored into. A Sentinel group may drive any number of Google Groups (e.g.
+// "Electronics" feeding both electronics@ and a shared-drive access group),
+// and a Google Group may be fed by several Sentinel groups — reconcile takes
+// the union of every binding that targets the same Google Group. The pair
+// (GroupID, GoogleGroupEmail) is unique.
 //
 // Owned by the google service: bindings are an integration-side concept that
 // reference Sentinel group IDs from core but live in google's domain. Sync is
 // one-way (Sentinel -> Google); this row only records where to project.
 type GroupGoogleBinding struct {
-	ID               string    `json:"id" gorm:"primaryKey"`
-	GroupID          string    `json:"group_id" gorm:"uniqueIndex"`
-	GoogleGroupEmail string    `json:"google_group_email" gorm:"uniqueIndex"`
+	ID               string            `json:"id" gorm:"primaryKey"`
+	GroupID          string            `json:"group_id" gorm:"uniqueIndex:idx_group_google_binding_pair"`
+	GoogleGroupEmail string            `json:"google_group_email" gorm:"uniqueIndex:idx_group_google_binding_pair;index"`
+	Mode             GoogleBindingMode `json:"mode" gorm:"default:MEMBERS"`
+	// MemberGroupEmail is the Google Group inserted as a nested member when
+	// Mode is NESTED. It must be a Google Group the same Sentinel group has a
+	// MEMBERS binding to. Empty for MEMBERS bindings.
+	MemberGroupEmail string    `json:"member_group_email"`
 	CreatedAt        time.Time `json:"created_at" gorm:"autoCreateTime"`
 }
 
 func (GroupGoogleBinding) TableName() string {
 	return "group_google_binding"
 }
+
+// IsNested reports whether the binding projects its group as a nested Google
+// Group member rather than flattening individual emails.
+func (b GroupGoogleBinding) IsNested() bool {
+	return b.Mode == GoogleBindingModeNested
+}
@@ -64,8 +64,10 @@ func listGroupMembers(ctx context.Context, groupEmail string) ([]memberEntry, er
 	return members, nil
 }
 
-// insertMember adds email to the Google Group as a plain MEMBER. A 409
-// (already a member) is treated as success — reconcile is idempotent.
+// insertMember adds email to the Google Group as a plain MEMBER. email may be a
+// user or another Google Group (NESTED bindings); the Directory API infers the
+// member type from the address. A 409 (already a member) is treated as
+// success — reconcile is idempotent.
 func insertMember(ctx context.Context, groupEmail, email string) error {
 	_, err := directorySvc.Members.Insert(groupEmail, &directory.Member{Email: email, Role: "MEMBER"}).Context(ctx).Do()
 	if err != nil {
@@ -2,14 +2,34 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/gaucho-racing/sentinel/google/database"
 	"github.com/gaucho-racing/sentinel/google/model"
 	"github.com/gaucho-racing/ulid-go"
-	"gorm.io/gorm"
 )
 
-var ErrBindingNotFound = errors.New("group google binding not found")
+// ErrBindingExists is returned when the (group, Google Group) pair is already
+// bound. The API layer maps it to 409.
+var ErrBindingExists = errors.New("group is already bound to that google group")
+
+// ErrNestedMemberUnbound is returned when a NESTED binding names a
+// member_group_email the Sentinel group isn't flattened into — there'd be no
+// Google Group carrying its membership to nest.
+var ErrNestedMemberUnbound = errors.New("member_group_email must be a google group this group has a MEMBERS binding to")
+
+// ErrNestedSelfRef is returned when a NESTED binding would make a Google Group
+// a member of itself.
+var ErrNestedSelfRef = errors.New("a google group cannot be nested into itself")
+
+// ErrNestedCycle is returned when a NESTED binding would close a cycle of
+// Google Groups nested into each other.
+var ErrNestedCycle = errors.New("nested binding would create a cycle of google groups")
+
+// ErrBindingInUse is returned when deleting a MEMBERS binding whose Google
+// Group is still nested into another Google Group by a NESTED binding.
+var ErrBindingInUse = errors.New("google group is nested by another binding; delete that binding first")
 
 func GetAllGoogleBindings() ([]model.GroupGoogleBinding, error) {
 	bindings := []model.GroupGoogleBinding{}
@@ -19,21 +39,37 @@ func GetAllGoogleBindings() ([]model.GroupGoogleBinding, error) {
 	return bindings, nil
 }
 
-// GetGoogleBindingForGroup returns the binding for a group, or
-// ErrBindingNotFound if the group has none. The 1:1 model means at most one
-// row per group.
-func GetGoogleBindingForGroup(groupID string) (model.GroupGoogleBinding, error) {
-	var binding model.GroupGoogleBinding
-	if err := database.DB.Where("group_id = ?", groupID).First(&binding).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return model.GroupGoogleBinding{}, ErrBindingNotFound
-		}
-		return model.GroupGoogleBinding{}, err
+// GetGoogleBindingsForGroup returns every binding for a group (MEMBERS and
+// NESTED). Empty slice when the group has none.
+func GetGoogleBindingsForGroup(groupID string) ([]model.GroupGoogleBinding, error) {
+	bindings := []model.GroupGoogleBinding{}
+	if err := database.DB.Where("group_id = ?", groupID).Find(&bindings).Error; err != nil {
+		return []model.GroupGoogleBinding{}, err
 	}
-	return binding, nil
+	return bindings, nil
 }
 
+// CreateGoogleBinding validates the binding against the existing set and
+// inserts it. Emails are compared case-insensitively — Google treats group
+// addresses that way — and stored lowercased so reconcile can group by them.
 func CreateGoogleBinding(binding model.GroupGoogleBinding) (model.GroupGoogleBinding, error) {
+	binding.GoogleGroupEmail = strings.ToLower(binding.GoogleGroupEmail)
+	binding.MemberGroupEmail = strings.ToLower(binding.MemberGroupEmail)
+	if binding.Mode == "" {
+		binding.Mode = model.GoogleBindingModeMembers
+	}
+	if !binding.IsNested() {
+		binding.MemberGroupEmail = ""
+	}
+
+	existing, err := GetAllGoogleBindings()
+	if err != nil {
+		return model.GroupGoogleBinding{}, fmt.Errorf("load existing bindings: %w", err)
+	}
+	if err := validateGoogleBinding(binding, existing); err != nil {
+		return model.GroupGoogleBinding{}, err
+	}
+
 	if binding.ID == "" {
 		binding.ID = ulid.Make().Prefixed("ggb")
 	}
@@ -43,9 +79,93 @@ func CreateGoogleBinding(binding model.GroupGoogleBinding) (model.GroupGoogleBin
 	return binding, nil
 }
 
+func validateGoogleBinding(binding model.GroupGoogleBinding, existing []model.GroupGoogleBinding) error {
+	for _, b := range existing {
+		if b.GroupID == binding.GroupID && strings.EqualFold(b.GoogleGroupEmail, binding.GoogleGroupEmail) {
+			return ErrBindingExists
+		}
+	}
+	if !binding.IsNested() {
+		return nil
+	}
+
+	if binding.MemberGroupEmail == binding.GoogleGroupEmail {
+		return ErrNestedSelfRef
+	}
+	flattened := false
+	for _, b := range existing {
+		if b.GroupID == binding.GroupID && !b.IsNested() && strings.EqualFold(b.GoogleGroupEmail, binding.MemberGroupEmail) {
+			flattened = true
+			break
+		}
+	}
+	if !flattened {
+		return ErrNestedMemberUnbound
+	}
+	if wouldCreateNestingCycle(binding, existing) {
+		return ErrNestedCycle
+	}
+	return nil
+}
+
+// wouldCreateNestingCycle reports whether nesting newBinding.MemberGroupEmail
+// into newBinding.GoogleGroupEmail would close a loop. Edges run child ->
+// parent (the nested Google Group is a member of the target); a cycle exists
+// if the target can already reach the new child.
+func wouldCreateNestingCycle(newBinding model.GroupGoogleBinding, existing []model.GroupGoogleBinding) bool {
+	parents := make(map[string][]string)
+	for _, b := range existing {
+		if !b.IsNested() {
+			continue
+		}
+		child := strings.ToLower(b.MemberGroupEmail)
+		parents[child] = append(parents[child], strings.ToLower(b.GoogleGroupEmail))
+	}
+
+	target := newBinding.MemberGroupEmail
+	visited := make(map[string]bool)
+	var dfs func(node string) bool
+	dfs = func(node string) bool {
+		if node == target {
+			return true
+		}
+		if visited[node] {
+			return false
+		}
+		visited[node] = true
+		for _, next := range parents[node] {
+			if dfs(next) {
+				return true
+			}
+		}
+		return false
+	}
+	return dfs(newBinding.GoogleGroupEmail)
+}
+
 // DeleteGoogleBinding scopes the delete to (groupID, bindingID) so a tampered
-// request can't drop a binding for a different group.
+// request can't drop a binding for a different group. A MEMBERS binding whose
+// Google Group is still nested elsewhere by the same Sentinel group is
+// refused with ErrBindingInUse.
 func DeleteGoogleBinding(groupID, bindingID string) error {
+	var binding model.GroupGoogleBinding
+	if err := database.DB.Where("group_id = ? AND id = ?", groupID, bindingID).Find(&binding).Error; err != nil {
+		return err
+	}
+	if binding.ID == "" {
+		return nil
+	}
+	if !binding.IsNested() {
+		var dependents int64
+		if err := database.DB.Model(&model.GroupGoogleBinding{}).
+			Where("group_id = ? AND mode = ? AND LOWER(member_group_email) = LOWER(?)", groupID, model.GoogleBindingModeNested, binding.GoogleGroupEmail).
+			Count(&dependents).Error; err != nil {
+			return err
+		}
+		if dependents > 0 {
+			return ErrBindingInUse
+		}
+	}
 	if err := database.DB.Where("group_id = ? AND id = ?", groupID, bindingID).Delete(&model.GroupGoogleBinding{}).Error; err != nil {
 		return err
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -30,11 +31,7 @@ type coreEntity struct {
 }
 
 func getGroupMembers(groupID string) ([]coreGroupMember, error) {
-	var rows []coreGroupMember
-	if err := sentinel.Get("/api/groups/"+groupID+"/members", &rows); err != nil {
-		return nil, err
-	}
-	return rows, nil
+	return sentinel.GetAll[coreGroupMember]("/api/groups/" + groupID + "/members")
 }
 
 // resolveEntityEmail returns the entity's login email (email_auth, falling back
@@ -54,17 +51,55 @@ func resolveEntityEmail(entityID string) (string, error) {
 	return "", nil
 }
 
-// reconcileBinding brings one Google Group's MEMBER-role membership into
-// agreement with its Sentinel group. The Google Group's role=MEMBER set is the
-// sync's authoritative state: anything manually added is OWNER/MANAGER and is
-// never touched. Adds are skipped when the user is already present in any role.
-func reconcileBinding(ctx context.Context, b model.GroupGoogleBinding) error {
-	members, err := getGroupMembers(b.GroupID)
-	if err != nil {
-		return fmt.Errorf("fetch sentinel members for group %s: %w", b.GroupID, err)
+// googleTarget is one Google Group and every binding that feeds it. Several
+// Sentinel groups may project onto the same Google Group, so reconcile works
+// per target on the union of its bindings — reconciling binding-by-binding
+// would have each one delete the others' members.
+type googleTarget struct {
+	Email    string
+	Bindings []model.GroupGoogleBinding
+}
+
+// groupTargets buckets bindings by (lowercased) Google Group email, in a
+// stable order so sweep logs read the same run to run.
+func groupTargets(bindings []model.GroupGoogleBinding) []googleTarget {
+	byEmail := make(map[string]*googleTarget)
+	var order []string
+	for _, b := range bindings {
+		email := strings.ToLower(b.GoogleGroupEmail)
+		t, ok := byEmail[email]
+		if !ok {
+			t = &googleTarget{Email: email}
+			byEmail[email] = t
+			order = append(order, email)
+		}
+		t.Bindings = append(t.Bindings, b)
+	}
+	sort.Strings(order)
+	targets := make([]googleTarget, 0, len(order))
+	for _, email := range order {
+		targets = append(targets, *byEmail[email])
 	}
+	return targets
+}
 
-	desired := make(map[string]struct{}, len(members))
+// memberEmailCache memoizes a Sentinel group's resolved member emails for the
+// duration of one sweep. A group that feeds several Google Groups would
+// otherwise re-resolve every member once per target.
+type memberEmailCache map[string]map[string]struct{}
+
+// desiredEmails returns the lowercased emails of every member of groupID,
+// skipping entities with no email (service accounts) and ones that fail to
+// resolve.
+func (cache memberEmailCache) desiredEmails(groupID string) (map[string]struct{}, error) {
+	if emails, ok := cache[groupID]; ok {
+		return emails, nil
+	}
+	members, err := getGroupMembers(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch sentinel members for group %s: %w", groupID, err)
+	}
+	emails := make(map[string]struct{}, len(members))
 	for _, m := range members {
 		email, err := resolveEntityEmail(m.EntityID)
 		if err != nil {
@@ -74,10 +109,40 @@ func reconcileBinding(ctx context.Context, b model.GroupGoogleBinding) error {
 		if email == "" {
 			continue
 		}
-		desired[strings.ToLower(email)] = struct{}{}
+		emails[strings.ToLower(email)] = struct{}{}
+	}
+	cache[groupID] = emails
+	return emails, nil
+}
+
+// reconcileTarget brings one Google Group's MEMBER-role membership into
+// agreement with the bindings that feed it. Desired state is the union of the
+// flattened member emails of every MEMBERS binding plus the nested Google
+// Group of every NESTED binding. The Google Group's role=MEMBER set is the
+// sync's authoritative state: anything manually added is OWNER/MANAGER and is
+// never touched. Adds are skipped when the address is already present in any
+// role.
+//
+// A failure resolving any feeding Sentinel group aborts the whole target —
+// computing removals from a partial desired set would strip members who are
+// only missing because of the failed fetch.
+func reconcileTarget(ctx context.Context, t googleTarget, cache memberEmailCache) error {
+	desired := make(map[string]struct{})
+	for _, b := range t.Bindings {
+		if b.IsNested() {
+			desired[strings.ToLower(b.MemberGroupEmail)] = struct{}{}
+			continue
+		}
+		emails, err := cache.desiredEmails(b.GroupID)
+		if err != nil {
+			return err
+		}
+		for email := range emails {
+			desired[email] = struct{}{}
+		}
 	}
 
-	actual, err := listGroupMembers(ctx, b.GoogleGroupEmail)
+	actual, err := listGroupMembers(ctx, t.Email)
 	if err != nil {
 		return err
 	}
@@ -100,11 +165,11 @@ func reconcileBinding(ctx context.Context, b model.GroupGoogleBinding) error {
 		if _, ok := present[email]; ok {
 			continue
 		}
-		if err := insertMember(ctx, b.GoogleGroupEmail, email); err != nil {
+		if err := insertMember(ctx, t.Email, email); err != nil {
 			logger.SugarLogger.Errorf("google sync: %v", err)
 			continue
 		}
-		logger.SugarLogger.Infof("google sync: added %s to %s", email, b.GoogleGroupEmail)
+		logger.SugarLogger.Infof("google sync: added %s to %s", email, t.Email)
 	}
 
 	var toRemove []string
@@ -115,38 +180,39 @@ func reconcileBinding(ctx context.Context, b model.GroupGoogleBinding) error {
 		toRemove = append(toRemove, email)
 	}
 	if len(toRemove) > config.GoogleSyncMaxRemovals {
-		logger.SugarLogger.Errorf("google sync: refusing to remove %d members from %s (exceeds GOOGLE_SYNC_MAX_REMOVALS=%d); skipping removals for this group", len(toRemove), b.GoogleGroupEmail, config.GoogleSyncMaxRemovals)
+		logger.SugarLogger.Errorf("google sync: refusing to remove %d members from %s (exceeds GOOGLE_SYNC_MAX_REMOVALS=%d); skipping removals for this group", len(toRemove), t.Email, config.GoogleSyncMaxRemovals)
 		return nil
 	}
 	for _, email := range toRemove {
 		if err := ctx.Err(); err != nil {
 			return err
 		}
-		if err := deleteMember(ctx, b.GoogleGroupEmail, email); err != nil {
+		if err := deleteMember(ctx, t.Email, email); err != nil {
 			logger.SugarLogger.Errorf("google sync: %v", err)
 			continue
 		}
-		logger.SugarLogger.Infof("google sync: removed %s from %s", email, b.GoogleGroupEmail)
+		logger.SugarLogger.Infof("google sync: removed %s from %s", email, t.Email)
 	}
 	return nil
 }
 
-// ReconcileAll reconciles every binding. A failure on one binding is logged and
-// does not abort the others.
+// ReconcileAll reconciles every bound Google Group. A failure on one target is
+// logged and does not abort the others.
 func ReconcileAll(ctx context.Context) error {
 	bindings, err := GetAllGoogleBindings()
 	if err != nil {
 		return fmt.Errorf("load bindings: %w", err)
 	}
-	for _, b := range bindings {
+	cache := memberEmailCache{}
+	for _, t := range groupTargets(bindings) {
 		if err := ctx.Err(); err != nil {
 			return err
 		}
-		if err := reconcileBinding(ctx, b); err != nil {
+		if err := reconcileTarget(ctx, t, cache); err != nil {
 			if errors.Is(err, context.Canceled) {
 				return err
 			}
-			logger.SugarLogger.Errorf("google sync: reconcile failed for group=%s google=%s: %v", b.GroupID, b.GoogleGroupEmail, err)
+			logger.SugarLogger.Errorf("google sync: reconcile failed for google=%s (%d bindings): %v", t.Email, len(t.Bindings), err)
 		}
 	}
 	return nil
@@ -37,15 +37,38 @@ func InitializeRoutes(router *gin.Engine) {
 	router.GET("/oauth/ping", Ping)
 	router.GET("/oauth/authorize", ValidateAuthorize)
 	router.POST("/oauth/authorize", Authorize)
+	router.POST("/oauth/par", PushedAuthorization)
+	router.POST("/oauth/register", RegisterClient)
+	router.GET("/oauth/register/:clientID", GetClientConfiguration)
+	router.PUT("/oauth/register/:clientID", UpdateClientConfiguration)
+	router.DELETE("/oauth/register/:clientID", DeleteClientConfiguration)
 	router.POST("/oauth/token", ExchangeToken)
+	router.POST("/oauth/device_authorization", DeviceAuthorization)
+	router.GET("/oauth/device", ValidateDevice)
+	router.POST("/oauth/device", ResolveDevice)
 	router.GET("/oauth/userinfo", UserInfo)
 	router.POST("/oauth/userinfo", UserInfo)
 
 	router.GET("/.well-known/openid-configuration", OpenIDConfiguration)
 
 	router.POST("/auth/login/email-password", LoginEmailPassword)
+	router.POST("/auth/login/email-code/request", RequestEmailLoginCode)
+	router.POST("/auth/login/email-code", LoginEmailCode)
 	router.POST("/auth/login/discord", LoginDiscord)
+	router.POST("/auth/login/github", LoginGitHub)
+	router.POST("/auth/login/google", LoginGoogle)
+	router.POST("/auth/connect/:provider", ConnectExternalAccount)
+	router.POST("/auth/login/mfa", LoginMFA)
+	router.POST("/auth/login/mfa/enroll", EnrollMFAForLogin)
+	router.POST("/auth/login/mfa/passkey/begin", BeginPasskeyMFA)
+	router.POST("/auth/login/mfa/passkey/finish", FinishPasskeyMFA)
+	router.POST("/auth/login/passkey/begin", BeginPasskeyLogin)
+	router.POST("/auth/login/passkey/finish", FinishPasskeyLogin)
+	router.POST("/auth/passkeys/register/begin", BeginPasskeyRegistration)
+	router.POST("/auth/passkeys/register/finish", FinishPasskeyRegistration)
 	router.POST("/auth/refresh", RefreshSession)
+	router.POST("/auth/password-reset/request", RequestPasswordReset)
+	router.POST("/auth/password-reset/confirm", ConfirmPasswordReset)
 }
 
 // GetClientIP returns the originating client IP. Prefers Cloudflare's
@@ -18,7 +18,7 @@ import (
 // be evaluated (a core fetch failed) — we fail closed with 502 rather than let
 // the login through.
 func writeGateError(c *gin.Context, err error) {
-	if errors.Is(err, service.ErrAccessDenied) {
+	if errors.Is(err, service.ErrAccessDenied) || errors.Is(err, service.ErrAccountInactive) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "access_denied", "error_description": err.Error()})
 		return
 	}
@@ -26,6 +26,17 @@ func writeGateError(c *gin.Context, err error) {
 	c.JSON(http.StatusBadGateway, gin.H{"error": "server_error", "error_description": "could not verify access"})
 }
 
+// writeScopeError answers a ResolveClientScopes failure: 400 for a scope
+// the client can't have, 502 when the registry couldn't be read.
+func writeScopeError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidScope) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	logger.SugarLogger.Errorf("scope resolution failed: %v", err)
+	c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
+}
+
 type applicationResponse struct {
 	ID           string   `json:"id"`
 	Name         string   `json:"name"`
@@ -33,71 +44,180 @@ type applicationResponse struct {
 	ClientID     string   `json:"client_id"`
 	IconURL      string   `json:"icon_url"`
 	RedirectURIs []string `json:"redirect_uris"`
+	// RequirePushedAuthorizationRequests means the authorize endpoint only
+	// accepts this client's parameters by request_uri from /oauth/par.
+	RequirePushedAuthorizationRequests bool `json:"require_pushed_authorization_requests"`
+	// TokenExchangeAudiences are the client_ids the app's service accounts
+	// may exchange users' tokens for, besides its own.
+	TokenExchangeAudiences []string `json:"token_exchange_audiences"`
+}
+
+// redirectURIRegistered reports whether uri matches one of the app's
+// registered redirect URIs.
+func (app applicationResponse) redirectURIRegistered(uri string) bool {
+	for _, registered := range app.RedirectURIs {
+		if service.MatchRedirectURI(registered, uri) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadAuthorizeParams returns the parameters of an authorization request.
+// A request_uri from /oauth/par (RFC 9126) or a signed request object (RFC
+// 9101) replaces everything in the query except client_id, so parameters
+// sent that way can't be read from or tampered with in the URL. A client
+// that requires PAR can only use request_uri. Writes the error response and
+// returns false on failure.
+func loadAuthorizeParams(c *gin.Context, app applicationResponse) (url.Values, bool) {
+	requestURI := c.Query("request_uri")
+	requestObject := c.Query("request")
+	var params url.Values
+	var err error
+	switch {
+	case requestURI != "" && requestObject != "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "send request or request_uri, not both"})
+		return nil, false
+	case requestURI != "":
+		params, err = service.GetPushedAuthorizationRequest(requestURI, app.ClientID)
+	case app.RequirePushedAuthorizationRequests:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "this client must use pushed authorization requests"})
+		return nil, false
+	case requestObject != "":
+		params, err = service.VerifyRequestObject(app.ClientID, requestObject)
+	default:
+		return c.Request.URL.Query(), true
+	}
+	if err != nil {
+		writeRequestParamError(c, err)
+		return nil, false
+	}
+	return params, true
+}
+
+// writeRequestParamError answers a failure to unpack request or
+// request_uri: 400 with the OAuth error code when the client sent something
+// invalid, 502 when core couldn't be reached to check it.
+func writeRequestParamError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRequestObject):
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidRequestObject.Error(), "error_description": err.Error()})
+	case errors.Is(err, service.ErrInvalidRequestURI):
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidRequestURI.Error(), "error_description": err.Error()})
+	default:
+		logger.SugarLogger.Errorf("authorization request resolution failed: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
+	}
+}
+
+// loadAuthorizeApplication fetches the application named by the client_id
+// query parameter. Writes the error response and returns false on failure.
+func loadAuthorizeApplication(c *gin.Context) (applicationResponse, bool) {
+	clientID := c.Query("client_id")
+	if clientID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "client_id is required"})
+		return applicationResponse{}, false
+	}
+	var app applicationResponse
+	if err := sentinel.Get("/api/applications/client/"+clientID, &app); err != nil {
+		logger.SugarLogger.Errorf("Failed to get application for client_id %s: %v", clientID, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client_id"})
+		return applicationResponse{}, false
+	}
+	return app, true
 }
 
 type validateAuthorizeResponse struct {
 	ClientID    string `json:"client_id"`
 	RedirectURI string `json:"redirect_uri"`
 	Scope       string `json:"scope"`
-	Prompt      string `json:"prompt"`
-	AppName     string `json:"app_name"`
-	AppIconURL  string `json:"app_icon_url"`
+	// State and Nonce are echoed so the SPA can use them when the request
+	// came by request_uri or request object rather than in its URL.
+	State      string `json:"state,omitempty"`
+	Nonce      string `json:"nonce,omitempty"`
+	Prompt     string `json:"prompt"`
+	AppName    string `json:"app_name"`
+	AppIconURL string `json:"app_icon_url"`
+	// Scopes describes each requested scope for the consent screen,
+	// including ones defined by other applications.
+	Scopes []service.ScopeInfo `json:"scopes"`
+	consentPolicy
+}
+
+// consentPolicy is what the consent screen tells the user about the app's
+// token policy.
+type consentPolicy struct {
+	// SharedIdentity is the email every user appears to this app as, when
+	// its token policy has a shared identity.
+	SharedIdentity string `json:"shared_identity,omitempty"`
+	// OfflineAccess means the app gets a refresh token and can keep the
+	// user signed in for up to SessionLifetime seconds.
+	OfflineAccess   bool `json:"offline_access"`
+	SessionLifetime int  `json:"session_lifetime,omitempty"`
+}
+
+func newConsentPolicy(policy service.TokenPolicy) consentPolicy {
+	var consent consentPolicy
+	if policy.SharedIdentity() {
+		consent.SharedIdentity = policy.SharedEmail
+	}
+	if policy.AllowOfflineAccess {
+		consent.OfflineAccess = true
+		consent.SessionLifetime = policy.RefreshAbsoluteTTL()
+	}
+	return consent
 }
 
 // ValidateAuthorize validates the OAuth authorize request parameters
 // and returns application info for the frontend consent screen.
 func ValidateAuthorize(c *gin.Context) {
-	clientID := c.Query("client_id")
-	if clientID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "client_id is required"})
+	app, ok := loadAuthorizeApplication(c)
+	if !ok {
+		return
+	}
+	clientID := app.ClientID
+	params, ok := loadAuthorizeParams(c, app)
+	if !ok {
 		return
 	}
 
-	redirectURI := c.Query("redirect_uri")
+	redirectURI := params.Get("redirect_uri")
 	if redirectURI == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "redirect_uri is required"})
 		return
 	}
 
-	scope := c.Query("scope")
+	scope := params.Get("scope")
 	if scope == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "scope is required"})
 		return
 	}
 
 	// The authorization code flow is the only response type we support.
-	if responseType := c.Query("response_type"); responseType != "" && responseType != "code" {
+	if responseType := params.Get("response_type"); responseType != "" && responseType != "code" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported_response_type"})
 		return
 	}
 
-	if !service.ValidateScopes(scope) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid scope"})
-		return
-	}
-
 	if service.ScopesContain(scope, "sentinel:all") {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "sentinel:all scope cannot be requested by client applications"})
 		return
 	}
 
-	var app applicationResponse
-	err := sentinel.Get("/api/applications/client/"+clientID, &app)
-	if err != nil {
-		logger.SugarLogger.Errorf("Failed to get application for client_id %s: %v", clientID, err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client_id"})
+	if !app.redirectURIRegistered(redirectURI) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid redirect_uri"})
 		return
 	}
 
-	validURI := false
-	for _, uri := range app.RedirectURIs {
-		if service.MatchRedirectURI(uri, redirectURI) {
-			validURI = true
-			break
-		}
+	scopes, err := service.ResolveClientScopes(clientID, scope)
+	if err != nil {
+		writeScopeError(c, err)
+		return
 	}
-	if !validURI {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid redirect_uri"})
+	policy, err := service.GetTokenPolicy(clientID)
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to load token policy: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
 		return
 	}
 
@@ -108,7 +228,7 @@ func ValidateAuthorize(c *gin.Context) {
 	entityID := c.Query("entity_id")
 	if entityID != "" {
 		if err := service.CheckAccessGate(entityID, clientID); err != nil {
-			if errors.Is(err, service.ErrAccessDenied) {
+			if errors.Is(err, service.ErrAccessDenied) || errors.Is(err, service.ErrAccountInactive) {
 				c.JSON(http.StatusForbidden, gin.H{"error": "access_denied", "app_name": app.Name, "app_icon_url": app.IconURL})
 				return
 			}
@@ -134,12 +254,16 @@ func ValidateAuthorize(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, validateAuthorizeResponse{
-		ClientID:    clientID,
-		RedirectURI: redirectURI,
-		Scope:       scope,
-		Prompt:      prompt,
-		AppName:     app.Name,
-		AppIconURL:  app.IconURL,
+		ClientID:      clientID,
+		RedirectURI:   redirectURI,
+		Scope:         scope,
+		State:         params.Get("state"),
+		Nonce:         params.Get("nonce"),
+		Prompt:        prompt,
+		AppName:       app.Name,
+		AppIconURL:    app.IconURL,
+		Scopes:        scopes,
+		consentPolicy: newConsentPolicy(policy),
 	})
 }
 
@@ -148,13 +272,24 @@ type authorizeRequest struct {
 }
 
 // Authorize generates an authorization code after the user approves consent.
-// The frontend sends the entity_id of the authenticated user.
+// The frontend sends the entity_id of the authenticated user, whose session
+// must be the request's bearer and satisfy their MFA policy, and the same
+// client_id plus parameters, request, or request_uri it validated with. A
+// request_uri is used up here.
 func Authorize(c *gin.Context) {
-	clientID := c.Query("client_id")
-	redirectURI := c.Query("redirect_uri")
-	scope := c.Query("scope")
+	app, ok := loadAuthorizeApplication(c)
+	if !ok {
+		return
+	}
+	clientID := app.ClientID
+	params, ok := loadAuthorizeParams(c, app)
+	if !ok {
+		return
+	}
+	redirectURI := params.Get("redirect_uri")
+	scope := params.Get("scope")
 
-	if clientID == "" || redirectURI == "" || scope == "" {
+	if redirectURI == "" || scope == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "client_id, redirect_uri, and scope are required"})
 		return
 	}
@@ -164,9 +299,13 @@ func Authorize(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	amr, ok := requireSessionFor(c, req.EntityID)
+	if !ok {
+		return
+	}
 
-	if !service.ValidateScopes(scope) || service.ScopesContain(scope, "sentinel:all") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid scope"})
+	if _, err := service.ResolveClientScopes(clientID, scope); err != nil {
+		writeScopeError(c, err)
 		return
 	}
 
@@ -174,8 +313,18 @@ func Authorize(c *gin.Context) {
 		writeGateError(c, err)
 		return
 	}
+	if !requireConsentMFA(c, req.EntityID, amr) {
+		return
+	}
+
+	if requestURI := c.Query("request_uri"); requestURI != "" {
+		if err := service.ConsumePushedAuthorizationRequest(requestURI); err != nil {
+			writeRequestParamError(c, err)
+			return
+		}
+	}
 
-	authCode, err := service.GenerateAuthorizationCode(req.EntityID, clientID, scope, redirectURI, c.Query("nonce"))
+	authCode, err := service.GenerateAuthorizationCode(req.EntityID, clientID, scope, redirectURI, params.Get("nonce"), amr)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -186,3 +335,37 @@ func Authorize(c *gin.Context) {
 		"redirect_uri": redirectURI,
 	})
 }
+
+// requireSessionFor checks that the request carries a first-party session
+// belonging to entityID and returns the session's amr. The entity_id in a
+// request body only names who the SPA thinks is signed in; without this
+// anyone could approve consent on someone else's behalf. Writes 401 or 403
+// and returns false otherwise.
+func requireSessionFor(c *gin.Context, entityID string) ([]string, bool) {
+	claims, ok := requireFirstPartyClaims(c)
+	if !ok {
+		return nil, false
+	}
+	if sub, _ := claims["sub"].(string); sub != entityID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "entity_id does not match the signed-in session"})
+		return nil, false
+	}
+	return service.AMRFromClaims(claims), true
+}
+
+// requireConsentMFA checks the consenting session against the entity's
+// group MFA policy. Writes 403 mfa_required, or 502 when the policy
+// couldn't be read, and returns false otherwise.
+func requireConsentMFA(c *gin.Context, entityID string, amr []string) bool {
+	err := service.CheckConsentMFA(entityID, amr)
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, service.ErrMFARequired) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "mfa_required", "error_description": err.Error()})
+		return false
+	}
+	logger.SugarLogger.Errorf("mfa policy check failed: %v", err)
+	c.JSON(http.StatusBadGateway, gin.H{"error": "server_error", "error_description": "could not verify mfa policy"})
+	return false
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func authorizeContext(query string) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+query, nil)
+	return c, w
+}
+
+func TestLoadAuthorizeParams(t *testing.T) {
+	app := applicationResponse{ClientID: "client"}
+	parApp := applicationResponse{ClientID: "client", RequirePushedAuthorizationRequests: true}
+
+	t.Run("plain query", func(t *testing.T) {
+		c, _ := authorizeContext("client_id=client&scope=openid&state=xyz")
+		params, ok := loadAuthorizeParams(c, app)
+		if !ok || params.Get("scope") != "openid" || params.Get("state") != "xyz" {
+			t.Errorf("loadAuthorizeParams = %v, %v, want the query", params, ok)
+		}
+	})
+
+	rejected := []struct {
+		name      string
+		app       applicationResponse
+		query     string
+		wantError string
+	}{
+		{"request and request_uri", app, "client_id=client&request=a.b.c&request_uri=urn:ietf:params:oauth:request_uri:x", "invalid_request"},
+		{"PAR required, plain query", parApp, "client_id=client&scope=openid", "invalid_request"},
+		{"PAR required, request object", parApp, "client_id=client&request=a.b.c", "invalid_request"},
+		{"request_uri not from /oauth/par", app, "client_id=client&request_uri=https://client.example.com/r.jwt", "invalid_request_uri"},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := authorizeContext(tt.query)
+			if _, ok := loadAuthorizeParams(c, tt.app); ok {
+				t.Fatal("loadAuthorizeParams accepted the request")
+			}
+			var body struct {
+				Error string `json:"error"`
+			}
+			_ = json.Unmarshal(w.Body.Bytes(), &body)
+			if w.Code != http.StatusBadRequest || body.Error != tt.wantError {
+				t.Errorf("response = %d %q, want 400 %q", w.Code, body.Error, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/oauth/config"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/logger"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
+	"github.com/gaucho-racing/sentinel/oauth/service"
+	"github.com/gin-gonic/gin"
+)
+
+type deviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// deviceVerificationURL is the SPA page where users enter a device's code.
+func deviceVerificationURL() string {
+	return config.Issuer + "/oauth/device"
+}
+
+// DeviceAuthorization starts the RFC 8628 device flow. The device
+// authenticates as it would at the token endpoint, then shows the user
+// the user_code and verification_uri and polls /oauth/token.
+func DeviceAuthorization(c *gin.Context) {
+	clientID, ok := authenticateClient(c, config.Issuer+"/api/oauth/device_authorization")
+	if !ok {
+		return
+	}
+	scope := c.PostForm("scope")
+	if scope == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope", "error_description": "scope is required"})
+		return
+	}
+	if _, err := service.ResolveClientScopes(clientID, scope); err != nil {
+		if errors.Is(err, service.ErrInvalidScope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope", "error_description": err.Error()})
+			return
+		}
+		logger.SugarLogger.Errorf("Failed to resolve device scopes: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
+		return
+	}
+
+	deviceCode, err := service.GenerateDeviceCode(clientID, scope)
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to create device code: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	userCode := service.FormatUserCode(deviceCode.UserCode)
+	c.JSON(http.StatusOK, deviceAuthorizationResponse{
+		DeviceCode:              deviceCode.DeviceCode,
+		UserCode:                userCode,
+		VerificationURI:         deviceVerificationURL(),
+		VerificationURIComplete: deviceVerificationURL() + "?user_code=" + userCode,
+		ExpiresIn:               int(time.Until(deviceCode.ExpiresAt).Seconds()),
+		Interval:                deviceCode.Interval,
+	})
+}
+
+type validateDeviceResponse struct {
+	ClientID   string    `json:"client_id"`
+	UserCode   string    `json:"user_code"`
+	Scope      string    `json:"scope"`
+	AppName    string    `json:"app_name"`
+	AppIconURL string    `json:"app_icon_url"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Scopes describes each requested scope for the consent screen.
+	Scopes []service.ScopeInfo `json:"scopes"`
+	consentPolicy
+}
+
+// ValidateDevice looks up a user code for the SPA's verification page and
+// runs the access gate, like ValidateAuthorize. There's no prompt=none
+// here: the user always confirms, since the code could have been read off
+// someone else's device.
+func ValidateDevice(c *gin.Context) {
+	deviceCode, err := service.GetPendingDeviceCode(c.Query("user_code"))
+	if err != nil {
+		if errors.Is(err, service.ErrUserCodeNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	var app applicationResponse
+	if err := sentinel.Get("/api/applications/client/"+deviceCode.ClientID, &app); err != nil {
+		logger.SugarLogger.Errorf("Failed to get application for client_id %s: %v", deviceCode.ClientID, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client_id"})
+		return
+	}
+
+	// Allow-lists can change in the ten minutes a code lives.
+	scopes, err := service.ResolveClientScopes(deviceCode.ClientID, deviceCode.Scope)
+	if err != nil {
+		writeScopeError(c, err)
+		return
+	}
+	policy, err := service.GetTokenPolicy(deviceCode.ClientID)
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to load token policy: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
+		return
+	}
+
+	if entityID := c.Query("entity_id"); entityID != "" {
+		if err := service.CheckAccessGate(entityID, deviceCode.ClientID); err != nil {
+			if errors.Is(err, service.ErrAccessDenied) || errors.Is(err, service.ErrAccountInactive) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "access_denied", "app_name": app.Name, "app_icon_url": app.IconURL})
+				return
+			}
+			logger.SugarLogger.Errorf("access gate evaluation failed: %v", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, validateDeviceResponse{
+		ClientID:      deviceCode.ClientID,
+		UserCode:      service.FormatUserCode(deviceCode.UserCode),
+		Scope:         deviceCode.Scope,
+		AppName:       app.Name,
+		AppIconURL:    app.IconURL,
+		ExpiresAt:     deviceCode.ExpiresAt,
+		Scopes:        scopes,
+		consentPolicy: newConsentPolicy(policy),
+	})
+}
+
+type resolveDeviceRequest struct {
+	UserCode string `json:"user_code" binding:"required"`
+	EntityID string `json:"entity_id" binding:"required"`
+	Approve  bool   `json:"approve"`
+}
+
+// ResolveDevice records the user's answer on the verification page. The
+// device learns it on its next poll. Only the signed-in user can answer for
+// themselves, since an approval hands the device their tokens.
+func ResolveDevice(c *gin.Context) {
+	var req resolveDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	amr, ok := requireSessionFor(c, req.EntityID)
+	if !ok {
+		return
+	}
+
+	var err error
+	if req.Approve {
+		deviceCode, lookupErr := service.GetPendingDeviceCode(req.UserCode)
+		if lookupErr != nil {
+			err = lookupErr
+		} else if gateErr := service.CheckAccessGate(req.EntityID, deviceCode.ClientID); gateErr != nil {
+			writeGateError(c, gateErr)
+			return
+		} else if !requireConsentMFA(c, req.EntityID, amr) {
+			return
+		} else {
+			err = service.ApproveDeviceCode(req.UserCode, req.EntityID, amr)
+		}
+	} else {
+		err = service.DenyDeviceCode(req.UserCode)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrUserCodeNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	if req.Approve {
+		c.JSON(http.StatusOK, gin.H{"message": "device approved"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "device denied"})
+}
+
+// handleDeviceCodeExchange is the device's poll. Until the user answers it
+// gets authorization_pending (or slow_down when polling too fast); after,
+// either tokens or access_denied, exactly once.
+func handleDeviceCodeExchange(c *gin.Context) {
+	code := c.PostForm("device_code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "device_code is required"})
+		return
+	}
+	clientID, ok := authenticateClient(c, tokenEndpointURL())
+	if !ok {
+		return
+	}
+
+	deviceCode, err := service.PollDeviceCode(code, clientID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAuthorizationPending),
+			errors.Is(err, service.ErrSlowDown),
+			errors.Is(err, service.ErrExpiredToken),
+			errors.Is(err, service.ErrDeviceAccessDenied),
+			errors.Is(err, service.ErrInvalidDeviceCode):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			logger.SugarLogger.Errorf("Failed to poll device code: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		}
+		return
+	}
+
+	// Membership may have changed while the device was waiting.
+	if err := service.CheckAccessGate(deviceCode.EntityID, clientID); err != nil {
+		writeGateError(c, err)
+		return
+	}
+	authTime := deviceCode.CreatedAt.Unix()
+	if deviceCode.ApprovedAt != nil {
+		authTime = deviceCode.ApprovedAt.Unix()
+	}
+	issueTokens(c, deviceCode.EntityID, clientID, deviceCode.Scope, "", service.SplitAMR(deviceCode.AMR), authTime)
+}
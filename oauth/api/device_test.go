@@ -0,0 +1,80 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveSessions answers core's token validation with claims for each known
+// bearer and 401 for anything else.
+func serveSessions(t *testing.T, sessions map[string]map[string]interface{}) {
+	t.Helper()
+	handleCore(t, http.MethodPost, "/api/core/token/validate", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Token string `json:"token"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		claims, ok := sessions[body.Token]
+		if !ok {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "invalid token"})
+			return
+		}
+		writeJSON(w, http.StatusOK, claims)
+	})
+}
+
+func TestResolveDeviceRequiresOwnSession(t *testing.T) {
+	serveSessions(t, map[string]map[string]interface{}{
+		"alice-session": {"sub": "ent_alice", "scope": firstPartyAccessScope},
+		"alice-refresh": {"sub": "ent_alice", "scope": firstPartyAccessScope + " refresh_token"},
+		"app-token":     {"sub": "ent_alice", "scope": "openid profile"},
+	})
+
+	tests := []struct {
+		name     string
+		bearer   string
+		entityID string
+		want     int
+	}{
+		{"no bearer", "", "ent_victim", http.StatusUnauthorized},
+		{"invalid bearer", "forged", "ent_victim", http.StatusUnauthorized},
+		{"someone else's session", "alice-session", "ent_victim", http.StatusForbidden},
+		{"refresh token", "alice-refresh", "ent_alice", http.StatusForbidden},
+		{"third-party token", "app-token", "ent_alice", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]interface{}{"user_code": "BCDF-GHJK", "entity_id": tt.entityID, "approve": true})
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/oauth/device", bytes.NewReader(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			if tt.bearer != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			ResolveDevice(c)
+			if w.Code != tt.want {
+				t.Errorf("ResolveDevice = %d %s, want %d", w.Code, w.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireSessionFor(t *testing.T) {
+	serveSessions(t, map[string]map[string]interface{}{
+		"alice-session": {"sub": "ent_alice", "scope": firstPartyAccessScope, "amr": []string{"pwd", "otp", "mfa"}},
+	})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/oauth/device", nil)
+	c.Request.Header.Set("Authorization", "Bearer alice-session")
+	amr, ok := requireSessionFor(c, "ent_alice")
+	if !ok || len(amr) != 3 || amr[1] != "otp" {
+		t.Errorf("requireSessionFor = %v, %v, want the session's amr", amr, ok)
+	}
+}
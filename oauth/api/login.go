@@ -45,51 +45,122 @@ func LoginEmailPassword(c *gin.Context) {
 		return
 	}
 
-	resp, err := mintFirstPartySession(c, verify.EntityID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	completeFirstPartyLogin(c, verify.EntityID, []string{amrPassword})
+}
+
+type emailCodeRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// RequestEmailLoginCode asks core to mail a passwordless login code. The
+// response is identical for registered and unregistered emails; only rate
+// limiting is surfaced, as 429.
+func RequestEmailLoginCode(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var req emailCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, resp)
+
+	if err := sentinel.Post("/api/core/login/email-code/request", req, nil); err != nil {
+		logger.SugarLogger.Errorf("login code request: upstream failure: %v", err)
+		var apiErr *sentinel.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusTooManyRequests {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": apiErr.Message})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "if that email is registered, a login code has been sent"})
 }
 
-type refreshSessionRequest struct {
-	RefreshToken string `json:"refresh_token" binding:"required"`
+type emailCodeLoginRequest struct {
+	Email string `json:"email" binding:"required"`
+	Code  string `json:"code" binding:"required"`
 }
 
-func RefreshSession(c *gin.Context) {
+// LoginEmailCode verifies a passwordless login code and mints a first-party
+// session, same as the email/password login.
+func LoginEmailCode(c *gin.Context) {
 	c.Header("Cache-Control", "no-store")
-	var req refreshSessionRequest
+	var req emailCodeLoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var claims map[string]interface{}
-	if err := sentinel.Post("/api/core/token/validate", map[string]string{"token": req.RefreshToken}, &claims); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired refresh token"})
+	var verify struct {
+		EntityID string `json:"entity_id"`
+	}
+	if err := sentinel.Post("/api/core/login/email-code/verify", req, &verify); err != nil {
+		logger.SugarLogger.Errorf("login code: upstream failure: %v", err)
+		var apiErr *sentinel.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusUnauthorized {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired login code"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
 		return
 	}
 
-	entityID, _ := claims["sub"].(string)
-	scope, _ := claims["scope"].(string)
-	if entityID == "" || !service.ScopesContain(scope, "refresh_token") {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "not a refresh token"})
+	completeFirstPartyLogin(c, verify.EntityID, []string{amrOTP})
+}
+
+type refreshSessionRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func RefreshSession(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var req refreshSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if tokenID, ok := claims["jti"].(string); ok {
-		sentinel.Delete("/api/core/token/"+tokenID, nil)
+	rotated, err := service.RotateRefreshToken(req.RefreshToken, config.SentinelClientID, GetClientIP(c))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		writeSessionError(c, err)
+		return
 	}
 
-	resp, err := mintFirstPartySession(c, entityID)
+	entityID, _ := rotated.Claims["sub"].(string)
+	// Refresh doesn't re-authenticate, so the session keeps the methods it
+	// was originally established with. Sessions from before token families
+	// start one.
+	amr := service.AMRFromClaims(rotated.Claims)
+	var resp sessionResponse
+	if rotated.Family != nil {
+		var policy service.TokenPolicy
+		if policy, err = service.GetTokenPolicy(config.SentinelClientID); err == nil {
+			resp, err = mintFirstPartyTokens(c, policy, entityID, amr, *rotated.Family)
+		}
+	} else {
+		resp, err = mintFirstPartySession(c, entityID, amr)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeSessionError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
 }
 
+// writeSessionError reports a mintFirstPartySession failure: 403 for a
+// suspended or offboarded account, 500 for anything else.
+func writeSessionError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrAccountInactive) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
 // First-party tokens carry sentinel:all — the privileged scope reserved
 // for Sentinel itself and service accounts. Granular scopes (user:read,
 // groups:read, etc.) exist for third-party OAuth clients to request via
@@ -98,20 +169,42 @@ func RefreshSession(c *gin.Context) {
 const firstPartyAccessScope = "sentinel:all"
 const firstPartyRefreshScope = firstPartyAccessScope + " refresh_token"
 
-// mintFirstPartySession builds claims, mints access + refresh JWTs, and
-// records an entity login for audit. Used by /auth/login and /auth/refresh.
-func mintFirstPartySession(c *gin.Context, entityID string) (sessionResponse, error) {
+// mintFirstPartySession checks the account is active, starts a refresh
+// token family, and mints the session's first tokens into it. Used by the
+// /auth/login/* endpoints.
+func mintFirstPartySession(c *gin.Context, entityID string, amr []string) (sessionResponse, error) {
+	if err := service.CheckEntityActive(entityID); err != nil {
+		return sessionResponse{}, err
+	}
+	policy, err := service.GetTokenPolicy(config.SentinelClientID)
+	if err != nil {
+		return sessionResponse{}, err
+	}
+	family, err := service.StartTokenFamily(entityID, config.SentinelClientID, policy)
+	if err != nil {
+		return sessionResponse{}, err
+	}
+	return mintFirstPartyTokens(c, policy, entityID, amr, family)
+}
+
+// mintFirstPartyTokens builds claims, mints access + refresh JWTs into
+// family, and records an entity login for audit. Used for new sessions and
+// by /auth/refresh. amr is stamped into both tokens. Lifetimes come from
+// the Sentinel client's token policy; its offline access setting doesn't
+// apply, since the console can't keep a session without a refresh token.
+func mintFirstPartyTokens(c *gin.Context, policy service.TokenPolicy, entityID string, amr []string, family service.TokenFamily) (sessionResponse, error) {
 	claims, err := service.BuildTokenClaims(entityID, config.SentinelClientID, firstPartyAccessScope)
 	if err != nil {
 		return sessionResponse{}, err
 	}
+	service.SetAMRClaim(claims, amr)
 
-	accessToken, accessTokenID, err := generateToken(entityID, config.SentinelClientID, firstPartyAccessScope, config.AccessTokenTTL, claims)
+	accessToken, accessTokenID, err := generateFamilyToken(family.ID, entityID, config.SentinelClientID, firstPartyAccessScope, policy.AccessTTL(), claims)
 	if err != nil {
 		return sessionResponse{}, errors.New("failed to generate access token")
 	}
 
-	refreshToken, refreshTokenID, err := generateToken(entityID, config.SentinelClientID, firstPartyRefreshScope, config.RefreshTokenTTL, claims)
+	refreshToken, refreshTokenID, err := generateFamilyToken(family.ID, entityID, config.SentinelClientID, firstPartyRefreshScope, service.RefreshTokenTTL(family, policy), claims)
 	if err != nil {
 		logger.SugarLogger.Errorf("Failed to generate refresh token for %s: %v", entityID, err)
 		refreshToken = ""
@@ -130,7 +223,7 @@ func mintFirstPartySession(c *gin.Context, entityID string) (sessionResponse, er
 	return sessionResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
-		ExpiresIn:    config.AccessTokenTTL,
+		ExpiresIn:    policy.AccessTTL(),
 		EntityID:     entityID,
 	}, nil
 }
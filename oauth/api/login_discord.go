@@ -82,10 +82,5 @@ func LoginDiscord(c *gin.Context) {
 		logger.SugarLogger.Warnf("discord login: metadata refresh failed for entity %s: %v", entity.ID, err)
 	}
 
-	resp, err := mintFirstPartySession(c, entity.ID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	c.JSON(http.StatusOK, resp)
+	completeFirstPartyLogin(c, entity.ID, []string{amrFederated})
 }
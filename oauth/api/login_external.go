@@ -0,0 +1,132 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gaucho-racing/sentinel/oauth/pkg/logger"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
+	"github.com/gaucho-racing/sentinel/oauth/service"
+	"github.com/gin-gonic/gin"
+)
+
+// LoginGitHub completes "Continue with GitHub". Same contract as
+// LoginDiscord: the code arrives as a query param, and a provider account
+// no entity has linked yet gets a structured 404.
+func LoginGitHub(c *gin.Context) {
+	loginWithExternalProvider(c, "GITHUB")
+}
+
+// LoginGoogle completes "Continue with Google".
+func LoginGoogle(c *gin.Context) {
+	loginWithExternalProvider(c, "GOOGLE")
+}
+
+func loginWithExternalProvider(c *gin.Context, provider string) {
+	c.Header("Cache-Control", "no-store")
+
+	code := c.Query("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing code"})
+		return
+	}
+	identity, err := service.ResolveExternalIdentity(provider, code)
+	if err != nil {
+		logger.SugarLogger.Errorf("%s login: code exchange failed: %v", provider, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired code"})
+		return
+	}
+
+	var entity struct {
+		ID string `json:"id"`
+	}
+	if err := sentinel.Get("/api/core/entity/external/"+identity.Provider+"/"+identity.ExternalID, &entity); err != nil {
+		var apiErr *sentinel.APIError
+		// Unlike Discord, GitHub and Google accounts are only ever linked
+		// from settings, so "no_account" here means "sign in another way
+		// and link this account first".
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "no_account",
+				"message": "No Sentinel account is linked to this " + providerLabel(identity.Provider) + " account.",
+			})
+			return
+		}
+		logger.SugarLogger.Errorf("%s login: entity lookup failed: %v", provider, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Best-effort metadata refresh, as in LoginDiscord.
+	if err := sentinel.Patch(
+		"/api/core/entity/"+entity.ID+"/external-auth/"+identity.Provider,
+		map[string]any{"metadata": identity.Metadata},
+		nil,
+	); err != nil {
+		logger.SugarLogger.Warnf("%s login: metadata refresh failed for entity %s: %v", provider, entity.ID, err)
+	}
+
+	completeFirstPartyLogin(c, entity.ID, []string{amrFederated})
+}
+
+type connectExternalRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ConnectExternalAccount links the GitHub or Google account behind an
+// authorization code to the signed-in user, after which it works as a login
+// method. Core refuses (409) an account already linked to someone else, or
+// a second account from the same provider.
+func ConnectExternalAccount(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	entityID, ok := requireFirstPartySession(c)
+	if !ok {
+		return
+	}
+	var req connectExternalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	identity, err := service.ResolveExternalIdentity(c.Param("provider"), req.Code)
+	if err != nil {
+		if errors.Is(err, service.ErrUnsupportedProvider) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "only GitHub and Google accounts can be linked"})
+			return
+		}
+		logger.SugarLogger.Errorf("connect %s: code exchange failed for %s: %v", c.Param("provider"), entityID, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired code"})
+		return
+	}
+
+	var auth map[string]any
+	if err := sentinel.Post("/api/core/entity/"+entityID+"/external-auth", map[string]any{
+		"provider":    identity.Provider,
+		"external_id": identity.ExternalID,
+		"metadata":    identity.Metadata,
+	}, &auth); err != nil {
+		var apiErr *sentinel.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusConflict {
+			c.JSON(http.StatusConflict, gin.H{"error": apiErr.Message})
+			return
+		}
+		logger.SugarLogger.Errorf("connect %s: link failed for %s: %v", identity.Provider, entityID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"provider":    identity.Provider,
+		"external_id": identity.ExternalID,
+		"metadata":    identity.Metadata,
+	})
+}
+
+func providerLabel(provider string) string {
+	switch provider {
+	case "GITHUB":
+		return "GitHub"
+	case "GOOGLE":
+		return "Google"
+	}
+	return provider
+}
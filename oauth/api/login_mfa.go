@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gaucho-racing/sentinel/oauth/pkg/logger"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
+	"github.com/gin-gonic/gin"
+)
+
+// First-factor amr values (RFC 8176). "fed" isn't registered; it marks a
+// login delegated to an external identity provider like Discord. Core adds
+// "otp" or "hwk", plus "mfa", when a second factor is completed.
+const (
+	amrPassword  = "pwd"
+	amrOTP       = "otp"
+	amrFederated = "fed"
+)
+
+type mfaChallengeResponse struct {
+	MFARequired        bool     `json:"mfa_required"`
+	EnrollmentRequired bool     `json:"enrollment_required,omitempty"`
+	Methods            []string `json:"methods,omitempty"`
+	MFAToken           string   `json:"mfa_token,omitempty"`
+	ExpiresAt          string   `json:"expires_at,omitempty"`
+}
+
+// completeFirstPartyLogin runs after a first factor succeeds. If core says
+// the entity needs MFA (enrolled, or a group requires it), it responds with
+// an MFA challenge instead of a session; the client finishes the login at
+// /auth/login/mfa. Otherwise the session is minted immediately.
+func completeFirstPartyLogin(c *gin.Context, entityID string, amr []string) {
+	var challenge mfaChallengeResponse
+	if err := sentinel.Post("/api/core/mfa/challenge", map[string]interface{}{
+		"entity_id": entityID,
+		"amr":       amr,
+	}, &challenge); err != nil {
+		// Fail closed: without knowing the MFA requirement we can't mint.
+		logger.SugarLogger.Errorf("login: mfa challenge failed for %s: %v", entityID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	if challenge.MFARequired {
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	resp, err := mintFirstPartySession(c, entityID, amr)
+	if err != nil {
+		writeSessionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+type mfaLoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type mfaSessionResponse struct {
+	sessionResponse
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// LoginMFA completes a two-step login with a TOTP or recovery code. When the
+// challenge was for a policy-forced enrollment, the code confirms the new
+// authenticator and the response also carries the recovery codes.
+func LoginMFA(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var req mfaLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var verification struct {
+		EntityID      string   `json:"entity_id"`
+		AMR           []string `json:"amr"`
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	if err := sentinel.Post("/api/core/mfa/challenge/verify", map[string]string{
+		"challenge_token": req.MFAToken,
+		"code":            req.Code,
+	}, &verification); err != nil {
+		logger.SugarLogger.Errorf("mfa login: upstream failure: %v", err)
+		var apiErr *sentinel.APIError
+		if errors.As(err, &apiErr) && apiErr.Status >= 400 && apiErr.Status < 500 {
+			c.JSON(apiErr.Status, gin.H{"error": apiErr.Message})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := mintFirstPartySession(c, verification.EntityID, verification.AMR)
+	if err != nil {
+		writeSessionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, mfaSessionResponse{
+		sessionResponse: resp,
+		RecoveryCodes:   verification.RecoveryCodes,
+	})
+}
+
+type mfaEnrollRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// EnrollMFAForLogin starts TOTP enrollment for a user whose group policy
+// requires MFA but who hasn't set it up yet. The challenge token from the
+// first factor is the only credential — there's no session yet.
+func EnrollMFAForLogin(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var req mfaEnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var enrollment struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}
+	if err := sentinel.Post("/api/core/mfa/challenge/enroll", map[string]string{
+		"challenge_token": req.MFAToken,
+	}, &enrollment); err != nil {
+		logger.SugarLogger.Errorf("mfa enroll: upstream failure: %v", err)
+		var apiErr *sentinel.APIError
+		if errors.As(err, &apiErr) && apiErr.Status >= 400 && apiErr.Status < 500 {
+			c.JSON(apiErr.Status, gin.H{"error": apiErr.Message})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gaucho-racing/sentinel/oauth/pkg/logger"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
+	"github.com/gaucho-racing/sentinel/oauth/service"
+	"github.com/gin-gonic/gin"
+)
+
+// amr values for passkey sign-ins (RFC 8176). "hwk" is proof of possession
+// of the passkey; a user-verified passkey also counts as "mfa" on its own.
+const (
+	amrHardwareKey = "hwk"
+	amrMFA         = "mfa"
+)
+
+// writePasskeyError maps ceremony failures to 401 and passes core's 4xx
+// answers through; anything else is an upstream failure.
+func writePasskeyError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidWebAuthnSession) || errors.Is(err, service.ErrPasskeyRejected) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var apiErr *sentinel.APIError
+	if errors.As(err, &apiErr) && apiErr.Status >= 400 && apiErr.Status < 500 {
+		c.JSON(apiErr.Status, gin.H{"error": apiErr.Message})
+		return
+	}
+	c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+}
+
+// requireFirstPartySession validates the request's bearer with core and
+// returns its subject. Passkey registration binds a credential to the
+// caller, so only a first-party access token (not a refresh token, not a
+// third-party OAuth token) is accepted.
+func requireFirstPartySession(c *gin.Context) (string, bool) {
+	claims, ok := requireFirstPartyClaims(c)
+	if !ok {
+		return "", false
+	}
+	entityID, _ := claims["sub"].(string)
+	return entityID, true
+}
+
+// requireFirstPartyClaims is requireFirstPartySession returning every claim
+// of the session's access token, for callers that also need its amr.
+func requireFirstPartyClaims(c *gin.Context) (map[string]interface{}, bool) {
+	bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || bearer == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+		return nil, false
+	}
+	var claims map[string]interface{}
+	if err := sentinel.Post("/api/core/token/validate", map[string]string{"token": bearer}, &claims); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+		return nil, false
+	}
+	entityID, _ := claims["sub"].(string)
+	scope, _ := claims["scope"].(string)
+	if entityID == "" || !service.ScopesContain(scope, firstPartyAccessScope) || service.ScopesContain(scope, "refresh_token") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "a first-party session is required"})
+		return nil, false
+	}
+	return claims, true
+}
+
+type passkeyCeremonyResponse struct {
+	SessionID string      `json:"session_id"`
+	Options   interface{} `json:"options"`
+}
+
+type passkeyFinishRequest struct {
+	SessionID  string          `json:"session_id" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+// BeginPasskeyRegistration returns creation options for
+// navigator.credentials.create() and the session ID to finish with.
+func BeginPasskeyRegistration(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	entityID, ok := requireFirstPartySession(c)
+	if !ok {
+		return
+	}
+	creation, sessionID, err := service.BeginPasskeyRegistration(entityID)
+	if err != nil {
+		logger.SugarLogger.Errorf("passkey registration: begin failed for %s: %v", entityID, err)
+		writePasskeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, passkeyCeremonyResponse{SessionID: sessionID, Options: creation})
+}
+
+type passkeyRegistrationFinishRequest struct {
+	passkeyFinishRequest
+	Name string `json:"name"`
+}
+
+func FinishPasskeyRegistration(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	entityID, ok := requireFirstPartySession(c)
+	if !ok {
+		return
+	}
+	var req passkeyRegistrationFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	passkey, err := service.FinishPasskeyRegistration(entityID, req.SessionID, req.Name, req.Credential)
+	if err != nil {
+		writePasskeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, passkey)
+}
+
+// BeginPasskeyLogin starts a username-less sign-in with a discoverable
+// passkey.
+func BeginPasskeyLogin(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	assertion, sessionID, err := service.BeginPasskeyLogin()
+	if err != nil {
+		logger.SugarLogger.Errorf("passkey login: begin failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, passkeyCeremonyResponse{SessionID: sessionID, Options: assertion})
+}
+
+// FinishPasskeyLogin mints a session straight from a verified passkey. The
+// ceremony requires user verification, so the passkey alone is two factors
+// (the device, plus the PIN or biometric that unlocked it) and no separate
+// MFA challenge is raised.
+func FinishPasskeyLogin(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var req passkeyFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	entityID, err := service.FinishPasskeyLogin(req.SessionID, req.Credential)
+	if err != nil {
+		writePasskeyError(c, err)
+		return
+	}
+	resp, err := mintFirstPartySession(c, entityID, []string{amrHardwareKey, amrMFA})
+	if err != nil {
+		writeSessionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+type passkeyMFABeginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// BeginPasskeyMFA starts a passkey assertion as the second factor of a
+// login that returned an MFA challenge.
+func BeginPasskeyMFA(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var req passkeyMFABeginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	assertion, sessionID, err := service.BeginPasskeyMFA(req.MFAToken)
+	if err != nil {
+		logger.SugarLogger.Errorf("passkey mfa: begin failed: %v", err)
+		writePasskeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, passkeyCeremonyResponse{SessionID: sessionID, Options: assertion})
+}
+
+type passkeyMFAFinishRequest struct {
+	passkeyFinishRequest
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+func FinishPasskeyMFA(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var req passkeyMFAFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	verification, err := service.FinishPasskeyMFA(req.MFAToken, req.SessionID, req.Credential)
+	if err != nil {
+		writePasskeyError(c, err)
+		return
+	}
+	resp, err := mintFirstPartySession(c, verification.EntityID, verification.AMR)
+	if err != nil {
+		writeSessionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/gaucho-racing/sentinel/oauth/pkg/kerbecs"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logger.Logger = zap.NewNop()
+	logger.SugarLogger = logger.Logger.Sugar()
+
+	core := httptest.NewServer(fakeCore)
+	fakeCore.url = core.URL
+	kerbecs.Init(core.URL, "", "")
+	code := m.Run()
+	core.Close()
+	os.Exit(code)
+}
+
+// fakeCore stands in for both the kerbecs gateway and core: every route
+// resolves back to it, and tests register the core endpoints they need
+// with handleCore. Unregistered routes get core's 404.
+var fakeCore = &fakeCoreServer{routes: map[string]http.HandlerFunc{}}
+
+type fakeCoreServer struct {
+	url    string
+	mu     sync.Mutex
+	routes map[string]http.HandlerFunc
+}
+
+func (f *fakeCoreServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/admin-gw/resolve" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"matched":        true,
+			"url":            f.url,
+			"rewritten_path": r.URL.Query().Get("path"),
+		})
+		return
+	}
+	f.mu.Lock()
+	handler, ok := f.routes[r.Method+" "+r.URL.Path]
+	f.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	handler(w, r)
+}
+
+// handleCore serves method and path from the fake core for the rest of the
+// test.
+func handleCore(t *testing.T, method, path string, handler http.HandlerFunc) {
+	t.Helper()
+	key := method + " " + path
+	fakeCore.mu.Lock()
+	fakeCore.routes[key] = handler
+	fakeCore.mu.Unlock()
+	t.Cleanup(func() {
+		fakeCore.mu.Lock()
+		delete(fakeCore.routes, key)
+		fakeCore.mu.Unlock()
+	})
+}
+
+// respondCore is a handler that always answers status with body as JSON.
+func respondCore(status int, body interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, status, body)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/oauth/config"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/logger"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
+	"github.com/gaucho-racing/sentinel/oauth/service"
+	"github.com/gin-gonic/gin"
+)
+
+// clientAuthParams are the form fields that authenticate the client. They
+// aren't authorization parameters and are never stored with a pushed
+// request.
+var clientAuthParams = []string{"client_secret", "client_assertion", "client_assertion_type"}
+
+// pushedAuthorizationEndpointURL is the PAR endpoint as advertised in
+// discovery.
+func pushedAuthorizationEndpointURL() string {
+	return config.Issuer + "/api/oauth/par"
+}
+
+type pushedAuthorizationResponse struct {
+	RequestURI string `json:"request_uri"`
+	ExpiresIn  int    `json:"expires_in"`
+}
+
+// PushedAuthorization is the RFC 9126 pushed authorization request
+// endpoint. The client authenticates as it would at the token endpoint and
+// posts its authorization parameters, or a signed request object holding
+// them, back-channel. They're validated as the authorize endpoint would,
+// then stored behind a short-lived request_uri the client sends the
+// browser to /oauth/authorize with, alongside its client_id.
+func PushedAuthorization(c *gin.Context) {
+	clientID, ok := authenticateClient(c, pushedAuthorizationEndpointURL())
+	if !ok {
+		return
+	}
+	if c.PostForm("request_uri") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "request_uri can't be pushed"})
+		return
+	}
+
+	var params url.Values
+	if requestObject := c.PostForm("request"); requestObject != "" {
+		var err error
+		if params, err = service.VerifyRequestObject(clientID, requestObject); err != nil {
+			writeRequestParamError(c, err)
+			return
+		}
+	} else {
+		params = url.Values{}
+		for name, values := range c.Request.PostForm {
+			params[name] = values
+		}
+		for _, name := range clientAuthParams {
+			params.Del(name)
+		}
+	}
+	if id := params.Get("client_id"); id != "" && id != clientID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "client_id doesn't match the authenticated client"})
+		return
+	}
+	params.Set("client_id", clientID)
+
+	if responseType := params.Get("response_type"); responseType != "" && responseType != "code" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported_response_type"})
+		return
+	}
+	var app applicationResponse
+	if err := sentinel.Get("/api/applications/client/"+clientID, &app); err != nil {
+		logger.SugarLogger.Errorf("Failed to get application for client_id %s: %v", clientID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
+		return
+	}
+	if redirectURI := params.Get("redirect_uri"); redirectURI == "" || !app.redirectURIRegistered(redirectURI) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "redirect_uri is missing or not registered"})
+		return
+	}
+	scope := params.Get("scope")
+	if scope == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope", "error_description": "scope is required"})
+		return
+	}
+	if _, err := service.ResolveClientScopes(clientID, scope); err != nil {
+		if errors.Is(err, service.ErrInvalidScope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope", "error_description": err.Error()})
+			return
+		}
+		logger.SugarLogger.Errorf("Failed to resolve pushed request scopes: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
+		return
+	}
+
+	request, err := service.PushAuthorizationRequest(clientID, params)
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to store pushed authorization request: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, pushedAuthorizationResponse{
+		RequestURI: request.RequestURI,
+		ExpiresIn:  int(time.Until(request.ExpiresAt).Seconds()),
+	})
+}
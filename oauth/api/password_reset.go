@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gaucho-racing/sentinel/oauth/pkg/logger"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
+	"github.com/gin-gonic/gin"
+)
+
+const passwordResetRequestedMessage = "if that account exists, a password reset link has been sent"
+
+type passwordResetRequest struct {
+	Email   string `json:"email" binding:"required"`
+	Channel string `json:"channel"`
+}
+
+// RequestPasswordReset asks core to issue a reset token. Core mails EMAIL
+// resets itself; for DISCORD it returns the DM, which is forwarded to the
+// discord service. The response is the same whether or not the account
+// exists, and whether or not a Discord DM went out, so the endpoint can't
+// be used to enumerate emails or which of them have Discord linked.
+func RequestPasswordReset(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var req passwordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var delivery struct {
+		DiscordID string `json:"discord_id"`
+		Message   string `json:"message"`
+	}
+	if err := sentinel.Post("/api/core/password-reset/request", req, &delivery); err != nil {
+		logger.SugarLogger.Errorf("password reset request: upstream failure: %v", err)
+		var apiErr *sentinel.APIError
+		if errors.As(err, &apiErr) && (apiErr.Status == http.StatusTooManyRequests || apiErr.Status == http.StatusBadRequest) {
+			c.JSON(apiErr.Status, gin.H{"error": apiErr.Message})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	if delivery.DiscordID != "" {
+		if err := sentinel.Post("/api/discord/direct-messages", map[string]string{
+			"user_id": delivery.DiscordID,
+			"content": delivery.Message,
+		}, nil); err != nil {
+			logger.SugarLogger.Errorf("password reset request: discord delivery failed: %v", err)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": passwordResetRequestedMessage})
+}
+
+type passwordResetConfirmRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ConfirmPasswordReset sets a new password from a reset token. Core revokes
+// every existing token for the entity on success; no new session is minted,
+// the user signs in again with the new password.
+func ConfirmPasswordReset(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	var req passwordResetConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := sentinel.Post("/api/core/password-reset/confirm", req, nil); err != nil {
+		logger.SugarLogger.Errorf("password reset confirm: upstream failure: %v", err)
+		var apiErr *sentinel.APIError
+		if errors.As(err, &apiErr) {
+			switch apiErr.Status {
+			case http.StatusBadRequest:
+				c.JSON(http.StatusBadRequest, gin.H{"error": apiErr.Message})
+				return
+			case http.StatusUnauthorized:
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired password reset link"})
+				return
+			}
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password updated"})
+}
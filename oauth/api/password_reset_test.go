@@ -0,0 +1,42 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestPasswordResetHidesDelivery(t *testing.T) {
+	handleCore(t, http.MethodPost, "/api/core/password-reset/request", func(w http.ResponseWriter, r *http.Request) {
+		var req passwordResetRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Email == "linked@example.com" {
+			writeJSON(w, http.StatusOK, map[string]string{"discord_id": "123", "message": "reset link"})
+			return
+		}
+		// Unknown accounts get an empty delivery.
+		writeJSON(w, http.StatusOK, map[string]string{})
+	})
+	handleCore(t, http.MethodPost, "/api/discord/direct-messages", respondCore(http.StatusBadGateway, map[string]string{"error": "discord is down"}))
+
+	for _, email := range []string{"linked@example.com", "nobody@example.com"} {
+		body, _ := json.Marshal(passwordResetRequest{Email: email, Channel: "DISCORD"})
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/auth/password-reset", bytes.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		RequestPasswordReset(c)
+
+		var resp struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusOK || resp.Message != passwordResetRequestedMessage {
+			t.Errorf("%s: RequestPasswordReset = %d %s, want the usual 200", email, w.Code, w.Body.String())
+		}
+	}
+}
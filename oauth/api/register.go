@@ -0,0 +1,187 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gaucho-racing/sentinel/oauth/config"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/logger"
+	"github.com/gaucho-racing/sentinel/oauth/service"
+	"github.com/gin-gonic/gin"
+)
+
+// registrationEndpointURL is the RFC 7591 registration endpoint as
+// advertised in discovery. Each client's configuration endpoint (RFC 7592)
+// is beneath it.
+func registrationEndpointURL() string {
+	return config.Issuer + "/api/oauth/register"
+}
+
+// clientInformationResponse is the RFC 7591 client information response,
+// also returned by the RFC 7592 read and update. client_secret is only
+// present when the client is registered; only its hash is kept after that.
+// grant_types and response_types list what every client may use.
+type clientInformationResponse struct {
+	ClientID                string   `json:"client_id"`
+	ClientSecret            string   `json:"client_secret,omitempty"`
+	ClientIDIssuedAt        int64    `json:"client_id_issued_at"`
+	ClientSecretExpiresAt   *int64   `json:"client_secret_expires_at,omitempty"`
+	RegistrationAccessToken string   `json:"registration_access_token"`
+	RegistrationClientURI   string   `json:"registration_client_uri"`
+	ClientName              string   `json:"client_name"`
+	ClientURI               string   `json:"client_uri,omitempty"`
+	LogoURI                 string   `json:"logo_uri,omitempty"`
+	RedirectURIs            []string `json:"redirect_uris"`
+	GrantTypes              []string `json:"grant_types"`
+	ResponseTypes           []string `json:"response_types"`
+	TokenEndpointAuthMethod string   `json:"token_endpoint_auth_method"`
+	JWKS                    any      `json:"jwks,omitempty"`
+	JWKSURI                 string   `json:"jwks_uri,omitempty"`
+	Scope                   string   `json:"scope,omitempty"`
+	// PendingApproval isn't RFC 7591 metadata: while it's true the client
+	// may only request openid, profile, and email.
+	PendingApproval bool `json:"pending_approval"`
+}
+
+func newClientInformationResponse(app service.RegisteredApplication, registrationAccessToken string) clientInformationResponse {
+	resp := clientInformationResponse{
+		ClientID:                app.ClientID,
+		ClientIDIssuedAt:        app.CreatedAt.Unix(),
+		RegistrationAccessToken: registrationAccessToken,
+		RegistrationClientURI:   registrationEndpointURL() + "/" + app.ClientID,
+		ClientName:              app.Name,
+		ClientURI:               app.LaunchURL,
+		LogoURI:                 app.IconURL,
+		RedirectURIs:            app.RedirectURIs,
+		GrantTypes:              []string{"authorization_code", "refresh_token", DeviceCodeGrantType, TokenExchangeGrantType},
+		ResponseTypes:           []string{"code"},
+		TokenEndpointAuthMethod: app.TokenEndpointAuthMethod,
+		JWKSURI:                 app.JWKSURI,
+		Scope:                   strings.Join(app.AllowedScopes, " "),
+		PendingApproval:         app.PendingApproval,
+	}
+	if len(app.JWKS) > 0 && string(app.JWKS) != "null" {
+		resp.JWKS = app.JWKS
+	}
+	if resp.RedirectURIs == nil {
+		resp.RedirectURIs = []string{}
+	}
+	return resp
+}
+
+// RegisterClient is the RFC 7591 dynamic client registration endpoint. The
+// caller needs an initial access token from an admin as its bearer token.
+// New clients are owned by that admin, start pending approval, and get a
+// registration access token for managing themselves through
+// registration_client_uri.
+func RegisterClient(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	token, ok := registrationBearer(c)
+	if !ok {
+		return
+	}
+	var metadata service.ClientMetadata
+	if err := c.ShouldBindJSON(&metadata); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_client_metadata", "error_description": err.Error()})
+		return
+	}
+	registered, err := service.RegisterClient(token, metadata)
+	if err != nil {
+		writeClientRegistrationError(c, err)
+		return
+	}
+	resp := newClientInformationResponse(registered.Application, registered.RegistrationAccessToken)
+	if registered.ClientSecret != "" {
+		resp.ClientSecret = registered.ClientSecret
+		// Secrets from registration don't expire.
+		var never int64
+		resp.ClientSecretExpiresAt = &never
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetClientConfiguration is the RFC 7592 read of a registered client.
+func GetClientConfiguration(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	token, ok := registrationBearer(c)
+	if !ok {
+		return
+	}
+	app, err := service.GetRegisteredClient(c.Param("clientID"), token)
+	if err != nil {
+		writeClientRegistrationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newClientInformationResponse(app, token))
+}
+
+type clientConfigurationUpdate struct {
+	service.ClientMetadata
+	ClientID string `json:"client_id"`
+}
+
+// UpdateClientConfiguration is the RFC 7592 update. The body replaces the
+// client's metadata wholesale and must name the client in client_id.
+func UpdateClientConfiguration(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	token, ok := registrationBearer(c)
+	if !ok {
+		return
+	}
+	var req clientConfigurationUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_client_metadata", "error_description": err.Error()})
+		return
+	}
+	if req.ClientID != c.Param("clientID") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_client_metadata", "error_description": "client_id must match the client being updated"})
+		return
+	}
+	app, err := service.UpdateRegisteredClient(c.Param("clientID"), token, req.ClientMetadata)
+	if err != nil {
+		writeClientRegistrationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newClientInformationResponse(app, token))
+}
+
+// DeleteClientConfiguration is the RFC 7592 delete. The client's tokens
+// stop working as soon as it's gone.
+func DeleteClientConfiguration(c *gin.Context) {
+	token, ok := registrationBearer(c)
+	if !ok {
+		return
+	}
+	if err := service.DeleteRegisteredClient(c.Param("clientID"), token); err != nil {
+		writeClientRegistrationError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// registrationBearer returns the bearer token of a registration request,
+// or writes a 401 and returns false when there isn't one.
+func registrationBearer(c *gin.Context) (string, bool) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token", "error_description": "missing bearer token"})
+		return "", false
+	}
+	return token, true
+}
+
+func writeClientRegistrationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidClientMetadata):
+		code, description := service.ClientRegistrationErrorCode(err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": code, "error_description": description})
+	case errors.Is(err, service.ErrInvalidRegistrationToken):
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+	default:
+		logger.SugarLogger.Errorf("Client registration failed: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
+	}
+}
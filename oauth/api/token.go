@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -17,6 +18,8 @@ type tokenRequest struct {
 	Scope     string                 `json:"scope"`
 	ExpiresIn int                    `json:"expires_in"`
 	Claims    map[string]interface{} `json:"claims"`
+	Audience  []string               `json:"audience,omitempty"`
+	FamilyID  string                 `json:"family_id,omitempty"`
 }
 
 type tokenResponse struct {
@@ -33,8 +36,12 @@ type exchangeTokenResponse struct {
 	Scope        string `json:"scope"`
 }
 
+// DeviceCodeGrantType is the RFC 8628 grant_type for device polling.
+const DeviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
 // ExchangeToken handles the OAuth token exchange.
-// Supports grant_type=authorization_code and grant_type=refresh_token.
+// Supports grant_type=authorization_code, refresh_token, the device code
+// grant, and token exchange.
 func ExchangeToken(c *gin.Context) {
 	grantType := c.PostForm("grant_type")
 	switch grantType {
@@ -42,6 +49,10 @@ func ExchangeToken(c *gin.Context) {
 		handleAuthorizationCodeExchange(c)
 	case "refresh_token":
 		handleRefreshTokenExchange(c)
+	case DeviceCodeGrantType:
+		handleDeviceCodeExchange(c)
+	case TokenExchangeGrantType:
+		handleTokenExchange(c)
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported grant_type"})
 	}
@@ -54,32 +65,14 @@ func handleAuthorizationCodeExchange(c *gin.Context) {
 		return
 	}
 
-	clientID, clientSecret, hasAuth := c.Request.BasicAuth()
-	if !hasAuth {
-		clientID = c.PostForm("client_id")
-		clientSecret = c.PostForm("client_secret")
-	}
-	if clientID == "" || clientSecret == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "client credentials are required"})
-		return
-	}
-
 	redirectURI := c.PostForm("redirect_uri")
 	if redirectURI == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "redirect_uri is required"})
 		return
 	}
 
-	// Validate client credentials via core
-	var app applicationResponse
-	err := sentinel.Get("/api/applications/client/"+clientID, &app)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid client credentials"})
-		return
-	}
-	// We need the secret to validate — fetch via internal endpoint
-	if !validateClientSecret(clientID, clientSecret) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid client credentials"})
+	clientID, ok := authenticateClient(c, tokenEndpointURL())
+	if !ok {
 		return
 	}
 
@@ -103,48 +96,79 @@ func handleAuthorizationCodeExchange(c *gin.Context) {
 		return
 	}
 
-	claims, err := service.BuildTokenClaims(authCode.EntityID, clientID, authCode.Scope)
+	issueTokens(c, authCode.EntityID, clientID, authCode.Scope, authCode.Nonce, service.SplitAMR(authCode.AMR), authCode.CreatedAt.Unix())
+}
+
+// issueTokens starts a refresh token family for a grant the user has just
+// completed and issues its first tokens. authTime is when the user approved.
+func issueTokens(c *gin.Context, entityID, clientID, scope, nonce string, amr []string, authTime int64) {
+	policy, err := service.GetTokenPolicy(clientID)
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to load token policy: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
+		return
+	}
+	family, err := service.StartTokenFamily(entityID, clientID, policy)
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to start token family: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
+		return
+	}
+	issueFamilyTokens(c, policy, family, entityID, clientID, scope, nonce, amr, authTime)
+}
+
+// issueFamilyTokens mints the access token, a refresh token, and (for
+// openid) an ID token into family, records the login, and writes the token
+// response. Lifetimes come from the client's token policy; the refresh
+// token never outlives the family, and is left out entirely when the
+// policy doesn't allow offline access.
+func issueFamilyTokens(c *gin.Context, policy service.TokenPolicy, family service.TokenFamily, entityID, clientID, scope, nonce string, amr []string, authTime int64) {
+	claims, err := service.BuildTokenClaims(entityID, clientID, scope)
 	if err != nil {
 		logger.SugarLogger.Errorf("Failed to build token claims: %v", err)
 		c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
 		return
 	}
+	service.SetAMRClaim(claims, amr)
 
 	// Generate access token via core
-	accessToken, accessTokenID, err := generateToken(authCode.EntityID, clientID, authCode.Scope, config.AccessTokenTTL, claims)
+	accessToken, accessTokenID, err := generateAccessToken(family.ID, entityID, clientID, scope, policy.AccessTTL(), claims)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate access token"})
 		return
 	}
 
 	// Generate refresh token via core
-	refreshToken, refreshTokenID, err := generateToken(authCode.EntityID, clientID, authCode.Scope+" refresh_token", config.RefreshTokenTTL, claims)
-	if err != nil {
-		logger.SugarLogger.Errorf("Failed to generate refresh token: %v", err)
-		refreshToken = ""
-		refreshTokenID = ""
+	var refreshToken, refreshTokenID string
+	if policy.AllowOfflineAccess {
+		refreshToken, refreshTokenID, err = generateFamilyToken(family.ID, entityID, clientID, scope+" refresh_token", service.RefreshTokenTTL(family, policy), claims)
+		if err != nil {
+			logger.SugarLogger.Errorf("Failed to generate refresh token: %v", err)
+			refreshToken = ""
+			refreshTokenID = ""
+		}
 	}
 
 	sentinel.Post("/api/core/entity/logins", map[string]string{
-		"entity_id":        authCode.EntityID,
+		"entity_id":        entityID,
 		"client_id":        clientID,
-		"scope":            authCode.Scope,
+		"scope":            scope,
 		"access_token_id":  accessTokenID,
 		"refresh_token_id": refreshTokenID,
 		"ip_address":       GetClientIP(c),
 	}, nil)
 
-	// OIDC: issue an ID token when the openid scope was granted. auth_time is
-	// the moment the user approved consent (when the code was minted).
+	// OIDC: issue an ID token when the openid scope was granted.
 	var idToken string
-	if service.ScopesContain(authCode.Scope, "openid") {
-		idClaims, idErr := service.BuildIDTokenClaims(authCode.EntityID, clientID, authCode.Scope, authCode.Nonce, accessToken, authCode.CreatedAt.Unix())
+	if service.ScopesContain(scope, "openid") {
+		idClaims, idErr := service.BuildIDTokenClaims(entityID, clientID, scope, nonce, accessToken, authTime)
 		if idErr != nil {
 			logger.SugarLogger.Errorf("Failed to build id token claims: %v", idErr)
 			c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
 			return
 		}
-		idToken, _, err = generateToken(authCode.EntityID, clientID, authCode.Scope, config.AccessTokenTTL, idClaims)
+		service.SetAMRClaim(idClaims, amr)
+		idToken, _, err = generateFamilyToken(family.ID, entityID, clientID, scope, policy.IDTTL(), idClaims)
 		if err != nil {
 			logger.SugarLogger.Errorf("Failed to generate id token: %v", err)
 			idToken = ""
@@ -156,11 +180,14 @@ func handleAuthorizationCodeExchange(c *gin.Context) {
 		RefreshToken: refreshToken,
 		IDToken:      idToken,
 		TokenType:    "Bearer",
-		ExpiresIn:    config.AccessTokenTTL,
-		Scope:        authCode.Scope,
+		ExpiresIn:    policy.AccessTTL(),
+		Scope:        scope,
 	})
 }
 
+// handleRefreshTokenExchange rotates the presented refresh token within its
+// family. Replaying a token that was already rotated revokes the family,
+// so a leaked token stops working for whoever holds it too.
 func handleRefreshTokenExchange(c *gin.Context) {
 	refreshToken := c.PostForm("refresh_token")
 	if refreshToken == "" {
@@ -168,41 +195,40 @@ func handleRefreshTokenExchange(c *gin.Context) {
 		return
 	}
 
-	clientID, clientSecret, hasAuth := c.Request.BasicAuth()
-	if !hasAuth {
-		clientID = c.PostForm("client_id")
-		clientSecret = c.PostForm("client_secret")
-	}
-	if clientID == "" || clientSecret == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "client credentials are required"})
+	clientID, ok := authenticateClient(c, tokenEndpointURL())
+	if !ok {
 		return
 	}
 
-	if !validateClientSecret(clientID, clientSecret) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid client credentials"})
+	// Checked before rotating, so a client whose policy has since turned
+	// offline access off doesn't use up the token for nothing.
+	policy, err := service.GetTokenPolicy(clientID)
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to load token policy: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
 		return
 	}
-
-	// Validate the refresh token via core
-	var claims map[string]interface{}
-	err := sentinel.Post("/api/core/token/validate", map[string]string{"token": refreshToken}, &claims)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired refresh token"})
+	if !policy.AllowOfflineAccess {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh tokens are not allowed for this client"})
 		return
 	}
 
-	entityID, _ := claims["sub"].(string)
-	scope, _ := claims["scope"].(string)
-
-	if !service.ScopesContain(scope, "refresh_token") {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "provided token is not a refresh token"})
+	rotated, err := service.RotateRefreshToken(refreshToken, clientID, GetClientIP(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRefreshToken), errors.Is(err, service.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrAccountInactive):
+			writeGateError(c, err)
+		default:
+			logger.SugarLogger.Errorf("Failed to rotate refresh token: %v", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
+		}
 		return
 	}
 
-	// Revoke the old refresh token
-	if tokenID, ok := claims["jti"].(string); ok {
-		sentinel.Delete("/api/core/token/"+tokenID, nil)
-	}
+	entityID, _ := rotated.Claims["sub"].(string)
+	scope, _ := rotated.Claims["scope"].(string)
 
 	// Re-check the gate on refresh — group membership may have changed
 	// since the original grant. If the user no longer qualifies, the
@@ -213,70 +239,35 @@ func handleRefreshTokenExchange(c *gin.Context) {
 		return
 	}
 
-	// Strip refresh_token from scope for the access token
-	accessScope := service.RemoveScope(scope, "refresh_token")
-
-	newClaims, err := service.BuildTokenClaims(entityID, clientID, accessScope)
-	if err != nil {
-		logger.SugarLogger.Errorf("Failed to build token claims: %v", err)
+	// Tokens from before families existed start one here.
+	var family service.TokenFamily
+	if rotated.Family != nil {
+		family = *rotated.Family
+	} else if family, err = service.StartTokenFamily(entityID, clientID, policy); err != nil {
+		logger.SugarLogger.Errorf("Failed to start token family: %v", err)
 		c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
 		return
 	}
 
-	// Generate new access token
-	accessToken, accessTokenID, err := generateToken(entityID, clientID, accessScope, config.AccessTokenTTL, newClaims)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate access token"})
-		return
-	}
-
-	// Generate new refresh token (keep refresh_token in scope)
-	newRefreshToken, newRefreshTokenID, err := generateToken(entityID, clientID, scope, config.RefreshTokenTTL, newClaims)
-	if err != nil {
-		logger.SugarLogger.Errorf("Failed to generate refresh token: %v", err)
-		newRefreshToken = ""
-		newRefreshTokenID = ""
-	}
-
-	sentinel.Post("/api/core/entity/logins", map[string]string{
-		"entity_id":        entityID,
-		"client_id":        clientID,
-		"scope":            accessScope,
-		"access_token_id":  accessTokenID,
-		"refresh_token_id": newRefreshTokenID,
-		"ip_address":       GetClientIP(c),
-	}, nil)
-
-	// OIDC: re-issue an ID token on refresh when openid is still in scope. The
-	// original nonce isn't replayed on refresh (per spec), and auth_time
-	// reflects this refresh since the original authentication time isn't
-	// carried forward.
-	var idToken string
-	if service.ScopesContain(accessScope, "openid") {
-		idClaims, idErr := service.BuildIDTokenClaims(entityID, clientID, accessScope, "", accessToken, time.Now().Unix())
-		if idErr != nil {
-			logger.SugarLogger.Errorf("Failed to build id token claims: %v", idErr)
-			c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
-			return
-		}
-		idToken, _, err = generateToken(entityID, clientID, accessScope, config.AccessTokenTTL, idClaims)
-		if err != nil {
-			logger.SugarLogger.Errorf("Failed to generate id token: %v", err)
-			idToken = ""
-		}
-	}
+	// Strip refresh_token from scope for the access token. amr describes
+	// the original authentication, which a refresh doesn't repeat, so it's
+	// carried over from the refresh token unchanged. The original nonce
+	// isn't replayed on refresh (per spec), and auth_time reflects this
+	// refresh since the original authentication time isn't carried forward.
+	accessScope := service.RemoveScope(scope, "refresh_token")
+	amr := service.AMRFromClaims(rotated.Claims)
+	issueFamilyTokens(c, policy, family, entityID, clientID, accessScope, "", amr, time.Now().Unix())
+}
 
-	c.JSON(http.StatusOK, exchangeTokenResponse{
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshToken,
-		IDToken:      idToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    config.AccessTokenTTL,
-		Scope:        accessScope,
-	})
+// generateToken mints a token via core. aud is clientID plus any
+// resourceServers.
+func generateToken(entityID string, clientID string, scope string, expiresIn int, claims map[string]interface{}, resourceServers ...string) (string, string, error) {
+	return generateFamilyToken("", entityID, clientID, scope, expiresIn, claims, resourceServers...)
 }
 
-func generateToken(entityID string, clientID string, scope string, expiresIn int, claims map[string]interface{}) (string, string, error) {
+// generateFamilyToken is generateToken for a token in a refresh token
+// family, so revoking the family revokes it too.
+func generateFamilyToken(familyID string, entityID string, clientID string, scope string, expiresIn int, claims map[string]interface{}, resourceServers ...string) (string, string, error) {
 	var result tokenResponse
 	err := sentinel.Post("/api/core/token", tokenRequest{
 		EntityID:  entityID,
@@ -284,6 +275,8 @@ func generateToken(entityID string, clientID string, scope string, expiresIn int
 		Scope:     scope,
 		ExpiresIn: expiresIn,
 		Claims:    claims,
+		Audience:  resourceServers,
+		FamilyID:  familyID,
 	}, &result)
 	if err != nil {
 		return "", "", err
@@ -291,6 +284,67 @@ func generateToken(entityID string, clientID string, scope string, expiresIn int
 	return result.Token, result.TokenID, nil
 }
 
+// generateAccessToken is generateFamilyToken for access tokens: the
+// resource servers behind any granted application scopes are added to aud,
+// so their APIs can tell the token is meant for them. familyID is empty for
+// tokens outside any family.
+func generateAccessToken(familyID string, entityID string, clientID string, scope string, expiresIn int, claims map[string]interface{}) (string, string, error) {
+	audiences, err := service.ResourceAudiences(scope)
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to resolve token audiences: %v", err)
+		return "", "", err
+	}
+	return generateFamilyToken(familyID, entityID, clientID, scope, expiresIn, claims, audiences...)
+}
+
+// authenticateClient identifies the client on a token-style endpoint, by
+// client_secret (HTTP Basic or form) or by a private_key_jwt assertion
+// (RFC 7523). endpoint is this endpoint's public URL, which assertions may
+// use as their aud. Writes the error response and returns false on failure.
+func authenticateClient(c *gin.Context, endpoint string) (string, bool) {
+	if assertion := c.PostForm("client_assertion"); assertion != "" {
+		var result struct {
+			ClientID string `json:"client_id"`
+		}
+		err := sentinel.Post("/api/core/applications/verify-assertion", map[string]string{
+			"client_assertion":      assertion,
+			"client_assertion_type": c.PostForm("client_assertion_type"),
+			"endpoint":              endpoint,
+		}, &result)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid client credentials"})
+			return "", false
+		}
+		// client_id is optional alongside an assertion, but must agree
+		// with it when sent.
+		if id := c.PostForm("client_id"); id != "" && id != result.ClientID {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid client credentials"})
+			return "", false
+		}
+		return result.ClientID, true
+	}
+
+	clientID, clientSecret, hasAuth := c.Request.BasicAuth()
+	if !hasAuth {
+		clientID = c.PostForm("client_id")
+		clientSecret = c.PostForm("client_secret")
+	}
+	if clientID == "" || clientSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "client credentials are required"})
+		return "", false
+	}
+	if !validateClientSecret(clientID, clientSecret) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid client credentials"})
+		return "", false
+	}
+	return clientID, true
+}
+
+// tokenEndpointURL is the token endpoint as advertised in discovery.
+func tokenEndpointURL() string {
+	return config.Issuer + "/api/oauth/token"
+}
+
 func validateClientSecret(clientID string, clientSecret string) bool {
 	var result map[string]interface{}
 	err := sentinel.Post("/api/core/applications/verify", map[string]string{
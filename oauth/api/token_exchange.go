@@ -0,0 +1,180 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/oauth/config"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/logger"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
+	"github.com/gaucho-racing/sentinel/oauth/service"
+	"github.com/gin-gonic/gin"
+)
+
+// TokenExchangeGrantType is the RFC 8693 grant_type.
+const TokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+type tokenExchangeResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int    `json:"expires_in"`
+	Scope           string `json:"scope"`
+}
+
+func isAccessTokenType(tokenType string) bool {
+	return tokenType == service.TokenTypeAccessToken || tokenType == service.TokenTypeJWT
+}
+
+// handleTokenExchange lets an internal service act for a user. The service
+// sends the user's access token as subject_token and its own
+// service-account bearer as actor_token — that bearer is its credential
+// here, as it is everywhere else. The subject token must have been issued
+// to the service's own client, and the audience must be that client or on
+// its application's token_exchange_audiences. The result is a short-lived
+// token for the user, limited to scope and audience, whose act claim names
+// the service.
+func handleTokenExchange(c *gin.Context) {
+	subjectToken := c.PostForm("subject_token")
+	actorToken := c.PostForm("actor_token")
+	if subjectToken == "" || actorToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "subject_token and actor_token are required"})
+		return
+	}
+	if !isAccessTokenType(c.PostForm("subject_token_type")) || !isAccessTokenType(c.PostForm("actor_token_type")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "only access tokens can be exchanged"})
+		return
+	}
+	if requested := c.PostForm("requested_token_type"); requested != "" && requested != service.TokenTypeAccessToken {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "only access tokens can be issued"})
+		return
+	}
+
+	var actorClaims map[string]interface{}
+	if err := sentinel.Post("/api/core/token/validate", map[string]string{"token": actorToken}, &actorClaims); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client", "error_description": "invalid actor_token"})
+		return
+	}
+	if tokenType, _ := actorClaims["type"].(string); tokenType != "service_account" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_client", "error_description": "actor_token must belong to a service account"})
+		return
+	}
+
+	var subjectClaims map[string]interface{}
+	if err := sentinel.Post("/api/core/token/validate", map[string]string{"token": subjectToken}, &subjectClaims); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "invalid or expired subject_token"})
+		return
+	}
+	entityID, _ := subjectClaims["sub"].(string)
+	subjectScope, _ := subjectClaims["scope"].(string)
+	if service.ScopesContain(subjectScope, "refresh_token") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "refresh tokens can't be exchanged"})
+		return
+	}
+	// ID tokens are routinely handed to front ends, so holding one mustn't
+	// be enough to get an access token.
+	if service.IsIDToken(subjectClaims) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "ID tokens can't be exchanged"})
+		return
+	}
+
+	// The audience defaults to the one the subject token was issued for.
+	audience := c.PostForm("audience")
+	if audience == "" {
+		if aud := service.ClaimAudiences(subjectClaims); len(aud) > 0 {
+			audience = aud[0]
+		}
+	}
+	var app applicationResponse
+	if audience == "" || sentinel.Get("/api/applications/client/"+audience, &app) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_target"})
+		return
+	}
+
+	var actorClientID string
+	if aud := service.ClaimAudiences(actorClaims); len(aud) > 0 {
+		actorClientID = aud[0]
+	}
+	var actorApp applicationResponse
+	if actorClientID == "" || sentinel.Get("/api/applications/client/"+actorClientID, &actorApp) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unauthorized_client", "error_description": "actor_token's application not found"})
+		return
+	}
+	if err := service.AuthorizeExchange(actorClientID, actorApp.TokenExchangeAudiences, subjectClaims, audience); err != nil {
+		code := "invalid_target"
+		if errors.Is(err, service.ErrExchangeSubject) {
+			code = "unauthorized_client"
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": code, "error_description": err.Error()})
+		return
+	}
+
+	scope, err := service.ExchangeScope(c.PostForm("scope"), subjectScope)
+	if err == nil {
+		_, err = service.ResolveClientScopes(audience, scope)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrExchangeScope) || errors.Is(err, service.ErrInvalidScope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_scope", "error_description": err.Error()})
+			return
+		}
+		logger.SugarLogger.Errorf("Failed to resolve exchange scope: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
+		return
+	}
+
+	// The user has to be allowed into the target app themselves; acting
+	// through a service doesn't get around its gate.
+	if err := service.CheckAccessGate(entityID, audience); err != nil {
+		writeGateError(c, err)
+		return
+	}
+
+	claims, err := service.BuildTokenClaims(entityID, audience, scope)
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to build token claims: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "server_error"})
+		return
+	}
+	service.SetAMRClaim(claims, service.AMRFromClaims(subjectClaims))
+	act := service.BuildActClaim(actorClaims, subjectClaims)
+	claims["act"] = act
+
+	// Never outlive the token being exchanged.
+	expiresIn := config.ExchangedTokenTTL
+	if exp, ok := subjectClaims["exp"].(float64); ok {
+		if remaining := int(time.Until(time.Unix(int64(exp), 0)).Seconds()); remaining < expiresIn {
+			expiresIn = remaining
+		}
+	}
+	if expiresIn <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_grant", "error_description": "invalid or expired subject_token"})
+		return
+	}
+
+	accessToken, accessTokenID, err := generateAccessToken("", entityID, audience, scope, expiresIn, claims)
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to generate exchanged token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate access token"})
+		return
+	}
+
+	actorID, _ := act["sub"].(string)
+	sentinel.Post("/api/core/entity/logins", map[string]string{
+		"entity_id":       entityID,
+		"client_id":       audience,
+		"scope":           scope,
+		"access_token_id": accessTokenID,
+		"ip_address":      GetClientIP(c),
+		"actor_id":        actorID,
+	}, nil)
+
+	c.JSON(http.StatusOK, tokenExchangeResponse{
+		AccessToken:     accessToken,
+		IssuedTokenType: service.TokenTypeAccessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       expiresIn,
+		Scope:           scope,
+	})
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gaucho-racing/sentinel/oauth/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTokenExchangeRejectsIDTokens(t *testing.T) {
+	serveSessions(t, map[string]map[string]interface{}{
+		"actor":        {"sub": "sa_worker", "type": "service_account", "aud": "worker"},
+		"access-token": {"sub": "ent_alice", "scope": "openid profile", "aud": "worker"},
+		"id-token":     {"sub": "ent_alice", "scope": "openid profile", "aud": "worker", "token_use": "id", "at_hash": "abc"},
+		"old-id-token": {"sub": "ent_alice", "scope": "openid profile", "aud": "worker", "at_hash": "abc"},
+	})
+
+	tests := []struct {
+		subject     string
+		wantError   string
+		wantMessage string
+	}{
+		{"id-token", "invalid_grant", "ID tokens can't be exchanged"},
+		{"old-id-token", "invalid_grant", "ID tokens can't be exchanged"},
+		// An access token gets past the check, and fails later only because
+		// the fake core knows no applications.
+		{"access-token", "invalid_target", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			form := url.Values{
+				"grant_type":         {TokenExchangeGrantType},
+				"subject_token":      {tt.subject},
+				"subject_token_type": {service.TokenTypeAccessToken},
+				"actor_token":        {"actor"},
+				"actor_token_type":   {service.TokenTypeAccessToken},
+			}
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+			c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			handleTokenExchange(c)
+
+			var body struct {
+				Error       string `json:"error"`
+				Description string `json:"error_description"`
+			}
+			_ = json.Unmarshal(w.Body.Bytes(), &body)
+			if w.Code != http.StatusBadRequest || body.Error != tt.wantError ||
+				(tt.wantMessage != "" && body.Description != tt.wantMessage) {
+				t.Errorf("handleTokenExchange = %d %s, want 400 %s", w.Code, w.Body.String(), tt.wantError)
+			}
+		})
+	}
+}
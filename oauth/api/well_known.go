@@ -5,7 +5,8 @@ import (
 	"sort"
 
 	"github.com/gaucho-racing/sentinel/oauth/config"
-	"github.com/gaucho-racing/sentinel/oauth/model"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/logger"
+	"github.com/gaucho-racing/sentinel/oauth/service"
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,38 +14,61 @@ import (
 // derived from the configured issuer (the public base URL). The browser-facing
 // authorization endpoint is the SPA consent route (no /api prefix); the
 // token/userinfo endpoints are backend routes behind the gateway's /api prefix;
-// the JWKS lives on core.
+// the JWKS lives on core. request_uri is only accepted as minted by the PAR
+// endpoint, hence request_uri_parameter_supported is false; requiring PAR is
+// a per-application setting, so it's false here too. Optional claims are
+// also per application (see service.TokenPolicy), so claims_supported lists
+// every claim a token policy can include.
 func OpenIDConfiguration(c *gin.Context) {
 	issuer := config.Issuer
 	c.JSON(http.StatusOK, gin.H{
 		"issuer":                                issuer,
 		"authorization_endpoint":                issuer + "/oauth/authorize",
-		"token_endpoint":                        issuer + "/api/oauth/token",
+		"token_endpoint":                        tokenEndpointURL(),
 		"userinfo_endpoint":                     issuer + "/api/oauth/userinfo",
+		"device_authorization_endpoint":         issuer + "/api/oauth/device_authorization",
 		"jwks_uri":                              issuer + "/api/core/keys",
 		"response_types_supported":              []string{"code"},
-		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token", DeviceCodeGrantType, TokenExchangeGrantType},
 		"subject_types_supported":               []string{"public"},
 		"id_token_signing_alg_values_supported": []string{"RS256"},
-		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
-		"scopes_supported":                      supportedScopes(),
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "private_key_jwt"},
+		"token_endpoint_auth_signing_alg_values_supported": []string{
+			"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512",
+		},
+		"pushed_authorization_request_endpoint": pushedAuthorizationEndpointURL(),
+		"require_pushed_authorization_requests": false,
+		"request_parameter_supported":           true,
+		"request_uri_parameter_supported":       false,
+		"request_object_signing_alg_values_supported": []string{
+			"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512",
+		},
+		"registration_endpoint": registrationEndpointURL(),
+		"scopes_supported":      supportedScopes(),
 		"claims_supported": []string{
-			"sub", "iss", "aud", "exp", "iat", "jti", "auth_time", "nonce", "at_hash",
+			"sub", "iss", "aud", "exp", "iat", "jti", "auth_time", "nonce", "at_hash", "amr", "token_use",
 			"name", "given_name", "family_name", "preferred_username", "picture",
 			"email", "email_verified",
+			"groups", "group_ids", "roles",
 		},
 	})
 }
 
-// supportedScopes lists the scopes a third-party client may request — every
-// valid scope except the reserved first-party sentinel:all.
+// supportedScopes lists the scopes a client may be allowed to request:
+// the builtins except the reserved first-party sentinel:all, plus every
+// scope an application has registered. nil if the registry can't be read.
 func supportedScopes() []string {
-	scopes := make([]string, 0, len(model.ValidScopes))
-	for scope := range model.ValidScopes {
-		if scope == "sentinel:all" {
+	registry, err := service.ListScopes()
+	if err != nil {
+		logger.SugarLogger.Errorf("Failed to list scopes for discovery: %v", err)
+		return nil
+	}
+	scopes := make([]string, 0, len(registry))
+	for _, info := range registry {
+		if info.Name == "sentinel:all" {
 			continue
 		}
-		scopes = append(scopes, scope)
+		scopes = append(scopes, info.Name)
 	}
 	sort.Strings(scopes)
 	return scopes
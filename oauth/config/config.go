@@ -27,8 +27,17 @@ var KerbecsPassword = os.Getenv("KERBECS_PASSWORD")
 // byte-identical for relying parties to accept the token.
 var Issuer = os.Getenv("ISSUER")
 
+// Default token lifetimes in seconds. An application's token policy can
+// override each. RefreshTokenTTL is the idle lifetime of a refresh token;
+// RefreshTokenAbsoluteTTL bounds its family, however often it's refreshed.
 var AccessTokenTTL int
+var IDTokenTTL int
 var RefreshTokenTTL int
+var RefreshTokenAbsoluteTTL int
+
+// ExchangedTokenTTL caps the lifetime of tokens minted by token exchange.
+// They're for one service-to-service hop, so they stay short.
+var ExchangedTokenTTL int
 
 // SentinelClientID is the first-party identifier used by /auth/login and
 // /auth/refresh when minting tokens. Direct-login tokens carry this as
@@ -38,12 +47,6 @@ var RefreshTokenTTL int
 // to every OAuth app's filter set.
 const SentinelClientID = "sentinel"
 
-// TeamGoogleClientID is the client_id of the shared Google Workspace account
-// application (team@gauchoracing.com). Identity-override lookups key on it, so
-// it's read from the environment to stay correct across deployments. When
-// unset, no override is registered for it.
-var TeamGoogleClientID = os.Getenv("TEAM_GOOGLE_CLIENT_ID")
-
 var DatabaseHost = os.Getenv("DATABASE_HOST")
 var DatabasePort = os.Getenv("DATABASE_PORT")
 var DatabaseUser = os.Getenv("DATABASE_USER")
@@ -69,6 +72,29 @@ var DiscordClientID     = os.Getenv("DISCORD_CLIENT_ID")
 var DiscordClientSecret = os.Getenv("DISCORD_CLIENT_SECRET")
 var DiscordRedirectURI  = os.Getenv("DISCORD_REDIRECT_URI")
 
+// GitHub and Google OAuth for "Continue with GitHub/Google" and for linking
+// those accounts from settings. Same byte-match rule as Discord: each
+// redirect URI must equal the one the web sent to the provider, which is its
+// <origin>/auth/login/github or /auth/login/google callback route. Both the
+// login and the connect flow use that one callback.
+var GitHubClientID     = os.Getenv("GITHUB_CLIENT_ID")
+var GitHubClientSecret = os.Getenv("GITHUB_CLIENT_SECRET")
+var GitHubRedirectURI  = os.Getenv("GITHUB_REDIRECT_URI")
+
+var GoogleClientID     = os.Getenv("GOOGLE_CLIENT_ID")
+var GoogleClientSecret = os.Getenv("GOOGLE_CLIENT_SECRET")
+var GoogleRedirectURI  = os.Getenv("GOOGLE_REDIRECT_URI")
+
+// WebAuthn relying party for passkeys. The RP ID is the domain credentials
+// are scoped to; changing it orphans every registered passkey, so it should
+// be the stable registrable domain, not a per-deploy hostname. Origins is a
+// comma-separated list of web origins allowed to run the ceremonies. Both
+// default from ISSUER.
+var WebAuthnRPID = os.Getenv("WEBAUTHN_RP_ID")
+var WebAuthnRPOrigins = os.Getenv("WEBAUTHN_RP_ORIGINS")
+
+const WebAuthnRPDisplayName = "Sentinel"
+
 func IsProduction() bool {
 	return Env == "PROD"
 }
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"net/url"
 	"os"
 	"strconv"
 
@@ -52,8 +53,25 @@ func Verify() {
 		KerbecsPassword = "admin"
 		logger.SugarLogger.Infoln("KERBECS_PASSWORD is not set, defaulting to \"admin\" — DO NOT USE IN PRODUCTION")
 	}
+	if WebAuthnRPID == "" || WebAuthnRPOrigins == "" {
+		issuerURL, err := url.Parse(Issuer)
+		if err != nil {
+			logger.SugarLogger.Fatalf("ISSUER is not a valid URL: %v", err)
+		}
+		if WebAuthnRPID == "" {
+			WebAuthnRPID = issuerURL.Hostname()
+			logger.SugarLogger.Infof("WEBAUTHN_RP_ID is not set, defaulting to %s", WebAuthnRPID)
+		}
+		if WebAuthnRPOrigins == "" {
+			WebAuthnRPOrigins = issuerURL.Scheme + "://" + issuerURL.Host
+			logger.SugarLogger.Infof("WEBAUTHN_RP_ORIGINS is not set, defaulting to %s", WebAuthnRPOrigins)
+		}
+	}
 	AccessTokenTTL = parseIntEnv("ACCESS_TOKEN_TTL", 30*60)
+	IDTokenTTL = parseIntEnv("ID_TOKEN_TTL", AccessTokenTTL)
 	RefreshTokenTTL = parseIntEnv("REFRESH_TOKEN_TTL", 7*24*60*60)
+	RefreshTokenAbsoluteTTL = parseIntEnv("REFRESH_TOKEN_ABSOLUTE_TTL", 30*24*60*60)
+	ExchangedTokenTTL = parseIntEnv("EXCHANGED_TOKEN_TTL", 5*60)
 }
 
 func parseIntEnv(key string, fallback int) int {
@@ -7,6 +7,7 @@ import (
 	"github.com/gaucho-racing/sentinel/oauth/pkg/kerbecs"
 	"github.com/gaucho-racing/sentinel/oauth/pkg/logger"
 	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
+	"github.com/gaucho-racing/sentinel/oauth/service"
 )
 
 func main() {
@@ -25,6 +26,7 @@ func main() {
 	}
 
 	database.Init()
+	service.InitWebAuthn()
 
 	api.Run()
 }
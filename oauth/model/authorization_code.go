@@ -11,6 +11,10 @@ type AuthorizationCode struct {
 	Nonce       string    `json:"nonce"`
 	ExpiresAt   time.Time `json:"expires_at"`
 	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// AMR is the space-separated amr of the session that approved consent,
+	// copied into the tokens the code is exchanged for.
+	AMR string `json:"amr"`
 }
 
 func (AuthorizationCode) TableName() string {
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+type DeviceCodeStatus string
+
+const (
+	DeviceCodePending  DeviceCodeStatus = "PENDING"
+	DeviceCodeApproved DeviceCodeStatus = "APPROVED"
+	DeviceCodeDenied   DeviceCodeStatus = "DENIED"
+)
+
+// DeviceCode is one RFC 8628 device authorization: the device polls the
+// token endpoint with DeviceCode while the user enters UserCode on the
+// verification page. EntityID and AMR are filled in when the user
+// approves. Rows are deleted once the device redeems or is refused them.
+type DeviceCode struct {
+	DeviceCode string           `json:"device_code" gorm:"primaryKey"`
+	UserCode   string           `json:"user_code" gorm:"uniqueIndex"`
+	ClientID   string           `json:"client_id"`
+	Scope      string           `json:"scope"`
+	Status     DeviceCodeStatus `json:"status"`
+	EntityID   string           `json:"entity_id"`
+	AMR        string           `json:"amr"`
+	// Interval is the minimum seconds between polls. It grows by five each
+	// time the device polls too fast, per the spec's slow_down.
+	Interval     int        `json:"interval"`
+	LastPolledAt *time.Time `json:"last_polled_at"`
+	ApprovedAt   *time.Time `json:"approved_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (DeviceCode) TableName() string {
+	return "device_code"
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// PushedAuthorizationRequest holds the authorization parameters a client
+// pushed to /oauth/par (RFC 9126) until the browser arrives at the
+// authorize endpoint with the request_uri. Params is the url.Values
+// encoding of the request. Rows are one-time: they're deleted when the
+// user approves, and expired ones are cleared on each push.
+type PushedAuthorizationRequest struct {
+	RequestURI string    `json:"request_uri" gorm:"primaryKey"`
+	ClientID   string    `json:"client_id"`
+	Params     string    `json:"params"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (PushedAuthorizationRequest) TableName() string {
+	return "pushed_authorization_request"
+}
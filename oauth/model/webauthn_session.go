@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+type WebAuthnSessionPurpose string
+
+const (
+	WebAuthnSessionRegistration WebAuthnSessionPurpose = "REGISTRATION"
+	WebAuthnSessionLogin        WebAuthnSessionPurpose = "LOGIN"
+	WebAuthnSessionMFA          WebAuthnSessionPurpose = "MFA"
+)
+
+// WebAuthnSession holds the server half of a WebAuthn ceremony between its
+// begin and finish requests. Data is the library's SessionData as JSON; it
+// carries the challenge the authenticator must sign. Sessions are single
+// use and are deleted when finished.
+type WebAuthnSession struct {
+	ID       string                 `json:"id" gorm:"primaryKey"`
+	Purpose  WebAuthnSessionPurpose `json:"purpose"`
+	EntityID string                 `json:"entity_id"`
+	// MFATokenHash binds an MFA ceremony to the login challenge it was begun
+	// for, so the assertion can't be replayed against a different challenge.
+	MFATokenHash string    `json:"-"`
+	Data         string    `json:"-" gorm:"type:jsonb"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+func (WebAuthnSession) TableName() string {
+	return "webauthn_session"
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
+)
+
+// ErrMFARequired means one of the entity's groups requires MFA and the
+// session they consented from didn't complete a second factor.
+var ErrMFARequired = errors.New("mfa is required for this account, sign in again with your second factor")
+
+// SetAMRClaim stamps the authentication methods the user satisfied (RFC
+// 8176 values such as "pwd", "otp", "mfa") into claims. Relying parties
+// check for "mfa" to require a second factor. No-op for an empty list so
+// tokens minted without a known login (e.g. service accounts) omit the claim.
+func SetAMRClaim(claims map[string]interface{}, amr []string) {
+	if claims == nil || len(amr) == 0 {
+		return
+	}
+	claims["amr"] = amr
+}
+
+// AMRFromClaims reads the amr claim back out of validated token claims, so
+// a refresh can carry it forward. Accepts the []interface{} shape JSON
+// decoding produces.
+func AMRFromClaims(claims map[string]interface{}) []string {
+	raw, ok := claims["amr"].([]interface{})
+	if !ok {
+		return nil
+	}
+	amr := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok && s != "" {
+			amr = append(amr, s)
+		}
+	}
+	return amr
+}
+
+// JoinAMR and SplitAMR convert to and from the space-separated form stored
+// on authorization codes.
+func JoinAMR(amr []string) string {
+	return strings.Join(amr, " ")
+}
+
+func SplitAMR(s string) []string {
+	return strings.Fields(s)
+}
+
+// HasSecondFactor reports whether amr includes a second factor: a one-time
+// code or a hardware key.
+func HasSecondFactor(amr []string) bool {
+	for _, m := range amr {
+		if m == "otp" || m == "hwk" {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckConsentMFA enforces group MFA policy where codes and tokens are
+// issued, not just at login: a session minted before the policy applied,
+// or without a second factor, can't approve consent for an entity that now
+// requires one. Fails closed when core can't be asked.
+func CheckConsentMFA(entityID string, amr []string) error {
+	if HasSecondFactor(amr) {
+		return nil
+	}
+	var status struct {
+		RequiredByPolicy bool `json:"required_by_policy"`
+	}
+	if err := sentinel.Get("/api/core/entity/"+entityID+"/mfa", &status); err != nil {
+		return fmt.Errorf("load mfa policy for %s: %w", entityID, err)
+	}
+	if status.RequiredByPolicy {
+		return ErrMFARequired
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestCheckConsentMFA(t *testing.T) {
+	handleCore(t, http.MethodGet, "/api/core/entity/ent_admin/mfa", respondCore(http.StatusOK, map[string]bool{"required_by_policy": true}))
+	handleCore(t, http.MethodGet, "/api/core/entity/ent_member/mfa", respondCore(http.StatusOK, map[string]bool{"required_by_policy": false}))
+	handleCore(t, http.MethodGet, "/api/core/entity/ent_broken/mfa", respondCore(http.StatusInternalServerError, map[string]string{"message": "boom"}))
+
+	tests := []struct {
+		name     string
+		entityID string
+		amr      []string
+		wantErr  error
+		wantFail bool
+	}{
+		{"policy met with otp", "ent_admin", []string{"pwd", "otp", "mfa"}, nil, false},
+		{"policy met with a passkey", "ent_admin", []string{"hwk", "mfa"}, nil, false},
+		{"password only", "ent_admin", []string{"pwd"}, ErrMFARequired, true},
+		{"no amr", "ent_admin", nil, ErrMFARequired, true},
+		{"mfa alone isn't a factor", "ent_admin", []string{"pwd", "mfa"}, ErrMFARequired, true},
+		{"no policy", "ent_member", []string{"pwd"}, nil, false},
+		{"policy unreadable", "ent_broken", []string{"pwd"}, nil, true},
+		{"second factor skips the lookup", "ent_broken", []string{"otp"}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckConsentMFA(tt.entityID, tt.amr)
+			if (err != nil) != tt.wantFail {
+				t.Fatalf("CheckConsentMFA error = %v, want failure %v", err, tt.wantFail)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckConsentMFA error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && errors.Is(err, ErrMFARequired) {
+				t.Errorf("CheckConsentMFA error = %v, want a lookup failure", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/oauth/database"
+	"github.com/gaucho-racing/sentinel/oauth/model"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
+)
+
+// PushedRequestTTL is how long a request_uri from /oauth/par stays valid.
+// RFC 9126 suggests keeping it short; it only has to outlive the redirect.
+const PushedRequestTTL = 90 * time.Second
+
+// RequestURIPrefix is the URN namespace RFC 9126 defines for request_uri
+// values minted by the authorization server.
+const RequestURIPrefix = "urn:ietf:params:oauth:request_uri:"
+
+// Errors for the request and request_uri parameters. Their messages are the
+// OAuth error codes, so handlers can return them as-is.
+var (
+	ErrInvalidRequestObject = errors.New("invalid_request_object")
+	ErrInvalidRequestURI    = errors.New("invalid_request_uri")
+)
+
+// PushAuthorizationRequest stores the parameters a client pushed and
+// returns the row holding its new request_uri.
+func PushAuthorizationRequest(clientID string, params url.Values) (model.PushedAuthorizationRequest, error) {
+	if err := database.DB.Where("expires_at < ?", time.Now()).Delete(&model.PushedAuthorizationRequest{}).Error; err != nil {
+		return model.PushedAuthorizationRequest{}, fmt.Errorf("clear expired pushed requests: %w", err)
+	}
+	request := model.PushedAuthorizationRequest{
+		RequestURI: RequestURIPrefix + generateCryptoString(32),
+		ClientID:   clientID,
+		Params:     params.Encode(),
+		ExpiresAt:  time.Now().Add(PushedRequestTTL),
+	}
+	if err := database.DB.Create(&request).Error; err != nil {
+		return model.PushedAuthorizationRequest{}, err
+	}
+	return request, nil
+}
+
+// GetPushedAuthorizationRequest returns the parameters behind a
+// request_uri, which must be live and belong to clientID. It doesn't use
+// the request up; see ConsumePushedAuthorizationRequest.
+func GetPushedAuthorizationRequest(requestURI string, clientID string) (url.Values, error) {
+	if !strings.HasPrefix(requestURI, RequestURIPrefix) {
+		return nil, fmt.Errorf("%w: only request_uri values from the pushed authorization endpoint are supported", ErrInvalidRequestURI)
+	}
+	var request model.PushedAuthorizationRequest
+	err := database.DB.Where("request_uri = ? AND expires_at > ?", requestURI, time.Now()).First(&request).Error
+	if err != nil || request.ClientID != clientID {
+		return nil, fmt.Errorf("%w: request_uri is unknown, expired, or was already used", ErrInvalidRequestURI)
+	}
+	params, err := url.ParseQuery(request.Params)
+	if err != nil {
+		return nil, fmt.Errorf("parse pushed request: %w", err)
+	}
+	return params, nil
+}
+
+// ConsumePushedAuthorizationRequest deletes a request_uri so it can't be
+// used for a second authorization. Of two concurrent callers, only one
+// succeeds.
+func ConsumePushedAuthorizationRequest(requestURI string) error {
+	result := database.DB.Where("request_uri = ? AND expires_at > ?", requestURI, time.Now()).Delete(&model.PushedAuthorizationRequest{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: request_uri is unknown, expired, or was already used", ErrInvalidRequestURI)
+	}
+	return nil
+}
+
+// VerifyRequestObject has core check an RFC 9101 request object against the
+// client's registered keys and returns the parameters it carries.
+func VerifyRequestObject(clientID string, requestObject string) (url.Values, error) {
+	var claims map[string]string
+	err := sentinel.Post("/api/core/applications/verify-request-object", map[string]string{
+		"client_id": clientID,
+		"request":   requestObject,
+	}, &claims)
+	if err != nil {
+		var apiErr *sentinel.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusBadRequest {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRequestObject, strings.TrimPrefix(apiErr.Message, "invalid_request_object: "))
+		}
+		return nil, fmt.Errorf("verify request object: %w", err)
+	}
+	params := url.Values{}
+	for name, value := range claims {
+		params.Set(name, value)
+	}
+	return params, nil
+}
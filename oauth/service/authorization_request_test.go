@@ -0,0 +1,55 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestVerifyRequestObject(t *testing.T) {
+	var got map[string]string
+	handleCore(t, http.MethodPost, "/api/core/applications/verify-request-object", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		switch got["request"] {
+		case "good":
+			writeJSON(w, http.StatusOK, map[string]string{"client_id": "client", "scope": "openid", "state": "xyz"})
+		case "bad":
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request_object: token is expired"})
+		default:
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "database is down"})
+		}
+	})
+
+	params, err := VerifyRequestObject("client", "good")
+	if err != nil {
+		t.Fatalf("VerifyRequestObject: %v", err)
+	}
+	if got["client_id"] != "client" {
+		t.Errorf("core was asked about client %q, want client", got["client_id"])
+	}
+	if params.Get("scope") != "openid" || params.Get("state") != "xyz" {
+		t.Errorf("params = %v, want the claims core returned", params)
+	}
+
+	_, err = VerifyRequestObject("client", "bad")
+	if !errors.Is(err, ErrInvalidRequestObject) {
+		t.Fatalf("rejected object: error = %v, want ErrInvalidRequestObject", err)
+	}
+	if err.Error() != "invalid_request_object: token is expired" {
+		t.Errorf("rejected object: error = %q, want core's reason once", err.Error())
+	}
+
+	// Core failing isn't the client's fault.
+	if _, err := VerifyRequestObject("client", "boom"); err == nil || errors.Is(err, ErrInvalidRequestObject) {
+		t.Errorf("core failure: error = %v, want a non-client error", err)
+	}
+}
+
+func TestGetPushedAuthorizationRequestRejectsForeignURIs(t *testing.T) {
+	for _, uri := range []string{"https://client.example.com/request.jwt", "urn:example:request"} {
+		if _, err := GetPushedAuthorizationRequest(uri, "client"); !errors.Is(err, ErrInvalidRequestURI) {
+			t.Errorf("GetPushedAuthorizationRequest(%q) error = %v, want ErrInvalidRequestURI", uri, err)
+		}
+	}
+}
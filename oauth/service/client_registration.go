@@ -0,0 +1,135 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
+)
+
+// Errors from dynamic client registration. ErrInvalidClientMetadata wraps
+// core's reason, whose message already starts with the RFC 7591 error code
+// (invalid_client_metadata or invalid_redirect_uri); see
+// ClientRegistrationErrorCode. ErrInvalidRegistrationToken covers both a bad
+// initial access token and a bad registration access token.
+var (
+	ErrInvalidClientMetadata    = errors.New("invalid client metadata")
+	ErrInvalidRegistrationToken = errors.New("invalid registration token")
+)
+
+// ClientMetadata is the RFC 7591 client metadata the registration endpoint
+// accepts, passed to core as-is.
+type ClientMetadata struct {
+	RedirectURIs            []string        `json:"redirect_uris"`
+	ClientName              string          `json:"client_name"`
+	ClientURI               string          `json:"client_uri"`
+	LogoURI                 string          `json:"logo_uri"`
+	TokenEndpointAuthMethod string          `json:"token_endpoint_auth_method"`
+	JWKS                    json.RawMessage `json:"jwks,omitempty"`
+	JWKSURI                 string          `json:"jwks_uri"`
+	GrantTypes              []string        `json:"grant_types"`
+	ResponseTypes           []string        `json:"response_types"`
+	Scope                   string          `json:"scope"`
+}
+
+// RegisteredApplication is the part of core's application a registration
+// response reports back.
+type RegisteredApplication struct {
+	Name                    string          `json:"name"`
+	ClientID                string          `json:"client_id"`
+	IconURL                 string          `json:"icon_url"`
+	LaunchURL               string          `json:"launch_url"`
+	RedirectURIs            []string        `json:"redirect_uris"`
+	AllowedScopes           []string        `json:"allowed_scopes"`
+	TokenEndpointAuthMethod string          `json:"token_endpoint_auth_method"`
+	JWKS                    json.RawMessage `json:"jwks"`
+	JWKSURI                 string          `json:"jwks_uri"`
+	PendingApproval         bool            `json:"pending_approval"`
+	CreatedAt               time.Time       `json:"created_at"`
+}
+
+// RegisteredClient is what core returns for a new registration: the
+// application plus its one-time-readable credentials.
+type RegisteredClient struct {
+	Application             RegisteredApplication `json:"application"`
+	ClientSecret            string                `json:"client_secret"`
+	RegistrationAccessToken string                `json:"registration_access_token"`
+}
+
+// ClientRegistrationErrorCode splits an ErrInvalidClientMetadata from core
+// into the RFC 7591 error code and its description.
+func ClientRegistrationErrorCode(err error) (string, string) {
+	msg := strings.TrimPrefix(err.Error(), ErrInvalidClientMetadata.Error()+": ")
+	if code, description, found := strings.Cut(msg, ": "); found && (code == "invalid_client_metadata" || code == "invalid_redirect_uri") {
+		return code, description
+	}
+	return "invalid_client_metadata", msg
+}
+
+// RegisterClient has core create an application from metadata, authorized
+// by an admin-issued initial access token.
+func RegisterClient(initialAccessToken string, metadata ClientMetadata) (RegisteredClient, error) {
+	var registered RegisteredClient
+	err := sentinel.Post("/api/core/registrations", metadata, &registered, map[string]string{
+		"X-Initial-Access-Token": initialAccessToken,
+	})
+	if err != nil {
+		return RegisteredClient{}, clientRegistrationError(err)
+	}
+	return registered, nil
+}
+
+// GetRegisteredClient reads a registered client's configuration, authorized
+// by its registration access token.
+func GetRegisteredClient(clientID, registrationAccessToken string) (RegisteredApplication, error) {
+	var app RegisteredApplication
+	err := sentinel.Get("/api/core/registrations/"+clientID, &app, map[string]string{
+		"X-Registration-Access-Token": registrationAccessToken,
+	})
+	if err != nil {
+		return RegisteredApplication{}, clientRegistrationError(err)
+	}
+	return app, nil
+}
+
+// UpdateRegisteredClient replaces a registered client's metadata.
+func UpdateRegisteredClient(clientID, registrationAccessToken string, metadata ClientMetadata) (RegisteredApplication, error) {
+	var app RegisteredApplication
+	err := sentinel.Put("/api/core/registrations/"+clientID, metadata, &app, map[string]string{
+		"X-Registration-Access-Token": registrationAccessToken,
+	})
+	if err != nil {
+		return RegisteredApplication{}, clientRegistrationError(err)
+	}
+	return app, nil
+}
+
+// DeleteRegisteredClient deletes a registered client.
+func DeleteRegisteredClient(clientID, registrationAccessToken string) error {
+	err := sentinel.Delete("/api/core/registrations/"+clientID, nil, map[string]string{
+		"X-Registration-Access-Token": registrationAccessToken,
+	})
+	if err != nil {
+		return clientRegistrationError(err)
+	}
+	return nil
+}
+
+// clientRegistrationError maps core's 400 and 401 onto this package's
+// errors; anything else is returned wrapped.
+func clientRegistrationError(err error) error {
+	var apiErr *sentinel.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Status {
+		case http.StatusBadRequest:
+			return fmt.Errorf("%w: %s", ErrInvalidClientMetadata, apiErr.Message)
+		case http.StatusUnauthorized:
+			return ErrInvalidRegistrationToken
+		}
+	}
+	return fmt.Errorf("client registration: %w", err)
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRegisterClient(t *testing.T) {
+	handleCore(t, http.MethodPost, "/api/core/registrations", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Initial-Access-Token") != "iat-good" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid initial access token"})
+			return
+		}
+		var metadata ClientMetadata
+		_ = json.NewDecoder(r.Body).Decode(&metadata)
+		if len(metadata.RedirectURIs) == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_redirect_uri: redirect_uris is required for the authorization_code grant"})
+			return
+		}
+		writeJSON(w, http.StatusCreated, RegisteredClient{
+			Application:             RegisteredApplication{Name: metadata.ClientName, ClientID: "new-client", RedirectURIs: metadata.RedirectURIs, PendingApproval: true},
+			ClientSecret:            "secret",
+			RegistrationAccessToken: "rat",
+		})
+	})
+	metadata := ClientMetadata{ClientName: "CLI", RedirectURIs: []string{"http://localhost/cb"}}
+
+	registered, err := RegisterClient("iat-good", metadata)
+	if err != nil {
+		t.Fatalf("RegisterClient: %v", err)
+	}
+	if registered.Application.ClientID != "new-client" || registered.ClientSecret != "secret" || registered.RegistrationAccessToken != "rat" || !registered.Application.PendingApproval {
+		t.Errorf("RegisterClient = %+v, want core's registration", registered)
+	}
+
+	if _, err := RegisterClient("iat-bad", metadata); !errors.Is(err, ErrInvalidRegistrationToken) {
+		t.Errorf("bad initial access token: error = %v, want ErrInvalidRegistrationToken", err)
+	}
+
+	_, err = RegisterClient("iat-good", ClientMetadata{ClientName: "CLI"})
+	if !errors.Is(err, ErrInvalidClientMetadata) {
+		t.Fatalf("bad metadata: error = %v, want ErrInvalidClientMetadata", err)
+	}
+	if code, description := ClientRegistrationErrorCode(err); code != "invalid_redirect_uri" || description != "redirect_uris is required for the authorization_code grant" {
+		t.Errorf("ClientRegistrationErrorCode = %q, %q, want core's code and reason", code, description)
+	}
+}
+
+func TestRegisteredClientManagement(t *testing.T) {
+	requireToken := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Registration-Access-Token") != "rat" {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid registration access token"})
+				return
+			}
+			next(w, r)
+		}
+	}
+	app := RegisteredApplication{Name: "CLI", ClientID: "client"}
+	handleCore(t, http.MethodGet, "/api/core/registrations/client", requireToken(respondCore(http.StatusOK, app)))
+	handleCore(t, http.MethodPut, "/api/core/registrations/client", requireToken(respondCore(http.StatusOK, app)))
+	handleCore(t, http.MethodDelete, "/api/core/registrations/client", requireToken(respondCore(http.StatusOK, map[string]string{})))
+
+	if got, err := GetRegisteredClient("client", "rat"); err != nil || got.ClientID != "client" {
+		t.Errorf("GetRegisteredClient = %+v, %v", got, err)
+	}
+	if _, err := UpdateRegisteredClient("client", "rat", ClientMetadata{ClientName: "CLI"}); err != nil {
+		t.Errorf("UpdateRegisteredClient: %v", err)
+	}
+	if err := DeleteRegisteredClient("client", "rat"); err != nil {
+		t.Errorf("DeleteRegisteredClient: %v", err)
+	}
+
+	if _, err := GetRegisteredClient("client", "wrong"); !errors.Is(err, ErrInvalidRegistrationToken) {
+		t.Errorf("GetRegisteredClient with a wrong token: error = %v, want ErrInvalidRegistrationToken", err)
+	}
+	if err := DeleteRegisteredClient("client", "wrong"); !errors.Is(err, ErrInvalidRegistrationToken) {
+		t.Errorf("DeleteRegisteredClient with a wrong token: error = %v, want ErrInvalidRegistrationToken", err)
+	}
+}
+
+func TestClientRegistrationErrorCode(t *testing.T) {
+	tests := []struct {
+		err             error
+		wantCode        string
+		wantDescription string
+	}{
+		{errors.New("invalid client metadata: invalid_client_metadata: client_name is required"), "invalid_client_metadata", "client_name is required"},
+		{errors.New("invalid client metadata: invalid_redirect_uri: \"x\" must use https"), "invalid_redirect_uri", "\"x\" must use https"},
+		{errors.New("invalid client metadata: something else: entirely"), "invalid_client_metadata", "something else: entirely"},
+	}
+	for _, tt := range tests {
+		code, description := ClientRegistrationErrorCode(tt.err)
+		if code != tt.wantCode || description != tt.wantDescription {
+			t.Errorf("ClientRegistrationErrorCode(%q) = %q, %q, want %q, %q", tt.err, code, description, tt.wantCode, tt.wantDescription)
+		}
+	}
+}
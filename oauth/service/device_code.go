@@ -0,0 +1,202 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/oauth/database"
+	"github.com/gaucho-racing/sentinel/oauth/model"
+	"gorm.io/gorm"
+)
+
+// DeviceCodeTTL is how long the user has to enter the code.
+const DeviceCodeTTL = 10 * time.Minute
+
+// DeviceCodeInterval is the initial minimum polling interval, in seconds.
+const DeviceCodeInterval = 5
+
+// Errors from PollDeviceCode. Their messages are the RFC 8628 error codes,
+// so the token endpoint can return them as-is.
+var (
+	ErrAuthorizationPending = errors.New("authorization_pending")
+	ErrSlowDown             = errors.New("slow_down")
+	ErrExpiredToken         = errors.New("expired_token")
+	ErrDeviceAccessDenied   = errors.New("access_denied")
+	ErrInvalidDeviceCode    = errors.New("invalid_grant")
+)
+
+// ErrUserCodeNotFound is returned for a user code that doesn't exist, has
+// expired, or was already approved or denied.
+var ErrUserCodeNotFound = errors.New("invalid or expired code")
+
+// userCodeAlphabet is consonants only: no vowels means no accidental
+// words, and no 0/O or 1/I to confuse on a small screen.
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+const userCodeLength = 8
+
+// GenerateDeviceCode starts a device authorization for the client. Expired
+// rows are cleared first; abandoned flows would otherwise pile up.
+func GenerateDeviceCode(clientID string, scope string) (model.DeviceCode, error) {
+	if err := database.DB.Where("expires_at < ?", time.Now()).Delete(&model.DeviceCode{}).Error; err != nil {
+		return model.DeviceCode{}, fmt.Errorf("clear expired device codes: %w", err)
+	}
+	// A user code collision is unlikely at 20^8 but not impossible, and the
+	// unique index would reject it — just draw again.
+	var lastErr error
+	for attempt := 0; attempt < 3; attempt++ {
+		deviceCode := model.DeviceCode{
+			DeviceCode: generateCryptoString(40),
+			UserCode:   generateUserCode(),
+			ClientID:   clientID,
+			Scope:      scope,
+			Status:     model.DeviceCodePending,
+			Interval:   DeviceCodeInterval,
+			ExpiresAt:  time.Now().Add(DeviceCodeTTL),
+		}
+		if lastErr = database.DB.Create(&deviceCode).Error; lastErr == nil {
+			return deviceCode, nil
+		}
+	}
+	return model.DeviceCode{}, lastErr
+}
+
+func generateUserCode() string {
+	b := make([]byte, userCodeLength)
+	rand.Read(b)
+	for i := range b {
+		b[i] = userCodeAlphabet[int(b[i])%len(userCodeAlphabet)]
+	}
+	return string(b)
+}
+
+// FormatUserCode renders a stored user code for display, e.g. BCDF-GHJK.
+func FormatUserCode(code string) string {
+	if len(code) != userCodeLength {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
+
+// NormalizeUserCode undoes what people do when typing a code: lowercase,
+// dashes, spaces.
+func NormalizeUserCode(input string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(input) {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// GetPendingDeviceCode looks up a user code that's still waiting for the
+// user. Returns ErrUserCodeNotFound otherwise.
+func GetPendingDeviceCode(userCode string) (model.DeviceCode, error) {
+	var deviceCode model.DeviceCode
+	err := database.DB.
+		Where("user_code = ? AND status = ? AND expires_at > ?", NormalizeUserCode(userCode), model.DeviceCodePending, time.Now()).
+		First(&deviceCode).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.DeviceCode{}, ErrUserCodeNotFound
+	}
+	return deviceCode, err
+}
+
+// ApproveDeviceCode records the user's consent; the device's next poll gets
+// tokens for entityID. amr is the approving session's, as with an
+// authorization code.
+func ApproveDeviceCode(userCode string, entityID string, amr []string) error {
+	now := time.Now()
+	return resolveDeviceCode(userCode, map[string]interface{}{
+		"status":      model.DeviceCodeApproved,
+		"entity_id":   entityID,
+		"amr":         JoinAMR(amr),
+		"approved_at": now,
+	})
+}
+
+// DenyDeviceCode records that the user refused; the device's next poll
+// gets access_denied.
+func DenyDeviceCode(userCode string) error {
+	return resolveDeviceCode(userCode, map[string]interface{}{"status": model.DeviceCodeDenied})
+}
+
+func resolveDeviceCode(userCode string, updates map[string]interface{}) error {
+	result := database.DB.Model(&model.DeviceCode{}).
+		Where("user_code = ? AND status = ? AND expires_at > ?", NormalizeUserCode(userCode), model.DeviceCodePending, time.Now()).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserCodeNotFound
+	}
+	return nil
+}
+
+// PollDeviceCode is one token-endpoint poll by the device. It returns the
+// approved row exactly once — the row is deleted in the same step, so two
+// racing polls can't both redeem it. Every other outcome is one of the
+// RFC 8628 errors above.
+func PollDeviceCode(code string, clientID string) (model.DeviceCode, error) {
+	var deviceCode model.DeviceCode
+	if err := database.DB.Where("device_code = ?", code).First(&deviceCode).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.DeviceCode{}, ErrInvalidDeviceCode
+		}
+		return model.DeviceCode{}, err
+	}
+	now := time.Now()
+	switch err := checkDevicePoll(deviceCode, clientID, now); err {
+	case nil:
+		result := database.DB.Where("device_code = ? AND status = ?", code, model.DeviceCodeApproved).Delete(&model.DeviceCode{})
+		if result.Error != nil {
+			return model.DeviceCode{}, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return model.DeviceCode{}, ErrInvalidDeviceCode
+		}
+		return deviceCode, nil
+	case ErrExpiredToken, ErrDeviceAccessDenied:
+		database.DB.Where("device_code = ?", code).Delete(&model.DeviceCode{})
+		return model.DeviceCode{}, err
+	case ErrSlowDown, ErrAuthorizationPending:
+		updates := map[string]interface{}{"last_polled_at": now}
+		if err == ErrSlowDown {
+			updates["interval"] = deviceCode.Interval + 5
+		}
+		if err := database.DB.Model(&model.DeviceCode{}).Where("device_code = ?", code).Updates(updates).Error; err != nil {
+			return model.DeviceCode{}, err
+		}
+		return model.DeviceCode{}, err
+	default:
+		return model.DeviceCode{}, err
+	}
+}
+
+// checkDevicePoll decides a poll of deviceCode by clientID at now: nil when
+// the code is approved and can be redeemed, otherwise the RFC 8628 error
+// the device gets. A poll sooner than the code's interval after the last
+// one is ErrSlowDown.
+func checkDevicePoll(deviceCode model.DeviceCode, clientID string, now time.Time) error {
+	if deviceCode.ClientID != clientID {
+		return ErrInvalidDeviceCode
+	}
+	if now.After(deviceCode.ExpiresAt) {
+		return ErrExpiredToken
+	}
+	switch deviceCode.Status {
+	case model.DeviceCodeApproved:
+		return nil
+	case model.DeviceCodeDenied:
+		return ErrDeviceAccessDenied
+	}
+	if deviceCode.LastPolledAt != nil && now.Sub(*deviceCode.LastPolledAt) < time.Duration(deviceCode.Interval)*time.Second {
+		return ErrSlowDown
+	}
+	return ErrAuthorizationPending
+}
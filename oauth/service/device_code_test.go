@@ -0,0 +1,76 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/oauth/model"
+)
+
+func TestCheckDevicePoll(t *testing.T) {
+	now := time.Now()
+	justPolled := now.Add(-2 * time.Second)
+	polledAWhileAgo := now.Add(-10 * time.Second)
+	pending := model.DeviceCode{
+		ClientID:  "client",
+		Status:    model.DeviceCodePending,
+		Interval:  DeviceCodeInterval,
+		ExpiresAt: now.Add(time.Minute),
+	}
+	with := func(edit func(*model.DeviceCode)) model.DeviceCode {
+		dc := pending
+		edit(&dc)
+		return dc
+	}
+
+	tests := []struct {
+		name     string
+		code     model.DeviceCode
+		clientID string
+		want     error
+	}{
+		{"first poll", pending, "client", ErrAuthorizationPending},
+		{"polled after the interval", with(func(dc *model.DeviceCode) { dc.LastPolledAt = &polledAWhileAgo }), "client", ErrAuthorizationPending},
+		{"polled inside the interval", with(func(dc *model.DeviceCode) { dc.LastPolledAt = &justPolled }), "client", ErrSlowDown},
+		{"interval grown past the gap", with(func(dc *model.DeviceCode) { dc.LastPolledAt = &polledAWhileAgo; dc.Interval = 15 }), "client", ErrSlowDown},
+		{"approved", with(func(dc *model.DeviceCode) { dc.Status = model.DeviceCodeApproved }), "client", nil},
+		{"approved but polled too fast", with(func(dc *model.DeviceCode) { dc.Status = model.DeviceCodeApproved; dc.LastPolledAt = &justPolled }), "client", nil},
+		{"denied", with(func(dc *model.DeviceCode) { dc.Status = model.DeviceCodeDenied }), "client", ErrDeviceAccessDenied},
+		{"expired", with(func(dc *model.DeviceCode) { dc.ExpiresAt = now.Add(-time.Second) }), "client", ErrExpiredToken},
+		{"expired after approval", with(func(dc *model.DeviceCode) { dc.Status = model.DeviceCodeApproved; dc.ExpiresAt = now.Add(-time.Second) }), "client", ErrExpiredToken},
+		{"another client's code", with(func(dc *model.DeviceCode) { dc.Status = model.DeviceCodeApproved }), "other", ErrInvalidDeviceCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkDevicePoll(tt.code, tt.clientID, now); got != tt.want {
+				t.Errorf("checkDevicePoll = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateUserCode(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code := generateUserCode()
+		if len(code) != userCodeLength {
+			t.Fatalf("generateUserCode() = %q, want %d characters", code, userCodeLength)
+		}
+		for _, r := range code {
+			if !strings.ContainsRune(userCodeAlphabet, r) {
+				t.Fatalf("generateUserCode() = %q, has %q outside the alphabet", code, r)
+			}
+		}
+	}
+}
+
+func TestUserCodeRoundTrip(t *testing.T) {
+	if got := FormatUserCode("BCDFGHJK"); got != "BCDF-GHJK" {
+		t.Errorf("FormatUserCode = %q, want BCDF-GHJK", got)
+	}
+	for _, typed := range []string{"BCDF-GHJK", "bcdf-ghjk", " bcdf ghjk ", "BcDfGhJk"} {
+		if got := NormalizeUserCode(typed); got != "BCDFGHJK" {
+			t.Errorf("NormalizeUserCode(%q) = %q, want BCDFGHJK", typed, got)
+		}
+	}
+}
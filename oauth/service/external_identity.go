@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrUnsupportedProvider is returned for providers without a code-exchange
+// flow here. Discord has its own (login_discord.go) and isn't linkable.
+var ErrUnsupportedProvider = errors.New("unsupported provider")
+
+// ExternalIdentity is a provider account proven by a completed OAuth code
+// exchange: the provider, its stable account ID, and the profile fields we
+// keep on the external auth row as metadata.
+type ExternalIdentity struct {
+	Provider   string
+	ExternalID string
+	Metadata   map[string]any
+}
+
+// ResolveExternalIdentity exchanges a GitHub or Google authorization code
+// and reads back who it belongs to. provider is case-insensitive.
+func ResolveExternalIdentity(provider, code string) (ExternalIdentity, error) {
+	switch strings.ToUpper(provider) {
+	case "GITHUB":
+		token, err := ExchangeGitHubCode(code)
+		if err != nil {
+			return ExternalIdentity{}, err
+		}
+		user, err := GetGitHubUser(token.AccessToken)
+		if err != nil {
+			return ExternalIdentity{}, err
+		}
+		return ExternalIdentity{
+			Provider:   "GITHUB",
+			ExternalID: user.ExternalID(),
+			Metadata: map[string]any{
+				"login":      user.Login,
+				"name":       user.Name,
+				"email":      user.Email,
+				"avatar_url": user.AvatarURL,
+			},
+		}, nil
+	case "GOOGLE":
+		token, err := ExchangeGoogleCode(code)
+		if err != nil {
+			return ExternalIdentity{}, err
+		}
+		user, err := GetGoogleUser(token.AccessToken)
+		if err != nil {
+			return ExternalIdentity{}, err
+		}
+		return ExternalIdentity{
+			Provider:   "GOOGLE",
+			ExternalID: user.Sub,
+			Metadata: map[string]any{
+				"email":          user.Email,
+				"email_verified": user.EmailVerified,
+				"name":           user.Name,
+				"picture":        user.Picture,
+			},
+		}, nil
+	}
+	return ExternalIdentity{}, ErrUnsupportedProvider
+}
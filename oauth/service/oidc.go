@@ -75,25 +75,42 @@ func identityClaims(e oidcEntity, scope string) map[string]interface{} {
 	return claims
 }
 
+// tokenUseID is the token_use claim stamped on ID tokens. They carry the
+// same scope as the access token they come with, so the scope alone can't
+// tell the two apart.
+const tokenUseID = "id"
+
+// IsIDToken reports whether validated claims are an ID token's: stamped
+// with token_use, or, for ones minted before it was, carrying at_hash.
+func IsIDToken(claims map[string]interface{}) bool {
+	if use, _ := claims["token_use"].(string); use == tokenUseID {
+		return true
+	}
+	_, ok := claims["at_hash"]
+	return ok
+}
+
 // BuildIDTokenClaims assembles the OIDC-specific custom claims for an ID token.
 // Registered claims (iss/sub/aud/exp/iat/jti) are stamped by core at signing
-// time; this supplies the identity, groups, auth_time, nonce, and at_hash
-// claims. Groups are included only when the groups:read scope is granted and
-// follow the same per-client visibility rules as the access token.
+// time; this supplies the identity, groups, roles, auth_time, nonce, and
+// at_hash claims. The client's token policy decides whether groups and roles
+// are included, and may swap the identity for a shared one. Groups also need
+// the groups:read scope and follow the same per-client visibility rules as
+// the access token; roles are the client's own, as on the access token.
 func BuildIDTokenClaims(entityID string, clientID string, scope string, nonce string, accessToken string, authTime int64) (map[string]interface{}, error) {
 	e, err := fetchOIDCEntity(entityID)
 	if err != nil {
 		return nil, err
 	}
-	e = applyIdentityOverride(clientID, e)
-	claims := identityClaims(e, scope)
-	if GroupsClaimAllowed(scope) {
-		groups, err := FilteredGroups(entityID, clientID)
-		if err != nil {
-			return nil, err
-		}
-		SetGroupClaims(claims, groups)
+	policy, err := GetTokenPolicy(clientID)
+	if err != nil {
+		return nil, err
 	}
+	claims := identityClaims(applySharedIdentity(policy, e), scope)
+	if err := setOptionalClaims(claims, policy, entityID, clientID, scope); err != nil {
+		return nil, err
+	}
+	claims["token_use"] = tokenUseID
 	claims["auth_time"] = authTime
 	if nonce != "" {
 		claims["nonce"] = nonce
@@ -106,20 +123,21 @@ func BuildIDTokenClaims(entityID string, clientID string, scope string, nonce st
 
 // BuildUserInfoClaims returns the UserInfo response for an entity, filtered by
 // the access token's granted scopes. `sub` is always present per spec; groups
-// are included only when the groups:read scope is granted.
+// are included only when the groups:read scope is granted. roles lists the
+// client's roles the entity holds. The client's token policy applies as it
+// does to the ID token.
 func BuildUserInfoClaims(entityID string, clientID string, scope string) (map[string]interface{}, error) {
 	e, err := fetchOIDCEntity(entityID)
 	if err != nil {
 		return nil, err
 	}
-	e = applyIdentityOverride(clientID, e)
-	claims := identityClaims(e, scope)
-	if GroupsClaimAllowed(scope) {
-		groups, err := FilteredGroups(entityID, clientID)
-		if err != nil {
-			return nil, err
-		}
-		SetGroupClaims(claims, groups)
+	policy, err := GetTokenPolicy(clientID)
+	if err != nil {
+		return nil, err
+	}
+	claims := identityClaims(applySharedIdentity(policy, e), scope)
+	if err := setOptionalClaims(claims, policy, entityID, clientID, scope); err != nil {
+		return nil, err
 	}
 	claims["sub"] = entityID
 	return claims, nil
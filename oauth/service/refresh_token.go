@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
+)
+
+var (
+	// ErrInvalidRefreshToken covers any refresh token core won't exchange:
+	// malformed, expired, revoked, another client's, or from an ended family.
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused means the refresh token had already been
+	// rotated. Core has revoked its family and recorded a security event.
+	ErrRefreshTokenReused = errors.New("refresh token was already used; the session has been revoked")
+)
+
+// TokenFamily is core's refresh token family for one grant. Every token
+// minted for the grant, and for each refresh of it, carries its ID.
+type TokenFamily struct {
+	ID        string    `json:"id"`
+	ClientID  string    `json:"client_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// RotatedRefreshToken is an exchanged refresh token's claims and the family
+// its replacement goes in. Family is nil for a token from before families,
+// in which case the caller starts one.
+type RotatedRefreshToken struct {
+	Claims map[string]interface{} `json:"claims"`
+	Family *TokenFamily           `json:"family"`
+}
+
+// StartTokenFamily has core start a family for a new grant, ending after
+// the absolute refresh token lifetime in the client's policy.
+func StartTokenFamily(entityID, clientID string, policy TokenPolicy) (TokenFamily, error) {
+	var family TokenFamily
+	err := sentinel.Post("/api/core/token/families", map[string]interface{}{
+		"entity_id":  entityID,
+		"client_id":  clientID,
+		"expires_in": policy.RefreshAbsoluteTTL(),
+	}, &family)
+	if err != nil {
+		return TokenFamily{}, fmt.Errorf("start token family: %w", err)
+	}
+	return family, nil
+}
+
+// RotateRefreshToken has core exchange a refresh token presented by
+// clientID from ipAddress. It returns ErrRefreshTokenReused when the token
+// was already rotated and ErrAccountInactive for a suspended or offboarded
+// user.
+func RotateRefreshToken(token, clientID, ipAddress string) (RotatedRefreshToken, error) {
+	var rotated RotatedRefreshToken
+	err := sentinel.Post("/api/core/token/rotate", map[string]string{
+		"token":      token,
+		"client_id":  clientID,
+		"ip_address": ipAddress,
+	}, &rotated)
+	if err != nil {
+		var apiErr *sentinel.APIError
+		if errors.As(err, &apiErr) {
+			switch apiErr.Status {
+			case http.StatusUnauthorized:
+				return RotatedRefreshToken{}, ErrInvalidRefreshToken
+			case http.StatusConflict:
+				return RotatedRefreshToken{}, ErrRefreshTokenReused
+			case http.StatusForbidden:
+				return RotatedRefreshToken{}, ErrAccountInactive
+			}
+		}
+		return RotatedRefreshToken{}, fmt.Errorf("rotate refresh token: %w", err)
+	}
+	return rotated, nil
+}
+
+// RefreshTokenTTL is how long a refresh token minted into family now may
+// live: the policy's idle lifetime, cut short by the family's end.
+func RefreshTokenTTL(family TokenFamily, policy TokenPolicy) int {
+	return min(policy.RefreshIdleTTL(), int(time.Until(family.ExpiresAt).Seconds()))
+}
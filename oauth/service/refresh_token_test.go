@@ -0,0 +1,124 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/oauth/config"
+)
+
+func TestRotateRefreshToken(t *testing.T) {
+	var got map[string]string
+	handleCore(t, http.MethodPost, "/api/core/token/rotate", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		switch got["token"] {
+		case "fresh":
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"claims": map[string]interface{}{"sub": "ent_1"},
+				"family": map[string]interface{}{"id": "tfam_1", "client_id": got["client_id"]},
+			})
+		case "legacy":
+			writeJSON(w, http.StatusOK, map[string]interface{}{"claims": map[string]interface{}{"sub": "ent_1"}})
+		case "rotated":
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "refresh token reuse detected"})
+		case "suspended":
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "user is suspended"})
+		case "reworded":
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "refresh token was already used; the session has been revoked"})
+		case "broken":
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "database is down"})
+		default:
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid or expired refresh token"})
+		}
+	})
+
+	rotated, err := RotateRefreshToken("fresh", "client", "203.0.113.7")
+	if err != nil {
+		t.Fatalf("RotateRefreshToken: %v", err)
+	}
+	if got["client_id"] != "client" || got["ip_address"] != "203.0.113.7" {
+		t.Errorf("core was sent %v, want the client and IP address", got)
+	}
+	if rotated.Claims["sub"] != "ent_1" || rotated.Family == nil || rotated.Family.ID != "tfam_1" {
+		t.Errorf("RotateRefreshToken = %+v, want the claims and family", rotated)
+	}
+
+	// A token from before families comes back without one, for the
+	// caller to start.
+	if rotated, err := RotateRefreshToken("legacy", "client", ""); err != nil || rotated.Family != nil {
+		t.Errorf("legacy token: Family = %v, err = %v, want no family", rotated.Family, err)
+	}
+
+	errorCases := []struct {
+		token string
+		want  error
+	}{
+		{"rotated", ErrRefreshTokenReused},
+		// Only the status marks reuse; the message is for people.
+		{"reworded", ErrInvalidRefreshToken},
+		{"garbage", ErrInvalidRefreshToken},
+		{"suspended", ErrAccountInactive},
+	}
+	for _, tt := range errorCases {
+		if _, err := RotateRefreshToken(tt.token, "client", ""); err != tt.want {
+			t.Errorf("RotateRefreshToken(%q) error = %v, want %v", tt.token, err, tt.want)
+		}
+	}
+	_, err = RotateRefreshToken("broken", "client", "")
+	if err == nil || errors.Is(err, ErrInvalidRefreshToken) || errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("core failure: error = %v, want it passed through", err)
+	}
+}
+
+func TestStartTokenFamily(t *testing.T) {
+	var got map[string]interface{}
+	handleCore(t, http.MethodPost, "/api/core/token/families", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		writeJSON(w, http.StatusCreated, map[string]interface{}{"id": "tfam_1", "client_id": got["client_id"]})
+	})
+
+	family, err := StartTokenFamily("ent_1", "client", TokenPolicy{RefreshTokenAbsoluteTTL: 3600})
+	if err != nil {
+		t.Fatalf("StartTokenFamily: %v", err)
+	}
+	if family.ID != "tfam_1" || got["entity_id"] != "ent_1" || got["client_id"] != "client" {
+		t.Errorf("StartTokenFamily = %+v after sending %v", family, got)
+	}
+	if got["expires_in"] != float64(3600) {
+		t.Errorf("expires_in = %v, want the policy's absolute lifetime", got["expires_in"])
+	}
+}
+
+func TestRefreshTokenTTL(t *testing.T) {
+	withDefaultTTLs(t)
+	policy := TokenPolicy{RefreshTokenIdleTTL: 3600}
+
+	far := TokenFamily{ExpiresAt: time.Now().Add(60 * 24 * time.Hour)}
+	if got := RefreshTokenTTL(far, policy); got != 3600 {
+		t.Errorf("RefreshTokenTTL with a distant family end = %d, want the idle lifetime 3600", got)
+	}
+	// The family ends before the idle lifetime would.
+	near := TokenFamily{ExpiresAt: time.Now().Add(10 * time.Minute)}
+	if got := RefreshTokenTTL(near, policy); got < 590 || got > 600 {
+		t.Errorf("RefreshTokenTTL with a family ending in 10m = %d, want about 600", got)
+	}
+	if got := RefreshTokenTTL(far, TokenPolicy{}); got != config.RefreshTokenTTL {
+		t.Errorf("RefreshTokenTTL with no policy lifetime = %d, want the default %d", got, config.RefreshTokenTTL)
+	}
+}
+
+// withDefaultTTLs sets this service's default token lifetimes for the rest
+// of the test.
+func withDefaultTTLs(t *testing.T) {
+	t.Helper()
+	prev := [...]int{config.AccessTokenTTL, config.IDTokenTTL, config.RefreshTokenTTL, config.RefreshTokenAbsoluteTTL}
+	config.AccessTokenTTL, config.IDTokenTTL = 900, 3600
+	config.RefreshTokenTTL, config.RefreshTokenAbsoluteTTL = 7*24*3600, 30*24*3600
+	t.Cleanup(func() {
+		config.AccessTokenTTL, config.IDTokenTTL = prev[0], prev[1]
+		config.RefreshTokenTTL, config.RefreshTokenAbsoluteTTL = prev[2], prev[3]
+	})
+}
@@ -1,18 +1,116 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
 
-	"github.com/gaucho-racing/sentinel/oauth/model"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
 )
 
-func ValidateScopes(scopes string) bool {
+// ErrInvalidScope is returned by ResolveClientScopes for a scope that
+// doesn't exist or that the client isn't allowed to request.
+var ErrInvalidScope = errors.New("invalid scope")
+
+// ScopeInfo is an entry of core's scope registry. Builtin scopes are
+// Sentinel's own; the rest belong to the resource server named by
+// ClientID.
+type ScopeInfo struct {
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	Builtin         bool   `json:"builtin"`
+	ClientID        string `json:"client_id"`
+	ApplicationName string `json:"application_name"`
+	// AllowedWhilePending marks the scopes a dynamically registered client
+	// may request before an admin approves it.
+	AllowedWhilePending bool `json:"allowed_while_pending"`
+}
+
+type clientScopesResponse struct {
+	AllowedScopes   []string `json:"allowed_scopes"`
+	PendingScopes   []string `json:"pending_scopes"`
+	PendingApproval bool     `json:"pending_approval"`
+}
+
+// ListScopes fetches the scope registry from core.
+func ListScopes() ([]ScopeInfo, error) {
+	var scopes []ScopeInfo
+	if err := sentinel.Get("/api/scopes", &scopes); err != nil {
+		return nil, fmt.Errorf("load scope registry: %w", err)
+	}
+	return scopes, nil
+}
+
+// ResolveClientScopes checks that clientID may request every scope in
+// scopes and returns their registry entries, in request order. sentinel:all
+// is never allowed. A client with no allow-list may request any builtin
+// scope; one with a list may request exactly what's on it, less the scopes
+// still waiting on their resource server's approval. A client still
+// pending approval is further limited to the scopes core marks
+// AllowedWhilePending, and one whose token policy doesn't allow offline
+// access can't have offline_access.
+func ResolveClientScopes(clientID string, scopes string) ([]ScopeInfo, error) {
+	var app clientScopesResponse
+	if err := sentinel.Get("/api/applications/client/"+clientID, &app); err != nil {
+		return nil, fmt.Errorf("load application %s: %w", clientID, err)
+	}
+	registry, err := ListScopes()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]ScopeInfo, len(registry))
+	for _, info := range registry {
+		byName[info.Name] = info
+	}
+
+	var resolved []ScopeInfo
 	for _, scope := range strings.Fields(scopes) {
-		if _, ok := model.ValidScopes[scope]; !ok {
-			return false
+		info, ok := byName[scope]
+		if !ok || scope == "sentinel:all" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+		if len(app.AllowedScopes) > 0 {
+			if !slices.Contains(app.AllowedScopes, scope) {
+				return nil, fmt.Errorf("%w: %s is not allowed for this client", ErrInvalidScope, scope)
+			}
+		} else if !info.Builtin {
+			return nil, fmt.Errorf("%w: %s is not allowed for this client", ErrInvalidScope, scope)
+		}
+		if slices.Contains(app.PendingScopes, scope) {
+			return nil, fmt.Errorf("%w: %s is waiting for %s to approve this client", ErrInvalidScope, scope, info.ApplicationName)
+		}
+		if app.PendingApproval && !info.AllowedWhilePending {
+			return nil, fmt.Errorf("%w: %s is not allowed until an admin approves this client", ErrInvalidScope, scope)
+		}
+		if scope == "offline_access" {
+			policy, err := GetTokenPolicy(clientID)
+			if err != nil {
+				return nil, err
+			}
+			if !policy.AllowOfflineAccess {
+				return nil, fmt.Errorf("%w: offline_access is not allowed for this client", ErrInvalidScope)
+			}
+		}
+		resolved = append(resolved, info)
+	}
+	return resolved, nil
+}
+
+// ResourceAudiences returns the client_ids of the resource servers whose
+// scopes appear in scopes, for the access token's aud.
+func ResourceAudiences(scopes string) ([]string, error) {
+	registry, err := ListScopes()
+	if err != nil {
+		return nil, err
+	}
+	var audiences []string
+	for _, info := range registry {
+		if info.ClientID != "" && ScopesContain(scopes, info.Name) && !slices.Contains(audiences, info.ClientID) {
+			audiences = append(audiences, info.ClientID)
 		}
 	}
-	return true
+	return audiences, nil
 }
 
 func ScopesContain(scopes string, target string) bool {
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// testScopeRegistry is a registry with Sentinel's sign-in scopes, one of
+// its data scopes, and a scope another application defines.
+var testScopeRegistry = []ScopeInfo{
+	{Name: "openid", Builtin: true, AllowedWhilePending: true},
+	{Name: "email", Builtin: true, AllowedWhilePending: true},
+	{Name: "offline_access", Builtin: true},
+	{Name: "groups:read", Builtin: true},
+	{Name: "sentinel:all", Builtin: true},
+	{Name: "telemetry:read", ClientID: "telemetry", ApplicationName: "Telemetry"},
+}
+
+// serveClientScopes has the fake core answer for client's application and
+// the scope registry.
+func serveClientScopes(t *testing.T, client string, app clientScopesResponse) {
+	t.Helper()
+	handleCore(t, http.MethodGet, "/api/applications/client/"+client, respondCore(http.StatusOK, app))
+	handleCore(t, http.MethodGet, "/api/scopes", respondCore(http.StatusOK, testScopeRegistry))
+}
+
+func TestResolveClientScopesPendingApproval(t *testing.T) {
+	serveClientScopes(t, "new-client", clientScopesResponse{
+		AllowedScopes:   []string{"openid", "email", "groups:read"},
+		PendingApproval: true,
+	})
+
+	resolved, err := ResolveClientScopes("new-client", "openid email")
+	if err != nil {
+		t.Fatalf("sign-in scopes: %v", err)
+	}
+	if len(resolved) != 2 || resolved[0].Name != "openid" || resolved[1].Name != "email" {
+		t.Errorf("ResolveClientScopes = %+v, want openid and email in order", resolved)
+	}
+	// On the allow-list, but not until an admin approves the client.
+	if _, err := ResolveClientScopes("new-client", "openid groups:read"); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("data scope before approval: error = %v, want ErrInvalidScope", err)
+	}
+}
+
+func TestResolveClientScopesAllowList(t *testing.T) {
+	serveClientScopes(t, "open-client", clientScopesResponse{})
+	serveClientScopes(t, "listed-client", clientScopesResponse{AllowedScopes: []string{"openid", "telemetry:read"}})
+
+	tests := []struct {
+		name    string
+		client  string
+		scopes  string
+		wantErr bool
+	}{
+		{"no list, builtin", "open-client", "openid groups:read", false},
+		{"no list, another app's scope", "open-client", "telemetry:read", true},
+		{"no list, sentinel:all", "open-client", "sentinel:all", true},
+		{"unknown scope", "open-client", "nope:read", true},
+		{"listed", "listed-client", "openid telemetry:read", false},
+		{"builtin off the list", "listed-client", "groups:read", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ResolveClientScopes(tt.client, tt.scopes)
+			if tt.wantErr != errors.Is(err, ErrInvalidScope) || (!tt.wantErr && err != nil) {
+				t.Errorf("ResolveClientScopes(%q) error = %v, want error: %v", tt.scopes, err, tt.wantErr)
+			}
+		})
+	}
+}
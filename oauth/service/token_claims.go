@@ -13,6 +13,10 @@ import (
 // this as `access_denied` per RFC 6749.
 var ErrAccessDenied = errors.New("access denied: user does not meet the required group membership for this application")
 
+// ErrAccountInactive is returned by CheckEntityActive (and so by
+// CheckAccessGate) for a suspended or offboarded user.
+var ErrAccountInactive = errors.New("access denied: this account is suspended or offboarded")
+
 type entityResponse struct {
 	ID             string    `json:"id"`
 	Type           string    `json:"type"`
@@ -55,6 +59,14 @@ type applicationGroupLink struct {
 // The groups claim is only populated when the scope grants it (see
 // GroupsClaimAllowed); when present it follows the per-client visibility rules.
 //
+// The roles claim carries the client application's roles the entity holds
+// (see SetRolesClaim). It isn't gated by scope: roles are defined by the
+// client for itself, so it's the only audience that sees them.
+//
+// Groups, roles, and email are optional claims: each is only included when
+// the client's token policy asks for it. Email also needs the email scope,
+// and honours the policy's shared identity.
+//
 // Gate enforcement (CheckAccessGate) is a separate call — BuildTokenClaims
 // assumes the gate has already been passed.
 //
@@ -76,17 +88,44 @@ func BuildTokenClaims(entityID string, clientID string, scope string) (map[strin
 		claims["service_account_id"] = entity.ServiceAccount.ID
 	}
 
-	if GroupsClaimAllowed(scope) {
-		groups, err := FilteredGroups(entityID, clientID)
+	policy, err := GetTokenPolicy(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := setOptionalClaims(claims, policy, entityID, clientID, scope); err != nil {
+		return nil, err
+	}
+	if policy.IncludesClaim(OptionalClaimEmail) && ScopesContain(scope, "email") {
+		e, err := fetchOIDCEntity(entityID)
 		if err != nil {
 			return nil, err
 		}
-		SetGroupClaims(claims, groups)
+		for k, v := range identityClaims(applySharedIdentity(policy, e), "email") {
+			claims[k] = v
+		}
 	}
 
 	return claims, nil
 }
 
+// setOptionalClaims writes the groups and roles claims, each only when the
+// policy includes it. Groups still need a scope that grants them.
+func setOptionalClaims(claims map[string]interface{}, policy TokenPolicy, entityID string, clientID string, scope string) error {
+	if policy.IncludesClaim(OptionalClaimGroups) && GroupsClaimAllowed(scope) {
+		groups, err := FilteredGroups(entityID, clientID)
+		if err != nil {
+			return err
+		}
+		SetGroupClaims(claims, groups)
+	}
+	if policy.IncludesClaim(OptionalClaimRoles) {
+		if err := SetRolesClaim(claims, entityID, clientID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SetGroupClaims writes the group claims onto a claim map: `groups` holds the
 // human-readable names (what RBAC policies key on) and `group_ids` holds the
 // stable ULIDs (for consumers that need rename-safe references).
@@ -101,6 +140,33 @@ func SetGroupClaims(claims map[string]interface{}, groups []GroupRef) {
 	claims["group_ids"] = ids
 }
 
+// SetRolesClaim writes the `roles` claim: the names of the roles the entity
+// holds on the client's application, as mapped from its groups by core. The
+// claim is left out when the entity holds none.
+func SetRolesClaim(claims map[string]interface{}, entityID string, clientID string) error {
+	roles, err := GetEntityRoles(entityID, clientID)
+	if err != nil {
+		return err
+	}
+	if len(roles) > 0 {
+		claims["roles"] = roles
+	}
+	return nil
+}
+
+// GetEntityRoles returns the names of the roles the entity holds on the
+// application with the given client_id.
+func GetEntityRoles(entityID string, clientID string) ([]string, error) {
+	if clientID == "" {
+		return nil, nil
+	}
+	var roles []string
+	if err := sentinel.Get("/api/core/applications/client/"+clientID+"/roles/"+entityID, &roles); err != nil {
+		return nil, fmt.Errorf("load roles for entity %s on client %s: %w", entityID, clientID, err)
+	}
+	return roles, nil
+}
+
 // GroupsClaimAllowed reports whether a token carrying the given scope should
 // include the groups claim — granted by the first-party sentinel:all scope or
 // the explicit groups:read scope.
@@ -144,7 +210,8 @@ func FilteredGroups(entityID string, clientID string) ([]GroupRef, error) {
 	return filtered, nil
 }
 
-// CheckAccessGate returns ErrAccessDenied when the entity is not in at
+// CheckAccessGate returns ErrAccountInactive for a suspended or offboarded
+// user, and ErrAccessDenied when the entity is not in at
 // least one Required-flagged group on the application. Apps with no
 // required-flagged links are open to anyone. The Sentinel client follows
 // the same rule — if it has required links of its own, they apply.
@@ -153,6 +220,9 @@ func FilteredGroups(entityID string, clientID string) ([]GroupRef, error) {
 // evaluated (core fetch failed). Callers must treat that as a denial, never
 // as "open" — otherwise a transient core outage would bypass gating.
 func CheckAccessGate(entityID, clientID string) error {
+	if err := CheckEntityActive(entityID); err != nil {
+		return err
+	}
 	links, err := getAppGroupLinks(clientID)
 	if err != nil {
 		return err
@@ -182,6 +252,29 @@ func CheckAccessGate(entityID, clientID string) error {
 	return ErrAccessDenied
 }
 
+// entityStatusResponse is the slice of core's entity response that carries
+// the user's lifecycle status.
+type entityStatusResponse struct {
+	User *struct {
+		Status string `json:"status"`
+	} `json:"user"`
+}
+
+// CheckEntityActive returns ErrAccountInactive when the entity is a
+// suspended or offboarded user. Core refuses to mint or validate tokens for
+// them anyway; checking up front lets login and authorize fail with a clear
+// denial instead of a token error. Fails closed like CheckAccessGate.
+func CheckEntityActive(entityID string) error {
+	var entity entityStatusResponse
+	if err := sentinel.Get("/api/core/entity/"+entityID, &entity); err != nil {
+		return fmt.Errorf("load entity %s: %w", entityID, err)
+	}
+	if entity.User != nil && (entity.User.Status == "SUSPENDED" || entity.User.Status == "OFFBOARDED") {
+		return ErrAccountInactive
+	}
+	return nil
+}
+
 func isSentinelClient(clientID string) bool {
 	return clientID == config.SentinelClientID
 }
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Token type identifiers from RFC 8693. Sentinel's access tokens are JWTs,
+// so either name is accepted for them.
+const (
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeJWT         = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+var (
+	// ErrExchangeScope is returned by ExchangeScope when the requested scope
+	// isn't covered by the subject token.
+	ErrExchangeScope = errors.New("requested scope is not covered by the subject token")
+	// ErrExchangeSubject is returned by AuthorizeExchange when the subject
+	// token wasn't issued to the actor's client.
+	ErrExchangeSubject = errors.New("subject_token was not issued to the actor's client")
+	// ErrExchangeTarget is returned by AuthorizeExchange for an audience the
+	// actor's client isn't allowed to exchange tokens for.
+	ErrExchangeTarget = errors.New("the actor's client may not exchange tokens for that audience")
+)
+
+// AuthorizeExchange checks that the service behind actorClientID may
+// exchange the subject token for audience. The user must have handed the
+// token to that service, so its aud has to include actorClientID; and the
+// audience must be actorClientID itself or one of exchangeAudiences, the
+// allow-list on the actor's application.
+func AuthorizeExchange(actorClientID string, exchangeAudiences []string, subjectClaims map[string]interface{}, audience string) error {
+	if actorClientID == "" || !slices.Contains(ClaimAudiences(subjectClaims), actorClientID) {
+		return ErrExchangeSubject
+	}
+	if audience != actorClientID && !slices.Contains(exchangeAudiences, audience) {
+		return ErrExchangeTarget
+	}
+	return nil
+}
+
+// ClaimAudiences returns a token's aud claim as a list, whether it was
+// issued as one string or an array.
+func ClaimAudiences(claims map[string]interface{}) []string {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		audiences := make([]string, 0, len(aud))
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+		return audiences
+	case []string:
+		return aud
+	}
+	return nil
+}
+
+// ExchangeScope resolves the scope for a token-exchange request. An empty
+// request takes everything the subject token had; otherwise every requested
+// scope must already be held. sentinel:all in the subject covers any scope,
+// but is never passed on — a delegated token is never first-party. Whether
+// the target may receive the scopes is ResolveClientScopes' job.
+func ExchangeScope(requested string, subjectScope string) (string, error) {
+	subjectScope = RemoveScope(subjectScope, "refresh_token")
+	superScope := ScopesContain(subjectScope, "sentinel:all")
+	if requested == "" {
+		requested = RemoveScope(subjectScope, "sentinel:all")
+	}
+	scopes := strings.Fields(requested)
+	if len(scopes) == 0 {
+		return "", ErrExchangeScope
+	}
+	for _, scope := range scopes {
+		if scope == "sentinel:all" {
+			return "", ErrExchangeScope
+		}
+		if !superScope && !ScopesContain(subjectScope, scope) {
+			return "", ErrExchangeScope
+		}
+	}
+	return strings.Join(scopes, " "), nil
+}
+
+// BuildActClaim builds the RFC 8693 act claim naming the service behind
+// actorClaims. If the subject token was itself delegated, its act is nested
+// inside, so the full chain of services stays visible.
+func BuildActClaim(actorClaims map[string]interface{}, subjectClaims map[string]interface{}) map[string]interface{} {
+	act := map[string]interface{}{}
+	if sub, ok := actorClaims["sub"].(string); ok {
+		act["sub"] = sub
+	}
+	if aud := ClaimAudiences(actorClaims); len(aud) > 0 {
+		act["client_id"] = aud[0]
+	}
+	if prior, ok := subjectClaims["act"].(map[string]interface{}); ok {
+		act["act"] = prior
+	}
+	return act
+}
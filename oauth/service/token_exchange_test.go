@@ -0,0 +1,94 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAuthorizeExchange(t *testing.T) {
+	subject := map[string]interface{}{"aud": []interface{}{"sentinel", "actor"}}
+	tests := []struct {
+		name      string
+		actor     string
+		allowed   []string
+		subject   map[string]interface{}
+		audience  string
+		wantError error
+	}{
+		{"down to itself", "actor", nil, subject, "actor", nil},
+		{"to an allowed audience", "actor", []string{"downstream"}, subject, "downstream", nil},
+		{"to an audience off the list", "actor", []string{"downstream"}, subject, "elsewhere", ErrExchangeTarget},
+		{"with no list", "actor", nil, subject, "downstream", ErrExchangeTarget},
+		{"subject issued to another client", "other", []string{"downstream"}, subject, "downstream", ErrExchangeSubject},
+		{"subject with a string aud", "actor", nil, map[string]interface{}{"aud": "actor"}, "actor", nil},
+		{"subject without aud", "actor", nil, map[string]interface{}{}, "actor", ErrExchangeSubject},
+		{"no actor client", "", []string{""}, map[string]interface{}{"aud": ""}, "", ErrExchangeSubject},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := AuthorizeExchange(tt.actor, tt.allowed, tt.subject, tt.audience); err != tt.wantError {
+				t.Errorf("AuthorizeExchange = %v, want %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestClaimAudiences(t *testing.T) {
+	tests := []struct {
+		aud  interface{}
+		want []string
+	}{
+		{"one", []string{"one"}},
+		{[]interface{}{"one", 2, "three"}, []string{"one", "three"}},
+		{[]string{"one", "two"}, []string{"one", "two"}},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		if got := ClaimAudiences(map[string]interface{}{"aud": tt.aud}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ClaimAudiences(%v) = %v, want %v", tt.aud, got, tt.want)
+		}
+	}
+}
+
+func TestExchangeScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		subject   string
+		want      string
+		wantError error
+	}{
+		{"everything the subject had", "", "openid email refresh_token", "openid email", nil},
+		{"a subset", "email", "openid email", "email", nil},
+		{"more than the subject had", "email groups:read", "openid email", "", ErrExchangeScope},
+		{"sentinel:all covers any scope", "groups:read", "sentinel:all", "groups:read", nil},
+		{"sentinel:all is never passed on", "sentinel:all", "sentinel:all", "", ErrExchangeScope},
+		{"nothing left to pass on", "", "sentinel:all refresh_token", "", ErrExchangeScope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExchangeScope(tt.requested, tt.subject)
+			if err != tt.wantError || got != tt.want {
+				t.Errorf("ExchangeScope(%q, %q) = %q, %v, want %q, %v", tt.requested, tt.subject, got, err, tt.want, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestBuildActClaim(t *testing.T) {
+	actor := map[string]interface{}{"sub": "ent_service", "aud": []interface{}{"actor", "sentinel"}}
+	prior := map[string]interface{}{"sub": "ent_first", "client_id": "first"}
+
+	got := BuildActClaim(actor, map[string]interface{}{"sub": "ent_user"})
+	want := map[string]interface{}{"sub": "ent_service", "client_id": "actor"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildActClaim = %v, want %v", got, want)
+	}
+
+	// A token that was already delegated keeps its chain.
+	got = BuildActClaim(actor, map[string]interface{}{"sub": "ent_user", "act": prior})
+	want = map[string]interface{}{"sub": "ent_service", "client_id": "actor", "act": prior}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildActClaim of a delegated subject = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gaucho-racing/sentinel/oauth/config"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
+)
+
+// Optional claims a token policy can include, matching core's
+// model.OptionalClaims.
+const (
+	OptionalClaimGroups = "groups"
+	OptionalClaimRoles  = "roles"
+	OptionalClaimEmail  = "email"
+)
+
+// TokenPolicy is core's per-application token policy. Lifetimes are in
+// seconds, with zero meaning this service's configured default; use the
+// methods rather than the fields.
+type TokenPolicy struct {
+	AccessTokenTTL          int      `json:"access_token_ttl"`
+	IDTokenTTL              int      `json:"id_token_ttl"`
+	RefreshTokenIdleTTL     int      `json:"refresh_token_idle_ttl"`
+	RefreshTokenAbsoluteTTL int      `json:"refresh_token_absolute_ttl"`
+	AllowOfflineAccess      bool     `json:"allow_offline_access"`
+	OptionalClaims          []string `json:"optional_claims"`
+	SharedEmail             string   `json:"shared_email"`
+	SharedUsername          string   `json:"shared_username"`
+	SharedFirstName         string   `json:"shared_first_name"`
+	SharedLastName          string   `json:"shared_last_name"`
+}
+
+// defaultTokenPolicy applies to clients without an application, and
+// matches core's model.DefaultTokenPolicy.
+var defaultTokenPolicy = TokenPolicy{
+	AllowOfflineAccess: true,
+	OptionalClaims:     []string{OptionalClaimGroups, OptionalClaimRoles},
+}
+
+// GetTokenPolicy loads clientID's token policy from core. A client with no
+// application, such as the Sentinel client on a fresh install, gets the
+// default policy. Fails closed on any other error: a policy may withhold
+// claims or refresh tokens, so issuing without it isn't safe.
+func GetTokenPolicy(clientID string) (TokenPolicy, error) {
+	var policy TokenPolicy
+	if err := sentinel.Get("/api/core/applications/client/"+clientID+"/token-policy", &policy); err != nil {
+		var apiErr *sentinel.APIError
+		if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+			return defaultTokenPolicy, nil
+		}
+		return TokenPolicy{}, fmt.Errorf("load token policy for client %s: %w", clientID, err)
+	}
+	return policy, nil
+}
+
+// AccessTTL is the lifetime of the client's access tokens.
+func (p TokenPolicy) AccessTTL() int {
+	return orDefault(p.AccessTokenTTL, config.AccessTokenTTL)
+}
+
+// IDTTL is the lifetime of the client's ID tokens.
+func (p TokenPolicy) IDTTL() int {
+	return orDefault(p.IDTokenTTL, config.IDTokenTTL)
+}
+
+// RefreshIdleTTL is how long the client's refresh tokens last unused.
+func (p TokenPolicy) RefreshIdleTTL() int {
+	return orDefault(p.RefreshTokenIdleTTL, config.RefreshTokenTTL)
+}
+
+// RefreshAbsoluteTTL is how long the client's refresh token families last.
+func (p TokenPolicy) RefreshAbsoluteTTL() int {
+	return orDefault(p.RefreshTokenAbsoluteTTL, config.RefreshTokenAbsoluteTTL)
+}
+
+// IncludesClaim reports whether the policy includes an optional claim.
+func (p TokenPolicy) IncludesClaim(claim string) bool {
+	return slices.Contains(p.OptionalClaims, claim)
+}
+
+// SharedIdentity reports whether the client sees every user as one shared
+// identity instead of themselves.
+func (p TokenPolicy) SharedIdentity() bool {
+	return p.SharedEmail != ""
+}
+
+func orDefault(seconds, fallback int) int {
+	if seconds > 0 {
+		return seconds
+	}
+	return fallback
+}
+
+// applySharedIdentity returns e with its identity fields replaced by the
+// policy's shared identity, if it has one. The entity ID is left untouched
+// so the issued token's `sub` still identifies the real user.
+func applySharedIdentity(policy TokenPolicy, e oidcEntity) oidcEntity {
+	if !policy.SharedIdentity() {
+		return e
+	}
+	e.EmailAuth.Email = policy.SharedEmail
+	// Copy the user rather than write through the caller's pointer.
+	user := oidcUser{}
+	if e.User != nil {
+		user = *e.User
+	}
+	e.User = &user
+	e.User.Email = policy.SharedEmail
+	e.User.Username = policy.SharedUsername
+	e.User.FirstName = policy.SharedFirstName
+	e.User.LastName = policy.SharedLastName
+	e.User.AvatarURL = ""
+	return e
+}
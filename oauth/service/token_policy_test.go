@@ -0,0 +1,145 @@
+package service
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gaucho-racing/sentinel/oauth/config"
+)
+
+func TestGetTokenPolicy(t *testing.T) {
+	policy := TokenPolicy{AccessTokenTTL: 300, OptionalClaims: []string{OptionalClaimEmail}}
+	handleCore(t, http.MethodGet, "/api/core/applications/client/with-policy/token-policy", respondCore(http.StatusOK, policy))
+	handleCore(t, http.MethodGet, "/api/core/applications/client/broken/token-policy", respondCore(http.StatusInternalServerError, map[string]string{"error": "database is down"}))
+
+	if got, err := GetTokenPolicy("with-policy"); err != nil || !reflect.DeepEqual(got, policy) {
+		t.Errorf("GetTokenPolicy = %+v, %v, want core's policy", got, err)
+	}
+	// No application, no policy: the default applies.
+	if got, err := GetTokenPolicy("no-app"); err != nil || !reflect.DeepEqual(got, defaultTokenPolicy) {
+		t.Errorf("GetTokenPolicy for an unknown client = %+v, %v, want the default", got, err)
+	}
+	// Any other failure must not fall back to the default.
+	if _, err := GetTokenPolicy("broken"); err == nil {
+		t.Error("GetTokenPolicy with core failing = nil error, want it to fail closed")
+	}
+}
+
+func TestTokenPolicyLifetimes(t *testing.T) {
+	withDefaultTTLs(t)
+
+	var defaults TokenPolicy
+	if defaults.AccessTTL() != config.AccessTokenTTL || defaults.IDTTL() != config.IDTokenTTL ||
+		defaults.RefreshIdleTTL() != config.RefreshTokenTTL || defaults.RefreshAbsoluteTTL() != config.RefreshTokenAbsoluteTTL {
+		t.Error("a policy without lifetimes should use the configured defaults")
+	}
+	custom := TokenPolicy{AccessTokenTTL: 60, IDTokenTTL: 120, RefreshTokenIdleTTL: 180, RefreshTokenAbsoluteTTL: 240}
+	if custom.AccessTTL() != 60 || custom.IDTTL() != 120 || custom.RefreshIdleTTL() != 180 || custom.RefreshAbsoluteTTL() != 240 {
+		t.Error("a policy's own lifetimes should win over the defaults")
+	}
+}
+
+func TestApplySharedIdentity(t *testing.T) {
+	e := oidcEntity{ID: "ent_1", User: &oidcUser{Username: "alice", FirstName: "Alice", LastName: "Liddell", Email: "alice@example.com", AvatarURL: "https://cdn.example.com/a.png"}}
+	e.EmailAuth.Email = "alice@example.com"
+
+	if got := applySharedIdentity(TokenPolicy{}, e); !reflect.DeepEqual(got, e) {
+		t.Errorf("without a shared identity the entity should be unchanged, got %+v", got)
+	}
+
+	policy := TokenPolicy{SharedEmail: "team@example.com", SharedUsername: "team", SharedFirstName: "Team", SharedLastName: "Account"}
+	shared := applySharedIdentity(policy, e)
+	if shared.ID != "ent_1" {
+		t.Errorf("ID = %q, want the real entity kept for sub", shared.ID)
+	}
+	want := oidcUser{Username: "team", FirstName: "Team", LastName: "Account", Email: "team@example.com"}
+	if shared.EmailAuth.Email != "team@example.com" || *shared.User != want {
+		t.Errorf("shared identity = %+v / %+v, want %+v", shared.EmailAuth, *shared.User, want)
+	}
+	if e.User.Username != "alice" {
+		t.Error("applySharedIdentity modified the caller's user")
+	}
+}
+
+func TestBuildTokenClaims(t *testing.T) {
+	handleCore(t, http.MethodGet, "/api/core/entity/ent_1", respondCore(http.StatusOK, map[string]interface{}{
+		"id":         "ent_1",
+		"type":       "USER",
+		"user":       map[string]string{"id": "usr_1", "username": "alice", "email": "alice@example.com"},
+		"email_auth": map[string]string{"email": "alice@example.com"},
+	}))
+	handleCore(t, http.MethodGet, "/api/core/entity/ent_1/groups", respondCore(http.StatusOK, []map[string]string{
+		{"id": "grp_eng", "name": "Engineering"},
+		{"id": "grp_social", "name": "Social"},
+	}))
+	handleCore(t, http.MethodGet, "/api/core/applications/client/app/groups", respondCore(http.StatusOK, []map[string]interface{}{{"id": "grp_eng"}}))
+	handleCore(t, http.MethodGet, "/api/core/applications/client/"+config.SentinelClientID+"/groups", respondCore(http.StatusOK, []map[string]interface{}{}))
+	handleCore(t, http.MethodGet, "/api/core/applications/client/app/roles/ent_1", respondCore(http.StatusOK, []string{"editor"}))
+
+	setPolicy := func(t *testing.T, policy TokenPolicy) {
+		handleCore(t, http.MethodGet, "/api/core/applications/client/app/token-policy", respondCore(http.StatusOK, policy))
+	}
+
+	t.Run("default claims", func(t *testing.T) {
+		setPolicy(t, defaultTokenPolicy)
+		claims, err := BuildTokenClaims("ent_1", "app", "openid groups:read email")
+		if err != nil {
+			t.Fatalf("BuildTokenClaims: %v", err)
+		}
+		if claims["entity_type"] != "USER" || claims["user_id"] != "usr_1" {
+			t.Errorf("identity claims = %v", claims)
+		}
+		if !reflect.DeepEqual(claims["groups"], []string{"Engineering"}) || !reflect.DeepEqual(claims["group_ids"], []string{"grp_eng"}) {
+			t.Errorf("groups = %v / %v, want only the app's linked group", claims["groups"], claims["group_ids"])
+		}
+		if !reflect.DeepEqual(claims["roles"], []string{"editor"}) {
+			t.Errorf("roles = %v, want [editor]", claims["roles"])
+		}
+		if _, ok := claims["email"]; ok {
+			t.Error("email is in access tokens only when the policy asks for it")
+		}
+	})
+
+	t.Run("groups need the scope too", func(t *testing.T) {
+		setPolicy(t, defaultTokenPolicy)
+		claims, err := BuildTokenClaims("ent_1", "app", "openid")
+		if err != nil {
+			t.Fatalf("BuildTokenClaims: %v", err)
+		}
+		if _, ok := claims["groups"]; ok {
+			t.Errorf("groups = %v without groups:read", claims["groups"])
+		}
+	})
+
+	t.Run("no optional claims", func(t *testing.T) {
+		setPolicy(t, TokenPolicy{OptionalClaims: []string{}})
+		claims, err := BuildTokenClaims("ent_1", "app", "openid groups:read email")
+		if err != nil {
+			t.Fatalf("BuildTokenClaims: %v", err)
+		}
+		for _, claim := range []string{"groups", "group_ids", "roles", "email"} {
+			if _, ok := claims[claim]; ok {
+				t.Errorf("%s = %v, want it left out", claim, claims[claim])
+			}
+		}
+	})
+
+	t.Run("email with a shared identity", func(t *testing.T) {
+		setPolicy(t, TokenPolicy{OptionalClaims: []string{OptionalClaimEmail}, SharedEmail: "team@example.com"})
+		claims, err := BuildTokenClaims("ent_1", "app", "openid email")
+		if err != nil {
+			t.Fatalf("BuildTokenClaims: %v", err)
+		}
+		if claims["email"] != "team@example.com" {
+			t.Errorf("email = %v, want the shared address", claims["email"])
+		}
+		claims, err = BuildTokenClaims("ent_1", "app", "openid")
+		if err != nil {
+			t.Fatalf("BuildTokenClaims: %v", err)
+		}
+		if _, ok := claims["email"]; ok {
+			t.Error("email needs the email scope as well as the policy")
+		}
+	})
+}
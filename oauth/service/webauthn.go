@@ -0,0 +1,407 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gaucho-racing/sentinel/oauth/config"
+	"github.com/gaucho-racing/sentinel/oauth/database"
+	"github.com/gaucho-racing/sentinel/oauth/model"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/logger"
+	"github.com/gaucho-racing/sentinel/oauth/pkg/sentinel"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidWebAuthnSession covers unknown, expired, already-finished, and
+// mismatched ceremony sessions alike.
+var ErrInvalidWebAuthnSession = errors.New("invalid or expired passkey session")
+
+// ErrPasskeyRejected is returned when the authenticator's response fails
+// verification. The library's reason is logged, not surfaced.
+var ErrPasskeyRejected = errors.New("passkey could not be verified")
+
+const webAuthnSessionTTL = 5 * time.Minute
+
+var webAuthn *webauthn.WebAuthn
+
+func InitWebAuthn() {
+	var origins []string
+	for _, o := range strings.Split(config.WebAuthnRPOrigins, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			origins = append(origins, o)
+		}
+	}
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          config.WebAuthnRPID,
+		RPDisplayName: config.WebAuthnRPDisplayName,
+		RPOrigins:     origins,
+	})
+	if err != nil {
+		logger.SugarLogger.Fatalf("Failed to initialize WebAuthn: %v", err)
+	}
+	webAuthn = w
+}
+
+// Passkey mirrors core's model.EntityPasskey.
+type Passkey struct {
+	ID                string     `json:"id"`
+	EntityID          string     `json:"entity_id"`
+	CredentialID      string     `json:"credential_id"`
+	PublicKey         []byte     `json:"public_key"`
+	AttestationType   string     `json:"attestation_type"`
+	AttestationFormat string     `json:"attestation_format"`
+	AAGUID            []byte     `json:"aaguid"`
+	Transports        []string   `json:"transports"`
+	UserPresent       bool       `json:"user_present"`
+	UserVerified      bool       `json:"user_verified"`
+	BackupEligible    bool       `json:"backup_eligible"`
+	BackupState       bool       `json:"backup_state"`
+	Name              string     `json:"name"`
+	LastUsedAt        *time.Time `json:"last_used_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	SignCount         uint32     `json:"sign_count"`
+}
+
+func (p Passkey) credential() webauthn.Credential {
+	id, _ := base64.RawURLEncoding.DecodeString(p.CredentialID)
+	transports := make([]protocol.AuthenticatorTransport, 0, len(p.Transports))
+	for _, t := range p.Transports {
+		transports = append(transports, protocol.AuthenticatorTransport(t))
+	}
+	return webauthn.Credential{
+		ID:                id,
+		PublicKey:         p.PublicKey,
+		AttestationType:   p.AttestationType,
+		AttestationFormat: p.AttestationFormat,
+		Transport:         transports,
+		Flags: webauthn.CredentialFlags{
+			UserPresent:    p.UserPresent,
+			UserVerified:   p.UserVerified,
+			BackupEligible: p.BackupEligible,
+			BackupState:    p.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    p.AAGUID,
+			SignCount: p.SignCount,
+		},
+	}
+}
+
+func passkeyFromCredential(cred *webauthn.Credential, name string) Passkey {
+	transports := make([]string, 0, len(cred.Transport))
+	for _, t := range cred.Transport {
+		transports = append(transports, string(t))
+	}
+	return Passkey{
+		CredentialID:      base64.RawURLEncoding.EncodeToString(cred.ID),
+		PublicKey:         cred.PublicKey,
+		AttestationType:   cred.AttestationType,
+		AttestationFormat: cred.AttestationFormat,
+		AAGUID:            cred.Authenticator.AAGUID,
+		Transports:        transports,
+		UserPresent:       cred.Flags.UserPresent,
+		UserVerified:      cred.Flags.UserVerified,
+		BackupEligible:    cred.Flags.BackupEligible,
+		BackupState:       cred.Flags.BackupState,
+		Name:              name,
+		SignCount:         cred.Authenticator.SignCount,
+	}
+}
+
+// passkeyUser adapts an entity and its passkeys to webauthn.User. The user
+// handle is the entity ID, which is what a discoverable credential hands
+// back during passkey-only login.
+type passkeyUser struct {
+	entityID    string
+	name        string
+	displayName string
+	credentials []webauthn.Credential
+}
+
+func (u *passkeyUser) WebAuthnID() []byte                         { return []byte(u.entityID) }
+func (u *passkeyUser) WebAuthnName() string                       { return u.name }
+func (u *passkeyUser) WebAuthnDisplayName() string                { return u.displayName }
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
+
+func loadPasskeyUser(entityID string) (*passkeyUser, error) {
+	entity, err := fetchOIDCEntity(entityID)
+	if err != nil {
+		return nil, err
+	}
+	var passkeys []Passkey
+	if err := sentinel.Get("/api/core/entity/"+entityID+"/passkeys", &passkeys); err != nil {
+		return nil, err
+	}
+	user := &passkeyUser{
+		entityID:    entityID,
+		name:        entityID,
+		displayName: entityID,
+	}
+	if entity.EmailAuth.Email != "" {
+		user.name = entity.EmailAuth.Email
+		user.displayName = entity.EmailAuth.Email
+	}
+	if entity.User != nil {
+		if entity.User.Username != "" {
+			user.name = entity.User.Username
+		}
+		if full := strings.TrimSpace(entity.User.FirstName + " " + entity.User.LastName); full != "" {
+			user.displayName = full
+		}
+	}
+	for _, p := range passkeys {
+		user.credentials = append(user.credentials, p.credential())
+	}
+	return user, nil
+}
+
+func saveWebAuthnSession(purpose model.WebAuthnSessionPurpose, entityID string, mfaToken string, data *webauthn.SessionData) (string, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	session := model.WebAuthnSession{
+		ID:        generateCryptoString(32),
+		Purpose:   purpose,
+		EntityID:  entityID,
+		Data:      string(raw),
+		ExpiresAt: time.Now().Add(webAuthnSessionTTL),
+	}
+	if mfaToken != "" {
+		session.MFATokenHash = hashMFAToken(mfaToken)
+	}
+	if err := database.DB.Create(&session).Error; err != nil {
+		return "", err
+	}
+	return session.ID, nil
+}
+
+// takeWebAuthnSession loads and deletes a ceremony session in one step, so
+// a challenge can only ever be answered once.
+func takeWebAuthnSession(id string, purpose model.WebAuthnSessionPurpose) (model.WebAuthnSession, webauthn.SessionData, error) {
+	var sessions []model.WebAuthnSession
+	result := database.DB.Clauses(clause.Returning{}).
+		Where("id = ? AND purpose = ?", id, purpose).
+		Delete(&sessions)
+	if result.Error != nil {
+		return model.WebAuthnSession{}, webauthn.SessionData{}, result.Error
+	}
+	if len(sessions) == 0 || time.Now().After(sessions[0].ExpiresAt) {
+		return model.WebAuthnSession{}, webauthn.SessionData{}, ErrInvalidWebAuthnSession
+	}
+	var data webauthn.SessionData
+	if err := json.Unmarshal([]byte(sessions[0].Data), &data); err != nil {
+		return model.WebAuthnSession{}, webauthn.SessionData{}, err
+	}
+	return sessions[0], data, nil
+}
+
+func hashMFAToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordPasskeyUse pushes the post-assertion authenticator state to core.
+// Failure only loses counter bookkeeping, so it's logged and not fatal.
+func recordPasskeyUse(cred *webauthn.Credential) {
+	credentialID := base64.RawURLEncoding.EncodeToString(cred.ID)
+	if cred.Authenticator.CloneWarning {
+		logger.SugarLogger.Warnf("Passkey %s reported a non-increasing signature counter; it may be cloned", credentialID)
+	}
+	if err := sentinel.Post("/api/core/passkeys/"+credentialID+"/use", map[string]interface{}{
+		"sign_count":    cred.Authenticator.SignCount,
+		"user_verified": cred.Flags.UserVerified,
+		"backup_state":  cred.Flags.BackupState,
+	}, nil); err != nil {
+		logger.SugarLogger.Errorf("Failed to record use of passkey %s: %v", credentialID, err)
+	}
+}
+
+// BeginPasskeyRegistration starts registering a new passkey for a signed-in
+// entity. Existing credentials are excluded so the same authenticator isn't
+// registered twice, and a discoverable credential is required so the
+// passkey can later sign in without a username.
+func BeginPasskeyRegistration(entityID string) (*protocol.CredentialCreation, string, error) {
+	user, err := loadPasskeyUser(entityID)
+	if err != nil {
+		return nil, "", err
+	}
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.credentials))
+	for _, cred := range user.credentials {
+		exclusions = append(exclusions, cred.Descriptor())
+	}
+	creation, data, err := webAuthn.BeginRegistration(user,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			UserVerification:   protocol.VerificationPreferred,
+		}),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	sessionID, err := saveWebAuthnSession(model.WebAuthnSessionRegistration, entityID, "", data)
+	if err != nil {
+		return nil, "", err
+	}
+	return creation, sessionID, nil
+}
+
+// FinishPasskeyRegistration verifies the authenticator's attestation
+// response and stores the new credential in core.
+func FinishPasskeyRegistration(entityID string, sessionID string, name string, response []byte) (Passkey, error) {
+	session, data, err := takeWebAuthnSession(sessionID, model.WebAuthnSessionRegistration)
+	if err != nil {
+		return Passkey{}, err
+	}
+	if session.EntityID != entityID {
+		return Passkey{}, ErrInvalidWebAuthnSession
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		logger.SugarLogger.Errorf("passkey registration: unparseable response for %s: %v", entityID, err)
+		return Passkey{}, ErrPasskeyRejected
+	}
+	user, err := loadPasskeyUser(entityID)
+	if err != nil {
+		return Passkey{}, err
+	}
+	cred, err := webAuthn.CreateCredential(user, data, parsed)
+	if err != nil {
+		logger.SugarLogger.Errorf("passkey registration: verification failed for %s: %v", entityID, err)
+		return Passkey{}, ErrPasskeyRejected
+	}
+	var created Passkey
+	if err := sentinel.Post("/api/core/entity/"+entityID+"/passkeys", passkeyFromCredential(cred, name), &created); err != nil {
+		return Passkey{}, err
+	}
+	logger.SugarLogger.Infof("Passkey %s registered for entity %s", created.ID, entityID)
+	return created, nil
+}
+
+// BeginPasskeyLogin starts a passkey-only sign-in. No user is named; the
+// browser offers whichever discoverable credentials it holds for this RP.
+// User verification is required because the passkey is the only factor.
+func BeginPasskeyLogin() (*protocol.CredentialAssertion, string, error) {
+	assertion, data, err := webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	sessionID, err := saveWebAuthnSession(model.WebAuthnSessionLogin, "", "", data)
+	if err != nil {
+		return nil, "", err
+	}
+	return assertion, sessionID, nil
+}
+
+// FinishPasskeyLogin verifies a discoverable assertion and returns the
+// entity that owns the credential.
+func FinishPasskeyLogin(sessionID string, response []byte) (string, error) {
+	_, data, err := takeWebAuthnSession(sessionID, model.WebAuthnSessionLogin)
+	if err != nil {
+		return "", err
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		logger.SugarLogger.Errorf("passkey login: unparseable response: %v", err)
+		return "", ErrPasskeyRejected
+	}
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		var passkey Passkey
+		if err := sentinel.Get("/api/core/passkeys/"+base64.RawURLEncoding.EncodeToString(rawID), &passkey); err != nil {
+			return nil, err
+		}
+		if passkey.EntityID != string(userHandle) {
+			return nil, fmt.Errorf("user handle does not match credential owner")
+		}
+		return loadPasskeyUser(passkey.EntityID)
+	}
+	user, cred, err := webAuthn.ValidatePasskeyLogin(handler, data, parsed)
+	if err != nil {
+		logger.SugarLogger.Errorf("passkey login: verification failed: %v", err)
+		return "", ErrPasskeyRejected
+	}
+	recordPasskeyUse(cred)
+	return string(user.WebAuthnID()), nil
+}
+
+// BeginPasskeyMFA starts a passkey assertion that completes an MFA login
+// challenge. Only the challenge entity's own credentials are allowed.
+func BeginPasskeyMFA(mfaToken string) (*protocol.CredentialAssertion, string, error) {
+	var challenge struct {
+		EntityID string `json:"entity_id"`
+	}
+	if err := sentinel.Post("/api/core/mfa/challenge/resolve", map[string]string{
+		"challenge_token": mfaToken,
+	}, &challenge); err != nil {
+		return nil, "", err
+	}
+	user, err := loadPasskeyUser(challenge.EntityID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(user.credentials) == 0 {
+		return nil, "", ErrPasskeyRejected
+	}
+	assertion, data, err := webAuthn.BeginLogin(user)
+	if err != nil {
+		return nil, "", err
+	}
+	sessionID, err := saveWebAuthnSession(model.WebAuthnSessionMFA, challenge.EntityID, mfaToken, data)
+	if err != nil {
+		return nil, "", err
+	}
+	return assertion, sessionID, nil
+}
+
+// MFAVerification is core's answer once a login challenge is completed.
+type MFAVerification struct {
+	EntityID string   `json:"entity_id"`
+	AMR      []string `json:"amr"`
+}
+
+// FinishPasskeyMFA verifies the assertion and, if it holds, asks core to
+// complete the login challenge it was begun for.
+func FinishPasskeyMFA(mfaToken string, sessionID string, response []byte) (MFAVerification, error) {
+	session, data, err := takeWebAuthnSession(sessionID, model.WebAuthnSessionMFA)
+	if err != nil {
+		return MFAVerification{}, err
+	}
+	if session.MFATokenHash != hashMFAToken(mfaToken) {
+		return MFAVerification{}, ErrInvalidWebAuthnSession
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		logger.SugarLogger.Errorf("passkey mfa: unparseable response for %s: %v", session.EntityID, err)
+		return MFAVerification{}, ErrPasskeyRejected
+	}
+	user, err := loadPasskeyUser(session.EntityID)
+	if err != nil {
+		return MFAVerification{}, err
+	}
+	cred, err := webAuthn.ValidateLogin(user, data, parsed)
+	if err != nil {
+		logger.SugarLogger.Errorf("passkey mfa: verification failed for %s: %v", session.EntityID, err)
+		return MFAVerification{}, ErrPasskeyRejected
+	}
+	recordPasskeyUse(cred)
+	var verification MFAVerification
+	if err := sentinel.Post("/api/core/mfa/challenge/passkey", map[string]string{
+		"challenge_token": mfaToken,
+		"credential_id":   base64.RawURLEncoding.EncodeToString(cred.ID),
+	}, &verification); err != nil {
+		return MFAVerification{}, err
+	}
+	return verification, nil
+}
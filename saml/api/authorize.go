@@ -1,7 +1,6 @@
 package api
 
 import (
-	"errors"
 	"net/http"
 
 	"github.com/gaucho-racing/sentinel/saml/pkg/logger"
@@ -14,7 +13,7 @@ import (
 // gate-evaluation failure fails closed with 502 rather than letting the login
 // through.
 func writeGateError(c *gin.Context, err error) {
-	if errors.Is(err, service.ErrAccessDenied) {
+	if service.IsAccessDenial(err) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "access_denied", "error_description": err.Error()})
 		return
 	}
@@ -57,7 +56,7 @@ func ValidateAuthorize(c *gin.Context) {
 		Require(c, RequestTokenHasEntityID(c, entityID))
 
 		if err := service.CheckAccessGate(entityID, sp.ClientID); err != nil {
-			if errors.Is(err, service.ErrAccessDenied) {
+			if service.IsAccessDenial(err) {
 				c.JSON(http.StatusForbidden, gin.H{"error": "access_denied", "app_name": sp.AppName, "app_icon_url": sp.AppIconURL})
 				return
 			}
@@ -108,7 +107,7 @@ func Authorize(c *gin.Context) {
 
 	form, err := service.GenerateResponse([]byte(stash.RequestBuffer), stash.RelayState, req.EntityID, GetClientIP(c), stash.CreatedAt)
 	if err != nil {
-		if errors.Is(err, service.ErrAccessDenied) {
+		if service.IsAccessDenial(err) {
 			writeGateError(c, err)
 			return
 		}
@@ -15,6 +15,10 @@ import (
 // of an application's required-flagged groups.
 var ErrAccessDenied = errors.New("access denied: user does not meet the required group membership for this application")
 
+// ErrAccountInactive is returned by CheckAccessGate for a suspended or
+// offboarded user.
+var ErrAccountInactive = errors.New("access denied: this account is suspended or offboarded")
+
 type entity struct {
 	ID        string `json:"id"`
 	Type      string `json:"type"`
@@ -27,6 +31,7 @@ type entity struct {
 		LastName  string `json:"last_name"`
 		Email     string `json:"email"`
 		AvatarURL string `json:"avatar_url"`
+		Status    string `json:"status"`
 	} `json:"user"`
 }
 
@@ -51,7 +56,9 @@ type applicationGroupLink struct {
 // SPs key on); identity attributes and the per-client filtered group set are
 // attached for the assertion. Groups are exposed both as session.Groups (which
 // the default assertion maker emits as eduPersonAffiliation) and as a plain
-// `groups` attribute, since most relying parties key on the latter.
+// `groups` attribute, since most relying parties key on the latter. The
+// application's roles the entity holds go in a multi-valued `role`
+// attribute, left out when there are none.
 func BuildSession(entityID string, clientID string) (*saml.Session, error) {
 	e, err := fetchEntity(entityID)
 	if err != nil {
@@ -61,6 +68,10 @@ func BuildSession(entityID string, clientID string) (*saml.Session, error) {
 	if err != nil {
 		return nil, err
 	}
+	roles, err := getEntityRoles(entityID, clientID)
+	if err != nil {
+		return nil, err
+	}
 
 	email := e.EmailAuth.Email
 	if email == "" && e.User != nil {
@@ -104,6 +115,9 @@ func BuildSession(entityID string, clientID string) (*saml.Session, error) {
 		stringAttribute("group_ids", ids),
 		stringAttribute("entity_id", []string{entityID}),
 	}
+	if len(roles) > 0 {
+		session.CustomAttributes = append(session.CustomAttributes, stringAttribute("role", roles))
+	}
 	return session, nil
 }
 
@@ -120,11 +134,19 @@ func stringAttribute(name string, values []string) saml.Attribute {
 	}
 }
 
-// CheckAccessGate returns ErrAccessDenied when the entity is in none of the
+// CheckAccessGate returns ErrAccountInactive for a suspended or offboarded
+// user, and ErrAccessDenied when the entity is in none of the
 // application's required-flagged groups. Apps with no required links are open.
 // Fails closed: a non-ErrAccessDenied error means the gate couldn't be
 // evaluated and callers must treat it as a denial.
 func CheckAccessGate(entityID, clientID string) error {
+	e, err := fetchEntity(entityID)
+	if err != nil {
+		return err
+	}
+	if e.User != nil && (e.User.Status == "SUSPENDED" || e.User.Status == "OFFBOARDED") {
+		return ErrAccountInactive
+	}
 	links, err := getAppGroupLinks(clientID)
 	if err != nil {
 		return err
@@ -187,6 +209,12 @@ func FilteredGroups(entityID string, clientID string) ([]GroupRef, error) {
 	return filtered, nil
 }
 
+// IsAccessDenial reports whether a CheckAccessGate error is a genuine
+// denial (as opposed to a failure to evaluate the gate).
+func IsAccessDenial(err error) bool {
+	return errors.Is(err, ErrAccessDenied) || errors.Is(err, ErrAccountInactive)
+}
+
 func fetchEntity(entityID string) (entity, error) {
 	var e entity
 	if err := sentinel.Get("/api/core/entity/"+entityID, &e); err != nil {
@@ -217,3 +245,14 @@ func getAppGroupLinks(clientID string) ([]applicationGroupLink, error) {
 	}
 	return links, nil
 }
+
+func getEntityRoles(entityID, clientID string) ([]string, error) {
+	if clientID == "" {
+		return nil, nil
+	}
+	var roles []string
+	if err := sentinel.Get("/api/core/applications/client/"+clientID+"/roles/"+entityID, &roles); err != nil {
+		return nil, fmt.Errorf("load roles for entity %s on client %s: %w", entityID, clientID, err)
+	}
+	return roles, nil
+}
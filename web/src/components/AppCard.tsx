@@ -1,6 +1,7 @@
 import { ChevronRight } from "lucide-react"
 import { Link } from "react-router-dom"
 
+import { Badge } from "@/components/ui/badge"
 import type { Application } from "@/lib/applications"
 
 function initial(name: string) {
@@ -42,7 +43,10 @@ export function AppCard({
             initial(app.name)
           )}
         </div>
-        <ChevronRight className="size-3.5 text-muted-foreground opacity-0 transition-opacity group-hover:opacity-100" />
+        <div className="flex items-center gap-1.5">
+          {app.pending_approval && <Badge variant="outline">Pending approval</Badge>}
+          <ChevronRight className="size-3.5 text-muted-foreground opacity-0 transition-opacity group-hover:opacity-100" />
+        </div>
       </div>
       <div>
         <p className="text-sm font-medium leading-none">{app.name}</p>
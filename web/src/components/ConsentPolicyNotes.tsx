@@ -0,0 +1,54 @@
+import { Clock, Users } from "lucide-react"
+
+// ConsentPolicy is the part of the authorize and device validate responses
+// that describes the app's token policy.
+export type ConsentPolicy = {
+  shared_identity?: string
+  offline_access?: boolean
+  session_lifetime?: number
+}
+
+function formatLifetime(seconds: number): string {
+  const days = Math.round(seconds / (24 * 60 * 60))
+  if (days >= 1) return days === 1 ? "1 day" : `${days} days`
+  const hours = Math.max(1, Math.round(seconds / (60 * 60)))
+  return hours === 1 ? "1 hour" : `${hours} hours`
+}
+
+// ConsentPolicyNotes tells the user, on a consent screen, how long the app
+// can keep them signed in and whether it sees them as a shared account.
+export function ConsentPolicyNotes({
+  appName,
+  policy,
+}: {
+  appName: string
+  policy: ConsentPolicy
+}) {
+  const notes: { key: string; icon: typeof Clock; text: string }[] = []
+  if (policy.shared_identity) {
+    notes.push({
+      key: "shared",
+      icon: Users,
+      text: `You'll appear to ${appName} as the shared account ${policy.shared_identity}.`,
+    })
+  }
+  if (policy.offline_access && policy.session_lifetime) {
+    notes.push({
+      key: "offline",
+      icon: Clock,
+      text: `${appName} can keep you signed in for up to ${formatLifetime(policy.session_lifetime)}.`,
+    })
+  }
+  if (notes.length === 0) return null
+
+  return (
+    <ul className="space-y-2">
+      {notes.map((note) => (
+        <li key={note.key} className="flex items-start gap-2 text-xs text-muted-foreground">
+          <note.icon className="mt-0.5 size-3.5 shrink-0" />
+          <span>{note.text}</span>
+        </li>
+      ))}
+    </ul>
+  )
+}
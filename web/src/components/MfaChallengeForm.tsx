@@ -0,0 +1,162 @@
+import { useEffect, useState } from "react"
+import { toast } from "sonner"
+
+import { OutlineButton } from "@/components/OutlineButton"
+import { Input } from "@/components/ui/input"
+import { Label } from "@/components/ui/label"
+import { api } from "@/lib/api"
+import { passkeysSupported, verifyMfaWithPasskey } from "@/lib/passkeys"
+
+// Returned by any /auth/login/* endpoint in place of a session when the
+// account needs a second factor.
+export type MfaChallenge = {
+  mfa_required: true
+  enrollment_required?: boolean
+  // Second factors the account has set up: "totp" and/or "passkey".
+  methods?: string[]
+  mfa_token: string
+}
+
+export type MfaSession = {
+  access_token: string
+  refresh_token: string
+  expires_in: number
+  entity_id: string
+  recovery_codes?: string[]
+}
+
+type Enrollment = { secret: string; otpauth_uri: string }
+
+export function isMfaChallenge(data: unknown): data is MfaChallenge {
+  return (data as { mfa_required?: boolean })?.mfa_required === true
+}
+
+function errorMessage(err: unknown, fallback: string) {
+  return (
+    (err as { response?: { data?: { error?: string } } })?.response?.data?.error ?? fallback
+  )
+}
+
+// Second step of a two-step login. For accounts a group policy forces into
+// MFA, it first walks through authenticator setup, then shows the one-time
+// recovery codes before handing the session back.
+export function MfaChallengeForm({
+  challenge,
+  onSession,
+}: {
+  challenge: MfaChallenge
+  onSession: (session: MfaSession) => void
+}) {
+  const [code, setCode] = useState("")
+  const [loading, setLoading] = useState(false)
+  const [enrollment, setEnrollment] = useState<Enrollment | null>(null)
+  const [pending, setPending] = useState<MfaSession | null>(null)
+  const canUsePasskey = passkeysSupported() && !!challenge.methods?.includes("passkey")
+  const canUseCode = challenge.enrollment_required || !challenge.methods || challenge.methods.includes("totp")
+
+  useEffect(() => {
+    if (!challenge.enrollment_required) return
+    api
+      .post<Enrollment>("/auth/login/mfa/enroll", { mfa_token: challenge.mfa_token })
+      .then((res) => setEnrollment(res.data))
+      .catch((err) => toast.error(errorMessage(err, "Couldn't start MFA setup.")))
+  }, [challenge])
+
+  async function handleSubmit(event: React.FormEvent) {
+    event.preventDefault()
+    if (loading) return
+    setLoading(true)
+    try {
+      const res = await api.post<MfaSession>("/auth/login/mfa", {
+        mfa_token: challenge.mfa_token,
+        code,
+      })
+      if (res.data.recovery_codes?.length) {
+        setPending(res.data)
+        setLoading(false)
+        return
+      }
+      onSession(res.data)
+    } catch (err: unknown) {
+      setLoading(false)
+      setCode("")
+      toast.error(errorMessage(err, "That code didn't work. Try again."))
+    }
+  }
+
+  async function handlePasskey() {
+    if (loading) return
+    setLoading(true)
+    try {
+      onSession(await verifyMfaWithPasskey<MfaSession>(challenge.mfa_token))
+    } catch (err: unknown) {
+      setLoading(false)
+      toast.error(errorMessage(err, "Couldn't verify your passkey. Try again."))
+    }
+  }
+
+  if (pending) {
+    return (
+      <div className="space-y-4">
+        <p className="text-sm text-muted-foreground">
+          Save these recovery codes somewhere safe. Each one works once if you lose your
+          authenticator. They won't be shown again.
+        </p>
+        <pre className="grid grid-cols-2 gap-1 rounded-md border border-border/60 bg-muted/30 p-3 font-mono text-xs">
+          {pending.recovery_codes!.map((c) => (
+            <span key={c}>{c}</span>
+          ))}
+        </pre>
+        <OutlineButton onClick={() => onSession(pending)}>I've saved them</OutlineButton>
+      </div>
+    )
+  }
+
+  const passkeyButton = canUsePasskey && (
+    <OutlineButton type="button" onClick={handlePasskey} loading={loading} disabled={loading}>
+      Use a passkey
+    </OutlineButton>
+  )
+
+  if (!canUseCode) {
+    return <div className="space-y-2">{passkeyButton}</div>
+  }
+
+  return (
+    <form onSubmit={handleSubmit} noValidate className="space-y-2">
+      {challenge.enrollment_required && (
+        <div className="space-y-2 rounded-md border border-border/60 bg-muted/30 p-4 text-sm">
+          <p>One of your groups requires two-factor authentication. Add Sentinel to an authenticator app:</p>
+          {enrollment ? (
+            <>
+              <a href={enrollment.otpauth_uri} className="text-foreground underline underline-offset-2">
+                Open in authenticator
+              </a>
+              <p className="text-muted-foreground">or enter this key manually:</p>
+              <code className="block break-all rounded bg-background px-2 py-1 font-mono text-xs">
+                {enrollment.secret}
+              </code>
+            </>
+          ) : (
+            <p className="text-muted-foreground">Generating a key…</p>
+          )}
+        </div>
+      )}
+      <Label htmlFor="mfa-code" className="sr-only">Authentication code</Label>
+      <Input
+        id="mfa-code"
+        autoComplete="one-time-code"
+        placeholder={challenge.enrollment_required ? "6-digit code" : "6-digit code or recovery code"}
+        value={code}
+        onChange={(e) => setCode(e.target.value)}
+        disabled={loading}
+        autoFocus
+        required
+      />
+      <OutlineButton type="submit" className="mt-4" loading={loading} disabled={loading}>
+        Verify
+      </OutlineButton>
+      {passkeyButton}
+    </form>
+  )
+}
@@ -1,6 +1,6 @@
 import { useQuery } from "@tanstack/react-query"
 
-import { api } from "@/lib/api"
+import { getAllPages } from "@/lib/api"
 import { loadSession } from "@/lib/auth"
 import type { GroupMember } from "@/lib/groups"
 
@@ -16,10 +16,7 @@ export function useAdmins() {
 
   const query = useQuery({
     queryKey: ["admins"],
-    queryFn: async () => {
-      const res = await api.get<GroupMember[]>(`/groups/${ADMINS_GROUP_ID}/members`)
-      return res.data
-    },
+    queryFn: () => getAllPages<GroupMember>(`/groups/${ADMINS_GROUP_ID}/members`),
     staleTime: 5 * 60 * 1000,
   })
 
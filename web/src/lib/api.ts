@@ -31,7 +31,10 @@ api.interceptors.response.use(
 
     // A 401 from these endpoints means "the credentials you just sent are
     // wrong," not "your bearer expired." Propagate so the caller can toast.
-    const isAuthEndpoint = url.includes("/auth/login") || url.includes("/auth/refresh")
+    const isAuthEndpoint =
+      url.includes("/auth/login") ||
+      url.includes("/auth/refresh") ||
+      url.includes("/auth/password-reset")
 
     if (status !== 401 || !original || original._retried || isAuthEndpoint) {
       return Promise.reject(error)
@@ -76,3 +79,37 @@ api.interceptors.response.use(
     return api(original)
   },
 )
+
+// Envelope returned by every list endpoint (core/service/pagination.go::Page).
+export type Page<T> = {
+  data: T[]
+  next_cursor: string
+  total: number
+}
+
+// Reads every page of a list endpoint by following next_cursor. The tables
+// and pickers filter client-side, so they want the whole set.
+export async function getAllPages<T>(url: string, params: Record<string, string> = {}): Promise<T[]> {
+  const all: T[] = []
+  let cursor = ""
+  do {
+    const res = await api.get<Page<T>>(url, {
+      params: { ...params, limit: 200, ...(cursor ? { cursor } : {}) },
+    })
+    all.push(...res.data.data)
+    cursor = res.data.next_cursor
+  } while (cursor)
+  return all
+}
+
+// Fetches an authenticated file (e.g. a CSV export) and hands it to the
+// browser as a download. A plain <a href> can't carry the bearer token.
+export async function downloadFile(url: string, filename: string, params: Record<string, string> = {}) {
+  const res = await api.get<Blob>(url, { params, responseType: "blob" })
+  const href = URL.createObjectURL(res.data)
+  const link = document.createElement("a")
+  link.href = href
+  link.download = filename
+  link.click()
+  URL.revokeObjectURL(href)
+}
@@ -1,3 +1,6 @@
+import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query"
+
+import { api } from "@/lib/api"
 import type { Group } from "./groups"
 
 // Application API shape — mirror of core's model.Application JSON.
@@ -11,10 +14,413 @@ export type Application = {
   icon_url: string
   launch_url: string
   redirect_uris: string[]
+  // Scopes this client may request. Empty means any builtin scope, but
+  // none defined by other applications.
+  allowed_scopes: string[]
+  // The allowed_scopes other apps define that their owners haven't
+  // approved for this client yet. They can't be requested until then.
+  pending_scopes: string[]
+  token_endpoint_auth_method: ClientAuthMethod
+  // Public keys for private_key_jwt: an inline JWK Set or a URL serving
+  // one. At most one is set.
+  jwks: { keys: Record<string, unknown>[] } | null
+  jwks_uri: string
+  // When set, the authorize page only accepts a request_uri from the PAR
+  // endpoint for this client.
+  require_pushed_authorization_requests: boolean
+  // Set on clients that registered themselves (RFC 7591) until an admin
+  // approves them; they may only request openid, profile, and email.
+  pending_approval: boolean
+  // Client IDs this app's service accounts may exchange users' tokens for
+  // (RFC 8693), besides its own. Admin-only.
+  token_exchange_audiences: string[]
+  // The initial access token a self-registered client used, if any.
+  registration_token_id: string
   updated_at: string
   created_at: string
 }
 
+// How a confidential client proves its identity at the token endpoint.
+// The two secret methods are interchangeable server-side.
+export type ClientAuthMethod = "client_secret_basic" | "client_secret_post" | "private_key_jwt"
+
+export const CLIENT_AUTH_METHOD_LABEL: Record<ClientAuthMethod, string> = {
+  client_secret_basic: "Client secret",
+  client_secret_post: "Client secret (form post)",
+  private_key_jwt: "Private key JWT",
+}
+
+// ApplicationSecret mirrors core's model.ApplicationSecret. Only metadata —
+// the secret itself is hashed at rest, and `hint` is its last 4 characters.
+// expires_at is null for secrets that never expire.
+export type ApplicationSecret = {
+  id: string
+  application_id: string
+  label: string
+  hint: string
+  expires_at: string | null
+  last_used_at: string | null
+  created_by: string
+  created_at: string
+}
+
+// CreatedApplicationSecret is what the create and rotate endpoints return:
+// the metadata plus the plaintext, which is never shown again.
+export type CreatedApplicationSecret = ApplicationSecret & { client_secret: string }
+
+export function isSecretExpired(secret: ApplicationSecret): boolean {
+  return !!secret.expires_at && new Date(secret.expires_at).getTime() <= Date.now()
+}
+
+export function useApplicationSecrets(applicationID: string, enabled = true) {
+  return useQuery({
+    queryKey: ["application", applicationID, "secrets"],
+    queryFn: async () => {
+      const res = await api.get<ApplicationSecret[]>(`/applications/${applicationID}/secrets`)
+      return res.data
+    },
+    enabled: !!applicationID && enabled,
+  })
+}
+
+export function useCreateApplicationSecret(applicationID: string) {
+  const qc = useQueryClient()
+  return useMutation({
+    mutationFn: async (input: { label: string; expires_at?: string | null }) => {
+      const res = await api.post<CreatedApplicationSecret>(
+        `/applications/${applicationID}/secrets`,
+        input,
+      )
+      return res.data
+    },
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["application", applicationID, "secrets"] })
+    },
+  })
+}
+
+// useRotateApplicationSecret mints a new secret and puts every other
+// active one on a grace_period_hours countdown (backend default 24h).
+export function useRotateApplicationSecret(applicationID: string) {
+  const qc = useQueryClient()
+  return useMutation({
+    mutationFn: async (input: { label: string; grace_period_hours: number }) => {
+      const res = await api.post<CreatedApplicationSecret>(
+        `/applications/${applicationID}/secrets/rotate`,
+        input,
+      )
+      return res.data
+    },
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["application", applicationID, "secrets"] })
+    },
+  })
+}
+
+export function useRevokeApplicationSecret(applicationID: string) {
+  const qc = useQueryClient()
+  return useMutation({
+    mutationFn: async (secretID: string) => {
+      await api.delete(`/applications/${applicationID}/secrets/${secretID}`)
+    },
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["application", applicationID, "secrets"] })
+    },
+  })
+}
+
+// ScopeInfo is an entry of the scope registry (GET /scopes): Sentinel's
+// builtin scopes, then those applications define for their own APIs.
+export type ScopeInfo = {
+  name: string
+  description: string
+  builtin: boolean
+  allowed_while_pending?: boolean
+  application_id?: string
+  client_id?: string
+  application_name?: string
+}
+
+// ApplicationScope is a scope an application defines as a resource server.
+export type ApplicationScope = {
+  name: string
+  application_id: string
+  description: string
+  created_at: string
+}
+
+// ScopeRequest is another client waiting for this app to approve one of
+// its scopes.
+export type ScopeRequest = {
+  scope: string
+  application_id: string
+  client_id: string
+  application_name: string
+}
+
+// ApplicationRole mirrors core's model.ApplicationRole. A user holds the
+// role if they're in every group of any one rule: AND within a rule, OR
+// across rules. Held roles go out as the `roles` token claim.
+export type ApplicationRole = {
+  id: string
+  application_id: string
+  name: string
+  description: string
+  rules: RoleRule[]
+  created_at: string
+}
+
+export type RoleRule = { group_ids: string[] }
+
+// RoleAssignment is one row of `GET /applications/:id/roles/assignments`:
+// an entity and the roles it would receive from the app.
+export type RoleAssignment = {
+  entity_id: string
+  entity_type: "USER" | "SERVICE_ACCOUNT"
+  user_id?: string
+  username?: string
+  name: string
+  roles: string[]
+}
+
+export function useScopeRegistry() {
+  return useQuery({
+    queryKey: ["scopes"],
+    queryFn: async () => {
+      const res = await api.get<ScopeInfo[]>("/scopes")
+      return res.data
+    },
+  })
+}
+
+export function useApplicationScopes(applicationID: string) {
+  return useQuery({
+    queryKey: ["application", applicationID, "scopes"],
+    queryFn: async () => {
+      const res = await api.get<ApplicationScope[]>(`/applications/${applicationID}/scopes`)
+      return res.data
+    },
+    enabled: !!applicationID,
+  })
+}
+
+export function useCreateApplicationScope(applicationID: string) {
+  const qc = useQueryClient()
+  return useMutation({
+    mutationFn: async (input: { name: string; description: string }) => {
+      const res = await api.post<ApplicationScope>(`/applications/${applicationID}/scopes`, input)
+      return res.data
+    },
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["application", applicationID, "scopes"] })
+      qc.invalidateQueries({ queryKey: ["scopes"] })
+    },
+  })
+}
+
+export function useDeleteApplicationScope(applicationID: string) {
+  const qc = useQueryClient()
+  return useMutation({
+    mutationFn: async (name: string) => {
+      await api.delete(`/applications/${applicationID}/scopes/${encodeURIComponent(name)}`)
+    },
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["application", applicationID, "scopes"] })
+      qc.invalidateQueries({ queryKey: ["scopes"] })
+      // Deleting a scope also takes it off every allow-list.
+      qc.invalidateQueries({ queryKey: ["application", "id"] })
+    },
+  })
+}
+
+export function useSetAllowedScopes(applicationID: string) {
+  const qc = useQueryClient()
+  return useMutation({
+    mutationFn: async (scopes: string[]) => {
+      const res = await api.put<string[]>(`/applications/${applicationID}/allowed-scopes`, {
+        scopes,
+      })
+      return res.data
+    },
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["application", "id", applicationID] })
+    },
+  })
+}
+
+export function useScopeRequests(applicationID: string) {
+  return useQuery({
+    queryKey: ["application", applicationID, "scope-requests"],
+    queryFn: async () => {
+      const res = await api.get<ScopeRequest[]>(`/applications/${applicationID}/scope-requests`)
+      return res.data
+    },
+    enabled: !!applicationID,
+  })
+}
+
+// useDecideScopeRequest approves or rejects another client's request for
+// one of this app's scopes.
+export function useDecideScopeRequest(applicationID: string) {
+  const qc = useQueryClient()
+  return useMutation({
+    mutationFn: async ({ request, approve }: { request: ScopeRequest; approve: boolean }) => {
+      const path = `/applications/${request.application_id}/allowed-scopes/${encodeURIComponent(request.scope)}`
+      if (approve) {
+        await api.post(`${path}/approve`)
+      } else {
+        await api.delete(path)
+      }
+    },
+    onSuccess: (_, { request }) => {
+      qc.invalidateQueries({ queryKey: ["application", applicationID, "scope-requests"] })
+      qc.invalidateQueries({ queryKey: ["application", "id", request.application_id] })
+    },
+  })
+}
+
+export function useApplicationRoles(applicationID: string) {
+  return useQuery({
+    queryKey: ["application", applicationID, "roles"],
+    queryFn: async () => {
+      const res = await api.get<ApplicationRole[]>(`/applications/${applicationID}/roles`)
+      return res.data
+    },
+    enabled: !!applicationID,
+  })
+}
+
+export function useApplicationRoleAssignments(applicationID: string) {
+  return useQuery({
+    queryKey: ["application", applicationID, "roles", "assignments"],
+    queryFn: async () => {
+      const res = await api.get<RoleAssignment[]>(
+        `/applications/${applicationID}/roles/assignments`,
+      )
+      return res.data
+    },
+    enabled: !!applicationID,
+  })
+}
+
+type RoleInput = { name: string; description: string; rules: RoleRule[] }
+
+export function useCreateApplicationRole(applicationID: string) {
+  const qc = useQueryClient()
+  return useMutation({
+    mutationFn: async (input: RoleInput) => {
+      const res = await api.post<ApplicationRole>(`/applications/${applicationID}/roles`, input)
+      return res.data
+    },
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["application", applicationID, "roles"] })
+    },
+  })
+}
+
+export function useUpdateApplicationRole(applicationID: string) {
+  const qc = useQueryClient()
+  return useMutation({
+    mutationFn: async ({ id, ...input }: RoleInput & { id: string }) => {
+      const res = await api.put<ApplicationRole>(
+        `/applications/${applicationID}/roles/${id}`,
+        input,
+      )
+      return res.data
+    },
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["application", applicationID, "roles"] })
+    },
+  })
+}
+
+export function useDeleteApplicationRole(applicationID: string) {
+  const qc = useQueryClient()
+  return useMutation({
+    mutationFn: async (roleID: string) => {
+      await api.delete(`/applications/${applicationID}/roles/${roleID}`)
+    },
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["application", applicationID, "roles"] })
+    },
+  })
+}
+
+export function useApproveApplication(applicationID: string) {
+  const qc = useQueryClient()
+  return useMutation({
+    mutationFn: async () => {
+      const res = await api.post<Application>(`/applications/${applicationID}/approve`)
+      return res.data
+    },
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["application", "id", applicationID] })
+      qc.invalidateQueries({ queryKey: ["applications"] })
+    },
+  })
+}
+
+// InitialAccessToken mirrors core's model.InitialAccessToken: an
+// admin-issued credential for the dynamic client registration endpoint.
+// max_registrations of 0 means no limit; expires_at is null for tokens
+// that never expire.
+export type InitialAccessToken = {
+  id: string
+  label: string
+  max_registrations: number
+  registrations: number
+  expires_at: string | null
+  revoked_at: string | null
+  created_by: string
+  created_at: string
+}
+
+export function isInitialAccessTokenUsable(token: InitialAccessToken): boolean {
+  if (token.revoked_at) return false
+  if (token.expires_at && new Date(token.expires_at).getTime() <= Date.now()) return false
+  return token.max_registrations === 0 || token.registrations < token.max_registrations
+}
+
+export function useInitialAccessTokens(enabled = true) {
+  return useQuery({
+    queryKey: ["registration-tokens"],
+    queryFn: async () => {
+      const res = await api.get<InitialAccessToken[]>("/registration-tokens")
+      return res.data
+    },
+    enabled,
+  })
+}
+
+export function useCreateInitialAccessToken() {
+  const qc = useQueryClient()
+  return useMutation({
+    mutationFn: async (input: {
+      label: string
+      max_registrations: number
+      expires_in_hours: number
+    }) => {
+      const res = await api.post<{ id: string; token: string }>("/registration-tokens", input)
+      return res.data
+    },
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["registration-tokens"] })
+    },
+  })
+}
+
+export function useRevokeInitialAccessToken() {
+  const qc = useQueryClient()
+  return useMutation({
+    mutationFn: async (id: string) => {
+      await api.delete(`/registration-tokens/${id}`)
+    },
+    onSuccess: () => {
+      qc.invalidateQueries({ queryKey: ["registration-tokens"] })
+    },
+  })
+}
+
 // GroupWithLink is what `GET /applications/:id/groups` returns — a Group
 // enriched with the `required` flag from its application_group link.
 // `required` gates OAuth access: if any linked group on the app has it set
@@ -26,6 +432,41 @@ export type GroupWithLink = Group & { required: boolean }
 // returns. Application + the link's `required` flag inline.
 export type ApplicationWithLink = Application & { required: boolean }
 
+// Optional claims a token policy can include, in display order. Groups
+// still need the groups:read scope and email the email scope.
+export type OptionalClaim = "groups" | "roles" | "email"
+
+export const OPTIONAL_CLAIMS: { claim: OptionalClaim; label: string; description: string }[] = [
+  {
+    claim: "groups",
+    label: "Groups",
+    description: "groups and group_ids, when the app is granted groups:read.",
+  },
+  { claim: "roles", label: "Roles", description: "The app's own roles the user holds." },
+  {
+    claim: "email",
+    label: "Email in access tokens",
+    description: "Adds email to access tokens too, when the email scope is granted.",
+  },
+]
+
+// TokenPolicy mirrors core's model.ApplicationTokenPolicy. Lifetimes are in
+// seconds; 0 uses the server default. A shared identity (set when
+// shared_email is) replaces every user's identity claims for this app.
+export type TokenPolicy = {
+  application_id: string
+  access_token_ttl: number
+  id_token_ttl: number
+  refresh_token_idle_ttl: number
+  refresh_token_absolute_ttl: number
+  allow_offline_access: boolean
+  optional_claims: OptionalClaim[]
+  shared_email: string
+  shared_username: string
+  shared_first_name: string
+  shared_last_name: string
+}
+
 // SAMLConfig mirrors core's model.SAMLServiceProvider — the SAML relying-party
 // registration attached to an application. `entity_id` is the SP's SAML
 // entityID (issuer); `acs_url` is its Assertion Consumer Service. Provide
@@ -99,6 +99,7 @@ export type Entity = {
     entity_id: string
     provider: "DISCORD" | "GOOGLE" | "GITHUB"
     external_id: string
+    metadata: Record<string, unknown> | null
     created_at: string
   }>
   user?: {
@@ -119,6 +120,8 @@ export type Entity = {
     sae_registration_number: string
     avatar_url: string
     initial_role: string
+    status: "ACTIVE" | "ALUMNI" | "SUSPENDED" | "OFFBOARDED"
+    status_effective_at: string
     groups: string[]
     created_at: string
     updated_at: string
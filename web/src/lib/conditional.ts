@@ -2,18 +2,62 @@ import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query"
 
 import { api } from "@/lib/api"
 
+// Mirror of core/model/conditional_binding.go::AttributePredicate. `field`
+// is a user profile field (JSON name) or "entity_type".
+export type PredicateOp =
+  | "eq"
+  | "neq"
+  | "in"
+  | "not_in"
+  | "gt"
+  | "gte"
+  | "lt"
+  | "lte"
+  | "present"
+  | "absent"
+
+export type AttributePredicate = {
+  field: string
+  op: PredicateOp
+  value?: string
+  values?: string[]
+}
+
 // Mirror of core/model/conditional_binding.go::GroupConditionalBinding.
-// Each binding is an AND-group of required Sentinel group IDs; group
-// membership is OR across the bindings on the same parent. So
-// `[{required_group_ids: [A, B]}, {required_group_ids: [C]}]` means:
-// any entity that's in BOTH A and B, OR is in C.
+// Each binding is an AND-group of required Sentinel group IDs and attribute
+// predicates; group membership is OR across the bindings on the same
+// parent. So `[{required_group_ids: [A, B]}, {required_group_ids: [C]}]`
+// means: any entity that's in BOTH A and B, OR is in C.
 export type GroupConditionalBinding = {
   id: string
   group_id: string
   required_group_ids: string[]
+  predicates: AttributePredicate[] | null
   created_at: string
 }
 
+const PREDICATE_OP_LABEL: Record<PredicateOp, string> = {
+  eq: "=",
+  neq: "≠",
+  in: "in",
+  not_in: "not in",
+  gt: ">",
+  gte: "≥",
+  lt: "<",
+  lte: "≤",
+  present: "is set",
+  absent: "is not set",
+}
+
+// formatPredicate renders a predicate the way admins write it, e.g.
+// `major in (EE, CE)` or `sae_registration_number is set`.
+export function formatPredicate(p: AttributePredicate) {
+  const op = PREDICATE_OP_LABEL[p.op] ?? p.op
+  if (p.op === "present" || p.op === "absent") return `${p.field} ${op}`
+  if (p.op === "in" || p.op === "not_in") return `${p.field} ${op} (${(p.values ?? []).join(", ")})`
+  return `${p.field} ${op} ${p.value ?? ""}`
+}
+
 export function useGroupConditionalBindings(groupID: string) {
   return useQuery({
     queryKey: ["group", groupID, "conditional-bindings"],
@@ -27,6 +71,34 @@ export function useGroupConditionalBindings(groupID: string) {
   })
 }
 
+// Mirror of core/service/conditional_explain.go::ConditionalSimulation —
+// the entity IDs a proposed binding would add to or remove from the group.
+export type ConditionalSimulation = {
+  group_id: string
+  conditional_enabled: boolean
+  added: string[]
+  removed: string[]
+}
+
+// useSimulateConditionalBinding previews a binding before it's saved. Runs
+// only once at least one required group is picked; validation errors (cycle,
+// self-reference) come back as a query error.
+export function useSimulateConditionalBinding(groupID: string, requiredGroupIDs: string[]) {
+  const sorted = [...requiredGroupIDs].sort()
+  return useQuery({
+    queryKey: ["group", groupID, "conditional-simulation", sorted],
+    queryFn: async () => {
+      const res = await api.post<ConditionalSimulation>(
+        `/groups/${groupID}/conditional-bindings/simulate`,
+        { required_group_ids: sorted },
+      )
+      return res.data
+    },
+    enabled: !!groupID && sorted.length > 0,
+    retry: false,
+  })
+}
+
 export function useAddGroupConditionalBinding(groupID: string) {
   const qc = useQueryClient()
   return useMutation({
@@ -0,0 +1,74 @@
+// GitHub and Google sign-in and account linking. Both flows send the
+// browser to the provider and come back to the same callback route
+// (/auth/login/github or /auth/login/google); the mode saved here tells the
+// callback page whether to sign in or to link the account to the current
+// session. The random state guards the round trip against a forged callback
+// linking someone else's account.
+
+export type ExternalProvider = "github" | "google"
+export type ExternalAuthMode = "login" | "connect"
+
+const STATE_KEY = "sentinel_external_auth"
+
+// Client IDs are public (they're in the authorize URL), but unlike Discord's
+// they differ per deployment, so they come from the build env.
+const PROVIDERS: Record<
+  ExternalProvider,
+  { label: string; authorizeURL: string; scope: string; clientID: string | undefined }
+> = {
+  github: {
+    label: "GitHub",
+    authorizeURL: "https://github.com/login/oauth/authorize",
+    scope: "read:user user:email",
+    clientID: import.meta.env.VITE_GITHUB_CLIENT_ID,
+  },
+  google: {
+    label: "Google",
+    authorizeURL: "https://accounts.google.com/o/oauth2/v2/auth",
+    scope: "openid email profile",
+    clientID: import.meta.env.VITE_GOOGLE_CLIENT_ID,
+  },
+}
+
+export function externalProviderLabel(provider: ExternalProvider) {
+  return PROVIDERS[provider].label
+}
+
+export function externalProviderConfigured(provider: ExternalProvider) {
+  return !!PROVIDERS[provider].clientID
+}
+
+// Sends the browser to the provider's consent screen.
+export function startExternalAuth(provider: ExternalProvider, mode: ExternalAuthMode) {
+  const config = PROVIDERS[provider]
+  if (!config.clientID) throw new Error(`${config.label} sign-in isn't configured.`)
+  const state = crypto.randomUUID()
+  sessionStorage.setItem(STATE_KEY, JSON.stringify({ provider, mode, state }))
+  const params = new URLSearchParams({
+    client_id: config.clientID,
+    response_type: "code",
+    // Must byte-match the oauth service's GITHUB_/GOOGLE_REDIRECT_URI.
+    redirect_uri: `${window.location.origin}/auth/login/${provider}`,
+    scope: config.scope,
+    state,
+  })
+  window.location.href = `${config.authorizeURL}?${params.toString()}`
+}
+
+// Reads and clears the saved round-trip state. Returns the mode when the
+// callback's state matches what this tab started, null otherwise.
+export function consumeExternalAuthState(
+  provider: ExternalProvider,
+  state: string | null,
+): ExternalAuthMode | null {
+  const raw = sessionStorage.getItem(STATE_KEY)
+  sessionStorage.removeItem(STATE_KEY)
+  if (!raw || !state) return null
+  try {
+    const saved = JSON.parse(raw) as { provider: string; mode: ExternalAuthMode; state: string }
+    if (saved.provider !== provider || saved.state !== state) return null
+    return saved.mode
+  } catch {
+    return null
+  }
+}
@@ -0,0 +1,37 @@
+import { useQuery } from "@tanstack/react-query"
+
+import { api } from "./api"
+
+// Mirror of github/model/group_binding.go::GroupGitHubBinding. A Sentinel
+// group may be bound to any number of teams in the GitHub org.
+export type GroupGitHubBinding = {
+  id: string
+  group_id: string
+  team_slug: string
+  created_at: string
+}
+
+// useGroupGitHubBindings returns every binding for a group (possibly empty).
+export function useGroupGitHubBindings(groupID: string) {
+  return useQuery({
+    queryKey: ["group", groupID, "github-bindings"],
+    queryFn: async () => {
+      const res = await api.get<GroupGitHubBinding[]>(`/github/group-bindings`, {
+        params: { group_id: groupID },
+      })
+      return res.data
+    },
+    enabled: !!groupID,
+  })
+}
+
+// useGroupGitHubBinding returns the group's first binding, or null. The group
+// edit form manages this one binding; additional bindings are created through
+// the API.
+export function useGroupGitHubBinding(groupID: string) {
+  const query = useGroupGitHubBindings(groupID)
+  return {
+    ...query,
+    data: query.data ? (query.data[0] ?? null) : query.data,
+  }
+}
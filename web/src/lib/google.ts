@@ -2,26 +2,45 @@ import { useQuery } from "@tanstack/react-query"
 
 import { api } from "./api"
 
-// Mirror of google/model/group_binding.go::GroupGoogleBinding. A 1:1 mapping of
-// a Sentinel group to the Google Group its membership is mirrored into.
+// Mirror of google/model/group_binding.go::GoogleBindingMode. MEMBERS flattens
+// the group's members into the Google Group; NESTED inserts one of the group's
+// own Google Groups (member_group_email) as a nested member.
+export type GoogleBindingMode = "MEMBERS" | "NESTED"
+
+// Mirror of google/model/group_binding.go::GroupGoogleBinding. A Sentinel group
+// may be bound to any number of Google Groups.
 export type GroupGoogleBinding = {
   id: string
   group_id: string
   google_group_email: string
+  mode: GoogleBindingMode
+  member_group_email: string
   created_at: string
 }
 
-// useGroupGoogleBinding returns the single binding for a group, or null. The
-// list endpoint returns an array (0 or 1 rows) since the mapping is 1:1.
-export function useGroupGoogleBinding(groupID: string) {
+// useGroupGoogleBindings returns every binding for a group (possibly empty).
+export function useGroupGoogleBindings(groupID: string) {
   return useQuery({
-    queryKey: ["group", groupID, "google-binding"],
+    queryKey: ["group", groupID, "google-bindings"],
     queryFn: async () => {
       const res = await api.get<GroupGoogleBinding[]>(`/google/group-bindings`, {
         params: { group_id: groupID },
       })
-      return res.data[0] ?? null
+      return res.data
     },
     enabled: !!groupID,
   })
 }
+
+// useGroupGoogleBinding returns the group's first MEMBERS binding, or null.
+// The group edit form manages this one binding; additional and NESTED
+// bindings are created through the API.
+export function useGroupGoogleBinding(groupID: string) {
+  const query = useGroupGoogleBindings(groupID)
+  return {
+    ...query,
+    data: query.data
+      ? (query.data.find((b) => b.mode !== "NESTED") ?? null)
+      : query.data,
+  }
+}
@@ -11,12 +11,25 @@ export const SOURCE_LABEL: Record<GroupSource, string> = {
   CONDITIONAL: "conditional",
 }
 
+// INHERITED is never stored or allowed on a group — core synthesizes it for
+// members who are only in the group through a nested child group.
+export type GroupMemberSource = GroupSource | "INHERITED"
+
+export const MEMBER_SOURCE_LABEL: Record<GroupMemberSource, string> = {
+  ...SOURCE_LABEL,
+  INHERITED: "inherited",
+}
+
 export type Group = {
   id: string
   name: string
   description: string
   allowed_sources: GroupSource[]
+  require_mfa: boolean
+  alumni_group_id: string
   created_by: string
+  // The service that acted for created_by through token exchange, if any.
+  created_by_actor?: string
   created_at: string
   updated_at: string
   member_count: number
@@ -27,11 +40,22 @@ export type Group = {
 export type GroupMember = {
   group_id: string
   entity_id: string
-  source: GroupSource | ""
+  source: GroupMemberSource | ""
   added_by: string
+  // The service that acted for added_by through token exchange, if any.
+  added_by_actor?: string
   has_expiration: boolean
   expires_at: string
   joined_at: string
+  // Set on INHERITED rows: the child group the membership comes through.
+  inherited_from?: string
+}
+
+export type GroupChild = {
+  parent_group_id: string
+  child_group_id: string
+  added_by: string
+  created_at: string
 }
 
 export type GroupOwner = {
@@ -57,6 +81,8 @@ export type GroupJoinRequest = {
   entity_id: string
   status: GroupJoinRequestStatus
   reviewed_by: string
+  // The service that acted for reviewed_by through token exchange, if any.
+  reviewed_by_actor?: string
   reviewed_at: string
   has_expiration: boolean
   expires_at: string
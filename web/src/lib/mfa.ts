@@ -0,0 +1,28 @@
+import { useQuery } from "@tanstack/react-query"
+
+import { api } from "./api"
+
+// Mirror of core/service/mfa.go::MFAStatus.
+export type MfaStatus = {
+  enabled: boolean
+  pending: boolean
+  required_by_policy: boolean
+  recovery_codes_remaining: number
+  passkeys: number
+}
+
+// Mirror of core/service/mfa.go::TOTPEnrollment.
+export type TotpEnrollment = {
+  secret: string
+  otpauth_uri: string
+}
+
+export function useMyMfa() {
+  return useQuery({
+    queryKey: ["me", "mfa"],
+    queryFn: async () => {
+      const res = await api.get<MfaStatus>("/entities/@me/mfa")
+      return res.data
+    },
+  })
+}
@@ -0,0 +1,144 @@
+import { useQuery } from "@tanstack/react-query"
+
+import { api } from "./api"
+
+// Mirror of core/model/entity.go::EntityPasskey (the fields the UI shows).
+export type Passkey = {
+  id: string
+  entity_id: string
+  credential_id: string
+  name: string
+  transports: string[] | null
+  backup_eligible: boolean
+  backup_state: boolean
+  last_used_at: string | null
+  created_at: string
+}
+
+// Begin responses from the oauth ceremony endpoints. `options` is the
+// go-webauthn CredentialCreation / CredentialAssertion, whose binary fields
+// are base64url strings.
+type CeremonyResponse = {
+  session_id: string
+  options: { publicKey: Record<string, unknown> & { challenge: string } }
+}
+
+export function passkeysSupported() {
+  return typeof window !== "undefined" && !!window.PublicKeyCredential
+}
+
+function fromBase64url(value: string): ArrayBuffer {
+  const padded = value.replace(/-/g, "+").replace(/_/g, "/").padEnd(Math.ceil(value.length / 4) * 4, "=")
+  const bytes = Uint8Array.from(atob(padded), (c) => c.charCodeAt(0))
+  return bytes.buffer
+}
+
+function toBase64url(buffer: ArrayBuffer | null): string | undefined {
+  if (!buffer) return undefined
+  let binary = ""
+  for (const b of new Uint8Array(buffer)) binary += String.fromCharCode(b)
+  return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "")
+}
+
+type DescriptorJSON = { type: PublicKeyCredentialType; id: string; transports?: AuthenticatorTransport[] }
+
+function decodeDescriptors(list: unknown): PublicKeyCredentialDescriptor[] | undefined {
+  if (!Array.isArray(list)) return undefined
+  return (list as DescriptorJSON[]).map((d) => ({ ...d, id: fromBase64url(d.id) }))
+}
+
+// Serializes a PublicKeyCredential into the JSON shape go-webauthn parses.
+function credentialToJSON(credential: PublicKeyCredential) {
+  const response = credential.response as AuthenticatorAttestationResponse & AuthenticatorAssertionResponse
+  return {
+    id: credential.id,
+    rawId: toBase64url(credential.rawId),
+    type: credential.type,
+    authenticatorAttachment: credential.authenticatorAttachment ?? undefined,
+    clientExtensionResults: credential.getClientExtensionResults(),
+    response: {
+      clientDataJSON: toBase64url(response.clientDataJSON),
+      attestationObject: toBase64url(response.attestationObject ?? null),
+      transports: response.getTransports?.(),
+      authenticatorData: toBase64url(response.authenticatorData ?? null),
+      signature: toBase64url(response.signature ?? null),
+      userHandle: toBase64url(response.userHandle ?? null),
+    },
+  }
+}
+
+async function runCreate(options: CeremonyResponse["options"]) {
+  const pk = options.publicKey as unknown as PublicKeyCredentialCreationOptions & {
+    challenge: string
+    user: PublicKeyCredentialUserEntity & { id: string }
+  }
+  const credential = (await navigator.credentials.create({
+    publicKey: {
+      ...pk,
+      challenge: fromBase64url(pk.challenge),
+      user: { ...pk.user, id: fromBase64url(pk.user.id) },
+      excludeCredentials: decodeDescriptors(pk.excludeCredentials),
+    },
+  })) as PublicKeyCredential | null
+  if (!credential) throw new Error("Passkey creation was cancelled.")
+  return credentialToJSON(credential)
+}
+
+async function runGet(options: CeremonyResponse["options"]) {
+  const pk = options.publicKey as unknown as PublicKeyCredentialRequestOptions & { challenge: string }
+  const credential = (await navigator.credentials.get({
+    publicKey: {
+      ...pk,
+      challenge: fromBase64url(pk.challenge),
+      allowCredentials: decodeDescriptors(pk.allowCredentials),
+    },
+  })) as PublicKeyCredential | null
+  if (!credential) throw new Error("Passkey sign-in was cancelled.")
+  return credentialToJSON(credential)
+}
+
+// Registers a passkey for the signed-in user.
+export async function registerPasskey(name: string): Promise<Passkey> {
+  const begin = await api.post<CeremonyResponse>("/auth/passkeys/register/begin")
+  const credential = await runCreate(begin.data.options)
+  const res = await api.post<Passkey>("/auth/passkeys/register/finish", {
+    session_id: begin.data.session_id,
+    name,
+    credential,
+  })
+  return res.data
+}
+
+// Passkey-only sign-in. Resolves to the same session shape as the other
+// /auth/login/* endpoints.
+export async function signInWithPasskey<T>(): Promise<T> {
+  const begin = await api.post<CeremonyResponse>("/auth/login/passkey/begin")
+  const credential = await runGet(begin.data.options)
+  const res = await api.post<T>("/auth/login/passkey/finish", {
+    session_id: begin.data.session_id,
+    credential,
+  })
+  return res.data
+}
+
+// Completes an MFA challenge with a passkey as the second factor.
+export async function verifyMfaWithPasskey<T>(mfaToken: string): Promise<T> {
+  const begin = await api.post<CeremonyResponse>("/auth/login/mfa/passkey/begin", { mfa_token: mfaToken })
+  const credential = await runGet(begin.data.options)
+  const res = await api.post<T>("/auth/login/mfa/passkey/finish", {
+    mfa_token: mfaToken,
+    session_id: begin.data.session_id,
+    credential,
+  })
+  return res.data
+}
+
+export function useMyPasskeys() {
+  return useQuery({
+    queryKey: ["me", "passkeys"],
+    queryFn: async () => {
+      const res = await api.get<Passkey[]>("/entities/@me/passkeys")
+      return res.data
+    },
+  })
+}
@@ -1,5 +1,6 @@
 import {
   AppWindow,
+  Boxes,
   Clock,
   IdCard,
   KeyRound,
@@ -67,13 +68,33 @@ const SCOPES: Record<string, ScopeMeta> = {
 
 export type ResolvedScope = ScopeMeta & { key: string; known: boolean }
 
-export function resolveScopes(scopeString: string): ResolvedScope[] {
+// RegisteredScope is how the backend describes a requested scope, which
+// covers the ones applications define for their own APIs.
+export type RegisteredScope = {
+  name: string
+  description: string
+  application_name?: string
+}
+
+export function resolveScopes(scopeString: string, registered: RegisteredScope[] = []): ResolvedScope[] {
   return scopeString
     .split(/\s+/)
     .filter(Boolean)
     .map((key) => {
       const meta = SCOPES[key]
       if (meta) return { key, known: true, ...meta }
+      const custom = registered.find((s) => s.name === key)
+      if (custom) {
+        return {
+          key,
+          known: false,
+          label: custom.description,
+          description: custom.application_name
+            ? `Access to ${custom.application_name} on your behalf.`
+            : "Access to another application on your behalf.",
+          icon: Boxes,
+        }
+      }
       return {
         key,
         known: false,
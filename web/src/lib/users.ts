@@ -1,6 +1,6 @@
 import { useQuery } from "@tanstack/react-query"
 
-import { api } from "@/lib/api"
+import { getAllPages } from "@/lib/api"
 
 export type UserOption = {
   id: string
@@ -15,10 +15,7 @@ export type UserOption = {
 export function useUsers({ enabled = true }: { enabled?: boolean } = {}) {
   return useQuery({
     queryKey: ["users"],
-    queryFn: async () => {
-      const res = await api.get<UserOption[]>("/users")
-      return res.data
-    },
+    queryFn: () => getAllPages<UserOption>("/users"),
     staleTime: 5 * 60 * 1000,
     enabled,
   })
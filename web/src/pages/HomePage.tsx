@@ -22,6 +22,7 @@ type EntityLogin = {
   access_token_id: string
   refresh_token_id: string
   ip_address: string
+  actor_id?: string
   created_at: string
 }
 
@@ -187,6 +188,11 @@ export default function HomePage() {
                           )}
                         </p>
                         <p className="mt-1 font-mono text-xs text-muted-foreground">{login.scope}</p>
+                        {login.actor_id && (
+                          <p className="mt-1 text-xs text-muted-foreground">
+                            On your behalf via <span className="font-mono">{login.actor_id}</span>
+                          </p>
+                        )}
                       </div>
                       <span className="font-mono text-xs text-muted-foreground">{login.ip_address}</span>
                     </li>
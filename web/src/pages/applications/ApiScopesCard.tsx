@@ -0,0 +1,323 @@
+import { Check, Plus, ShieldCheck, Trash2, X } from "lucide-react"
+import { useMemo, useState } from "react"
+import { toast } from "sonner"
+
+import { Badge } from "@/components/ui/badge"
+import { Button } from "@/components/ui/button"
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card"
+import { Input } from "@/components/ui/input"
+import { Label } from "@/components/ui/label"
+import { Skeleton } from "@/components/ui/skeleton"
+import {
+  useApplicationScopes,
+  useCreateApplicationScope,
+  useDecideScopeRequest,
+  useDeleteApplicationScope,
+  useScopeRegistry,
+  useScopeRequests,
+  useSetAllowedScopes,
+  type Application,
+  type ScopeRequest,
+} from "@/lib/applications"
+import { cn } from "@/lib/utils"
+
+function extractError(e: unknown, fallback: string): string {
+  const msg = (e as { response?: { data?: { error?: string } } })?.response?.data?.error
+  return msg ?? fallback
+}
+
+function sameSet(a: string[], b: string[]) {
+  return a.length === b.length && a.every((s) => b.includes(s))
+}
+
+// ApiScopesCard manages both sides of an app's scopes: which ones it may
+// request as a client, and which ones it defines for its own API.
+export function ApiScopesCard({ app }: { app: Application }) {
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <ShieldCheck className="size-4 text-muted-foreground" />
+          Scopes
+        </CardTitle>
+        <CardDescription>
+          What this app can ask users for, and the scopes it offers other apps for its own
+          API.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-6">
+        <AllowedScopesSection app={app} />
+        <DefinedScopesSection applicationID={app.id} />
+        <ScopeRequestsSection applicationID={app.id} />
+      </CardContent>
+    </Card>
+  )
+}
+
+function AllowedScopesSection({ app }: { app: Application }) {
+  const registry = useScopeRegistry()
+  const setAllowed = useSetAllowedScopes(app.id)
+  const saved = app.allowed_scopes ?? []
+  const pending = app.pending_scopes ?? []
+  const [selected, setSelected] = useState<string[]>(saved)
+  const dirty = !sameSet(selected, saved)
+
+  const options = useMemo(
+    () => (registry.data ?? []).filter((s) => s.name !== "sentinel:all"),
+    [registry.data],
+  )
+
+  function toggle(name: string) {
+    setSelected((prev) =>
+      prev.includes(name) ? prev.filter((s) => s !== name) : [...prev, name],
+    )
+  }
+
+  async function save() {
+    try {
+      const result = await setAllowed.mutateAsync(selected)
+      setSelected(result)
+      toast.success("Allowed scopes saved")
+    } catch (e) {
+      toast.error(extractError(e, "Couldn't save allowed scopes."))
+    }
+  }
+
+  return (
+    <div className="space-y-3">
+      <div>
+        <p className="text-sm font-medium">Requestable scopes</p>
+        <p className="text-xs text-muted-foreground">
+          {selected.length === 0
+            ? "None selected: this app may request any built-in scope, but none defined by other apps."
+            : "This app may request only the selected scopes."}{" "}
+          Another app's scope stays pending until that app's owner or an admin approves it.
+        </p>
+      </div>
+      {registry.isLoading ? (
+        <Skeleton className="h-16 w-full" />
+      ) : (
+        <div className="flex flex-wrap gap-1.5">
+          {options.map((scope) => {
+            const on = selected.includes(scope.name)
+            const waiting = on && pending.includes(scope.name)
+            return (
+              <button
+                key={scope.name}
+                type="button"
+                onClick={() => toggle(scope.name)}
+                title={
+                  scope.builtin
+                    ? scope.description
+                    : `${scope.description} · ${scope.application_name ?? "another app"}${
+                        waiting ? " · pending approval" : ""
+                      }`
+                }
+                className={cn(
+                  "rounded-md border px-2 py-1 font-mono text-xs transition-colors",
+                  on
+                    ? "border-gr-pink/60 bg-gr-pink/10 text-foreground"
+                    : "border-border/60 bg-muted/40 text-muted-foreground hover:text-foreground",
+                  waiting && "border-dashed",
+                )}
+              >
+                {scope.name}
+                {waiting && <span className="ml-1 text-muted-foreground">(pending)</span>}
+              </button>
+            )
+          })}
+        </div>
+      )}
+      {dirty && (
+        <div className="flex gap-2">
+          <Button type="button" size="sm" onClick={save} disabled={setAllowed.isPending}>
+            Save
+          </Button>
+          <Button type="button" size="sm" variant="outline" onClick={() => setSelected(saved)}>
+            Reset
+          </Button>
+        </div>
+      )}
+    </div>
+  )
+}
+
+function DefinedScopesSection({ applicationID }: { applicationID: string }) {
+  const scopesQuery = useApplicationScopes(applicationID)
+  const createScope = useCreateApplicationScope(applicationID)
+  const deleteScope = useDeleteApplicationScope(applicationID)
+  const [name, setName] = useState("")
+  const [description, setDescription] = useState("")
+
+  async function add() {
+    try {
+      await createScope.mutateAsync({ name: name.trim(), description: description.trim() })
+      setName("")
+      setDescription("")
+      toast.success("Scope registered")
+    } catch (e) {
+      toast.error(extractError(e, "Couldn't register scope."))
+    }
+  }
+
+  async function remove(scopeName: string) {
+    try {
+      await deleteScope.mutateAsync(scopeName)
+      toast.success("Scope removed")
+    } catch (e) {
+      toast.error(extractError(e, "Couldn't remove scope."))
+    }
+  }
+
+  const scopes = scopesQuery.data ?? []
+
+  return (
+    <div className="space-y-3">
+      <div>
+        <p className="text-sm font-medium">API scopes</p>
+        <p className="text-xs text-muted-foreground">
+          Scopes for this app's own API. Tokens granting one include this app's client ID in{" "}
+          <code className="font-mono">aud</code>. The description is shown on the consent
+          screen.
+        </p>
+      </div>
+      {scopesQuery.isLoading ? (
+        <Skeleton className="h-10 w-full" />
+      ) : scopes.length === 0 ? (
+        <p className="text-sm text-muted-foreground">This app doesn't define any scopes.</p>
+      ) : (
+        <ul className="space-y-2">
+          {scopes.map((s) => (
+            <li
+              key={s.name}
+              className="flex items-center justify-between gap-3 rounded-md border border-border/60 bg-muted/40 px-3 py-2"
+            >
+              <div className="min-w-0">
+                <Badge variant="outline" className="font-mono">
+                  {s.name}
+                </Badge>
+                <p className="mt-1 text-xs text-muted-foreground">{s.description}</p>
+              </div>
+              <Button
+                variant="ghost"
+                size="icon-sm"
+                onClick={() => remove(s.name)}
+                disabled={deleteScope.isPending}
+                title="Remove scope"
+              >
+                <Trash2 className="size-3.5" />
+              </Button>
+            </li>
+          ))}
+        </ul>
+      )}
+      <div className="grid gap-2 sm:grid-cols-[200px_1fr_auto] sm:items-end">
+        <div className="space-y-1">
+          <Label htmlFor="scope-name" className="text-xs">
+            Name
+          </Label>
+          <Input
+            id="scope-name"
+            value={name}
+            onChange={(e) => setName(e.target.value.toLowerCase())}
+            placeholder="telemetry:read"
+            className="font-mono"
+          />
+        </div>
+        <div className="space-y-1">
+          <Label htmlFor="scope-description" className="text-xs">
+            Description
+          </Label>
+          <Input
+            id="scope-description"
+            value={description}
+            onChange={(e) => setDescription(e.target.value)}
+            placeholder="Read telemetry from your runs"
+          />
+        </div>
+        <Button
+          type="button"
+          onClick={add}
+          disabled={!name.trim() || !description.trim() || createScope.isPending}
+        >
+          <Plus className="mr-1 size-3.5" />
+          Add
+        </Button>
+      </div>
+    </div>
+  )
+}
+
+function ScopeRequestsSection({ applicationID }: { applicationID: string }) {
+  const requestsQuery = useScopeRequests(applicationID)
+  const decide = useDecideScopeRequest(applicationID)
+  const requests = requestsQuery.data ?? []
+
+  async function answer(request: ScopeRequest, approve: boolean) {
+    try {
+      await decide.mutateAsync({ request, approve })
+      toast.success(approve ? "Scope approved" : "Scope request rejected")
+    } catch (e) {
+      toast.error(extractError(e, "Couldn't update the scope request."))
+    }
+  }
+
+  if (requestsQuery.isLoading || requests.length === 0) {
+    return null
+  }
+
+  return (
+    <div className="space-y-3">
+      <div>
+        <p className="text-sm font-medium">Pending requests</p>
+        <p className="text-xs text-muted-foreground">
+          Other apps asking to request this app's scopes. Until you approve, they can't get
+          tokens for this app's API with them.
+        </p>
+      </div>
+      <ul className="space-y-2">
+        {requests.map((r) => (
+          <li
+            key={`${r.application_id}:${r.scope}`}
+            className="flex items-center justify-between gap-3 rounded-md border border-border/60 bg-muted/40 px-3 py-2"
+          >
+            <div className="min-w-0">
+              <Badge variant="outline" className="font-mono">
+                {r.scope}
+              </Badge>
+              <p className="mt-1 truncate text-xs text-muted-foreground">
+                {r.application_name} · <span className="font-mono">{r.client_id}</span>
+              </p>
+            </div>
+            <div className="flex gap-1">
+              <Button
+                variant="ghost"
+                size="icon-sm"
+                onClick={() => answer(r, true)}
+                disabled={decide.isPending}
+                title="Approve"
+              >
+                <Check className="size-3.5" />
+              </Button>
+              <Button
+                variant="ghost"
+                size="icon-sm"
+                onClick={() => answer(r, false)}
+                disabled={decide.isPending}
+                title="Reject"
+              >
+                <X className="size-3.5" />
+              </Button>
+            </div>
+          </li>
+        ))}
+      </ul>
+    </div>
+  )
+}
@@ -1,7 +1,7 @@
-import { useQuery, useQueryClient } from "@tanstack/react-query"
-import { ArrowLeft, Copy, ExternalLink, Eye, EyeOff, Pencil } from "lucide-react"
-import { useState } from "react"
-import { Link, useParams } from "react-router-dom"
+import { useQuery } from "@tanstack/react-query"
+import { ArrowLeft, Copy, ExternalLink, Pencil, ShieldAlert } from "lucide-react"
+import { useEffect, useState } from "react"
+import { Link, useLocation, useNavigate, useParams } from "react-router-dom"
 import { toast } from "sonner"
 
 import { OutlineButton } from "@/components/OutlineButton"
@@ -13,9 +13,17 @@ import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card"
 import { Skeleton } from "@/components/ui/skeleton"
 import { useAdmins } from "@/lib/admin"
 import { api } from "@/lib/api"
-import type { Application, GroupWithLink } from "@/lib/applications"
+import {
+  CLIENT_AUTH_METHOD_LABEL,
+  useApproveApplication,
+  type Application,
+  type GroupWithLink,
+} from "@/lib/applications"
 import { loadSession, type Entity } from "@/lib/auth"
 
+import { ApiScopesCard } from "./ApiScopesCard"
+import { ApplicationRolesCard } from "./ApplicationRolesCard"
+import { ClientSecretsCard } from "./ClientSecretsCard"
 import { ServiceAccountsCard } from "./ServiceAccountsCard"
 
 function initial(name: string) {
@@ -71,9 +79,57 @@ function CopyableMono({
   )
 }
 
+// PendingApprovalNotice explains why a self-registered client can't request
+// more than the sign-in scopes yet, and lets admins lift that.
+function PendingApprovalNotice({ app, canApprove }: { app: Application; canApprove: boolean }) {
+  const approve = useApproveApplication(app.id)
+
+  async function handleApprove() {
+    try {
+      await approve.mutateAsync()
+      toast.success("Application approved")
+    } catch (e) {
+      const msg = (e as { response?: { data?: { error?: string } } })?.response?.data?.error
+      toast.error(msg ?? "Couldn't approve application.")
+    }
+  }
+
+  return (
+    <div className="flex flex-wrap items-start justify-between gap-3 rounded-lg border border-border/60 bg-muted/40 px-4 py-3">
+      <div className="flex items-start gap-3">
+        <ShieldAlert className="mt-0.5 size-4 shrink-0 text-muted-foreground" />
+        <div>
+          <p className="text-sm font-medium">Pending approval</p>
+          <p className="text-xs text-muted-foreground">
+            This client registered itself through dynamic client registration. Until an admin
+            approves it, it can only request <code className="font-mono">openid</code>,{" "}
+            <code className="font-mono">profile</code>, and{" "}
+            <code className="font-mono">email</code>.
+          </p>
+        </div>
+      </div>
+      {canApprove && (
+        <Button type="button" size="sm" onClick={handleApprove} disabled={approve.isPending}>
+          {approve.isPending ? "Approving…" : "Approve"}
+        </Button>
+      )}
+    </div>
+  )
+}
+
 export default function ApplicationDetailsPage() {
   const { id } = useParams<{ id: string }>()
-  const [secretVisible, setSecretVisible] = useState(false)
+  const location = useLocation()
+  const navigate = useNavigate()
+  // ApplicationNewPage hands over the first client secret in router state.
+  // Keep it for this render only and drop it from history, so a reload or
+  // back-navigation doesn't show it again.
+  const [initialSecret] = useState(
+    () => (location.state as { clientSecret?: string } | null)?.clientSecret,
+  )
+  useEffect(() => {
+    if (initialSecret) navigate(location.pathname, { replace: true, state: null })
+  }, [initialSecret, location.pathname, navigate])
 
   const query = useQuery({
     queryKey: ["application", "id", id],
@@ -84,30 +140,6 @@ export default function ApplicationDetailsPage() {
     enabled: !!id,
   })
 
-  const queryClient = useQueryClient()
-  const secretQueryOptions = {
-    queryKey: ["application", "id", id, "secret"],
-    queryFn: async () => {
-      const res = await api.get<{ client_secret: string }>(`/applications/${id}/secret`)
-      return res.data.client_secret
-    },
-    staleTime: 5 * 60 * 1000,
-  }
-  const secretQuery = useQuery({
-    ...secretQueryOptions,
-    enabled: !!id && secretVisible,
-  })
-
-  async function copySecret() {
-    try {
-      const secret = await queryClient.fetchQuery(secretQueryOptions)
-      await navigator.clipboard.writeText(secret)
-      toast.success("Client secret copied")
-    } catch {
-      toast.error("Failed to copy client secret")
-    }
-  }
-
   const linkedGroupsQuery = useQuery({
     queryKey: ["application", "id", id, "groups"],
     queryFn: async () => {
@@ -126,8 +158,8 @@ export default function ApplicationDetailsPage() {
   // rather than render a broken UI.
   const canManageServiceAccounts =
     !!ownerId && (ownerId === myEntityID || isAdmin)
-  // Same gate as the backend's secret endpoint: owner or admin.
-  const canRevealSecret = canManageServiceAccounts
+  // Secret and scope management use the same gate (ApplicationWriteAuthorized).
+  const canManageSecrets = canManageServiceAccounts
   const ownerQuery = useQuery({
     queryKey: ["entity", ownerId],
     queryFn: async () => {
@@ -223,7 +255,6 @@ export default function ApplicationDetailsPage() {
   }
 
   const app = query.data
-  const maskedSecret = "•".repeat(48)
 
   return (
     <PageContainer>
@@ -273,6 +304,8 @@ export default function ApplicationDetailsPage() {
       </header>
 
       <div className="space-y-4">
+        {app.pending_approval && <PendingApprovalNotice app={app} canApprove={isAdmin} />}
+
         <Card>
           <CardHeader>
             <CardTitle>OAuth credentials</CardTitle>
@@ -281,30 +314,29 @@ export default function ApplicationDetailsPage() {
             <Field label="Client ID">
               <CopyableMono value={app.client_id} label="Client ID" />
             </Field>
-            <Field label="Client Secret">
-              <div className="flex items-center gap-1 rounded-md border border-border/60 bg-muted/40 px-2.5 py-1.5">
-                <code className="flex-1 truncate font-mono text-xs">
-                  {secretVisible ? (secretQuery.data ?? "Loading…") : maskedSecret}
-                </code>
-                {canRevealSecret && (
-                  <>
-                    <Button
-                      variant="ghost"
-                      size="icon-sm"
-                      onClick={() => setSecretVisible((v) => !v)}
-                    >
-                      {secretVisible ? <EyeOff className="size-3.5" /> : <Eye className="size-3.5" />}
-                    </Button>
-                    <Button variant="ghost" size="icon-sm" onClick={copySecret}>
-                      <Copy className="size-3.5" />
-                    </Button>
-                  </>
+            <Field label="Authentication">
+              <span className="text-sm">
+                {CLIENT_AUTH_METHOD_LABEL[app.token_endpoint_auth_method] ??
+                  CLIENT_AUTH_METHOD_LABEL.client_secret_basic}
+                {app.token_endpoint_auth_method === "private_key_jwt" && (
+                  <span className="text-muted-foreground">
+                    {" "}
+                    · {app.jwks_uri ? app.jwks_uri : `${app.jwks?.keys.length ?? 0} inline key(s)`}
+                  </span>
                 )}
-              </div>
+              </span>
             </Field>
           </CardContent>
         </Card>
 
+        {canManageSecrets && (
+          <ClientSecretsCard
+            applicationID={app.id}
+            initialSecret={initialSecret}
+            acceptsSecrets={app.token_endpoint_auth_method !== "private_key_jwt"}
+          />
+        )}
+
         <Card>
           <CardHeader>
             <CardTitle>Redirect URIs</CardTitle>
@@ -374,6 +406,10 @@ export default function ApplicationDetailsPage() {
           </CardContent>
         </Card>
 
+        {canManageSecrets && <ApiScopesCard app={app} />}
+
+        {canManageSecrets && <ApplicationRolesCard applicationID={app.id} />}
+
         {canManageServiceAccounts && app && (
           <ServiceAccountsCard applicationID={app.id} />
         )}
@@ -27,12 +27,18 @@ import {
 } from "@/components/ui/select"
 import { Skeleton } from "@/components/ui/skeleton"
 import { Textarea } from "@/components/ui/textarea"
-import { api } from "@/lib/api"
+import { useAdmins } from "@/lib/admin"
+import { api, getAllPages } from "@/lib/api"
 import {
+  CLIENT_AUTH_METHOD_LABEL,
+  OPTIONAL_CLAIMS,
   redirectURIWildcardExamples,
   type Application,
+  type ClientAuthMethod,
   type GroupWithLink,
+  type OptionalClaim,
   type SAMLConfig,
+  type TokenPolicy,
 } from "@/lib/applications"
 import type { Group } from "@/lib/groups"
 
@@ -438,6 +444,419 @@ function SamlConfigCard({
   )
 }
 
+const SECONDS_PER_MINUTE = 60
+const SECONDS_PER_DAY = 24 * 60 * 60
+
+// Lifetimes are stored in seconds but edited in minutes or days. Blank
+// means the default, stored as 0.
+function secondsToInput(seconds: number | undefined, unit: number): string {
+  return seconds ? String(seconds / unit) : ""
+}
+
+function inputToSeconds(value: string, unit: number): number {
+  const n = parseFloat(value)
+  return Number.isFinite(n) && n > 0 ? Math.round(n * unit) : 0
+}
+
+// TokenPolicyDraft is the token policy as edited: lifetimes are the
+// input strings, in the units the card shows.
+type TokenPolicyDraft = {
+  accessMinutes: string
+  idMinutes: string
+  refreshIdleDays: string
+  refreshAbsoluteDays: string
+  allowOfflineAccess: boolean
+  optionalClaims: OptionalClaim[]
+  sharedEmail: string
+  sharedUsername: string
+  sharedFirstName: string
+  sharedLastName: string
+}
+
+function tokenPolicyDraft(policy: TokenPolicy): TokenPolicyDraft {
+  return {
+    accessMinutes: secondsToInput(policy.access_token_ttl, SECONDS_PER_MINUTE),
+    idMinutes: secondsToInput(policy.id_token_ttl, SECONDS_PER_MINUTE),
+    refreshIdleDays: secondsToInput(policy.refresh_token_idle_ttl, SECONDS_PER_DAY),
+    refreshAbsoluteDays: secondsToInput(policy.refresh_token_absolute_ttl, SECONDS_PER_DAY),
+    allowOfflineAccess: policy.allow_offline_access,
+    optionalClaims: policy.optional_claims ?? [],
+    sharedEmail: policy.shared_email,
+    sharedUsername: policy.shared_username,
+    sharedFirstName: policy.shared_first_name,
+    sharedLastName: policy.shared_last_name,
+  }
+}
+
+function tokenPolicyBody(draft: TokenPolicyDraft) {
+  return {
+    access_token_ttl: inputToSeconds(draft.accessMinutes, SECONDS_PER_MINUTE),
+    id_token_ttl: inputToSeconds(draft.idMinutes, SECONDS_PER_MINUTE),
+    refresh_token_idle_ttl: inputToSeconds(draft.refreshIdleDays, SECONDS_PER_DAY),
+    refresh_token_absolute_ttl: inputToSeconds(draft.refreshAbsoluteDays, SECONDS_PER_DAY),
+    allow_offline_access: draft.allowOfflineAccess,
+    optional_claims: draft.optionalClaims,
+    shared_email: draft.sharedEmail.trim(),
+    shared_username: draft.sharedUsername.trim(),
+    shared_first_name: draft.sharedFirstName.trim(),
+    shared_last_name: draft.sharedLastName.trim(),
+  }
+}
+
+function LifetimeInput({
+  id,
+  label,
+  hint,
+  value,
+  disabled,
+  onChange,
+}: {
+  id: string
+  label: string
+  hint: string
+  value: string
+  disabled?: boolean
+  onChange: (v: string) => void
+}) {
+  return (
+    <div className="space-y-2">
+      <Label htmlFor={id}>{label}</Label>
+      <Input
+        id={id}
+        type="number"
+        min={0}
+        step="any"
+        value={value}
+        disabled={disabled}
+        onChange={(e) => onChange(e.target.value)}
+        placeholder="Default"
+      />
+      <p className="text-xs text-muted-foreground">{hint}</p>
+    </div>
+  )
+}
+
+function TokenPolicyCard({
+  draft,
+  canEditSharedIdentity,
+  onChange,
+}: {
+  draft: TokenPolicyDraft
+  canEditSharedIdentity: boolean
+  onChange: (draft: TokenPolicyDraft) => void
+}) {
+  function set<K extends keyof TokenPolicyDraft>(key: K, value: TokenPolicyDraft[K]) {
+    onChange({ ...draft, [key]: value })
+  }
+
+  function toggleClaim(claim: OptionalClaim, on: boolean) {
+    const rest = draft.optionalClaims.filter((c) => c !== claim)
+    set("optionalClaims", on ? [...rest, claim] : rest)
+  }
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>Tokens</CardTitle>
+        <CardDescription>
+          How long this app's tokens last and what they contain. Leave a lifetime blank to use
+          the server default.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-6">
+        <div className="grid gap-5 sm:grid-cols-2">
+          <LifetimeInput
+            id="access_token_minutes"
+            label="Access token lifetime (minutes)"
+            hint="Up to a day."
+            value={draft.accessMinutes}
+            onChange={(v) => set("accessMinutes", v)}
+          />
+          <LifetimeInput
+            id="id_token_minutes"
+            label="ID token lifetime (minutes)"
+            hint="Up to a day."
+            value={draft.idMinutes}
+            onChange={(v) => set("idMinutes", v)}
+          />
+        </div>
+
+        <div className="space-y-4">
+          <label className="flex items-start gap-3 text-sm">
+            <input
+              type="checkbox"
+              className="mt-0.5"
+              checked={draft.allowOfflineAccess}
+              onChange={(e) => set("allowOfflineAccess", e.target.checked)}
+            />
+            <span>
+              Allow offline access
+              <span className="block text-muted-foreground">
+                Issue refresh tokens so the app can keep users signed in. Each refresh hands
+                out a new refresh token; if an old one is used again, every token from that
+                sign-in is revoked.
+              </span>
+            </span>
+          </label>
+          <div className="grid gap-5 sm:grid-cols-2">
+            <LifetimeInput
+              id="refresh_idle_days"
+              label="Refresh token idle lifetime (days)"
+              hint="How long a refresh token lasts if it isn't used."
+              value={draft.refreshIdleDays}
+              disabled={!draft.allowOfflineAccess}
+              onChange={(v) => set("refreshIdleDays", v)}
+            />
+            <LifetimeInput
+              id="refresh_absolute_days"
+              label="Refresh token absolute lifetime (days)"
+              hint="How long a sign-in lasts, however often it's refreshed."
+              value={draft.refreshAbsoluteDays}
+              disabled={!draft.allowOfflineAccess}
+              onChange={(v) => set("refreshAbsoluteDays", v)}
+            />
+          </div>
+        </div>
+
+        <div className="space-y-3">
+          <Label>Optional claims</Label>
+          {OPTIONAL_CLAIMS.map(({ claim, label, description }) => (
+            <label key={claim} className="flex items-start gap-3 text-sm">
+              <input
+                type="checkbox"
+                className="mt-0.5"
+                checked={draft.optionalClaims.includes(claim)}
+                onChange={(e) => toggleClaim(claim, e.target.checked)}
+              />
+              <span>
+                {label}
+                <span className="block text-muted-foreground">{description}</span>
+              </span>
+            </label>
+          ))}
+        </div>
+
+        <div className="space-y-3">
+          <div>
+            <Label>Shared identity</Label>
+            <p className="mt-1 text-xs text-muted-foreground">
+              Every user appears to this app as the same account, such as a shared mailbox.
+              Tokens still identify the real user to Sentinel.{" "}
+              {canEditSharedIdentity
+                ? "Leave the email blank to turn this off."
+                : "Only admins can change it."}
+            </p>
+          </div>
+          <div className="grid gap-5 sm:grid-cols-2">
+            <div className="space-y-2">
+              <Label htmlFor="shared_email">Email</Label>
+              <Input
+                id="shared_email"
+                type="email"
+                value={draft.sharedEmail}
+                disabled={!canEditSharedIdentity}
+                onChange={(e) => set("sharedEmail", e.target.value)}
+                placeholder="team@gauchoracing.com"
+              />
+            </div>
+            <div className="space-y-2">
+              <Label htmlFor="shared_username">Username</Label>
+              <Input
+                id="shared_username"
+                value={draft.sharedUsername}
+                disabled={!canEditSharedIdentity}
+                onChange={(e) => set("sharedUsername", e.target.value)}
+                placeholder="team"
+              />
+            </div>
+            <div className="space-y-2">
+              <Label htmlFor="shared_first_name">First name</Label>
+              <Input
+                id="shared_first_name"
+                value={draft.sharedFirstName}
+                disabled={!canEditSharedIdentity}
+                onChange={(e) => set("sharedFirstName", e.target.value)}
+              />
+            </div>
+            <div className="space-y-2">
+              <Label htmlFor="shared_last_name">Last name</Label>
+              <Input
+                id="shared_last_name"
+                value={draft.sharedLastName}
+                disabled={!canEditSharedIdentity}
+                onChange={(e) => set("sharedLastName", e.target.value)}
+              />
+            </div>
+          </div>
+        </div>
+      </CardContent>
+    </Card>
+  )
+}
+
+type KeySource = "inline" | "uri"
+
+function ClientAuthCard({
+  method,
+  keySource,
+  jwksText,
+  jwksURI,
+  requirePAR,
+  onChangeMethod,
+  onChangeKeySource,
+  onChangeJWKSText,
+  onChangeJWKSURI,
+  onChangeRequirePAR,
+}: {
+  method: ClientAuthMethod
+  keySource: KeySource
+  jwksText: string
+  jwksURI: string
+  requirePAR: boolean
+  onChangeMethod: (v: ClientAuthMethod) => void
+  onChangeKeySource: (v: KeySource) => void
+  onChangeJWKSText: (v: string) => void
+  onChangeJWKSURI: (v: string) => void
+  onChangeRequirePAR: (v: boolean) => void
+}) {
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>Client authentication</CardTitle>
+        <CardDescription>
+          How this app proves its identity at the token endpoint. With a private key JWT, the
+          app signs a short-lived assertion with its own key and Sentinel checks it against the
+          public keys registered here — no shared secret is accepted. The same keys verify
+          signed authorization requests.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-5">
+        <div className="space-y-2">
+          <Label htmlFor="auth_method">Method</Label>
+          <Select value={method} onValueChange={(v) => onChangeMethod(v as ClientAuthMethod)}>
+            <SelectTrigger id="auth_method">
+              <SelectValue />
+            </SelectTrigger>
+            <SelectContent>
+              {(["client_secret_basic", "private_key_jwt"] as const).map((m) => (
+                <SelectItem key={m} value={m}>
+                  {CLIENT_AUTH_METHOD_LABEL[m]}
+                </SelectItem>
+              ))}
+            </SelectContent>
+          </Select>
+        </div>
+        <div className="space-y-2">
+          <Label htmlFor="key_source">
+            Public keys{method === "private_key_jwt" ? "" : " (optional)"}
+          </Label>
+          <Select value={keySource} onValueChange={(v) => onChangeKeySource(v as KeySource)}>
+            <SelectTrigger id="key_source">
+              <SelectValue />
+            </SelectTrigger>
+            <SelectContent>
+              <SelectItem value="inline">Paste a JWK Set</SelectItem>
+              <SelectItem value="uri">Fetch from a JWKS URL</SelectItem>
+            </SelectContent>
+          </Select>
+        </div>
+        {keySource === "inline" ? (
+          <div className="space-y-2">
+            <Label htmlFor="jwks">JWK Set</Label>
+            <Textarea
+              id="jwks"
+              value={jwksText}
+              onChange={(e) => onChangeJWKSText(e.target.value)}
+              rows={6}
+              placeholder={'{"keys": [{"kty": "EC", "crv": "P-256", "x": "…", "y": "…"}]}'}
+              className="font-mono text-xs"
+            />
+            <p className="text-xs text-muted-foreground">
+              Public keys only. RSA (2048 bits or more) and EC P-256/P-384/P-521 are supported.
+            </p>
+          </div>
+        ) : (
+          <div className="space-y-2">
+            <Label htmlFor="jwks_uri">JWKS URL</Label>
+            <Input
+              id="jwks_uri"
+              type="url"
+              value={jwksURI}
+              onChange={(e) => onChangeJWKSURI(e.target.value)}
+              placeholder="https://app.gauchoracing.com/.well-known/jwks.json"
+            />
+            <p className="text-xs text-muted-foreground">
+              Must be https. Cached for a few minutes; an unknown key ID triggers a refetch, so
+              new keys can be published before they're used.
+            </p>
+          </div>
+        )}
+        {method === "private_key_jwt" && (
+          <p className="text-xs text-muted-foreground">
+            Assertions must have <code>iss</code> and <code>sub</code> set to the client ID,{" "}
+            <code>aud</code> set to the issuer or token endpoint, a unique <code>jti</code>,
+            and expire within an hour.
+          </p>
+        )}
+        <label className="flex items-start gap-3 text-sm">
+          <input
+            type="checkbox"
+            className="mt-0.5"
+            checked={requirePAR}
+            onChange={(e) => onChangeRequirePAR(e.target.checked)}
+          />
+          <span>
+            Require pushed authorization requests
+            <span className="block text-muted-foreground">
+              The app must post its authorization parameters to the PAR endpoint and send users
+              to the authorize page with only a <code>request_uri</code>, so nothing sensitive
+              travels in the browser's URL.
+            </span>
+          </span>
+        </label>
+      </CardContent>
+    </Card>
+  )
+}
+
+function TokenExchangeCard({
+  audiences,
+  canEdit,
+  onChange,
+}: {
+  audiences: string
+  canEdit: boolean
+  onChange: (v: string) => void
+}) {
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>Token exchange</CardTitle>
+        <CardDescription>
+          Other apps this app's service accounts may act on a user's behalf for. A service can
+          only exchange a token the user issued to this app, and always for this app itself;
+          anything else has to be listed here.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-2">
+        <Label htmlFor="exchange_audiences">Client IDs</Label>
+        <Textarea
+          id="exchange_audiences"
+          value={audiences}
+          onChange={(e) => onChange(e.target.value)}
+          rows={3}
+          placeholder="One client ID per line"
+          className="font-mono text-xs"
+          disabled={!canEdit}
+        />
+        {!canEdit && (
+          <p className="text-xs text-muted-foreground">Only admins can change this list.</p>
+        )}
+      </CardContent>
+    </Card>
+  )
+}
+
 export default function ApplicationEditPage() {
   const { id } = useParams<{ id: string }>()
   const navigate = useNavigate()
@@ -463,10 +882,7 @@ export default function ApplicationEditPage() {
 
   const groupsQuery = useQuery({
     queryKey: ["groups"],
-    queryFn: async () => {
-      const res = await api.get<Group[]>(`/groups`)
-      return res.data
-    },
+    queryFn: () => getAllPages<Group>(`/groups`),
     staleTime: 5 * 60 * 1000,
   })
 
@@ -489,11 +905,27 @@ export default function ApplicationEditPage() {
     retry: false,
   })
 
+  const policyQuery = useQuery({
+    queryKey: ["application", "id", id, "token-policy"],
+    queryFn: async () => {
+      const res = await api.get<TokenPolicy>(`/applications/${id}/token-policy`)
+      return res.data
+    },
+    enabled: !!id,
+  })
+  const { isAdmin } = useAdmins()
+
   // Basics form state.
   const [name, setName] = useState("")
   const [description, setDescription] = useState("")
   const [iconURL, setIconURL] = useState("")
   const [launchURL, setLaunchURL] = useState("")
+  const [authMethod, setAuthMethod] = useState<ClientAuthMethod>("client_secret_basic")
+  const [keySource, setKeySource] = useState<KeySource>("inline")
+  const [jwksText, setJWKSText] = useState("")
+  const [jwksURI, setJWKSURI] = useState("")
+  const [requirePAR, setRequirePAR] = useState(false)
+  const [exchangeAudiences, setExchangeAudiences] = useState("")
   const [initialized, setInitialized] = useState(false)
 
   // Staged redirect URI changes — applied on Save.
@@ -513,6 +945,9 @@ export default function ApplicationEditPage() {
   const [samlExisted, setSamlExisted] = useState(false)
   const [samlInitialized, setSamlInitialized] = useState(false)
 
+  // Staged token policy — PUT whole on Save.
+  const [policyDraft, setPolicyDraft] = useState<TokenPolicyDraft | null>(null)
+
   // Dialog / in-flight state.
   const [submitting, setSubmitting] = useState(false)
   const [deleteConfirmOpen, setDeleteConfirmOpen] = useState(false)
@@ -524,6 +959,12 @@ export default function ApplicationEditPage() {
       setDescription(query.data.description)
       setIconURL(query.data.icon_url)
       setLaunchURL(query.data.launch_url)
+      setAuthMethod(query.data.token_endpoint_auth_method || "client_secret_basic")
+      setKeySource(query.data.jwks_uri ? "uri" : "inline")
+      setJWKSText(query.data.jwks ? JSON.stringify(query.data.jwks, null, 2) : "")
+      setJWKSURI(query.data.jwks_uri ?? "")
+      setRequirePAR(query.data.require_pushed_authorization_requests ?? false)
+      setExchangeAudiences((query.data.token_exchange_audiences ?? []).join("\n"))
       setInitialized(true)
     }
   }, [query.data, initialized])
@@ -548,6 +989,12 @@ export default function ApplicationEditPage() {
     }
   }, [samlQuery.data, samlQuery.isError, samlInitialized])
 
+  useEffect(() => {
+    if (policyQuery.data && !policyDraft) {
+      setPolicyDraft(tokenPolicyDraft(policyQuery.data))
+    }
+  }, [policyQuery.data, policyDraft])
+
   // Render rows for the card: each link gets its current desired-required
   // state from linkState, name from groupsQuery (canonical source). Falls
   // back to the group_id when names haven't loaded.
@@ -610,6 +1057,17 @@ export default function ApplicationEditPage() {
 
   async function commitSave() {
     if (!id) return
+    // Keys are kept whatever the method: they also verify signed
+    // authorization requests.
+    let jwks: unknown = null
+    if (keySource === "inline" && jwksText.trim()) {
+      try {
+        jwks = JSON.parse(jwksText)
+      } catch {
+        toast.error("The JWK Set isn't valid JSON.")
+        return
+      }
+    }
     setSubmitting(true)
     try {
       for (const uri of pendingURIRemoves) {
@@ -652,15 +1110,28 @@ export default function ApplicationEditPage() {
         await api.delete(`/applications/${id}/saml`)
       }
 
+      if (policyDraft) {
+        await api.put(`/applications/${id}/token-policy`, tokenPolicyBody(policyDraft))
+      }
+
       await api.put(`/applications/${id}`, {
         name,
         description,
         icon_url: iconURL,
         launch_url: launchURL,
+        token_endpoint_auth_method: authMethod,
+        jwks,
+        jwks_uri: keySource === "uri" ? jwksURI.trim() : "",
+        require_pushed_authorization_requests: requirePAR,
+        // Omitted for non-admins, which leaves it unchanged.
+        token_exchange_audiences: isAdmin
+          ? exchangeAudiences.split(/[\s,]+/).filter(Boolean)
+          : undefined,
       })
       qc.invalidateQueries({ queryKey: ["application", "id", id] })
       qc.invalidateQueries({ queryKey: ["application", "id", id, "groups"] })
       qc.invalidateQueries({ queryKey: ["application", "id", id, "saml"] })
+      qc.invalidateQueries({ queryKey: ["application", "id", id, "token-policy"] })
       qc.invalidateQueries({ queryKey: ["applications"] })
       toast.success("Application updated")
       navigate(`/applications/${id}`)
@@ -692,7 +1163,13 @@ export default function ApplicationEditPage() {
     }
   }
 
-  if (query.isLoading || !initialized || !linksInitialized || !samlInitialized) {
+  if (
+    query.isLoading ||
+    !initialized ||
+    !linksInitialized ||
+    !samlInitialized ||
+    (policyQuery.isLoading && !policyDraft)
+  ) {
     return (
       <PageContainer>
         <Skeleton className="mb-4 h-4 w-24" />
@@ -764,6 +1241,30 @@ export default function ApplicationEditPage() {
           onAddURI={handleAddURI}
           onRemoveURI={handleRemoveURI}
         />
+        <ClientAuthCard
+          method={authMethod}
+          keySource={keySource}
+          jwksText={jwksText}
+          jwksURI={jwksURI}
+          onChangeMethod={setAuthMethod}
+          onChangeKeySource={setKeySource}
+          onChangeJWKSText={setJWKSText}
+          requirePAR={requirePAR}
+          onChangeJWKSURI={setJWKSURI}
+          onChangeRequirePAR={setRequirePAR}
+        />
+        <TokenExchangeCard
+          audiences={exchangeAudiences}
+          canEdit={isAdmin}
+          onChange={setExchangeAudiences}
+        />
+        {policyDraft && (
+          <TokenPolicyCard
+            draft={policyDraft}
+            canEditSharedIdentity={isAdmin}
+            onChange={setPolicyDraft}
+          />
+        )}
         <LinkedGroupsCard
           links={linkList}
           allGroups={groupsQuery.data ?? []}
@@ -814,9 +1315,9 @@ export default function ApplicationEditPage() {
             </div>
             <DialogTitle>Delete {app.name}?</DialogTitle>
             <DialogDescription>
-              This permanently removes the application along with its OAuth credentials and
-              redirect URIs. Any tokens already issued under this client stop validating
-              immediately. This can't be undone.
+              The application stops accepting sign-ins right away. Its credentials,
+              redirect URIs, and group links are kept for 30 days, so it can be restored;
+              after that it's deleted for good.
             </DialogDescription>
           </DialogHeader>
 
@@ -25,14 +25,16 @@ export default function ApplicationNewPage() {
     if (submitting) return
     setSubmitting(true)
     try {
-      const res = await api.post<Application>("/applications", {
+      const res = await api.post<Application & { client_secret: string }>("/applications", {
         name,
         description,
         icon_url: iconURL,
         launch_url: launchURL,
       })
       toast.success("Application created")
-      navigate(`/applications/${res.data.id}`)
+      navigate(`/applications/${res.data.id}`, {
+        state: { clientSecret: res.data.client_secret },
+      })
     } catch (err: unknown) {
       const message =
         (err as { response?: { data?: { error?: string } } })?.response?.data?.error ??
@@ -0,0 +1,282 @@
+import { useQuery } from "@tanstack/react-query"
+import { Plus, Trash2, UserCog, X } from "lucide-react"
+import { useMemo, useState } from "react"
+import { toast } from "sonner"
+
+import { Badge } from "@/components/ui/badge"
+import { Button } from "@/components/ui/button"
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card"
+import { Input } from "@/components/ui/input"
+import { Label } from "@/components/ui/label"
+import { Skeleton } from "@/components/ui/skeleton"
+import { getAllPages } from "@/lib/api"
+import {
+  useApplicationRoleAssignments,
+  useApplicationRoles,
+  useCreateApplicationRole,
+  useDeleteApplicationRole,
+  useUpdateApplicationRole,
+  type ApplicationRole,
+  type RoleRule,
+} from "@/lib/applications"
+import type { Group } from "@/lib/groups"
+
+import { GroupPickerDialog } from "../groups/GroupPickerDialog"
+
+function extractError(e: unknown, fallback: string): string {
+  const msg = (e as { response?: { data?: { error?: string } } })?.response?.data?.error
+  return msg ?? fallback
+}
+
+// ApplicationRolesCard manages the roles an app defines and the group rules
+// that grant them, and previews who ends up with which role.
+export function ApplicationRolesCard({ applicationID }: { applicationID: string }) {
+  const rolesQuery = useApplicationRoles(applicationID)
+  const createRole = useCreateApplicationRole(applicationID)
+  const [name, setName] = useState("")
+  const [description, setDescription] = useState("")
+
+  const groupsQuery = useQuery({
+    queryKey: ["groups"],
+    queryFn: () => getAllPages<Group>("/groups"),
+  })
+  const groupNamesByID = useMemo(() => {
+    const m: Record<string, string> = {}
+    for (const g of groupsQuery.data ?? []) m[g.id] = g.name
+    return m
+  }, [groupsQuery.data])
+
+  async function add() {
+    try {
+      await createRole.mutateAsync({ name: name.trim(), description: description.trim(), rules: [] })
+      setName("")
+      setDescription("")
+      toast.success("Role created")
+    } catch (e) {
+      toast.error(extractError(e, "Couldn't create role."))
+    }
+  }
+
+  const roles = rolesQuery.data ?? []
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <UserCog className="size-4 text-muted-foreground" />
+          Roles
+        </CardTitle>
+        <CardDescription>
+          Roles this app understands, granted from Sentinel groups. Users get the ones they
+          qualify for in the <code className="font-mono">roles</code> claim, the userinfo{" "}
+          <code className="font-mono">roles</code> field, and the SAML{" "}
+          <code className="font-mono">role</code> attribute.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-6">
+        {rolesQuery.isLoading ? (
+          <Skeleton className="h-16 w-full" />
+        ) : roles.length === 0 ? (
+          <p className="text-sm text-muted-foreground">This app doesn't define any roles.</p>
+        ) : (
+          <ul className="space-y-3">
+            {roles.map((role) => (
+              <RoleRow
+                key={role.id}
+                applicationID={applicationID}
+                role={role}
+                groupNamesByID={groupNamesByID}
+              />
+            ))}
+          </ul>
+        )}
+
+        <div className="grid gap-2 sm:grid-cols-[200px_1fr_auto] sm:items-end">
+          <div className="space-y-1">
+            <Label htmlFor="role-name" className="text-xs">
+              Name
+            </Label>
+            <Input
+              id="role-name"
+              value={name}
+              onChange={(e) => setName(e.target.value)}
+              placeholder="admin"
+              className="font-mono"
+            />
+          </div>
+          <div className="space-y-1">
+            <Label htmlFor="role-description" className="text-xs">
+              Description
+            </Label>
+            <Input
+              id="role-description"
+              value={description}
+              onChange={(e) => setDescription(e.target.value)}
+              placeholder="Full access to the dashboard"
+            />
+          </div>
+          <Button type="button" onClick={add} disabled={!name.trim() || createRole.isPending}>
+            <Plus className="mr-1 size-3.5" />
+            Add
+          </Button>
+        </div>
+
+        {roles.length > 0 && <RoleAssignments applicationID={applicationID} />}
+      </CardContent>
+    </Card>
+  )
+}
+
+function RoleRow({
+  applicationID,
+  role,
+  groupNamesByID,
+}: {
+  applicationID: string
+  role: ApplicationRole
+  groupNamesByID: Record<string, string>
+}) {
+  const updateRole = useUpdateApplicationRole(applicationID)
+  const deleteRole = useDeleteApplicationRole(applicationID)
+  const [pickerOpen, setPickerOpen] = useState(false)
+  const rules = role.rules ?? []
+
+  async function saveRules(next: RoleRule[]) {
+    try {
+      await updateRole.mutateAsync({
+        id: role.id,
+        name: role.name,
+        description: role.description,
+        rules: next,
+      })
+    } catch (e) {
+      toast.error(extractError(e, "Couldn't update role."))
+    }
+  }
+
+  async function remove() {
+    try {
+      await deleteRole.mutateAsync(role.id)
+      toast.success("Role removed")
+    } catch (e) {
+      toast.error(extractError(e, "Couldn't remove role."))
+    }
+  }
+
+  return (
+    <li className="space-y-2 rounded-md border border-border/60 bg-muted/40 px-3 py-2">
+      <div className="flex items-center justify-between gap-3">
+        <div className="min-w-0">
+          <Badge variant="outline" className="font-mono">
+            {role.name}
+          </Badge>
+          {role.description && (
+            <p className="mt-1 text-xs text-muted-foreground">{role.description}</p>
+          )}
+        </div>
+        <Button
+          variant="ghost"
+          size="icon-sm"
+          onClick={remove}
+          disabled={deleteRole.isPending}
+          title="Remove role"
+        >
+          <Trash2 className="size-3.5" />
+        </Button>
+      </div>
+
+      {rules.length === 0 ? (
+        <p className="text-xs text-muted-foreground">No rules yet — nobody holds this role.</p>
+      ) : (
+        <ul className="space-y-1">
+          {rules.map((rule, i) => (
+            <li key={i} className="flex flex-wrap items-center gap-1.5 text-xs">
+              {i > 0 && <span className="text-muted-foreground">or</span>}
+              {rule.group_ids.map((id, j) => (
+                <span key={id} className="flex items-center gap-1.5">
+                  {j > 0 && <span className="text-muted-foreground">and</span>}
+                  <Badge variant="secondary">{groupNamesByID[id] ?? id}</Badge>
+                </span>
+              ))}
+              <Button
+                variant="ghost"
+                size="icon-sm"
+                onClick={() => saveRules(rules.filter((_, k) => k !== i))}
+                disabled={updateRole.isPending}
+                title="Remove rule"
+              >
+                <X className="size-3" />
+              </Button>
+            </li>
+          ))}
+        </ul>
+      )}
+
+      <Button type="button" size="sm" variant="outline" onClick={() => setPickerOpen(true)}>
+        <Plus className="mr-1 size-3.5" />
+        Add rule
+      </Button>
+      <GroupPickerDialog
+        open={pickerOpen}
+        onOpenChange={setPickerOpen}
+        excludeGroupID=""
+        onAddBinding={(groupIDs) => saveRules([...rules, { group_ids: groupIDs }])}
+        title={`Grant ${role.name}`}
+        description={
+          <>
+            Users must be a member of <strong>all</strong> selected groups to get this role. To
+            express "either-or", add a second rule.
+          </>
+        }
+        confirmLabel="Add rule"
+      />
+    </li>
+  )
+}
+
+function RoleAssignments({ applicationID }: { applicationID: string }) {
+  const assignmentsQuery = useApplicationRoleAssignments(applicationID)
+  const assignments = assignmentsQuery.data ?? []
+
+  return (
+    <div className="space-y-3">
+      <div>
+        <p className="text-sm font-medium">Who gets what</p>
+        <p className="text-xs text-muted-foreground">
+          The roles each member would receive if they signed in now.
+        </p>
+      </div>
+      {assignmentsQuery.isLoading ? (
+        <Skeleton className="h-10 w-full" />
+      ) : assignments.length === 0 ? (
+        <p className="text-sm text-muted-foreground">Nobody qualifies for any role yet.</p>
+      ) : (
+        <ul className="divide-y divide-border/60 rounded-md border border-border/60">
+          {assignments.map((a) => (
+            <li key={a.entity_id} className="flex items-center justify-between gap-3 px-3 py-2">
+              <div className="min-w-0">
+                <p className="truncate text-sm">{a.name || a.entity_id}</p>
+                <p className="truncate text-xs text-muted-foreground">
+                  {a.entity_type === "SERVICE_ACCOUNT" ? "Service account" : `@${a.username}`}
+                </p>
+              </div>
+              <div className="flex flex-wrap justify-end gap-1">
+                {a.roles.map((r) => (
+                  <Badge key={r} variant="outline" className="font-mono">
+                    {r}
+                  </Badge>
+                ))}
+              </div>
+            </li>
+          ))}
+        </ul>
+      )}
+    </div>
+  )
+}
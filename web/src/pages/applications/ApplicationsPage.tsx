@@ -8,19 +8,20 @@ import { PageContainer, PageHeader } from "@/components/PageContainer"
 import { Button } from "@/components/ui/button"
 import { Input } from "@/components/ui/input"
 import { Skeleton } from "@/components/ui/skeleton"
-import { api } from "@/lib/api"
+import { useAdmins } from "@/lib/admin"
+import { getAllPages } from "@/lib/api"
 import type { Application } from "@/lib/applications"
 import { fuzzyFilter } from "@/lib/fuzzy"
 
+import { RegistrationTokensCard } from "./RegistrationTokensCard"
+
 export default function ApplicationsPage() {
   const [query, setQuery] = useState("")
+  const { isAdmin } = useAdmins()
 
   const appsQuery = useQuery({
     queryKey: ["applications"],
-    queryFn: async () => {
-      const res = await api.get<Application[]>("/applications")
-      return res.data
-    },
+    queryFn: () => getAllPages<Application>("/applications"),
   })
 
   const apps = appsQuery.data ?? []
@@ -66,6 +67,12 @@ export default function ApplicationsPage() {
           sorted.map((app) => <AppCard key={app.id} app={app} />)
         )}
       </div>
+
+      {isAdmin && (
+        <div className="mt-8">
+          <RegistrationTokensCard />
+        </div>
+      )}
     </PageContainer>
   )
 }
@@ -0,0 +1,513 @@
+import { Copy, KeyRound, Plus, RefreshCw, Trash2 } from "lucide-react"
+import { useState } from "react"
+import { toast } from "sonner"
+
+import { OutlineButton } from "@/components/OutlineButton"
+import { Badge } from "@/components/ui/badge"
+import { Button } from "@/components/ui/button"
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card"
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogHeader,
+  DialogTitle,
+} from "@/components/ui/dialog"
+import { Input } from "@/components/ui/input"
+import { Label } from "@/components/ui/label"
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "@/components/ui/select"
+import { Skeleton } from "@/components/ui/skeleton"
+import {
+  isSecretExpired,
+  useApplicationSecrets,
+  useCreateApplicationSecret,
+  useRevokeApplicationSecret,
+  useRotateApplicationSecret,
+  type ApplicationSecret,
+} from "@/lib/applications"
+
+// How long the previous secrets keep working after a rotation. The
+// backend caps this at a week.
+const GRACE_PRESETS = [
+  { hours: 0, label: "Immediately" },
+  { hours: 1, label: "1 hour" },
+  { hours: 24, label: "24 hours" },
+  { hours: 72, label: "3 days" },
+  { hours: 168, label: "7 days" },
+]
+
+function formatDate(iso: string | null | undefined): string {
+  if (!iso) return "—"
+  return new Date(iso).toLocaleString(undefined, {
+    year: "numeric",
+    month: "short",
+    day: "numeric",
+    hour: "numeric",
+    minute: "2-digit",
+  })
+}
+
+function extractError(e: unknown, fallback: string): string {
+  const msg = (e as { response?: { data?: { error?: string } } })?.response?.data?.error
+  return msg ?? fallback
+}
+
+// A revealed secret: the plaintext plus what to call it in the dialog.
+type Revealed = { label: string; secret: string }
+
+export function ClientSecretsCard({
+  applicationID,
+  initialSecret,
+  acceptsSecrets = true,
+}: {
+  applicationID: string
+  // False when the app authenticates with private_key_jwt; its secrets are
+  // kept but the token endpoint won't accept them.
+  acceptsSecrets?: boolean
+  // Set right after the app is created, so the first secret is shown once
+  // without the owner having to go looking for it.
+  initialSecret?: string
+}) {
+  const secretsQuery = useApplicationSecrets(applicationID)
+  const [createOpen, setCreateOpen] = useState(false)
+  const [rotateOpen, setRotateOpen] = useState(false)
+  const [revoking, setRevoking] = useState<ApplicationSecret | null>(null)
+  const [revealed, setRevealed] = useState<Revealed | null>(
+    initialSecret ? { label: "Initial secret", secret: initialSecret } : null,
+  )
+
+  const secrets = secretsQuery.data ?? []
+  const activeCount = secrets.filter((s) => !isSecretExpired(s)).length
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <KeyRound className="size-4 text-muted-foreground" />
+          Client secrets
+        </CardTitle>
+        <CardDescription>
+          Any active secret is accepted, so you can roll to a new one without
+          downtime. Secrets are only shown once, when they're created.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-3">
+        {!acceptsSecrets && (
+          <p className="rounded-md border border-border/60 bg-muted/20 px-3 py-2 text-xs text-muted-foreground">
+            This app authenticates with a private key JWT, so secrets aren't accepted. They're
+            kept in case you switch back.
+          </p>
+        )}
+        {secretsQuery.isLoading ? (
+          <Skeleton className="h-16 w-full" />
+        ) : secrets.length === 0 ? (
+          <p className="text-sm text-muted-foreground">
+            No client secrets. Confidential clients can't use the token
+            endpoint until you create one.
+          </p>
+        ) : (
+          <ul className="space-y-2">
+            {secrets.map((s) => {
+              const expired = isSecretExpired(s)
+              return (
+                <li
+                  key={s.id}
+                  className="flex items-start justify-between gap-3 rounded-md border border-border/60 bg-muted/40 p-3"
+                >
+                  <div className="min-w-0 space-y-0.5">
+                    <p className="flex items-center gap-2 text-sm">
+                      <span className="truncate">{s.label || "Untitled secret"}</span>
+                      <code className="font-mono text-xs text-muted-foreground">
+                        ••••{s.hint}
+                      </code>
+                      {expired && <Badge variant="outline">Expired</Badge>}
+                    </p>
+                    <p className="text-xs text-muted-foreground">
+                      Created {formatDate(s.created_at)}
+                      <span className="mx-1.5">·</span>
+                      {s.expires_at
+                        ? `${expired ? "Expired" : "Expires"} ${formatDate(s.expires_at)}`
+                        : "Never expires"}
+                      <span className="mx-1.5">·</span>
+                      {s.last_used_at ? `Last used ${formatDate(s.last_used_at)}` : "Never used"}
+                    </p>
+                  </div>
+                  <Button
+                    variant="ghost"
+                    size="icon-sm"
+                    onClick={() => setRevoking(s)}
+                    title="Revoke secret"
+                  >
+                    <Trash2 className="size-3.5" />
+                  </Button>
+                </li>
+              )
+            })}
+          </ul>
+        )}
+        <div className="flex flex-wrap gap-2 pt-1">
+          <Button type="button" onClick={() => setCreateOpen(true)}>
+            <Plus className="mr-1 size-3.5" />
+            New secret
+          </Button>
+          {activeCount > 0 && (
+            <Button type="button" variant="outline" onClick={() => setRotateOpen(true)}>
+              <RefreshCw className="mr-1 size-3.5" />
+              Rotate
+            </Button>
+          )}
+        </div>
+      </CardContent>
+
+      {createOpen && (
+        <CreateSecretDialog
+          open={createOpen}
+          onOpenChange={setCreateOpen}
+          applicationID={applicationID}
+          onCreated={(result) => {
+            setCreateOpen(false)
+            setRevealed(result)
+          }}
+        />
+      )}
+
+      {rotateOpen && (
+        <RotateSecretDialog
+          open={rotateOpen}
+          onOpenChange={setRotateOpen}
+          applicationID={applicationID}
+          onRotated={(result) => {
+            setRotateOpen(false)
+            setRevealed(result)
+          }}
+        />
+      )}
+
+      <RevokeSecretDialog
+        secret={revoking}
+        applicationID={applicationID}
+        onClose={() => setRevoking(null)}
+      />
+
+      <RevealSecretDialog revealed={revealed} onClose={() => setRevealed(null)} />
+    </Card>
+  )
+}
+
+function CreateSecretDialog({
+  open,
+  onOpenChange,
+  applicationID,
+  onCreated,
+}: {
+  open: boolean
+  onOpenChange: (open: boolean) => void
+  applicationID: string
+  onCreated: (result: Revealed) => void
+}) {
+  const create = useCreateApplicationSecret(applicationID)
+  const [label, setLabel] = useState("")
+  const [expiresOn, setExpiresOn] = useState("")
+
+  async function handleCreate() {
+    try {
+      const result = await create.mutateAsync({
+        label: label.trim(),
+        expires_at: expiresOn ? new Date(`${expiresOn}T23:59:59`).toISOString() : null,
+      })
+      onCreated({ label: result.label, secret: result.client_secret })
+    } catch (e) {
+      toast.error(extractError(e, "Couldn't create secret."))
+    }
+  }
+
+  return (
+    <Dialog
+      open={open}
+      onOpenChange={(o) => {
+        if (!create.isPending) onOpenChange(o)
+      }}
+    >
+      <DialogContent className="gap-5 sm:max-w-md">
+        <DialogHeader className="gap-3">
+          <div className="flex size-10 items-center justify-center rounded-xl bg-gradient-to-br from-gr-pink to-gr-purple text-white">
+            <KeyRound className="size-5" />
+          </div>
+          <DialogTitle>New client secret</DialogTitle>
+          <DialogDescription>
+            Adds a secret alongside the existing ones. Nothing that uses the
+            current secrets is affected.
+          </DialogDescription>
+        </DialogHeader>
+
+        <div className="space-y-4">
+          <div className="space-y-2">
+            <Label htmlFor="secret-label">Label</Label>
+            <Input
+              id="secret-label"
+              value={label}
+              onChange={(e) => setLabel(e.target.value)}
+              placeholder="e.g. production"
+              autoFocus
+              disabled={create.isPending}
+            />
+          </div>
+          <div className="space-y-2">
+            <Label htmlFor="secret-expires">Expires on (optional)</Label>
+            <Input
+              id="secret-expires"
+              type="date"
+              value={expiresOn}
+              onChange={(e) => setExpiresOn(e.target.value)}
+              disabled={create.isPending}
+            />
+          </div>
+        </div>
+
+        <div className="flex justify-end gap-2 pt-1">
+          <Button
+            type="button"
+            variant="ghost"
+            disabled={create.isPending}
+            onClick={() => onOpenChange(false)}
+          >
+            Cancel
+          </Button>
+          <OutlineButton
+            type="button"
+            size="sm"
+            className="w-auto"
+            loading={create.isPending}
+            disabled={create.isPending}
+            onClick={handleCreate}
+          >
+            Create secret
+          </OutlineButton>
+        </div>
+      </DialogContent>
+    </Dialog>
+  )
+}
+
+function RotateSecretDialog({
+  open,
+  onOpenChange,
+  applicationID,
+  onRotated,
+}: {
+  open: boolean
+  onOpenChange: (open: boolean) => void
+  applicationID: string
+  onRotated: (result: Revealed) => void
+}) {
+  const rotate = useRotateApplicationSecret(applicationID)
+  const [label, setLabel] = useState("")
+  const [graceHours, setGraceHours] = useState("24")
+
+  async function handleRotate() {
+    try {
+      const result = await rotate.mutateAsync({
+        label: label.trim(),
+        grace_period_hours: parseInt(graceHours, 10),
+      })
+      onRotated({ label: result.label, secret: result.client_secret })
+    } catch (e) {
+      toast.error(extractError(e, "Couldn't rotate secret."))
+    }
+  }
+
+  return (
+    <Dialog
+      open={open}
+      onOpenChange={(o) => {
+        if (!rotate.isPending) onOpenChange(o)
+      }}
+    >
+      <DialogContent className="gap-5 sm:max-w-md">
+        <DialogHeader className="gap-3">
+          <div className="flex size-10 items-center justify-center rounded-xl bg-gradient-to-br from-gr-pink to-gr-purple text-white">
+            <RefreshCw className="size-5" />
+          </div>
+          <DialogTitle>Rotate client secret</DialogTitle>
+          <DialogDescription>
+            Creates a new secret. The current secrets keep working for the
+            grace period, then expire — redeploy with the new one before then.
+          </DialogDescription>
+        </DialogHeader>
+
+        <div className="space-y-4">
+          <div className="space-y-2">
+            <Label htmlFor="rotate-label">Label</Label>
+            <Input
+              id="rotate-label"
+              value={label}
+              onChange={(e) => setLabel(e.target.value)}
+              placeholder="e.g. production"
+              autoFocus
+              disabled={rotate.isPending}
+            />
+          </div>
+          <div className="space-y-2">
+            <Label htmlFor="rotate-grace">Old secrets stop working after</Label>
+            <Select value={graceHours} onValueChange={setGraceHours} disabled={rotate.isPending}>
+              <SelectTrigger id="rotate-grace">
+                <SelectValue />
+              </SelectTrigger>
+              <SelectContent>
+                {GRACE_PRESETS.map((preset) => (
+                  <SelectItem key={preset.hours} value={String(preset.hours)}>
+                    {preset.label}
+                  </SelectItem>
+                ))}
+              </SelectContent>
+            </Select>
+          </div>
+        </div>
+
+        <div className="flex justify-end gap-2 pt-1">
+          <Button
+            type="button"
+            variant="ghost"
+            disabled={rotate.isPending}
+            onClick={() => onOpenChange(false)}
+          >
+            Cancel
+          </Button>
+          <OutlineButton
+            type="button"
+            size="sm"
+            className="w-auto"
+            loading={rotate.isPending}
+            disabled={rotate.isPending}
+            onClick={handleRotate}
+          >
+            Rotate secret
+          </OutlineButton>
+        </div>
+      </DialogContent>
+    </Dialog>
+  )
+}
+
+function RevokeSecretDialog({
+  secret,
+  applicationID,
+  onClose,
+}: {
+  secret: ApplicationSecret | null
+  applicationID: string
+  onClose: () => void
+}) {
+  const revoke = useRevokeApplicationSecret(applicationID)
+
+  async function handleRevoke() {
+    if (!secret) return
+    try {
+      await revoke.mutateAsync(secret.id)
+      toast.success("Secret revoked")
+      onClose()
+    } catch (e) {
+      toast.error(extractError(e, "Couldn't revoke secret."))
+    }
+  }
+
+  return (
+    <Dialog
+      open={secret !== null}
+      onOpenChange={(o) => {
+        if (!o && !revoke.isPending) onClose()
+      }}
+    >
+      <DialogContent className="gap-5 sm:max-w-md">
+        <DialogHeader className="gap-3">
+          <div className="flex size-10 items-center justify-center rounded-xl bg-destructive/10 text-destructive">
+            <Trash2 className="size-5" />
+          </div>
+          <DialogTitle>Revoke {secret?.label || "this secret"}?</DialogTitle>
+          <DialogDescription>
+            Any client still using the secret ending in{" "}
+            <code className="font-mono">{secret?.hint}</code> will fail to
+            authenticate immediately. This can't be undone.
+          </DialogDescription>
+        </DialogHeader>
+
+        <div className="flex justify-end gap-2 pt-1">
+          <Button type="button" variant="ghost" disabled={revoke.isPending} onClick={onClose}>
+            Cancel
+          </Button>
+          <Button
+            type="button"
+            variant="destructive"
+            disabled={revoke.isPending}
+            onClick={handleRevoke}
+          >
+            {revoke.isPending ? "Revoking…" : "Revoke secret"}
+          </Button>
+        </div>
+      </DialogContent>
+    </Dialog>
+  )
+}
+
+function RevealSecretDialog({
+  revealed,
+  onClose,
+}: {
+  revealed: Revealed | null
+  onClose: () => void
+}) {
+  function copySecret() {
+    if (!revealed) return
+    void navigator.clipboard
+      .writeText(revealed.secret)
+      .then(() => toast.success("Client secret copied"))
+      .catch(() => toast.error("Couldn't copy client secret."))
+  }
+
+  return (
+    <Dialog
+      open={revealed !== null}
+      onOpenChange={(o) => {
+        if (!o) onClose()
+      }}
+    >
+      <DialogContent className="gap-5 sm:max-w-md">
+        <DialogHeader className="gap-3">
+          <div className="flex size-10 items-center justify-center rounded-xl bg-gradient-to-br from-gr-pink to-gr-purple text-white">
+            <KeyRound className="size-5" />
+          </div>
+          <DialogTitle>Copy your client secret</DialogTitle>
+          <DialogDescription>
+            {revealed?.label ? <strong>{revealed.label}</strong> : "The new secret"} is
+            shown only this once. Store it somewhere safe before closing.
+          </DialogDescription>
+        </DialogHeader>
+
+        <div className="flex items-center gap-1 rounded-md border border-border/60 bg-muted/40 px-2.5 py-1.5">
+          <code className="flex-1 break-all font-mono text-xs">{revealed?.secret}</code>
+          <Button variant="ghost" size="icon-sm" onClick={copySecret} title="Copy secret">
+            <Copy className="size-3.5" />
+          </Button>
+        </div>
+
+        <div className="flex justify-end gap-2 pt-1">
+          <OutlineButton type="button" size="sm" className="w-auto" onClick={onClose}>
+            Done
+          </OutlineButton>
+        </div>
+      </DialogContent>
+    </Dialog>
+  )
+}
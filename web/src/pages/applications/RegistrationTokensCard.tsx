@@ -0,0 +1,323 @@
+import { Copy, Plus, Ticket, Trash2 } from "lucide-react"
+import { useState } from "react"
+import { toast } from "sonner"
+
+import { OutlineButton } from "@/components/OutlineButton"
+import { Badge } from "@/components/ui/badge"
+import { Button } from "@/components/ui/button"
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card"
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogHeader,
+  DialogTitle,
+} from "@/components/ui/dialog"
+import { Input } from "@/components/ui/input"
+import { Label } from "@/components/ui/label"
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "@/components/ui/select"
+import { Skeleton } from "@/components/ui/skeleton"
+import {
+  isInitialAccessTokenUsable,
+  useCreateInitialAccessToken,
+  useInitialAccessTokens,
+  useRevokeInitialAccessToken,
+} from "@/lib/applications"
+
+const EXPIRY_PRESETS = [
+  { hours: 24, label: "1 day" },
+  { hours: 168, label: "7 days" },
+  { hours: 720, label: "30 days" },
+  { hours: 0, label: "Never" },
+]
+
+const REGISTRATION_ENDPOINT = `${window.location.origin}/api/oauth/register`
+
+function formatDate(iso: string | null | undefined): string {
+  if (!iso) return "—"
+  return new Date(iso).toLocaleString(undefined, {
+    year: "numeric",
+    month: "short",
+    day: "numeric",
+  })
+}
+
+function extractError(e: unknown, fallback: string): string {
+  const msg = (e as { response?: { data?: { error?: string } } })?.response?.data?.error
+  return msg ?? fallback
+}
+
+// RegistrationTokensCard lets admins mint and revoke initial access tokens,
+// which developers use to register their own clients at the RFC 7591
+// endpoint. Admin-only; the backend gates the same way.
+export function RegistrationTokensCard() {
+  const tokensQuery = useInitialAccessTokens()
+  const revoke = useRevokeInitialAccessToken()
+  const [createOpen, setCreateOpen] = useState(false)
+  const [revealed, setRevealed] = useState<string | null>(null)
+
+  async function handleRevoke(id: string) {
+    try {
+      await revoke.mutateAsync(id)
+      toast.success("Token revoked")
+    } catch (e) {
+      toast.error(extractError(e, "Couldn't revoke token."))
+    }
+  }
+
+  const tokens = tokensQuery.data ?? []
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <Ticket className="size-4 text-muted-foreground" />
+          Registration tokens
+        </CardTitle>
+        <CardDescription>
+          Developers with a token can register clients themselves at{" "}
+          <code className="font-mono">{REGISTRATION_ENDPOINT}</code>. New clients are owned by
+          you and can only request <code className="font-mono">openid</code>,{" "}
+          <code className="font-mono">profile</code>, and{" "}
+          <code className="font-mono">email</code> until an admin approves them.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-3">
+        {tokensQuery.isLoading ? (
+          <Skeleton className="h-16 w-full" />
+        ) : tokens.length === 0 ? (
+          <p className="text-sm text-muted-foreground">No registration tokens yet.</p>
+        ) : (
+          <ul className="space-y-2">
+            {tokens.map((t) => {
+              const usable = isInitialAccessTokenUsable(t)
+              return (
+                <li
+                  key={t.id}
+                  className="flex items-start justify-between gap-3 rounded-md border border-border/60 bg-muted/40 p-3"
+                >
+                  <div className="min-w-0 space-y-0.5">
+                    <p className="flex items-center gap-2 text-sm">
+                      <span className="truncate">{t.label}</span>
+                      {t.revoked_at ? (
+                        <Badge variant="outline">Revoked</Badge>
+                      ) : (
+                        !usable && <Badge variant="outline">Used up or expired</Badge>
+                      )}
+                    </p>
+                    <p className="text-xs text-muted-foreground">
+                      {t.registrations}
+                      {t.max_registrations > 0 ? ` of ${t.max_registrations}` : ""} registered
+                      <span className="mx-1.5">·</span>
+                      {t.expires_at ? `Expires ${formatDate(t.expires_at)}` : "Never expires"}
+                    </p>
+                  </div>
+                  {!t.revoked_at && (
+                    <Button
+                      variant="ghost"
+                      size="icon-sm"
+                      onClick={() => handleRevoke(t.id)}
+                      disabled={revoke.isPending}
+                      title="Revoke token"
+                    >
+                      <Trash2 className="size-3.5" />
+                    </Button>
+                  )}
+                </li>
+              )
+            })}
+          </ul>
+        )}
+        <Button type="button" onClick={() => setCreateOpen(true)}>
+          <Plus className="mr-1 size-3.5" />
+          New token
+        </Button>
+      </CardContent>
+
+      {createOpen && (
+        <CreateTokenDialog
+          open={createOpen}
+          onOpenChange={setCreateOpen}
+          onCreated={(token) => {
+            setCreateOpen(false)
+            setRevealed(token)
+          }}
+        />
+      )}
+
+      <RevealTokenDialog token={revealed} onClose={() => setRevealed(null)} />
+    </Card>
+  )
+}
+
+function CreateTokenDialog({
+  open,
+  onOpenChange,
+  onCreated,
+}: {
+  open: boolean
+  onOpenChange: (open: boolean) => void
+  onCreated: (token: string) => void
+}) {
+  const create = useCreateInitialAccessToken()
+  const [label, setLabel] = useState("")
+  const [maxRegistrations, setMaxRegistrations] = useState("1")
+  const [expiresInHours, setExpiresInHours] = useState("168")
+
+  async function handleCreate() {
+    try {
+      const result = await create.mutateAsync({
+        label: label.trim(),
+        max_registrations: Math.max(0, parseInt(maxRegistrations, 10) || 0),
+        expires_in_hours: parseInt(expiresInHours, 10),
+      })
+      onCreated(result.token)
+    } catch (e) {
+      toast.error(extractError(e, "Couldn't create token."))
+    }
+  }
+
+  return (
+    <Dialog
+      open={open}
+      onOpenChange={(o) => {
+        if (!create.isPending) onOpenChange(o)
+      }}
+    >
+      <DialogContent className="gap-5 sm:max-w-md">
+        <DialogHeader className="gap-3">
+          <div className="flex size-10 items-center justify-center rounded-xl bg-gradient-to-br from-gr-pink to-gr-purple text-white">
+            <Ticket className="size-5" />
+          </div>
+          <DialogTitle>New registration token</DialogTitle>
+          <DialogDescription>
+            Hand this to a developer so they can register their own clients.
+          </DialogDescription>
+        </DialogHeader>
+
+        <div className="space-y-4">
+          <div className="space-y-2">
+            <Label htmlFor="iat-label">Label</Label>
+            <Input
+              id="iat-label"
+              value={label}
+              onChange={(e) => setLabel(e.target.value)}
+              placeholder="e.g. Spring hackathon"
+              autoFocus
+              disabled={create.isPending}
+            />
+          </div>
+          <div className="space-y-2">
+            <Label htmlFor="iat-max">Registrations allowed (0 for no limit)</Label>
+            <Input
+              id="iat-max"
+              type="number"
+              min={0}
+              value={maxRegistrations}
+              onChange={(e) => setMaxRegistrations(e.target.value)}
+              disabled={create.isPending}
+            />
+          </div>
+          <div className="space-y-2">
+            <Label htmlFor="iat-expiry">Expires after</Label>
+            <Select
+              value={expiresInHours}
+              onValueChange={setExpiresInHours}
+              disabled={create.isPending}
+            >
+              <SelectTrigger id="iat-expiry">
+                <SelectValue />
+              </SelectTrigger>
+              <SelectContent>
+                {EXPIRY_PRESETS.map((preset) => (
+                  <SelectItem key={preset.hours} value={String(preset.hours)}>
+                    {preset.label}
+                  </SelectItem>
+                ))}
+              </SelectContent>
+            </Select>
+          </div>
+        </div>
+
+        <div className="flex justify-end gap-2 pt-1">
+          <Button
+            type="button"
+            variant="ghost"
+            disabled={create.isPending}
+            onClick={() => onOpenChange(false)}
+          >
+            Cancel
+          </Button>
+          <OutlineButton
+            type="button"
+            size="sm"
+            className="w-auto"
+            loading={create.isPending}
+            disabled={!label.trim() || create.isPending}
+            onClick={handleCreate}
+          >
+            Create token
+          </OutlineButton>
+        </div>
+      </DialogContent>
+    </Dialog>
+  )
+}
+
+function RevealTokenDialog({ token, onClose }: { token: string | null; onClose: () => void }) {
+  function copyToken() {
+    if (!token) return
+    void navigator.clipboard
+      .writeText(token)
+      .then(() => toast.success("Token copied"))
+      .catch(() => toast.error("Couldn't copy token."))
+  }
+
+  return (
+    <Dialog
+      open={token !== null}
+      onOpenChange={(o) => {
+        if (!o) onClose()
+      }}
+    >
+      <DialogContent className="gap-5 sm:max-w-md">
+        <DialogHeader className="gap-3">
+          <div className="flex size-10 items-center justify-center rounded-xl bg-gradient-to-br from-gr-pink to-gr-purple text-white">
+            <Ticket className="size-5" />
+          </div>
+          <DialogTitle>Copy the registration token</DialogTitle>
+          <DialogDescription>
+            It's shown only this once. Developers send it as a bearer token when they{" "}
+            <code className="font-mono">POST</code> client metadata to the registration
+            endpoint.
+          </DialogDescription>
+        </DialogHeader>
+
+        <div className="flex items-center gap-1 rounded-md border border-border/60 bg-muted/40 px-2.5 py-1.5">
+          <code className="flex-1 break-all font-mono text-xs">{token}</code>
+          <Button variant="ghost" size="icon-sm" onClick={copyToken} title="Copy token">
+            <Copy className="size-3.5" />
+          </Button>
+        </div>
+
+        <div className="flex justify-end gap-2 pt-1">
+          <OutlineButton type="button" size="sm" className="w-auto" onClick={onClose}>
+            Done
+          </OutlineButton>
+        </div>
+      </DialogContent>
+    </Dialog>
+  )
+}
@@ -3,6 +3,7 @@ import { useEffect, useRef, useState } from "react"
 import { useNavigate, useSearchParams } from "react-router-dom"
 import { toast } from "sonner"
 
+import { isMfaChallenge, MfaChallengeForm, type MfaChallenge, type MfaSession } from "@/components/MfaChallengeForm"
 import { OutlineButton } from "@/components/OutlineButton"
 import { SuccessCheck } from "@/components/SuccessCheck"
 import { DiscordIcon } from "@/components/icons/socials"
@@ -23,7 +24,7 @@ type ErrorBody = {
   message?: string
 }
 
-type Phase = "loading" | "no_account" | "error"
+type Phase = "loading" | "mfa" | "no_account" | "error"
 
 // Mirror the LoginPage transition so the user experiences the same
 // converge → check → hold → navigate sequence on a successful Discord login.
@@ -37,6 +38,7 @@ export default function LoginDiscordPage() {
   const [phase, setPhase] = useState<Phase>("loading")
   const [errorMessage, setErrorMessage] = useState<string>("")
   const [transitioning, setTransitioning] = useState(false)
+  const [challenge, setChallenge] = useState<MfaChallenge | null>(null)
 
   // The OAuth callback effect must run exactly once — React 18's
   // double-invoked effects in dev would otherwise burn the code (Discord
@@ -54,25 +56,15 @@ export default function LoginDiscordPage() {
     }
     void (async () => {
       try {
-        const res = await api.post<LoginResponse>(
+        const res = await api.post<LoginResponse | MfaChallenge>(
           `/auth/login/discord?code=${encodeURIComponent(code)}`,
         )
-        saveSession({
-          accessToken: res.data.access_token,
-          refreshToken: res.data.refresh_token,
-          expiresIn: res.data.expires_in,
-          entityId: res.data.entity_id,
-        })
-        const dest = consumeLoginReturnLocation()
-        setTransitioning(true)
-        await new Promise((r) =>
-          setTimeout(r, CONVERGE_MS + CHECKMARK_DRAW_MS + HOLD_MS),
-        )
-        if (document.startViewTransition) {
-          document.startViewTransition(() => navigate(dest, { replace: true }))
-        } else {
-          navigate(dest, { replace: true })
+        if (isMfaChallenge(res.data)) {
+          setChallenge(res.data)
+          setPhase("mfa")
+          return
         }
+        await finishLogin(res.data)
       } catch (err: unknown) {
         const body =
           (err as { response?: { data?: ErrorBody } })?.response?.data ?? {}
@@ -85,8 +77,29 @@ export default function LoginDiscordPage() {
         toast.error(body.message || body.error || "Discord login failed.")
       }
     })()
+    // finishLogin only closes over navigate, which the deps already cover.
+    // eslint-disable-next-line react-hooks/exhaustive-deps
   }, [navigate, params])
 
+  async function finishLogin(session: LoginResponse | MfaSession) {
+    saveSession({
+      accessToken: session.access_token,
+      refreshToken: session.refresh_token,
+      expiresIn: session.expires_in,
+      entityId: session.entity_id,
+    })
+    const dest = consumeLoginReturnLocation()
+    setTransitioning(true)
+    await new Promise((r) =>
+      setTimeout(r, CONVERGE_MS + CHECKMARK_DRAW_MS + HOLD_MS),
+    )
+    if (document.startViewTransition) {
+      document.startViewTransition(() => navigate(dest, { replace: true }))
+    } else {
+      navigate(dest, { replace: true })
+    }
+  }
+
   return (
     <main className="relative flex min-h-svh items-center justify-center px-4 py-12">
       <div
@@ -102,11 +115,13 @@ export default function LoginDiscordPage() {
           <div>
             <h1 className="text-2xl font-semibold tracking-tight">
               {phase === "loading" && "Signing you in"}
+              {phase === "mfa" && "Two-factor authentication"}
               {phase === "no_account" && "No account found"}
               {phase === "error" && "Discord sign-in failed"}
             </h1>
             <p className="mt-1 text-sm text-muted-foreground">
               {phase === "loading" && "Finishing the Discord handshake…"}
+              {phase === "mfa" && "Complete two-factor authentication to continue."}
               {phase === "no_account" && "We couldn't find a Sentinel account linked to this Discord user."}
               {phase === "error" && (errorMessage || "Try again from the login page.")}
             </p>
@@ -119,6 +134,10 @@ export default function LoginDiscordPage() {
           </div>
         )}
 
+        {phase === "mfa" && challenge && (
+          <MfaChallengeForm challenge={challenge} onSession={(s) => void finishLogin(s)} />
+        )}
+
         {phase === "no_account" && (
           <div className="space-y-4">
             <div className="rounded-md border border-border/60 bg-muted/30 p-4 text-sm">
@@ -0,0 +1,177 @@
+import { Loader2 } from "lucide-react"
+import { useEffect, useRef, useState } from "react"
+import { useNavigate, useSearchParams } from "react-router-dom"
+import { toast } from "sonner"
+
+import { isMfaChallenge, MfaChallengeForm, type MfaChallenge, type MfaSession } from "@/components/MfaChallengeForm"
+import { OutlineButton } from "@/components/OutlineButton"
+import { SuccessCheck } from "@/components/SuccessCheck"
+import { api } from "@/lib/api"
+import { consumeLoginReturnLocation, saveSession } from "@/lib/auth"
+import { consumeExternalAuthState, externalProviderLabel, type ExternalProvider } from "@/lib/externalAuth"
+import { cn } from "@/lib/utils"
+
+type LoginResponse = {
+  access_token: string
+  refresh_token: string
+  expires_in: number
+  entity_id: string
+}
+
+type ErrorBody = {
+  error?: string
+  message?: string
+}
+
+type Phase = "loading" | "mfa" | "no_account" | "error"
+
+const CONVERGE_MS = 250
+const CHECKMARK_DRAW_MS = 650
+const HOLD_MS = 250
+
+// Callback for GitHub and Google. Depending on how the round trip was
+// started, it either signs in with the linked account or links the account
+// to the current session and returns to settings.
+export default function LoginExternalPage({ provider }: { provider: ExternalProvider }) {
+  const navigate = useNavigate()
+  const [params] = useSearchParams()
+  const [phase, setPhase] = useState<Phase>("loading")
+  const [errorMessage, setErrorMessage] = useState<string>("")
+  const [transitioning, setTransitioning] = useState(false)
+  const [challenge, setChallenge] = useState<MfaChallenge | null>(null)
+  const label = externalProviderLabel(provider)
+
+  // Codes are single-use; see LoginDiscordPage.
+  const exchangedRef = useRef(false)
+
+  useEffect(() => {
+    if (exchangedRef.current) return
+    exchangedRef.current = true
+
+    const code = params.get("code")
+    const mode = consumeExternalAuthState(provider, params.get("state"))
+    if (!code || !mode) {
+      navigate("/auth/login", { replace: true })
+      return
+    }
+    void (async () => {
+      if (mode === "connect") {
+        try {
+          await api.post(`/auth/connect/${provider}`, { code })
+          toast.success(`${label} account linked.`)
+        } catch (err: unknown) {
+          const body = (err as { response?: { data?: ErrorBody } })?.response?.data ?? {}
+          toast.error(body.error || `Couldn't link your ${label} account.`)
+        }
+        navigate("/settings", { replace: true })
+        return
+      }
+      try {
+        const res = await api.post<LoginResponse | MfaChallenge>(
+          `/auth/login/${provider}?code=${encodeURIComponent(code)}`,
+        )
+        if (isMfaChallenge(res.data)) {
+          setChallenge(res.data)
+          setPhase("mfa")
+          return
+        }
+        await finishLogin(res.data)
+      } catch (err: unknown) {
+        const body = (err as { response?: { data?: ErrorBody } })?.response?.data ?? {}
+        if (body.error === "no_account") {
+          setPhase("no_account")
+          return
+        }
+        setErrorMessage(body.message || body.error || `Couldn't sign you in with ${label}.`)
+        setPhase("error")
+        toast.error(body.message || body.error || `${label} login failed.`)
+      }
+    })()
+    // finishLogin only closes over navigate, which the deps already cover.
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [navigate, params, provider, label])
+
+  async function finishLogin(session: LoginResponse | MfaSession) {
+    saveSession({
+      accessToken: session.access_token,
+      refreshToken: session.refresh_token,
+      expiresIn: session.expires_in,
+      entityId: session.entity_id,
+    })
+    const dest = consumeLoginReturnLocation()
+    setTransitioning(true)
+    await new Promise((r) => setTimeout(r, CONVERGE_MS + CHECKMARK_DRAW_MS + HOLD_MS))
+    if (document.startViewTransition) {
+      document.startViewTransition(() => navigate(dest, { replace: true }))
+    } else {
+      navigate(dest, { replace: true })
+    }
+  }
+
+  return (
+    <main className="relative flex min-h-svh items-center justify-center px-4 py-12">
+      <div
+        className={cn(
+          "w-full max-w-sm space-y-8 transition-all ease-in",
+          transitioning ? "scale-0 opacity-0 duration-[250ms]" : "scale-100 opacity-100 duration-200",
+        )}
+      >
+        <div className="flex flex-col items-center gap-3 text-center">
+          <img src="/logo/gr-logo-blank.png" alt="Gaucho Racing" className="size-12" />
+          <div>
+            <h1 className="text-2xl font-semibold tracking-tight">
+              {phase === "loading" && "Just a moment"}
+              {phase === "mfa" && "Two-factor authentication"}
+              {phase === "no_account" && "No account found"}
+              {phase === "error" && `${label} sign-in failed`}
+            </h1>
+            <p className="mt-1 text-sm text-muted-foreground">
+              {phase === "loading" && `Finishing the ${label} handshake…`}
+              {phase === "mfa" && "Complete two-factor authentication to continue."}
+              {phase === "no_account" && `No Sentinel account is linked to this ${label} account.`}
+              {phase === "error" && (errorMessage || "Try again from the login page.")}
+            </p>
+          </div>
+        </div>
+
+        {phase === "loading" && (
+          <div className="flex items-center justify-center py-6">
+            <Loader2 className="size-8 animate-spin text-muted-foreground" />
+          </div>
+        )}
+
+        {phase === "mfa" && challenge && (
+          <MfaChallengeForm challenge={challenge} onSession={(s) => void finishLogin(s)} />
+        )}
+
+        {phase === "no_account" && (
+          <div className="space-y-4">
+            <div className="rounded-md border border-border/60 bg-muted/30 p-4 text-sm">
+              Sign in another way first, then link your {label} account from{" "}
+              <strong>Settings → Connected accounts</strong>. After that you can use it to sign in.
+            </div>
+            <OutlineButton onClick={() => navigate("/auth/login", { replace: true })}>
+              Back to sign in
+            </OutlineButton>
+          </div>
+        )}
+
+        {phase === "error" && (
+          <OutlineButton onClick={() => navigate("/auth/login", { replace: true })}>
+            Back to sign in
+          </OutlineButton>
+        )}
+      </div>
+
+      {transitioning && (
+        <div
+          aria-hidden
+          className="pointer-events-none absolute inset-0 flex items-center justify-center"
+          style={{ animationDelay: `${CONVERGE_MS}ms` }}
+        >
+          <SuccessCheck className="size-20" />
+        </div>
+      )}
+    </main>
+  )
+}
@@ -1,22 +1,28 @@
-import { Loader2 } from "lucide-react"
+import { KeyRound, Loader2 } from "lucide-react"
 import type { ComponentType, SVGProps } from "react"
 import { useEffect, useState } from "react"
-import { useLocation, useNavigate, useSearchParams } from "react-router-dom"
+import { Link, useLocation, useNavigate, useSearchParams } from "react-router-dom"
 import { toast } from "sonner"
 
+import { isMfaChallenge, MfaChallengeForm, type MfaChallenge, type MfaSession } from "@/components/MfaChallengeForm"
 import { OutlineButton } from "@/components/OutlineButton"
 import { SuccessCheck } from "@/components/SuccessCheck"
-import { DiscordIcon, GoogleIcon } from "@/components/icons/socials"
+import { DiscordIcon, GithubIcon, GoogleIcon } from "@/components/icons/socials"
 import { Button } from "@/components/ui/button"
 import { Input } from "@/components/ui/input"
 import { Label } from "@/components/ui/label"
 import { api } from "@/lib/api"
+import { startExternalAuth } from "@/lib/externalAuth"
 import { consumeLoginReturnTo, locationFromReturnPath, peekLoginReturnTo, saveLoginReturnTo, saveSession } from "@/lib/auth"
 import { DISCORD_INVITE_URL } from "@/lib/links"
+import { passkeysSupported, signInWithPasskey } from "@/lib/passkeys"
 import { cn } from "@/lib/utils"
 
-type ProviderId = "google" | "discord"
-type LoadingTarget = "email" | ProviderId | null
+type ProviderId = "google" | "github" | "discord"
+type LoadingTarget = "email" | "send-code" | "passkey" | ProviderId | null
+// "password" signs in with email + password; "code" mails a one-time login
+// code and signs in with that instead.
+type EmailMode = "password" | "code"
 
 const PROVIDERS: Array<{
   id: ProviderId
@@ -25,6 +31,7 @@ const PROVIDERS: Array<{
   iconClassName?: string
 }> = [
   { id: "google", label: "Continue with Google", Icon: GoogleIcon },
+  { id: "github", label: "Continue with GitHub", Icon: GithubIcon },
   {
     id: "discord",
     label: "Continue with Discord",
@@ -75,6 +82,10 @@ export default function LoginPage() {
   const from = fromRouter && fromRouter !== "/" ? fromRouter : (peekLoginReturnTo() ?? "/")
   const [email, setEmail] = useState(params.get("email") ?? "")
   const [password, setPassword] = useState("")
+  const [mode, setMode] = useState<EmailMode>("password")
+  const [code, setCode] = useState("")
+  const [codeSent, setCodeSent] = useState(false)
+  const [challenge, setChallenge] = useState<MfaChallenge | null>(null)
   const [loading, setLoading] = useState<LoadingTarget>(null)
   const [transitioning, setTransitioning] = useState(false)
   const isBusy = loading !== null || transitioning
@@ -99,15 +110,51 @@ export default function LoginPage() {
     }
   }
 
+  function switchMode(next: EmailMode) {
+    setMode(next)
+    setPassword("")
+    setCode("")
+    setCodeSent(false)
+  }
+
+  async function handleSendCode() {
+    if (isBusy) return
+    setLoading("send-code")
+    try {
+      await api.post("/auth/login/email-code/request", { email })
+      setCodeSent(true)
+      toast.success("If that email is registered, a code is on its way.")
+    } catch (err: unknown) {
+      const message =
+        (err as { response?: { data?: { error?: string } } })?.response?.data?.error ??
+        "Couldn't send a code. Try again."
+      toast.error(message)
+    } finally {
+      setLoading(null)
+    }
+  }
+
   async function handleSubmit(event: React.FormEvent) {
     event.preventDefault()
     if (isBusy) return
+    if (mode === "code" && !codeSent) {
+      handleSendCode()
+      return
+    }
     setLoading("email")
     try {
-      const res = await api.post<LoginResponse>("/auth/login/email-password", {
-        email,
-        password,
-      })
+      const res =
+        mode === "code"
+          ? await api.post<LoginResponse | MfaChallenge>("/auth/login/email-code", { email, code })
+          : await api.post<LoginResponse | MfaChallenge>("/auth/login/email-password", {
+              email,
+              password,
+            })
+      if (isMfaChallenge(res.data)) {
+        setLoading(null)
+        setChallenge(res.data)
+        return
+      }
       saveSession({
         accessToken: res.data.access_token,
         refreshToken: res.data.refresh_token,
@@ -125,6 +172,38 @@ export default function LoginPage() {
     handleSuccess()
   }
 
+  function handleMfaSession(session: MfaSession) {
+    saveSession({
+      accessToken: session.access_token,
+      refreshToken: session.refresh_token,
+      expiresIn: session.expires_in,
+      entityId: session.entity_id,
+    })
+    handleSuccess()
+  }
+
+  async function handlePasskey() {
+    if (isBusy) return
+    setLoading("passkey")
+    try {
+      const session = await signInWithPasskey<LoginResponse>()
+      saveSession({
+        accessToken: session.access_token,
+        refreshToken: session.refresh_token,
+        expiresIn: session.expires_in,
+        entityId: session.entity_id,
+      })
+    } catch (err: unknown) {
+      setLoading(null)
+      const message =
+        (err as { response?: { data?: { error?: string } } })?.response?.data?.error ??
+        "Couldn't sign you in with a passkey."
+      toast.error(message)
+      return
+    }
+    handleSuccess()
+  }
+
   function handleProvider(id: ProviderId) {
     if (isBusy) return
     if (id === "discord") {
@@ -146,7 +225,14 @@ export default function LoginPage() {
       window.location.href = `${DISCORD_AUTHORIZE_URL}?${params.toString()}`
       return
     }
-    toast.info("Google login is coming soon.")
+    try {
+      setLoading(id)
+      saveLoginReturnTo(from)
+      startExternalAuth(id, "login")
+    } catch (err: unknown) {
+      setLoading(null)
+      toast.error((err as Error).message)
+    }
   }
 
   return (
@@ -166,95 +252,163 @@ export default function LoginPage() {
               {arrivedFromOnboarding ? "Account created" : "Sign in to Sentinel"}
             </h1>
             <p className="mt-1 text-sm text-muted-foreground">
-              {arrivedFromOnboarding
-                ? "Sign in with the password you just set."
-                : "Choose how you'd like to sign in."}
+              {challenge
+                ? challenge.methods?.includes("totp") || challenge.enrollment_required
+                  ? "Enter the code from your authenticator app."
+                  : "Confirm it's you with your passkey."
+                : arrivedFromOnboarding
+                  ? "Sign in with the password you just set."
+                  : "Choose how you'd like to sign in."}
             </p>
           </div>
         </div>
 
-        <div className="space-y-8">
-          <form onSubmit={handleSubmit} noValidate className="space-y-2">
-            <Label htmlFor="email" className="sr-only">Email</Label>
-            <Input
-              id="email"
-              type="email"
-              autoComplete="email"
-              placeholder="Email"
-              value={email}
-              onChange={(e) => setEmail(e.target.value)}
-              disabled={isBusy}
-              required
-            />
+        {challenge ? (
+          <MfaChallengeForm challenge={challenge} onSession={handleMfaSession} />
+        ) : (
+          <div className="space-y-8">
+            <form onSubmit={handleSubmit} noValidate className="space-y-2">
+              <Label htmlFor="email" className="sr-only">Email</Label>
+              <Input
+                id="email"
+                type="email"
+                autoComplete="email"
+                placeholder="Email"
+                value={email}
+                onChange={(e) => setEmail(e.target.value)}
+                disabled={isBusy}
+                required
+              />
 
-            <Label htmlFor="password" className="sr-only">Password</Label>
-            <Input
-              id="password"
-              type="password"
-              autoComplete="current-password"
-              placeholder="Password"
-              value={password}
-              onChange={(e) => setPassword(e.target.value)}
-              disabled={isBusy}
-              required
-            />
+              {mode === "password" ? (
+                <>
+                  <Label htmlFor="password" className="sr-only">Password</Label>
+                  <Input
+                    id="password"
+                    type="password"
+                    autoComplete="current-password"
+                    placeholder="Password"
+                    value={password}
+                    onChange={(e) => setPassword(e.target.value)}
+                    disabled={isBusy}
+                    required
+                  />
+                </>
+              ) : codeSent ? (
+                <>
+                  <Label htmlFor="code" className="sr-only">Login code</Label>
+                  <Input
+                    id="code"
+                    inputMode="numeric"
+                    autoComplete="one-time-code"
+                    placeholder="6-digit code"
+                    maxLength={6}
+                    value={code}
+                    onChange={(e) => setCode(e.target.value.replace(/\D/g, ""))}
+                    disabled={isBusy}
+                    required
+                  />
+                </>
+              ) : null}
 
-            <OutlineButton
-              type="submit"
-              className="mt-4"
-              loading={loading === "email"}
-              disabled={isBusy}
-            >
-              Sign in
-            </OutlineButton>
-          </form>
+              <OutlineButton
+                type="submit"
+                className="mt-4"
+                loading={loading === "email" || loading === "send-code"}
+                disabled={isBusy}
+              >
+                {mode === "code" && !codeSent ? "Email me a code" : "Sign in"}
+              </OutlineButton>
 
-          <div className="relative">
-            <div className="absolute inset-0 flex items-center">
-              <span className="w-full border-t border-border/60" />
-            </div>
-            <div className="relative flex justify-center">
-              <span className="bg-background px-2 text-xs uppercase tracking-wider text-muted-foreground">
-                or
-              </span>
+              <div className="flex justify-center gap-3 pt-1 text-xs text-muted-foreground">
+                {mode === "password" && (
+                  <Link to="/auth/reset-password" className="transition-colors hover:text-foreground">
+                    Forgot password?
+                  </Link>
+                )}
+                {mode === "code" && codeSent && (
+                  <button
+                    type="button"
+                    className="transition-colors hover:text-foreground"
+                    onClick={handleSendCode}
+                    disabled={isBusy}
+                  >
+                    Resend code
+                  </button>
+                )}
+                <button
+                  type="button"
+                  className="transition-colors hover:text-foreground"
+                  onClick={() => switchMode(mode === "password" ? "code" : "password")}
+                  disabled={isBusy}
+                >
+                  {mode === "password" ? "Email me a login code instead" : "Use a password instead"}
+                </button>
+              </div>
+            </form>
+
+            <div className="relative">
+              <div className="absolute inset-0 flex items-center">
+                <span className="w-full border-t border-border/60" />
+              </div>
+              <div className="relative flex justify-center">
+                <span className="bg-background px-2 text-xs uppercase tracking-wider text-muted-foreground">
+                  or
+                </span>
+              </div>
             </div>
-          </div>
 
-          <div className="space-y-2">
-            {PROVIDERS.map(({ id, label, Icon, iconClassName }) => {
-              const isLoading = loading === id
-              return (
+            <div className="space-y-2">
+              {passkeysSupported() && (
                 <Button
-                  key={id}
                   variant="outline"
                   className="w-full justify-center gap-2"
                   disabled={isBusy}
-                  onClick={() => handleProvider(id)}
+                  onClick={handlePasskey}
                 >
-                  {isLoading ? (
+                  {loading === "passkey" ? (
                     <Loader2 className="size-4 animate-spin" />
                   ) : (
-                    <Icon className={`size-4 ${iconClassName ?? ""}`} />
+                    <KeyRound className="size-4" />
                   )}
-                  {label}
+                  Sign in with a passkey
                 </Button>
-              )
-            })}
-          </div>
+              )}
+              {PROVIDERS.map(({ id, label, Icon, iconClassName }) => {
+                const isLoading = loading === id
+                return (
+                  <Button
+                    key={id}
+                    variant="outline"
+                    className="w-full justify-center gap-2"
+                    disabled={isBusy}
+                    onClick={() => handleProvider(id)}
+                  >
+                    {isLoading ? (
+                      <Loader2 className="size-4 animate-spin" />
+                    ) : (
+                      <Icon className={`size-4 ${iconClassName ?? ""}`} />
+                    )}
+                    {label}
+                  </Button>
+                )
+              })}
+            </div>
 
-          <p className="text-center text-xs text-muted-foreground">
-            Don't have an account? Reach out on the{" "}
-            <a
-              href={DISCORD_INVITE_URL}
-              target="_blank"
-              rel="noreferrer"
-              className="text-foreground transition-colors hover:text-gr-pink"
-            >
-              Discord
-            </a>
-            .
-          </p>
-        </div>
+            <p className="text-center text-xs text-muted-foreground">
+              Don't have an account? Reach out on the{" "}
+              <a
+                href={DISCORD_INVITE_URL}
+                target="_blank"
+                rel="noreferrer"
+                className="text-foreground transition-colors hover:text-gr-pink"
+              >
+                Discord
+              </a>
+              .
+            </p>
+          </div>
+        )}
       </div>
 
       {transitioning && (
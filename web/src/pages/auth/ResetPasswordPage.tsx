@@ -0,0 +1,178 @@
+import { useState } from "react"
+import { Link, useNavigate, useSearchParams } from "react-router-dom"
+import { toast } from "sonner"
+
+import { OutlineButton } from "@/components/OutlineButton"
+import { Input } from "@/components/ui/input"
+import { Label } from "@/components/ui/label"
+import { api } from "@/lib/api"
+import { cn } from "@/lib/utils"
+
+type Channel = "EMAIL" | "DISCORD"
+
+function errorMessage(err: unknown, fallback: string) {
+  return (
+    (err as { response?: { data?: { error?: string } } })?.response?.data?.error ?? fallback
+  )
+}
+
+// Two-step page: without ?token it asks where to send a reset link; the link
+// lands back here with ?token and the page collects the new password.
+export default function ResetPasswordPage() {
+  const [params] = useSearchParams()
+  const token = params.get("token")
+
+  return (
+    <main className="relative flex min-h-svh items-center justify-center px-4 py-12">
+      <div className="w-full max-w-sm space-y-8">
+        <div className="flex flex-col items-center gap-3 text-center">
+          <img src="/logo/gr-logo-blank.png" alt="Gaucho Racing" className="size-12" />
+          <div>
+            <h1 className="text-2xl font-semibold tracking-tight">Reset your password</h1>
+            <p className="mt-1 text-sm text-muted-foreground">
+              {token
+                ? "Choose a new password for your account."
+                : "We'll send you a link to choose a new one."}
+            </p>
+          </div>
+        </div>
+
+        {token ? <ConfirmForm token={token} /> : <RequestForm />}
+
+        <p className="text-center text-xs text-muted-foreground">
+          <Link to="/auth/login" className="text-foreground transition-colors hover:text-gr-pink">
+            Back to sign in
+          </Link>
+        </p>
+      </div>
+    </main>
+  )
+}
+
+function RequestForm() {
+  const [email, setEmail] = useState("")
+  const [channel, setChannel] = useState<Channel>("EMAIL")
+  const [loading, setLoading] = useState(false)
+  const [sent, setSent] = useState(false)
+
+  async function handleSubmit(event: React.FormEvent) {
+    event.preventDefault()
+    if (loading) return
+    setLoading(true)
+    try {
+      await api.post("/auth/password-reset/request", { email, channel })
+      setSent(true)
+    } catch (err: unknown) {
+      toast.error(errorMessage(err, "Couldn't send a reset link. Try again."))
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  if (sent) {
+    return (
+      <p className="text-center text-sm text-muted-foreground">
+        If that account exists, a reset link is on its way
+        {channel === "DISCORD" ? " in your Discord DMs" : " to your inbox"}.
+      </p>
+    )
+  }
+
+  return (
+    <form onSubmit={handleSubmit} noValidate className="space-y-2">
+      <Label htmlFor="email" className="sr-only">Email</Label>
+      <Input
+        id="email"
+        type="email"
+        autoComplete="email"
+        placeholder="Email"
+        value={email}
+        onChange={(e) => setEmail(e.target.value)}
+        disabled={loading}
+        required
+      />
+
+      <div className="grid grid-cols-2 gap-2 pt-2">
+        {(["EMAIL", "DISCORD"] as const).map((c) => (
+          <button
+            key={c}
+            type="button"
+            onClick={() => setChannel(c)}
+            disabled={loading}
+            className={cn(
+              "rounded-md border px-3 py-2 text-sm transition-colors",
+              channel === c
+                ? "border-foreground text-foreground"
+                : "border-border/60 text-muted-foreground hover:text-foreground",
+            )}
+          >
+            {c === "EMAIL" ? "Send by email" : "Send by Discord DM"}
+          </button>
+        ))}
+      </div>
+
+      <OutlineButton type="submit" className="mt-4" loading={loading} disabled={loading}>
+        Send reset link
+      </OutlineButton>
+    </form>
+  )
+}
+
+function ConfirmForm({ token }: { token: string }) {
+  const navigate = useNavigate()
+  const [password, setPassword] = useState("")
+  const [confirm, setConfirm] = useState("")
+  const [loading, setLoading] = useState(false)
+
+  async function handleSubmit(event: React.FormEvent) {
+    event.preventDefault()
+    if (loading) return
+    if (password !== confirm) {
+      toast.error("Passwords don't match.")
+      return
+    }
+    setLoading(true)
+    try {
+      await api.post("/auth/password-reset/confirm", { token, password })
+    } catch (err: unknown) {
+      setLoading(false)
+      toast.error(errorMessage(err, "Couldn't reset your password. Try again."))
+      return
+    }
+    toast.success("Password updated. Sign in with your new password.")
+    navigate("/auth/login", { replace: true })
+  }
+
+  return (
+    <form onSubmit={handleSubmit} noValidate className="space-y-2">
+      <Label htmlFor="password" className="sr-only">New password</Label>
+      <Input
+        id="password"
+        type="password"
+        autoComplete="new-password"
+        placeholder="New password"
+        value={password}
+        onChange={(e) => setPassword(e.target.value)}
+        disabled={loading}
+        required
+      />
+      <Label htmlFor="confirm" className="sr-only">Confirm new password</Label>
+      <Input
+        id="confirm"
+        type="password"
+        autoComplete="new-password"
+        placeholder="Confirm new password"
+        value={confirm}
+        onChange={(e) => setConfirm(e.target.value)}
+        disabled={loading}
+        required
+      />
+      <p className="pt-1 text-xs text-muted-foreground">
+        8–64 characters, with at least one number and one capital letter.
+      </p>
+      <OutlineButton type="submit" className="mt-4" loading={loading} disabled={loading}>
+        Set new password
+      </OutlineButton>
+    </form>
+  )
+}
@@ -4,14 +4,17 @@ import {
   Bot,
   Check,
   Crown,
+  Download,
   Hourglass,
   Inbox,
+  Layers,
   Mail,
   Pencil,
   Search,
   Shield,
   Sparkles,
   Trash2,
+  Upload,
   UserPlus,
 } from "lucide-react"
 import { useMemo, useState } from "react"
@@ -19,6 +22,7 @@ import { Link, useParams } from "react-router-dom"
 import { toast } from "sonner"
 
 import { EntityChip } from "@/components/EntityChip"
+import { GithubIcon } from "@/components/icons/socials"
 import { PageContainer } from "@/components/PageContainer"
 import { Avatar, AvatarFallback, AvatarImage } from "@/components/ui/avatar"
 import { Badge } from "@/components/ui/badge"
@@ -42,10 +46,11 @@ import {
 import { Skeleton } from "@/components/ui/skeleton"
 import { Textarea } from "@/components/ui/textarea"
 import { useAdmins } from "@/lib/admin"
-import { api } from "@/lib/api"
+import { api, downloadFile, getAllPages } from "@/lib/api"
 import type { Application } from "@/lib/applications"
 import { loadSession } from "@/lib/auth"
 import {
+  formatPredicate,
   useGroupConditionalBindings,
   type GroupConditionalBinding,
 } from "@/lib/conditional"
@@ -68,12 +73,15 @@ import {
   type DurationUnit,
 } from "@/lib/duration"
 import { fuzzyFilter } from "@/lib/fuzzy"
-import { useGroupGoogleBinding } from "@/lib/google"
+import { useGroupGitHubBindings } from "@/lib/github"
+import { useGroupGoogleBindings } from "@/lib/google"
 import {
+  MEMBER_SOURCE_LABEL,
   SOURCE_LABEL,
   type Group,
   type GroupJoinRequest,
   type GroupMember,
+  type GroupMemberSource,
   type GroupOwner,
   type GroupSource,
 } from "@/lib/groups"
@@ -86,6 +94,7 @@ import {
 } from "@/lib/users"
 
 import { AddGroupPersonDialog } from "./AddGroupPersonDialog"
+import { ImportGroupMembersDialog } from "./ImportGroupMembersDialog"
 import { ReviewRequestDialog } from "./ReviewRequestDialog"
 
 function formatDate(iso: string) {
@@ -118,6 +127,14 @@ function SourcePill({ source }: { source: GroupSource }) {
   )
 }
 
+function MemberSourcePill({ source }: { source: GroupMemberSource }) {
+  return (
+    <Badge variant="outline" className="font-mono text-[10px]">
+      {MEMBER_SOURCE_LABEL[source]}
+    </Badge>
+  )
+}
+
 // h-10 OutlineButton-style display pill: colored ring + bg-card interior +
 // colored text. Brand tone uses the gradient; gold/green use solid accents.
 function OutlinePill({
@@ -158,9 +175,11 @@ function OutlinePill({
 function MemberRow({
   member,
   user,
+  groupNamesByID,
 }: {
   member: GroupMember
   user?: UserOption
+  groupNamesByID?: Record<string, string>
 }) {
   const name = user ? userName(user) : ""
 
@@ -187,7 +206,12 @@ function MemberRow({
         <EntityChip entityId={member.entity_id} />
       )}
       <div className="flex shrink-0 items-center gap-2">
-        {member.source && <SourcePill source={member.source as GroupSource} />}
+        {member.source && <MemberSourcePill source={member.source} />}
+        {member.inherited_from && (
+          <span className="hidden truncate text-xs text-muted-foreground sm:inline">
+            via {groupNamesByID?.[member.inherited_from] ?? member.inherited_from}
+          </span>
+        )}
         {member.has_expiration ? (
           <span className="hidden text-xs text-muted-foreground sm:inline">
             expires {formatAbsoluteDate(member.expires_at)}
@@ -319,7 +343,7 @@ function SyncConfigBlock({
       <div className="min-w-0 flex-1">
         <p className="text-sm font-medium">Conditional rule</p>
         <p className="mt-0.5 text-xs text-muted-foreground">
-          Members of any of the group sets below are added automatically.
+          Members matching any of the rules below are added automatically.
         </p>
         {bindings.length === 0 ? (
           <p className="mt-2 text-xs italic text-muted-foreground">
@@ -350,6 +374,18 @@ function SyncConfigBlock({
                     </span>
                   )
                 })}
+                {(binding.predicates ?? []).map((predicate, idx) => (
+                  <span key={`predicate-${idx}`} className="flex items-center gap-1.5">
+                    {(idx > 0 || binding.required_group_ids.length > 0) && (
+                      <span className="text-xs font-medium text-muted-foreground">
+                        AND
+                      </span>
+                    )}
+                    <span className="inline-flex items-center rounded-md border border-border/60 bg-muted/40 px-2 py-0.5">
+                      <code className="font-mono text-xs">{formatPredicate(predicate)}</code>
+                    </span>
+                  </span>
+                ))}
               </li>
             ))}
           </ul>
@@ -379,6 +415,8 @@ export default function GroupDetailsPage() {
   const [cancelConfirmOpen, setCancelConfirmOpen] = useState(false)
   const [addPersonOpen, setAddPersonOpen] = useState(false)
   const [addPersonMode, setAddPersonMode] = useState<"member" | "owner">("member")
+  const [importOpen, setImportOpen] = useState(false)
+  const [exporting, setExporting] = useState(false)
 
   const groupQuery = useQuery({
     queryKey: ["group", id],
@@ -391,10 +429,7 @@ export default function GroupDetailsPage() {
 
   const membersQuery = useQuery({
     queryKey: ["group", id, "members"],
-    queryFn: async () => {
-      const res = await api.get<GroupMember[]>(`/groups/${id}/members`)
-      return res.data
-    },
+    queryFn: () => getAllPages<GroupMember>(`/groups/${id}/members`),
     enabled: !!id,
   })
   const usersQuery = useUsers({ enabled: !!id })
@@ -426,18 +461,34 @@ export default function GroupDetailsPage() {
     enabled: !!id,
   })
 
+  const childrenQuery = useQuery({
+    queryKey: ["group", id, "children"],
+    queryFn: async () => {
+      const res = await api.get<Group[]>(`/groups/${id}/children`)
+      return res.data
+    },
+    enabled: !!id,
+  })
+
+  const parentsQuery = useQuery({
+    queryKey: ["group", id, "parents"],
+    queryFn: async () => {
+      const res = await api.get<Group[]>(`/groups/${id}/parents`)
+      return res.data
+    },
+    enabled: !!id,
+  })
+
   const discordBindingsQuery = useGroupDiscordBindings(id ?? "")
   const discordRolesQuery = useDiscordRoles()
   const conditionalBindingsQuery = useGroupConditionalBindings(id ?? "")
-  const googleBindingQuery = useGroupGoogleBinding(id ?? "")
+  const googleBindingsQuery = useGroupGoogleBindings(id ?? "")
+  const githubBindingsQuery = useGroupGitHubBindings(id ?? "")
   // Fetch ALL groups once so we can resolve required_group_ids → names for
   // the conditional-binding chips. Cheap query for typical org scale.
   const allGroupsQuery = useQuery({
     queryKey: ["groups"],
-    queryFn: async () => {
-      const res = await api.get<Group[]>("/groups")
-      return res.data
-    },
+    queryFn: () => getAllPages<Group>("/groups"),
   })
   const groupNamesByID = useMemo(() => {
     const m: Record<string, string> = {}
@@ -613,6 +664,17 @@ export default function GroupDetailsPage() {
     setAddPersonOpen(true)
   }
 
+  async function exportMembers() {
+    setExporting(true)
+    try {
+      await downloadFile(`/groups/${id}/members/export`, `${group.name}-members.csv`)
+    } catch {
+      toast.error("Couldn't export members.")
+    } finally {
+      setExporting(false)
+    }
+  }
+
   return (
     <PageContainer>
       <Button asChild variant="ghost" size="sm" className="-ml-2 mb-4 text-muted-foreground">
@@ -889,20 +951,97 @@ export default function GroupDetailsPage() {
                   )}
                 </section>
 
-                {googleBindingQuery.data && (
+                {(!!childrenQuery.data?.length || !!parentsQuery.data?.length) && (
                   <section className="border-t border-border/60 pt-6">
                     <p className="text-xs font-medium uppercase tracking-wider text-muted-foreground">
-                      Google Group
+                      Nested groups
                     </p>
                     <p className="mt-1 text-xs text-muted-foreground">
-                      Members are mirrored into this Google Group.
+                      Members of a nested group are members of every group above it.
                     </p>
-                    <div className="mt-3 flex items-center gap-2.5 rounded-md border border-border/60 bg-muted/40 px-3 py-2">
-                      <Mail className="size-4 shrink-0 text-muted-foreground" />
-                      <span className="truncate font-mono text-sm">
-                        {googleBindingQuery.data.google_group_email}
-                      </span>
-                    </div>
+                    <ul className="mt-3 space-y-2">
+                      {(parentsQuery.data ?? []).map((parent) => (
+                        <li key={`parent-${parent.id}`}>
+                          <Link
+                            to={`/groups/${parent.id}`}
+                            className="flex items-center gap-2.5 rounded-md border border-border/60 bg-muted/40 px-3 py-2 transition-colors hover:bg-muted"
+                          >
+                            <Layers className="size-4 shrink-0 text-muted-foreground" />
+                            <p className="min-w-0 flex-1 truncate text-sm">{parent.name}</p>
+                            <span className="shrink-0 text-xs text-muted-foreground">parent</span>
+                          </Link>
+                        </li>
+                      ))}
+                      {(childrenQuery.data ?? []).map((child) => (
+                        <li key={`child-${child.id}`}>
+                          <Link
+                            to={`/groups/${child.id}`}
+                            className="flex items-center gap-2.5 rounded-md border border-border/60 bg-muted/40 px-3 py-2 transition-colors hover:bg-muted"
+                          >
+                            <Layers className="size-4 shrink-0 text-muted-foreground" />
+                            <p className="min-w-0 flex-1 truncate text-sm">{child.name}</p>
+                            <span className="shrink-0 text-xs text-muted-foreground">
+                              {child.member_count} members
+                            </span>
+                          </Link>
+                        </li>
+                      ))}
+                    </ul>
+                  </section>
+                )}
+
+                {!!googleBindingsQuery.data?.length && (
+                  <section className="border-t border-border/60 pt-6">
+                    <p className="text-xs font-medium uppercase tracking-wider text-muted-foreground">
+                      Google Groups
+                    </p>
+                    <p className="mt-1 text-xs text-muted-foreground">
+                      Members are mirrored into these Google Groups.
+                    </p>
+                    <ul className="mt-3 space-y-2">
+                      {googleBindingsQuery.data.map((binding) => (
+                        <li
+                          key={binding.id}
+                          className="flex items-center gap-2.5 rounded-md border border-border/60 bg-muted/40 px-3 py-2"
+                        >
+                          <Mail className="size-4 shrink-0 text-muted-foreground" />
+                          <div className="min-w-0 flex-1 leading-tight">
+                            <p className="truncate font-mono text-sm">
+                              {binding.google_group_email}
+                            </p>
+                            {binding.mode === "NESTED" && (
+                              <p className="truncate text-xs text-muted-foreground">
+                                nested via {binding.member_group_email}
+                              </p>
+                            )}
+                          </div>
+                        </li>
+                      ))}
+                    </ul>
+                  </section>
+                )}
+
+                {!!githubBindingsQuery.data?.length && (
+                  <section className="border-t border-border/60 pt-6">
+                    <p className="text-xs font-medium uppercase tracking-wider text-muted-foreground">
+                      GitHub teams
+                    </p>
+                    <p className="mt-1 text-xs text-muted-foreground">
+                      Members with a linked GitHub account are kept on these teams.
+                    </p>
+                    <ul className="mt-3 space-y-2">
+                      {githubBindingsQuery.data.map((binding) => (
+                        <li
+                          key={binding.id}
+                          className="flex items-center gap-2.5 rounded-md border border-border/60 bg-muted/40 px-3 py-2"
+                        >
+                          <GithubIcon className="size-4 shrink-0 text-muted-foreground" />
+                          <p className="min-w-0 flex-1 truncate font-mono text-sm">
+                            {binding.team_slug}
+                          </p>
+                        </li>
+                      ))}
+                    </ul>
                   </section>
                 )}
 
@@ -991,7 +1130,34 @@ export default function GroupDetailsPage() {
               {members.length} total · {directCount} direct · {syncedCount} synced
             </CardDescription>
             {isOwner && (
-              <CardAction>
+              <CardAction className="flex flex-wrap justify-end gap-2">
+                <Button
+                  type="button"
+                  variant="ghost"
+                  size="sm"
+                  className="gap-1.5"
+                  disabled={exporting}
+                  onClick={exportMembers}
+                >
+                  <Download className="size-3.5" />
+                  Export
+                </Button>
+                <Button
+                  type="button"
+                  variant="outline"
+                  size="sm"
+                  className="gap-1.5"
+                  disabled={!directMembershipsEnabled}
+                  title={
+                    directMembershipsEnabled
+                      ? undefined
+                      : "Enable direct source before importing direct members"
+                  }
+                  onClick={() => setImportOpen(true)}
+                >
+                  <Upload className="size-3.5" />
+                  Import
+                </Button>
                 <Button
                   type="button"
                   variant="outline"
@@ -1035,6 +1201,7 @@ export default function GroupDetailsPage() {
                     <MemberRow
                       member={m}
                       user={usersByEntityID.get(m.entity_id)}
+                      groupNamesByID={groupNamesByID}
                     />
                   </li>
                 ))}
@@ -1217,6 +1384,14 @@ export default function GroupDetailsPage() {
           existingOwnerEntityIDs={owners.map((owner) => owner.entity_id)}
         />
       )}
+      {isOwner && (
+        <ImportGroupMembersDialog
+          open={importOpen}
+          onOpenChange={setImportOpen}
+          groupID={id ?? ""}
+          groupName={group.name}
+        />
+      )}
     </PageContainer>
   )
 }
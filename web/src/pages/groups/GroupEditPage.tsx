@@ -4,6 +4,7 @@ import { useEffect, useMemo, useState } from "react"
 import { Link, useNavigate, useParams } from "react-router-dom"
 import { toast } from "sonner"
 
+import { GithubIcon } from "@/components/icons/socials"
 import { OutlineButton } from "@/components/OutlineButton"
 import { PageContainer } from "@/components/PageContainer"
 import { Badge } from "@/components/ui/badge"
@@ -26,10 +27,11 @@ import {
 } from "@/components/ui/select"
 import { Skeleton } from "@/components/ui/skeleton"
 import { useAdmins } from "@/lib/admin"
-import { api } from "@/lib/api"
+import { api, getAllPages } from "@/lib/api"
 import type { Application, ApplicationWithLink } from "@/lib/applications"
 import { loadSession } from "@/lib/auth"
 import {
+  formatPredicate,
   useGroupConditionalBindings,
   type GroupConditionalBinding,
 } from "@/lib/conditional"
@@ -39,6 +41,7 @@ import {
   useGroupDiscordBindings,
   type GroupDiscordRoleBinding,
 } from "@/lib/discord"
+import { useGroupGitHubBinding } from "@/lib/github"
 import { useGroupGoogleBinding } from "@/lib/google"
 import type { Group, GroupMember, GroupOwner, GroupSource } from "@/lib/groups"
 
@@ -198,6 +201,18 @@ function ConditionalSyncCard({
                       </span>
                     )
                   })}
+                  {(binding.predicates ?? []).map((predicate, idx) => (
+                    <span key={`predicate-${idx}`} className="flex items-center gap-1.5">
+                      {(idx > 0 || binding.required_group_ids.length > 0) && (
+                        <span className="text-xs font-medium text-muted-foreground">
+                          AND
+                        </span>
+                      )}
+                      <span className="inline-flex items-center rounded-md border border-border/60 bg-background/60 px-2 py-0.5">
+                        <code className="font-mono text-xs">{formatPredicate(predicate)}</code>
+                      </span>
+                    </span>
+                  ))}
                 </div>
                 <Button
                   variant="ghost"
@@ -270,6 +285,47 @@ function GoogleSyncCard({
   )
 }
 
+function GitHubSyncCard({
+  teamSlug,
+  onChange,
+  onSyncNow,
+  syncing,
+}: {
+  teamSlug: string
+  onChange: (teamSlug: string) => void
+  onSyncNow: () => void
+  syncing: boolean
+}) {
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          <GithubIcon className="size-4 text-muted-foreground" />
+          GitHub team sync
+        </CardTitle>
+        <CardDescription>
+          Keep a team in the GitHub org in step with this group. Members who have linked a
+          GitHub account are invited to the team as members; maintainers added directly in
+          GitHub are left untouched. Leave blank to disable. Changes apply on Save.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-3">
+        <Input
+          autoComplete="off"
+          placeholder="firmware"
+          value={teamSlug}
+          onChange={(e) => onChange(e.target.value)}
+        />
+        <div className="pt-1">
+          <Button type="button" variant="outline" disabled={syncing} onClick={onSyncNow}>
+            {syncing ? "Syncing…" : "Sync now"}
+          </Button>
+        </div>
+      </CardContent>
+    </Card>
+  )
+}
+
 function LinkedApplicationsCard({
   links,
   allApps,
@@ -403,26 +459,21 @@ export default function GroupEditPage() {
 
   const membersQuery = useQuery({
     queryKey: ["group", id, "members"],
-    queryFn: async () => {
-      const res = await api.get<GroupMember[]>(`/groups/${id}/members`)
-      return res.data
-    },
+    queryFn: () => getAllPages<GroupMember>(`/groups/${id}/members`),
     enabled: !!id,
   })
 
   const bindingsQuery = useGroupDiscordBindings(id ?? "")
   const conditionalBindingsQuery = useGroupConditionalBindings(id ?? "")
   const googleBindingQuery = useGroupGoogleBinding(id ?? "")
+  const githubBindingQuery = useGroupGitHubBinding(id ?? "")
 
   // All groups, used by the conditional editor to resolve required_group_ids
   // → names for the chips and to feed the picker dialog. Cheap query for
   // typical org scale.
   const allGroupsQuery = useQuery({
     queryKey: ["groups"],
-    queryFn: async () => {
-      const res = await api.get<Group[]>("/groups")
-      return res.data
-    },
+    queryFn: () => getAllPages<Group>("/groups"),
   })
   const groupNamesByID = useMemo(() => {
     const m: Record<string, string> = {}
@@ -456,12 +507,18 @@ export default function GroupEditPage() {
   const [values, setValues] = useState<GroupFormValues | null>(null)
   const [submitting, setSubmitting] = useState(false)
   const [deleting, setDeleting] = useState(false)
+  const [requireMfa, setRequireMfa] = useState<boolean | null>(null)
+  const [alumniGroupId, setAlumniGroupId] = useState<string | null>(null)
   // Google Group binding (1:1). Staged like the rest of the page — applied on
   // Save by diffing against the server binding. Null until the query settles so
   // we don't briefly show an empty field over an existing binding.
   const [googleEmail, setGoogleEmail] = useState("")
   const [googleEmailInitialized, setGoogleEmailInitialized] = useState(false)
   const [syncingGoogle, setSyncingGoogle] = useState(false)
+  // GitHub team binding, staged the same way.
+  const [githubTeam, setGitHubTeam] = useState("")
+  const [githubTeamInitialized, setGitHubTeamInitialized] = useState(false)
+  const [syncingGitHub, setSyncingGitHub] = useState(false)
   const [confirmOpen, setConfirmOpen] = useState(false)
   const [cascadeConfirmOpen, setCascadeConfirmOpen] = useState(false)
   // Pending binding state — staged changes are applied to the server in
@@ -523,6 +580,7 @@ export default function GroupEditPage() {
       id: p.tempID,
       group_id: id ?? "",
       required_group_ids: p.required_group_ids,
+      predicates: null,
       created_at: "",
     })),
   ]
@@ -557,6 +615,8 @@ export default function GroupEditPage() {
         description: query.data.description,
         allowed_sources: query.data.allowed_sources ?? [],
       })
+      setRequireMfa(query.data.require_mfa ?? false)
+      setAlumniGroupId(query.data.alumni_group_id ?? "")
     }
   }, [query.data, values])
 
@@ -582,6 +642,28 @@ export default function GroupEditPage() {
     }
   }
 
+  useEffect(() => {
+    if (!githubBindingQuery.isLoading && !githubTeamInitialized) {
+      setGitHubTeam(githubBindingQuery.data?.team_slug ?? "")
+      setGitHubTeamInitialized(true)
+    }
+  }, [githubBindingQuery.isLoading, githubBindingQuery.data, githubTeamInitialized])
+
+  async function handleSyncGitHubNow() {
+    setSyncingGitHub(true)
+    try {
+      await api.post("/github/reconcile")
+      toast.success("GitHub sync triggered")
+    } catch (err: unknown) {
+      const message =
+        (err as { response?: { data?: { error?: string } } })?.response?.data?.error ??
+        "Couldn't trigger GitHub sync."
+      toast.error(message)
+    } finally {
+      setSyncingGitHub(false)
+    }
+  }
+
   useEffect(() => {
     if (linkedAppsQuery.data && !appLinksInitialized) {
       const m = new Map<string, boolean>()
@@ -683,6 +765,23 @@ export default function GroupEditPage() {
           })
         }
       }
+      // Same diff for the GitHub team binding.
+      const serverGitHubBinding = githubBindingQuery.data ?? null
+      const desiredGitHubTeam = githubTeam.trim().toLowerCase()
+      const currentGitHubTeam = serverGitHubBinding?.team_slug ?? ""
+      if (desiredGitHubTeam !== currentGitHubTeam) {
+        if (serverGitHubBinding) {
+          await api.delete(`/github/group-bindings/${serverGitHubBinding.id}`, {
+            params: { group_id: id },
+          })
+        }
+        if (desiredGitHubTeam) {
+          await api.post(`/github/group-bindings`, {
+            group_id: id,
+            team_slug: desiredGitHubTeam,
+          })
+        }
+      }
 
       // Diff application links against the server state. POST is upsert,
       // so we send any link whose required flag differs (or doesn't exist
@@ -709,12 +808,15 @@ export default function GroupEditPage() {
         name: values.name.trim(),
         description: values.description,
         allowed_sources: values.allowed_sources,
+        require_mfa: requireMfa ?? undefined,
+        alumni_group_id: alumniGroupId ?? undefined,
       })
       qc.invalidateQueries({ queryKey: ["groups"] })
       qc.invalidateQueries({ queryKey: ["group", id] })
       qc.invalidateQueries({ queryKey: ["group", id, "members"] })
       qc.invalidateQueries({ queryKey: ["group", id, "discord-bindings"] })
-      qc.invalidateQueries({ queryKey: ["group", id, "google-binding"] })
+      qc.invalidateQueries({ queryKey: ["group", id, "google-bindings"] })
+      qc.invalidateQueries({ queryKey: ["group", id, "github-bindings"] })
       qc.invalidateQueries({ queryKey: ["group", id, "applications"] })
       toast.success("Group updated")
       navigate(`/groups/${id}`)
@@ -781,6 +883,7 @@ export default function GroupEditPage() {
     bindingsQuery.isLoading ||
     conditionalBindingsQuery.isLoading ||
     googleBindingQuery.isLoading ||
+    githubBindingQuery.isLoading ||
     adminsLoading
   ) {
     return (
@@ -873,6 +976,59 @@ export default function GroupEditPage() {
           </CardContent>
         </Card>
 
+        <Card>
+          <CardHeader>
+            <CardTitle>Security</CardTitle>
+            <CardDescription>Sign-in requirements for everyone in this group.</CardDescription>
+          </CardHeader>
+          <CardContent>
+            <label className="flex items-start gap-3 text-sm">
+              <input
+                type="checkbox"
+                className="mt-0.5"
+                checked={requireMfa ?? false}
+                onChange={(e) => setRequireMfa(e.target.checked)}
+              />
+              <span>
+                Require two-factor authentication
+                <span className="block text-muted-foreground">
+                  Members without an authenticator are asked to set one up the next time they sign in.
+                </span>
+              </span>
+            </label>
+          </CardContent>
+        </Card>
+
+        <Card>
+          <CardHeader>
+            <CardTitle>Alumni</CardTitle>
+            <CardDescription>
+              When a member becomes alumni, they're added to this group and their direct membership
+              here ends.
+            </CardDescription>
+          </CardHeader>
+          <CardContent>
+            <Select
+              value={alumniGroupId || "none"}
+              onValueChange={(v) => setAlumniGroupId(v === "none" ? "" : v)}
+            >
+              <SelectTrigger className="w-full max-w-sm">
+                <SelectValue />
+              </SelectTrigger>
+              <SelectContent>
+                <SelectItem value="none">No alumni group</SelectItem>
+                {(allGroupsQuery.data ?? [])
+                  .filter((g) => g.id !== id)
+                  .map((g) => (
+                    <SelectItem key={g.id} value={g.id}>
+                      {g.name}
+                    </SelectItem>
+                  ))}
+              </SelectContent>
+            </Select>
+          </CardContent>
+        </Card>
+
         {values.allowed_sources.includes("DISCORD") && (
           <DiscordSyncCard
             bindings={effectiveBindings}
@@ -898,6 +1054,13 @@ export default function GroupEditPage() {
           syncing={syncingGoogle}
         />
 
+        <GitHubSyncCard
+          teamSlug={githubTeam}
+          onChange={setGitHubTeam}
+          onSyncNow={handleSyncGitHubNow}
+          syncing={syncingGitHub}
+        />
+
         <LinkedApplicationsCard
           links={appLinkList}
           allApps={allAppsQuery.data ?? []}
@@ -939,9 +1102,9 @@ export default function GroupEditPage() {
             </div>
             <DialogTitle>Delete {group.name}?</DialogTitle>
             <DialogDescription>
-              This permanently removes the group along with all member, owner, and
-              join-request rows linked to it. Linked application bindings stay (the apps
-              just stop granting access via this group). This can't be undone.
+              The group disappears right away and stops granting access. Its members,
+              owners, join requests, and application links are kept for 30 days, so it
+              can be restored; after that it's deleted for good.
             </DialogDescription>
           </DialogHeader>
 
@@ -1,6 +1,6 @@
 import { useQuery } from "@tanstack/react-query"
 import { Check, Search, Sparkles } from "lucide-react"
-import { useEffect, useMemo, useState } from "react"
+import { useEffect, useMemo, useState, type ReactNode } from "react"
 
 import { Button } from "@/components/ui/button"
 import {
@@ -12,7 +12,8 @@ import {
 } from "@/components/ui/dialog"
 import { Input } from "@/components/ui/input"
 import { Skeleton } from "@/components/ui/skeleton"
-import { api } from "@/lib/api"
+import { getAllPages } from "@/lib/api"
+import { useSimulateConditionalBinding } from "@/lib/conditional"
 import { fuzzyFilter } from "@/lib/fuzzy"
 import type { Group } from "@/lib/groups"
 
@@ -21,27 +22,37 @@ import type { Group } from "@/lib/groups"
 // keyed on Sentinel groups instead of Discord roles. `excludeGroupID` is
 // the parent group of the binding — excluded from the picker since a
 // binding can't require its own group (the backend would reject anyway).
+// Application role rules reuse it with their own copy and no excluded group.
 export function GroupPickerDialog({
   open,
   onOpenChange,
   excludeGroupID,
   onAddBinding,
+  title = "Add conditional binding",
+  description = (
+    <>
+      Entities must be a member of <strong>all</strong> selected groups to be synced through
+      this binding. To express "either-or", add a second binding.
+    </>
+  ),
+  confirmLabel = "Add binding",
 }: {
   open: boolean
   onOpenChange: (open: boolean) => void
   excludeGroupID: string
   onAddBinding: (groupIDs: string[]) => void
+  title?: string
+  description?: ReactNode
+  confirmLabel?: string
 }) {
   const groupsQuery = useQuery({
     queryKey: ["groups"],
-    queryFn: async () => {
-      const res = await api.get<Group[]>("/groups")
-      return res.data
-    },
+    queryFn: () => getAllPages<Group>("/groups"),
     enabled: open,
   })
   const [search, setSearch] = useState("")
   const [selected, setSelected] = useState<Set<string>>(new Set())
+  const simulation = useSimulateConditionalBinding(open ? excludeGroupID : "", [...selected])
 
   // Reset transient state every time the dialog reopens.
   useEffect(() => {
@@ -81,11 +92,8 @@ export function GroupPickerDialog({
           <div className="flex size-10 items-center justify-center rounded-xl bg-gradient-to-br from-gr-pink to-gr-purple text-white">
             <Sparkles className="size-5" />
           </div>
-          <DialogTitle>Add conditional binding</DialogTitle>
-          <DialogDescription>
-            Pick one or more groups. Entities must be a member of <strong>all</strong> selected
-            groups to be synced through this binding. To express "either-or", add a second binding.
-          </DialogDescription>
+          <DialogTitle>{title}</DialogTitle>
+          <DialogDescription>Pick one or more groups. {description}</DialogDescription>
         </DialogHeader>
 
         <div className="relative">
@@ -157,6 +165,13 @@ export function GroupPickerDialog({
               : selected.size === 1
                 ? "1 group selected."
                 : `${selected.size} groups selected — all required.`}
+            {selected.size > 0 && simulation.data?.conditional_enabled && (
+              <>
+                {" "}
+                Would add {simulation.data.added.length}{" "}
+                {simulation.data.added.length === 1 ? "entity" : "entities"}.
+              </>
+            )}
           </p>
           <div className="flex gap-2">
             <Button
@@ -171,7 +186,7 @@ export function GroupPickerDialog({
               disabled={selected.size === 0}
               onClick={commit}
             >
-              Add binding
+              {confirmLabel}
             </Button>
           </div>
         </div>
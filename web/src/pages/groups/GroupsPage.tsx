@@ -8,7 +8,7 @@ import { PageContainer, PageHeader } from "@/components/PageContainer"
 import { Button } from "@/components/ui/button"
 import { Input } from "@/components/ui/input"
 import { Skeleton } from "@/components/ui/skeleton"
-import { api } from "@/lib/api"
+import { getAllPages } from "@/lib/api"
 import { fuzzyFilter } from "@/lib/fuzzy"
 import type { Group } from "@/lib/groups"
 
@@ -17,10 +17,7 @@ export default function GroupsPage() {
 
   const groupsQuery = useQuery({
     queryKey: ["groups"],
-    queryFn: async () => {
-      const res = await api.get<Group[]>("/groups")
-      return res.data
-    },
+    queryFn: () => getAllPages<Group>("/groups"),
   })
 
   const groups = groupsQuery.data ?? []
@@ -0,0 +1,211 @@
+import { useMutation, useQueryClient } from "@tanstack/react-query"
+import { FileUp, Upload } from "lucide-react"
+import { useEffect, useState } from "react"
+import { toast } from "sonner"
+
+import { Button } from "@/components/ui/button"
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogHeader,
+  DialogTitle,
+} from "@/components/ui/dialog"
+import { Label } from "@/components/ui/label"
+import { Textarea } from "@/components/ui/textarea"
+import { api } from "@/lib/api"
+
+// Mirror of core/service/group_import.go::MembershipImportReport.
+type MembershipImportStatus = "ADDED" | "EXISTS" | "ERROR" | "NOT_APPLIED"
+
+type MembershipImportResult = {
+  row: number
+  identifier: string
+  entity_id?: string
+  status: MembershipImportStatus
+  message?: string
+}
+
+type MembershipImportReport = {
+  group_id: string
+  applied: boolean
+  added: number
+  errors: number
+  results: MembershipImportResult[]
+}
+
+// Pasted lists are usually just identifiers, one per line; the endpoint
+// wants a CSV header, so add one when it's missing.
+function toImportCSV(text: string) {
+  const trimmed = text.trim()
+  const firstLine = trimmed.split(/\r?\n/, 1)[0] ?? ""
+  const hasHeader = firstLine
+    .split(",")
+    .some((col) => col.trim().toLowerCase() === "identifier")
+  return hasHeader ? trimmed : `identifier\n${trimmed}`
+}
+
+export function ImportGroupMembersDialog({
+  open,
+  onOpenChange,
+  groupID,
+  groupName,
+}: {
+  open: boolean
+  onOpenChange: (open: boolean) => void
+  groupID: string
+  groupName: string
+}) {
+  const qc = useQueryClient()
+  const [text, setText] = useState("")
+  const [allOrNothing, setAllOrNothing] = useState(true)
+  const [report, setReport] = useState<MembershipImportReport | null>(null)
+
+  useEffect(() => {
+    if (open) {
+      setText("")
+      setAllOrNothing(true)
+      setReport(null)
+    }
+  }, [open])
+
+  const mutation = useMutation({
+    mutationFn: async () => {
+      const res = await api.post<MembershipImportReport>(
+        `/groups/${groupID}/members/import`,
+        toImportCSV(text),
+        {
+          headers: { "Content-Type": "text/csv" },
+          params: { all_or_nothing: allOrNothing ? "true" : "false" },
+        },
+      )
+      return res.data
+    },
+    onSuccess: async (data) => {
+      setReport(data)
+      if (data.added > 0) {
+        await Promise.all([
+          qc.invalidateQueries({ queryKey: ["group", groupID] }),
+          qc.invalidateQueries({ queryKey: ["group", groupID, "members"] }),
+        ])
+      }
+      if (!data.applied) {
+        toast.error(`Nothing imported — ${data.errors} row${data.errors === 1 ? "" : "s"} failed.`)
+      } else if (data.errors > 0) {
+        toast.warning(`Added ${data.added}; ${data.errors} row${data.errors === 1 ? "" : "s"} failed.`)
+      } else {
+        toast.success(`Added ${data.added} member${data.added === 1 ? "" : "s"}`)
+      }
+    },
+    onError: (err: unknown) => {
+      const message =
+        (err as { response?: { data?: { error?: string } } })?.response?.data?.error ??
+        "Couldn't import members."
+      toast.error(message)
+    },
+  })
+
+  async function loadFile(file: File | undefined) {
+    if (!file) return
+    setText(await file.text())
+    setReport(null)
+  }
+
+  const problemRows = (report?.results ?? []).filter((r) => r.status === "ERROR")
+
+  return (
+    <Dialog open={open} onOpenChange={onOpenChange}>
+      <DialogContent className="gap-5 sm:max-w-lg">
+        <DialogHeader className="gap-3">
+          <div className="flex size-10 items-center justify-center rounded-xl bg-gradient-to-br from-gr-pink to-gr-purple text-white">
+            <Upload className="size-5" />
+          </div>
+          <DialogTitle>Import members</DialogTitle>
+          <DialogDescription>
+            Add people to {groupName} in bulk. Paste one username, email, Discord ID, or
+            entity ID per line, or upload a CSV with an <code>identifier</code> column and an
+            optional <code>expires_at</code> column.
+          </DialogDescription>
+        </DialogHeader>
+
+        <div className="space-y-2">
+          <div className="flex items-center justify-between gap-2">
+            <Label htmlFor="group-import-rows">Rows</Label>
+            <Button asChild type="button" variant="ghost" size="sm" className="gap-1.5">
+              <label>
+                <FileUp className="size-3.5" />
+                Choose CSV
+                <input
+                  type="file"
+                  accept=".csv,text/csv"
+                  className="sr-only"
+                  onChange={(e) => loadFile(e.target.files?.[0])}
+                />
+              </label>
+            </Button>
+          </div>
+          <Textarea
+            id="group-import-rows"
+            placeholder={"jdoe\njane@example.com\n123456789012345678"}
+            value={text}
+            onChange={(e) => {
+              setText(e.target.value)
+              setReport(null)
+            }}
+            rows={8}
+            className="font-mono text-xs"
+          />
+        </div>
+
+        <label className="flex items-start gap-2.5 text-sm">
+          <input
+            type="checkbox"
+            checked={allOrNothing}
+            onChange={(e) => setAllOrNothing(e.target.checked)}
+            className="mt-0.5 size-4 accent-gr-pink"
+          />
+          <span>
+            All or nothing
+            <span className="block text-xs text-muted-foreground">
+              If any row fails, don't add anyone.
+            </span>
+          </span>
+        </label>
+
+        {report && (
+          <div className="space-y-2 rounded-md border border-border/60 bg-muted/20 p-3 text-sm">
+            <p>
+              {report.applied ? `${report.added} added` : "Nothing imported"} ·{" "}
+              {report.results.filter((r) => r.status === "EXISTS").length} already members ·{" "}
+              {report.errors} failed
+            </p>
+            {problemRows.length > 0 && (
+              <ul className="max-h-40 space-y-1 overflow-y-auto text-xs">
+                {problemRows.map((r) => (
+                  <li key={r.row} className="flex gap-2">
+                    <span className="shrink-0 text-muted-foreground">Row {r.row}</span>
+                    <code className="truncate font-mono">{r.identifier || "—"}</code>
+                    <span className="text-destructive">{r.message}</span>
+                  </li>
+                ))}
+              </ul>
+            )}
+          </div>
+        )}
+
+        <div className="flex justify-end gap-2">
+          <Button type="button" variant="ghost" onClick={() => onOpenChange(false)}>
+            {report?.applied ? "Done" : "Cancel"}
+          </Button>
+          <Button
+            type="button"
+            disabled={!text.trim() || mutation.isPending}
+            onClick={() => mutation.mutate()}
+          >
+            {mutation.isPending ? "Importing…" : "Import"}
+          </Button>
+        </div>
+      </DialogContent>
+    </Dialog>
+  )
+}
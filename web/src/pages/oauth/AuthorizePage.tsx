@@ -3,13 +3,14 @@ import { Loader2 } from "lucide-react"
 import { useEffect, useMemo, useRef, useState } from "react"
 import { Navigate, useLocation, useSearchParams } from "react-router-dom"
 
+import { ConsentPolicyNotes, type ConsentPolicy } from "@/components/ConsentPolicyNotes"
 import { OutlineButton } from "@/components/OutlineButton"
 import { SuccessCheck } from "@/components/SuccessCheck"
 import { Avatar, AvatarFallback, AvatarImage } from "@/components/ui/avatar"
 import { Button } from "@/components/ui/button"
 import { api } from "@/lib/api"
 import { loadSession, saveLoginReturnFrom, useAuth } from "@/lib/auth"
-import { resolveScopes } from "@/lib/scopes"
+import { resolveScopes, type RegisteredScope } from "@/lib/scopes"
 import { cn } from "@/lib/utils"
 
 const CONVERGE_MS = 250
@@ -22,10 +23,13 @@ type ValidateResponse = {
   client_id: string
   redirect_uri: string
   scope: string
+  state?: string
+  nonce?: string
   prompt: string
   app_name: string
   app_icon_url: string
-}
+  scopes?: RegisteredScope[]
+} & ConsentPolicy
 
 function initials(name: string) {
   return name
@@ -95,30 +99,42 @@ export default function AuthorizePage() {
   const { user } = useAuth()
 
   const clientId = params.get("client_id")
-  const redirectUri = params.get("redirect_uri") ?? ""
-  const scope = params.get("scope") ?? ""
-  const state = params.get("state")
-  const nonce = params.get("nonce")
+  // A pushed request (request_uri) or signed request object (request)
+  // carries every other parameter; the backend unpacks it and returns them
+  // from validate, and anything else in the URL is ignored.
+  const requestUri = params.get("request_uri")
+  const requestObject = params.get("request")
+  const byReference = !!(requestUri || requestObject)
 
   const [busy, setBusy] = useState<Action | null>(null)
   const [success, setSuccess] = useState(false)
   const [deniedApp, setDeniedApp] = useState<DeniedApp | null>(null)
   const autoApproved = useRef(false)
 
-  // Echo `state` back to the client on every redirect — it's the client's
-  // CSRF token and the spec requires it round-trips untouched.
-  const withState = (extra: Record<string, string>) =>
-    state ? { ...extra, state } : extra
+  // The request parameters to send back to the backend as-is: the reference
+  // when there is one, otherwise the plain query parameters.
+  function requestSearch(): URLSearchParams {
+    const search = new URLSearchParams({ client_id: clientId ?? "" })
+    if (requestUri) search.set("request_uri", requestUri)
+    else if (requestObject) search.set("request", requestObject)
+    return search
+  }
 
   const validate = useQuery({
-    queryKey: ["oauth-authorize", clientId, redirectUri, scope, session?.entityId],
+    queryKey: [
+      "oauth-authorize",
+      clientId,
+      requestUri ?? requestObject ?? params.get("redirect_uri"),
+      params.get("scope"),
+      session?.entityId,
+    ],
     queryFn: async () => {
-      const search = new URLSearchParams({
-        client_id: clientId ?? "",
-        redirect_uri: redirectUri,
-        scope,
-        entity_id: session?.entityId ?? "",
-      })
+      const search = requestSearch()
+      if (!byReference) {
+        search.set("redirect_uri", params.get("redirect_uri") ?? "")
+        search.set("scope", params.get("scope") ?? "")
+      }
+      search.set("entity_id", session?.entityId ?? "")
       const res = await api.get<ValidateResponse>(`/oauth/authorize?${search.toString()}`)
       return res.data
     },
@@ -126,8 +142,23 @@ export default function AuthorizePage() {
     retry: false,
   })
 
+  const redirectUri = byReference
+    ? (validate.data?.redirect_uri ?? "")
+    : (params.get("redirect_uri") ?? "")
+  const scope = byReference ? (validate.data?.scope ?? "") : (params.get("scope") ?? "")
+  const state = byReference ? validate.data?.state : params.get("state")
+  const nonce = byReference ? validate.data?.nonce : params.get("nonce")
+
+  // Echo `state` back to the client on every redirect — it's the client's
+  // CSRF token and the spec requires it round-trips untouched.
+  const withState = (extra: Record<string, string>) =>
+    state ? { ...extra, state } : extra
+
   const resolvedScope = validate.data?.scope ?? scope
-  const scopes = useMemo(() => resolveScopes(resolvedScope), [resolvedScope])
+  const scopes = useMemo(
+    () => resolveScopes(resolvedScope, validate.data?.scopes),
+    [resolvedScope, validate.data?.scopes],
+  )
 
   async function complete(action: Action) {
     if (busy) return
@@ -139,14 +170,14 @@ export default function AuthorizePage() {
     }
 
     try {
-      const search = new URLSearchParams({
-        client_id: clientId ?? "",
-        redirect_uri: redirectUri,
-        scope: resolvedScope,
-      })
-      // Bind the OIDC nonce to the authorization code so the backend can echo
-      // it into the issued ID token.
-      if (nonce) search.set("nonce", nonce)
+      const search = requestSearch()
+      if (!byReference) {
+        search.set("redirect_uri", redirectUri)
+        search.set("scope", resolvedScope)
+        // Bind the OIDC nonce to the authorization code so the backend can
+        // echo it into the issued ID token.
+        if (nonce) search.set("nonce", nonce)
+      }
       const res = await api.post<{ code: string; redirect_uri: string }>(
         `/oauth/authorize?${search.toString()}`,
         { entity_id: session?.entityId },
@@ -193,7 +224,7 @@ export default function AuthorizePage() {
     return <Navigate to="/auth/login" state={{ from: location }} replace />
   }
 
-  if (!clientId || !redirectUri || !scope) {
+  if (!clientId || (!byReference && (!redirectUri || !scope))) {
     return (
       <main className="flex min-h-svh items-center justify-center px-4 py-12">
         <div className="w-full max-w-sm space-y-2 text-center">
@@ -330,6 +361,7 @@ export default function AuthorizePage() {
               </li>
             ))}
           </ul>
+          <ConsentPolicyNotes appName={app.app_name} policy={app} />
         </div>
 
         <div className="space-y-3">
@@ -0,0 +1,264 @@
+import { useQuery } from "@tanstack/react-query"
+import { Loader2 } from "lucide-react"
+import { useMemo, useState, type FormEvent, type ReactNode } from "react"
+import { Navigate, useLocation, useSearchParams } from "react-router-dom"
+
+import { ConsentPolicyNotes, type ConsentPolicy } from "@/components/ConsentPolicyNotes"
+import { OutlineButton } from "@/components/OutlineButton"
+import { Avatar, AvatarFallback, AvatarImage } from "@/components/ui/avatar"
+import { Button } from "@/components/ui/button"
+import { Input } from "@/components/ui/input"
+import { api } from "@/lib/api"
+import { loadSession, saveLoginReturnFrom } from "@/lib/auth"
+import { resolveScopes, type RegisteredScope } from "@/lib/scopes"
+
+type Action = "approve" | "deny"
+
+type ValidateResponse = {
+  client_id: string
+  user_code: string
+  scope: string
+  app_name: string
+  app_icon_url: string
+  expires_at: string
+  scopes?: RegisteredScope[]
+} & ConsentPolicy
+
+function errorMessage(err: unknown): string | undefined {
+  return (err as { response?: { data?: { error?: string } } })?.response?.data?.error
+}
+
+function isAccessDenied(err: unknown): boolean {
+  const res = (err as { response?: { status?: number; data?: { error?: string } } })?.response
+  return res?.status === 403 && res.data?.error === "access_denied"
+}
+
+function AppAvatar({ name, iconUrl }: { name: string; iconUrl?: string }) {
+  const letter = (name.slice(0, 1) || "?").toUpperCase()
+  return (
+    <Avatar className="size-14 rounded-xl">
+      {iconUrl && <AvatarImage src={iconUrl} alt={name} />}
+      <AvatarFallback className="rounded-xl bg-gradient-to-br from-gr-pink to-gr-purple text-xl font-semibold text-white">
+        {letter}
+      </AvatarFallback>
+    </Avatar>
+  )
+}
+
+function Centered({ children }: { children: ReactNode }) {
+  return (
+    <main className="flex min-h-svh items-center justify-center px-4 py-12">
+      <div className="w-full max-w-sm space-y-2 text-center">{children}</div>
+    </main>
+  )
+}
+
+// DevicePage is the verification_uri for the device flow: the user types
+// (or follows a link with) the code shown on the device, reviews what the
+// app is asking for, and approves or denies. The device picks up the answer
+// on its next poll — nothing redirects back.
+export default function DevicePage() {
+  const [params, setParams] = useSearchParams()
+  const location = useLocation()
+  const session = loadSession()
+
+  const userCode = params.get("user_code") ?? ""
+  const [input, setInput] = useState(userCode)
+  const [busy, setBusy] = useState<Action | null>(null)
+  const [result, setResult] = useState<Action | null>(null)
+  const [completeError, setCompleteError] = useState<string | null>(null)
+
+  const validate = useQuery({
+    queryKey: ["oauth-device", userCode, session?.entityId],
+    queryFn: async () => {
+      const search = new URLSearchParams({
+        user_code: userCode,
+        entity_id: session?.entityId ?? "",
+      })
+      const res = await api.get<ValidateResponse>(`/oauth/device?${search.toString()}`)
+      return res.data
+    },
+    enabled: !!session && !!userCode,
+    retry: false,
+  })
+
+  const scopes = useMemo(
+    () => resolveScopes(validate.data?.scope ?? "", validate.data?.scopes),
+    [validate.data?.scope, validate.data?.scopes],
+  )
+
+  if (!session) {
+    saveLoginReturnFrom(location)
+    return <Navigate to="/auth/login" state={{ from: location }} replace />
+  }
+
+  function submitCode(e: FormEvent) {
+    e.preventDefault()
+    const code = input.trim()
+    if (!code) return
+    setCompleteError(null)
+    setParams({ user_code: code }, { replace: true })
+  }
+
+  async function complete(action: Action) {
+    if (busy) return
+    setBusy(action)
+    setCompleteError(null)
+    try {
+      await api.post("/oauth/device", {
+        user_code: userCode,
+        entity_id: session?.entityId,
+        approve: action === "approve",
+      })
+      setResult(action)
+    } catch (err) {
+      setCompleteError(
+        isAccessDenied(err)
+          ? "You don't have access to this application."
+          : errorMessage(err) ?? "Something went wrong. Try again.",
+      )
+    } finally {
+      setBusy(null)
+    }
+  }
+
+  if (result === "approve") {
+    return (
+      <Centered>
+        <h1 className="text-xl font-semibold tracking-tight">Device connected</h1>
+        <p className="text-sm text-muted-foreground">
+          {validate.data?.app_name ?? "The app"} is signing in. You can return to your device.
+        </p>
+      </Centered>
+    )
+  }
+
+  if (result === "deny") {
+    return (
+      <Centered>
+        <h1 className="text-xl font-semibold tracking-tight">Request denied</h1>
+        <p className="text-sm text-muted-foreground">
+          The device won't get access to your account. You can close this page.
+        </p>
+      </Centered>
+    )
+  }
+
+  if (!userCode || validate.isError) {
+    const denied = validate.isError && isAccessDenied(validate.error)
+    return (
+      <main className="flex min-h-svh items-center justify-center px-4 py-12">
+        <form onSubmit={submitCode} className="w-full max-w-sm space-y-6">
+          <div className="space-y-2 text-center">
+            <h1 className="text-xl font-semibold tracking-tight">Connect a device</h1>
+            <p className="text-sm text-muted-foreground">
+              Enter the code shown on your device.
+            </p>
+          </div>
+          <Input
+            autoFocus
+            value={input}
+            onChange={(e) => setInput(e.target.value.toUpperCase())}
+            placeholder="XXXX-XXXX"
+            autoComplete="off"
+            spellCheck={false}
+            className="h-12 text-center font-mono text-lg tracking-[0.3em]"
+          />
+          {validate.isError && (
+            <p className="text-center text-sm text-destructive">
+              {denied
+                ? "You don't have access to this application."
+                : errorMessage(validate.error) ?? "That code couldn't be checked."}
+            </p>
+          )}
+          <OutlineButton type="submit" className="w-full" disabled={!input.trim()}>
+            Continue
+          </OutlineButton>
+        </form>
+      </main>
+    )
+  }
+
+  if (validate.isLoading || !validate.data) {
+    return (
+      <main className="flex min-h-svh items-center justify-center px-4 py-12">
+        <Loader2 className="size-6 animate-spin text-muted-foreground" />
+      </main>
+    )
+  }
+
+  const app = validate.data
+
+  return (
+    <main className="flex min-h-svh items-center justify-center px-4 py-12">
+      <div className="w-full max-w-md space-y-8">
+        <div className="flex flex-col items-center gap-3 text-center">
+          <AppAvatar name={app.app_name} iconUrl={app.app_icon_url} />
+          <div>
+            <h1 className="text-xl font-semibold tracking-tight">{app.app_name}</h1>
+            <p className="mt-1 text-sm text-muted-foreground">
+              wants to access your Sentinel account from another device
+            </p>
+          </div>
+        </div>
+
+        <div className="space-y-2 text-center">
+          <p className="text-xs uppercase tracking-wider text-muted-foreground">Device code</p>
+          <p className="font-mono text-lg tracking-[0.3em]">{app.user_code}</p>
+          <p className="text-xs text-muted-foreground">
+            Only continue if this matches the code on your device.
+          </p>
+        </div>
+
+        <div className="space-y-3">
+          <p className="text-xs uppercase tracking-wider text-muted-foreground">
+            This will let {app.app_name}:
+          </p>
+          <ul className="space-y-3">
+            {scopes.map((scope) => (
+              <li key={scope.key} className="flex items-start gap-3">
+                <div className="mt-0.5 flex size-7 shrink-0 items-center justify-center rounded-md bg-muted/60 text-muted-foreground">
+                  <scope.icon className="size-3.5" />
+                </div>
+                <div className="min-w-0 flex-1">
+                  <p className="text-sm leading-tight">{scope.label}</p>
+                  <p className="mt-1 text-xs text-muted-foreground">{scope.description}</p>
+                  {!scope.known && (
+                    <p className="mt-1 font-mono text-[11px] text-muted-foreground">{scope.key}</p>
+                  )}
+                </div>
+              </li>
+            ))}
+          </ul>
+          <ConsentPolicyNotes appName={app.app_name} policy={app} />
+        </div>
+
+        <div className="space-y-3">
+          {completeError && (
+            <p className="text-center text-sm text-destructive">{completeError}</p>
+          )}
+          <div className="flex gap-2">
+            <Button
+              type="button"
+              variant="outline"
+              className="h-10 flex-1 rounded-xl"
+              disabled={busy !== null}
+              onClick={() => complete("deny")}
+            >
+              {busy === "deny" ? <Loader2 className="size-4 animate-spin" /> : "Deny"}
+            </Button>
+            <OutlineButton
+              type="button"
+              className="flex-1"
+              loading={busy === "approve"}
+              disabled={busy !== null}
+              onClick={() => complete("approve")}
+            >
+              Authorize
+            </OutlineButton>
+          </div>
+        </div>
+      </div>
+    </main>
+  )
+}
@@ -0,0 +1,122 @@
+import { useState } from "react"
+import { toast } from "sonner"
+
+import { DiscordIcon, GithubIcon, GoogleIcon } from "@/components/icons/socials"
+import { Button } from "@/components/ui/button"
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card"
+import { api } from "@/lib/api"
+import { useAuth } from "@/lib/auth"
+import { externalProviderConfigured, startExternalAuth, type ExternalProvider } from "@/lib/externalAuth"
+
+function errorMessage(err: unknown, fallback: string) {
+  return (
+    (err as { response?: { data?: { error?: string } } })?.response?.data?.error ?? fallback
+  )
+}
+
+// Which account handle to show for a linked provider, from its metadata.
+function accountName(metadata: Record<string, unknown> | null, ...keys: string[]) {
+  for (const key of keys) {
+    const value = metadata?.[key]
+    if (typeof value === "string" && value) return value
+  }
+  return null
+}
+
+const LINKABLE: Array<{
+  provider: ExternalProvider
+  label: string
+  Icon: typeof GithubIcon
+  nameKeys: string[]
+}> = [
+  { provider: "github", label: "GitHub", Icon: GithubIcon, nameKeys: ["login", "email"] },
+  { provider: "google", label: "Google", Icon: GoogleIcon, nameKeys: ["email", "name"] },
+]
+
+// Linked sign-in accounts. Discord comes from onboarding and is shown
+// read-only; GitHub and Google can be linked and unlinked here, and once
+// linked they work on the login page.
+export function ConnectedAccountsCard() {
+  const { user, refresh } = useAuth()
+  const [busy, setBusy] = useState(false)
+  const auths = user?.external_auths ?? []
+  const discord = auths.find((a) => a.provider === "DISCORD")
+
+  function link(provider: ExternalProvider) {
+    try {
+      startExternalAuth(provider, "connect")
+    } catch (err: unknown) {
+      toast.error((err as Error).message)
+    }
+  }
+
+  async function unlink(provider: ExternalProvider, label: string) {
+    if (busy) return
+    if (!window.confirm(`Unlink your ${label} account? You won't be able to sign in with it anymore.`)) return
+    setBusy(true)
+    try {
+      await api.delete(`/entities/@me/external-auths/${provider}`)
+      toast.success(`${label} account unlinked.`)
+      await refresh()
+    } catch (err: unknown) {
+      toast.error(errorMessage(err, `Couldn't unlink your ${label} account.`))
+    } finally {
+      setBusy(false)
+    }
+  }
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>Connected accounts</CardTitle>
+        <CardDescription>
+          Accounts you can sign in with. Link a second one so you can still get in if you lose
+          access to Discord.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="text-sm">
+        <ul className="divide-y divide-border/60 rounded-md border border-border/60">
+          <li className="flex items-center gap-3 px-3 py-2">
+            <DiscordIcon className="size-4 text-discord-blurple" />
+            <div className="min-w-0 flex-1">
+              <p className="font-medium">Discord</p>
+              <p className="text-xs text-muted-foreground">
+                {discord
+                  ? (accountName(discord.metadata, "username", "email") ?? "Linked")
+                  : "Not linked"}
+              </p>
+            </div>
+          </li>
+          {LINKABLE.map(({ provider, label, Icon, nameKeys }) => {
+            const auth = auths.find((a) => a.provider === provider.toUpperCase())
+            return (
+              <li key={provider} className="flex items-center gap-3 px-3 py-2">
+                <Icon className="size-4" />
+                <div className="min-w-0 flex-1">
+                  <p className="font-medium">{label}</p>
+                  <p className="text-xs text-muted-foreground">
+                    {auth ? (accountName(auth.metadata, ...nameKeys) ?? "Linked") : "Not linked"}
+                  </p>
+                </div>
+                {auth ? (
+                  <Button size="sm" variant="ghost" onClick={() => unlink(provider, label)} disabled={busy}>
+                    Unlink
+                  </Button>
+                ) : (
+                  <Button
+                    size="sm"
+                    variant="outline"
+                    onClick={() => link(provider)}
+                    disabled={busy || !externalProviderConfigured(provider)}
+                  >
+                    Link
+                  </Button>
+                )}
+              </li>
+            )
+          })}
+        </ul>
+      </CardContent>
+    </Card>
+  )
+}
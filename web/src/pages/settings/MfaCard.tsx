@@ -0,0 +1,160 @@
+import { useQueryClient } from "@tanstack/react-query"
+import { useState } from "react"
+import { toast } from "sonner"
+
+import { Badge } from "@/components/ui/badge"
+import { Button } from "@/components/ui/button"
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card"
+import { Input } from "@/components/ui/input"
+import { api } from "@/lib/api"
+import { type TotpEnrollment, useMyMfa } from "@/lib/mfa"
+
+function errorMessage(err: unknown, fallback: string) {
+  return (
+    (err as { response?: { data?: { error?: string } } })?.response?.data?.error ?? fallback
+  )
+}
+
+// Two-factor authentication settings: enroll a TOTP authenticator, rotate
+// recovery codes, or turn MFA off (unless a group policy requires it).
+export function MfaCard() {
+  const queryClient = useQueryClient()
+  const { data: status, isLoading } = useMyMfa()
+  const [enrollment, setEnrollment] = useState<TotpEnrollment | null>(null)
+  const [recoveryCodes, setRecoveryCodes] = useState<string[] | null>(null)
+  const [code, setCode] = useState("")
+  const [busy, setBusy] = useState(false)
+
+  const refresh = () => queryClient.invalidateQueries({ queryKey: ["me", "mfa"] })
+
+  async function run(fn: () => Promise<void>, fallback: string) {
+    if (busy) return
+    setBusy(true)
+    try {
+      await fn()
+    } catch (err: unknown) {
+      toast.error(errorMessage(err, fallback))
+    } finally {
+      setBusy(false)
+      setCode("")
+    }
+  }
+
+  const startEnrollment = () =>
+    run(async () => {
+      const res = await api.post<TotpEnrollment>("/entities/@me/mfa/totp")
+      setEnrollment(res.data)
+    }, "Couldn't start setup.")
+
+  const confirmEnrollment = () =>
+    run(async () => {
+      const res = await api.post<{ recovery_codes: string[] }>("/entities/@me/mfa/totp/confirm", { code })
+      setEnrollment(null)
+      setRecoveryCodes(res.data.recovery_codes)
+      toast.success("Two-factor authentication is on.")
+      await refresh()
+    }, "That code didn't work.")
+
+  const regenerate = () =>
+    run(async () => {
+      const res = await api.post<{ recovery_codes: string[] }>("/entities/@me/mfa/recovery-codes", { code })
+      setRecoveryCodes(res.data.recovery_codes)
+      await refresh()
+    }, "That code didn't work.")
+
+  const disable = () =>
+    run(async () => {
+      await api.post("/entities/@me/mfa/disable", { code })
+      setRecoveryCodes(null)
+      toast.success("Two-factor authentication is off.")
+      await refresh()
+    }, "Couldn't turn off two-factor authentication.")
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex items-center gap-2">
+          Two-factor authentication
+          {status?.enabled && <Badge variant="secondary">On</Badge>}
+          {status?.required_by_policy && <Badge variant="outline">Required</Badge>}
+        </CardTitle>
+        <CardDescription>
+          Require a code from an authenticator app in addition to your password or sign-in link.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4 text-sm">
+        {isLoading || !status ? (
+          <p className="text-muted-foreground">Loading…</p>
+        ) : recoveryCodes ? (
+          <div className="space-y-3">
+            <p className="text-muted-foreground">
+              Save these recovery codes somewhere safe. Each works once; they won't be shown again.
+            </p>
+            <pre className="grid grid-cols-2 gap-1 rounded-md border border-border/60 bg-muted/30 p-3 font-mono text-xs">
+              {recoveryCodes.map((c) => (
+                <span key={c}>{c}</span>
+              ))}
+            </pre>
+            <Button variant="outline" onClick={() => setRecoveryCodes(null)}>
+              Done
+            </Button>
+          </div>
+        ) : enrollment ? (
+          <div className="space-y-3">
+            <p>
+              <a href={enrollment.otpauth_uri} className="underline underline-offset-2">
+                Open in authenticator
+              </a>{" "}
+              or enter this key manually:
+            </p>
+            <code className="block break-all rounded bg-muted/30 px-2 py-1 font-mono text-xs">
+              {enrollment.secret}
+            </code>
+            <div className="flex gap-2">
+              <Input
+                autoComplete="one-time-code"
+                placeholder="6-digit code"
+                value={code}
+                onChange={(e) => setCode(e.target.value)}
+                disabled={busy}
+              />
+              <Button onClick={confirmEnrollment} disabled={busy || !code}>
+                Confirm
+              </Button>
+            </div>
+          </div>
+        ) : status.enabled ? (
+          <div className="space-y-3">
+            <p className="text-muted-foreground">
+              {status.recovery_codes_remaining} recovery code
+              {status.recovery_codes_remaining === 1 ? "" : "s"} remaining. Enter a current code to
+              manage two-factor authentication.
+            </p>
+            <div className="flex flex-wrap gap-2">
+              <Input
+                className="max-w-48"
+                autoComplete="one-time-code"
+                placeholder="Code"
+                value={code}
+                onChange={(e) => setCode(e.target.value)}
+                disabled={busy}
+              />
+              <Button variant="outline" onClick={regenerate} disabled={busy || !code}>
+                New recovery codes
+              </Button>
+              {(!status.required_by_policy || status.passkeys > 0) && (
+                <Button variant="destructive" onClick={disable} disabled={busy || !code}>
+                  Turn off
+                </Button>
+              )}
+            </div>
+          </div>
+        ) : (
+          <Button onClick={startEnrollment} disabled={busy}>
+            Set up authenticator
+          </Button>
+        )}
+      </CardContent>
+    </Card>
+  )
+}
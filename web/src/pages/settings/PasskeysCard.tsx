@@ -0,0 +1,155 @@
+import { useQueryClient } from "@tanstack/react-query"
+import { useState } from "react"
+import { toast } from "sonner"
+
+import { Badge } from "@/components/ui/badge"
+import { Button } from "@/components/ui/button"
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card"
+import { Input } from "@/components/ui/input"
+import { api } from "@/lib/api"
+import { type Passkey, passkeysSupported, registerPasskey, useMyPasskeys } from "@/lib/passkeys"
+
+function errorMessage(err: unknown, fallback: string) {
+  return (
+    (err as { response?: { data?: { error?: string } } })?.response?.data?.error ?? fallback
+  )
+}
+
+function formatDate(value: string | null) {
+  return value ? new Date(value).toLocaleDateString() : "never"
+}
+
+// Passkey settings: register a new passkey on this device, rename or remove
+// existing ones. Passkeys sign in on their own and also count as a second
+// factor for password, code, and Discord logins.
+export function PasskeysCard() {
+  const queryClient = useQueryClient()
+  const { data: passkeys, isLoading } = useMyPasskeys()
+  const [name, setName] = useState("")
+  const [editing, setEditing] = useState<{ id: string; name: string } | null>(null)
+  const [busy, setBusy] = useState(false)
+
+  const refresh = () =>
+    Promise.all([
+      queryClient.invalidateQueries({ queryKey: ["me", "passkeys"] }),
+      queryClient.invalidateQueries({ queryKey: ["me", "mfa"] }),
+    ])
+
+  async function run(fn: () => Promise<void>, fallback: string) {
+    if (busy) return
+    setBusy(true)
+    try {
+      await fn()
+    } catch (err: unknown) {
+      toast.error(errorMessage(err, fallback))
+    } finally {
+      setBusy(false)
+    }
+  }
+
+  const add = () =>
+    run(async () => {
+      await registerPasskey(name)
+      setName("")
+      toast.success("Passkey added.")
+      await refresh()
+    }, "Couldn't add the passkey.")
+
+  const rename = () =>
+    run(async () => {
+      if (!editing) return
+      await api.patch(`/entities/@me/passkeys/${editing.id}`, { name: editing.name })
+      setEditing(null)
+      await refresh()
+    }, "Couldn't rename the passkey.")
+
+  const remove = (passkey: Passkey) =>
+    run(async () => {
+      if (!window.confirm(`Remove "${passkey.name}"? You won't be able to sign in with it anymore.`)) return
+      await api.delete(`/entities/@me/passkeys/${passkey.id}`)
+      toast.success("Passkey removed.")
+      await refresh()
+    }, "Couldn't remove the passkey.")
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>Passkeys</CardTitle>
+        <CardDescription>
+          Sign in with your fingerprint, face, or device PIN instead of a password. A passkey also
+          works as your second factor.
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="space-y-4 text-sm">
+        {isLoading ? (
+          <p className="text-muted-foreground">Loading…</p>
+        ) : passkeys && passkeys.length > 0 ? (
+          <ul className="divide-y divide-border/60 rounded-md border border-border/60">
+            {passkeys.map((passkey) => (
+              <li key={passkey.id} className="flex flex-wrap items-center gap-2 px-3 py-2">
+                {editing?.id === passkey.id ? (
+                  <>
+                    <Input
+                      className="max-w-64"
+                      value={editing.name}
+                      onChange={(e) => setEditing({ id: passkey.id, name: e.target.value })}
+                      disabled={busy}
+                      autoFocus
+                    />
+                    <Button size="sm" onClick={rename} disabled={busy || !editing.name.trim()}>
+                      Save
+                    </Button>
+                    <Button size="sm" variant="ghost" onClick={() => setEditing(null)} disabled={busy}>
+                      Cancel
+                    </Button>
+                  </>
+                ) : (
+                  <>
+                    <div className="min-w-0 flex-1">
+                      <p className="flex items-center gap-2 font-medium">
+                        {passkey.name}
+                        {passkey.backup_eligible && <Badge variant="secondary">Synced</Badge>}
+                      </p>
+                      <p className="text-xs text-muted-foreground">
+                        Added {formatDate(passkey.created_at)} · Last used {formatDate(passkey.last_used_at)}
+                      </p>
+                    </div>
+                    <Button
+                      size="sm"
+                      variant="ghost"
+                      onClick={() => setEditing({ id: passkey.id, name: passkey.name })}
+                      disabled={busy}
+                    >
+                      Rename
+                    </Button>
+                    <Button size="sm" variant="destructive" onClick={() => remove(passkey)} disabled={busy}>
+                      Remove
+                    </Button>
+                  </>
+                )}
+              </li>
+            ))}
+          </ul>
+        ) : (
+          <p className="text-muted-foreground">You haven't added any passkeys yet.</p>
+        )}
+        {passkeysSupported() ? (
+          <div className="flex flex-wrap gap-2">
+            <Input
+              className="max-w-64"
+              placeholder="Name (e.g. MacBook)"
+              value={name}
+              onChange={(e) => setName(e.target.value)}
+              disabled={busy}
+            />
+            <Button onClick={add} disabled={busy}>
+              Add a passkey
+            </Button>
+          </div>
+        ) : (
+          <p className="text-muted-foreground">This browser doesn't support passkeys.</p>
+        )}
+      </CardContent>
+    </Card>
+  )
+}
@@ -1,6 +1,10 @@
 import { PageContainer, PageHeader } from "@/components/PageContainer"
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card"
 
+import { ConnectedAccountsCard } from "./ConnectedAccountsCard"
+import { MfaCard } from "./MfaCard"
+import { PasskeysCard } from "./PasskeysCard"
+
 export default function SettingsPage() {
   return (
     <PageContainer>
@@ -8,10 +12,13 @@ export default function SettingsPage() {
         title="Settings"
         description="Profile, authentication methods, linked accounts, and active sessions."
       />
+      <MfaCard />
+      <PasskeysCard />
+      <ConnectedAccountsCard />
       <Card>
         <CardHeader>
           <CardTitle>Coming soon</CardTitle>
-          <CardDescription>Profile editor and security settings will live here.</CardDescription>
+          <CardDescription>Profile editor and the rest of the security settings will live here.</CardDescription>
         </CardHeader>
         <CardContent className="text-sm text-muted-foreground">
           Placeholder page during design phase.
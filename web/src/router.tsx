@@ -8,7 +8,9 @@ import ApplicationEditPage from "@/pages/applications/ApplicationEditPage"
 import ApplicationNewPage from "@/pages/applications/ApplicationNewPage"
 import ApplicationsPage from "@/pages/applications/ApplicationsPage"
 import LoginDiscordPage from "@/pages/auth/LoginDiscordPage"
+import LoginExternalPage from "@/pages/auth/LoginExternalPage"
 import LoginPage from "@/pages/auth/LoginPage"
+import ResetPasswordPage from "@/pages/auth/ResetPasswordPage"
 import DebugPage from "@/pages/debug/DebugPage"
 import GroupDetailsPage from "@/pages/groups/GroupDetailsPage"
 import GroupEditPage from "@/pages/groups/GroupEditPage"
@@ -19,6 +21,7 @@ import GroupsPage from "@/pages/groups/GroupsPage"
 import HomePage from "@/pages/HomePage"
 import NotFoundPage from "@/pages/NotFoundPage"
 import AuthorizePage from "@/pages/oauth/AuthorizePage"
+import DevicePage from "@/pages/oauth/DevicePage"
 import OnboardingPage from "@/pages/onboarding/OnboardingPage"
 import SamlAuthorizePage from "@/pages/saml/SamlAuthorizePage"
 import SettingsPage from "@/pages/settings/SettingsPage"
@@ -50,7 +53,11 @@ export const router = createBrowserRouter([
   },
   { path: "/auth/login", element: <LoginPage /> },
   { path: "/auth/login/discord", element: <LoginDiscordPage /> },
+  { path: "/auth/login/github", element: <LoginExternalPage key="github" provider="github" /> },
+  { path: "/auth/login/google", element: <LoginExternalPage key="google" provider="google" /> },
+  { path: "/auth/reset-password", element: <ResetPasswordPage /> },
   { path: "/oauth/authorize", element: <AuthorizePage /> },
+  { path: "/oauth/device", element: <DevicePage /> },
   { path: "/saml/authorize", element: <SamlAuthorizePage /> },
   { path: "/onboard", element: <OnboardingPage /> },
   { path: "*", element: <NotFoundPage /> },